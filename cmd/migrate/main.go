@@ -1,21 +1,31 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"runtime"
+	"strings"
 
 	"reimbursement-audit/internal/config"
+	"reimbursement-audit/internal/domain/rag"
 	"reimbursement-audit/internal/infra/storage/mysql"
 	mysqlmigration "reimbursement-audit/internal/infra/storage/mysql/migration"
 	"reimbursement-audit/internal/pkg/logger"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
 var (
 	configFile = flag.String("config", "config.yaml", "配置文件路径")
 	action     = flag.String("action", "up", "迁移操作 (up/down/status/version)")
+	steps      = flag.Int("steps", 1, "回滚的迁移版本数，仅用于down")
+	dir        = flag.String("dir", mysqlmigration.DefaultMigrationsDir, "版本化迁移文件目录")
+	yes        = flag.Bool("yes", false, "回滚前不要求确认")
 	version    = flag.Bool("version", false, "显示版本信息")
 	help       = flag.Bool("help", false, "显示帮助信息")
 	buildTime  = "unknown" // 构建时间，由编译时设置
@@ -50,7 +60,20 @@ func main() {
 	// 创建MySQL客户端
 	dbConfig := mysql.DefaultConfig()
 	if cfg != nil {
-		// TODO: 从配置中设置数据库参数
+		dbConfig = dbConfig.Merge(&mysql.Config{
+			Host:            cfg.Database.Host,
+			Port:            cfg.Database.Port,
+			Username:        cfg.Database.Username,
+			Password:        cfg.Database.Password,
+			DBName:          cfg.Database.DBName,
+			Charset:         cfg.Database.Charset,
+			Collation:       cfg.Database.Collation,
+			Loc:             cfg.Database.Loc,
+			MaxOpenConns:    cfg.Database.MaxOpenConns,
+			MaxIdleConns:    cfg.Database.MaxIdleConns,
+			ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+			ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		})
 	}
 
 	// 创建日志记录器
@@ -67,6 +90,9 @@ func main() {
 
 	// 创建迁移管理器
 	mgr := mysqlmigration.NewMigrationManager(client)
+	if err := mgr.LoadMigrations(*dir); err != nil {
+		log.Fatalf("加载迁移文件失败: %v", err)
+	}
 
 	// 执行迁移操作
 	switch *action {
@@ -74,9 +100,30 @@ func main() {
 		if err := mgr.Up(context.Background()); err != nil {
 			log.Fatalf("执行迁移失败: %v", err)
 		}
+		if cfg != nil && cfg.RAG.VectorDSN != "" {
+			if err := migrateVectorSchema(context.Background(), &cfg.RAG); err != nil {
+				log.Fatalf("迁移向量库失败: %v", err)
+			}
+		}
 		log.Println("迁移执行成功")
 	case "down":
-		if err := mgr.Down(context.Background()); err != nil {
+		plan, err := mgr.PlanDown(context.Background(), *steps)
+		if err != nil {
+			log.Fatalf("回滚迁移失败: %v", err)
+		}
+		if len(plan) == 0 {
+			log.Println("没有可回滚的迁移版本")
+			return
+		}
+		fmt.Println("将要回滚的迁移版本:")
+		for _, migration := range plan {
+			fmt.Printf("  %s - %s\n", migration.Version, migration.Description)
+		}
+		if !*yes && !confirm(fmt.Sprintf("确认回滚以上%d个迁移版本? [y/N]: ", len(plan))) {
+			log.Println("已取消回滚")
+			return
+		}
+		if err := mgr.Down(context.Background(), *steps); err != nil {
 			log.Fatalf("回滚迁移失败: %v", err)
 		}
 		log.Println("迁移回滚成功")
@@ -85,7 +132,7 @@ func main() {
 		if err != nil {
 			log.Fatalf("获取迁移状态失败: %v", err)
 		}
-		fmt.Printf("迁移状态: %v\n", status)
+		printStatus(status)
 	case "version":
 		version, err := mgr.Version(context.Background())
 		if err != nil {
@@ -97,6 +144,66 @@ func main() {
 	}
 }
 
+// migrateVectorSchema 迁移pgvector向量库：创建vector扩展、向量表和向量索引
+func migrateVectorSchema(ctx context.Context, ragConfig *config.RAGConfig) error {
+	db, err := gorm.Open(postgres.Open(ragConfig.VectorDSN), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		return fmt.Errorf("连接向量库失败: %w", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	return rag.MigrateVectorSchema(ctx, db, rag.VectorSchemaOptions{
+		IndexName:      ragConfig.VectorIndexName,
+		IndexMethod:    ragConfig.VectorIndexMethod,
+		Lists:          ragConfig.VectorIndexLists,
+		M:              ragConfig.VectorIndexM,
+		EfConstruction: ragConfig.VectorIndexEfConstruction,
+	})
+}
+
+// confirm 在终端提示确认，输入y或yes时返回true
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printStatus 输出数据库连接状态和各迁移版本的应用情况
+func printStatus(status *mysqlmigration.StatusReport) {
+	fmt.Printf("数据库状态: %s\n", status.Status)
+	if status.Error != "" {
+		fmt.Printf("连接错误: %s\n", status.Error)
+		return
+	}
+	fmt.Printf("数据表数量: %d\n", len(status.Tables))
+
+	if len(status.Migrations) == 0 {
+		fmt.Println("没有版本化迁移")
+		return
+	}
+	fmt.Println("迁移版本:")
+	for _, migration := range status.Migrations {
+		state := "未应用"
+		appliedAt := "-"
+		if migration.Applied {
+			state = "已应用"
+			appliedAt = migration.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		switch {
+		case migration.Tampered:
+			state += "(文件已被修改)"
+		case migration.Missing:
+			state += "(迁移文件不存在)"
+		}
+		fmt.Printf("  %-20s %-30s %-24s %s\n", migration.Version, migration.Description, state, appliedAt)
+	}
+}
+
 // showHelp 显示帮助信息
 func showHelp() {
 	fmt.Printf(`%s - %s
@@ -109,14 +216,24 @@ func showHelp() {
         配置文件路径 (默认: "config.yaml")
   -action string
         迁移操作 (up/down/status/version) (默认: "up")
+  -steps int
+        回滚的迁移版本数，仅用于down (默认: 1)
+  -dir string
+        版本化迁移文件目录，文件名格式为<版本>_<描述>.up.sql/.down.sql (默认: "sql/migrations")
+  -yes
+        回滚前不要求确认
   -version
         显示版本信息
   -help
         显示帮助信息
 
+说明:
+  配置rag.vector_dsn后，up会同时迁移pgvector向量库：创建vector扩展、向量表和向量索引
+  (索引类型和参数见rag.vector_index_method/vector_index_lists/vector_index_m/vector_index_ef_construction)
+
 示例:
   %s -action up -config config.yaml
-  %s -action down -config config.yaml
+  %s -action down -steps 2 -config config.yaml
   %s -action status -config config.yaml
   %s -action version -config config.yaml
 `, AppName, AppDesc, AppName, AppName, AppName, AppName, AppName)
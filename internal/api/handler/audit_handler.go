@@ -6,15 +6,32 @@
 // 4. 整合审核结果并生成审核报告
 // 5. 返回审核状态和结果
 // 6. 处理审核过程中的异常情况
+// 7. 查询超出SLA的审核记录
+// 8. 查询报销单的审核历史（含重试记录）
+// 9. 按状态、风险等级、日期范围分页查询审核列表
+// 10. 审核记录或报销单不存在时返回404
+// 11. 查询审核时采用的限额标准
+// 12. 生成和校验审核结论的签名证明
+// 13. 审核前预览报销单将执行的规则
+// 14. 人工改判审核结论，保留原审核结论
+// 15. 规则变更后批量重审受影响的报销单，查询重审批次进度
+// 16. 下载PDF/Excel格式的审核报告
 
 package handler
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
 	"reimbursement-audit/internal/api/middleware"
 	"reimbursement-audit/internal/api/request"
 	"reimbursement-audit/internal/api/response"
 	"reimbursement-audit/internal/application/service"
+	"reimbursement-audit/internal/domain/audit"
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/pkg/errs"
 
 	"github.com/gin-gonic/gin"
 )
@@ -54,6 +71,14 @@ func (h *AuditHandler) StartAudit(c *gin.Context) {
 	auditResponse, err := h.auditService.StartAudit(ctx, &req)
 	if err != nil {
 		middleware.LogError(c, "开始审核失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, audit.ErrAuditInProgress) {
+			response.ErrorResponse(c, response.CodeAuditFailed, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
@@ -79,6 +104,10 @@ func (h *AuditHandler) GetAuditStatus(c *gin.Context) {
 	statusResponse, err := h.auditService.GetAuditStatus(ctx, auditID)
 	if err != nil {
 		middleware.LogError(c, "获取审核状态失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
@@ -104,6 +133,10 @@ func (h *AuditHandler) GetAuditResult(c *gin.Context) {
 	resultResponse, err := h.auditService.GetAuditResult(ctx, auditID)
 	if err != nil {
 		middleware.LogError(c, "获取审核结果失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
@@ -112,6 +145,64 @@ func (h *AuditHandler) GetAuditResult(c *gin.Context) {
 	response.SuccessResponse(c, resultResponse)
 }
 
+// GetAuditStandards 获取审核时采用的限额标准（类别/城市级别/职级/生效日期）
+func (h *AuditHandler) GetAuditStandards(c *gin.Context) {
+	middleware.LogInfo(c, "获取审核限额标准请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	auditID := c.Param("id")
+	if auditID == "" {
+		middleware.LogError(c, "缺少审核ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少审核ID")
+		return
+	}
+
+	standards, err := h.auditService.GetAppliedStandards(ctx, auditID)
+	if err != nil {
+		middleware.LogError(c, "获取审核限额标准失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "获取审核限额标准成功", "audit_id", auditID, "context", ctx)
+	response.SuccessResponse(c, standards)
+}
+
+// GetApplicableRules 预览报销单审核时将执行的规则，不执行规则
+func (h *AuditHandler) GetApplicableRules(c *gin.Context) {
+	middleware.LogInfo(c, "预览报销单适用规则请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	reimbursementID := c.Param("id")
+	if reimbursementID == "" {
+		middleware.LogError(c, "缺少报销单ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少报销单ID")
+		return
+	}
+
+	preview, err := h.auditService.GetApplicableRules(ctx, reimbursementID)
+	if err != nil {
+		middleware.LogError(c, "预览报销单适用规则失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "预览报销单适用规则成功", "reimbursement_id", reimbursementID, "rule_count", preview.RuleCount, "context", ctx)
+	response.SuccessResponse(c, preview)
+}
+
 // RetryAudit 重试审核
 func (h *AuditHandler) RetryAudit(c *gin.Context) {
 	middleware.LogInfo(c, "重试审核请求", "path", c.Request.URL.Path,
@@ -129,10 +220,333 @@ func (h *AuditHandler) RetryAudit(c *gin.Context) {
 	resultResponse, err := h.auditService.RetryAudit(ctx, auditID)
 	if err != nil {
 		middleware.LogError(c, "重试审核失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, audit.ErrRetryLimitExceeded) || errors.Is(err, audit.ErrAuditInProgress) {
+			response.ErrorResponse(c, response.CodeAuditFailed, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
 
 	middleware.LogInfo(c, "重试审核成功", "audit_id", auditID, "context", ctx)
 	response.SuccessResponse(c, resultResponse)
-}
\ No newline at end of file
+}
+
+// OverrideAudit 人工改判审核结论，改判结论成为报销单的最终结论
+// 需要reviewer或auditor_admin角色（由路由校验），改判人取自认证令牌
+func (h *AuditHandler) OverrideAudit(c *gin.Context) {
+	middleware.LogInfo(c, "人工改判审核结论请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	auditID := c.Param("id")
+	if auditID == "" {
+		middleware.LogError(c, "缺少审核ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少审核ID")
+		return
+	}
+
+	var req request.OverrideAuditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.LogError(c, "JSON数据绑定失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+	req.ActorID = middleware.GetUserID(c)
+	req.Actor = middleware.GetUserName(c)
+	if req.Actor == "" {
+		req.Actor = req.ActorID
+	}
+	req.ActorRoles = middleware.GetUserRoles(c)
+	if err := req.Validate(); err != nil {
+		middleware.LogError(c, "请求参数校验失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	resultResponse, err := h.auditService.OverrideAudit(ctx, auditID, &req)
+	if err != nil {
+		middleware.LogError(c, "人工改判审核结论失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, audit.ErrInvalidOverride) || errors.Is(err, audit.ErrAuditNotOverridable) ||
+			errors.Is(err, reimbursement.ErrInvalidStatusTransition) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "人工改判审核结论成功", "audit_id", auditID, "effective_pass", resultResponse.EffectivePass, "context", ctx)
+	response.SuccessResponse(c, resultResponse)
+}
+
+// GetAuditAttestation 生成审核结论的签名证明
+func (h *AuditHandler) GetAuditAttestation(c *gin.Context) {
+	middleware.LogInfo(c, "生成审核证明请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	auditID := c.Param("id")
+	if auditID == "" {
+		middleware.LogError(c, "缺少审核ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少审核ID")
+		return
+	}
+
+	attestation, err := h.auditService.GetAuditAttestation(ctx, auditID)
+	if err != nil {
+		middleware.LogError(c, "生成审核证明失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, audit.ErrAuditNotAttestable) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "生成审核证明成功", "audit_id", auditID, "context", ctx)
+	response.SuccessResponse(c, attestation)
+}
+
+// ExportAuditReport 下载审核报告文件
+// 查询参数：format 报告格式(pdf/xlsx)，默认pdf
+func (h *AuditHandler) ExportAuditReport(c *gin.Context) {
+	middleware.LogInfo(c, "导出审核报告请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	auditID := c.Param("id")
+	if auditID == "" {
+		middleware.LogError(c, "缺少审核ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少审核ID")
+		return
+	}
+	format := c.DefaultQuery("format", audit.ReportFormatPDF)
+
+	file, err := h.auditService.ExportAuditReport(ctx, auditID, format)
+	if err != nil {
+		middleware.LogError(c, "导出审核报告失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, audit.ErrUnsupportedReportFormat) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "导出审核报告成功", "audit_id", auditID, "format", format, "size", len(file.Data), "context", ctx)
+	c.Header("Content-Disposition", "attachment; filename=\""+file.FileName+"\"; filename*=UTF-8''"+url.PathEscape(file.FileName))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+// VerifyAuditAttestation 校验审核证明，返回证明是否有效及无效原因
+func (h *AuditHandler) VerifyAuditAttestation(c *gin.Context) {
+	middleware.LogInfo(c, "校验审核证明请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	var req request.VerifyAttestationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.LogError(c, "JSON数据绑定失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	verification, err := h.auditService.VerifyAuditAttestation(ctx, &req)
+	if err != nil {
+		middleware.LogError(c, "校验审核证明失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "校验审核证明完成", "valid", verification.Valid, "context", ctx)
+	response.SuccessResponse(c, verification)
+}
+
+// ListSLABreaches 查询超出SLA的审核记录
+// 查询参数：page 页码，size 每页大小
+func (h *AuditHandler) ListSLABreaches(c *gin.Context) {
+	middleware.LogInfo(c, "查询超出SLA的审核记录请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		response.ErrorResponse(c, response.CodeInvalidParams, "page参数必须为正整数")
+		return
+	}
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if err != nil || size <= 0 || size > 100 {
+		response.ErrorResponse(c, response.CodeInvalidParams, "size参数必须为1-100之间的整数")
+		return
+	}
+
+	listResponse, err := h.auditService.ListSLABreaches(ctx, page, size)
+	if err != nil {
+		middleware.LogError(c, "查询超出SLA的审核记录失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "查询超出SLA的审核记录成功", "total", listResponse.Total, "context", ctx)
+	response.SuccessResponse(c, listResponse)
+}
+
+// ListAuditHistory 查询报销单的审核历史（含重试记录）
+func (h *AuditHandler) ListAuditHistory(c *gin.Context) {
+	middleware.LogInfo(c, "查询审核历史请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	reimbursementID := c.Param("id")
+	if reimbursementID == "" {
+		middleware.LogError(c, "缺少报销单ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少报销单ID")
+		return
+	}
+
+	listResponse, err := h.auditService.ListAuditHistory(ctx, reimbursementID)
+	if err != nil {
+		middleware.LogError(c, "查询审核历史失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "查询审核历史成功", "reimbursement_id", reimbursementID, "total", listResponse.Total, "context", ctx)
+	response.SuccessResponse(c, listResponse)
+}
+
+// ListAudits 分页查询审核列表
+// 查询参数：status 审核状态，risk_level 风险等级，start_date/end_date 日期范围(YYYY-MM-DD)，page 页码，size 每页大小
+func (h *AuditHandler) ListAudits(c *gin.Context) {
+	middleware.LogInfo(c, "查询审核列表请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	var req request.ListAuditsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.LogError(c, "参数绑定失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "参数格式错误")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		middleware.LogError(c, "请求参数校验失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	pageResponse, err := h.auditService.ListAudits(ctx, &req)
+	if err != nil {
+		middleware.LogError(c, "查询审核列表失败", "error", err.Error(), "context", ctx)
+		if errors.Is(err, audit.ErrInvalidAuditFilter) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "查询审核列表成功", "total", pageResponse.Total, "context", ctx)
+	response.SuccessResponse(c, pageResponse)
+}
+// ReauditAffected 规则启用或修改后批量重审受影响的报销单，重审在后台执行，返回重审批次
+func (h *AuditHandler) ReauditAffected(c *gin.Context) {
+	middleware.LogInfo(c, "批量重审受规则影响报销单请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	ruleID := c.Param("id")
+	if ruleID == "" {
+		middleware.LogError(c, "缺少规则ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少规则ID")
+		return
+	}
+
+	// 请求体可省略，省略时使用默认回溯天数和单批数量
+	var req request.ReauditAffectedRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.LogError(c, "JSON数据绑定失败", "error", err.Error(), "context", ctx)
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+	}
+	if err := req.Validate(); err != nil {
+		middleware.LogError(c, "请求参数校验失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	batch, err := h.auditService.ReauditAffected(ctx, ruleID, &req)
+	if err != nil {
+		middleware.LogError(c, "批量重审失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		if errors.Is(err, audit.ErrReauditRuleDisabled) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "批量重审已开始", "rule_id", ruleID, "batch_id", batch.ID, "total", batch.Total, "context", ctx)
+	response.SuccessResponse(c, batch)
+}
+
+// GetReauditBatch 查询重审批次进度
+func (h *AuditHandler) GetReauditBatch(c *gin.Context) {
+	middleware.LogInfo(c, "查询重审批次请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	batchID := c.Param("batch_id")
+	if batchID == "" {
+		middleware.LogError(c, "缺少重审批次ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少重审批次ID")
+		return
+	}
+
+	batch, err := h.auditService.GetReauditBatch(ctx, batchID)
+	if err != nil {
+		middleware.LogError(c, "查询重审批次失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "查询重审批次成功", "batch_id", batchID, "status", batch.Status, "context", ctx)
+	response.SuccessResponse(c, batch)
+}
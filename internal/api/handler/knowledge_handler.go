@@ -0,0 +1,231 @@
+// knowledge_handler.go 处理知识库管理的控制器
+// 功能点：
+// 1. 分页查询已入库的文档分片
+// 2. 支持按文档ID过滤
+// 3. 支持按分片内容关键词过滤
+// 4. 返回分片内容及元数据供管理员复核
+// 5. 查询报销制度，支持markdown/plain/json输出格式
+// 6. 按游标分页导出分片向量，逐条写出响应，供数据分析使用
+// 7. 查询向量缓存命中率统计
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"reimbursement-audit/internal/api/middleware"
+	"reimbursement-audit/internal/api/request"
+	"reimbursement-audit/internal/api/response"
+	"reimbursement-audit/internal/domain/rag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// embeddingStream 分片向量导出响应写出器，第一条记录写出前不写响应头，便于出错时返回普通错误响应
+type embeddingStream struct {
+	c          *gin.Context
+	collection string
+	started    bool
+}
+
+// begin 写出响应头和响应体开头
+func (w *embeddingStream) begin() {
+	if w.started {
+		return
+	}
+	w.started = true
+	collection, _ := json.Marshal(w.collection)
+	traceID, _ := json.Marshal(middleware.GetTraceId(w.c))
+	w.c.Header("Content-Type", "application/json; charset=utf-8")
+	w.c.Status(200)
+	_, _ = w.c.Writer.WriteString(`{"code":` + strconv.Itoa(response.CodeSuccess) + `,"message":"成功","trace_id":` + string(traceID) +
+		`,"data":{"collection":` + string(collection) + `,"embeddings":[`)
+}
+
+// write 写出一条分片向量记录
+func (w *embeddingStream) write(record *rag.EmbeddingRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if w.started {
+		_, _ = w.c.Writer.WriteString(",")
+	}
+	w.begin()
+	_, err = w.c.Writer.Write(data)
+	return err
+}
+
+// end 写出下一页游标和响应体结尾
+func (w *embeddingStream) end(nextCursor string) {
+	w.begin()
+	cursor, _ := json.Marshal(nextCursor)
+	_, _ = w.c.Writer.WriteString(`],"next_cursor":` + string(cursor) + `}}`)
+}
+
+const (
+	defaultChunkPageSize = 20  // 分片列表默认每页大小
+	maxChunkPageSize     = 100 // 分片列表最大每页大小
+)
+
+// KnowledgeHandler 处理知识库管理请求的结构体
+type KnowledgeHandler struct {
+	ragService *rag.RAGService
+}
+
+// NewKnowledgeHandler 创建知识库管理处理器实例
+func NewKnowledgeHandler(ragService *rag.RAGService) *KnowledgeHandler {
+	return &KnowledgeHandler{
+		ragService: ragService,
+	}
+}
+
+// ListChunks 分页查询文档分片
+// 查询参数：documentId 文档ID，q 内容关键词，page 页码，size 每页大小
+func (h *KnowledgeHandler) ListChunks(c *gin.Context) {
+	middleware.LogInfo(c, "查询文档分片请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	filter := &rag.ChunkFilter{
+		DocumentID: c.Query("documentId"),
+		Keyword:    c.Query("q"),
+		Page:       1,
+		Size:       defaultChunkPageSize,
+	}
+
+	if page := c.Query("page"); page != "" {
+		p, err := strconv.Atoi(page)
+		if err != nil || p <= 0 {
+			response.ErrorResponse(c, response.CodeInvalidParams, "page参数必须为正整数")
+			return
+		}
+		filter.Page = p
+	}
+
+	if size := c.Query("size"); size != "" {
+		s, err := strconv.Atoi(size)
+		if err != nil || s <= 0 || s > maxChunkPageSize {
+			response.ErrorResponse(c, response.CodeInvalidParams, "size参数必须为1-100之间的整数")
+			return
+		}
+		filter.Size = s
+	}
+
+	chunks, total, err := h.ragService.ListChunks(ctx, filter)
+	if err != nil {
+		middleware.LogError(c, "查询文档分片失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "查询文档分片成功", "total", total, "count", len(chunks), "context", ctx)
+	response.SuccessResponse(c, gin.H{
+		"chunks": chunks,
+		"total":  total,
+		"page":   filter.Page,
+		"size":   filter.Size,
+	})
+}
+
+// Query 查询报销制度
+// 请求体：query 查询内容，top_k 检索分片数量，format 输出格式(markdown/plain/json)，language 查询语言(zh-CN/en)
+func (h *KnowledgeHandler) Query(c *gin.Context) {
+	middleware.LogInfo(c, "报销制度查询请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	var req request.PolicyQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.LogError(c, "JSON数据绑定失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	format, err := rag.ParseOutputFormat(req.Format)
+	if err != nil {
+		middleware.LogError(c, "输出格式无效", "format", req.Format, "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	if req.Language != "" && rag.NormalizeLanguage(req.Language) == "" {
+		middleware.LogError(c, "查询语言无效", "language", req.Language, "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "不支持的查询语言: "+req.Language)
+		return
+	}
+
+	result, err := h.ragService.QueryWithLanguage(ctx, req.Query, req.Language, req.TopK, format)
+	if err != nil {
+		middleware.LogError(c, "报销制度查询失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "报销制度查询成功", "format", format, "execution_time", result.ExecutionTime, "context", ctx)
+
+	data := gin.H{
+		"format": result.Format,
+		"output": result.Output,
+	}
+	if result.Answer != nil {
+		data["answer"] = result.Answer.Answer
+		data["citations"] = result.Answer.Citations
+	}
+	response.SuccessResponse(c, data)
+}
+
+// ListEmbeddings 按游标分页导出分片向量
+// 查询参数：collection 分片类别(为空时导出全部)，cursor 上一页返回的next_cursor，size 每页条数(1-1000，默认100)
+// 响应逐条写出，next_cursor为空表示已导出全部分片；data_scientist或auditor_admin角色由路由校验
+func (h *KnowledgeHandler) ListEmbeddings(c *gin.Context) {
+	middleware.LogInfo(c, "导出分片向量请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	filter := &rag.EmbeddingFilter{
+		Collection: c.Query("collection"),
+		After:      c.Query("cursor"),
+		Size:       rag.DefaultEmbeddingPageSize,
+	}
+	if size := c.Query("size"); size != "" {
+		s, err := strconv.Atoi(size)
+		if err != nil || s <= 0 || s > rag.MaxEmbeddingPageSize {
+			response.ErrorResponse(c, response.CodeInvalidParams, "size参数必须为1-1000之间的整数")
+			return
+		}
+		filter.Size = s
+	}
+
+	stream := &embeddingStream{c: c, collection: filter.Collection}
+	count := 0
+	nextCursor, err := h.ragService.StreamEmbeddings(ctx, filter, func(record *rag.EmbeddingRecord) error {
+		count++
+		return stream.write(record)
+	})
+	if err != nil {
+		middleware.LogError(c, "导出分片向量失败", "error", err.Error(), "count", count, "context", ctx)
+		if stream.started {
+			// 响应已开始写出，无法再返回错误响应，客户端将收到不完整的JSON
+			return
+		}
+		response.ErrorResponse(c, response.CodeVectorSearchError, err.Error())
+		return
+	}
+	stream.end(nextCursor)
+
+	middleware.LogInfo(c, "导出分片向量成功", "collection", filter.Collection, "count", count,
+		"next_cursor", nextCursor, "context", ctx)
+}
+
+// GetEmbeddingCacheStats 查询向量缓存命中统计
+func (h *KnowledgeHandler) GetEmbeddingCacheStats(c *gin.Context) {
+	stats := h.ragService.EmbeddingCacheStats()
+	middleware.LogInfo(c, "查询向量缓存统计", "enabled", stats.Enabled, "hits", stats.Hits, "misses", stats.Misses)
+	response.SuccessResponse(c, stats)
+}
@@ -6,13 +6,19 @@
 // 4. 支持分页查询
 // 5. 支持条件组合查询
 // 6. 返回结构化的审核报告数据
+// 7. 报销单列表查询，支持按用户、状态、部门、标签、申请日期范围和关键词组合筛选及排序
+// 8. 查询发票OCR识别进度
 
 package handler
 
 import (
+	"errors"
 	"net/http"
 
+	"reimbursement-audit/internal/api/request"
 	"reimbursement-audit/internal/application/service"
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/pkg/errs"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,6 +51,14 @@ func (h *QueryHandler) GetReimbursementByID(c *gin.Context) {
 	// 调用应用服务获取报销单详情
 	reimbursement, err := h.reimbursementService.GetReimbursementDetail(c.Request.Context(), id)
 	if err != nil {
+		if errs.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"message": err.Error(),
+				"data":    nil,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    http.StatusInternalServerError,
 			"message": "获取报销单详情失败: " + err.Error(),
@@ -61,6 +75,131 @@ func (h *QueryHandler) GetReimbursementByID(c *gin.Context) {
 	})
 }
 
+// GetInvoiceByID 根据发票ID查询发票详情
+// 返回结果包含OCR识别字段的位置框(field_boxes)，供前端在发票图片上叠加显示
+func (h *QueryHandler) GetInvoiceByID(c *gin.Context) {
+	// 获取路径参数
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"message": "发票ID不能为空",
+			"data":    nil,
+		})
+		return
+	}
+
+	// 调用应用服务获取发票详情
+	invoice, err := h.reimbursementService.GetInvoiceDetail(c.Request.Context(), id)
+	if err != nil {
+		if errs.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"message": err.Error(),
+				"data":    nil,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"message": "获取发票详情失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	// 返回结果
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    invoice,
+	})
+}
+
+// ListReimbursements 分页查询报销单列表
+// 支持按用户ID、状态、部门、标签、申请日期范围和关键词组合筛选及排序
+// 如 GET /api/v1/reimbursements?user_id=u1&status=待审核&start_date=2024-01-01&end_date=2024-03-31&sort_by=apply_date
+func (h *QueryHandler) ListReimbursements(c *gin.Context) {
+	var req request.ListReimbursementsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"message": "请求参数格式错误: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	result, err := h.reimbursementService.ListReimbursements(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, reimbursement.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": err.Error(),
+				"data":    nil,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"message": "获取报销单列表失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    result,
+	})
+}
+
+// GetInvoiceOCRStatus 查询发票OCR识别进度
+func (h *QueryHandler) GetInvoiceOCRStatus(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"message": "发票ID不能为空",
+			"data":    nil,
+		})
+		return
+	}
+
+	status, err := h.reimbursementService.GetOCRStatus(c.Request.Context(), id)
+	if err != nil {
+		if errs.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":    http.StatusNotFound,
+				"message": err.Error(),
+				"data":    nil,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    http.StatusInternalServerError,
+			"message": "获取发票识别进度失败: " + err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    status,
+	})
+}
+
 // GetReimbursementsByUserID 根据用户ID查询
 func (h *QueryHandler) GetReimbursementsByUserID(w http.ResponseWriter, r *http.Request) {
 	// TODO: 实现根据用户ID查询报销单列表逻辑
@@ -6,16 +6,21 @@
 // 4. 规则分类管理（金额校验、频次校验、发票信息校验等）
 // 5. 规则导入/导出
 // 6. 规则测试和验证
+// 7. 销售方黑名单管理（查询、列入、移出）
 
 package handler
 
 import (
 	"context"
+	"errors"
 	"reimbursement-audit/internal/api/middleware"
 	"reimbursement-audit/internal/api/request"
 	"reimbursement-audit/internal/api/response"
 	"reimbursement-audit/internal/domain/rule"
+	"reimbursement-audit/internal/pkg/errs"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,13 +51,17 @@ func (h *RuleHandler) CreateRule(c *gin.Context) {
 		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
 		return
 	}
-	rule, err := h.ruleService.CreateRule(ctx, &req)
+	createdRule, err := h.ruleService.CreateRule(ctx, &req)
 	if err != nil {
 		middleware.LogError(c, "创建规则失败", "error", err.Error(), "context", ctx)
+		if errors.Is(err, rule.ErrInvalidEffectiveWindow) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
-	middleware.LogInfo(c, "创建新规则成功", "rule_id", rule.ID, "context", ctx)
+	middleware.LogInfo(c, "创建新规则成功", "rule_id", createdRule.ID, "context", ctx)
 	response.SuccessResponse(c, "规则创建成功")
 }
 
@@ -70,15 +79,23 @@ func (h *RuleHandler) UpdateRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := h.ruleService.UpdateRule(ctx, &req)
+	updatedRule, err := h.ruleService.UpdateRule(ctx, &req)
 	if err != nil {
 		middleware.LogError(c, "更新规则失败", "error", err.Error(), "context", ctx)
+		if errors.Is(err, rule.ErrInvalidEffectiveWindow) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
 
-	middleware.LogInfo(c, "更新规则成功", "rule_id", rule.ID, "context", ctx)
-	response.SuccessResponse(c, rule)
+	middleware.LogInfo(c, "更新规则成功", "rule_id", updatedRule.ID, "context", ctx)
+	response.SuccessResponse(c, updatedRule)
 }
 
 // DeleteRule 删除规则
@@ -97,6 +114,10 @@ func (h *RuleHandler) DeleteRule(c *gin.Context) {
 
 	if err := h.ruleService.DeleteRule(ctx, ruleID); err != nil {
 		middleware.LogError(c, "删除规则失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
@@ -163,6 +184,10 @@ func (h *RuleHandler) EnableRule(c *gin.Context) {
 
 	if err := h.ruleService.EnableRule(ctx, ruleID); err != nil {
 		middleware.LogError(c, "启用规则失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
@@ -187,6 +212,10 @@ func (h *RuleHandler) DisableRule(c *gin.Context) {
 
 	if err := h.ruleService.DisableRule(ctx, ruleID); err != nil {
 		middleware.LogError(c, "禁用规则失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
@@ -202,12 +231,169 @@ func (h *RuleHandler) TestRule(c *gin.Context) {
 	traceId := middleware.GetTraceId(c)
 	ctx := middleware.WithTraceId(context.Background(), traceId)
 
-	ruleID := c.Param("id")
-	if ruleID == "" {
-		middleware.LogError(c, "缺少规则ID", "context", ctx)
-		response.ErrorResponse(c, response.CodeInvalidParams, "缺少规则ID")
+	var req request.TestRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.LogError(c, "JSON数据绑定失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	testRule := &rule.Rule{
+		Name:       req.Name,
+		Type:       req.Type,
+		Definition: req.Definition,
+		Timeout:    req.Timeout,
+	}
+
+	// 未提供规则定义时，测试已保存的规则
+	if req.Definition == "" {
+		ruleID := c.Param("id")
+		if ruleID == "" {
+			middleware.LogError(c, "缺少规则定义", "context", ctx)
+			response.ErrorResponse(c, response.CodeInvalidParams, "缺少规则定义")
+			return
+		}
+
+		savedRule, err := h.ruleService.GetRuleByID(ctx, ruleID)
+		if err != nil {
+			middleware.LogError(c, "获取规则失败", "error", err.Error(), "context", ctx)
+			if errs.IsNotFound(err) {
+				response.NotFoundResponse(c, err.Error())
+				return
+			}
+			response.ErrorResponse(c, response.CodeInternalError, err.Error())
+			return
+		}
+		testRule = savedRule
+		if req.Timeout > 0 {
+			testRule.Timeout = req.Timeout
+		}
+	}
+
+	startTime := time.Now()
+	result, err := h.ruleService.TestRule(ctx, testRule, req.TestData)
+	duration := time.Since(startTime).Milliseconds()
+	if err != nil {
+		middleware.LogError(c, "测试规则失败", "error", err.Error(), "context", ctx)
+		if errors.Is(err, rule.ErrRuleSyntax) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "测试规则成功", "passed", result.Passed, "duration", duration, "context", ctx)
+	response.SuccessResponse(c, gin.H{
+		"result":   result,
+		"duration": duration,
+	})
+}
+
+// GetRuleCoverage 获取报销类别的规则覆盖度报告
+// 支持category（单个类别）或categories（逗号分隔的多个类别），均为空时统计默认类别
+func (h *RuleHandler) GetRuleCoverage(c *gin.Context) {
+	middleware.LogInfo(c, "获取规则覆盖度请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	var categories []string
+	if category := strings.TrimSpace(c.Query("category")); category != "" {
+		categories = append(categories, category)
+	}
+	for _, category := range strings.Split(c.Query("categories"), ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+
+	report, err := h.ruleService.GetCoverageReport(ctx, categories)
+	if err != nil {
+		middleware.LogError(c, "获取规则覆盖度失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "获取规则覆盖度成功", "category_count", len(report.Categories),
+		"uncovered_count", report.UncoveredCount, "context", ctx)
+	response.SuccessResponse(c, report)
+}
+
+// ListSellerBlacklist 获取销售方黑名单
+func (h *RuleHandler) ListSellerBlacklist(c *gin.Context) {
+	middleware.LogInfo(c, "获取销售方黑名单请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	entries, err := h.ruleService.ListSellerBlacklist(ctx)
+	if err != nil {
+		middleware.LogError(c, "获取销售方黑名单失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "获取销售方黑名单成功", "count", len(entries), "context", ctx)
+	response.SuccessResponse(c, gin.H{
+		"entries": entries,
+		"total":   len(entries),
+	})
+}
+
+// AddSellerBlacklistEntry 将销售方列入黑名单，需要auditor_admin角色
+func (h *RuleHandler) AddSellerBlacklistEntry(c *gin.Context) {
+	middleware.LogInfo(c, "新增销售方黑名单请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	var req request.AddSellerBlacklistRequest
+	if err := c.ShouldBind(&req); err != nil {
+		middleware.LogError(c, "JSON数据绑定失败", "error", err.Error(), "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		return
+	}
+
+	entry, err := h.ruleService.AddSellerBlacklistEntry(ctx, &req)
+	if err != nil {
+		middleware.LogError(c, "新增销售方黑名单失败", "error", err.Error(), "context", ctx)
+		if errors.Is(err, rule.ErrInvalidBlacklistEntry) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		return
+	}
+
+	middleware.LogInfo(c, "新增销售方黑名单成功", "entry_id", entry.ID, "context", ctx)
+	response.SuccessResponse(c, entry)
+}
+
+// RemoveSellerBlacklistEntry 将销售方移出黑名单，需要auditor_admin角色
+func (h *RuleHandler) RemoveSellerBlacklistEntry(c *gin.Context) {
+	middleware.LogInfo(c, "移除销售方黑名单请求", "path", c.Request.URL.Path,
+		"method", c.Request.Method, "remote_addr", c.ClientIP())
+	traceId := middleware.GetTraceId(c)
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	entryID := c.Param("id")
+	if entryID == "" {
+		middleware.LogError(c, "缺少黑名单条目ID", "context", ctx)
+		response.ErrorResponse(c, response.CodeInvalidParams, "缺少黑名单条目ID")
+		return
+	}
+
+	if err := h.ruleService.RemoveSellerBlacklistEntry(ctx, entryID); err != nil {
+		middleware.LogError(c, "移除销售方黑名单失败", "error", err.Error(), "context", ctx)
+		if errs.IsNotFound(err) {
+			response.NotFoundResponse(c, err.Error())
+			return
+		}
+		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
 
-	response.ErrorResponse(c, response.CodeInternalError, "测试规则功能暂未实现")
+	middleware.LogInfo(c, "移除销售方黑名单成功", "entry_id", entryID, "context", ctx)
+	response.SuccessResponse(c, "已移出黑名单")
 }
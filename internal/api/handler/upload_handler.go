@@ -6,11 +6,14 @@
 // 4. 上传文件临时存储
 // 5. 调用OCR服务解析发票信息
 // 6. 返回上传结果和初步解析信息
+// 7. 更新报销单标签
+// 8. 重新识别发票
 
 package handler
 
 import (
 	"context"
+	"errors"
 
 	"github.com/gin-gonic/gin"
 
@@ -18,6 +21,10 @@ import (
 	"reimbursement-audit/internal/api/request"
 	"reimbursement-audit/internal/api/response"
 	"reimbursement-audit/internal/application/service"
+	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/domain/reimbursement"
+	storage "reimbursement-audit/internal/infra/storage/file"
+	"reimbursement-audit/internal/pkg/errs"
 )
 
 // UploadHandler 处理文件上传的结构体
@@ -90,6 +97,16 @@ func (h *UploadHandler) UploadReimbursement(c *gin.Context) {
 			"error", err.Error(),
 			"user_id", req.UserID,
 			"context", ctx)
+		var dateErr *reimbursement.DateValidationError
+		if errors.As(err, &dateErr) {
+			// 日期不一致时返回字段错误列表，便于前端定位字段
+			response.JSONResponse(c, response.CodeInvalidParams, err.Error(), dateErr.Fields)
+			return
+		}
+		if errors.Is(err, reimbursement.ErrInvalidTags) || errors.Is(err, reimbursement.ErrInvalidRecurrence) {
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+			return
+		}
 		response.ErrorResponse(c, response.CodeInternalError, err.Error())
 		return
 	}
@@ -140,7 +157,14 @@ func (h *UploadHandler) UploadInvoices(c *gin.Context) {
 			"reimbursement_id", reimbursementID,
 			"filename", file.Filename,
 			"context", ctx)
-		response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		switch {
+		case errors.Is(err, storage.ErrFileTooLarge):
+			response.ErrorResponse(c, response.CodeFileSizeExceeded, err.Error())
+		case errors.Is(err, storage.ErrFileTypeNotAllowed):
+			response.ErrorResponse(c, response.CodeFileFormatInvalid, err.Error())
+		default:
+			response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		}
 		return
 	}
 
@@ -210,8 +234,90 @@ func (h *UploadHandler) BatchUpload(c *gin.Context) {
 	// 返回成功响应
 	middleware.LogInfo(c, "批量上传处理完成",
 		"batch_id", result.BatchID,
+		"status", result.Status,
 		"total", result.TotalCount,
 		"success_count", result.SuccessCount,
 		"failure_count", result.FailedCount)
 	response.SuccessResponse(c, result)
 }
+
+// UpdateReimbursementTags 更新报销单标签
+// 请求体中的标签整体替换报销单原有标签，传入空列表表示清除全部标签
+func (h *UploadHandler) UpdateReimbursementTags(c *gin.Context) {
+	// 获取traceId
+	traceId := middleware.GetTraceId(c)
+
+	// 创建上下文，用于数据库操作
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	reimbursementID := c.Param("id")
+	if reimbursementID == "" {
+		response.ErrorResponse(c, response.CodeInvalidParams, "报销单ID不能为空")
+		return
+	}
+
+	var req request.UpdateReimbursementTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorResponse(c, response.CodeInvalidParams, "请求参数格式错误: "+err.Error())
+		return
+	}
+
+	result, err := h.reimbursementAppService.UpdateReimbursementTags(ctx, reimbursementID, &req)
+	if err != nil {
+		middleware.LogError(c, "更新报销单标签失败",
+			"error", err.Error(),
+			"reimbursement_id", reimbursementID)
+		switch {
+		case errors.Is(err, reimbursement.ErrInvalidTags):
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		case errs.IsNotFound(err):
+			response.NotFoundResponse(c, err.Error())
+		default:
+			response.ErrorResponse(c, response.CodeInternalError, err.Error())
+		}
+		return
+	}
+
+	middleware.LogInfo(c, "报销单标签更新完成",
+		"reimbursement_id", reimbursementID,
+		"tags", result.Tags)
+	response.SuccessResponse(c, result)
+}
+
+// RetryInvoiceOCR 重新识别发票
+// 识别任务入队后立即返回，可通过识别进度接口查询结果
+func (h *UploadHandler) RetryInvoiceOCR(c *gin.Context) {
+	// 获取traceId
+	traceId := middleware.GetTraceId(c)
+
+	// 创建上下文，用于数据库操作
+	ctx := middleware.WithTraceId(context.Background(), traceId)
+
+	invoiceID := c.Param("id")
+	if invoiceID == "" {
+		response.ErrorResponse(c, response.CodeInvalidParams, "发票ID不能为空")
+		return
+	}
+
+	result, err := h.reimbursementAppService.RetryOCR(ctx, invoiceID)
+	if err != nil {
+		middleware.LogError(c, "重新识别发票失败",
+			"error", err.Error(),
+			"invoice_id", invoiceID)
+		switch {
+		case errs.IsNotFound(err):
+			response.NotFoundResponse(c, err.Error())
+		case errors.Is(err, ocr.ErrTaskInProgress):
+			response.ErrorResponse(c, response.CodeInvalidParams, err.Error())
+		case errors.Is(err, ocr.ErrTaskQueueFull):
+			response.ErrorResponse(c, response.CodeTooManyRequests, err.Error())
+		default:
+			response.ErrorResponse(c, response.CodeOCRError, err.Error())
+		}
+		return
+	}
+
+	middleware.LogInfo(c, "发票重新识别任务已入队",
+		"invoice_id", invoiceID)
+	response.SuccessResponse(c, result)
+}
@@ -2,61 +2,160 @@ package middleware
 
 // auth.go 认证中间件
 // 功能点：
-// 1. JWT令牌验证
-// 2. 用户身份识别
-// 3. 权限校验（基于RBAC模型）
-// 4. API密钥验证（可选）
-// 5. 会话管理
-// 6. 跨域请求处理（CORS）
+// 1. JWT令牌验证（HS256，Authorization: Bearer <token>）
+// 2. 用户身份识别：令牌中的用户ID、姓名和角色写入请求上下文
+// 3. 权限校验（基于角色）：RequireRole按路由要求角色，未认证返回401，角色不足返回403
+// 4. 未携带令牌的请求按匿名用户继续处理，由需要角色的路由拒绝
+// 5. 跨域请求处理（CORS）
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 上下文中存储当前用户信息的键
+const (
+	UserIDKey    = "user_id"    // 用户ID
+	UserNameKey  = "user_name"  // 用户姓名
+	UserRolesKey = "user_roles" // 用户角色列表
+)
+
+// 系统角色
+const (
+	RoleAuditorAdmin  = "auditor_admin"  // 审核管理员：维护规则、销售方黑名单，批量重审
+	RoleReviewer      = "reviewer"       // 复核员：人工改判审核结论
+	RoleDataScientist = "data_scientist" // 数据分析：导出分片向量
+)
+
+// 与response包的错误码保持一致，中间件不能依赖response包（response依赖middleware）
+const (
+	codeUnauthorized = 1002 // 未授权
+	codeForbidden    = 1003 // 禁止访问
 )
 
+// ErrAuthNotConfigured 未配置JWT密钥，无法校验令牌
+var ErrAuthNotConfigured = errors.New("未配置JWT密钥，无法校验令牌")
+
 // AuthConfig 认证中间件配置
 type AuthConfig struct {
-	// TODO: 定义认证配置项（如JWT密钥、过期时间等）
+	Secret string // JWT签名密钥(HS256)，为空时携带令牌的请求一律返回401
+	Issuer string // 令牌签发方，为空时不校验
+}
+
+// Claims JWT令牌声明，用户ID使用标准的sub声明
+type Claims struct {
+	Name  string   `json:"name"`  // 用户姓名
+	Roles []string `json:"roles"` // 用户角色
+	jwt.RegisteredClaims
 }
 
 // Auth 认证中间件结构体
 type Auth struct {
 	config AuthConfig
-	// TODO: 添加认证相关实例
 }
 
 // NewAuth 创建认证中间件实例
 func NewAuth(config AuthConfig) *Auth {
 	return &Auth{
 		config: config,
-		// TODO: 初始化认证组件
 	}
 }
 
 // Middleware 返回认证中间件函数
-func (a *Auth) Middleware() func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// TODO: 实现认证逻辑
-			next.ServeHTTP(w, r)
-		})
+// 携带Bearer令牌时校验签名、过期时间和签发方，校验通过后将用户信息写入上下文，失败返回401
+func (a *Auth) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.Next()
+			return
+		}
+
+		claims, err := a.ParseToken(token)
+		if err != nil {
+			LogWarn(c, "令牌校验失败", "error", err.Error())
+			abortWithCode(c, http.StatusUnauthorized, codeUnauthorized, "令牌无效或已过期")
+			return
+		}
+
+		SetUser(c, claims.Subject, claims.Name, claims.Roles)
+		c.Next()
 	}
 }
 
-// RequirePermission 需要特定权限的中间件
-func (a *Auth) RequirePermission(permission string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// TODO: 实现权限校验逻辑
-			next.ServeHTTP(w, r)
-		})
+// ParseToken 校验并解析令牌
+func (a *Auth) ParseToken(token string) (*Claims, error) {
+	if a.config.Secret == "" {
+		return nil, ErrAuthNotConfigured
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
 	}
+	if a.config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(a.config.Issuer))
+	}
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(a.config.Secret), nil
+	}, options...); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("令牌缺少用户ID")
+	}
+	return claims, nil
 }
 
-// RequireRole 需要特定角色的中间件
-func (a *Auth) RequireRole(role string) func(http.Handler) http.Handler {
+// IssueToken 签发令牌，供登录服务和运维脚本使用
+func (a *Auth) IssueToken(userID, userName string, roles []string, ttl time.Duration) (string, error) {
+	if a.config.Secret == "" {
+		return "", ErrAuthNotConfigured
+	}
+	now := time.Now()
+	claims := &Claims{
+		Name:  userName,
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			Issuer:    a.config.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(a.config.Secret))
+}
+
+// RequireRole 需要任一指定角色的中间件，未认证返回401，角色不足返回403
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserID(c) == "" {
+			abortWithCode(c, http.StatusUnauthorized, codeUnauthorized, "未认证，请先登录")
+			return
+		}
+		userRoles := GetUserRoles(c)
+		for _, role := range roles {
+			if HasRole(userRoles, role) {
+				c.Next()
+				return
+			}
+		}
+		LogWarn(c, "角色不足，拒绝访问", "user_id", GetUserID(c), "roles", userRoles, "required", roles)
+		abortWithCode(c, http.StatusForbidden, codeForbidden, "无权限，需要以下角色之一: "+strings.Join(roles, "/"))
+	}
+}
+
+// RequirePermission 需要特定权限的中间件
+func (a *Auth) RequirePermission(permission string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// TODO: 实现角色校验逻辑
+			// TODO: 实现权限校验逻辑
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -71,3 +170,63 @@ func (a *Auth) CORS() func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// bearerToken 从Authorization头中提取Bearer令牌
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// abortWithCode 终止请求并返回与response包格式一致的错误响应
+func abortWithCode(c *gin.Context, status, code int, message string) {
+	data := gin.H{
+		"code":    code,
+		"message": message,
+		"data":    nil,
+	}
+	if traceId := GetTraceId(c); traceId != "" {
+		data["trace_id"] = traceId
+	}
+	c.AbortWithStatusJSON(status, data)
+}
+
+// SetUser 将认证得到的用户信息写入请求上下文
+func SetUser(c *gin.Context, userID, userName string, roles []string) {
+	c.Set(UserIDKey, userID)
+	c.Set(UserNameKey, userName)
+	c.Set(UserRolesKey, roles)
+}
+
+// GetUserID 从请求上下文中获取当前用户ID，未认证时返回空
+func GetUserID(c *gin.Context) string {
+	return c.GetString(UserIDKey)
+}
+
+// GetUserName 从请求上下文中获取当前用户姓名
+func GetUserName(c *gin.Context) string {
+	return c.GetString(UserNameKey)
+}
+
+// GetUserRoles 从请求上下文中获取当前用户角色列表
+func GetUserRoles(c *gin.Context) []string {
+	if roles, exists := c.Get(UserRolesKey); exists {
+		if list, ok := roles.([]string); ok {
+			return list
+		}
+	}
+	return nil
+}
+
+// HasRole 判断角色列表中是否包含指定角色
+func HasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
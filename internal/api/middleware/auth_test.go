@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := NewAuth(AuthConfig{Secret: "test-secret", Issuer: "reimbursement-audit"})
+	issue := func(roles []string, ttl time.Duration) string {
+		token, err := auth.IssueToken("u1", "张三", roles, ttl)
+		if err != nil {
+			t.Fatalf("签发令牌失败: %v", err)
+		}
+		return "Bearer " + token
+	}
+	otherIssuer, err := NewAuth(AuthConfig{Secret: "test-secret", Issuer: "other"}).
+		IssueToken("u1", "张三", []string{RoleAuditorAdmin}, time.Hour)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	wrongSecret, err := NewAuth(AuthConfig{Secret: "other-secret", Issuer: "reimbursement-audit"}).
+		IssueToken("u1", "张三", []string{RoleAuditorAdmin}, time.Hour)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "审核管理员通过", authorization: issue([]string{"auditor", RoleAuditorAdmin}, time.Hour), wantStatus: http.StatusOK},
+		{name: "普通审核员403", authorization: issue([]string{"auditor"}, time.Hour), wantStatus: http.StatusForbidden},
+		{name: "无角色403", authorization: issue(nil, time.Hour), wantStatus: http.StatusForbidden},
+		{name: "未携带令牌401", wantStatus: http.StatusUnauthorized},
+		{name: "非Bearer令牌按未认证处理", authorization: "Basic dTE6cGFzcw==", wantStatus: http.StatusUnauthorized},
+		{name: "令牌已过期401", authorization: issue([]string{RoleAuditorAdmin}, -time.Minute), wantStatus: http.StatusUnauthorized},
+		{name: "签名密钥不符401", authorization: "Bearer " + wrongSecret, wantStatus: http.StatusUnauthorized},
+		{name: "签发方不符401", authorization: "Bearer " + otherIssuer, wantStatus: http.StatusUnauthorized},
+		{name: "格式错误401", authorization: "Bearer not-a-jwt", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(auth.Middleware())
+			engine.POST("/rules", RequireRole(RoleAuditorAdmin), func(c *gin.Context) {
+				if GetUserID(c) != "u1" || GetUserName(c) != "张三" {
+					t.Errorf("用户信息 = %q/%q, want u1/张三", GetUserID(c), GetUserName(c))
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/rules", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthWithoutSecret(t *testing.T) {
+	auth := NewAuth(AuthConfig{})
+	if _, err := auth.IssueToken("u1", "张三", []string{RoleAuditorAdmin}, time.Hour); err != ErrAuthNotConfigured {
+		t.Errorf("IssueToken() error = %v, want %v", err, ErrAuthNotConfigured)
+	}
+	// 未配置密钥时不能以空密钥签名的令牌冒充任何用户
+	token, err := NewAuth(AuthConfig{Secret: "x"}).IssueToken("u1", "张三", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	if _, err := auth.ParseToken(token); err != ErrAuthNotConfigured {
+		t.Errorf("ParseToken() error = %v, want %v", err, ErrAuthNotConfigured)
+	}
+}
@@ -0,0 +1,220 @@
+package middleware
+
+// compress.go 响应压缩中间件
+// 功能点：
+// 1. 客户端Accept-Encoding声明支持gzip时压缩响应体
+// 2. 响应体达到最小压缩大小时才压缩，小响应直接返回，避免压缩开销大于收益
+// 3. 响应已设置Content-Encoding时不重复压缩
+// 4. 压缩响应设置Content-Encoding头并去除失效的Content-Length，压缩与否都声明Vary: Accept-Encoding
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCompressionMinSize 默认最小压缩大小(字节)
+const DefaultCompressionMinSize = 1024
+
+// CompressionConfig 响应压缩配置
+type CompressionConfig struct {
+	MinSize int // 响应体达到该大小(字节)才压缩，非正数时使用默认值
+	Level   int // gzip压缩级别(1-9)，0或非法值时使用默认级别
+}
+
+// CompressionMiddleware 按Accept-Encoding压缩响应的中间件
+func CompressionMiddleware(config CompressionConfig) gin.HandlerFunc {
+	if config.MinSize <= 0 {
+		config.MinSize = DefaultCompressionMinSize
+	}
+	if config.Level < gzip.BestSpeed || config.Level > gzip.BestCompression {
+		config.Level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{
+		New: func() interface{} {
+			writer, _ := gzip.NewWriterLevel(nil, config.Level)
+			return writer
+		},
+	}
+
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{
+			ResponseWriter: c.Writer,
+			minSize:        config.MinSize,
+			pool:           pool,
+			status:         http.StatusOK,
+		}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip 判断Accept-Encoding是否接受gzip，q=0表示明确拒绝
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		params = strings.ReplaceAll(params, " ", "")
+		if q, ok := strings.CutPrefix(params, "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter 缓冲响应体直到达到最小压缩大小，再决定是否压缩
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minSize int
+	pool    *sync.Pool
+
+	status     int          // 处理器设置的状态码，决定是否压缩前暂不写出
+	buffer     bytes.Buffer // 决定是否压缩前缓冲的响应体
+	decided    bool         // 是否已决定压缩方式并写出响应头
+	gzipWriter *gzip.Writer // 压缩写出器，不压缩时为nil
+}
+
+// WriteHeader 记录状态码，决定是否压缩后再写出
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// WriteHeaderNow 响应头在决定是否压缩后写出，此处不立即写出
+func (w *gzipResponseWriter) WriteHeaderNow() {}
+
+// Status 获取响应状态码
+func (w *gzipResponseWriter) Status() int {
+	if !w.decided {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+// Written 判断是否已写出响应
+func (w *gzipResponseWriter) Written() bool {
+	return w.decided || w.buffer.Len() > 0
+}
+
+// Write 写出响应体，未达到最小压缩大小前缓冲
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buffer.Write(data)
+		if w.buffer.Len() < w.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.gzipWriter != nil {
+		return w.gzipWriter.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString 写出字符串响应体
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush 流式响应刷新时按已缓冲的大小决定是否压缩，并刷新到客户端
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(w.buffer.Len() >= w.minSize)
+	}
+	if w.gzipWriter != nil {
+		_ = w.gzipWriter.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack 连接被接管(如WebSocket)时不再压缩
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// decide 决定是否压缩，写出响应头和已缓冲的响应体
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	// 是否压缩取决于Accept-Encoding，压缩与否都需声明，避免缓存把压缩响应返回给不支持的客户端
+	header.Add("Vary", "Accept-Encoding")
+	if compress && header.Get("Content-Encoding") == "" && bodyAllowed(w.status) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gzipWriter = w.pool.Get().(*gzip.Writer)
+		w.gzipWriter.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gzipWriter != nil {
+		_, err = w.gzipWriter.Write(w.buffer.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buffer.Bytes())
+	}
+	w.buffer.Reset()
+	return err
+}
+
+// finish 处理器返回后写出未达到最小压缩大小的响应，或结束压缩流
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		// 处理器没有写出任何内容时保持原样，由gin写出状态码
+		if w.buffer.Len() == 0 {
+			w.decided = true
+			w.ResponseWriter.WriteHeader(w.status)
+			return
+		}
+		_ = w.decide(false)
+	}
+	if w.gzipWriter != nil {
+		_ = w.gzipWriter.Close()
+		w.gzipWriter.Reset(nil)
+		w.pool.Put(w.gzipWriter)
+		w.gzipWriter = nil
+	}
+}
+
+// bodyAllowed 判断状态码是否允许响应体
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
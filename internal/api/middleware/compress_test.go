@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           bool
+	}{
+		{name: "未声明", acceptEncoding: "", want: false},
+		{name: "支持gzip", acceptEncoding: "gzip, deflate, br", want: true},
+		{name: "忽略大小写和权重", acceptEncoding: "br;q=1.0, GZIP;q=0.8", want: true},
+		{name: "通配符", acceptEncoding: "*", want: true},
+		{name: "q=0明确拒绝", acceptEncoding: "gzip; q=0", want: false},
+		{name: "只支持其它编码", acceptEncoding: "deflate, br", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptsGzip(tt.acceptEncoding); got != tt.want {
+				t.Errorf("acceptsGzip(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := strings.Repeat("报销审核", 200)
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        gin.HandlerFunc
+		wantStatus     int
+		wantGzip       bool
+		wantVary       bool
+		wantBody       string
+	}{
+		{
+			name:           "大响应压缩",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			handler:        func(c *gin.Context) { c.String(http.StatusOK, large) },
+			wantStatus:     http.StatusOK,
+			wantGzip:       true,
+			wantVary:       true,
+			wantBody:       large,
+		},
+		{
+			name:           "小响应不压缩",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			handler:        func(c *gin.Context) { c.String(http.StatusCreated, "ok") },
+			wantStatus:     http.StatusCreated,
+			wantVary:       true,
+			wantBody:       "ok",
+		},
+		{
+			name:       "客户端不支持gzip",
+			method:     http.MethodGet,
+			handler:    func(c *gin.Context) { c.String(http.StatusOK, large) },
+			wantStatus: http.StatusOK,
+			wantBody:   large,
+		},
+		{
+			name:           "已设置Content-Encoding时不重复压缩",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			handler: func(c *gin.Context) {
+				c.Header("Content-Encoding", "br")
+				c.String(http.StatusOK, large)
+			},
+			wantStatus: http.StatusOK,
+			wantVary:   true,
+			wantBody:   large,
+		},
+		{
+			name:           "无响应体时保留状态码",
+			method:         http.MethodGet,
+			acceptEncoding: "gzip",
+			handler:        func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			wantStatus:     http.StatusNoContent,
+		},
+		{
+			name:           "HEAD请求不压缩",
+			method:         http.MethodHead,
+			acceptEncoding: "gzip",
+			handler:        func(c *gin.Context) { c.Status(http.StatusOK) },
+			wantStatus:     http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(CompressionMiddleware(CompressionConfig{MinSize: 100}))
+			engine.Handle(tt.method, "/", tt.handler)
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("状态码 = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotGzip := rec.Header().Get("Content-Encoding") == "gzip"; gotGzip != tt.wantGzip {
+				t.Errorf("Content-Encoding = %q, wantGzip %v", rec.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			if gotVary := rec.Header().Get("Vary") == "Accept-Encoding"; gotVary != tt.wantVary {
+				t.Errorf("Vary = %q, wantVary %v", rec.Header().Get("Vary"), tt.wantVary)
+			}
+
+			body := io.Reader(rec.Body)
+			if tt.wantGzip {
+				reader, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("解压响应失败: %v", err)
+				}
+				body = reader
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("读取响应失败: %v", err)
+			}
+			if string(data) != tt.wantBody {
+				t.Errorf("响应体长度 = %d, want %d", len(data), len(tt.wantBody))
+			}
+		})
+	}
+}
@@ -6,9 +6,19 @@
 // 4. 实现参数校验规则
 // 5. 支持分页参数校验
 // 6. 提供参数绑定和校验方法
+// 7. 定义审核列表查询请求（状态、风险等级、日期范围、分页）
+// 8. 定义审核证明校验请求
+// 9. 定义审核结论人工改判请求
+// 10. 定义规则变更后批量重审受影响报销单请求
 
 package request
 
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
 // StartAuditRequest 开始审核请求
 type StartAuditRequest struct {
 	ReimbursementID string `json:"reimbursement_id" binding:"required"`
@@ -32,12 +42,44 @@ type AuditHistoryRequest struct {
 	Size            int    `json:"size" binding:"min=1,max=100"`
 }
 
+// ListAuditsRequest 审核列表查询请求
+type ListAuditsRequest struct {
+	Status    string `form:"status"`     // 审核状态，可选
+	RiskLevel string `form:"risk_level"` // 风险等级，可选
+	StartDate string `form:"start_date"` // 开始日期，可选，格式：YYYY-MM-DD
+	EndDate   string `form:"end_date"`   // 结束日期(含当天)，可选，格式：YYYY-MM-DD
+	Page      int    `form:"page"`       // 页码，默认1
+	Size      int    `form:"size"`       // 每页大小，默认20，最大100
+}
+
 // PaginationRequest 分页请求
 type PaginationRequest struct {
 	Page int `json:"page" binding:"min=1"`
 	Size int `json:"size" binding:"min=1,max=100"`
 }
 
+// VerifyAttestationRequest 审核证明校验请求
+type VerifyAttestationRequest struct {
+	Token string `json:"token" binding:"required"` // 审核证明(JWS紧凑格式)
+}
+
+// OverrideAuditRequest 审核结论人工改判请求
+type OverrideAuditRequest struct {
+	Verdict string `json:"verdict" binding:"required,oneof=通过 未通过"` // 改判结论(通过/未通过)
+	Reason  string `json:"reason" binding:"required"`               // 改判理由
+
+	// 改判人信息取自认证令牌，不从请求体读取
+	Actor      string   `json:"-"` // 改判人
+	ActorID    string   `json:"-"` // 改判人用户ID
+	ActorRoles []string `json:"-"` // 改判人角色
+}
+
+// ReauditAffectedRequest 规则变更后批量重审受影响报销单请求，参数均可省略
+type ReauditAffectedRequest struct {
+	LookbackDays int `json:"lookback_days"` // 只重审最近多少天内申请的报销单，0表示使用默认天数
+	MaxItems     int `json:"max_items"`     // 单批最多重审的报销单数量，0表示使用默认数量
+}
+
 // Validate 校验开始审核请求
 func (r *StartAuditRequest) Validate() error {
 	if r.ReimbursementID == "" {
@@ -82,4 +124,72 @@ func (r *PaginationRequest) Validate() error {
 		r.Size = 10
 	}
 	return nil
+}
+
+// Validate 校验审核列表查询请求
+func (r *ListAuditsRequest) Validate() error {
+	if r.Page < 0 {
+		return errors.New("page参数必须为正整数")
+	}
+	if r.Size < 0 || r.Size > 100 {
+		return errors.New("size参数必须为1-100之间的整数")
+	}
+	if r.Page == 0 {
+		r.Page = 1
+	}
+	if r.Size == 0 {
+		r.Size = 20
+	}
+
+	startTime, endTime, err := r.TimeRange()
+	if err != nil {
+		return err
+	}
+	if startTime != nil && endTime != nil && startTime.After(*endTime) {
+		return errors.New("开始日期不能晚于结束日期")
+	}
+	return nil
+}
+
+// Validate 校验审核结论人工改判请求
+func (r *OverrideAuditRequest) Validate() error {
+	if strings.TrimSpace(r.Reason) == "" {
+		return errors.New("改判理由不能为空")
+	}
+	if strings.TrimSpace(r.Actor) == "" {
+		return errors.New("改判人不能为空")
+	}
+	return nil
+}
+
+// Validate 校验批量重审请求
+func (r *ReauditAffectedRequest) Validate() error {
+	if r.LookbackDays < 0 || r.LookbackDays > 366 {
+		return errors.New("lookback_days参数必须为0-366之间的整数")
+	}
+	if r.MaxItems < 0 || r.MaxItems > 1000 {
+		return errors.New("max_items参数必须为0-1000之间的整数")
+	}
+	return nil
+}
+
+// TimeRange 解析日期范围，结束日期包含当天
+func (r *ListAuditsRequest) TimeRange() (*time.Time, *time.Time, error) {
+	var startTime, endTime *time.Time
+	if r.StartDate != "" {
+		start, err := time.ParseInLocation("2006-01-02", r.StartDate, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("开始日期格式不正确，应为YYYY-MM-DD")
+		}
+		startTime = &start
+	}
+	if r.EndDate != "" {
+		end, err := time.ParseInLocation("2006-01-02", r.EndDate, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("结束日期格式不正确，应为YYYY-MM-DD")
+		}
+		end = end.Add(24*time.Hour - time.Nanosecond)
+		endTime = &end
+	}
+	return startTime, endTime, nil
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+// knowledge_request.go 知识库查询请求结构体
+// 功能点：
+// 1. 定义报销制度查询请求结构体
+// 2. 支持指定检索数量和输出格式
+// 3. 支持指定查询语言，未指定时根据查询内容检测
+
+package request
+
+// PolicyQueryRequest 报销制度查询请求
+type PolicyQueryRequest struct {
+	Query    string `json:"query" binding:"required"` // 查询内容
+	TopK     int    `json:"top_k"`                    // 检索分片数量，0表示使用默认值
+	Format   string `json:"format"`                   // 输出格式(markdown/plain/json)，默认markdown
+	Language string `json:"language"`                 // 查询语言(zh-CN/en)，为空时根据查询内容检测
+}
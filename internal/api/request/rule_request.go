@@ -6,6 +6,7 @@
 // 4. 定义规则测试请求结构体
 // 5. 实现参数校验规则
 // 6. 提供参数绑定和校验方法
+// 7. 定义销售方黑名单新增请求结构体
 
 package request
 
@@ -16,12 +17,17 @@ type CreateRuleRequest struct {
 	Type        string   `json:"type"`        // 规则类型(金额/频次/发票/合规等)
 	Category    string   `json:"category"`    // 规则分类
 	Definition  string   `json:"definition"`  // 规则定义(Grule语法)
+	Explanation string   `json:"explanation"` // 违规说明模板(支持变量插值)
 	Priority    int      `json:"priority"`    // 优先级(数字越大优先级越高)
+	Timeout     int      `json:"timeout"`     // 执行超时时间(毫秒，0表示使用引擎默认值)
 	Enabled     bool     `json:"enabled"`     // 是否启用
 	CreatedBy   string   `json:"created_by"`  // 创建人
 	UpdatedBy   string   `json:"updated_by"`  // 更新人
 	Version     int      `json:"version"`     // 版本号
 	Tags        []string `json:"tags"`        // 标签
+
+	EffectiveFrom string `json:"effective_from"` // 生效开始日期(YYYY-MM-DD，含当天)，为空表示不限制
+	EffectiveTo   string `json:"effective_to"`   // 生效结束日期(YYYY-MM-DD，含当天)，为空表示不限制
 }
 
 // UpdateRuleRequest 更新规则请求
@@ -34,10 +40,32 @@ type UpdateRuleRequest struct {
 	Category    string   `json:"category"`    // 规则分类
 	Status      string   `json:"status"`      // 规则状态(启用/禁用/草稿)
 	Definition  string   `json:"definition"`  // 规则定义(Grule语法)
+	Explanation string   `json:"explanation"` // 违规说明模板(支持变量插值)
 	Priority    int      `json:"priority"`    // 优先级(数字越大优先级越高)
+	Timeout     int      `json:"timeout"`     // 执行超时时间(毫秒，0表示使用引擎默认值)
 	Enabled     bool     `json:"enabled"`     // 是否启用
 	CreatedBy   string   `json:"created_by"`  // 创建人
 	UpdatedBy   string   `json:"updated_by"`  // 更新人
 	Version     int      `json:"version"`     // 版本号
 	Tags        []string `json:"tags"`        // 标签
+
+	EffectiveFrom string `json:"effective_from"` // 生效开始日期(YYYY-MM-DD，含当天)，为空表示不限制
+	EffectiveTo   string `json:"effective_to"`   // 生效结束日期(YYYY-MM-DD，含当天)，为空表示不限制
+}
+
+// TestRuleRequest 测试规则请求
+type TestRuleRequest struct {
+	Name       string                 `json:"name"`       // 规则名称
+	Type       string                 `json:"type"`       // 规则类型
+	Definition string                 `json:"definition"` // 规则定义(Grule语法)，为空时使用路径中规则ID对应的已保存规则
+	Timeout    int                    `json:"timeout"`    // 执行超时时间(毫秒)
+	TestData   map[string]interface{} `json:"test_data"`  // 测试数据，规则中通过data访问
+}
+
+// AddSellerBlacklistRequest 新增销售方黑名单请求，名称和税号至少填写一项
+type AddSellerBlacklistRequest struct {
+	SellerName  string `json:"seller_name"`   // 销售方名称
+	SellerTaxNo string `json:"seller_tax_no"` // 销售方税号
+	Reason      string `json:"reason"`        // 列入黑名单的原因
+	CreatedBy   string `json:"created_by"`    // 创建人
 }
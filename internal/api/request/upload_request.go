@@ -6,6 +6,8 @@
 // 4. 支持文件格式和大小校验
 // 5. 支持自定义校验规则
 // 6. 提供参数绑定和校验方法
+// 7. 定义报销单标签更新请求和报销单列表查询请求
+// 8. 报销单列表查询支持部门、申请日期范围、关键词筛选和排序
 
 package request
 
@@ -20,15 +22,42 @@ import (
 
 // ReimbursementUploadRequest 报销单上传请求
 type ReimbursementUploadRequest struct {
-	UserID      string  `json:"user_id" form:"user_id"`           // 用户ID，必填
-	UserName    string  `json:"user_name" form:"user_name"`       // 用户姓名，必填
-	TotalAmount float64 `json:"total_amount" form:"total_amount"` // 总金额，必填，大于0
-	Category    string  `json:"category" form:"category"`         // 报销类别，必填
-	Reason      string  `json:"reason" form:"reason"`             // 报销事由，必填
-	Department  string  `json:"department" form:"department"`     // 所属部门，可选
-	ApplyDate   string  `json:"apply_date" form:"apply_date"`     // 申请日期，可选，格式：YYYY-MM-DD
-	ExpenseDate string  `json:"expense_date" form:"expense_date"` // 费用发生日期，可选，格式：YYYY-MM-DD
-	Description string  `json:"description" form:"description"`   // 报销描述，可选
+	UserID      string   `json:"user_id" form:"user_id"`           // 用户ID，必填
+	UserName    string   `json:"user_name" form:"user_name"`       // 用户姓名，必填
+	TotalAmount float64  `json:"total_amount" form:"total_amount"` // 总金额，必填，大于0
+	Category    string   `json:"category" form:"category"`         // 报销类别，必填
+	Reason      string   `json:"reason" form:"reason"`             // 报销事由，必填
+	Department  string   `json:"department" form:"department"`     // 所属部门，可选
+	ApplyDate   string   `json:"apply_date" form:"apply_date"`     // 申请日期，可选，格式：YYYY-MM-DD
+	ExpenseDate string   `json:"expense_date" form:"expense_date"` // 费用发生日期，可选，格式：YYYY-MM-DD
+	StartDate   string   `json:"start_date" form:"start_date"`     // 出差开始日期，可选，格式：YYYY-MM-DD
+	EndDate     string   `json:"end_date" form:"end_date"`         // 出差结束日期，可选，格式：YYYY-MM-DD
+	Description string   `json:"description" form:"description"`   // 报销描述，可选
+	Tags        []string `json:"tags" form:"tags"`                 // 标签，可选，如"年会"
+
+	IsRecurring      bool   `json:"is_recurring" form:"is_recurring"`           // 是否为周期性报销(如按月订阅的软件服务)，可选
+	RecurrencePeriod string `json:"recurrence_period" form:"recurrence_period"` // 报销周期(monthly/quarterly/yearly)，可选，默认monthly
+	RecurrenceKey    string `json:"recurrence_key" form:"recurrence_key"`       // 订阅标识，可选，默认使用报销事由
+}
+
+// UpdateReimbursementTagsRequest 报销单标签更新请求，传入的标签整体替换原有标签
+type UpdateReimbursementTagsRequest struct {
+	Tags []string `json:"tags"` // 标签列表，为空表示清除全部标签
+}
+
+// ListReimbursementsRequest 报销单列表查询请求
+type ListReimbursementsRequest struct {
+	UserID     string `form:"user_id"`    // 用户ID，可选
+	Status     string `form:"status"`     // 报销单状态，可选
+	Tag        string `form:"tag"`        // 标签，可选
+	Department string `form:"department"` // 所属部门，可选
+	StartDate  string `form:"start_date"` // 申请日期起(含)，可选，格式：YYYY-MM-DD
+	EndDate    string `form:"end_date"`   // 申请日期止(含)，可选，格式：YYYY-MM-DD
+	Keyword    string `form:"keyword"`    // 关键词，可选，匹配申请人姓名、标题和描述
+	SortBy     string `form:"sort_by"`    // 排序字段(created_at/updated_at/apply_date/expense_date/total_amount)，默认created_at
+	SortOrder  string `form:"sort_order"` // 排序方向(asc/desc)，默认desc
+	Page       int    `form:"page"`       // 页码，默认1
+	Size       int    `form:"size"`       // 每页大小，默认20，最大100
 }
 
 // InvoiceUploadRequest 发票上传请求
@@ -90,6 +119,18 @@ func (r *ReimbursementUploadRequest) Validate() error {
 		}
 	}
 
+	if r.StartDate != "" {
+		if _, err := time.Parse("2006-01-02", r.StartDate); err != nil {
+			return errors.New("出差开始日期格式不正确，应为YYYY-MM-DD")
+		}
+	}
+
+	if r.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", r.EndDate); err != nil {
+			return errors.New("出差结束日期格式不正确，应为YYYY-MM-DD")
+		}
+	}
+
 	return nil
 }
 
@@ -132,6 +173,29 @@ func (r *BatchUploadRequest) Validate() error {
 	return nil
 }
 
+// Validate 校验报销单列表查询请求
+func (r *ListReimbursementsRequest) Validate() error {
+	if r.Page < 0 {
+		return errors.New("page参数必须为正整数")
+	}
+	if r.Size < 0 || r.Size > 100 {
+		return errors.New("size参数必须为1-100之间的整数")
+	}
+	if r.Page == 0 {
+		r.Page = 1
+	}
+	if r.Size == 0 {
+		r.Size = 20
+	}
+
+	r.UserID = strings.TrimSpace(r.UserID)
+	r.Status = strings.TrimSpace(r.Status)
+	r.Tag = strings.TrimSpace(r.Tag)
+	r.Department = strings.TrimSpace(r.Department)
+	r.Keyword = strings.TrimSpace(r.Keyword)
+	return nil
+}
+
 // ValidateFile 校验上传文件
 func (f *FileUploadInfo) ValidateFile() error {
 	if f.File == nil {
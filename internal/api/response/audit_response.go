@@ -17,9 +17,22 @@ type AuditResponse struct {
 	RiskScore       float64                `json:"risk_score"`
 	Reason          string                 `json:"reason"`
 	Suggestions     []string               `json:"suggestions"`
+	SubmittedAt     time.Time              `json:"submitted_at"`
 	StartedAt       time.Time              `json:"started_at"`
 	CompletedAt     *time.Time             `json:"completed_at"`
 	Duration        int64                  `json:"duration"`
+	TurnaroundTime  int64                  `json:"turnaround_time"`
+	SLABreached     bool                   `json:"sla_breached"`
+	RetryOf         string                 `json:"retry_of"`
+	Attempt         int                    `json:"attempt"`
+	Transient       bool                   `json:"transient"`
+
+	EffectivePass  bool                   `json:"effective_pass"`
+	Overridden     bool                   `json:"overridden"`
+	OverrideReason string                 `json:"override_reason"`
+	OverriddenBy   string                 `json:"overridden_by"`
+	OverriddenAt   *time.Time             `json:"overridden_at"`
+	OverrideTrail  []*audit.AuditOverride `json:"override_trail"`
 }
 
 // AuditStatusResponse 审核状态响应
@@ -42,6 +55,7 @@ type AuditResultResponse struct {
 	FinalPass       bool                        `json:"final_pass"`
 	RuleResults     []*RuleValidationResult     `json:"rule_results"`
 	RAGResults      *RAGAnalysisResultResponse `json:"rag_results"`
+	InvoiceSummary  *audit.InvoiceAuditSummary  `json:"invoice_summary"`
 	RiskLevel       string                      `json:"risk_level"`
 	RiskScore       float64                     `json:"risk_score"`
 	Reason          string                      `json:"reason"`
@@ -57,6 +71,7 @@ type RuleValidationResult struct {
 	RuleCode      string                 `json:"rule_code"`
 	RuleName      string                 `json:"rule_name"`
 	RuleType      string                 `json:"rule_type"`
+	Severity      string                 `json:"severity"`
 	Passed        bool                   `json:"passed"`
 	Message       string                 `json:"message"`
 	Details       map[string]interface{} `json:"details"`
@@ -80,6 +95,8 @@ type VectorReference struct {
 	Similarity float64 `json:"similarity"`
 	Category   string  `json:"category"`
 	DocumentID string  `json:"document_id"`
+	Index      int     `json:"index"`
+	Cited      bool    `json:"cited"`
 }
 
 // NewAuditResponse 创建审核响应
@@ -95,9 +112,21 @@ func NewAuditResponse(auditResult *audit.AuditResult) *AuditResponse {
 		RiskScore:       auditResult.RiskScore,
 		Reason:          auditResult.Reason,
 		Suggestions:     auditResult.Suggestions,
+		SubmittedAt:     auditResult.SubmittedAt,
 		StartedAt:       auditResult.StartedAt,
 		CompletedAt:     auditResult.CompletedAt,
 		Duration:        auditResult.Duration,
+		TurnaroundTime:  auditResult.TurnaroundTime,
+		SLABreached:     auditResult.SLABreached,
+		RetryOf:         auditResult.RetryOf,
+		Attempt:         auditResult.Attempt,
+		Transient:       auditResult.Transient,
+		EffectivePass:   auditResult.EffectivePass(),
+		Overridden:      auditResult.Overridden,
+		OverrideReason:  auditResult.OverrideReason,
+		OverriddenBy:    auditResult.OverriddenBy,
+		OverriddenAt:    auditResult.OverriddenAt,
+		OverrideTrail:   auditResult.OverrideTrail,
 	}
 }
 
@@ -126,6 +155,7 @@ func NewAuditResultResponse(auditResult *audit.AuditResult) *AuditResultResponse
 		RiskScore:       auditResult.RiskScore,
 		Reason:          auditResult.Reason,
 		Suggestions:     auditResult.Suggestions,
+		InvoiceSummary:  auditResult.InvoiceSummary,
 		StartedAt:       auditResult.StartedAt,
 		CompletedAt:     auditResult.CompletedAt,
 		Duration:        auditResult.Duration,
@@ -139,6 +169,7 @@ func NewAuditResultResponse(auditResult *audit.AuditResult) *AuditResultResponse
 				RuleCode:      result.RuleCode,
 				RuleName:      result.RuleName,
 				RuleType:      result.RuleType,
+				Severity:      result.Severity,
 				Passed:        result.Passed,
 				Message:       result.Message,
 				Details:       result.Details,
@@ -165,6 +196,8 @@ func NewAuditResultResponse(auditResult *audit.AuditResult) *AuditResultResponse
 					Similarity: ref.Similarity,
 					Category:   ref.Category,
 					DocumentID: ref.DocumentID,
+					Index:      ref.Index,
+					Cited:      ref.Cited,
 				}
 			}
 		}
@@ -172,3 +205,78 @@ func NewAuditResultResponse(auditResult *audit.AuditResult) *AuditResultResponse
 
 	return response
 }
+
+// AuditListResponse 审核列表响应
+type AuditListResponse struct {
+	Audits []*AuditResponse `json:"audits"`
+	Total  int64            `json:"total"`
+	Page   int              `json:"page"`
+	Size   int              `json:"size"`
+}
+
+// NewAuditListResponse 创建审核列表响应
+func NewAuditListResponse(auditResults []*audit.AuditResult, total int64, page, size int) *AuditListResponse {
+	audits := make([]*AuditResponse, 0, len(auditResults))
+	for _, auditResult := range auditResults {
+		audits = append(audits, NewAuditResponse(auditResult))
+	}
+
+	return &AuditListResponse{
+		Audits: audits,
+		Total:  total,
+		Page:   page,
+		Size:   size,
+	}
+}
+
+// AuditSummaryResponse 审核列表项响应，仅包含列表展示所需的摘要字段
+type AuditSummaryResponse struct {
+	ID              string     `json:"id"`
+	ReimbursementID string     `json:"reimbursement_id"`
+	Status          string     `json:"status"`
+	FinalPass       bool       `json:"final_pass"`
+	RiskLevel       string     `json:"risk_level"`
+	RiskScore       float64    `json:"risk_score"`
+	Reason          string     `json:"reason"`
+	SLABreached     bool       `json:"sla_breached"`
+	Attempt         int        `json:"attempt"`
+	SubmittedAt     time.Time  `json:"submitted_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// AuditPageResponse 审核列表分页响应
+type AuditPageResponse struct {
+	Items []*AuditSummaryResponse `json:"items"`
+	Total int64                   `json:"total"`
+	Page  int                     `json:"page"`
+	Size  int                     `json:"size"`
+}
+
+// NewAuditPageResponse 创建审核列表分页响应
+func NewAuditPageResponse(auditResults []*audit.AuditResult, total int64, page, size int) *AuditPageResponse {
+	items := make([]*AuditSummaryResponse, 0, len(auditResults))
+	for _, auditResult := range auditResults {
+		items = append(items, &AuditSummaryResponse{
+			ID:              auditResult.ID,
+			ReimbursementID: auditResult.ReimbursementID,
+			Status:          string(auditResult.Status),
+			FinalPass:       auditResult.FinalPass,
+			RiskLevel:       auditResult.RiskLevel,
+			RiskScore:       auditResult.RiskScore,
+			Reason:          auditResult.Reason,
+			SLABreached:     auditResult.SLABreached,
+			Attempt:         auditResult.Attempt,
+			SubmittedAt:     auditResult.SubmittedAt,
+			CompletedAt:     auditResult.CompletedAt,
+			CreatedAt:       auditResult.CreatedAt,
+		})
+	}
+
+	return &AuditPageResponse{
+		Items: items,
+		Total: total,
+		Page:  page,
+		Size:  size,
+	}
+}
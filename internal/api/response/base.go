@@ -34,3 +34,41 @@ func ErrorResponse(c *gin.Context, code int, message string) {
 func SuccessResponse(c *gin.Context, data interface{}) {
 	JSONResponse(c, CodeSuccess, "成功", data)
 }
+
+// NotFoundResponse 返回HTTP 404资源不存在响应的辅助函数
+func NotFoundResponse(c *gin.Context, message string) {
+	if message == "" {
+		message = codeMessages[CodeNotFound]
+	}
+
+	responseData := gin.H{
+		"code":    CodeNotFound,
+		"message": message,
+		"data":    nil,
+	}
+
+	if traceId := middleware.GetTraceId(c); traceId != "" {
+		responseData["trace_id"] = traceId
+	}
+
+	c.JSON(http.StatusNotFound, responseData)
+}
+
+// ForbiddenResponse 返回HTTP 403无权限响应的辅助函数
+func ForbiddenResponse(c *gin.Context, message string) {
+	if message == "" {
+		message = codeMessages[CodeForbidden]
+	}
+
+	responseData := gin.H{
+		"code":    CodeForbidden,
+		"message": message,
+		"data":    nil,
+	}
+
+	if traceId := middleware.GetTraceId(c); traceId != "" {
+		responseData["trace_id"] = traceId
+	}
+
+	c.JSON(http.StatusForbidden, responseData)
+}
@@ -2,22 +2,33 @@
 // 功能点：
 // 1. 定义报销单上传响应结构体
 // 2. 定义发票上传响应结构体
-// 3. 定义批量上传响应结构体
+// 3. 定义批量上传响应结构体，逐个文件返回上传结果，支持部分成功
 // 4. 提供响应数据转换方法
+// 5. 定义报销单列表分页响应，列表项包含发票数量
+// 6. 定义发票OCR识别进度响应
 
 package response
 
-import "time"
+import (
+	"time"
+
+	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/domain/reimbursement"
+)
 
 // ReimbursementUploadResponse 报销单上传响应
 type ReimbursementUploadResponse struct {
-	ReimbursementID string    `json:"reimbursement_id"` // 报销单ID
-	UserID          string    `json:"user_id"`          // 用户ID
-	UserName        string    `json:"user_name"`        // 用户姓名
-	TotalAmount     float64   `json:"total_amount"`     // 总金额
-	Category        string    `json:"category"`         // 报销类别
-	Status          string    `json:"status"`           // 状态
-	CreatedAt       time.Time `json:"created_at"`       // 创建时间
+	ReimbursementID  string    `json:"reimbursement_id"`            // 报销单ID
+	UserID           string    `json:"user_id"`                     // 用户ID
+	UserName         string    `json:"user_name"`                   // 用户姓名
+	TotalAmount      float64   `json:"total_amount"`                // 总金额
+	Category         string    `json:"category"`                    // 报销类别
+	Status           string    `json:"status"`                      // 状态
+	Tags             []string  `json:"tags"`                        // 标签
+	IsRecurring      bool      `json:"is_recurring"`                // 是否为周期性报销
+	RecurrencePeriod string    `json:"recurrence_period,omitempty"` // 报销周期
+	InvoiceCount     int       `json:"invoice_count"`               // 发票数量
+	CreatedAt        time.Time `json:"created_at"`                  // 创建时间
 }
 
 // InvoiceUploadResponse 发票上传响应
@@ -29,15 +40,38 @@ type InvoiceUploadResponse struct {
 	UploadStatus    string `json:"upload_status"`     // 上传状态
 }
 
+// 批量上传状态
+const (
+	BatchUploadStatusSuccess = "success" // 全部成功
+	BatchUploadStatusPartial = "partial" // 部分成功，客户端可按文件结果重试失败的文件
+	BatchUploadStatusFailed  = "failed"  // 全部失败
+)
+
+// 单个文件上传状态
+const (
+	FileUploadStatusSuccess = "success" // 上传成功
+	FileUploadStatusFailed  = "failed"  // 上传失败
+)
+
 // BatchUploadResponse 批量上传响应
 type BatchUploadResponse struct {
-	BatchID       string                         `json:"batch_id"`        // 批次ID
-	TotalCount    int                            `json:"total_count"`     // 总数量
-	SuccessCount  int                            `json:"success_count"`   // 成功数量
-	FailedCount   int                            `json:"failed_count"`    // 失败数量
-	Reimbursements []ReimbursementUploadResponse `json:"reimbursements"`  // 报销单列表
-	Invoices      []InvoiceUploadResponse        `json:"invoices"`        // 发票列表
-	Errors        []string                       `json:"errors"`          // 错误信息
+	BatchID        string                        `json:"batch_id"`       // 批次ID
+	Status         string                        `json:"status"`         // 批次状态(success/partial/failed)
+	TotalCount     int                           `json:"total_count"`    // 总数量
+	SuccessCount   int                           `json:"success_count"`  // 成功数量
+	FailedCount    int                           `json:"failed_count"`   // 失败数量
+	Reimbursements []ReimbursementUploadResponse `json:"reimbursements"` // 报销单列表
+	Invoices       []InvoiceUploadResponse       `json:"invoices"`       // 发票列表
+	Files          []BatchUploadFileResult       `json:"files"`          // 逐个文件的上传结果，顺序与上传顺序一致
+}
+
+// BatchUploadFileResult 批量上传中单个文件的上传结果
+type BatchUploadFileResult struct {
+	Index     int    `json:"index"`                // 文件在本次上传中的序号(从0开始)，文件名重复时用于区分
+	Filename  string `json:"filename"`             // 文件名
+	Status    string `json:"status"`               // 上传状态(success/failed)
+	Error     string `json:"error,omitempty"`      // 失败原因
+	InvoiceID string `json:"invoice_id,omitempty"` // 上传成功时的发票ID
 }
 
 // NewReimbursementUploadResponse 创建报销单上传响应
@@ -69,12 +103,89 @@ func NewInvoiceUploadResponse(invoiceID, reimbursementID, filePath string,
 // NewBatchUploadResponse 创建批量上传响应
 func NewBatchUploadResponse(batchID string, totalCount, successCount, failedCount int) *BatchUploadResponse {
 	return &BatchUploadResponse{
-		BatchID:      batchID,
-		TotalCount:   totalCount,
-		SuccessCount: successCount,
-		FailedCount:  failedCount,
+		BatchID:        batchID,
+		Status:         batchUploadStatus(successCount, failedCount),
+		TotalCount:     totalCount,
+		SuccessCount:   successCount,
+		FailedCount:    failedCount,
 		Reimbursements: make([]ReimbursementUploadResponse, 0),
-		Invoices:     make([]InvoiceUploadResponse, 0),
-		Errors:       make([]string, 0),
+		Invoices:       make([]InvoiceUploadResponse, 0),
+		Files:          make([]BatchUploadFileResult, 0),
+	}
+}
+
+// NewBatchUploadFileSuccess 创建单个文件上传成功结果
+func NewBatchUploadFileSuccess(index int, filename, invoiceID string) BatchUploadFileResult {
+	return BatchUploadFileResult{
+		Index:     index,
+		Filename:  filename,
+		Status:    FileUploadStatusSuccess,
+		InvoiceID: invoiceID,
+	}
+}
+
+// NewBatchUploadFileFailure 创建单个文件上传失败结果
+func NewBatchUploadFileFailure(index int, filename, errMsg string) BatchUploadFileResult {
+	return BatchUploadFileResult{
+		Index:    index,
+		Filename: filename,
+		Status:   FileUploadStatusFailed,
+		Error:    errMsg,
+	}
+}
+
+// batchUploadStatus 根据成功和失败数量确定批次状态
+func batchUploadStatus(successCount, failedCount int) string {
+	switch {
+	case failedCount == 0:
+		return BatchUploadStatusSuccess
+	case successCount == 0:
+		return BatchUploadStatusFailed
+	default:
+		return BatchUploadStatusPartial
+	}
+}
+
+// ReimbursementPageResponse 报销单列表分页响应
+type ReimbursementPageResponse struct {
+	Items []*ReimbursementUploadResponse `json:"items"`
+	Total int64                          `json:"total"`
+	Page  int                            `json:"page"`
+	Size  int                            `json:"size"`
+}
+
+// NewReimbursementPageResponse 创建报销单列表分页响应
+func NewReimbursementPageResponse(reimbursements []*reimbursement.Reimbursement, total int64, page, size int) *ReimbursementPageResponse {
+	items := make([]*ReimbursementUploadResponse, 0, len(reimbursements))
+	for _, item := range reimbursements {
+		resp := NewReimbursementUploadResponse(item.ID, item.UserID, item.UserName, item.Type,
+			item.TotalAmount, item.Status, item.CreatedAt)
+		resp.Tags = item.Tags
+		resp.IsRecurring = item.IsRecurring
+		resp.RecurrencePeriod = item.RecurrencePeriod
+		items = append(items, resp)
+	}
+
+	return &ReimbursementPageResponse{
+		Items: items,
+		Total: total,
+		Page:  page,
+		Size:  size,
+	}
+}
+
+// OCRStatusResponse 发票OCR识别进度响应
+type OCRStatusResponse struct {
+	InvoiceID     string    `json:"invoice_id"`     // 发票ID
+	InvoiceStatus string    `json:"invoice_status"` // 发票状态(待识别/已识别/部分识别/解析失败/识别失败/无效)
+	Task          *ocr.Task `json:"task,omitempty"` // 识别任务状态，未经过任务队列时为空
+}
+
+// NewOCRStatusResponse 创建发票OCR识别进度响应
+func NewOCRStatusResponse(invoiceID, invoiceStatus string, task *ocr.Task) *OCRStatusResponse {
+	return &OCRStatusResponse{
+		InvoiceID:     invoiceID,
+		InvoiceStatus: invoiceStatus,
+		Task:          task,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,62 @@
+package response
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewBatchUploadResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		successCount int
+		failedCount  int
+		wantStatus   string
+	}{
+		{name: "全部成功", successCount: 3, wantStatus: BatchUploadStatusSuccess},
+		{name: "部分成功", successCount: 2, failedCount: 1, wantStatus: BatchUploadStatusPartial},
+		{name: "全部失败", failedCount: 3, wantStatus: BatchUploadStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewBatchUploadResponse("b1", tt.successCount+tt.failedCount, tt.successCount, tt.failedCount)
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			if got.Invoices == nil || got.Reimbursements == nil || got.Files == nil {
+				t.Errorf("列表字段应初始化为空切片: %+v", got)
+			}
+		})
+	}
+}
+
+func TestBatchUploadFileResultJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		result BatchUploadFileResult
+		want   string
+	}{
+		{
+			name:   "上传成功带发票ID",
+			result: NewBatchUploadFileSuccess(0, "a.pdf", "inv1"),
+			want:   `{"index":0,"filename":"a.pdf","status":"success","invoice_id":"inv1"}`,
+		},
+		{
+			name:   "上传失败带原因",
+			result: NewBatchUploadFileFailure(1, "a.pdf", "文件过大"),
+			want:   `{"index":1,"filename":"a.pdf","status":"failed","error":"文件过大"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.result)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("json.Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"reimbursement-audit/internal/api/request"
 	"reimbursement-audit/internal/api/response"
 	"reimbursement-audit/internal/domain/audit"
+	"reimbursement-audit/internal/domain/rule"
 	"reimbursement-audit/internal/pkg/logger"
 )
 
@@ -66,6 +68,32 @@ func (s *AuditApplicationService) GetAuditResult(ctx context.Context, auditID st
 	return response.NewAuditResultResponse(auditResult), nil
 }
 
+// GetAppliedStandards 获取审核采用的限额标准用例
+func (s *AuditApplicationService) GetAppliedStandards(ctx context.Context, auditID string) ([]*rule.AppliedLimitStandard, error) {
+	s.logger.WithContext(ctx).Info("获取审核限额标准", logger.NewField("audit_id", auditID))
+
+	standards, err := s.auditService.GetAppliedStandards(ctx, auditID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取审核限额标准失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取审核限额标准失败: %w", err)
+	}
+
+	return standards, nil
+}
+
+// GetApplicableRules 预览报销单审核时将执行的规则用例
+func (s *AuditApplicationService) GetApplicableRules(ctx context.Context, reimbursementID string) (*audit.ApplicableRulesPreview, error) {
+	s.logger.WithContext(ctx).Info("预览报销单适用规则", logger.NewField("reimbursement_id", reimbursementID))
+
+	preview, err := s.auditService.PreviewApplicableRules(ctx, reimbursementID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("预览报销单适用规则失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("预览报销单适用规则失败: %w", err)
+	}
+
+	return preview, nil
+}
+
 // GetAuditByReimbursementID 根据报销单ID获取审核结果用例
 func (s *AuditApplicationService) GetAuditByReimbursementID(ctx context.Context, reimbursementID string) (*response.AuditResultResponse, error) {
 	s.logger.WithContext(ctx).Info("根据报销单ID获取审核结果", logger.NewField("reimbursement_id", reimbursementID))
@@ -91,3 +119,154 @@ func (s *AuditApplicationService) RetryAudit(ctx context.Context, auditID string
 
 	return response.NewAuditResponse(auditResult), nil
 }
+
+// OverrideAudit 人工改判审核结论用例
+func (s *AuditApplicationService) OverrideAudit(ctx context.Context, auditID string, req *request.OverrideAuditRequest) (*response.AuditResponse, error) {
+	s.logger.WithContext(ctx).Info("人工改判审核结论",
+		logger.NewField("audit_id", auditID),
+		logger.NewField("verdict", req.Verdict),
+		logger.NewField("actor", req.Actor))
+
+	auditResult, err := s.auditService.OverrideAudit(ctx, auditID, &audit.OverrideInput{
+		Pass:       req.Verdict == audit.VerdictPass,
+		Reason:     req.Reason,
+		Actor:      req.Actor,
+		ActorID:    req.ActorID,
+		ActorRoles: req.ActorRoles,
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("人工改判审核结论失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("人工改判审核结论失败: %w", err)
+	}
+
+	return response.NewAuditResponse(auditResult), nil
+}
+
+// GetAuditAttestation 生成审核结论签名证明用例
+func (s *AuditApplicationService) GetAuditAttestation(ctx context.Context, auditID string) (*audit.AuditAttestation, error) {
+	s.logger.WithContext(ctx).Info("生成审核证明", logger.NewField("audit_id", auditID))
+
+	attestation, err := s.auditService.AttestAudit(ctx, auditID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("生成审核证明失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("生成审核证明失败: %w", err)
+	}
+
+	return attestation, nil
+}
+
+// ExportAuditReport 导出审核报告用例，format为pdf或xlsx
+func (s *AuditApplicationService) ExportAuditReport(ctx context.Context, auditID, format string) (*audit.AuditReportFile, error) {
+	s.logger.WithContext(ctx).Info("导出审核报告",
+		logger.NewField("audit_id", auditID),
+		logger.NewField("format", format))
+
+	file, err := s.auditService.ExportAuditReport(ctx, auditID, format)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("导出审核报告失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("导出审核报告失败: %w", err)
+	}
+
+	return file, nil
+}
+
+// VerifyAuditAttestation 校验审核证明用例
+func (s *AuditApplicationService) VerifyAuditAttestation(ctx context.Context, req *request.VerifyAttestationRequest) (*audit.AttestationVerification, error) {
+	verification, err := s.auditService.VerifyAttestation(ctx, req.Token)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("校验审核证明失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("校验审核证明失败: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("校验审核证明",
+		logger.NewField("valid", verification.Valid),
+		logger.NewField("reason", verification.Reason))
+	return verification, nil
+}
+
+// ListSLABreaches 查询超出SLA的审核记录用例
+func (s *AuditApplicationService) ListSLABreaches(ctx context.Context, page, size int) (*response.AuditListResponse, error) {
+	s.logger.WithContext(ctx).Info("查询超出SLA的审核记录", logger.NewField("page", page), logger.NewField("size", size))
+
+	auditResults, total, err := s.auditService.ListSLABreaches(ctx, page, size)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询超出SLA的审核记录失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("查询超出SLA的审核记录失败: %w", err)
+	}
+
+	return response.NewAuditListResponse(auditResults, total, page, size), nil
+}
+
+// ListAuditHistory 查询报销单审核历史（含重试记录）用例
+func (s *AuditApplicationService) ListAuditHistory(ctx context.Context, reimbursementID string) (*response.AuditListResponse, error) {
+	s.logger.WithContext(ctx).Info("查询审核历史", logger.NewField("reimbursement_id", reimbursementID))
+
+	auditResults, err := s.auditService.ListAuditHistory(ctx, reimbursementID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询审核历史失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("查询审核历史失败: %w", err)
+	}
+
+	return response.NewAuditListResponse(auditResults, int64(len(auditResults)), 1, len(auditResults)), nil
+}
+
+// ListAudits 按状态、风险等级、日期范围分页查询审核记录用例
+func (s *AuditApplicationService) ListAudits(ctx context.Context, req *request.ListAuditsRequest) (*response.AuditPageResponse, error) {
+	s.logger.WithContext(ctx).Info("查询审核列表",
+		logger.NewField("status", req.Status),
+		logger.NewField("risk_level", req.RiskLevel),
+		logger.NewField("page", req.Page),
+		logger.NewField("size", req.Size))
+
+	startTime, endTime, err := req.TimeRange()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &audit.AuditFilter{
+		Status:    audit.AuditStatus(req.Status),
+		RiskLevel: req.RiskLevel,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Page:      req.Page,
+		Size:      req.Size,
+	}
+
+	auditResults, total, err := s.auditService.ListAudits(ctx, filter)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询审核列表失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("查询审核列表失败: %w", err)
+	}
+
+	return response.NewAuditPageResponse(auditResults, total, filter.Page, filter.Size), nil
+}
+
+// ReauditAffected 规则变更后批量重审受影响报销单用例，返回后台执行的重审批次
+func (s *AuditApplicationService) ReauditAffected(ctx context.Context, ruleID string, req *request.ReauditAffectedRequest) (*audit.ReauditBatch, error) {
+	s.logger.WithContext(ctx).Info("批量重审受规则影响的报销单",
+		logger.NewField("rule_id", ruleID),
+		logger.NewField("lookback_days", req.LookbackDays),
+		logger.NewField("max_items", req.MaxItems))
+
+	batch, err := s.auditService.ReauditAffected(ctx, ruleID, audit.ReauditOptions{
+		Lookback: time.Duration(req.LookbackDays) * 24 * time.Hour,
+		MaxItems: req.MaxItems,
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("批量重审失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("批量重审失败: %w", err)
+	}
+
+	return batch, nil
+}
+
+// GetReauditBatch 查询重审批次进度用例
+func (s *AuditApplicationService) GetReauditBatch(ctx context.Context, batchID string) (*audit.ReauditBatch, error) {
+	batch, err := s.auditService.GetReauditBatch(ctx, batchID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询重审批次失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("查询重审批次失败: %w", err)
+	}
+
+	return batch, nil
+}
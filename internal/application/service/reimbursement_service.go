@@ -4,11 +4,15 @@
 // 2. 协调领域服务和基础设施
 // 3. 处理事务边界
 // 4. 提供用例级别的接口
+// 5. 更新报销单标签，按标签分页查询报销单
+// 6. 发票OCR识别通过任务队列异步执行，支持查询识别进度和重新识别
+// 7. 报销单列表一次批量统计各报销单的发票数量，避免逐单查询发票
 
 package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"mime/multipart"
 	"time"
@@ -30,9 +34,13 @@ type ReimbursementApplicationService struct {
 	ocrService           ocr.InvoiceParser
 	ocrRepo              ocr.Repository
 	fileService          *storage.Service
+	ocrTaskQueue         *ocr.TaskQueue
 	logger               logger.Logger
 }
 
+// errOCRTaskQueueDisabled OCR任务队列未启用
+var errOCRTaskQueueDisabled = errors.New("OCR任务队列未启用")
+
 // NewReimbursementApplicationService 创建报销单应用服务
 func NewReimbursementApplicationService(
 	reimbursementRepo reimbursement.Repository,
@@ -52,6 +60,11 @@ func NewReimbursementApplicationService(
 	}
 }
 
+// SetOCRTaskQueue 设置OCR识别任务队列，未设置时上传后直接启动协程识别
+func (s *ReimbursementApplicationService) SetOCRTaskQueue(queue *ocr.TaskQueue) {
+	s.ocrTaskQueue = queue
+}
+
 // CreateReimbursement 创建报销单用例
 func (s *ReimbursementApplicationService) CreateReimbursement(ctx context.Context, req *request.ReimbursementUploadRequest) (*response.ReimbursementUploadResponse, error) {
 	// 清理和标准化请求数据
@@ -73,6 +86,13 @@ func (s *ReimbursementApplicationService) CreateReimbursement(ctx context.Contex
 		TotalAmount: req.TotalAmount,
 		ApplyDate:   req.ApplyDate,
 		ExpenseDate: req.ExpenseDate,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Tags:        req.Tags,
+
+		IsRecurring:      req.IsRecurring,
+		RecurrencePeriod: req.RecurrencePeriod,
+		RecurrenceKey:    req.RecurrenceKey,
 	}
 
 	// 调用领域服务创建报销单
@@ -82,7 +102,60 @@ func (s *ReimbursementApplicationService) CreateReimbursement(ctx context.Contex
 	}
 
 	// 创建响应数据
-	return response.NewReimbursementUploadResponse(
+	return newReimbursementResponse(reimbursementModel), nil
+}
+
+// UpdateReimbursementTags 更新报销单标签用例
+func (s *ReimbursementApplicationService) UpdateReimbursementTags(ctx context.Context, reimbursementID string, req *request.UpdateReimbursementTagsRequest) (*response.ReimbursementUploadResponse, error) {
+	reimbursementModel, err := s.reimbursementService.UpdateTags(ctx, reimbursementID, req.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("更新报销单标签失败: %w", err)
+	}
+
+	return newReimbursementResponse(reimbursementModel), nil
+}
+
+// ListReimbursements 报销单列表查询用例，支持按用户、状态、部门、标签、申请日期范围和关键词组合筛选
+func (s *ReimbursementApplicationService) ListReimbursements(ctx context.Context, req *request.ListReimbursementsRequest) (*response.ReimbursementPageResponse, error) {
+	filter := &reimbursement.ReimbursementFilter{
+		UserID:     req.UserID,
+		Status:     req.Status,
+		Department: req.Department,
+		Tag:        req.Tag,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+		Keyword:    req.Keyword,
+		SortBy:     req.SortBy,
+		SortOrder:  req.SortOrder,
+		Page:       req.Page,
+		Size:       req.Size,
+	}
+
+	reimbursements, total, err := s.reimbursementService.ListReimbursements(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("查询报销单列表失败: %w", err)
+	}
+
+	// 一次分组查询统计当前页各报销单的发票数量
+	reimbursementIDs := make([]string, 0, len(reimbursements))
+	for _, item := range reimbursements {
+		reimbursementIDs = append(reimbursementIDs, item.ID)
+	}
+	invoiceCounts, err := s.ocrRepo.CountInvoicesByReimbursementIDs(ctx, reimbursementIDs)
+	if err != nil {
+		return nil, fmt.Errorf("统计发票数量失败: %w", err)
+	}
+
+	pageResponse := response.NewReimbursementPageResponse(reimbursements, total, filter.Page, filter.Size)
+	for _, item := range pageResponse.Items {
+		item.InvoiceCount = invoiceCounts[item.ReimbursementID]
+	}
+	return pageResponse, nil
+}
+
+// newReimbursementResponse 将报销单领域模型转换为响应数据
+func newReimbursementResponse(reimbursementModel *reimbursement.Reimbursement) *response.ReimbursementUploadResponse {
+	resp := response.NewReimbursementUploadResponse(
 		reimbursementModel.ID,
 		reimbursementModel.UserID,
 		reimbursementModel.UserName,
@@ -90,11 +163,20 @@ func (s *ReimbursementApplicationService) CreateReimbursement(ctx context.Contex
 		reimbursementModel.TotalAmount,
 		reimbursementModel.Status,
 		reimbursementModel.CreatedAt,
-	), nil
+	)
+	resp.Tags = reimbursementModel.Tags
+	resp.IsRecurring = reimbursementModel.IsRecurring
+	resp.RecurrencePeriod = reimbursementModel.RecurrencePeriod
+	return resp
 }
 
 // UploadInvoice 上传发票用例
 func (s *ReimbursementApplicationService) UploadInvoice(ctx context.Context, reimbursementID string, fileHeader *multipart.FileHeader) (*response.InvoiceUploadResponse, error) {
+	// 校验文件类型和大小，不合规立即拒绝
+	if err := s.fileService.ValidateFile(fileHeader); err != nil {
+		return nil, fmt.Errorf("文件校验失败: %w", err)
+	}
+
 	// 验证报销单是否存在
 	_, err := s.reimbursementRepo.GetReimbursementByID(ctx, reimbursementID)
 	if err != nil {
@@ -124,7 +206,7 @@ func (s *ReimbursementApplicationService) UploadInvoice(ctx context.Context, rei
 	}
 
 	// 异步进行OCR解析
-	go s.processOCRAsync(ctx, invoice.ID)
+	s.enqueueOCR(ctx, invoice.ID)
 
 	// 创建响应数据
 	return response.NewInvoiceUploadResponse(
@@ -156,21 +238,32 @@ func (s *ReimbursementApplicationService) BatchUploadInvoices(ctx context.Contex
 	// 存储成功上传的发票信息
 	var successfulInvoices []*ocr.Invoice
 	var invoiceResponses []response.InvoiceUploadResponse
-	var errors []string
+	fileResults := make([]response.BatchUploadFileResult, 0, len(fileHeaders))
+	failedCount := 0
 
-	// 逐个处理文件上传
-	for _, fileHeader := range fileHeaders {
+	// 逐个处理文件上传，单个文件失败不影响其他文件
+	for index, fileHeader := range fileHeaders {
 		// 类型断言
 		multipartFileHeader, ok := fileHeader.(*multipart.FileHeader)
 		if !ok {
-			errors = append(errors, "文件类型错误")
+			fileResults = append(fileResults, response.NewBatchUploadFileFailure(index, "", "文件类型错误"))
+			failedCount++
+			continue
+		}
+		filename := multipartFileHeader.Filename
+
+		// 校验文件类型和大小
+		if err := s.fileService.ValidateFile(multipartFileHeader); err != nil {
+			fileResults = append(fileResults, response.NewBatchUploadFileFailure(index, filename, err.Error()))
+			failedCount++
 			continue
 		}
 
 		// 上传文件
 		fileInfo, err := s.fileService.UploadInvoice(ctx, multipartFileHeader)
 		if err != nil {
-			errors = append(errors, fmt.Sprintf("上传文件失败: %s", err.Error()))
+			fileResults = append(fileResults, response.NewBatchUploadFileFailure(index, filename, "上传文件失败: "+err.Error()))
+			failedCount++
 			continue
 		}
 
@@ -187,7 +280,8 @@ func (s *ReimbursementApplicationService) BatchUploadInvoices(ctx context.Contex
 
 		// 保存发票记录到数据库
 		if err := s.ocrRepo.CreateInvoice(ctx, invoice); err != nil {
-			errors = append(errors, fmt.Sprintf("保存发票记录失败: %s", err.Error()))
+			fileResults = append(fileResults, response.NewBatchUploadFileFailure(index, filename, "保存发票记录失败: "+err.Error()))
+			failedCount++
 			continue
 		}
 
@@ -199,27 +293,36 @@ func (s *ReimbursementApplicationService) BatchUploadInvoices(ctx context.Contex
 			fileInfo.Size,
 			invoice.Status,
 		))
+		fileResults = append(fileResults, response.NewBatchUploadFileSuccess(index, filename, invoice.ID))
 	}
 
 	// 异步进行批量OCR解析
-	go s.processBatchOCRAsync(ctx, successfulInvoices)
+	if s.ocrTaskQueue != nil {
+		for _, invoice := range successfulInvoices {
+			s.enqueueOCR(ctx, invoice.ID)
+		}
+	} else {
+		go s.processBatchOCRAsync(context.WithoutCancel(ctx), successfulInvoices)
+	}
 
 	// 创建批量上传响应
 	batchResponse := response.NewBatchUploadResponse(
 		batchID,
 		len(fileHeaders),
 		len(successfulInvoices),
-		len(errors),
+		failedCount,
 	)
 
 	// 设置响应数据
-	batchResponse.Invoices = invoiceResponses
-	batchResponse.Errors = errors
+	if invoiceResponses != nil {
+		batchResponse.Invoices = invoiceResponses
+	}
+	batchResponse.Files = fileResults
 
 	return batchResponse, nil
 }
 
-// GetReimbursementDetail 获取报销单详情（包括发票列表）
+// GetReimbursementDetail 获取报销单详情（包括发票列表和按发票类别的金额拆分）
 func (s *ReimbursementApplicationService) GetReimbursementDetail(ctx context.Context, id string) (*reimbursement.Reimbursement, error) {
 	// 获取报销单基本信息
 	reimb, err := s.reimbursementRepo.GetReimbursementByID(ctx, id)
@@ -235,10 +338,78 @@ func (s *ReimbursementApplicationService) GetReimbursementDetail(ctx context.Con
 
 	// 组装完整信息
 	reimb.Invoices = invoices
+	reimb.AmountBreakdown = ocr.BuildAmountBreakdown(invoices)
 
 	return reimb, nil
 }
 
+// GetInvoiceDetail 获取发票详情（包括OCR字段位置框和商品明细）
+func (s *ReimbursementApplicationService) GetInvoiceDetail(ctx context.Context, id string) (*ocr.Invoice, error) {
+	invoice, err := s.ocrRepo.GetInvoiceByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取发票失败: %w", err)
+	}
+
+	items, err := s.ocrRepo.ListInvoiceItems(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取发票商品明细失败: %w", err)
+	}
+	invoice.Items = items
+
+	return invoice, nil
+}
+
+// GetOCRStatus 查询发票OCR识别进度
+func (s *ReimbursementApplicationService) GetOCRStatus(ctx context.Context, invoiceID string) (*response.OCRStatusResponse, error) {
+	invoice, err := s.ocrRepo.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("获取发票失败: %w", err)
+	}
+
+	var task *ocr.Task
+	if s.ocrTaskQueue != nil {
+		task, _ = s.ocrTaskQueue.Status(invoiceID)
+	}
+	return response.NewOCRStatusResponse(invoice.ID, invoice.Status, task), nil
+}
+
+// RetryOCR 重新识别发票，发票正在排队或识别中时返回ocr.ErrTaskInProgress
+func (s *ReimbursementApplicationService) RetryOCR(ctx context.Context, invoiceID string) (*response.OCRStatusResponse, error) {
+	if s.ocrTaskQueue == nil {
+		return nil, errOCRTaskQueueDisabled
+	}
+
+	invoice, err := s.ocrRepo.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("获取发票失败: %w", err)
+	}
+
+	task, err := s.ocrTaskQueue.Enqueue(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("重新识别发票失败: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("发票重新识别任务已入队",
+		logger.NewField("invoice_id", invoiceID),
+		logger.NewField("invoice_status", invoice.Status))
+	return response.NewOCRStatusResponse(invoice.ID, invoice.Status, task), nil
+}
+
+// enqueueOCR 将发票识别任务加入队列，未配置队列时直接启动协程识别
+// 入队失败（如队列已满）时发票保持待识别状态，可稍后通过重新识别接口重试
+func (s *ReimbursementApplicationService) enqueueOCR(ctx context.Context, invoiceID string) {
+	if s.ocrTaskQueue == nil {
+		go s.processOCRAsync(context.WithoutCancel(ctx), invoiceID)
+		return
+	}
+
+	if _, err := s.ocrTaskQueue.Enqueue(ctx, invoiceID); err != nil {
+		s.logger.WithContext(ctx).Warn("OCR识别任务入队失败",
+			logger.NewField("invoice_id", invoiceID),
+			logger.NewField("error", err.Error()))
+	}
+}
+
 // processOCRAsync 异步处理OCR解析
 func (s *ReimbursementApplicationService) processOCRAsync(ctx context.Context, invoiceID string) {
 	if s.ocrService == nil {
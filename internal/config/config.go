@@ -5,11 +5,16 @@
 // 3. 定义大模型API配置结构体
 // 4. 定义存储配置结构体
 // 5. 定义日志配置结构体
-// 6. 提供配置验证方法
+// 6. 提供配置验证方法，汇总返回全部不合法的配置项，缺失必填密钥时报错
 
 package config
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Config 系统配置结构体
 type Config struct {
@@ -22,6 +27,9 @@ type Config struct {
 	Logger   LoggerConfig   `json:"logger" yaml:"logger"`     // 日志配置
 	Security SecurityConfig `json:"security" yaml:"security"` // 安全配置
 	App      AppConfig      `json:"app" yaml:"app"`           // 应用配置
+	Audit    AuditConfig    `json:"audit" yaml:"audit"`       // 审核配置
+	Rule     RuleConfig     `json:"rule" yaml:"rule"`         // 规则引擎配置
+	RAG      RAGConfig      `json:"rag" yaml:"rag"`           // RAG配置
 }
 
 // ServerConfig 服务器配置
@@ -31,6 +39,13 @@ type ServerConfig struct {
 	ReadTimeout  int    `json:"read_timeout" yaml:"read_timeout"`   // 读超时时间(秒)
 	WriteTimeout int    `json:"write_timeout" yaml:"write_timeout"` // 写超时时间(秒)
 	IdleTimeout  int    `json:"idle_timeout" yaml:"idle_timeout"`   // 空闲超时时间(秒)
+
+	CompressionEnabled bool `json:"compression_enabled" yaml:"compression_enabled"`   // 是否按Accept-Encoding对响应进行gzip压缩
+	CompressionMinSize int  `json:"compression_min_size" yaml:"compression_min_size"` // 响应体达到该大小(字节)才压缩，0表示使用默认值1024
+	CompressionLevel   int  `json:"compression_level" yaml:"compression_level"`       // gzip压缩级别(1-9)，0表示使用默认级别
+
+	ReadinessTimeout  int `json:"readiness_timeout" yaml:"readiness_timeout"`     // 就绪检查单个依赖探测的超时时间(毫秒)，0表示使用默认值2000
+	ReadinessCacheTTL int `json:"readiness_cache_ttl" yaml:"readiness_cache_ttl"` // 就绪检查探测结果的缓存时间(秒)，0表示使用默认值5
 }
 
 // DatabaseConfig 数据库配置
@@ -43,6 +58,12 @@ type DatabaseConfig struct {
 	SSLMode      string `json:"sslmode" yaml:"sslmode"`               // SSL模式
 	MaxOpenConns int    `json:"max_open_conns" yaml:"max_open_conns"` // 最大打开连接数
 	MaxIdleConns int    `json:"max_idle_conns" yaml:"max_idle_conns"` // 最大空闲连接数
+
+	Charset         string        `json:"charset" yaml:"charset"`                       // 字符集
+	Collation       string        `json:"collation" yaml:"collation"`                   // 排序规则
+	Loc             string        `json:"loc" yaml:"loc"`                               // 时区
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`   // 连接最大生存时间(如1h)
+	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time" yaml:"conn_max_idle_time"` // 连接最大空闲时间(如10m)
 }
 
 // RedisConfig Redis配置
@@ -55,6 +76,7 @@ type RedisConfig struct {
 
 // LLMConfig 大模型配置
 type LLMConfig struct {
+	Enabled     bool    `json:"enabled" yaml:"enabled"`         // 是否启用，启用时API密钥/基础URL/模型名称必填
 	Provider    string  `json:"provider" yaml:"provider"`       // 提供商(zhipu/wenxin等)
 	APIKey      string  `json:"api_key" yaml:"api_key"`         // API密钥
 	BaseURL     string  `json:"base_url" yaml:"base_url"`       // 基础URL
@@ -66,12 +88,48 @@ type LLMConfig struct {
 
 // OCRConfig OCR配置
 type OCRConfig struct {
-	Provider   string `json:"provider" yaml:"provider"`       // OCR提供商(tencent)
-	SecretID   string `json:"secret_id" yaml:"secret_id"`     // 腾讯云SecretId
-	SecretKey  string `json:"secret_key" yaml:"secret_key"`   // 腾讯云SecretKey
-	Region     string `json:"region" yaml:"region"`           // 腾讯云地域
+	Provider   string `json:"provider" yaml:"provider"`       // OCR提供商(tencent/aliyun)
+	Endpoint   string `json:"endpoint" yaml:"endpoint"`       // 接口地址，为空时按提供商和地域确定
+	SecretID   string `json:"secret_id" yaml:"secret_id"`     // 腾讯云SecretId/阿里云AccessKeyId
+	SecretKey  string `json:"secret_key" yaml:"secret_key"`   // 腾讯云SecretKey/阿里云AccessKeySecret
+	Region     string `json:"region" yaml:"region"`           // 地域(腾讯云如ap-beijing，阿里云如cn-hangzhou)
 	Timeout    int    `json:"timeout" yaml:"timeout"`         // 超时时间(秒)
 	MaxRetries int    `json:"max_retries" yaml:"max_retries"` // 最大重试次数
+
+	UseImageURL     bool `json:"use_image_url" yaml:"use_image_url"`       // URL图片(如对象存储地址)直接传给OCR服务，不在本地下载
+	DownloadTimeout int  `json:"download_timeout" yaml:"download_timeout"` // URL图片下载超时时间(秒)
+	MaxImageSize    int  `json:"max_image_size" yaml:"max_image_size"`     // 图片大小上限(MB)
+
+	PartialRecognition bool     `json:"partial_recognition" yaml:"partial_recognition"` // 非关键字段缺失时标记为部分识别，允许人工补全
+	CriticalFields     []string `json:"critical_fields" yaml:"critical_fields"`         // 关键字段(invoice_code/invoice_number/invoice_date/total_amount)，缺失时判定为无效
+
+	InvoiceFormats []InvoiceFormatConfig `json:"invoice_formats" yaml:"invoice_formats"` // 发票代码/号码格式规则，按顺序匹配，为空时使用内置规则
+
+	Workers     int `json:"workers" yaml:"workers"`           // 识别任务并发worker数量，限制对OCR服务的并发调用
+	QueueSize   int `json:"queue_size" yaml:"queue_size"`     // 识别任务队列容量，队列满时拒绝入队
+	TaskTimeout int `json:"task_timeout" yaml:"task_timeout"` // 单个识别任务超时时间(秒)
+
+	AutoRetry     bool `json:"auto_retry" yaml:"auto_retry"`         // 是否自动重试"解析失败"的发票，最多重试MaxRetries次
+	RetryInterval int  `json:"retry_interval" yaml:"retry_interval"` // 扫描"解析失败"发票的周期(秒)
+	RetryBackoff  int  `json:"retry_backoff" yaml:"retry_backoff"`   // 首次重试前的等待时间(秒)，之后每次翻倍
+
+	ExchangeRates []ExchangeRateConfig `json:"exchange_rates" yaml:"exchange_rates"` // 汇率历史表，外币发票按开票日期生效的汇率折算为人民币
+}
+
+// ExchangeRateConfig 汇率历史记录配置
+type ExchangeRateConfig struct {
+	Currency      string  `json:"currency" yaml:"currency"`             // 币种(ISO 4217，如USD)
+	Rate          float64 `json:"rate" yaml:"rate"`                     // 汇率(1单位外币折合人民币)
+	EffectiveDate string  `json:"effective_date" yaml:"effective_date"` // 生效日期(YYYY-MM-DD)，至同币种下一条记录生效前有效
+}
+
+// InvoiceFormatConfig 发票格式规则配置
+type InvoiceFormatConfig struct {
+	Name          string   `json:"name" yaml:"name"`                     // 规则名称
+	TypeKeywords  []string `json:"type_keywords" yaml:"type_keywords"`   // 发票类型包含任一关键字时适用，为空表示默认规则
+	NumberLengths []int    `json:"number_lengths" yaml:"number_lengths"` // 发票号码允许的位数
+	CodeLengths   []int    `json:"code_lengths" yaml:"code_lengths"`     // 发票代码允许的位数，为空表示不校验位数
+	CodeRequired  bool     `json:"code_required" yaml:"code_required"`   // 是否必须有发票代码
 }
 
 // StorageConfig 存储配置
@@ -79,11 +137,15 @@ type StorageConfig struct {
 	Type  string             `json:"type" yaml:"type"`   // 存储类型(local/minio)
 	Local LocalStorageConfig `json:"local" yaml:"local"` // 本地存储配置
 	MinIO MinIOConfig        `json:"minio" yaml:"minio"` // MinIO存储配置
+
+	MaxFileSize  int      `json:"max_file_size" yaml:"max_file_size"` // 单个上传文件大小上限(MB)，默认10
+	AllowedTypes []string `json:"allowed_types" yaml:"allowed_types"` // 允许上传的文件扩展名，默认jpg/jpeg/png/pdf
 }
 
 // LocalStorageConfig 本地存储配置
 type LocalStorageConfig struct {
-	Path string `json:"path" yaml:"path"` // 存储路径
+	Path    string `json:"path" yaml:"path"`         // 存储路径
+	BaseURL string `json:"base_url" yaml:"base_url"` // 文件访问URL前缀
 }
 
 // MinIOConfig MinIO配置
@@ -93,6 +155,9 @@ type MinIOConfig struct {
 	SecretKey string `json:"secret_key" yaml:"secret_key"` // 秘密密钥
 	Bucket    string `json:"bucket" yaml:"bucket"`         // 存储桶
 	UseSSL    bool   `json:"use_ssl" yaml:"use_ssl"`       // 是否使用SSL
+	Region    string `json:"region" yaml:"region"`         // 地域，可为空
+	PublicURL string `json:"public_url" yaml:"public_url"` // 公开桶访问URL前缀，为空时生成预签名URL
+	URLExpiry int    `json:"url_expiry" yaml:"url_expiry"` // 预签名URL有效期(秒)，默认86400，最长7天
 }
 
 // LoggerConfig 日志配置
@@ -117,6 +182,9 @@ type SecurityConfig struct {
 	KeyFile      string   `json:"key_file" yaml:"key_file"`           // 私钥文件
 	EnableCORS   bool     `json:"enable_cors" yaml:"enable_cors"`     // 是否启用CORS
 	TrustedIPs   []string `json:"trusted_ips" yaml:"trusted_ips"`     // 信任IP列表
+
+	AttestationSecret string `json:"attestation_secret" yaml:"attestation_secret"` // 审核证明签名密钥，为空时不提供审核证明
+	JWTIssuer         string `json:"jwt_issuer" yaml:"jwt_issuer"`                 // JWT签发方，为空时不校验
 }
 
 // AppConfig 应用配置
@@ -128,31 +196,268 @@ type AppConfig struct {
 	TimeZone    string `json:"timezone" yaml:"timezone"`       // 时区
 }
 
-// Validate 验证配置
+// AuditConfig 审核配置
+type AuditConfig struct {
+	NotifyDedupWindow   int             `json:"notify_dedup_window" yaml:"notify_dedup_window"`       // 审核通知去重时间窗口(秒)
+	RiskScoreMode       string          `json:"risk_score_mode" yaml:"risk_score_mode"`               // 风险分数计算模式(hybrid/deterministic)
+	RiskScoreIncludeRAG bool            `json:"risk_score_include_rag" yaml:"risk_score_include_rag"` // 确定性模式下是否叠加RAG分量
+	RiskScoreRAGWeight  float64         `json:"risk_score_rag_weight" yaml:"risk_score_rag_weight"`   // RAG分量权重(0-1)
+	SLAMinutes          int             `json:"sla_minutes" yaml:"sla_minutes"`                       // 提交到审核完成的时效要求(分钟)，0表示不跟踪
+	MaxRetries          int             `json:"max_retries" yaml:"max_retries"`                       // 同一报销单最大连续重试次数
+	ConcurrencyMode     string          `json:"concurrency_mode" yaml:"concurrency_mode"`             // 同一报销单并发审核策略(wait等待/reject拒绝)
+	AllowEmptyRules     bool            `json:"allow_empty_rules" yaml:"allow_empty_rules"`           // 未加载任何规则时是否允许规则校验自动通过
+	RuleRequiredTypes   []string        `json:"rule_required_types" yaml:"rule_required_types"`       // 必须有审核规则的报销类别，为空表示全部类别
+	Risk                RiskConfig      `json:"risk" yaml:"risk"`                                     // 风险权重配置
+	AutoRetry           AutoRetryConfig `json:"auto_retry" yaml:"auto_retry"`                         // 失败审核自动重试配置
+
+	VerdictTemplates VerdictTemplatesConfig `json:"verdict_templates" yaml:"verdict_templates"` // 审核结论措辞模板
+
+	ReportFontPath string `json:"report_font_path" yaml:"report_font_path"` // PDF审核报告内嵌的TrueType字体文件路径(需包含中文字形)，为空时只能导出Excel报告
+}
+
+// VerdictTemplatesConfig 审核结论措辞模板配置
+type VerdictTemplatesConfig struct {
+	Default       VerdictPhrasingConfig            `json:"default" yaml:"default"`               // 默认措辞，为空的项使用内置措辞
+	BusinessUnits map[string]VerdictPhrasingConfig `json:"business_units" yaml:"business_units"` // 按业务单元(部门)覆盖的措辞，为空的项使用默认措辞
+}
+
+// VerdictPhrasingConfig 审核结论措辞，使用text/template语法
+type VerdictPhrasingConfig struct {
+	Pass                 string `json:"pass" yaml:"pass"`                                     // 审核通过结论
+	Reject               string `json:"reject" yaml:"reject"`                                 // 审核未通过结论，{{.Reason}}为未通过原因
+	RuleFailedReason     string `json:"rule_failed_reason" yaml:"rule_failed_reason"`         // 规则校验未通过原因
+	RAGFailedReason      string `json:"rag_failed_reason" yaml:"rag_failed_reason"`           // RAG分析未通过原因
+	PassSuggestion       string `json:"pass_suggestion" yaml:"pass_suggestion"`               // 审核通过时的建议
+	RuleFailedSuggestion string `json:"rule_failed_suggestion" yaml:"rule_failed_suggestion"` // 规则校验未通过时的建议
+	RuleItemSuggestion   string `json:"rule_item_suggestion" yaml:"rule_item_suggestion"`     // 单条未通过规则的建议，{{.RuleName}}/{{.Message}}
+	RAGFailedSuggestion  string `json:"rag_failed_suggestion" yaml:"rag_failed_suggestion"`   // RAG分析未通过时的建议
+	HighRiskSuggestion   string `json:"high_risk_suggestion" yaml:"high_risk_suggestion"`     // 高风险时的建议
+}
+
+// AutoRetryConfig 失败审核自动重试配置
+type AutoRetryConfig struct {
+	Enabled        bool `json:"enabled" yaml:"enabled"`                 // 是否启用自动重试（仅重试限流、超时等临时性失败）
+	MaxAttempts    int  `json:"max_attempts" yaml:"max_attempts"`       // 单个报销单最多审核尝试次数(含首次)
+	InitialBackoff int  `json:"initial_backoff" yaml:"initial_backoff"` // 首次重试前的等待时长(秒)，之后按2倍递增
+	MaxBackoff     int  `json:"max_backoff" yaml:"max_backoff"`         // 单次退避等待时长上限(秒)
+	Interval       int  `json:"interval" yaml:"interval"`               // 扫描失败审核的周期(秒)
+	Lookback       int  `json:"lookback" yaml:"lookback"`               // 只扫描该时长内创建的失败审核(秒)
+}
+
+// RiskConfig 风险权重配置
+type RiskConfig struct {
+	SeverityWeights       map[string]float64 `json:"severity_weights" yaml:"severity_weights"`               // 每条未通过规则按严重程度(高/中/低)累加的分值
+	DefaultSeverityWeight *float64           `json:"default_severity_weight" yaml:"default_severity_weight"` // 未标注严重程度的未通过规则累加的分值，未配置时使用默认值，0表示不计分
+	RAGFailWeight         *float64           `json:"rag_fail_weight" yaml:"rag_fail_weight"`                 // RAG未通过累加的分值，未配置时使用默认值，0表示不计分
+	RAGConfidenceWeight   *float64           `json:"rag_confidence_weight" yaml:"rag_confidence_weight"`     // RAG置信度不足分量的最大分值，未配置时使用默认值，0表示不计分
+	HighThreshold         float64            `json:"high_threshold" yaml:"high_threshold"`                   // 高风险阈值
+	MediumThreshold       float64            `json:"medium_threshold" yaml:"medium_threshold"`               // 中风险阈值
+}
+
+// RuleConfig 规则引擎配置
+type RuleConfig struct {
+	MaxCycle               uint64                  `json:"max_cycle" yaml:"max_cycle"`                               // 规则最大执行周期(防止规则死循环)
+	ExecutionTimeout       int                     `json:"execution_timeout" yaml:"execution_timeout"`               // 单条规则默认执行超时(毫秒)
+	HolidaySource          string                  `json:"holiday_source" yaml:"holiday_source"`                     // 节假日数据源(builtin/config/database)
+	Holidays               []HolidayCalendarConfig `json:"holidays" yaml:"holidays"`                                 // 节假日安排(holiday_source为config时生效)
+	LimitStandards         []LimitStandardConfig   `json:"limit_standards" yaml:"limit_standards"`                   // 限额标准，为空时使用内置标准
+	AmountTolerance        float64                 `json:"amount_tolerance" yaml:"amount_tolerance"`                 // 报销单总额与发票价税合计允许的误差(元)，为0时使用0.01
+	InvoiceMaxAge          int                     `json:"invoice_max_age" yaml:"invoice_max_age"`                   // 开票日期距报销申请日期的最长天数，为0时使用180天
+	OCRConfidenceThreshold float64                 `json:"ocr_confidence_threshold" yaml:"ocr_confidence_threshold"` // 金额、发票号码、税号的最低OCR识别置信度(0-1)，低于该值需人工复核，为0时使用0.8
+}
+
+// LimitStandardConfig 限额标准配置
+type LimitStandardConfig struct {
+	Category      string  `json:"category" yaml:"category"`             // 限额类别(住宿/招待)
+	City          string  `json:"city" yaml:"city"`                     // 城市级别，为空表示所有城市级别
+	Tier          string  `json:"tier" yaml:"tier"`                     // 职级，为空表示所有职级
+	Limit         float64 `json:"limit" yaml:"limit"`                   // 限额(元)
+	EffectiveFrom string  `json:"effective_from" yaml:"effective_from"` // 生效日期(YYYY-MM-DD)，为空表示一直有效
+}
+
+// HolidayCalendarConfig 年度节假日安排配置
+type HolidayCalendarConfig struct {
+	Year     int      `json:"year" yaml:"year"`         // 年份
+	Holidays []string `json:"holidays" yaml:"holidays"` // 法定节假日(YYYY-MM-DD)
+	Workdays []string `json:"workdays" yaml:"workdays"` // 调休上班日(YYYY-MM-DD)
+}
+
+// RAGConfig RAG配置
+type RAGConfig struct {
+	Enabled                 bool     `json:"enabled" yaml:"enabled"`                                     // 是否启用RAG
+	IngestConcurrency       int      `json:"ingest_concurrency" yaml:"ingest_concurrency"`               // 批量导入文档并发数
+	EmbeddingConcurrency    int      `json:"embedding_concurrency" yaml:"embedding_concurrency"`         // 全局向量生成并发数
+	EmbeddingBatchSize      int      `json:"embedding_batch_size" yaml:"embedding_batch_size"`           // 单次向量生成请求的最大文本数，批量导入时跨文档合并分片
+	EmbeddingModel          string   `json:"embedding_model" yaml:"embedding_model"`                     // 嵌入模型名称，为空时使用默认模型
+	EmbeddingCacheEnabled   bool     `json:"embedding_cache_enabled" yaml:"embedding_cache_enabled"`     // 是否缓存向量，相同文本不重复调用向量生成接口
+	EmbeddingCacheBackend   string   `json:"embedding_cache_backend" yaml:"embedding_cache_backend"`     // 向量缓存后端(memory/redis)，redis时使用redis配置
+	EmbeddingCacheSize      int      `json:"embedding_cache_size" yaml:"embedding_cache_size"`           // 内存向量缓存最大条数
+	EmbeddingCacheTTL       int      `json:"embedding_cache_ttl" yaml:"embedding_cache_ttl"`             // Redis向量缓存过期时间(秒)
+	LanguageBoost           float64  `json:"language_boost" yaml:"language_boost"`                       // 与查询语言相同的分片加权分值
+	MinKeywordDensity       float64  `json:"min_keyword_density" yaml:"min_keyword_density"`             // 关键词检索结果的最低关键词密度，低于该值的弱命中不参与融合
+	ContextWindow           int      `json:"context_window" yaml:"context_window"`                       // 模型上下文窗口大小(Token)，发送前校验请求大小
+	QueryCacheEnabled       bool     `json:"query_cache_enabled" yaml:"query_cache_enabled"`             // 是否缓存查询结果
+	QueryCacheTTL           int      `json:"query_cache_ttl" yaml:"query_cache_ttl"`                     // 查询缓存过期时间(秒)
+	QueryCacheSize          int      `json:"query_cache_size" yaml:"query_cache_size"`                   // 查询缓存最大条数
+	QueryCacheBackend       string   `json:"query_cache_backend" yaml:"query_cache_backend"`             // 查询缓存后端(memory/redis)，redis时使用redis配置，多实例共享缓存
+	SelfTest                bool     `json:"self_test" yaml:"self_test"`                                 // 启动时是否执行金丝雀自检
+	SelfTestTimeout         int      `json:"self_test_timeout" yaml:"self_test_timeout"`                 // 金丝雀自检超时时间(秒)
+	EmbeddingFields         []string `json:"embedding_fields" yaml:"embedding_fields"`                   // 允许写入向量查询的报销字段，未配置时使用默认字段
+	EmbeddingRedactedFields []string `json:"embedding_redacted_fields" yaml:"embedding_redacted_fields"` // 始终不写入向量查询的个人信息字段，优先于embedding_fields
+
+	VectorIndexName             string              `json:"vector_index_name" yaml:"vector_index_name"`                           // 向量索引名称
+	VectorIndexRebuildThreshold int                 `json:"vector_index_rebuild_threshold" yaml:"vector_index_rebuild_threshold"` // 累计导入多少个分片后在后台按vector_index_method重建向量索引，0表示不自动重建
+	VectorDSN                   string              `json:"vector_dsn" yaml:"vector_dsn"`                                         // pgvector数据库连接串，配置后migrate工具的up同时迁移向量库
+	VectorIndexMethod           string              `json:"vector_index_method" yaml:"vector_index_method"`                       // 向量索引类型(hnsw/ivfflat)，默认hnsw
+	VectorIndexLists            int                 `json:"vector_index_lists" yaml:"vector_index_lists"`                         // IVFFlat聚类中心数，0表示使用默认值100
+	VectorIndexM                int                 `json:"vector_index_m" yaml:"vector_index_m"`                                 // HNSW每个节点的最大连接数，0表示使用默认值16
+	VectorIndexEfConstruction   int                 `json:"vector_index_ef_construction" yaml:"vector_index_ef_construction"`     // HNSW建索引时的候选列表大小，0表示使用默认值64
+	CategoryKeywords            map[string][]string `json:"category_keywords" yaml:"category_keywords"`                           // 导入制度文档时推断分片类别的关键词(类别→关键词)，未配置时使用默认关键词
+	AuditTopK                   int                 `json:"audit_top_k" yaml:"audit_top_k"`                                       // 审核时检索的制度分片数，0表示使用默认值5
+	CategoryTopK                map[string]int      `json:"category_top_k" yaml:"category_top_k"`                                 // 按报销类别覆盖审核检索分片数(类别→分片数)，未配置的类别使用audit_top_k
+	PromptGuardEnabled          bool                `json:"prompt_guard_enabled" yaml:"prompt_guard_enabled"`                     // 是否清洗检索内容并在系统提示词中追加防护条款，防止制度文档中的提示词注入
+	PromptGuardPatterns         []string            `json:"prompt_guard_patterns" yaml:"prompt_guard_patterns"`                   // 疑似注入指令的正则表达式，未配置时使用内置规则
+	AuditPromptMaxInvoices      int                 `json:"audit_prompt_max_invoices" yaml:"audit_prompt_max_invoices"`           // 审核提示词中最多逐张列出的发票数，超出时只列出金额最高的发票并汇总其余发票，0表示使用默认值20
+}
+
+// 配置项允许的取值
+var (
+	allowedLogLevels    = []string{"debug", "info", "warn", "error", "fatal"}
+	allowedStorageTypes = []string{"local", "minio"}
+)
+
+// Validate 验证配置，返回全部不合法的配置项
 func (c *Config) Validate() error {
 	if c == nil {
 		return fmt.Errorf("配置不能为空")
 	}
 
-	// 验证服务器配置
-	if c.Server.Host == "" {
-		return fmt.Errorf("服务器主机不能为空")
+	return errors.Join(
+		c.Server.Validate(),
+		c.Database.Validate(),
+		c.LLM.Validate(),
+		c.Storage.Validate(),
+		c.Logger.Validate(),
+		c.validateSecrets(),
+	)
+}
+
+// Validate 验证服务器配置
+func (c ServerConfig) Validate() error {
+	var errs []error
+	if strings.TrimSpace(c.Host) == "" {
+		errs = append(errs, errors.New("服务器主机(server.host)不能为空"))
+	}
+	if !isValidPort(c.Port) {
+		errs = append(errs, fmt.Errorf("服务器端口(server.port)必须在1-65535范围内: %d", c.Port))
+	}
+	if c.CompressionMinSize < 0 {
+		errs = append(errs, fmt.Errorf("最小压缩大小(server.compression_min_size)不能为负数: %d", c.CompressionMinSize))
+	}
+	if c.CompressionLevel < 0 || c.CompressionLevel > 9 {
+		errs = append(errs, fmt.Errorf("压缩级别(server.compression_level)必须在0-9范围内: %d", c.CompressionLevel))
+	}
+	if c.ReadinessTimeout < 0 {
+		errs = append(errs, fmt.Errorf("就绪检查超时时间(server.readiness_timeout)不能为负数: %d", c.ReadinessTimeout))
+	}
+	if c.ReadinessCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("就绪检查缓存时间(server.readiness_cache_ttl)不能为负数: %d", c.ReadinessCacheTTL))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 验证数据库配置
+func (c DatabaseConfig) Validate() error {
+	var errs []error
+	if strings.TrimSpace(c.Host) == "" {
+		errs = append(errs, errors.New("数据库主机(database.host)不能为空"))
+	}
+	if !isValidPort(c.Port) {
+		errs = append(errs, fmt.Errorf("数据库端口(database.port)必须在1-65535范围内: %d", c.Port))
+	}
+	if strings.TrimSpace(c.DBName) == "" {
+		errs = append(errs, errors.New("数据库名(database.dbname)不能为空"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 验证大模型配置，未启用时不校验
+func (c LLMConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if strings.TrimSpace(c.APIKey) == "" {
+		errs = append(errs, errors.New("启用大模型时API密钥(llm.api_key)不能为空"))
 	}
-	if c.Server.Port <= 0 || c.Server.Port > 65535 {
-		return fmt.Errorf("服务器端口必须在1-65535范围内")
+	if strings.TrimSpace(c.BaseURL) == "" {
+		errs = append(errs, errors.New("启用大模型时基础URL(llm.base_url)不能为空"))
 	}
+	if strings.TrimSpace(c.Model) == "" {
+		errs = append(errs, errors.New("启用大模型时模型名称(llm.model)不能为空"))
+	}
+	return errors.Join(errs...)
+}
 
+// Validate 验证存储配置，存储类型为minio时MinIO配置须齐全
+func (c StorageConfig) Validate() error {
+	var errs []error
+	switch {
+	case c.Type == "" || strings.EqualFold(c.Type, "local"):
+		if strings.TrimSpace(c.Local.Path) == "" {
+			errs = append(errs, errors.New("本地存储路径(storage.local.path)不能为空"))
+		}
+	case strings.EqualFold(c.Type, "minio"):
+		required := []struct {
+			key   string
+			value string
+		}{
+			{"storage.minio.endpoint", c.MinIO.Endpoint},
+			{"storage.minio.access_key", c.MinIO.AccessKey},
+			{"storage.minio.secret_key", c.MinIO.SecretKey},
+			{"storage.minio.bucket", c.MinIO.Bucket},
+		}
+		for _, field := range required {
+			if strings.TrimSpace(field.value) == "" {
+				errs = append(errs, fmt.Errorf("存储类型为minio时%s不能为空", field.key))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("存储类型(storage.type)不支持: %s（可选: %s）", c.Type, strings.Join(allowedStorageTypes, "/")))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 验证日志配置，日志级别为空时使用info
+func (c LoggerConfig) Validate() error {
+	if c.Level != "" && !containsFold(allowedLogLevels, c.Level) {
+		return fmt.Errorf("日志级别(logger.level)不合法: %s（可选: %s）", c.Level, strings.Join(allowedLogLevels, "/"))
+	}
 	return nil
 }
 
+// isValidPort 端口是否在1-65535范围内
+func isValidPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// containsFold 忽略大小写判断切片是否包含value
+func containsFold(values []string, value string) bool {
+	for _, item := range values {
+		if strings.EqualFold(item, strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsProduction 是否为生产环境
 func (c *Config) IsProduction() bool {
-	// TODO: 实现环境判断逻辑
-	return false
+	return strings.EqualFold(c.App.Environment, "production")
 }
 
 // IsDevelopment 是否为开发环境
 func (c *Config) IsDevelopment() bool {
-	// TODO: 实现环境判断逻辑
-	return false
+	return strings.EqualFold(c.App.Environment, "development")
 }
@@ -0,0 +1,122 @@
+// env.go 环境变量占位符与密钥覆盖
+// 功能点：
+// 1. 加载时将配置中的${ENV_VAR}占位符替换为环境变量，支持${ENV_VAR:-默认值}
+// 2. 敏感配置项（大模型API密钥、OCR密钥、数据库密码、JWT密钥）可被同名环境变量直接覆盖
+// 3. 校验密钥配置：占位符引用的环境变量未设置或生产环境缺失密钥时报错
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// envPlaceholderPattern 环境变量占位符，${NAME}或${NAME:-默认值}
+var envPlaceholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// secretField 可被环境变量覆盖的敏感配置项
+type secretField struct {
+	key    string                  // 配置项路径
+	envKey string                  // 覆盖该配置项的环境变量
+	value  func(c *Config) *string // 配置项取值
+}
+
+// secretFields 敏感配置项，环境变量名与配置项路径同名(如llm.api_key对应LLM_API_KEY)
+var secretFields = []secretField{
+	{"llm.api_key", "LLM_API_KEY", func(c *Config) *string { return &c.LLM.APIKey }},
+	{"ocr.secret_key", "OCR_SECRET_KEY", func(c *Config) *string { return &c.OCR.SecretKey }},
+	{"database.password", "DATABASE_PASSWORD", func(c *Config) *string { return &c.Database.Password }},
+	{"security.jwt_secret", "SECURITY_JWT_SECRET", func(c *Config) *string { return &c.Security.JWTSecret }},
+}
+
+// expandEnvPlaceholders 替换配置中全部字符串字段的环境变量占位符
+// 环境变量未设置且没有默认值时保留占位符，由Validate报告缺失
+func expandEnvPlaceholders(config *Config) {
+	if config == nil {
+		return
+	}
+	expandValue(reflect.ValueOf(config).Elem())
+}
+
+// expandValue 递归替换结构体、切片、映射中的字符串
+func expandValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(expandEnvString(v.String()))
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			expandValue(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				expandValue(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			expandValue(v.Index(i))
+		}
+	case reflect.Map:
+		// 映射的值不可寻址，复制后替换再写回
+		for _, key := range v.MapKeys() {
+			item := reflect.New(v.Type().Elem()).Elem()
+			item.Set(v.MapIndex(key))
+			expandValue(item)
+			v.SetMapIndex(key, item)
+		}
+	}
+}
+
+// expandEnvString 替换字符串中的环境变量占位符
+func expandEnvString(value string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	return envPlaceholderPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+		match := envPlaceholderPattern.FindStringSubmatch(placeholder)
+		if env, ok := os.LookupEnv(match[1]); ok {
+			return env
+		}
+		if strings.Contains(placeholder, ":-") {
+			return match[2]
+		}
+		return placeholder
+	})
+}
+
+// applySecretEnv 使用同名环境变量覆盖敏感配置项
+func applySecretEnv(config *Config) {
+	for _, field := range secretFields {
+		if value := os.Getenv(field.envKey); value != "" {
+			*field.value(config) = value
+		}
+	}
+}
+
+// validateSecrets 校验敏感配置项
+// 占位符引用的环境变量未设置时报错；生产环境下敏感配置项均不能为空
+func (c *Config) validateSecrets() error {
+	var errs []error
+	for _, field := range secretFields {
+		value := *field.value(c)
+		if match := envPlaceholderPattern.FindStringSubmatch(value); match != nil {
+			if match[1] == field.envKey {
+				errs = append(errs, fmt.Errorf("配置项%s引用的环境变量%s未设置", field.key, match[1]))
+			} else {
+				errs = append(errs, fmt.Errorf("配置项%s引用的环境变量%s未设置（也可通过环境变量%s直接设置）", field.key, match[1], field.envKey))
+			}
+			continue
+		}
+		if c.IsProduction() && strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("生产环境必须配置%s（可通过环境变量%s设置）", field.key, field.envKey))
+		}
+	}
+	return errors.Join(errs...)
+}
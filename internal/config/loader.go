@@ -6,6 +6,7 @@
 // 4. 提供配置热重载功能
 // 5. 提供配置项获取方法
 // 6. 支持配置项默认值设置
+// 7. 支持${ENV_VAR}占位符，敏感配置项可被同名环境变量覆盖
 
 package config
 
@@ -70,6 +71,9 @@ func (l *Loader) LoadFromYAML() (*Config, error) {
 		return nil, fmt.Errorf("解析YAML配置失败: %w", err)
 	}
 
+	// 替换${ENV_VAR}占位符，密钥只需在yaml中保留占位符
+	expandEnvPlaceholders(config)
+
 	return config, nil
 }
 
@@ -93,13 +97,13 @@ func (l *Loader) LoadFromEnv(config *Config) *Config {
 	if secretID := os.Getenv("OCR_SECRET_ID"); secretID != "" {
 		config.OCR.SecretID = secretID
 	}
-	if secretKey := os.Getenv("OCR_SECRET_KEY"); secretKey != "" {
-		config.OCR.SecretKey = secretKey
-	}
 	if region := os.Getenv("OCR_REGION"); region != "" {
 		config.OCR.Region = region
 	}
 
+	// 敏感配置项（LLM_API_KEY/OCR_SECRET_KEY/DATABASE_PASSWORD/SECURITY_JWT_SECRET）
+	applySecretEnv(config)
+
 	return config
 }
 
@@ -206,7 +210,7 @@ func (l *Loader) getDefaultConfig() *Config {
 		},
 		Database: DatabaseConfig{
 			Host:   "localhost",
-			Port:   5432,
+			Port:   3306,
 			DBName: "default",
 		},
 		Redis: RedisConfig{
@@ -0,0 +1,81 @@
+// applicable_rules.go 审核前预览适用规则
+// 功能点：
+// 1. 按报销单的类别和申请日期列出审核时将执行的规则，不实际执行规则
+// 2. 规则按执行顺序（优先级从高到低）排列，与StartAudit执行的规则一致
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"reimbursement-audit/internal/domain/rule"
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// ApplicableRule 适用规则摘要
+type ApplicableRule struct {
+	Order         int        `json:"order"`          // 执行顺序(从1开始)
+	ID            string     `json:"id"`             // 规则ID
+	RuleCode      string     `json:"rule_code"`      // 规则编码
+	Name          string     `json:"name"`           // 规则名称
+	Type          string     `json:"type"`           // 规则类型
+	Category      string     `json:"category"`       // 规则分类
+	Priority      int        `json:"priority"`       // 优先级
+	EffectiveFrom *time.Time `json:"effective_from"` // 生效开始日期
+	EffectiveTo   *time.Time `json:"effective_to"`   // 生效结束日期
+}
+
+// ApplicableRulesPreview 报销单适用规则预览
+type ApplicableRulesPreview struct {
+	ReimbursementID string            `json:"reimbursement_id"` // 报销单ID
+	Category        string            `json:"category"`         // 报销类别
+	ApplyDate       time.Time         `json:"apply_date"`       // 申请日期，按该日期判断规则是否生效
+	RuleCount       int               `json:"rule_count"`       // 适用规则数量
+	Rules           []*ApplicableRule `json:"rules"`            // 按执行顺序排列的适用规则
+}
+
+// PreviewApplicableRules 预览报销单审核时将执行的规则，不执行规则
+func (s *Service) PreviewApplicableRules(ctx context.Context, reimbursementID string) (*ApplicableRulesPreview, error) {
+	reimbursement, err := s.reimbursementRepo.GetReimbursementByID(ctx, reimbursementID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取报销单失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取报销单失败: %w", err)
+	}
+
+	rules, err := s.ruleService.ListApplicableRules(ctx, reimbursement.Type, reimbursement.ApplyDate)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取适用规则失败",
+			logger.NewField("reimbursement_id", reimbursementID),
+			logger.NewField("error", err))
+		return nil, fmt.Errorf("获取适用规则失败: %w", err)
+	}
+
+	return newApplicableRulesPreview(reimbursementID, reimbursement.Type, reimbursement.ApplyDate, rules), nil
+}
+
+// newApplicableRulesPreview 构建适用规则预览，rules需已按执行顺序排列
+func newApplicableRulesPreview(reimbursementID, category string, applyDate time.Time, rules []*rule.Rule) *ApplicableRulesPreview {
+	preview := &ApplicableRulesPreview{
+		ReimbursementID: reimbursementID,
+		Category:        category,
+		ApplyDate:       applyDate,
+		RuleCount:       len(rules),
+		Rules:           make([]*ApplicableRule, 0, len(rules)),
+	}
+	for i, r := range rules {
+		preview.Rules = append(preview.Rules, &ApplicableRule{
+			Order:         i + 1,
+			ID:            r.ID,
+			RuleCode:      r.RuleCode,
+			Name:          r.Name,
+			Type:          r.Type,
+			Category:      r.Category,
+			Priority:      r.Priority,
+			EffectiveFrom: r.EffectiveFrom,
+			EffectiveTo:   r.EffectiveTo,
+		})
+	}
+	return preview
+}
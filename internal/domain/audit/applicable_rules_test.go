@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/domain/rule"
+)
+
+func TestPreviewApplicableRules(t *testing.T) {
+	date := func(month, day int) time.Time { return time.Date(2026, time.Month(month), day, 0, 0, 0, 0, time.Local) }
+	expiredAt, effectiveFrom := date(6, 30), date(9, 1)
+	ruleRepo := newMemRuleRepo(
+		&rule.Rule{ID: "travel-hotel", Name: "住宿费标准", Category: "差旅费", Priority: 5, Enabled: true},
+		&rule.Rule{ID: "travel-old", Name: "旧版差旅标准", Category: "差旅费", Priority: 9, Enabled: true, EffectiveTo: &expiredAt},
+		&rule.Rule{ID: "travel-new", Name: "新版差旅标准", Category: "差旅费", Priority: 3, Enabled: true, EffectiveFrom: &effectiveFrom},
+		&rule.Rule{ID: "travel-disabled", Name: "已停用差旅规则", Category: "差旅费", Priority: 10, Enabled: false},
+		&rule.Rule{ID: "office-limit", Name: "办公用品限额", Category: "办公费", Priority: 7, Enabled: true},
+		&rule.Rule{ID: "invoice-title", Name: "发票抬头", Category: "发票", Priority: 8, Enabled: true},
+		&rule.Rule{ID: "generic", Name: "通用规则", Priority: 1, Enabled: true},
+	)
+
+	tests := []struct {
+		name      string
+		category  string
+		applyDate time.Time
+		want      []string // 按执行顺序的规则ID
+	}{
+		{name: "差旅费使用申请日期生效的规则", category: "差旅费", applyDate: date(10, 16), want: []string{"invoice-title", "travel-hotel", "travel-new", "generic"}},
+		{name: "差旅费申请日期早于新规生效", category: "差旅费", applyDate: date(5, 1), want: []string{"travel-old", "invoice-title", "travel-hotel", "generic"}},
+		{name: "生效日期当天适用", category: "差旅费", applyDate: date(9, 1), want: []string{"invoice-title", "travel-hotel", "travel-new", "generic"}},
+		{name: "办公费不包含差旅费规则", category: "办公费", applyDate: date(10, 16), want: []string{"invoice-title", "office-limit", "generic"}},
+		{name: "无专属规则的类别只适用通用规则", category: "招待费", applyDate: date(10, 16), want: []string{"invoice-title", "generic"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.putReimbursement(&reimbursement.Reimbursement{ID: "r1", Type: tt.category, ApplyDate: tt.applyDate, Status: reimbursement.StatusPending})
+			service := newPipelineService(t, store, ruleRepo)
+
+			preview, err := service.PreviewApplicableRules(context.Background(), "r1")
+			if err != nil {
+				t.Fatalf("PreviewApplicableRules() error = %v", err)
+			}
+			ids := make([]string, 0, len(preview.Rules))
+			for i, r := range preview.Rules {
+				if r.Order != i+1 {
+					t.Errorf("规则%s Order = %d, want %d", r.ID, r.Order, i+1)
+				}
+				ids = append(ids, r.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) || preview.RuleCount != len(tt.want) {
+				t.Errorf("适用规则 = %q(RuleCount %d), want %q", ids, preview.RuleCount, tt.want)
+			}
+			if preview.Category != tt.category || !preview.ApplyDate.Equal(tt.applyDate) {
+				t.Errorf("Category/ApplyDate = %s/%s, want %s/%s", preview.Category, preview.ApplyDate, tt.category, tt.applyDate)
+			}
+			// 预览不执行规则，不产生审核记录
+			if audits, _, _ := (&memAuditRepo{store}).ListAudits(context.Background(), nil); len(audits) != 0 {
+				t.Errorf("预览产生了%d条审核记录", len(audits))
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+// attestation.go 审核结论签名证明
+// 功能点：
+// 1. 为已完成的审核生成防篡改的签名证明(JWS紧凑格式，HMAC-SHA256签名)
+// 2. 证明包含报销单ID、审核结论、签发时间和审核内容哈希
+// 3. 校验证明签名，并与当前审核记录的内容哈希比对，发现证明或审核记录被篡改
+// 4. 人工改判后证明的审核结论为改判结论，改判信息纳入内容哈希
+
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/pkg/errs"
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// AttestationAlgorithm 审核证明签名算法
+const AttestationAlgorithm = "HS256"
+
+// 审核结论
+const (
+	VerdictPass   = "通过"
+	VerdictReject = "未通过"
+)
+
+var (
+	// ErrAttestationUnavailable 未配置审核证明签名密钥
+	ErrAttestationUnavailable = errors.New("未配置审核证明签名密钥")
+	// ErrAuditNotAttestable 审核未完成，无法生成证明
+	ErrAuditNotAttestable = errors.New("审核未完成，无法生成证明")
+	// ErrInvalidAttestation 审核证明格式或签名不合法
+	ErrInvalidAttestation = errors.New("审核证明不合法")
+)
+
+// attestationHeader JWS头部
+var attestationHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// AttestationClaims 审核证明内容
+type AttestationClaims struct {
+	AuditID         string    `json:"audit_id"`         // 审核ID
+	ReimbursementID string    `json:"reimbursement_id"` // 报销单ID
+	Verdict         string    `json:"verdict"`          // 审核结论(通过/未通过)
+	RiskLevel       string    `json:"risk_level"`       // 风险等级
+	ContentHash     string    `json:"content_hash"`     // 审核内容SHA-256哈希
+	CompletedAt     time.Time `json:"completed_at"`     // 审核完成时间
+	IssuedAt        time.Time `json:"issued_at"`        // 证明签发时间
+}
+
+// AuditAttestation 审核证明
+type AuditAttestation struct {
+	AttestationClaims
+	Algorithm string `json:"algorithm"` // 签名算法
+	Token     string `json:"token"`     // JWS紧凑格式的签名证明
+}
+
+// AttestationVerification 审核证明校验结果
+type AttestationVerification struct {
+	Valid  bool               `json:"valid"`            // 证明是否有效
+	Claims *AttestationClaims `json:"claims,omitempty"` // 签名有效时的证明内容
+	Reason string             `json:"reason,omitempty"` // 无效原因
+}
+
+// AttestationSigner 审核证明签名器
+type AttestationSigner struct {
+	secret []byte
+}
+
+// NewAttestationSigner 创建审核证明签名器，密钥不能为空
+func NewAttestationSigner(secret string) (*AttestationSigner, error) {
+	if strings.TrimSpace(secret) == "" {
+		return nil, ErrAttestationUnavailable
+	}
+	return &AttestationSigner{secret: []byte(secret)}, nil
+}
+
+// Sign 签名证明内容，返回JWS紧凑格式
+func (s *AttestationSigner) Sign(claims *AttestationClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("序列化审核证明失败: %w", err)
+	}
+	signingInput := attestationHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + s.signature(signingInput), nil
+}
+
+// Verify 校验证明签名并解析证明内容
+func (s *AttestationSigner) Verify(token string) (*AttestationClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: 格式错误", ErrInvalidAttestation)
+	}
+	// 只接受固定的头部，防止篡改签名算法
+	if parts[0] != attestationHeader {
+		return nil, fmt.Errorf("%w: 不支持的签名算法", ErrInvalidAttestation)
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.signature(signingInput))) {
+		return nil, fmt.Errorf("%w: 签名校验失败", ErrInvalidAttestation)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: 内容解码失败", ErrInvalidAttestation)
+	}
+	var claims AttestationClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: 内容解析失败", ErrInvalidAttestation)
+	}
+	return &claims, nil
+}
+
+// signature 计算HMAC-SHA256签名
+func (s *AttestationSigner) signature(signingInput string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// attestationContent 参与内容哈希的审核字段，审核记录中任一字段被修改都会导致哈希变化
+type attestationContent struct {
+	AuditID         string                  `json:"audit_id"`
+	ReimbursementID string                  `json:"reimbursement_id"`
+	Status          AuditStatus             `json:"status"`
+	RulePass        bool                    `json:"rule_pass"`
+	RAGPass         bool                    `json:"rag_pass"`
+	FinalPass       bool                    `json:"final_pass"`
+	RiskLevel       string                  `json:"risk_level"`
+	RiskScore       float64                 `json:"risk_score"`
+	Reason          string                  `json:"reason"`
+	Suggestions     []string                `json:"suggestions"`
+	RuleResults     []*RuleValidationResult `json:"rule_results"`
+	CompletedAt     string                  `json:"completed_at"`
+	Override        *AuditOverride          `json:"override,omitempty"`
+}
+
+// AuditContentHash 计算审核结论内容的SHA-256哈希
+// 未改判的审核不包含改判信息，哈希与改判功能上线前一致
+func AuditContentHash(audit *AuditResult) (string, error) {
+	completedAt := ""
+	if audit.CompletedAt != nil {
+		completedAt = audit.CompletedAt.UTC().Format(time.RFC3339Nano)
+	}
+	var override *AuditOverride
+	if audit.Overridden {
+		override = &AuditOverride{
+			Pass:   audit.OverridePass,
+			Reason: audit.OverrideReason,
+			Actor:  audit.OverriddenBy,
+		}
+		if audit.OverriddenAt != nil {
+			override.CreatedAt = audit.OverriddenAt.UTC()
+		}
+	}
+	data, err := json.Marshal(&attestationContent{
+		AuditID:         audit.ID,
+		ReimbursementID: audit.ReimbursementID,
+		Status:          audit.Status,
+		RulePass:        audit.RulePass,
+		RAGPass:         audit.RAGPass,
+		FinalPass:       audit.FinalPass,
+		RiskLevel:       audit.RiskLevel,
+		RiskScore:       audit.RiskScore,
+		Reason:          audit.Reason,
+		Suggestions:     audit.Suggestions,
+		RuleResults:     audit.RuleResults,
+		CompletedAt:     completedAt,
+		Override:        override,
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化审核内容失败: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// auditVerdict 获取审核的最终结论，有人工改判时为改判结论
+func auditVerdict(audit *AuditResult) string {
+	if audit.EffectivePass() {
+		return VerdictPass
+	}
+	return VerdictReject
+}
+
+// NewAuditAttestation 为已完成的审核生成签名证明
+func NewAuditAttestation(signer *AttestationSigner, audit *AuditResult, issuedAt time.Time) (*AuditAttestation, error) {
+	if signer == nil {
+		return nil, ErrAttestationUnavailable
+	}
+	if audit.Status != AuditStatusCompleted || audit.CompletedAt == nil {
+		return nil, fmt.Errorf("%w: 当前状态为%s", ErrAuditNotAttestable, audit.Status)
+	}
+
+	contentHash, err := AuditContentHash(audit)
+	if err != nil {
+		return nil, err
+	}
+	claims := AttestationClaims{
+		AuditID:         audit.ID,
+		ReimbursementID: audit.ReimbursementID,
+		Verdict:         auditVerdict(audit),
+		RiskLevel:       audit.RiskLevel,
+		ContentHash:     contentHash,
+		CompletedAt:     audit.CompletedAt.UTC(),
+		IssuedAt:        issuedAt.UTC(),
+	}
+	token, err := signer.Sign(&claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditAttestation{
+		AttestationClaims: claims,
+		Algorithm:         AttestationAlgorithm,
+		Token:             token,
+	}, nil
+}
+
+// VerifyAuditAttestation 校验证明签名，并比对证明与审核记录的内容哈希
+// audit为nil时只校验签名
+func VerifyAuditAttestation(signer *AttestationSigner, token string, audit *AuditResult) (*AttestationVerification, error) {
+	if signer == nil {
+		return nil, ErrAttestationUnavailable
+	}
+
+	claims, err := signer.Verify(token)
+	if err != nil {
+		return &AttestationVerification{Valid: false, Reason: err.Error()}, nil
+	}
+	if audit == nil {
+		return &AttestationVerification{Valid: true, Claims: claims}, nil
+	}
+
+	if audit.ID != claims.AuditID {
+		return &AttestationVerification{Valid: false, Claims: claims, Reason: "证明与审核记录不匹配"}, nil
+	}
+	contentHash, err := AuditContentHash(audit)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(contentHash), []byte(claims.ContentHash)) {
+		return &AttestationVerification{Valid: false, Claims: claims, Reason: "审核记录内容与证明不一致，可能已被篡改"}, nil
+	}
+
+	return &AttestationVerification{Valid: true, Claims: claims}, nil
+}
+
+// SetAttestationSigner 设置审核证明签名器，未设置时无法生成和校验证明
+func (s *Service) SetAttestationSigner(signer *AttestationSigner) {
+	s.attestationSigner = signer
+}
+
+// AttestAudit 为审核结论生成签名证明
+func (s *Service) AttestAudit(ctx context.Context, auditID string) (*AuditAttestation, error) {
+	audit, err := s.repo.GetAuditByID(ctx, auditID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取审核记录失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取审核记录失败: %w", err)
+	}
+
+	attestation, err := NewAuditAttestation(s.attestationSigner, audit, time.Now())
+	if err != nil {
+		s.logger.WithContext(ctx).Error("生成审核证明失败",
+			logger.NewField("audit_id", auditID),
+			logger.NewField("error", err))
+		return nil, err
+	}
+	return attestation, nil
+}
+
+// VerifyAttestation 校验审核证明，签名有效时再与当前审核记录比对内容哈希
+func (s *Service) VerifyAttestation(ctx context.Context, token string) (*AttestationVerification, error) {
+	if s.attestationSigner == nil {
+		return nil, ErrAttestationUnavailable
+	}
+
+	claims, err := s.attestationSigner.Verify(token)
+	if err != nil {
+		return &AttestationVerification{Valid: false, Reason: err.Error()}, nil
+	}
+
+	audit, err := s.repo.GetAuditByID(ctx, claims.AuditID)
+	if err != nil {
+		if errs.IsNotFound(err) {
+			return &AttestationVerification{Valid: false, Claims: claims, Reason: "审核记录不存在"}, nil
+		}
+		s.logger.WithContext(ctx).Error("获取审核记录失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取审核记录失败: %w", err)
+	}
+
+	return VerifyAuditAttestation(s.attestationSigner, token, audit)
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// attestedAudit 生成一条已完成且经人工改判的审核记录
+func attestedAudit() *AuditResult {
+	completedAt := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	overriddenAt := completedAt.Add(time.Hour)
+	return &AuditResult{
+		ID:              "a1",
+		ReimbursementID: "r1",
+		Status:          AuditStatusCompleted,
+		RulePass:        false,
+		RAGPass:         true,
+		FinalPass:       false,
+		RiskLevel:       "中",
+		RiskScore:       45,
+		Reason:          "住宿费超出标准",
+		Suggestions:     []string{"请补充超标说明"},
+		RuleResults: []*RuleValidationResult{
+			{RuleID: "RULE_001", RuleName: "住宿费标准", Severity: SeverityHigh, Passed: false, Message: "超出标准200元"},
+			{RuleID: "RULE_002", RuleName: "发票抬头", Severity: "低", Passed: true},
+		},
+		CompletedAt:    &completedAt,
+		Overridden:     true,
+		OverridePass:   true,
+		OverrideReason: "经部门负责人审批同意超标",
+		OverriddenBy:   "李四",
+		OverriddenAt:   &overriddenAt,
+	}
+}
+
+func TestAuditAttestation(t *testing.T) {
+	signer, err := NewAttestationSigner("attestation-secret")
+	if err != nil {
+		t.Fatalf("NewAttestationSigner() error = %v", err)
+	}
+	attestation, err := NewAuditAttestation(signer, attestedAudit(), time.Now())
+	if err != nil {
+		t.Fatalf("NewAuditAttestation() error = %v", err)
+	}
+	if attestation.Verdict != VerdictPass {
+		t.Errorf("Verdict = %s, want 改判后的结论%s", attestation.Verdict, VerdictPass)
+	}
+
+	otherSigner, err := NewAttestationSigner("other-secret")
+	if err != nil {
+		t.Fatalf("NewAttestationSigner() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		signer     *AttestationSigner
+		token      string
+		mutate     func(a *AuditResult)
+		wantValid  bool
+		wantReason string
+	}{
+		{name: "未修改的审核记录校验通过", wantValid: true},
+		{name: "修改规则结论", mutate: func(a *AuditResult) { a.RuleResults[0].Passed = true }, wantReason: "可能已被篡改"},
+		{name: "修改规则违规说明", mutate: func(a *AuditResult) { a.RuleResults[0].Message = "未超出标准" }, wantReason: "可能已被篡改"},
+		{name: "删除规则结果", mutate: func(a *AuditResult) { a.RuleResults = a.RuleResults[1:] }, wantReason: "可能已被篡改"},
+		{name: "修改改判结论", mutate: func(a *AuditResult) { a.OverridePass = false }, wantReason: "可能已被篡改"},
+		{name: "修改改判原因", mutate: func(a *AuditResult) { a.OverrideReason = "无" }, wantReason: "可能已被篡改"},
+		{name: "修改改判人", mutate: func(a *AuditResult) { a.OverriddenBy = "王五" }, wantReason: "可能已被篡改"},
+		{name: "修改改判时间", mutate: func(a *AuditResult) { at := a.OverriddenAt.Add(time.Minute); a.OverriddenAt = &at }, wantReason: "可能已被篡改"},
+		{name: "撤销改判", mutate: func(a *AuditResult) { a.Overridden = false }, wantReason: "可能已被篡改"},
+		{name: "其他审核记录", mutate: func(a *AuditResult) { a.ID = "a2" }, wantReason: "不匹配"},
+		{name: "密钥不一致", signer: otherSigner, wantReason: "签名校验失败"},
+		{name: "篡改证明内容", token: tamperPayload(t, attestation.Token), wantReason: "签名校验失败"},
+		{name: "格式错误", token: "not-a-token", wantReason: "格式错误"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := attestedAudit()
+			if tt.mutate != nil {
+				tt.mutate(audit)
+			}
+			verifier := signer
+			if tt.signer != nil {
+				verifier = tt.signer
+			}
+			token := attestation.Token
+			if tt.token != "" {
+				token = tt.token
+			}
+
+			result, err := VerifyAuditAttestation(verifier, token, audit)
+			if err != nil {
+				t.Fatalf("VerifyAuditAttestation() error = %v", err)
+			}
+			if result.Valid != tt.wantValid || !strings.Contains(result.Reason, tt.wantReason) {
+				t.Errorf("Valid/Reason = %v/%q, want %v/%q", result.Valid, result.Reason, tt.wantValid, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestNewAuditAttestationUnfinished(t *testing.T) {
+	signer, err := NewAttestationSigner("attestation-secret")
+	if err != nil {
+		t.Fatalf("NewAttestationSigner() error = %v", err)
+	}
+	audit := attestedAudit()
+	audit.Status = AuditStatusManualReview
+	if _, err := NewAuditAttestation(signer, audit, time.Now()); !errors.Is(err, ErrAuditNotAttestable) {
+		t.Errorf("NewAuditAttestation() error = %v, want %v", err, ErrAuditNotAttestable)
+	}
+	if _, err := NewAttestationSigner(" "); !errors.Is(err, ErrAttestationUnavailable) {
+		t.Errorf("NewAttestationSigner() error = %v, want %v", err, ErrAttestationUnavailable)
+	}
+}
+
+// tamperPayload 替换证明内容中的审核结论，签名保持不变
+func tamperPayload(t *testing.T, token string) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("证明格式错误: %s", token)
+	}
+	signer, err := NewAttestationSigner("attacker-secret")
+	if err != nil {
+		t.Fatalf("NewAttestationSigner() error = %v", err)
+	}
+	forged, err := signer.Sign(&AttestationClaims{AuditID: "a1", ReimbursementID: "r1", Verdict: VerdictReject})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	return parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+}
@@ -0,0 +1,101 @@
+// audit_lock.go 同一报销单审核并发隔离
+// 功能点：
+// 1. 按报销单ID加进程内锁，同一报销单同一时间只执行一个审核，避免自动触发与人工触发并发写入冲突的结果
+// 2. 并发策略可配置：wait(默认)等待前一个审核结束后再执行，reject直接拒绝并返回ErrAuditInProgress
+// 3. 等待期间响应上下文取消，锁在没有审核持有或等待时释放，不随报销单数量无限增长
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 同一报销单并发审核策略
+const (
+	ConcurrencyModeWait   = "wait"   // 等待前一个审核结束
+	ConcurrencyModeReject = "reject" // 拒绝并发审核
+)
+
+// ErrAuditInProgress 报销单正在审核中
+var ErrAuditInProgress = errors.New("报销单正在审核中")
+
+// reimbursementLocks 按报销单ID管理的审核锁
+type reimbursementLocks struct {
+	mu    sync.Mutex
+	locks map[string]*reimbursementLock
+}
+
+// reimbursementLock 单个报销单的审核锁，refs为持有和等待该锁的审核数
+type reimbursementLock struct {
+	sem  chan struct{}
+	refs int
+}
+
+// acquire 获取报销单的审核锁，wait为false时锁被占用立即返回ErrAuditInProgress
+// 获取成功时返回释放函数，调用方必须在审核结束后调用
+func (l *reimbursementLocks) acquire(ctx context.Context, reimbursementID string, wait bool) (func(), error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*reimbursementLock)
+	}
+	lock, ok := l.locks[reimbursementID]
+	if !ok {
+		lock = &reimbursementLock{sem: make(chan struct{}, 1)}
+		l.locks[reimbursementID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	select {
+	case lock.sem <- struct{}{}:
+		return func() {
+			<-lock.sem
+			l.unref(reimbursementID, lock)
+		}, nil
+	default:
+	}
+
+	if !wait {
+		l.unref(reimbursementID, lock)
+		return nil, fmt.Errorf("%w: %s", ErrAuditInProgress, reimbursementID)
+	}
+
+	select {
+	case lock.sem <- struct{}{}:
+		return func() {
+			<-lock.sem
+			l.unref(reimbursementID, lock)
+		}, nil
+	case <-ctx.Done():
+		l.unref(reimbursementID, lock)
+		return nil, fmt.Errorf("等待报销单审核锁失败: %w", ctx.Err())
+	}
+}
+
+// unref 减少锁的引用数，没有审核持有或等待时删除该锁
+func (l *reimbursementLocks) unref(reimbursementID string, lock *reimbursementLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock.refs--
+	if lock.refs == 0 && l.locks[reimbursementID] == lock {
+		delete(l.locks, reimbursementID)
+	}
+}
+
+// SetConcurrencyMode 设置同一报销单并发审核策略(wait/reject)，未知策略时使用wait
+func (s *Service) SetConcurrencyMode(mode string) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode != ConcurrencyModeReject {
+		mode = ConcurrencyModeWait
+	}
+	s.concurrencyMode = mode
+}
+
+// lockReimbursement 获取报销单的审核锁，按并发策略等待或拒绝
+func (s *Service) lockReimbursement(ctx context.Context, reimbursementID string) (func(), error) {
+	return s.auditLocks.acquire(ctx, reimbursementID, s.concurrencyMode != ConcurrencyModeReject)
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLockReimbursement(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name            string
+		mode            string
+		ctx             context.Context
+		reimbursementID string
+		wantErr         error
+	}{
+		{name: "拒绝模式下同一报销单返回审核中", mode: ConcurrencyModeReject, ctx: context.Background(), reimbursementID: "r1", wantErr: ErrAuditInProgress},
+		{name: "策略忽略大小写", mode: " REJECT ", ctx: context.Background(), reimbursementID: "r1", wantErr: ErrAuditInProgress},
+		{name: "等待模式下上下文取消时返回错误", mode: ConcurrencyModeWait, ctx: canceled, reimbursementID: "r1", wantErr: context.Canceled},
+		{name: "未知策略按等待处理", mode: "unknown", ctx: canceled, reimbursementID: "r1", wantErr: context.Canceled},
+		{name: "不同报销单互不影响", mode: ConcurrencyModeReject, ctx: context.Background(), reimbursementID: "r2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{}
+			s.SetConcurrencyMode(tt.mode)
+			release, err := s.lockReimbursement(context.Background(), "r1")
+			if err != nil {
+				t.Fatalf("首次加锁失败: %v", err)
+			}
+
+			second, err := s.lockReimbursement(tt.ctx, tt.reimbursementID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("lockReimbursement() error = %v, want %v", err, tt.wantErr)
+			}
+			if second != nil {
+				second()
+			}
+			release()
+
+			if len(s.auditLocks.locks) != 0 {
+				t.Errorf("释放后仍有%d个锁未清理", len(s.auditLocks.locks))
+			}
+		})
+	}
+}
+
+// TestLockReimbursementWaitSerializes 等待模式下同一报销单的审核串行执行，需配合-race运行
+func TestLockReimbursementWaitSerializes(t *testing.T) {
+	s := &Service{}
+	s.SetConcurrencyMode(ConcurrencyModeWait)
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.lockReimbursement(context.Background(), "r1")
+			if err != nil {
+				t.Errorf("lockReimbursement() error = %v", err)
+				return
+			}
+			defer release()
+			current := atomic.AddInt32(&running, 1)
+			for {
+				observed := atomic.LoadInt32(&maxRunning)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+					break
+				}
+			}
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning != 1 {
+		t.Errorf("同时执行的审核数 = %d, want 1", maxRunning)
+	}
+	if len(s.auditLocks.locks) != 0 {
+		t.Errorf("全部释放后仍有%d个锁未清理", len(s.auditLocks.locks))
+	}
+}
@@ -0,0 +1,290 @@
+// auto_retry.go 失败审核自动重试
+// 功能点：
+// 1. 区分临时性失败（大模型限流、服务端错误、超时、网络异常）与永久性失败
+// 2. 后台周期扫描临时性失败的审核并自动重试
+// 3. 按尝试次数指数退避，单次退避时长有上限
+// 4. 自动重试次数有上限，同时受服务最大重试次数约束
+// 5. 报销单已有更新的审核记录时不再重试旧记录
+// 6. 只扫描回溯时间窗口内的失败审核
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// 自动重试默认配置
+const (
+	DefaultAutoRetryMaxAttempts    = 3
+	DefaultAutoRetryInitialBackoff = 30 * time.Second
+	DefaultAutoRetryMaxBackoff     = 10 * time.Minute
+	DefaultAutoRetryInterval       = 30 * time.Second
+	DefaultAutoRetryBatchSize      = 50
+	DefaultAutoRetryLookback       = 24 * time.Hour
+)
+
+// temporaryError 可判断是否为临时性错误的错误（如大模型接口限流）
+type temporaryError interface {
+	Temporary() bool
+}
+
+// IsTransientError 判断审核失败原因是否为临时性错误，临时性错误稍后重试可能成功
+// 临时性错误：大模型限流(429)或服务端错误(5xx)、请求超时、连接被拒绝或重置
+// 其余错误（报销单不存在、规则加载失败、响应格式错误等）视为永久性错误
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var temporary temporaryError
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
+
+// AutoRetryConfig 自动重试配置
+type AutoRetryConfig struct {
+	Enabled        bool          `json:"enabled"`         // 是否启用自动重试
+	MaxAttempts    int           `json:"max_attempts"`    // 单个报销单最多审核尝试次数（含首次）
+	InitialBackoff time.Duration `json:"initial_backoff"` // 首次重试前的等待时长
+	MaxBackoff     time.Duration `json:"max_backoff"`     // 单次退避等待时长上限
+	Interval       time.Duration `json:"interval"`        // 扫描失败审核的周期
+	BatchSize      int           `json:"batch_size"`      // 每次扫描的分页大小
+	Lookback       time.Duration `json:"lookback"`        // 只扫描该时长内创建的失败审核
+}
+
+// DefaultAutoRetryConfig 默认自动重试配置（默认不启用）
+func DefaultAutoRetryConfig() AutoRetryConfig {
+	return AutoRetryConfig{
+		MaxAttempts:    DefaultAutoRetryMaxAttempts,
+		InitialBackoff: DefaultAutoRetryInitialBackoff,
+		MaxBackoff:     DefaultAutoRetryMaxBackoff,
+		Interval:       DefaultAutoRetryInterval,
+		BatchSize:      DefaultAutoRetryBatchSize,
+		Lookback:       DefaultAutoRetryLookback,
+	}
+}
+
+// normalize 规范化自动重试配置，非法值回退为默认值
+func (c AutoRetryConfig) normalize() AutoRetryConfig {
+	defaults := DefaultAutoRetryConfig()
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaults.MaxAttempts
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = defaults.InitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaults.MaxBackoff
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		c.MaxBackoff = c.InitialBackoff
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaults.Interval
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaults.BatchSize
+	}
+	if c.Lookback <= 0 {
+		c.Lookback = defaults.Lookback
+	}
+	return c
+}
+
+// Backoff 计算第attempt次尝试失败后的退避时长：InitialBackoff * 2^(attempt-1)，不超过MaxBackoff
+func (c AutoRetryConfig) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	backoff := c.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+	if backoff > c.MaxBackoff {
+		return c.MaxBackoff
+	}
+	return backoff
+}
+
+// AutoRetrier 失败审核自动重试器
+type AutoRetrier struct {
+	service *Service
+	config  AutoRetryConfig
+	logger  logger.Logger
+	now     func() time.Time
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAutoRetrier 创建失败审核自动重试器
+func NewAutoRetrier(service *Service, config AutoRetryConfig, logger logger.Logger) *AutoRetrier {
+	return &AutoRetrier{
+		service: service,
+		config:  config.normalize(),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Start 启动后台重试，未启用或已启动时不做任何操作
+func (r *AutoRetrier) Start(ctx context.Context) {
+	if !r.config.Enabled {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go r.run(ctx, r.done)
+
+	r.logger.WithContext(ctx).Info("失败审核自动重试已启动",
+		logger.NewField("max_attempts", r.config.MaxAttempts),
+		logger.NewField("interval", r.config.Interval.String()))
+}
+
+// Stop 停止后台重试并等待当前扫描结束
+func (r *AutoRetrier) Stop() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// run 周期扫描失败审核
+func (r *AutoRetrier) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RetryOnce(ctx)
+		}
+	}
+}
+
+// RetryOnce 扫描一次临时性失败的审核并重试到期的记录，返回发起重试的数量
+func (r *AutoRetrier) RetryOnce(ctx context.Context) int {
+	transient := true
+	since := r.now().Add(-r.config.Lookback)
+	filter := &AuditFilter{
+		Status:    AuditStatusFailed,
+		Transient: &transient,
+		StartTime: &since,
+		Size:      r.config.BatchSize,
+	}
+
+	// 先收集全部候选记录再重试，避免重试产生的新记录影响分页
+	var candidates []*AuditResult
+	for page := 1; ; page++ {
+		filter.Page = page
+		failedAudits, total, err := r.service.repo.ListAudits(ctx, filter)
+		if err != nil {
+			r.logger.WithContext(ctx).Error("查询失败审核失败", logger.NewField("error", err))
+			return 0
+		}
+		candidates = append(candidates, failedAudits...)
+		if len(failedAudits) < filter.Size || int64(page*filter.Size) >= total {
+			break
+		}
+	}
+
+	retried := 0
+	for _, failed := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		if !r.shouldRetry(ctx, failed) {
+			continue
+		}
+
+		retryAudit, err := r.service.RetryAudit(ctx, failed.ID)
+		retried++
+		if err != nil {
+			r.logger.WithContext(ctx).Warn("自动重试审核失败",
+				logger.NewField("audit_id", failed.ID),
+				logger.NewField("reimbursement_id", failed.ReimbursementID),
+				logger.NewField("attempt", failed.Attempt+1),
+				logger.NewField("error", err))
+			continue
+		}
+
+		r.logger.WithContext(ctx).Info("自动重试审核完成",
+			logger.NewField("audit_id", failed.ID),
+			logger.NewField("retry_audit_id", retryAudit.ID),
+			logger.NewField("attempt", retryAudit.Attempt))
+	}
+
+	return retried
+}
+
+// shouldRetry 判断失败审核是否需要自动重试：次数未超限、退避时间已到且为报销单最新的审核记录
+func (r *AutoRetrier) shouldRetry(ctx context.Context, failed *AuditResult) bool {
+	attempt := failed.Attempt
+	if attempt <= 0 {
+		attempt = 1
+	}
+	if attempt >= r.config.MaxAttempts || attempt > r.service.maxRetries {
+		return false
+	}
+
+	failedAt := failed.UpdatedAt
+	if failed.CompletedAt != nil {
+		failedAt = *failed.CompletedAt
+	}
+	if r.now().Before(failedAt.Add(r.config.Backoff(attempt))) {
+		return false
+	}
+
+	latest, err := r.service.repo.GetAuditByReimbursementID(ctx, failed.ReimbursementID)
+	if err != nil {
+		r.logger.WithContext(ctx).Warn("查询报销单最新审核记录失败",
+			logger.NewField("reimbursement_id", failed.ReimbursementID),
+			logger.NewField("error", err))
+		return false
+	}
+
+	return latest != nil && latest.ID == failed.ID
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/domain/rag"
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+)
+
+func TestAutoRetryAPIError(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		wantTransient bool
+		wantRetried   int
+		wantStatus    string
+	}{
+		{name: "限流429自动重试", statusCode: http.StatusTooManyRequests, wantTransient: true, wantRetried: 1, wantStatus: reimbursement.StatusApproved},
+		{name: "服务端错误503自动重试", statusCode: http.StatusServiceUnavailable, wantTransient: true, wantRetried: 1, wantStatus: reimbursement.StatusApproved},
+		{name: "请求错误400不重试", statusCode: http.StatusBadRequest, wantStatus: reimbursement.StatusPending},
+		{name: "未授权401不重试", statusCode: http.StatusUnauthorized, wantStatus: reimbursement.StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.putReimbursement(&reimbursement.Reimbursement{ID: "r1", Type: "差旅费", TotalAmount: 300, Status: reimbursement.StatusPending})
+			service := newPipelineService(t, store, newMemRuleRepo(amountLimitRule("500")))
+			analyzer := &fakeAnalyzer{confidence: 0.9, err: &rag.APIError{StatusCode: tt.statusCode}}
+			service.ragService = analyzer
+
+			if _, err := service.StartAudit(context.Background(), "r1"); err == nil {
+				t.Fatalf("StartAudit() 大模型接口返回%d时应失败", tt.statusCode)
+			}
+			failed, err := service.repo.GetAuditByReimbursementID(context.Background(), "r1")
+			if err != nil {
+				t.Fatalf("获取审核记录失败: %v", err)
+			}
+			if failed.Status != AuditStatusFailed || failed.Transient != tt.wantTransient {
+				t.Fatalf("Status/Transient = %s/%v, want %s/%v", failed.Status, failed.Transient, AuditStatusFailed, tt.wantTransient)
+			}
+
+			// 大模型接口恢复，退避时间已过
+			analyzer.err = nil
+			retrier := NewAutoRetrier(service, AutoRetryConfig{Enabled: true, InitialBackoff: time.Minute}, loggertest.New(t))
+			retrier.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+			if retried := retrier.RetryOnce(context.Background()); retried != tt.wantRetried {
+				t.Errorf("RetryOnce() = %d, want %d", retried, tt.wantRetried)
+			}
+			latest, err := service.repo.GetAuditByReimbursementID(context.Background(), "r1")
+			if err != nil {
+				t.Fatalf("获取审核记录失败: %v", err)
+			}
+			if tt.wantRetried > 0 && (latest.ID == failed.ID || latest.Attempt != 2 || latest.Status != AuditStatusCompleted) {
+				t.Errorf("重试后最新审核 = %s(第%d次, %s), want 新记录第2次且审核完成", latest.ID, latest.Attempt, latest.Status)
+			}
+			if tt.wantRetried == 0 && latest.ID != failed.ID {
+				t.Errorf("永久性失败不应产生新的审核记录")
+			}
+			if status := store.reimbursement("r1").Status; status != tt.wantStatus {
+				t.Errorf("报销单状态 = %s, want %s", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAutoRetryBackoff(t *testing.T) {
+	store := newMemStore()
+	store.putReimbursement(&reimbursement.Reimbursement{ID: "r1", Type: "差旅费", TotalAmount: 300, Status: reimbursement.StatusPending})
+	service := newPipelineService(t, store, newMemRuleRepo(amountLimitRule("500")))
+	service.ragService = &fakeAnalyzer{err: &rag.APIError{StatusCode: http.StatusTooManyRequests}}
+	if _, err := service.StartAudit(context.Background(), "r1"); err == nil {
+		t.Fatalf("StartAudit() 大模型接口限流时应失败")
+	}
+
+	// 退避时间未到时不重试
+	retrier := NewAutoRetrier(service, AutoRetryConfig{Enabled: true, InitialBackoff: time.Hour}, loggertest.New(t))
+	if retried := retrier.RetryOnce(context.Background()); retried != 0 {
+		t.Errorf("RetryOnce() = %d, 退避时间未到时应为0", retried)
+	}
+}
@@ -0,0 +1,201 @@
+// invoice_summary.go 报销单发票校验汇总
+// 功能点：
+// 1. 汇总报销单内各发票的校验结果（总数/通过数/未通过数）
+// 2. 提取决定审核结论的主要违规项
+// 3. 根据汇总结果给出整体处理建议
+// 4. 存在OCR识别置信度不足的发票时转人工复核，不依据可能识别错误的字段自动通过或驳回
+// 5. 存在高严重程度违规发票（如黑名单销售方）时审核不通过
+// 6. 发票校验执行失败时转人工复核，不把未完成的校验当作通过
+
+package audit
+
+import (
+	"fmt"
+	"sort"
+
+	"reimbursement-audit/internal/domain/rule"
+)
+
+// maxControllingViolations 汇总中保留的主要违规项数量上限
+const maxControllingViolations = 5
+
+// 整体处理建议
+const (
+	InvoiceRecommendationApprove = "全部发票校验通过，建议通过"
+	InvoiceRecommendationReview  = "部分发票存在中低风险违规，建议人工复核"
+	InvoiceRecommendationReject  = "存在高严重程度违规发票，建议驳回"
+	InvoiceRecommendationNone    = "报销单无发票，需补充发票后再审核"
+	InvoiceRecommendationOCR     = "存在OCR识别置信度不足的发票，需人工确认识别结果"
+)
+
+// OCR识别置信度不足时的审核结论
+const (
+	reasonOCRLowConfidence     = "%d张发票的关键字段OCR识别置信度不足，无法自动判定，需人工复核"
+	suggestionOCRLowConfidence = "请对照发票原件确认金额、发票号码、税号等字段的识别结果，修正后重新审核"
+)
+
+// 存在高严重程度违规发票时的审核结论
+const (
+	reasonInvoiceRejected     = "发票存在高严重程度违规，审核不通过：%s"
+	suggestionInvoiceRejected = "请处理发票违规项（如更换黑名单销售方开具的发票）后重新提交审核"
+)
+
+// 发票校验执行失败时的审核结论
+const (
+	reasonInvoiceValidationFailed     = "发票校验执行失败，无法确认发票合规性，需人工复核: %s"
+	suggestionInvoiceValidationFailed = "请人工核对发票的销售方、重复报销、金额与时效，或待校验服务恢复后重新审核"
+)
+
+// severityRank 违规严重程度排序权重
+var severityRank = map[string]int{"高": 3, "中": 2, "低": 1}
+
+// InvoiceAuditSummary 报销单发票校验汇总
+type InvoiceAuditSummary struct {
+	TotalInvoices         int                     `json:"total_invoices"`         // 发票总数
+	PassedInvoices        int                     `json:"passed_invoices"`        // 校验通过的发票数
+	FailedInvoices        int                     `json:"failed_invoices"`        // 校验未通过的发票数
+	ManualReviewInvoices  int                     `json:"manual_review_invoices"` // 需人工复核识别结果的发票数
+	ControllingViolations []*ControllingViolation `json:"controlling_violations"` // 主要违规项（按严重程度和优先级排序）
+	Recommendation        string                  `json:"recommendation"`         // 整体处理建议
+	Invoices              []*InvoiceAuditItem     `json:"invoices"`               // 各发票校验结果
+}
+
+// InvoiceAuditItem 单张发票校验结果
+type InvoiceAuditItem struct {
+	InvoiceID      string `json:"invoice_id"`      // 发票ID
+	InvoiceNumber  string `json:"invoice_number"`  // 发票号码
+	Passed         bool   `json:"passed"`          // 是否通过
+	ManualReview   bool   `json:"manual_review"`   // 是否需人工复核识别结果
+	ViolationCount int    `json:"violation_count"` // 违规数量
+	Summary        string `json:"summary"`         // 校验摘要
+}
+
+// ControllingViolation 主要违规项
+type ControllingViolation struct {
+	InvoiceID     string `json:"invoice_id"`     // 发票ID
+	InvoiceNumber string `json:"invoice_number"` // 发票号码
+	RuleID        string `json:"rule_id"`        // 规则ID
+	RuleName      string `json:"rule_name"`      // 规则名称
+	Severity      string `json:"severity"`       // 严重程度
+	Message       string `json:"message"`        // 违规描述
+	Priority      int    `json:"priority"`       // 规则优先级
+}
+
+// BuildInvoiceAuditSummary 根据各发票校验结果生成汇总，numbers为发票ID到发票号码的映射
+func BuildInvoiceAuditSummary(results []*rule.InvoiceValidationResult, numbers map[string]string) *InvoiceAuditSummary {
+	summary := &InvoiceAuditSummary{
+		Invoices:              make([]*InvoiceAuditItem, 0, len(results)),
+		ControllingViolations: make([]*ControllingViolation, 0),
+	}
+
+	var violations []*ControllingViolation
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+
+		summary.TotalInvoices++
+		if result.Passed {
+			summary.PassedInvoices++
+		} else {
+			summary.FailedInvoices++
+		}
+		if result.ManualReview {
+			summary.ManualReviewInvoices++
+		}
+
+		summary.Invoices = append(summary.Invoices, &InvoiceAuditItem{
+			InvoiceID:      result.InvoiceID,
+			InvoiceNumber:  numbers[result.InvoiceID],
+			Passed:         result.Passed,
+			ManualReview:   result.ManualReview,
+			ViolationCount: len(result.Violations),
+			Summary:        result.Summary,
+		})
+
+		for _, violation := range result.Violations {
+			if violation == nil {
+				continue
+			}
+			violations = append(violations, &ControllingViolation{
+				InvoiceID:     result.InvoiceID,
+				InvoiceNumber: numbers[result.InvoiceID],
+				RuleID:        violation.RuleID,
+				RuleName:      violation.RuleName,
+				Severity:      violation.Severity,
+				Message:       violation.Message,
+				Priority:      violation.Priority,
+			})
+		}
+	}
+
+	// 严重程度优先，其次规则优先级
+	sort.SliceStable(violations, func(i, j int) bool {
+		if severityRank[violations[i].Severity] != severityRank[violations[j].Severity] {
+			return severityRank[violations[i].Severity] > severityRank[violations[j].Severity]
+		}
+		return violations[i].Priority > violations[j].Priority
+	})
+	if len(violations) > maxControllingViolations {
+		violations = violations[:maxControllingViolations]
+	}
+	summary.ControllingViolations = append(summary.ControllingViolations, violations...)
+
+	summary.Recommendation = recommendInvoiceAction(summary)
+	return summary
+}
+
+// recommendInvoiceAction 根据汇总结果给出整体处理建议
+func recommendInvoiceAction(summary *InvoiceAuditSummary) string {
+	if summary.TotalInvoices == 0 {
+		return InvoiceRecommendationNone
+	}
+	if summary.FailedInvoices == 0 {
+		return InvoiceRecommendationApprove
+	}
+	// 识别结果不可信时其它违规也可能是误判，先人工确认识别结果
+	if summary.ManualReviewInvoices > 0 {
+		return InvoiceRecommendationOCR
+	}
+	for _, violation := range summary.ControllingViolations {
+		if violation.Severity == "高" {
+			return InvoiceRecommendationReject
+		}
+	}
+	return InvoiceRecommendationReview
+}
+
+// requiresManualReview 判断发票校验汇总是否要求人工复核识别结果
+func (s *InvoiceAuditSummary) requiresManualReview() bool {
+	return s != nil && s.ManualReviewInvoices > 0
+}
+
+// applyInvoiceManualReview 将存在OCR识别置信度不足发票的审核标记为待人工复核，最终结论不通过
+func applyInvoiceManualReview(audit *AuditResult) {
+	audit.FinalPass = false
+	audit.Status = AuditStatusManualReview
+	audit.Reason = fmt.Sprintf(reasonOCRLowConfidence, audit.InvoiceSummary.ManualReviewInvoices)
+	audit.Suggestions = append([]string{suggestionOCRLowConfidence}, audit.Suggestions...)
+}
+
+// rejects 判断发票校验汇总是否存在高严重程度违规，存在时审核不能通过
+func (s *InvoiceAuditSummary) rejects() bool {
+	return s != nil && s.Recommendation == InvoiceRecommendationReject
+}
+
+// applyInvoiceRejection 将存在高严重程度违规发票的审核标记为不通过，原因取最严重的违规项
+func applyInvoiceRejection(audit *AuditResult) {
+	audit.FinalPass = false
+	if violations := audit.InvoiceSummary.ControllingViolations; len(violations) > 0 {
+		audit.Reason = fmt.Sprintf(reasonInvoiceRejected, violations[0].RuleName+"，"+violations[0].Message)
+	}
+	audit.Suggestions = append([]string{suggestionInvoiceRejected}, audit.Suggestions...)
+}
+
+// applyInvoiceValidationFailure 将发票校验执行失败的审核标记为待人工复核，最终结论不通过
+func applyInvoiceValidationFailure(audit *AuditResult, err error) {
+	audit.FinalPass = false
+	audit.Status = AuditStatusManualReview
+	audit.Reason = fmt.Sprintf(reasonInvoiceValidationFailed, err.Error())
+	audit.Suggestions = append([]string{suggestionInvoiceValidationFailed}, audit.Suggestions...)
+}
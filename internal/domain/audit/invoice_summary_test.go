@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"testing"
+
+	"reimbursement-audit/internal/domain/rule"
+)
+
+func TestBuildInvoiceAuditSummary(t *testing.T) {
+	numbers := map[string]string{"i1": "00000001", "i2": "00000002"}
+	violation := func(ruleID, severity string, priority int) *rule.InvoiceViolation {
+		return &rule.InvoiceViolation{RuleID: ruleID, Severity: severity, Priority: priority}
+	}
+
+	tests := []struct {
+		name               string
+		results            []*rule.InvoiceValidationResult
+		wantTotal          int
+		wantPassed         int
+		wantFailed         int
+		wantRecommendation string
+		wantRuleIDs        []string
+	}{
+		{
+			name:               "无发票",
+			wantRecommendation: InvoiceRecommendationNone,
+		},
+		{
+			name:               "全部通过",
+			results:            []*rule.InvoiceValidationResult{{InvoiceID: "i1", Passed: true}, nil},
+			wantTotal:          1,
+			wantPassed:         1,
+			wantRecommendation: InvoiceRecommendationApprove,
+		},
+		{
+			name: "存在高严重程度违规建议驳回，违规按严重程度和优先级排序",
+			results: []*rule.InvoiceValidationResult{
+				{InvoiceID: "i1", Passed: true},
+				{InvoiceID: "i2", Violations: []*rule.InvoiceViolation{
+					violation("low", "低", 9), violation("mid", "中", 1), violation("high", "高", 1), violation("mid2", "中", 5), nil,
+				}},
+			},
+			wantTotal:          2,
+			wantPassed:         1,
+			wantFailed:         1,
+			wantRecommendation: InvoiceRecommendationReject,
+			wantRuleIDs:        []string{"high", "mid2", "mid", "low"},
+		},
+		{
+			name: "仅中低风险违规建议人工复核",
+			results: []*rule.InvoiceValidationResult{
+				{InvoiceID: "i1", Violations: []*rule.InvoiceViolation{violation("mid", "中", 1)}},
+			},
+			wantTotal:          1,
+			wantFailed:         1,
+			wantRecommendation: InvoiceRecommendationReview,
+			wantRuleIDs:        []string{"mid"},
+		},
+		{
+			name: "识别置信度不足优先转人工确认",
+			results: []*rule.InvoiceValidationResult{
+				{InvoiceID: "i1", ManualReview: true, Violations: []*rule.InvoiceViolation{violation("high", "高", 1)}},
+			},
+			wantTotal:          1,
+			wantFailed:         1,
+			wantRecommendation: InvoiceRecommendationOCR,
+			wantRuleIDs:        []string{"high"},
+		},
+		{
+			name: "主要违规项最多保留5条",
+			results: []*rule.InvoiceValidationResult{
+				{InvoiceID: "i1", Violations: []*rule.InvoiceViolation{
+					violation("r1", "低", 1), violation("r2", "低", 2), violation("r3", "低", 3),
+					violation("r4", "低", 4), violation("r5", "低", 5), violation("r6", "低", 6),
+				}},
+			},
+			wantTotal:          1,
+			wantFailed:         1,
+			wantRecommendation: InvoiceRecommendationReview,
+			wantRuleIDs:        []string{"r6", "r5", "r4", "r3", "r2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := BuildInvoiceAuditSummary(tt.results, numbers)
+			if summary.TotalInvoices != tt.wantTotal || summary.PassedInvoices != tt.wantPassed || summary.FailedInvoices != tt.wantFailed {
+				t.Fatalf("发票数 = %d/%d/%d, want %d/%d/%d", summary.TotalInvoices, summary.PassedInvoices,
+					summary.FailedInvoices, tt.wantTotal, tt.wantPassed, tt.wantFailed)
+			}
+			if summary.Recommendation != tt.wantRecommendation {
+				t.Errorf("Recommendation = %q, want %q", summary.Recommendation, tt.wantRecommendation)
+			}
+			if len(summary.ControllingViolations) != len(tt.wantRuleIDs) {
+				t.Fatalf("ControllingViolations = %d条, want %d条", len(summary.ControllingViolations), len(tt.wantRuleIDs))
+			}
+			for i, ruleID := range tt.wantRuleIDs {
+				got := summary.ControllingViolations[i]
+				if got.RuleID != ruleID {
+					t.Errorf("ControllingViolations[%d].RuleID = %s, want %s", i, got.RuleID, ruleID)
+				}
+				if got.InvoiceNumber != numbers[got.InvoiceID] {
+					t.Errorf("ControllingViolations[%d].InvoiceNumber = %s, want %s", i, got.InvoiceNumber, numbers[got.InvoiceID])
+				}
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package audit
 
 import (
+	"bytes"
+	"encoding/json"
 	"reimbursement-audit/internal/domain/rag"
+	"reimbursement-audit/internal/domain/rule"
 	"time"
 )
 
@@ -9,31 +12,60 @@ import (
 type AuditStatus string
 
 const (
-	AuditStatusPending   AuditStatus = "待审核"
-	AuditStatusRunning   AuditStatus = "审核中"
-	AuditStatusCompleted AuditStatus = "审核完成"
-	AuditStatusFailed    AuditStatus = "审核失败"
+	AuditStatusPending      AuditStatus = "待审核"
+	AuditStatusRunning      AuditStatus = "审核中"
+	AuditStatusCompleted    AuditStatus = "审核完成"
+	AuditStatusManualReview AuditStatus = "待人工复核" // 无法自动得出结论（如未加载任何规则），需人工复核
+	AuditStatusFailed       AuditStatus = "审核失败"
+)
+
+// 风险等级
+const (
+	RiskLevelHigh   = "高风险"
+	RiskLevelMedium = "中风险"
+	RiskLevelLow    = "低风险"
 )
 
 // AuditResult 审核结果
 type AuditResult struct {
-	ID              string                  `json:"id"`
-	ReimbursementID string                  `json:"reimbursement_id"`
-	Status          AuditStatus             `json:"status"`
-	RulePass        bool                    `json:"rule_pass"`
-	RAGPass         bool                    `json:"rag_pass"`
-	FinalPass       bool                    `json:"final_pass"`
-	RuleResults     []*RuleValidationResult `json:"rule_results"`
-	RAGResults      *RAGAnalysisResult      `json:"rag_results"`
-	RiskLevel       string                  `json:"risk_level"`
-	RiskScore       float64                 `json:"risk_score"`
-	Reason          string                  `json:"reason"`
-	Suggestions     []string                `json:"suggestions"`
-	StartedAt       time.Time               `json:"started_at"`
-	CompletedAt     *time.Time              `json:"completed_at"`
-	Duration        int64                   `json:"duration"`
-	CreatedAt       time.Time               `json:"created_at"`
-	UpdatedAt       time.Time               `json:"updated_at"`
+	ID               string                       `json:"id" gorm:"primaryKey;type:varchar(36);column:id"`
+	ReimbursementID  string                       `json:"reimbursement_id" gorm:"type:varchar(36);not null;index;column:reimbursement_id"`
+	Status           AuditStatus                  `json:"status" gorm:"type:varchar(20);not null;column:status"`
+	RulePass         bool                         `json:"rule_pass" gorm:"column:rule_pass"`
+	RAGPass          bool                         `json:"rag_pass" gorm:"column:rag_pass"`
+	FinalPass        bool                         `json:"final_pass" gorm:"column:final_pass"`
+	RuleResults      []*RuleValidationResult      `json:"rule_results" gorm:"serializer:json;type:json;column:rule_results"`
+	RAGResults       *RAGAnalysisResult           `json:"rag_results" gorm:"serializer:json;type:json;column:rag_results"`
+	InvoiceSummary   *InvoiceAuditSummary         `json:"invoice_summary" gorm:"serializer:json;type:json;column:invoice_summary"`
+	AppliedStandards []*rule.AppliedLimitStandard `json:"applied_standards" gorm:"serializer:json;type:json;column:applied_standards"`
+	RiskLevel        string                       `json:"risk_level" gorm:"type:varchar(20);index;column:risk_level"`
+	RiskScore        float64                      `json:"risk_score" gorm:"type:decimal(5,4);column:risk_score"`
+	Reason           string                       `json:"reason" gorm:"type:text;column:reason"`
+	Suggestions      []string                     `json:"suggestions" gorm:"serializer:json;type:json;column:suggestions"`
+	SubmittedAt      time.Time                    `json:"submitted_at" gorm:"column:submitted_at"`
+	StartedAt        time.Time                    `json:"started_at" gorm:"column:started_at"`
+	CompletedAt      *time.Time                   `json:"completed_at" gorm:"column:completed_at"`
+	Duration         int64                        `json:"duration" gorm:"column:duration"`
+	TurnaroundTime   int64                        `json:"turnaround_time" gorm:"column:turnaround_time"`
+	SLABreached      bool                         `json:"sla_breached" gorm:"index;column:sla_breached"`
+	RetryOf          string                       `json:"retry_of" gorm:"type:varchar(36);index;column:retry_of"`
+	Attempt          int                          `json:"attempt" gorm:"not null;default:1;column:attempt"`
+	Transient        bool                         `json:"transient" gorm:"index;column:transient"`
+	CreatedAt        time.Time                    `json:"created_at" gorm:"not null;column:created_at"`
+	UpdatedAt        time.Time                    `json:"updated_at" gorm:"not null;column:updated_at"`
+
+	// 人工改判，改判后以改判结论为准，原审核结论(FinalPass)保留
+	Overridden     bool             `json:"overridden" gorm:"index;column:overridden"`
+	OverridePass   bool             `json:"override_pass" gorm:"column:override_pass"`
+	OverrideReason string           `json:"override_reason" gorm:"type:text;column:override_reason"`
+	OverriddenBy   string           `json:"overridden_by" gorm:"type:varchar(100);column:overridden_by"`
+	OverriddenAt   *time.Time       `json:"overridden_at" gorm:"column:overridden_at"`
+	OverrideTrail  []*AuditOverride `json:"override_trail" gorm:"serializer:json;type:json;column:override_trail"`
+}
+
+// TableName 指定审核结果表名
+func (AuditResult) TableName() string {
+	return "audit_results"
 }
 
 // RuleValidationResult 规则校验结果
@@ -42,10 +74,67 @@ type RuleValidationResult struct {
 	RuleCode      string                 `json:"rule_code"`
 	RuleName      string                 `json:"rule_name"`
 	RuleType      string                 `json:"rule_type"`
+	Severity      string                 `json:"severity"`
 	Passed        bool                   `json:"passed"`
 	Message       string                 `json:"message"`
 	Details       map[string]interface{} `json:"details"`
 	ExecutionTime int64                  `json:"execution_time"`
+	Overridden    bool                   `json:"overridden"`
+	OverriddenBy  string                 `json:"overridden_by"`
+	ConflictNote  string                 `json:"conflict_note"`
+}
+
+// UnmarshalJSON 反序列化规则校验结果
+// Details按json.Number解码，整数还原为int64、小数还原为float64，避免落库往返后整数变为float64
+func (r *RuleValidationResult) UnmarshalJSON(data []byte) error {
+	type alias RuleValidationResult
+	aux := &struct {
+		Details json.RawMessage `json:"details"`
+		*alias
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	r.Details = nil
+	if len(aux.Details) == 0 || string(aux.Details) == "null" {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(aux.Details))
+	decoder.UseNumber()
+	var details map[string]interface{}
+	if err := decoder.Decode(&details); err != nil {
+		return err
+	}
+	r.Details = normalizeJSONNumbers(details).(map[string]interface{})
+	return nil
+}
+
+// normalizeJSONNumbers 递归将json.Number转换为int64或float64
+func normalizeJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONNumbers(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONNumbers(item)
+		}
+		return v
+	default:
+		return v
+	}
 }
 
 // RAGAnalysisResult RAG分析结果
@@ -66,12 +155,17 @@ type VectorReference struct {
 	Similarity float64 `json:"similarity"`
 	Category   string  `json:"category"`
 	DocumentID string  `json:"document_id"`
+	Index      int     `json:"index"`
+	Cited      bool    `json:"cited"`
 }
 
 // AuditFilter 审核查询过滤器
 type AuditFilter struct {
 	ReimbursementID string      `json:"reimbursement_id"`
 	Status          AuditStatus `json:"status"`
+	RiskLevel       string      `json:"risk_level"`
+	SLABreached     *bool       `json:"sla_breached"`
+	Transient       *bool       `json:"transient"`
 	StartTime       *time.Time  `json:"start_time"`
 	EndTime         *time.Time  `json:"end_time"`
 	Page            int         `json:"page"`
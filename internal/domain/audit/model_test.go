@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRuleValidationResultUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantDetails map[string]interface{}
+	}{
+		{
+			name:        "整数还原为int64，小数还原为float64",
+			data:        `{"rule_id":"r1","passed":false,"details":{"count":3,"amount":12.5}}`,
+			wantDetails: map[string]interface{}{"count": int64(3), "amount": 12.5},
+		},
+		{
+			name: "嵌套结构递归还原",
+			data: `{"rule_id":"r1","details":{"limits":[1,2.5],"standard":{"max":500}}}`,
+			wantDetails: map[string]interface{}{
+				"limits":   []interface{}{int64(1), 2.5},
+				"standard": map[string]interface{}{"max": int64(500)},
+			},
+		},
+		{name: "details为null", data: `{"rule_id":"r1","details":null}`},
+		{name: "缺少details", data: `{"rule_id":"r1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &RuleValidationResult{Details: map[string]interface{}{"stale": true}}
+			if err := json.Unmarshal([]byte(tt.data), result); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if result.RuleID != "r1" {
+				t.Errorf("RuleID = %q, want r1", result.RuleID)
+			}
+			if !reflect.DeepEqual(result.Details, tt.wantDetails) {
+				t.Errorf("Details = %#v, want %#v", result.Details, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestRuleValidationResultRoundTrip(t *testing.T) {
+	original := []*RuleValidationResult{{
+		RuleID:   "r1",
+		RuleName: "住宿限额",
+		Passed:   false,
+		Details:  map[string]interface{}{"limit": int64(500), "actual": 620.5, "city": "北京"},
+	}}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var restored []*RuleValidationResult
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(restored, original) {
+		t.Errorf("往返后 = %#v, want %#v", restored[0], original[0])
+	}
+}
@@ -0,0 +1,158 @@
+// notifier.go 审核结果通知
+// 功能点：
+// 1. 定义审核结果通知接口
+// 2. 提供基于日志的默认通知实现
+// 3. 提供按报销单+审核结论去重的通知装饰器
+// 4. 去重时间窗口可配置
+
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// DefaultNotificationDedupWindow 默认通知去重时间窗口
+const DefaultNotificationDedupWindow = 24 * time.Hour
+
+// AuditNotification 审核结果通知
+type AuditNotification struct {
+	AuditID         string    `json:"audit_id"`
+	ReimbursementID string    `json:"reimbursement_id"`
+	FinalPass       bool      `json:"final_pass"`
+	RiskLevel       string    `json:"risk_level"`
+	Reason          string    `json:"reason"`
+	VerdictHash     string    `json:"verdict_hash"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Notifier 审核结果通知接口
+type Notifier interface {
+	// Notify 发送审核结果通知
+	Notify(ctx context.Context, notification *AuditNotification) error
+}
+
+// LogNotifier 基于日志的通知实现
+type LogNotifier struct {
+	logger logger.Logger
+}
+
+// NewLogNotifier 创建日志通知器
+func NewLogNotifier(log logger.Logger) *LogNotifier {
+	return &LogNotifier{logger: log}
+}
+
+// Notify 记录审核结果通知
+func (n *LogNotifier) Notify(ctx context.Context, notification *AuditNotification) error {
+	n.logger.WithContext(ctx).Info("审核结果通知",
+		logger.NewField("audit_id", notification.AuditID),
+		logger.NewField("reimbursement_id", notification.ReimbursementID),
+		logger.NewField("final_pass", notification.FinalPass),
+		logger.NewField("risk_level", notification.RiskLevel))
+	return nil
+}
+
+// DedupNotifier 去重通知装饰器
+// 记录每个报销单最近一次通知的审核结论，时间窗口内结论与最近一次相同时不重复通知；
+// 结论变化(包括变回更早的结论)时重新通知
+type DedupNotifier struct {
+	next   Notifier
+	window time.Duration
+	logger logger.Logger
+	mu     sync.Mutex
+	sent   map[string]sentVerdict // key: 报销单ID -> 最近一次通知的结论
+	now    func() time.Time
+}
+
+// sentVerdict 最近一次通知的审核结论
+type sentVerdict struct {
+	hash string
+	at   time.Time
+}
+
+// NewDedupNotifier 创建去重通知器，window<=0时使用默认窗口
+func NewDedupNotifier(next Notifier, window time.Duration, log logger.Logger) *DedupNotifier {
+	if window <= 0 {
+		window = DefaultNotificationDedupWindow
+	}
+	return &DedupNotifier{
+		next:   next,
+		window: window,
+		logger: log,
+		sent:   make(map[string]sentVerdict),
+		now:    time.Now,
+	}
+}
+
+// Notify 发送通知，窗口内结论与最近一次通知相同时直接跳过
+func (n *DedupNotifier) Notify(ctx context.Context, notification *AuditNotification) error {
+	now := n.now()
+
+	n.mu.Lock()
+	n.evictExpired(now)
+	if last, ok := n.sent[notification.ReimbursementID]; ok && last.hash == notification.VerdictHash {
+		n.mu.Unlock()
+		n.logger.WithContext(ctx).Info("审核结论未变化，跳过重复通知",
+			logger.NewField("reimbursement_id", notification.ReimbursementID),
+			logger.NewField("audit_id", notification.AuditID))
+		return nil
+	}
+	n.mu.Unlock()
+
+	if err := n.next.Notify(ctx, notification); err != nil {
+		return err
+	}
+
+	// 仅在发送成功后记录，发送失败时允许下次重试
+	n.mu.Lock()
+	n.sent[notification.ReimbursementID] = sentVerdict{hash: notification.VerdictHash, at: now}
+	n.mu.Unlock()
+
+	return nil
+}
+
+// evictExpired 清理过期的去重记录，调用方需持有锁
+func (n *DedupNotifier) evictExpired(now time.Time) {
+	for reimbursementID, last := range n.sent {
+		if now.Sub(last.at) >= n.window {
+			delete(n.sent, reimbursementID)
+		}
+	}
+}
+
+// NewAuditNotification 根据审核结果构建通知
+func NewAuditNotification(audit *AuditResult) *AuditNotification {
+	return &AuditNotification{
+		AuditID:         audit.ID,
+		ReimbursementID: audit.ReimbursementID,
+		FinalPass:       audit.FinalPass,
+		RiskLevel:       audit.RiskLevel,
+		Reason:          audit.Reason,
+		VerdictHash:     ComputeVerdictHash(audit),
+		CreatedAt:       time.Now(),
+	}
+}
+
+// ComputeVerdictHash 计算审核结论哈希
+// 只包含结论相关字段（是否通过、风险等级、原因、未通过的规则），与审核ID和耗时无关
+func ComputeVerdictHash(audit *AuditResult) string {
+	failedRules := make([]string, 0)
+	for _, result := range audit.RuleResults {
+		if result != nil && !result.Passed {
+			failedRules = append(failedRules, result.RuleID)
+		}
+	}
+	sort.Strings(failedRules)
+
+	raw := fmt.Sprintf("%t|%s|%s|%s", audit.FinalPass, audit.RiskLevel, audit.Reason, strings.Join(failedRules, ","))
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
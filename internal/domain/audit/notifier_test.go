@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+)
+
+// recordingNotifier 记录收到的通知，err不为空时返回错误
+type recordingNotifier struct {
+	notifications []*AuditNotification
+	err           error
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, notification *AuditNotification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestDedupNotifier(t *testing.T) {
+	type send struct {
+		reimbursementID string
+		hash            string
+		after           time.Duration // 距上一次发送的时间
+	}
+
+	tests := []struct {
+		name  string
+		sends []send
+		want  []string // 实际发出的结论哈希
+	}{
+		{
+			name:  "相同结论重复审核不再通知",
+			sends: []send{{"r1", "A", 0}, {"r1", "A", time.Minute}, {"r1", "A", time.Minute}},
+			want:  []string{"A"},
+		},
+		{
+			name:  "结论变化重新通知",
+			sends: []send{{"r1", "A", 0}, {"r1", "B", time.Minute}},
+			want:  []string{"A", "B"},
+		},
+		{
+			name:  "结论变回先前结论重新通知",
+			sends: []send{{"r1", "A", 0}, {"r1", "B", time.Minute}, {"r1", "A", time.Minute}},
+			want:  []string{"A", "B", "A"},
+		},
+		{
+			name:  "不同报销单互不影响",
+			sends: []send{{"r1", "A", 0}, {"r2", "A", time.Minute}, {"r1", "A", time.Minute}},
+			want:  []string{"A", "A"},
+		},
+		{
+			name:  "超出时间窗口后重新通知",
+			sends: []send{{"r1", "A", 0}, {"r1", "A", time.Hour}},
+			want:  []string{"A", "A"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingNotifier{}
+			notifier := NewDedupNotifier(next, time.Hour, loggertest.New(t))
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			notifier.now = func() time.Time { return now }
+
+			for _, s := range tt.sends {
+				now = now.Add(s.after)
+				err := notifier.Notify(context.Background(), &AuditNotification{ReimbursementID: s.reimbursementID, VerdictHash: s.hash})
+				if err != nil {
+					t.Fatalf("Notify() error = %v", err)
+				}
+			}
+
+			if len(next.notifications) != len(tt.want) {
+				t.Fatalf("通知次数 = %d, want %d", len(next.notifications), len(tt.want))
+			}
+			for i, notification := range next.notifications {
+				if notification.VerdictHash != tt.want[i] {
+					t.Fatalf("第%d次通知结论 = %s, want %s", i+1, notification.VerdictHash, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDedupNotifierRetriesAfterFailure(t *testing.T) {
+	next := &recordingNotifier{err: errors.New("发送失败")}
+	notifier := NewDedupNotifier(next, time.Hour, loggertest.New(t))
+	notification := &AuditNotification{ReimbursementID: "r1", VerdictHash: "A"}
+
+	if err := notifier.Notify(context.Background(), notification); err == nil {
+		t.Fatal("Notify() 应返回下游错误")
+	}
+
+	next.err = nil
+	if err := notifier.Notify(context.Background(), notification); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(next.notifications) != 1 {
+		t.Fatalf("发送失败后应允许重试，通知次数 = %d, want 1", len(next.notifications))
+	}
+}
+
+func TestComputeVerdictHash(t *testing.T) {
+	base := &AuditResult{
+		ID: "a1", FinalPass: false, RiskLevel: "高", Reason: "规则校验未通过",
+		RuleResults: []*RuleValidationResult{{RuleID: "r2"}, {RuleID: "r1"}, {RuleID: "r3", Passed: true}},
+	}
+
+	tests := []struct {
+		name     string
+		audit    *AuditResult
+		wantSame bool
+	}{
+		{
+			name: "审核ID与规则顺序不影响哈希",
+			audit: &AuditResult{
+				ID: "a2", FinalPass: false, RiskLevel: "高", Reason: "规则校验未通过",
+				RuleResults: []*RuleValidationResult{{RuleID: "r1"}, {RuleID: "r2"}},
+			},
+			wantSame: true,
+		},
+		{
+			name: "未通过规则变化影响哈希",
+			audit: &AuditResult{
+				ID: "a1", FinalPass: false, RiskLevel: "高", Reason: "规则校验未通过",
+				RuleResults: []*RuleValidationResult{{RuleID: "r1"}},
+			},
+			wantSame: false,
+		},
+		{
+			name:     "结论变化影响哈希",
+			audit:    &AuditResult{ID: "a1", FinalPass: true, RiskLevel: "低"},
+			wantSame: false,
+		},
+	}
+
+	baseHash := ComputeVerdictHash(base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := ComputeVerdictHash(tt.audit) == baseHash; same != tt.wantSame {
+				t.Fatalf("哈希相同 = %v, want %v", same, tt.wantSame)
+			}
+		})
+	}
+}
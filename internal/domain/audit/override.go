@@ -0,0 +1,170 @@
+// override.go 审核结论人工改判
+// 功能点：
+// 1. 审核人员不认同规则/大模型的审核结论时，可填写理由改判，改判结论成为最终结论
+// 2. 原审核结论保留不变，每次改判都追加到改判记录中，形成审核轨迹
+// 3. 改判后同步更新报销单状态，使报销单的最终结论与改判一致
+// 4. 改判记录和报销单状态流转在同一事务中保存，任一步失败时全部回滚
+// 5. 改判人及其角色取自认证信息，复核员/审核管理员角色由API层路由校验
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+var (
+	// ErrInvalidOverride 改判参数不合法
+	ErrInvalidOverride = errors.New("改判参数不合法")
+	// ErrAuditNotOverridable 审核尚未得出结论，无法改判
+	ErrAuditNotOverridable = errors.New("审核尚未得出结论，无法改判")
+)
+
+// AuditOverride 一次人工改判记录
+type AuditOverride struct {
+	Pass         bool      `json:"pass"`                  // 改判结论
+	PreviousPass bool      `json:"previous_pass"`         // 改判前的最终结论
+	Reason       string    `json:"reason"`                // 改判理由
+	Actor        string    `json:"actor"`                 // 改判人
+	ActorID      string    `json:"actor_id,omitempty"`    // 改判人用户ID
+	ActorRoles   []string  `json:"actor_roles,omitempty"` // 改判时改判人的角色
+	CreatedAt    time.Time `json:"created_at"`            // 改判时间
+}
+
+// OverrideInput 改判参数
+type OverrideInput struct {
+	Pass       bool     // 改判结论
+	Reason     string   // 改判理由
+	Actor      string   // 改判人
+	ActorID    string   // 改判人用户ID
+	ActorRoles []string // 改判人角色
+}
+
+// EffectivePass 获取审核的最终结论，有人工改判时以改判结论为准
+func (a *AuditResult) EffectivePass() bool {
+	if a.Overridden {
+		return a.OverridePass
+	}
+	return a.FinalPass
+}
+
+// ApplyOverride 记录人工改判，只修改改判相关字段，原审核结论保留
+func (a *AuditResult) ApplyOverride(input *OverrideInput, now time.Time) error {
+	if a.Status != AuditStatusCompleted && a.Status != AuditStatusManualReview {
+		return fmt.Errorf("%w: 当前状态为%s", ErrAuditNotOverridable, a.Status)
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return fmt.Errorf("%w: 改判理由不能为空", ErrInvalidOverride)
+	}
+	actor := strings.TrimSpace(input.Actor)
+	if actor == "" {
+		return fmt.Errorf("%w: 改判人不能为空", ErrInvalidOverride)
+	}
+
+	a.OverrideTrail = append(a.OverrideTrail, &AuditOverride{
+		Pass:         input.Pass,
+		PreviousPass: a.EffectivePass(),
+		Reason:       reason,
+		Actor:        actor,
+		ActorID:      input.ActorID,
+		ActorRoles:   input.ActorRoles,
+		CreatedAt:    now,
+	})
+	a.Overridden = true
+	a.OverridePass = input.Pass
+	a.OverrideReason = reason
+	a.OverriddenBy = actor
+	a.OverriddenAt = &now
+	return nil
+}
+
+// OverrideAudit 人工改判审核结论，并同步报销单状态
+func (s *Service) OverrideAudit(ctx context.Context, auditID string, input *OverrideInput) (*AuditResult, error) {
+	if input == nil {
+		return nil, fmt.Errorf("%w: 缺少改判参数", ErrInvalidOverride)
+	}
+
+	audit, err := s.repo.GetAuditByID(ctx, auditID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取审核记录失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取审核记录失败: %w", err)
+	}
+
+	if err := audit.ApplyOverride(input, time.Now()); err != nil {
+		return nil, err
+	}
+	// 先校验报销单状态能否流转到改判结论，避免审核记录已改判而报销单状态无法同步
+	reim, path, err := s.reimbursementDecision(ctx, audit)
+	if err != nil {
+		return nil, err
+	}
+
+	// 改判记录与报销单状态流转在同一事务中保存，避免改判已保存而报销单停留在审核中
+	err = s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateAudit(ctx, audit); err != nil {
+			s.logger.WithContext(ctx).Error("保存改判结果失败",
+				logger.NewField("audit_id", auditID),
+				logger.NewField("error", err))
+			return fmt.Errorf("保存改判结果失败: %w", err)
+		}
+		return s.transitReimbursement(ctx, reim, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithContext(ctx).Info("审核结论已人工改判",
+		logger.NewField("audit_id", auditID),
+		logger.NewField("reimbursement_id", audit.ReimbursementID),
+		logger.NewField("final_pass", audit.FinalPass),
+		logger.NewField("override_pass", audit.OverridePass),
+		logger.NewField("actor", audit.OverriddenBy))
+	return audit, nil
+}
+
+// transitReimbursement 按路径逐步流转报销单状态并保存，每一步都按状态机条件更新
+func (s *Service) transitReimbursement(ctx context.Context, reim *reimbursement.Reimbursement, path []string) error {
+	for _, status := range path {
+		if err := reim.TransitionTo(status); err != nil {
+			return err
+		}
+		if err := s.reimbursementRepo.UpdateReimbursement(ctx, reim); err != nil {
+			s.logger.WithContext(ctx).Error("更新报销单状态失败",
+				logger.NewField("reimbursement_id", reim.ID),
+				logger.NewField("status", status),
+				logger.NewField("error", err))
+			return fmt.Errorf("更新报销单状态失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// reimbursementDecision 获取报销单及按审核的最终结论流转需依次经过的状态，状态流转不合法时返回错误
+// 已审结或待审核的报销单需先进入审核中再得出结论
+func (s *Service) reimbursementDecision(ctx context.Context, audit *AuditResult) (*reimbursement.Reimbursement, []string, error) {
+	reim, err := s.reimbursementRepo.GetReimbursementByID(ctx, audit.ReimbursementID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取报销单失败", logger.NewField("error", err))
+		return nil, nil, fmt.Errorf("获取报销单失败: %w", err)
+	}
+
+	decision := reimbursement.StatusRejected
+	if audit.EffectivePass() {
+		decision = reimbursement.StatusApproved
+	}
+	path, err := reim.DecisionPath(decision)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("报销单状态流转不合法",
+			logger.NewField("reimbursement_id", reim.ID),
+			logger.NewField("error", err))
+		return nil, nil, err
+	}
+	return reim, path, nil
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+)
+
+func TestOverrideAudit(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     AuditStatus
+		reimStatus string
+		failStatus string
+		input      *OverrideInput
+		wantErr    error
+		wantPass   bool
+		wantReim   string
+	}{
+		{
+			name:       "规则未通过改判为通过",
+			status:     AuditStatusCompleted,
+			reimStatus: reimbursement.StatusRejected,
+			input:      &OverrideInput{Pass: true, Reason: "补充了审批单", Actor: "李四", ActorID: "u2", ActorRoles: []string{"reviewer"}},
+			wantPass:   true,
+			wantReim:   reimbursement.StatusApproved,
+		},
+		{
+			name:       "待人工复核的报销单改判为通过",
+			status:     AuditStatusManualReview,
+			reimStatus: reimbursement.StatusAuditing,
+			input:      &OverrideInput{Pass: true, Reason: "人工核对无误", Actor: "李四"},
+			wantPass:   true,
+			wantReim:   reimbursement.StatusApproved,
+		},
+		{
+			name:       "报销单状态保存失败时改判回滚",
+			status:     AuditStatusCompleted,
+			reimStatus: reimbursement.StatusRejected,
+			failStatus: reimbursement.StatusApproved,
+			input:      &OverrideInput{Pass: true, Reason: "补充了审批单", Actor: "李四"},
+			wantReim:   reimbursement.StatusRejected,
+			wantErr:    errStoreFailure,
+		},
+		{
+			name:       "缺少改判人",
+			status:     AuditStatusCompleted,
+			reimStatus: reimbursement.StatusRejected,
+			input:      &OverrideInput{Pass: true, Reason: "补充了审批单"},
+			wantReim:   reimbursement.StatusRejected,
+			wantErr:    ErrInvalidOverride,
+		},
+		{
+			name:       "审核未结束不能改判",
+			status:     AuditStatusRunning,
+			reimStatus: reimbursement.StatusAuditing,
+			input:      &OverrideInput{Pass: true, Reason: "补充了审批单", Actor: "李四"},
+			wantReim:   reimbursement.StatusAuditing,
+			wantErr:    ErrAuditNotOverridable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.failStatus = tt.failStatus
+			store.putAudit(&AuditResult{ID: "a1", ReimbursementID: "r1", Status: tt.status, FinalPass: false})
+			store.putReimbursement(&reimbursement.Reimbursement{ID: "r1", Status: tt.reimStatus})
+
+			got, err := newMemService(t, store).OverrideAudit(context.Background(), "a1", tt.input)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatal("OverrideAudit() error = nil, want error")
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("OverrideAudit() error = %v, want %v", err, tt.wantErr)
+				}
+				if stored := store.audit("a1"); stored.Overridden {
+					t.Error("失败后审核记录不应保留改判")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("OverrideAudit() error = %v", err)
+				}
+				if got.EffectivePass() != tt.wantPass || got.FinalPass {
+					t.Errorf("EffectivePass() = %v, FinalPass = %v, want %v/false", got.EffectivePass(), got.FinalPass, tt.wantPass)
+				}
+				stored := store.audit("a1")
+				if !stored.Overridden || len(stored.OverrideTrail) != 1 {
+					t.Fatalf("改判记录未保存: %+v", stored)
+				}
+				if trail := stored.OverrideTrail[0]; trail.Actor != tt.input.Actor || trail.ActorID != tt.input.ActorID || trail.PreviousPass {
+					t.Errorf("改判轨迹 = %+v", trail)
+				}
+			}
+			if status := store.reimbursement("r1").Status; status != tt.wantReim {
+				t.Errorf("报销单状态 = %s, want %s", status, tt.wantReim)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// rag_topk.go 审核时制度检索分片数配置
+// 功能点：
+// 1. 全局检索分片数(topK)可配置，默认5
+// 2. 按报销类别覆盖检索分片数，如差旅费制度条款多、需要检索更多分片
+// 3. 审核时按报销单类别选取检索分片数，类别未配置覆盖时使用全局值
+
+package audit
+
+import "strings"
+
+// DefaultRAGTopK 审核时默认检索的制度分片数
+const DefaultRAGTopK = 5
+
+// SetRAGTopK 设置审核时检索的制度分片数，topK非正数时使用默认值
+// categoryTopK为按报销类别覆盖的分片数(类别→分片数)，非正数的覆盖项忽略
+func (s *Service) SetRAGTopK(topK int, categoryTopK map[string]int) {
+	if topK <= 0 {
+		topK = DefaultRAGTopK
+	}
+	s.ragTopK = topK
+
+	s.categoryTopK = make(map[string]int, len(categoryTopK))
+	for category, value := range categoryTopK {
+		category = strings.TrimSpace(category)
+		if category == "" || value <= 0 {
+			continue
+		}
+		s.categoryTopK[category] = value
+	}
+}
+
+// RAGTopK 获取报销类别对应的检索分片数，类别未配置覆盖时使用全局值
+func (s *Service) RAGTopK(category string) int {
+	if topK, ok := s.categoryTopK[strings.TrimSpace(category)]; ok {
+		return topK
+	}
+	if s.ragTopK <= 0 {
+		return DefaultRAGTopK
+	}
+	return s.ragTopK
+}
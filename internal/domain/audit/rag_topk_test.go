@@ -0,0 +1,37 @@
+package audit
+
+import "testing"
+
+func TestRAGTopK(t *testing.T) {
+	tests := []struct {
+		name         string
+		topK         int
+		categoryTopK map[string]int
+		category     string
+		want         int
+	}{
+		{name: "未配置覆盖时使用全局值", topK: 8, category: "差旅费", want: 8},
+		{name: "全局值非正数时使用默认值", topK: 0, category: "差旅费", want: DefaultRAGTopK},
+		{name: "按类别覆盖", topK: 5, categoryTopK: map[string]int{" 差旅费 ": 10}, category: "差旅费", want: 10},
+		{name: "查询类别去除空白", topK: 5, categoryTopK: map[string]int{"差旅费": 10}, category: " 差旅费", want: 10},
+		{name: "忽略非正数的覆盖项", topK: 5, categoryTopK: map[string]int{"差旅费": 0}, category: "差旅费", want: 5},
+		{name: "其它类别使用全局值", topK: 5, categoryTopK: map[string]int{"差旅费": 10}, category: "办公费", want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{}
+			s.SetRAGTopK(tt.topK, tt.categoryTopK)
+			if got := s.RAGTopK(tt.category); got != tt.want {
+				t.Errorf("RAGTopK(%q) = %d, want %d", tt.category, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRAGTopKUnset(t *testing.T) {
+	s := &Service{}
+	if got := s.RAGTopK("差旅费"); got != DefaultRAGTopK {
+		t.Errorf("RAGTopK() = %d, want %d", got, DefaultRAGTopK)
+	}
+}
@@ -0,0 +1,370 @@
+// reaudit.go 规则变更后批量重审受影响的报销单
+// 功能点：
+// 1. 规则启用或修改后，从近期完成的审核中找出最终结论为通过、且在规则适用范围内（报销类别、生效时间段）的报销单
+// 2. 受影响的报销单作为一个重审批次在后台异步重新审核，立即返回批次ID
+// 3. 并发重审数量有上限，单批重审数量有上限，避免一次规则变更压垮规则引擎和大模型接口
+// 4. 按批次查询重审进度，重审后未通过的报销单标记为新发现问题
+// 5. 批次记录保存在进程内，只保留最近的批次
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"reimbursement-audit/internal/domain/rule"
+	"reimbursement-audit/internal/pkg/errs"
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// 批量重审默认配置
+const (
+	DefaultReauditLookback = 30 * 24 * time.Hour
+	DefaultReauditMaxItems = 200
+	reauditConcurrency     = 4
+	reauditPageSize        = 100
+	maxReauditBatches      = 100
+)
+
+// 重审批次状态
+const (
+	ReauditBatchStatusRunning   = "重审中"
+	ReauditBatchStatusCompleted = "重审完成"
+)
+
+// 重审报销单状态
+const (
+	ReauditItemStatusPending   = "待重审"
+	ReauditItemStatusRunning   = "重审中"
+	ReauditItemStatusCompleted = "重审完成"
+	ReauditItemStatusFailed    = "重审失败"
+)
+
+// ErrReauditRuleDisabled 规则未启用，重审不会受该规则影响
+var ErrReauditRuleDisabled = errors.New("规则未启用，无需重审")
+
+// ReauditOptions 批量重审参数
+type ReauditOptions struct {
+	Lookback time.Duration // 只重审该时长内申请的报销单，非正数时使用默认时长
+	MaxItems int           // 单批最多重审的报销单数量，非正数时使用默认数量
+}
+
+// normalize 规范化批量重审参数，非法值回退为默认值
+func (o ReauditOptions) normalize() ReauditOptions {
+	if o.Lookback <= 0 {
+		o.Lookback = DefaultReauditLookback
+	}
+	if o.MaxItems <= 0 {
+		o.MaxItems = DefaultReauditMaxItems
+	}
+	return o
+}
+
+// ReauditItem 批次中单个报销单的重审情况
+type ReauditItem struct {
+	ReimbursementID string `json:"reimbursement_id"`   // 报销单ID
+	PreviousAuditID string `json:"previous_audit_id"`  // 重审前通过的审核ID
+	AuditID         string `json:"audit_id,omitempty"` // 重审生成的审核ID
+	Status          string `json:"status"`             // 重审状态
+	Pass            bool   `json:"pass"`               // 重审结论
+	NewlyFlagged    bool   `json:"newly_flagged"`      // 原审核通过、重审未通过
+	Error           string `json:"error,omitempty"`    // 重审失败原因
+}
+
+// ReauditBatch 规则变更触发的重审批次
+type ReauditBatch struct {
+	ID          string         `json:"id"`                     // 批次ID
+	RuleID      string         `json:"rule_id"`                // 触发重审的规则ID
+	RuleCode    string         `json:"rule_code"`              // 规则编码
+	Category    string         `json:"category"`               // 规则分类
+	Status      string         `json:"status"`                 // 批次状态
+	Total       int            `json:"total"`                  // 受影响的报销单数量
+	Completed   int            `json:"completed"`              // 已重审完成数量
+	Failed      int            `json:"failed"`                 // 重审失败数量
+	Flagged     int            `json:"flagged"`                // 重审后新发现问题的数量
+	Truncated   bool           `json:"truncated"`              // 受影响的报销单超过单批上限，只重审了前MaxItems个
+	Items       []*ReauditItem `json:"items"`                  // 各报销单的重审情况
+	CreatedAt   time.Time      `json:"created_at"`             // 创建时间
+	CompletedAt *time.Time     `json:"completed_at,omitempty"` // 全部重审结束时间
+}
+
+// reauditBatches 进程内的重审批次记录
+type reauditBatches struct {
+	mu      sync.Mutex
+	batches map[string]*ReauditBatch
+}
+
+// add 保存批次，超过保留数量时删除最早结束的批次
+func (b *reauditBatches) add(batch *ReauditBatch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.batches == nil {
+		b.batches = make(map[string]*ReauditBatch)
+	}
+	b.batches[batch.ID] = batch
+	if len(b.batches) <= maxReauditBatches {
+		return
+	}
+
+	finished := make([]*ReauditBatch, 0, len(b.batches))
+	for _, existing := range b.batches {
+		if existing.CompletedAt != nil {
+			finished = append(finished, existing)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CompletedAt.Before(*finished[j].CompletedAt)
+	})
+	for _, existing := range finished {
+		if len(b.batches) <= maxReauditBatches {
+			break
+		}
+		delete(b.batches, existing.ID)
+	}
+}
+
+// get 获取批次快照，快照与后台重审互不影响
+func (b *reauditBatches) get(batchID string) (*ReauditBatch, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	batch, ok := b.batches[batchID]
+	if !ok {
+		return nil, false
+	}
+
+	snapshot := *batch
+	snapshot.Items = make([]*ReauditItem, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		copied := *item
+		snapshot.Items = append(snapshot.Items, &copied)
+	}
+	return &snapshot, true
+}
+
+// update 在锁内修改批次，保证与快照读取互斥
+func (b *reauditBatches) update(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fn()
+}
+
+// ReauditAffected 批量重审受规则影响的报销单，返回已开始在后台执行的重审批次
+// 受影响的报销单：最近Lookback内申请、最近一次审核已完成且最终结论为通过、规则适用于其类别且在申请日期生效
+func (s *Service) ReauditAffected(ctx context.Context, ruleID string, opts ReauditOptions) (*ReauditBatch, error) {
+	opts = opts.normalize()
+
+	r, err := s.ruleService.GetRuleByID(ctx, ruleID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取规则失败",
+			logger.NewField("rule_id", ruleID),
+			logger.NewField("error", err))
+		return nil, fmt.Errorf("获取规则失败: %w", err)
+	}
+	if !r.Enabled {
+		return nil, fmt.Errorf("%w: %s", ErrReauditRuleDisabled, r.RuleCode)
+	}
+
+	items, truncated, err := s.findAffectedReimbursements(ctx, r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ReauditBatch{
+		ID:        uuid.New().String(),
+		RuleID:    r.ID,
+		RuleCode:  r.RuleCode,
+		Category:  r.Category,
+		Status:    ReauditBatchStatusRunning,
+		Total:     len(items),
+		Truncated: truncated,
+		Items:     items,
+		CreatedAt: time.Now(),
+	}
+	if len(items) == 0 {
+		completedAt := batch.CreatedAt
+		batch.Status = ReauditBatchStatusCompleted
+		batch.CompletedAt = &completedAt
+	}
+	s.reauditBatches.add(batch)
+
+	s.logger.WithContext(ctx).Info("开始批量重审受规则影响的报销单",
+		logger.NewField("batch_id", batch.ID),
+		logger.NewField("rule_id", r.ID),
+		logger.NewField("total", batch.Total),
+		logger.NewField("truncated", truncated))
+
+	snapshot, _ := s.reauditBatches.get(batch.ID)
+	if len(items) > 0 {
+		// 重审在请求结束后继续执行，保留链路ID等上下文信息但不随请求取消
+		go s.runReauditBatch(context.WithoutCancel(ctx), batch)
+	}
+	return snapshot, nil
+}
+
+// GetReauditBatch 查询重审批次进度
+func (s *Service) GetReauditBatch(ctx context.Context, batchID string) (*ReauditBatch, error) {
+	batch, ok := s.reauditBatches.get(batchID)
+	if !ok {
+		return nil, errs.NotFound("重审批次不存在")
+	}
+	return batch, nil
+}
+
+// findAffectedReimbursements 按创建时间倒序分页遍历近期完成的审核，查找受规则影响的报销单，超过MaxItems时截断
+// 以审核结论而非报销单状态筛选：自动审核通过的报销单不会因审核流转到已完成
+func (s *Service) findAffectedReimbursements(ctx context.Context, r *rule.Rule, opts ReauditOptions) ([]*ReauditItem, bool, error) {
+	since := time.Now().Add(-opts.Lookback)
+	filter := &AuditFilter{
+		Status:    AuditStatusCompleted,
+		StartTime: &since,
+		Size:      reauditPageSize,
+	}
+
+	items := make([]*ReauditItem, 0)
+	seen := make(map[string]bool)
+	for page := 1; ; page++ {
+		filter.Page = page
+		audits, total, err := s.repo.ListAudits(ctx, filter)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("查询审核记录失败", logger.NewField("error", err))
+			return nil, false, fmt.Errorf("查询审核记录失败: %w", err)
+		}
+
+		for _, previous := range audits {
+			// 同一报销单只按最近一次完成的审核判断
+			if seen[previous.ReimbursementID] {
+				continue
+			}
+			seen[previous.ReimbursementID] = true
+			if !previous.EffectivePass() {
+				continue
+			}
+			affected, err := s.isReauditCandidate(ctx, r, previous, since)
+			if err != nil {
+				return nil, false, err
+			}
+			if !affected {
+				continue
+			}
+			if len(items) == opts.MaxItems {
+				return items, true, nil
+			}
+			items = append(items, &ReauditItem{
+				ReimbursementID: previous.ReimbursementID,
+				PreviousAuditID: previous.ID,
+				Status:          ReauditItemStatusPending,
+			})
+		}
+
+		if len(audits) == 0 || int64(page*reauditPageSize) >= total {
+			return items, false, nil
+		}
+	}
+}
+
+// isReauditCandidate 判断通过的审核对应的报销单是否需要重审
+// 审核须为报销单最近一次审核（之后没有失败或待复核的审核），报销单在since之后申请且在规则适用范围内
+func (s *Service) isReauditCandidate(ctx context.Context, r *rule.Rule, previous *AuditResult, since time.Time) (bool, error) {
+	latest, err := s.repo.GetAuditByReimbursementID(ctx, previous.ReimbursementID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取审核记录失败",
+			logger.NewField("reimbursement_id", previous.ReimbursementID),
+			logger.NewField("error", err))
+		return false, fmt.Errorf("获取审核记录失败: %w", err)
+	}
+	if latest.ID != previous.ID {
+		return false, nil
+	}
+
+	reim, err := s.reimbursementRepo.GetReimbursementByID(ctx, previous.ReimbursementID)
+	if err != nil {
+		if errs.IsNotFound(err) {
+			return false, nil
+		}
+		s.logger.WithContext(ctx).Error("获取报销单失败",
+			logger.NewField("reimbursement_id", previous.ReimbursementID),
+			logger.NewField("error", err))
+		return false, fmt.Errorf("获取报销单失败: %w", err)
+	}
+	// 按申请日期比较，since当天申请的报销单也在范围内
+	if reim.ApplyDate.Format("2006-01-02") < since.Format("2006-01-02") {
+		return false, nil
+	}
+	return r.AppliesToCategory(reim.Type) && r.IsEffectiveAt(reim.ApplyDate), nil
+}
+
+// runReauditBatch 并发重审批次中的报销单，并发数不超过reauditConcurrency
+func (s *Service) runReauditBatch(ctx context.Context, batch *ReauditBatch) {
+	sem := make(chan struct{}, reauditConcurrency)
+	var wg sync.WaitGroup
+	for _, item := range batch.Items {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(item *ReauditItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.reauditItem(ctx, batch, item)
+		}(item)
+	}
+	wg.Wait()
+
+	var failed, flagged int
+	s.reauditBatches.update(func() {
+		completedAt := time.Now()
+		batch.Status = ReauditBatchStatusCompleted
+		batch.CompletedAt = &completedAt
+		failed, flagged = batch.Failed, batch.Flagged
+	})
+	s.logger.WithContext(ctx).Info("批量重审完成",
+		logger.NewField("batch_id", batch.ID),
+		logger.NewField("total", batch.Total),
+		logger.NewField("failed", failed),
+		logger.NewField("flagged", flagged))
+}
+
+// reauditItem 重审单个报销单并记录结论
+func (s *Service) reauditItem(ctx context.Context, batch *ReauditBatch, item *ReauditItem) {
+	s.reauditBatches.update(func() {
+		item.Status = ReauditItemStatusRunning
+	})
+
+	result, err := s.StartAudit(ctx, item.ReimbursementID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("重审报销单失败",
+			logger.NewField("batch_id", batch.ID),
+			logger.NewField("reimbursement_id", item.ReimbursementID),
+			logger.NewField("error", err))
+		s.reauditBatches.update(func() {
+			item.Status = ReauditItemStatusFailed
+			item.Error = err.Error()
+			batch.Failed++
+		})
+		return
+	}
+
+	s.reauditBatches.update(func() {
+		item.AuditID = result.ID
+		item.Status = ReauditItemStatusCompleted
+		item.Pass = result.EffectivePass()
+		item.NewlyFlagged = !item.Pass
+		batch.Completed++
+		if item.NewlyFlagged {
+			batch.Flagged++
+		}
+	})
+	if item.NewlyFlagged {
+		s.logger.WithContext(ctx).Warn("规则变更后重审未通过",
+			logger.NewField("batch_id", batch.ID),
+			logger.NewField("rule_id", batch.RuleID),
+			logger.NewField("reimbursement_id", item.ReimbursementID),
+			logger.NewField("audit_id", result.ID))
+	}
+}
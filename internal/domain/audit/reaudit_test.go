@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/domain/rule"
+)
+
+// amountLimitRule 差旅费金额上限规则，limit为GRL中的金额上限
+func amountLimitRule(limit string) *rule.Rule {
+	return &rule.Rule{
+		ID:       "r-limit",
+		RuleCode: "TRAVEL_LIMIT",
+		Name:     "差旅费金额上限",
+		Type:     "金额",
+		Category: "差旅费",
+		Enabled:  true,
+		Version:  1,
+		Definition: `rule TravelLimit "差旅费金额上限" salience 10 {
+	when
+		data["total_amount"] > ` + limit + ` && result.Passed == true
+	then
+		result.Passed = false;
+		result.Message = "超出差旅费金额上限";
+		Retract("TravelLimit");
+}`,
+	}
+}
+
+func TestReauditAffectedAfterStricterRule(t *testing.T) {
+	now := time.Now()
+	store := newMemStore()
+	reims := []*reimbursement.Reimbursement{
+		{ID: "r-travel", Type: "差旅费", TotalAmount: 800, ApplyDate: now, Status: reimbursement.StatusAuditing},
+		{ID: "r-small", Type: "差旅费", TotalAmount: 300, ApplyDate: now, Status: reimbursement.StatusAuditing},
+		{ID: "r-office", Type: "办公费", TotalAmount: 800, ApplyDate: now, Status: reimbursement.StatusAuditing},
+		{ID: "r-rejected", Type: "差旅费", TotalAmount: 800, ApplyDate: now, Status: reimbursement.StatusRejected},
+		{ID: "r-old", Type: "差旅费", TotalAmount: 800, ApplyDate: now.AddDate(0, 0, -60), Status: reimbursement.StatusAuditing},
+		{ID: "r-retried", Type: "差旅费", TotalAmount: 800, ApplyDate: now, Status: reimbursement.StatusPending},
+	}
+	for _, reim := range reims {
+		store.putReimbursement(reim)
+	}
+	audits := []*AuditResult{
+		// 自动审核通过，报销单仍在审核中
+		{ID: "a-travel", ReimbursementID: "r-travel", Status: AuditStatusCompleted, FinalPass: true},
+		{ID: "a-small", ReimbursementID: "r-small", Status: AuditStatusCompleted, FinalPass: true},
+		// 规则不适用的类别
+		{ID: "a-office", ReimbursementID: "r-office", Status: AuditStatusCompleted, FinalPass: true},
+		// 原审核未通过
+		{ID: "a-rejected", ReimbursementID: "r-rejected", Status: AuditStatusCompleted, FinalPass: false},
+		// 超出回溯时间
+		{ID: "a-old", ReimbursementID: "r-old", Status: AuditStatusCompleted, FinalPass: true},
+		// 通过后又有一次失败的审核，以最近一次为准
+		{ID: "a-retried-pass", ReimbursementID: "r-retried", Status: AuditStatusCompleted, FinalPass: true, CreatedAt: now.Add(-time.Hour)},
+		{ID: "a-retried-fail", ReimbursementID: "r-retried", Status: AuditStatusFailed, CreatedAt: now},
+	}
+	for _, a := range audits {
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = now.Add(-time.Minute)
+		}
+		store.putAudit(a)
+	}
+
+	// 上限从1000收紧到500，原先通过的800元差旅费报销单应被标记
+	service := newPipelineService(t, store, newMemRuleRepo(amountLimitRule("500")))
+	batch, err := service.ReauditAffected(context.Background(), "r-limit", ReauditOptions{})
+	if err != nil {
+		t.Fatalf("ReauditAffected() error = %v", err)
+	}
+
+	wantItems := map[string]bool{"r-travel": true, "r-small": true}
+	if batch.Total != len(wantItems) {
+		t.Fatalf("Total = %d, want %d, items = %+v", batch.Total, len(wantItems), batch.Items)
+	}
+	for _, item := range batch.Items {
+		if !wantItems[item.ReimbursementID] {
+			t.Errorf("不应重审报销单%s", item.ReimbursementID)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for batch.CompletedAt == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("重审批次未在5秒内完成")
+		}
+		time.Sleep(10 * time.Millisecond)
+		if batch, err = service.GetReauditBatch(context.Background(), batch.ID); err != nil {
+			t.Fatalf("GetReauditBatch() error = %v", err)
+		}
+	}
+
+	if batch.Completed != 2 || batch.Failed != 0 || batch.Flagged != 1 {
+		t.Fatalf("Completed/Failed/Flagged = %d/%d/%d, want 2/0/1", batch.Completed, batch.Failed, batch.Flagged)
+	}
+	for _, item := range batch.Items {
+		wantFlagged := item.ReimbursementID == "r-travel"
+		if item.NewlyFlagged != wantFlagged || item.Pass == wantFlagged {
+			t.Errorf("报销单%s NewlyFlagged/Pass = %v/%v, want %v/%v",
+				item.ReimbursementID, item.NewlyFlagged, item.Pass, wantFlagged, !wantFlagged)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// report.go 审核报告导出
+// 功能点：
+// 1. 按审核ID生成审核报告，包含审核概要、报销单信息、规则违规、发票校验、RAG结论、引用出处和处理建议
+// 2. 支持导出PDF和Excel(xlsx)，PDF内嵌所用字形的字体子集，中文正常显示
+// 3. 有人工改判时报告以改判结论为准，并列出改判信息
+// 4. 报销单已删除时仍可导出，报销单信息只保留报销单ID
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/pkg/errs"
+	"reimbursement-audit/internal/pkg/logger"
+	"reimbursement-audit/internal/pkg/report"
+)
+
+// 审核报告格式
+const (
+	ReportFormatPDF  = report.FormatPDF
+	ReportFormatXLSX = report.FormatXLSX
+)
+
+// maxReportReferenceRunes 引用出处内容在报告中保留的最大字符数
+const maxReportReferenceRunes = 300
+
+// reportTimeLayout 报告中的时间格式
+const reportTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	// ErrUnsupportedReportFormat 不支持的报告格式
+	ErrUnsupportedReportFormat = errors.New("不支持的报告格式，仅支持pdf和xlsx")
+	// ErrReportFontUnavailable 未配置PDF报告字体
+	ErrReportFontUnavailable = errors.New("未配置PDF报告字体，无法导出PDF报告")
+)
+
+// AuditReportFile 导出的审核报告文件
+type AuditReportFile struct {
+	FileName    string // 文件名
+	ContentType string // MIME类型
+	Data        []byte // 文件内容
+}
+
+// SetReportFont 设置PDF报告内嵌的字体，未设置时只能导出Excel报告
+func (s *Service) SetReportFont(font *report.Font) {
+	s.reportFont = font
+}
+
+// ExportAuditReport 导出审核报告，format为pdf或xlsx
+func (s *Service) ExportAuditReport(ctx context.Context, auditID, format string) (*AuditReportFile, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format != ReportFormatPDF && format != ReportFormatXLSX {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedReportFormat, format)
+	}
+	if format == ReportFormatPDF && s.reportFont == nil {
+		return nil, ErrReportFontUnavailable
+	}
+
+	audit, err := s.repo.GetAuditByID(ctx, auditID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取审核记录失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取审核记录失败: %w", err)
+	}
+
+	reim, err := s.reimbursementRepo.GetReimbursementByID(ctx, audit.ReimbursementID)
+	if err != nil {
+		if !errs.IsNotFound(err) {
+			s.logger.WithContext(ctx).Error("获取报销单失败", logger.NewField("error", err))
+			return nil, fmt.Errorf("获取报销单失败: %w", err)
+		}
+		s.logger.WithContext(ctx).Warn("报销单不存在，审核报告不包含报销单信息",
+			logger.NewField("audit_id", auditID),
+			logger.NewField("reimbursement_id", audit.ReimbursementID))
+		reim = nil
+	}
+
+	doc := BuildAuditReport(audit, reim, time.Now())
+	file := &AuditReportFile{FileName: "audit_report_" + audit.ID + "." + format}
+	switch format {
+	case ReportFormatPDF:
+		file.ContentType = report.ContentTypePDF
+		file.Data, err = report.RenderPDF(doc, s.reportFont)
+	default:
+		file.ContentType = report.ContentTypeXLSX
+		file.Data, err = report.RenderXLSX(doc)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("生成审核报告失败",
+			logger.NewField("audit_id", auditID),
+			logger.NewField("format", format),
+			logger.NewField("error", err))
+		return nil, fmt.Errorf("生成审核报告失败: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("导出审核报告",
+		logger.NewField("audit_id", auditID),
+		logger.NewField("format", format),
+		logger.NewField("size", len(file.Data)))
+	return file, nil
+}
+
+// BuildAuditReport 根据审核记录和报销单生成审核报告内容，报销单为nil时只输出报销单ID
+func BuildAuditReport(audit *AuditResult, reim *reimbursement.Reimbursement, generatedAt time.Time) *report.Document {
+	doc := &report.Document{
+		Title:    "报销审核报告",
+		Subtitle: "审核ID：" + audit.ID + "    生成时间：" + generatedAt.Format(reportTimeLayout),
+	}
+	doc.Sections = append(doc.Sections,
+		auditSummarySection(audit),
+		reimbursementSection(audit, reim),
+		ruleViolationSection(audit),
+	)
+	if section := invoiceSection(audit); section != nil {
+		doc.Sections = append(doc.Sections, section)
+	}
+	doc.Sections = append(doc.Sections, ragSection(audit), referenceSection(audit))
+	if len(audit.Suggestions) > 0 {
+		table := &report.Table{Headers: []string{"序号", "建议"}, Widths: []float64{1, 9}}
+		for i, suggestion := range audit.Suggestions {
+			table.Rows = append(table.Rows, []string{strconv.Itoa(i + 1), suggestion})
+		}
+		doc.Sections = append(doc.Sections, &report.Section{Title: "处理建议", Table: table})
+	}
+	return doc
+}
+
+// auditSummarySection 审核概要
+func auditSummarySection(audit *AuditResult) *report.Section {
+	section := &report.Section{Title: "审核概要"}
+	section.AddField("审核ID", audit.ID)
+	section.AddField("审核状态", string(audit.Status))
+	section.AddField("审核结论", reportVerdict(audit))
+	section.AddField("风险等级", audit.RiskLevel)
+	section.AddField("风险分数", strconv.FormatFloat(audit.RiskScore, 'f', 2, 64))
+	section.AddField("规则校验", passText(audit.RulePass))
+	section.AddField("RAG分析", passText(audit.RAGPass))
+	section.AddField("审核意见", audit.Reason)
+	section.AddField("提交时间", formatReportTime(audit.SubmittedAt))
+	if audit.CompletedAt != nil {
+		section.AddField("完成时间", formatReportTime(*audit.CompletedAt))
+	}
+	if audit.Overridden {
+		section.AddField("原审核结论", passText(audit.FinalPass))
+		section.AddField("改判理由", audit.OverrideReason)
+		section.AddField("改判人", audit.OverriddenBy)
+		if audit.OverriddenAt != nil {
+			section.AddField("改判时间", formatReportTime(*audit.OverriddenAt))
+		}
+	}
+	return section
+}
+
+// reimbursementSection 报销单信息
+func reimbursementSection(audit *AuditResult, reim *reimbursement.Reimbursement) *report.Section {
+	section := &report.Section{Title: "报销单信息"}
+	section.AddField("报销单ID", audit.ReimbursementID)
+	if reim == nil {
+		section.Paragraphs = append(section.Paragraphs, "报销单不存在或已删除")
+		return section
+	}
+
+	currency := reim.Currency
+	if currency == "" {
+		currency = "CNY"
+	}
+	section.AddField("报销标题", reim.Title)
+	section.AddField("申请人", reim.UserName)
+	section.AddField("所属部门", reim.Department)
+	section.AddField("报销类型", reim.Type)
+	section.AddField("报销金额", fmt.Sprintf("%.2f %s", reim.TotalAmount, currency))
+	section.AddField("申请日期", formatReportDate(reim.ApplyDate))
+	section.AddField("费用发生日期", formatReportDate(reim.ExpenseDate))
+	if !reim.StartDate.IsZero() || !reim.EndDate.IsZero() {
+		section.AddField("出差日期", formatReportDate(reim.StartDate)+" 至 "+formatReportDate(reim.EndDate))
+	}
+	section.AddField("出差城市", reim.City)
+	section.AddField("出差目的地", reim.Destination)
+	section.AddField("出差事由", reim.TravelReason)
+	section.AddField("项目编码", reim.ProjectCode)
+	section.AddField("报销描述", reim.Description)
+	section.AddField("发票张数", strconv.Itoa(len(reim.Invoices)))
+	return section
+}
+
+// ruleViolationSection 规则违规列表，只列出未通过的规则
+func ruleViolationSection(audit *AuditResult) *report.Section {
+	section := &report.Section{Title: "规则违规"}
+	table := &report.Table{
+		Headers: []string{"规则编码", "规则名称", "类型", "严重程度", "违规说明"},
+		Widths:  []float64{1.5, 2, 1, 1, 4.5},
+	}
+	for _, result := range audit.RuleResults {
+		if result == nil || result.Passed {
+			continue
+		}
+		message := result.Message
+		if result.ConflictNote != "" {
+			message += "（" + result.ConflictNote + "）"
+		}
+		table.Rows = append(table.Rows, []string{result.RuleCode, result.RuleName, result.RuleType, result.Severity, message})
+	}
+
+	section.Paragraphs = append(section.Paragraphs,
+		fmt.Sprintf("共执行%d条规则，%d条未通过", len(audit.RuleResults), len(table.Rows)))
+	if len(table.Rows) > 0 {
+		section.Table = table
+	}
+	return section
+}
+
+// invoiceSection 发票校验结果，无发票校验汇总时返回nil
+func invoiceSection(audit *AuditResult) *report.Section {
+	summary := audit.InvoiceSummary
+	if summary == nil {
+		return nil
+	}
+
+	section := &report.Section{Title: "发票校验"}
+	section.AddField("发票总数", strconv.Itoa(summary.TotalInvoices))
+	section.AddField("校验通过", strconv.Itoa(summary.PassedInvoices))
+	section.AddField("校验未通过", strconv.Itoa(summary.FailedInvoices))
+	if summary.ManualReviewInvoices > 0 {
+		section.AddField("需人工复核", strconv.Itoa(summary.ManualReviewInvoices))
+	}
+	section.AddField("处理建议", summary.Recommendation)
+
+	if len(summary.ControllingViolations) > 0 {
+		table := &report.Table{
+			Headers: []string{"发票号码", "规则名称", "严重程度", "违规说明"},
+			Widths:  []float64{2, 2, 1, 5},
+		}
+		for _, violation := range summary.ControllingViolations {
+			table.Rows = append(table.Rows, []string{violation.InvoiceNumber, violation.RuleName, violation.Severity, violation.Message})
+		}
+		section.Table = table
+	}
+	return section
+}
+
+// ragSection RAG分析结论
+func ragSection(audit *AuditResult) *report.Section {
+	section := &report.Section{Title: "RAG结论"}
+	result := audit.RAGResults
+	if result == nil {
+		section.Paragraphs = append(section.Paragraphs, "未进行RAG分析")
+		return section
+	}
+
+	section.AddField("分析结论", passText(audit.RAGPass))
+	section.AddField("置信度", strconv.FormatFloat(result.Confidence, 'f', 2, 64))
+	analysis := strings.TrimSpace(result.Analysis)
+	if analysis == "" {
+		analysis = strings.TrimSpace(result.Content)
+	}
+	if analysis != "" {
+		section.Paragraphs = append(section.Paragraphs, analysis)
+	}
+	return section
+}
+
+// referenceSection RAG引用出处，被结论引用的条目排在前面
+func referenceSection(audit *AuditResult) *report.Section {
+	section := &report.Section{Title: "引用出处"}
+	if audit.RAGResults == nil || len(audit.RAGResults.References) == 0 {
+		section.Paragraphs = append(section.Paragraphs, "无引用出处")
+		return section
+	}
+
+	table := &report.Table{
+		Headers: []string{"编号", "制度文档", "类别", "相似度", "是否引用", "内容摘要"},
+		Widths:  []float64{0.8, 2, 1, 1, 1, 5},
+	}
+	var cited, others [][]string
+	for _, reference := range audit.RAGResults.References {
+		if reference == nil {
+			continue
+		}
+		row := []string{
+			strconv.Itoa(reference.Index),
+			reference.DocumentID,
+			reference.Category,
+			strconv.FormatFloat(reference.Similarity, 'f', 3, 64),
+			yesNo(reference.Cited),
+			truncateReportText(reference.Content, maxReportReferenceRunes),
+		}
+		if reference.Cited {
+			cited = append(cited, row)
+		} else {
+			others = append(others, row)
+		}
+	}
+	table.Rows = append(cited, others...)
+	section.Table = table
+	return section
+}
+
+// reportVerdict 报告中的审核结论，审核未完成且未改判时为审核状态
+func reportVerdict(audit *AuditResult) string {
+	if !audit.Overridden && audit.Status != AuditStatusCompleted {
+		return string(audit.Status)
+	}
+	return auditVerdict(audit)
+}
+
+// passText 通过/未通过
+func passText(pass bool) string {
+	if pass {
+		return VerdictPass
+	}
+	return VerdictReject
+}
+
+// yesNo 是/否
+func yesNo(value bool) string {
+	if value {
+		return "是"
+	}
+	return "否"
+}
+
+// formatReportTime 格式化报告中的时间，零值返回空
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(reportTimeLayout)
+}
+
+// formatReportDate 格式化报告中的日期，零值返回空
+func formatReportDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// truncateReportText 截断过长的文本，合并多余空白
+func truncateReportText(text string, maxRunes int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes]) + "…"
+}
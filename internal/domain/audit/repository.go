@@ -21,4 +21,7 @@ type Repository interface {
 
 	// DeleteAudit 删除审核记录
 	DeleteAudit(ctx context.Context, id string) error
+
+	// Transaction 在同一事务中执行fn，fn内使用传入的ctx调用审核和报销单仓储，任一步失败时全部回滚
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
@@ -0,0 +1,322 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/domain/rag"
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/domain/rule"
+	"reimbursement-audit/internal/pkg/errs"
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+)
+
+// errStoreFailure 模拟的存储错误
+var errStoreFailure = errors.New("模拟数据库错误")
+
+// memStore 内存审核和报销单存储，Transaction中fn返回错误时回滚到事务开始前的数据
+type memStore struct {
+	mu     sync.Mutex
+	audits map[string]*AuditResult
+	reims  map[string]*reimbursement.Reimbursement
+	// failStatus 报销单更新为该状态时返回错误，用于验证事务回滚
+	failStatus string
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		audits: make(map[string]*AuditResult),
+		reims:  make(map[string]*reimbursement.Reimbursement),
+	}
+}
+
+// audit 获取审核记录副本
+func (m *memStore) audit(id string) *AuditResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, ok := m.audits[id]; ok {
+		c := *a
+		return &c
+	}
+	return nil
+}
+
+// reimbursement 获取报销单副本
+func (m *memStore) reimbursement(id string) *reimbursement.Reimbursement {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.reims[id]; ok {
+		c := *r
+		return &c
+	}
+	return nil
+}
+
+func (m *memStore) putAudit(a *AuditResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *a
+	m.audits[a.ID] = &c
+}
+
+func (m *memStore) putReimbursement(r *reimbursement.Reimbursement) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := *r
+	m.reims[r.ID] = &c
+}
+
+// memAuditRepo 内存审核仓储
+type memAuditRepo struct {
+	*memStore
+}
+
+func (r *memAuditRepo) CreateAudit(_ context.Context, a *AuditResult) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	r.putAudit(a)
+	return nil
+}
+
+func (r *memAuditRepo) GetAuditByID(_ context.Context, id string) (*AuditResult, error) {
+	if a := r.audit(id); a != nil {
+		return a, nil
+	}
+	return nil, errs.NotFound("审核记录不存在")
+}
+
+func (r *memAuditRepo) GetAuditByReimbursementID(ctx context.Context, reimbursementID string) (*AuditResult, error) {
+	audits, _, _ := r.ListAudits(ctx, &AuditFilter{ReimbursementID: reimbursementID})
+	if len(audits) == 0 {
+		return nil, errs.NotFound("审核记录不存在")
+	}
+	return audits[0], nil
+}
+
+func (r *memAuditRepo) UpdateAudit(_ context.Context, a *AuditResult) error {
+	if r.audit(a.ID) == nil {
+		return errs.NotFound("审核记录不存在")
+	}
+	r.putAudit(a)
+	return nil
+}
+
+// ListAudits 按报销单、状态、是否临时性失败和创建时间筛选，按创建时间倒序
+func (r *memAuditRepo) ListAudits(_ context.Context, filter *AuditFilter) ([]*AuditResult, int64, error) {
+	r.mu.Lock()
+	var results []*AuditResult
+	for _, a := range r.audits {
+		if filter != nil && filter.ReimbursementID != "" && a.ReimbursementID != filter.ReimbursementID {
+			continue
+		}
+		if filter != nil && filter.Status != "" && a.Status != filter.Status {
+			continue
+		}
+		if filter != nil && filter.Transient != nil && a.Transient != *filter.Transient {
+			continue
+		}
+		if filter != nil && filter.StartTime != nil && a.CreatedAt.Before(*filter.StartTime) {
+			continue
+		}
+		if filter != nil && filter.SLABreached != nil && a.SLABreached != *filter.SLABreached {
+			continue
+		}
+		c := *a
+		results = append(results, &c)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].CreatedAt.After(results[j].CreatedAt) })
+	total := int64(len(results))
+	if filter != nil && filter.Size > 0 {
+		start := (filter.Page - 1) * filter.Size
+		if start < 0 {
+			start = 0
+		}
+		if start > len(results) {
+			start = len(results)
+		}
+		end := start + filter.Size
+		if end > len(results) {
+			end = len(results)
+		}
+		results = results[start:end]
+	}
+	return results, total, nil
+}
+
+func (r *memAuditRepo) DeleteAudit(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.audits, id)
+	return nil
+}
+
+func (r *memAuditRepo) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.mu.Lock()
+	audits := make(map[string]*AuditResult, len(r.audits))
+	for id, a := range r.audits {
+		c := *a
+		audits[id] = &c
+	}
+	reims := make(map[string]*reimbursement.Reimbursement, len(r.reims))
+	for id, item := range r.reims {
+		c := *item
+		reims[id] = &c
+	}
+	r.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		r.mu.Lock()
+		r.audits, r.reims = audits, reims
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// memReimbursementRepo 内存报销单仓储，只实现审核服务用到的方法
+type memReimbursementRepo struct {
+	reimbursement.Repository
+	*memStore
+}
+
+func (r *memReimbursementRepo) GetReimbursementByID(_ context.Context, id string) (*reimbursement.Reimbursement, error) {
+	if item := r.reimbursement(id); item != nil {
+		return item, nil
+	}
+	return nil, errs.NotFound("报销单不存在")
+}
+
+// UpdateReimbursement 与MySQL仓储一致，按状态机条件更新
+func (r *memReimbursementRepo) UpdateReimbursement(_ context.Context, item *reimbursement.Reimbursement) error {
+	current := r.reimbursement(item.ID)
+	if current == nil {
+		return errs.NotFound("报销单不存在")
+	}
+	if r.failStatus != "" && item.Status == r.failStatus {
+		return fmt.Errorf("报销单无法更新为%s: %w", item.Status, errStoreFailure)
+	}
+	if err := reimbursement.ValidateStatusTransition(current.Status, item.Status); err != nil {
+		return err
+	}
+	r.putReimbursement(item)
+	return nil
+}
+
+func (r *memReimbursementRepo) ListUserReimbursementsSince(context.Context, string, time.Time, []string) ([]*reimbursement.Reimbursement, error) {
+	return nil, nil
+}
+
+// newMemService 创建使用内存仓储的审核服务，未配置规则和RAG服务
+func newMemService(t *testing.T, store *memStore) *Service {
+	t.Helper()
+	return NewService(&memAuditRepo{store}, &memReimbursementRepo{memStore: store}, nil, nil, loggertest.New(t))
+}
+
+// memRuleRepo 内存规则仓储，只实现审核服务用到的方法
+type memRuleRepo struct {
+	rule.Repository
+	mu    sync.Mutex
+	rules map[string]*rule.Rule
+}
+
+func newMemRuleRepo(rules ...*rule.Rule) *memRuleRepo {
+	repo := &memRuleRepo{rules: make(map[string]*rule.Rule)}
+	for _, r := range rules {
+		repo.put(r)
+	}
+	return repo
+}
+
+func (r *memRuleRepo) put(item *rule.Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *item
+	r.rules[item.ID] = &c
+}
+
+func (r *memRuleRepo) GetRuleByID(_ context.Context, id string) (*rule.Rule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if item, ok := r.rules[id]; ok {
+		c := *item
+		return &c, nil
+	}
+	return nil, errs.NotFound("规则不存在")
+}
+
+func (r *memRuleRepo) ListRules(_ context.Context, filter *rule.RuleFilter) ([]*rule.Rule, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rules := make([]*rule.Rule, 0, len(r.rules))
+	for _, item := range r.rules {
+		if filter != nil && filter.Enabled != nil && item.Enabled != *filter.Enabled {
+			continue
+		}
+		c := *item
+		rules = append(rules, &c)
+	}
+	return rules, int64(len(rules)), nil
+}
+
+// fakeAnalyzer 返回固定置信度的大模型审核分析，err不为空时返回错误
+type fakeAnalyzer struct {
+	confidence float64
+	err        error
+}
+
+func (a *fakeAnalyzer) AuditReimbursement(context.Context, map[string]interface{}, int) (*rag.RAGResult, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &rag.RAGResult{AnalysisResult: &rag.AnalysisResult{Conclusion: "符合制度", Confidence: a.confidence}}, nil
+}
+
+// newPipelineService 创建可完整执行审核流程的服务：内存仓储、真实规则引擎和固定结论的大模型分析
+func newPipelineService(t *testing.T, store *memStore, ruleRepo *memRuleRepo) *Service {
+	t.Helper()
+	log := loggertest.New(t)
+	service := newMemService(t, store)
+	service.ruleService = rule.NewRuleService(ruleRepo, log, rule.NewGRuleEngine(ruleRepo, log))
+	service.ragService = &fakeAnalyzer{confidence: 0.9}
+	return service
+}
+
+// blacklistValidator 按销售方黑名单校验发票，命中时产生高严重程度违规；err不为空时批量校验返回错误
+type blacklistValidator struct {
+	rule.InvoiceValidator
+	blacklist *rule.SellerBlacklist
+	err       error
+}
+
+func (v *blacklistValidator) ValidateBatch(ctx context.Context, reqs []*rule.InvoiceValidationRequest) ([]*rule.InvoiceValidationResult, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	results := make([]*rule.InvoiceValidationResult, 0, len(reqs))
+	for _, req := range reqs {
+		result := &rule.InvoiceValidationResult{Passed: true, InvoiceID: req.Invoice.ID}
+		blacklisted, err := v.blacklist.IsBlacklistedSeller(ctx, req.Invoice.SellerName, req.Invoice.SellerTaxNo)
+		if err != nil {
+			return nil, err
+		}
+		if blacklisted {
+			result.Passed = false
+			result.Violations = []*rule.InvoiceViolation{{
+				RuleID:   "RULE_SELLER_BLACKLIST",
+				RuleName: "销售方黑名单",
+				Severity: SeverityHigh,
+				Message:  "销售方已列入黑名单",
+			}}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,195 @@
+// risk_score.go 审核风险分数计算
+// 功能点：
+// 1. 定义风险分数计算模式（混合/确定性）
+// 2. 确定性模式仅依据规则校验结果计算，保证同一报销单多次审核分数一致
+// 3. RAG置信度可作为可选的附加分量
+// 4. 计算模式可配置
+// 5. 混合模式按违规严重程度累加可配置的分值，风险等级阈值可配置
+// 6. 发票校验的主要违规项与未通过规则一样计入风险分数
+
+package audit
+
+// RiskScoreMode 风险分数计算模式
+type RiskScoreMode string
+
+const (
+	// RiskScoreModeHybrid 混合模式：规则结果与RAG结论共同决定风险分数
+	RiskScoreModeHybrid RiskScoreMode = "hybrid"
+	// RiskScoreModeDeterministic 确定性模式：仅依据规则校验结果计算风险分数
+	RiskScoreModeDeterministic RiskScoreMode = "deterministic"
+)
+
+// DefaultRAGRiskWeight 确定性模式下RAG附加分量的默认权重
+const DefaultRAGRiskWeight = 0.2
+
+// 违规严重程度
+const (
+	SeverityHigh   = "高"
+	SeverityMedium = "中"
+	SeverityLow    = "低"
+)
+
+// RiskConfig 风险权重配置，用于按企业风控口径调整风险分数
+type RiskConfig struct {
+	SeverityWeights       map[string]float64 `json:"severity_weights"`        // 每条未通过规则按严重程度(高/中/低)累加的分值
+	DefaultSeverityWeight float64            `json:"default_severity_weight"` // 未标注严重程度的未通过规则累加的分值
+	RAGFailWeight         float64            `json:"rag_fail_weight"`         // RAG未通过累加的分值
+	RAGConfidenceWeight   float64            `json:"rag_confidence_weight"`   // RAG置信度不足分量的最大分值
+	HighThreshold         float64            `json:"high_threshold"`          // 高风险阈值
+	MediumThreshold       float64            `json:"medium_threshold"`        // 中风险阈值
+}
+
+// DefaultRiskConfig 默认风险权重配置
+// 单条高严重程度违规与历史"规则未通过0.5"一致，阈值与历史0.7/0.4一致
+func DefaultRiskConfig() RiskConfig {
+	return RiskConfig{
+		SeverityWeights: map[string]float64{
+			SeverityHigh:   0.5,
+			SeverityMedium: 0.3,
+			SeverityLow:    0.1,
+		},
+		DefaultSeverityWeight: 0.5,
+		RAGFailWeight:         0.3,
+		RAGConfidenceWeight:   0.2,
+		HighThreshold:         0.7,
+		MediumThreshold:       0.4,
+	}
+}
+
+// normalize 规范化风险权重配置，权重为负数或阈值非法时回退为默认值，权重为0表示该项不计分
+func (c RiskConfig) normalize() RiskConfig {
+	defaults := DefaultRiskConfig()
+
+	weights := make(map[string]float64, len(defaults.SeverityWeights))
+	for severity, weight := range defaults.SeverityWeights {
+		weights[severity] = weight
+	}
+	for severity, weight := range c.SeverityWeights {
+		if weight >= 0 {
+			weights[severity] = weight
+		}
+	}
+	c.SeverityWeights = weights
+
+	if c.DefaultSeverityWeight < 0 {
+		c.DefaultSeverityWeight = defaults.DefaultSeverityWeight
+	}
+	if c.RAGFailWeight < 0 {
+		c.RAGFailWeight = defaults.RAGFailWeight
+	}
+	if c.RAGConfidenceWeight < 0 {
+		c.RAGConfidenceWeight = defaults.RAGConfidenceWeight
+	}
+	if c.HighThreshold <= 0 || c.HighThreshold > 1 {
+		c.HighThreshold = defaults.HighThreshold
+	}
+	if c.MediumThreshold <= 0 || c.MediumThreshold >= c.HighThreshold {
+		c.MediumThreshold = defaults.MediumThreshold
+		if c.MediumThreshold >= c.HighThreshold {
+			c.MediumThreshold = c.HighThreshold / 2
+		}
+	}
+	return c
+}
+
+// severityWeight 获取违规严重程度对应的分值
+func (c RiskConfig) severityWeight(severity string) float64 {
+	if weight, ok := c.SeverityWeights[severity]; ok {
+		return weight
+	}
+	return c.DefaultSeverityWeight
+}
+
+// RiskScoreOptions 风险分数计算选项
+type RiskScoreOptions struct {
+	Mode       RiskScoreMode `json:"mode"`        // 计算模式
+	IncludeRAG bool          `json:"include_rag"` // 确定性模式下是否叠加RAG分量
+	RAGWeight  float64       `json:"rag_weight"`  // RAG分量权重(0-1)
+}
+
+// DefaultRiskScoreOptions 默认风险分数计算选项，与历史行为保持一致
+func DefaultRiskScoreOptions() RiskScoreOptions {
+	return RiskScoreOptions{
+		Mode:      RiskScoreModeHybrid,
+		RAGWeight: DefaultRAGRiskWeight,
+	}
+}
+
+// normalize 规范化计算选项，非法值回退为默认值
+func (o RiskScoreOptions) normalize() RiskScoreOptions {
+	if o.Mode != RiskScoreModeDeterministic {
+		o.Mode = RiskScoreModeHybrid
+	}
+	if o.RAGWeight <= 0 || o.RAGWeight > 1 {
+		o.RAGWeight = DefaultRAGRiskWeight
+	}
+	return o
+}
+
+// calculateHybridRiskScore 混合模式风险分数
+// 每条未通过规则按严重程度累加分值，RAG未通过累加RAGFailWeight，RAG置信度不足最多累加RAGConfidenceWeight
+func calculateHybridRiskScore(audit *AuditResult, config RiskConfig) float64 {
+	riskScore := 0.0
+
+	for _, result := range audit.RuleResults {
+		if result != nil && !result.Passed && !result.Overridden {
+			riskScore += config.severityWeight(result.Severity)
+		}
+	}
+	if audit.InvoiceSummary != nil {
+		for _, violation := range audit.InvoiceSummary.ControllingViolations {
+			riskScore += config.severityWeight(violation.Severity)
+		}
+	}
+
+	if !audit.RAGPass {
+		riskScore += config.RAGFailWeight
+	}
+
+	if audit.RAGResults != nil {
+		riskScore += (1.0 - audit.RAGResults.Confidence) * config.RAGConfidenceWeight
+	}
+
+	return clampRiskScore(riskScore)
+}
+
+// calculateDeterministicRiskScore 确定性模式风险分数
+// 规则分量：存在未通过规则时基础分0.5，再按未通过比例叠加至多0.5
+// RAG分量：仅在IncludeRAG开启时按(1-置信度)*权重叠加
+func calculateDeterministicRiskScore(audit *AuditResult, options RiskScoreOptions) float64 {
+	riskScore := 0.0
+
+	total := len(audit.RuleResults)
+	failed := 0
+	for _, result := range audit.RuleResults {
+		if result != nil && !result.Passed && !result.Overridden {
+			failed++
+		}
+	}
+	// 未通过的发票按未通过规则计
+	if audit.InvoiceSummary != nil {
+		total += audit.InvoiceSummary.TotalInvoices
+		failed += audit.InvoiceSummary.FailedInvoices
+	}
+
+	if failed > 0 {
+		riskScore = 0.5 + 0.5*float64(failed)/float64(total)
+	}
+
+	if options.IncludeRAG && audit.RAGResults != nil {
+		riskScore += (1.0 - audit.RAGResults.Confidence) * options.RAGWeight
+	}
+
+	return clampRiskScore(riskScore)
+}
+
+// clampRiskScore 将风险分数限制在[0, 1]区间
+func clampRiskScore(riskScore float64) float64 {
+	if riskScore < 0 {
+		return 0
+	}
+	if riskScore > 1.0 {
+		return 1.0
+	}
+	return riskScore
+}
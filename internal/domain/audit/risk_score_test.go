@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRiskConfigNormalize(t *testing.T) {
+	defaults := DefaultRiskConfig()
+
+	tests := []struct {
+		name   string
+		config RiskConfig
+		want   RiskConfig
+	}{
+		{
+			name:   "零值权重保留为不计分",
+			config: RiskConfig{HighThreshold: 0.7, MediumThreshold: 0.4},
+			want: RiskConfig{
+				SeverityWeights: defaults.SeverityWeights,
+				HighThreshold:   0.7, MediumThreshold: 0.4,
+			},
+		},
+		{
+			name: "负数权重回退为默认值",
+			config: RiskConfig{
+				DefaultSeverityWeight: -1, RAGFailWeight: -0.1, RAGConfidenceWeight: -0.2,
+				HighThreshold: 0.8, MediumThreshold: 0.5,
+			},
+			want: RiskConfig{
+				SeverityWeights:       defaults.SeverityWeights,
+				DefaultSeverityWeight: defaults.DefaultSeverityWeight,
+				RAGFailWeight:         defaults.RAGFailWeight,
+				RAGConfidenceWeight:   defaults.RAGConfidenceWeight,
+				HighThreshold:         0.8, MediumThreshold: 0.5,
+			},
+		},
+		{
+			name: "严重程度权重为0时覆盖默认值，负数忽略",
+			config: RiskConfig{
+				SeverityWeights: map[string]float64{SeverityLow: 0, SeverityHigh: -1},
+				HighThreshold:   0.7, MediumThreshold: 0.4,
+			},
+			want: RiskConfig{
+				SeverityWeights: map[string]float64{SeverityHigh: 0.5, SeverityMedium: 0.3, SeverityLow: 0},
+				HighThreshold:   0.7, MediumThreshold: 0.4,
+			},
+		},
+		{
+			name:   "非法阈值回退为默认值",
+			config: RiskConfig{HighThreshold: 1.5, MediumThreshold: 0.9},
+			want: RiskConfig{
+				SeverityWeights: defaults.SeverityWeights,
+				HighThreshold:   defaults.HighThreshold, MediumThreshold: defaults.MediumThreshold,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.config.normalize()
+			if got.DefaultSeverityWeight != tt.want.DefaultSeverityWeight ||
+				got.RAGFailWeight != tt.want.RAGFailWeight ||
+				got.RAGConfidenceWeight != tt.want.RAGConfidenceWeight ||
+				got.HighThreshold != tt.want.HighThreshold ||
+				got.MediumThreshold != tt.want.MediumThreshold {
+				t.Fatalf("normalize() = %+v, want %+v", got, tt.want)
+			}
+			if len(got.SeverityWeights) != len(tt.want.SeverityWeights) {
+				t.Fatalf("SeverityWeights = %v, want %v", got.SeverityWeights, tt.want.SeverityWeights)
+			}
+			for severity, weight := range tt.want.SeverityWeights {
+				if got.SeverityWeights[severity] != weight {
+					t.Fatalf("SeverityWeights[%s] = %v, want %v", severity, got.SeverityWeights[severity], weight)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateHybridRiskScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RiskConfig
+		audit  *AuditResult
+		want   float64
+	}{
+		{
+			name:   "按严重程度累加未通过规则",
+			config: DefaultRiskConfig(),
+			audit: &AuditResult{
+				RAGPass: true,
+				RuleResults: []*RuleValidationResult{
+					{Severity: SeverityMedium},
+					{Severity: SeverityLow},
+					{Severity: SeverityHigh, Passed: true},
+				},
+			},
+			want: 0.4,
+		},
+		{
+			name:   "被覆盖的规则不计分",
+			config: DefaultRiskConfig(),
+			audit: &AuditResult{
+				RAGPass:     true,
+				RuleResults: []*RuleValidationResult{{Severity: SeverityHigh, Overridden: true}},
+			},
+			want: 0,
+		},
+		{
+			name:   "RAG未通过权重为0时不计分",
+			config: RiskConfig{RAGFailWeight: 0, HighThreshold: 0.7, MediumThreshold: 0.4}.normalize(),
+			audit:  &AuditResult{RAGPass: false},
+			want:   0,
+		},
+		{
+			name:   "未标注严重程度使用默认分值",
+			config: DefaultRiskConfig(),
+			audit: &AuditResult{
+				RAGPass:     false,
+				RuleResults: []*RuleValidationResult{{}},
+			},
+			want: 0.8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateHybridRiskScore(tt.audit, tt.config); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("calculateHybridRiskScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRiskScoreOptionsNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		options RiskScoreOptions
+		want    RiskScoreOptions
+	}{
+		{
+			name:    "未知模式回退为混合模式",
+			options: RiskScoreOptions{Mode: "unknown", RAGWeight: 0.5},
+			want:    RiskScoreOptions{Mode: RiskScoreModeHybrid, RAGWeight: 0.5},
+		},
+		{
+			name:    "确定性模式保留",
+			options: RiskScoreOptions{Mode: RiskScoreModeDeterministic, IncludeRAG: true, RAGWeight: 1},
+			want:    RiskScoreOptions{Mode: RiskScoreModeDeterministic, IncludeRAG: true, RAGWeight: 1},
+		},
+		{
+			name:    "RAG权重超出范围回退为默认值",
+			options: RiskScoreOptions{Mode: RiskScoreModeDeterministic, RAGWeight: 1.5},
+			want:    RiskScoreOptions{Mode: RiskScoreModeDeterministic, RAGWeight: DefaultRAGRiskWeight},
+		},
+		{
+			name:    "RAG权重为0回退为默认值",
+			options: RiskScoreOptions{},
+			want:    DefaultRiskScoreOptions(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.normalize(); got != tt.want {
+				t.Fatalf("normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateDeterministicRiskScore(t *testing.T) {
+	lowConfidence := &RAGAnalysisResult{Confidence: 0.5}
+
+	tests := []struct {
+		name    string
+		options RiskScoreOptions
+		audit   *AuditResult
+		want    float64
+	}{
+		{
+			name:    "规则全部通过为0",
+			options: DefaultRiskScoreOptions(),
+			audit:   &AuditResult{RuleResults: []*RuleValidationResult{{Passed: true}, {Passed: true}}},
+			want:    0,
+		},
+		{
+			name:    "按未通过比例叠加",
+			options: DefaultRiskScoreOptions(),
+			audit:   &AuditResult{RuleResults: []*RuleValidationResult{{Passed: true}, {Passed: false}}},
+			want:    0.75,
+		},
+		{
+			name:    "被覆盖的规则不计入未通过",
+			options: DefaultRiskScoreOptions(),
+			audit:   &AuditResult{RuleResults: []*RuleValidationResult{{Passed: true}, {Overridden: true}}},
+			want:    0,
+		},
+		{
+			name:    "未开启RAG分量时忽略置信度",
+			options: RiskScoreOptions{Mode: RiskScoreModeDeterministic, RAGWeight: 0.2},
+			audit:   &AuditResult{RAGResults: lowConfidence},
+			want:    0,
+		},
+		{
+			name:    "开启RAG分量时按置信度叠加",
+			options: RiskScoreOptions{Mode: RiskScoreModeDeterministic, IncludeRAG: true, RAGWeight: 0.2},
+			audit:   &AuditResult{RAGResults: lowConfidence},
+			want:    0.1,
+		},
+		{
+			name:    "分数不超过1",
+			options: RiskScoreOptions{Mode: RiskScoreModeDeterministic, IncludeRAG: true, RAGWeight: 1},
+			audit:   &AuditResult{RuleResults: []*RuleValidationResult{{}}, RAGResults: lowConfidence},
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateDeterministicRiskScore(tt.audit, tt.options); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("calculateDeterministicRiskScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
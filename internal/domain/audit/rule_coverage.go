@@ -0,0 +1,55 @@
+// rule_coverage.go 规则覆盖检查
+// 功能点：
+// 1. 配置哪些报销类别必须有启用的审核规则
+// 2. 规则库未加载任何规则时，不将空的规则校验结果视为通过
+// 3. 将此类审核标记为待人工复核，并给出原因和建议
+
+package audit
+
+import "strings"
+
+// 未加载规则时的审核结论
+const (
+	reasonNoRulesLoaded     = "未加载任何审核规则，无法自动判定，需人工复核"
+	suggestionNoRulesLoaded = "规则库中没有启用的审核规则，请检查规则配置或规则引擎启动日志后重新审核"
+)
+
+// RuleCoveragePolicy 规则覆盖策略
+type RuleCoveragePolicy struct {
+	AllowEmpty bool     `json:"allow_empty"` // 是否允许在没有任何规则时自动通过规则校验
+	Categories []string `json:"categories"`  // 必须有规则的报销类别，为空表示所有类别都必须有规则
+}
+
+// SetRuleCoveragePolicy 设置规则覆盖策略
+func (s *Service) SetRuleCoveragePolicy(policy RuleCoveragePolicy) {
+	s.ruleCoverage = policy
+}
+
+// requiresRules 判断报销类别是否必须有审核规则
+func (p RuleCoveragePolicy) requiresRules(category string) bool {
+	if p.AllowEmpty {
+		return false
+	}
+	if len(p.Categories) == 0 {
+		return true
+	}
+	for _, c := range p.Categories {
+		if strings.TrimSpace(c) == category {
+			return true
+		}
+	}
+	return false
+}
+
+// isRuleCoverageMissing 判断审核是否因缺少规则而无法得出结论
+func (s *Service) isRuleCoverageMissing(category string, results []*RuleValidationResult) bool {
+	return len(results) == 0 && s.ruleCoverage.requiresRules(category)
+}
+
+// applyManualReview 将缺少规则的审核标记为待人工复核，最终结论不通过
+func applyManualReview(audit *AuditResult) {
+	audit.FinalPass = false
+	audit.Status = AuditStatusManualReview
+	audit.Reason = reasonNoRulesLoaded
+	audit.Suggestions = []string{suggestionNoRulesLoaded}
+}
@@ -0,0 +1,43 @@
+package audit
+
+import "testing"
+
+func TestIsRuleCoverageMissing(t *testing.T) {
+	results := []*RuleValidationResult{{RuleID: "r1", Passed: true}}
+
+	tests := []struct {
+		name     string
+		policy   RuleCoveragePolicy
+		category string
+		results  []*RuleValidationResult
+		want     bool
+	}{
+		{name: "默认策略下没有规则结果", category: "差旅", want: true},
+		{name: "有规则结果时不缺失", category: "差旅", results: results, want: false},
+		{name: "允许为空时不缺失", policy: RuleCoveragePolicy{AllowEmpty: true}, category: "差旅", want: false},
+		{name: "配置的类别必须有规则", policy: RuleCoveragePolicy{Categories: []string{" 差旅 ", "招待"}}, category: "差旅", want: true},
+		{name: "未配置的类别不要求规则", policy: RuleCoveragePolicy{Categories: []string{"招待"}}, category: "差旅", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{}
+			s.SetRuleCoveragePolicy(tt.policy)
+			if got := s.isRuleCoverageMissing(tt.category, tt.results); got != tt.want {
+				t.Errorf("isRuleCoverageMissing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyManualReview(t *testing.T) {
+	audit := &AuditResult{FinalPass: true, Status: AuditStatusCompleted}
+	applyManualReview(audit)
+
+	if audit.FinalPass || audit.Status != AuditStatusManualReview {
+		t.Errorf("FinalPass = %v, Status = %s, want false, %s", audit.FinalPass, audit.Status, AuditStatusManualReview)
+	}
+	if audit.Reason != reasonNoRulesLoaded || len(audit.Suggestions) != 1 || audit.Suggestions[0] != suggestionNoRulesLoaded {
+		t.Errorf("Reason = %q, Suggestions = %v", audit.Reason, audit.Suggestions)
+	}
+}
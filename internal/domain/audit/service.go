@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"time"
 
+	"reimbursement-audit/internal/domain/ocr"
 	"reimbursement-audit/internal/domain/rag"
 	"reimbursement-audit/internal/domain/reimbursement"
 	"reimbursement-audit/internal/domain/rule"
 	"reimbursement-audit/internal/pkg/logger"
+	"reimbursement-audit/internal/pkg/report"
 
 	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
 )
 
 // Service 审核服务
@@ -19,10 +22,39 @@ type Service struct {
 	repo              Repository
 	reimbursementRepo reimbursement.Repository
 	ruleService       *rule.RuleService
-	ragService        *rag.RAGService
+	ragService        reimbursementAnalyzer
+	invoiceValidator  rule.InvoiceValidator
+	notifier          Notifier
+	riskScoreOptions  RiskScoreOptions
+	riskConfig        RiskConfig
+	sla               time.Duration
+	maxRetries        int
+	ruleCoverage      RuleCoveragePolicy
+	verdictRenderer   *VerdictRenderer
+	attestationSigner *AttestationSigner
+	reportFont        *report.Font
+	ragTopK           int
+	categoryTopK      map[string]int
+	concurrencyMode   string
+	auditLocks        reimbursementLocks
+	reauditBatches    reauditBatches
 	logger            logger.Logger
 }
 
+// reimbursementAnalyzer 大模型审核分析，由RAG服务实现
+type reimbursementAnalyzer interface {
+	AuditReimbursement(ctx context.Context, reimbursementInfo map[string]interface{}, topK int) (*rag.RAGResult, error)
+}
+
+// DefaultMaxRetries 同一报销单默认最大连续重试次数
+const DefaultMaxRetries = 3
+
+// ErrRetryLimitExceeded 超出最大连续重试次数
+var ErrRetryLimitExceeded = errors.New("超出最大重试次数")
+
+// ErrInvalidAuditFilter 审核列表查询条件不合法
+var ErrInvalidAuditFilter = errors.New("审核查询条件不合法")
+
 // NewService 创建审核服务
 func NewService(
 	repo Repository,
@@ -36,81 +68,224 @@ func NewService(
 		reimbursementRepo: reimbursementRepo,
 		ruleService:       ruleService,
 		ragService:        ragService,
+		riskScoreOptions:  DefaultRiskScoreOptions(),
+		riskConfig:        DefaultRiskConfig(),
+		maxRetries:        DefaultMaxRetries,
+		ragTopK:           DefaultRAGTopK,
+		concurrencyMode:   ConcurrencyModeWait,
+		verdictRenderer:   defaultVerdictRenderer(),
 		logger:            logger,
 	}
 }
 
+// SetNotifier 设置审核结果通知器
+func (s *Service) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// SetInvoiceValidator 设置发票校验器，设置后审核时逐张校验发票并生成汇总
+func (s *Service) SetInvoiceValidator(validator rule.InvoiceValidator) {
+	s.invoiceValidator = validator
+}
+
+// SetRiskScoreOptions 设置风险分数计算选项
+func (s *Service) SetRiskScoreOptions(options RiskScoreOptions) {
+	s.riskScoreOptions = options.normalize()
+}
+
+// SetRiskConfig 设置风险权重与风险等级阈值
+func (s *Service) SetRiskConfig(config RiskConfig) {
+	s.riskConfig = config.normalize()
+}
+
+// SetMaxRetries 设置同一报销单最大连续重试次数，非正数时使用默认值
+func (s *Service) SetMaxRetries(maxRetries int) {
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	s.maxRetries = maxRetries
+}
+
+// SetSLA 设置审核时效要求（提交到审核完成的最长时长），0表示不跟踪超时
+func (s *Service) SetSLA(sla time.Duration) {
+	if sla < 0 {
+		sla = 0
+	}
+	s.sla = sla
+}
+
 // StartAudit 开始审核
 func (s *Service) StartAudit(ctx context.Context, reimbursementID string) (*AuditResult, error) {
+	return s.startAudit(ctx, reimbursementID, "", 1)
+}
+
+// startAudit 执行审核，retryOf为被重试的审核ID，attempt为第几次尝试（首次为1）
+func (s *Service) startAudit(ctx context.Context, reimbursementID, retryOf string, attempt int) (*AuditResult, error) {
 	startTime := time.Now()
 
 	s.logger.WithContext(ctx).Info("开始审核", logger.NewField("reimbursement_id", reimbursementID))
 
+	// 同一报销单同一时间只执行一个审核
+	unlock, err := s.lockReimbursement(ctx, reimbursementID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取报销单审核锁失败",
+			logger.NewField("reimbursement_id", reimbursementID),
+			logger.NewField("error", err))
+		return nil, err
+	}
+	defer unlock()
+
 	reimbursement, err := s.reimbursementRepo.GetReimbursementByID(ctx, reimbursementID)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("获取报销单失败", logger.NewField("error", err))
 		return nil, fmt.Errorf("获取报销单失败: %w", err)
 	}
 
+	// 以报销单创建时间作为提交时间
+	submittedAt := reimbursement.CreatedAt
+	if submittedAt.IsZero() {
+		submittedAt = startTime
+	}
+
 	audit := &AuditResult{
 		ID:              uuid.New().String(),
 		ReimbursementID: reimbursementID,
 		Status:          AuditStatusRunning,
+		SubmittedAt:     submittedAt,
+		RetryOf:         retryOf,
+		Attempt:         attempt,
 		StartedAt:       startTime,
 		CreatedAt:       startTime,
 		UpdatedAt:       startTime,
 	}
 
-	if err := s.repo.CreateAudit(ctx, audit); err != nil {
-		s.logger.WithContext(ctx).Error("创建审核记录失败", logger.NewField("error", err))
-		return nil, fmt.Errorf("创建审核记录失败: %w", err)
+	// 报销单与审核记录一起进入审核中
+	startPath, err := reimbursement.AuditStartPath()
+	if err != nil {
+		s.logger.WithContext(ctx).Error("报销单当前状态不能开始审核",
+			logger.NewField("reimbursement_id", reimbursementID),
+			logger.NewField("status", reimbursement.Status),
+			logger.NewField("error", err))
+		return nil, err
 	}
-
-	ruleResults, err := s.executeRuleValidation(ctx, reimbursement)
+	err = s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateAudit(ctx, audit); err != nil {
+			s.logger.WithContext(ctx).Error("创建审核记录失败", logger.NewField("error", err))
+			return fmt.Errorf("创建审核记录失败: %w", err)
+		}
+		return s.transitReimbursement(ctx, reimbursement, startPath)
+	})
 	if err != nil {
-		s.logger.WithContext(ctx).Error("规则校验失败", logger.NewField("error", err))
-		audit.Status = AuditStatusFailed
-		audit.Reason = fmt.Sprintf("规则校验失败: %s", err.Error())
-		audit.CompletedAt = &startTime
-		audit.Duration = time.Since(startTime).Milliseconds()
-		s.repo.UpdateAudit(ctx, audit)
 		return nil, err
 	}
 
-	audit.RuleResults = ruleResults
-	rulePass := s.checkRulePass(ruleResults)
-	audit.RulePass = rulePass
-
+	// 规则校验与RAG分析互不依赖，并行执行；任一失败时取消另一个
+	// 各阶段结果写入局部变量，汇合后再赋值给audit，避免并发写入
+	var (
+		ruleResults      []*RuleValidationResult
+		invoiceSummary   *InvoiceAuditSummary
+		appliedStandards []*rule.AppliedLimitStandard
+		ragResult        *RAGAnalysisResult
+		ruleErr          error
+		invoiceErr       error
+	)
 	reimbursementInfo := s.buildReimbursementInfo(reimbursement)
-	ragResult, err := s.executeRAGAnalysis(ctx, reimbursementInfo)
-	if err != nil {
-		s.logger.WithContext(ctx).Error("RAG分析失败", logger.NewField("error", err))
+	// 大模型审核需要逐张发票的明细，规则校验数据只使用汇总信息
+	reimbursementInfo[rag.ReimbursementInvoicesKey] = buildInvoiceDetails(reimbursement.Invoices)
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		ruleResults, ruleErr = s.executeRuleValidation(groupCtx, reimbursement)
+		if ruleErr != nil {
+			return ruleErr
+		}
+		// 发票校验失败不中断审核，审核结论转人工复核
+		invoiceSummary, appliedStandards, invoiceErr = s.executeInvoiceValidation(groupCtx, reimbursement)
+		return nil
+	})
+	group.Go(func() error {
+		var err error
+		ragResult, err = s.executeRAGAnalysis(groupCtx, reimbursementInfo)
+		return err
+	})
+
+	if err := group.Wait(); err != nil {
+		stage := "RAG分析"
+		if err == ruleErr {
+			stage = "规则校验"
+		}
+		s.logger.WithContext(ctx).Error(stage+"失败", logger.NewField("error", err))
+		failedTime := time.Now()
 		audit.Status = AuditStatusFailed
-		audit.Reason = fmt.Sprintf("RAG分析失败: %s", err.Error())
-		audit.CompletedAt = &startTime
-		audit.Duration = time.Since(startTime).Milliseconds()
-		s.repo.UpdateAudit(ctx, audit)
+		audit.Reason = fmt.Sprintf("%s失败: %s", stage, err.Error())
+		audit.Transient = IsTransientError(err)
+		audit.CompletedAt = &failedTime
+		audit.Duration = failedTime.Sub(startTime).Milliseconds()
+		applySLA(audit, failedTime, s.sla)
+		if saveErr := s.saveAuditOutcome(ctx, audit, reimbursement); saveErr != nil {
+			s.logger.WithContext(ctx).Error("保存失败的审核记录失败",
+				logger.NewField("audit_id", audit.ID),
+				logger.NewField("error", saveErr))
+		}
 		return nil, err
 	}
 
+	audit.RuleResults = ruleResults
+	audit.RulePass = s.checkRulePass(ruleResults)
+	// 必须有规则的类别没有执行任何规则时，空结果不视为通过
+	rulesMissing := s.isRuleCoverageMissing(reimbursement.Type, ruleResults)
+	if rulesMissing {
+		audit.RulePass = false
+	}
+	audit.InvoiceSummary = invoiceSummary
+	audit.AppliedStandards = appliedStandards
 	audit.RAGResults = ragResult
 	audit.RAGPass = ragResult != nil && ragResult.Confidence > 0.6
 
-	audit.FinalPass = audit.RulePass && audit.RAGPass
+	// 发票校验失败或存在高严重程度违规发票时不能通过，即使规则校验和RAG分析均通过
+	audit.FinalPass = audit.RulePass && audit.RAGPass && invoiceErr == nil && !invoiceSummary.rejects()
 	audit.RiskScore = s.calculateRiskScore(audit)
 	audit.RiskLevel = s.determineRiskLevel(audit.RiskScore)
-	audit.Suggestions = s.generateSuggestions(audit)
-	audit.Reason = s.generateAuditReason(audit)
+	audit.Suggestions = s.generateSuggestions(audit, reimbursement.Department)
+	audit.Reason = s.generateAuditReason(audit, reimbursement.Department)
 
 	completedTime := time.Now()
 	audit.CompletedAt = &completedTime
 	audit.Duration = completedTime.Sub(startTime).Milliseconds()
 	audit.Status = AuditStatusCompleted
 	audit.UpdatedAt = completedTime
-
-	if err := s.repo.UpdateAudit(ctx, audit); err != nil {
-		s.logger.WithContext(ctx).Error("更新审核记录失败", logger.NewField("error", err))
-		return nil, fmt.Errorf("更新审核记录失败: %w", err)
+	if rulesMissing {
+		applyManualReview(audit)
+		s.logger.WithContext(ctx).Warn("未加载任何审核规则，审核转人工复核",
+			logger.NewField("audit_id", audit.ID),
+			logger.NewField("category", reimbursement.Type))
+	} else if invoiceErr != nil {
+		applyInvoiceValidationFailure(audit, invoiceErr)
+		s.logger.WithContext(ctx).Warn("发票校验失败，审核转人工复核",
+			logger.NewField("audit_id", audit.ID),
+			logger.NewField("error", invoiceErr))
+	} else if invoiceSummary.requiresManualReview() {
+		applyInvoiceManualReview(audit)
+		s.logger.WithContext(ctx).Warn("发票OCR识别置信度不足，审核转人工复核",
+			logger.NewField("audit_id", audit.ID),
+			logger.NewField("manual_review_invoices", invoiceSummary.ManualReviewInvoices))
+	} else if invoiceSummary.rejects() {
+		applyInvoiceRejection(audit)
+		s.logger.WithContext(ctx).Warn("发票存在高严重程度违规，审核不通过",
+			logger.NewField("audit_id", audit.ID),
+			logger.NewField("failed_invoices", invoiceSummary.FailedInvoices))
+	}
+	applySLA(audit, completedTime, s.sla)
+
+	if audit.SLABreached {
+		s.logger.WithContext(ctx).Warn("审核超出SLA",
+			logger.NewField("audit_id", audit.ID),
+			logger.NewField("turnaround_time", audit.TurnaroundTime),
+			logger.NewField("sla", s.sla.Milliseconds()))
+	}
+
+	if err := s.saveAuditOutcome(ctx, audit, reimbursement); err != nil {
+		return nil, err
 	}
 
 	s.logger.WithContext(ctx).Info("审核完成",
@@ -119,9 +294,53 @@ func (s *Service) StartAudit(ctx context.Context, reimbursementID string) (*Audi
 		logger.NewField("risk_level", audit.RiskLevel),
 		logger.NewField("duration", audit.Duration))
 
+	s.notify(ctx, audit)
+
 	return audit, nil
 }
 
+// saveAuditOutcome 在同一事务中保存审核结果并按结果流转报销单状态
+// 审核完成时报销单流转到已完成/已驳回，待人工复核时保持审核中，审核失败时退回待审核等待重试
+func (s *Service) saveAuditOutcome(ctx context.Context, audit *AuditResult, reim *reimbursement.Reimbursement) error {
+	var path []string
+	switch audit.Status {
+	case AuditStatusCompleted:
+		decision := reimbursement.StatusRejected
+		if audit.EffectivePass() {
+			decision = reimbursement.StatusApproved
+		}
+		var err error
+		if path, err = reim.DecisionPath(decision); err != nil {
+			return err
+		}
+	case AuditStatusFailed:
+		if reim.Status != reimbursement.StatusPending {
+			path = []string{reimbursement.StatusPending}
+		}
+	}
+
+	return s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateAudit(ctx, audit); err != nil {
+			s.logger.WithContext(ctx).Error("更新审核记录失败", logger.NewField("error", err))
+			return fmt.Errorf("更新审核记录失败: %w", err)
+		}
+		return s.transitReimbursement(ctx, reim, path)
+	})
+}
+
+// notify 发送审核结果通知，通知失败不影响审核结果
+func (s *Service) notify(ctx context.Context, audit *AuditResult) {
+	if s.notifier == nil {
+		return
+	}
+
+	if err := s.notifier.Notify(ctx, NewAuditNotification(audit)); err != nil {
+		s.logger.WithContext(ctx).Warn("发送审核结果通知失败",
+			logger.NewField("audit_id", audit.ID),
+			logger.NewField("error", err))
+	}
+}
+
 // GetAuditStatus 获取审核状态
 func (s *Service) GetAuditStatus(ctx context.Context, auditID string) (*AuditResult, error) {
 	audit, err := s.repo.GetAuditByID(ctx, auditID)
@@ -133,6 +352,20 @@ func (s *Service) GetAuditStatus(ctx context.Context, auditID string) (*AuditRes
 	return audit, nil
 }
 
+// GetAppliedStandards 获取审核时采用的限额标准
+func (s *Service) GetAppliedStandards(ctx context.Context, auditID string) ([]*rule.AppliedLimitStandard, error) {
+	audit, err := s.repo.GetAuditByID(ctx, auditID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取审核记录失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取审核记录失败: %w", err)
+	}
+
+	if audit.AppliedStandards == nil {
+		return make([]*rule.AppliedLimitStandard, 0), nil
+	}
+	return audit.AppliedStandards, nil
+}
+
 // GetAuditByReimbursementID 根据报销单ID获取审核结果
 func (s *Service) GetAuditByReimbursementID(ctx context.Context, reimbursementID string) (*AuditResult, error) {
 	audit, err := s.repo.GetAuditByReimbursementID(ctx, reimbursementID)
@@ -144,12 +377,75 @@ func (s *Service) GetAuditByReimbursementID(ctx context.Context, reimbursementID
 	return audit, nil
 }
 
-// executeRuleValidation 执行规则校验
+// ListSLABreaches 分页查询超出SLA的审核记录
+func (s *Service) ListSLABreaches(ctx context.Context, page, size int) ([]*AuditResult, int64, error) {
+	breached := true
+	audits, total, err := s.repo.ListAudits(ctx, &AuditFilter{
+		SLABreached: &breached,
+		Page:        page,
+		Size:        size,
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询超出SLA的审核记录失败", logger.NewField("error", err))
+		return nil, 0, fmt.Errorf("查询超出SLA的审核记录失败: %w", err)
+	}
+
+	return audits, total, nil
+}
+
+// 审核列表默认分页参数
+const (
+	defaultAuditPageSize = 20
+	maxAuditPageSize     = 100
+)
+
+// ListAudits 按状态、风险等级、时间范围分页查询审核记录，按创建时间倒序
+func (s *Service) ListAudits(ctx context.Context, filter *AuditFilter) ([]*AuditResult, int64, error) {
+	if filter == nil {
+		filter = &AuditFilter{}
+	}
+
+	switch filter.Status {
+	case "", AuditStatusPending, AuditStatusRunning, AuditStatusCompleted, AuditStatusManualReview, AuditStatusFailed:
+	default:
+		return nil, 0, fmt.Errorf("%w: 不支持的审核状态%s", ErrInvalidAuditFilter, filter.Status)
+	}
+
+	switch filter.RiskLevel {
+	case "", RiskLevelHigh, RiskLevelMedium, RiskLevelLow:
+	default:
+		return nil, 0, fmt.Errorf("%w: 不支持的风险等级%s", ErrInvalidAuditFilter, filter.RiskLevel)
+	}
+
+	if filter.StartTime != nil && filter.EndTime != nil && filter.StartTime.After(*filter.EndTime) {
+		return nil, 0, fmt.Errorf("%w: 开始时间不能晚于结束时间", ErrInvalidAuditFilter)
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 {
+		filter.Size = defaultAuditPageSize
+	}
+	if filter.Size > maxAuditPageSize {
+		filter.Size = maxAuditPageSize
+	}
+
+	audits, total, err := s.repo.ListAudits(ctx, filter)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询审核列表失败", logger.NewField("error", err))
+		return nil, 0, fmt.Errorf("查询审核列表失败: %w", err)
+	}
+
+	return audits, total, nil
+}
+
+// executeRuleValidation 执行报销类别在申请日期适用的规则校验
 func (s *Service) executeRuleValidation(ctx context.Context, reimbursement *reimbursement.Reimbursement) ([]*RuleValidationResult, error) {
 	s.logger.WithContext(ctx).Info("开始规则校验")
 
 	data := s.buildRuleValidationData(reimbursement)
-	results, err := s.ruleService.ValidateAllRules(ctx, data)
+	results, err := s.ruleService.ValidateApplicableRules(ctx, data, reimbursement.Type, reimbursement.ApplyDate)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("规则校验失败", logger.NewField("error", err))
 		return nil, err
@@ -162,10 +458,14 @@ func (s *Service) executeRuleValidation(ctx context.Context, reimbursement *reim
 			RuleCode:      result.RuleID,
 			RuleName:      result.RuleName,
 			RuleType:      result.RuleType,
+			Severity:      result.Severity,
 			Passed:        result.Passed,
 			Message:       result.Message,
 			Details:       map[string]interface{}{"details": result.Details},
 			ExecutionTime: result.ExecutionTime,
+			Overridden:    result.Overridden,
+			OverriddenBy:  result.OverriddenBy,
+			ConflictNote:  result.ConflictNote,
 		}
 	}
 
@@ -174,11 +474,65 @@ func (s *Service) executeRuleValidation(ctx context.Context, reimbursement *reim
 	return convertedResults, nil
 }
 
+// executeInvoiceValidation 逐张校验报销单内的发票，生成汇总并收集采用的限额标准，未设置发票校验器时返回nil
+// 批量校验失败时返回错误，此时销售方、重复报销、金额核对和时效等校验均未完成
+func (s *Service) executeInvoiceValidation(ctx context.Context, reimbursement *reimbursement.Reimbursement) (*InvoiceAuditSummary, []*rule.AppliedLimitStandard, error) {
+	if s.invoiceValidator == nil {
+		return nil, nil, nil
+	}
+
+	numbers := make(map[string]string, len(reimbursement.Invoices))
+	reqs := make([]*rule.InvoiceValidationRequest, 0, len(reimbursement.Invoices))
+	for _, invoice := range reimbursement.Invoices {
+		if invoice == nil {
+			continue
+		}
+		numbers[invoice.ID] = invoice.Number
+		reqs = append(reqs, &rule.InvoiceValidationRequest{
+			Invoice:       invoice,
+			Reimbursement: reimbursement,
+			ApplyDate:     reimbursement.ApplyDate,
+		})
+	}
+
+	var results []*rule.InvoiceValidationResult
+	if len(reqs) > 0 {
+		var err error
+		results, err = s.invoiceValidator.ValidateBatch(ctx, reqs)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("发票校验失败", logger.NewField("error", err))
+			return nil, nil, fmt.Errorf("发票校验失败: %w", err)
+		}
+	}
+
+	summary := BuildInvoiceAuditSummary(results, numbers)
+	s.logger.WithContext(ctx).Info("发票校验汇总完成",
+		logger.NewField("total_invoices", summary.TotalInvoices),
+		logger.NewField("failed_invoices", summary.FailedInvoices),
+		logger.NewField("recommendation", summary.Recommendation))
+
+	return summary, collectAppliedStandards(results), nil
+}
+
+// collectAppliedStandards 收集各发票校验时采用的限额标准
+func collectAppliedStandards(results []*rule.InvoiceValidationResult) []*rule.AppliedLimitStandard {
+	standards := make([]*rule.AppliedLimitStandard, 0)
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		standards = append(standards, result.AppliedStandards...)
+	}
+	return standards
+}
+
 // executeRAGAnalysis 执行RAG分析
 func (s *Service) executeRAGAnalysis(ctx context.Context, reimbursementInfo map[string]interface{}) (*RAGAnalysisResult, error) {
-	s.logger.WithContext(ctx).Info("开始RAG分析")
+	category, _ := reimbursementInfo["category"].(string)
+	topK := s.RAGTopK(category)
+	s.logger.WithContext(ctx).Info("开始RAG分析", logger.NewField("category", category), logger.NewField("top_k", topK))
 
-	result, err := s.ragService.AuditReimbursement(ctx, reimbursementInfo, 5)
+	result, err := s.ragService.AuditReimbursement(ctx, reimbursementInfo, topK)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("RAG分析失败", logger.NewField("error", err))
 		return nil, err
@@ -186,23 +540,14 @@ func (s *Service) executeRAGAnalysis(ctx context.Context, reimbursementInfo map[
 
 	ragResult := &RAGAnalysisResult{
 		Query:         result.Query,
-		Content:       result.AnalysisResult.Conclusion,
-		Confidence:    result.AnalysisResult.Confidence,
-		Analysis:      result.AnalysisResult.Reasoning,
 		ExecutionTime: result.ExecutionTime,
 		Chunks:        result.Chunks,
+		References:    buildVectorReferences(result),
 	}
-
-	for _, doc := range result.Documents {
-		for _, chunk := range doc.Chunks {
-			ragResult.References = append(ragResult.References, &VectorReference{
-				ChunkID:    chunk.ID,
-				Content:    chunk.Content,
-				Similarity: 0.0,
-				Category:   doc.Metadata.Category,
-				DocumentID: doc.ID,
-			})
-		}
+	if result.AnalysisResult != nil {
+		ragResult.Content = result.AnalysisResult.Conclusion
+		ragResult.Confidence = result.AnalysisResult.Confidence
+		ragResult.Analysis = result.AnalysisResult.Reasoning
 	}
 
 	s.logger.WithContext(ctx).Info("RAG分析完成", logger.NewField("confidence", ragResult.Confidence))
@@ -210,6 +555,42 @@ func (s *Service) executeRAGAnalysis(ctx context.Context, reimbursementInfo map[
 	return ragResult, nil
 }
 
+// buildVectorReferences 根据RAG结果构建向量检索引用，携带检索相似度分数和制度文档类别
+// 优先使用审核结论的引用列表（引用编号与结论中标注的[编号]一致），没有引用列表时使用检索到的分片
+func buildVectorReferences(result *rag.RAGResult) []*VectorReference {
+	if result.AnalysisResult != nil && len(result.AnalysisResult.Citations) > 0 {
+		references := make([]*VectorReference, 0, len(result.AnalysisResult.Citations))
+		for _, citation := range result.AnalysisResult.Citations {
+			references = append(references, &VectorReference{
+				ChunkID:    citation.ChunkID,
+				Content:    citation.Content,
+				Similarity: citation.Score,
+				Category:   citation.Category,
+				DocumentID: citation.DocumentID,
+				Index:      citation.Index,
+				Cited:      citation.Cited,
+			})
+		}
+		return references
+	}
+
+	references := make([]*VectorReference, 0, len(result.Chunks))
+	for _, chunk := range result.Chunks {
+		if chunk == nil {
+			continue
+		}
+		references = append(references, &VectorReference{
+			ChunkID:    chunk.ID,
+			Content:    chunk.Content,
+			Similarity: chunk.Score,
+			Category:   chunk.Category,
+			DocumentID: chunk.DocumentID,
+			Index:      len(references) + 1,
+		})
+	}
+	return references
+}
+
 // buildReimbursementInfo 构建报销单信息
 func (s *Service) buildReimbursementInfo(reimbursement *reimbursement.Reimbursement) map[string]interface{} {
 	return map[string]interface{}{
@@ -228,6 +609,30 @@ func (s *Service) buildReimbursementInfo(reimbursement *reimbursement.Reimbursem
 	}
 }
 
+// buildInvoiceDetails 构建逐张发票的明细（金额、销售方、商品、日期等），顺序与报销单发票顺序一致
+func buildInvoiceDetails(invoices []*ocr.Invoice) []map[string]interface{} {
+	details := make([]map[string]interface{}, 0, len(invoices))
+	for _, invoice := range invoices {
+		if invoice == nil {
+			continue
+		}
+		seller := invoice.SellerName
+		if seller == "" {
+			seller = invoice.Payee
+		}
+		details = append(details, map[string]interface{}{
+			"number":    invoice.Number,
+			"date":      invoice.Date,
+			"amount":    invoice.Amount,
+			"seller":    seller,
+			"commodity": invoice.CommodityName,
+			"category":  ocr.BreakdownCategory(invoice),
+			"city":      invoice.City,
+		})
+	}
+	return details
+}
+
 // buildRuleValidationData 构建规则校验数据
 func (s *Service) buildRuleValidationData(reimbursement *reimbursement.Reimbursement) map[string]interface{} {
 	return s.buildReimbursementInfo(reimbursement)
@@ -240,7 +645,8 @@ func (s *Service) checkRulePass(results []*RuleValidationResult) bool {
 	}
 
 	for _, result := range results {
-		if !result.Passed {
+		// 冲突解决中被覆盖的结论不参与判定
+		if !result.Passed && !result.Overridden {
 			return false
 		}
 	}
@@ -250,99 +656,73 @@ func (s *Service) checkRulePass(results []*RuleValidationResult) bool {
 
 // calculateRiskScore 计算风险分数
 func (s *Service) calculateRiskScore(audit *AuditResult) float64 {
-	riskScore := 0.0
-
-	if !audit.RulePass {
-		riskScore += 0.5
-	}
-
-	if !audit.RAGPass {
-		riskScore += 0.3
-	}
-
-	if audit.RAGResults != nil {
-		riskScore += (1.0 - audit.RAGResults.Confidence) * 0.2
-	}
-
-	if riskScore > 1.0 {
-		riskScore = 1.0
+	if s.riskScoreOptions.Mode == RiskScoreModeDeterministic {
+		return calculateDeterministicRiskScore(audit, s.riskScoreOptions)
 	}
-
-	return riskScore
+	return calculateHybridRiskScore(audit, s.riskConfig)
 }
 
 // determineRiskLevel 确定风险等级
 func (s *Service) determineRiskLevel(riskScore float64) string {
-	if riskScore >= 0.7 {
-		return "高风险"
-	} else if riskScore >= 0.4 {
-		return "中风险"
+	if riskScore >= s.riskConfig.HighThreshold {
+		return RiskLevelHigh
+	} else if riskScore >= s.riskConfig.MediumThreshold {
+		return RiskLevelMedium
 	} else {
-		return "低风险"
+		return RiskLevelLow
 	}
 }
 
-// generateSuggestions 生成建议
-func (s *Service) generateSuggestions(audit *AuditResult) []string {
-	var suggestions []string
-
-	if !audit.RulePass {
-		suggestions = append(suggestions, "请检查规则校验不通过的项目")
-		for _, result := range audit.RuleResults {
-			if !result.Passed {
-				suggestions = append(suggestions, fmt.Sprintf("- %s: %s", result.RuleName, result.Message))
-			}
-		}
-	}
-
-	if !audit.RAGPass && audit.RAGResults != nil {
-		suggestions = append(suggestions, "请检查RAG分析结果，建议人工复核")
-	}
-
-	if audit.RiskLevel == "高风险" {
-		suggestions = append(suggestions, "该报销单风险较高，建议进行详细审核")
-	}
-
-	if len(suggestions) == 0 {
-		suggestions = append(suggestions, "审核通过，可以继续后续流程")
-	}
+// generateSuggestions 按业务单元的措辞模板生成建议
+func (s *Service) generateSuggestions(audit *AuditResult, businessUnit string) []string {
+	return s.verdictRenderer.Suggestions(audit, businessUnit)
+}
 
-	return suggestions
+// generateAuditReason 按业务单元的措辞模板生成审核原因
+func (s *Service) generateAuditReason(audit *AuditResult, businessUnit string) string {
+	return s.verdictRenderer.Reason(audit, businessUnit)
 }
 
-// generateAuditReason 生成审核原因
-func (s *Service) generateAuditReason(audit *AuditResult) string {
-	if audit.FinalPass {
-		return "审核通过"
+// RetryAudit 重试审核
+func (s *Service) RetryAudit(ctx context.Context, auditID string) (*AuditResult, error) {
+	audit, err := s.repo.GetAuditByID(ctx, auditID)
+	if err != nil {
+		return nil, fmt.Errorf("获取审核记录失败: %w", err)
 	}
 
-	var reasons []string
-
-	if !audit.RulePass {
-		reasons = append(reasons, "规则校验未通过")
+	if audit.Status != AuditStatusFailed {
+		return nil, errors.New("只能重试失败的审核")
 	}
 
-	if !audit.RAGPass {
-		reasons = append(reasons, "RAG分析未通过")
+	// 历史记录没有尝试次数时视为首次审核
+	attempt := audit.Attempt
+	if attempt <= 0 {
+		attempt = 1
 	}
-
-	if len(reasons) == 0 {
-		return "审核未通过"
+	if attempt > s.maxRetries {
+		s.logger.WithContext(ctx).Warn("审核重试次数超限",
+			logger.NewField("audit_id", auditID),
+			logger.NewField("reimbursement_id", audit.ReimbursementID),
+			logger.NewField("attempt", attempt),
+			logger.NewField("max_retries", s.maxRetries))
+		return nil, fmt.Errorf("%w: 报销单%s已连续重试%d次，上限为%d次", ErrRetryLimitExceeded,
+			audit.ReimbursementID, attempt-1, s.maxRetries)
 	}
 
-	return "审核未通过: " + reasons[0]
+	return s.startAudit(ctx, audit.ReimbursementID, audit.ID, attempt+1)
 }
 
-// RetryAudit 重试审核
-func (s *Service) RetryAudit(ctx context.Context, auditID string) (*AuditResult, error) {
-	audit, err := s.repo.GetAuditByID(ctx, auditID)
-	if err != nil {
-		return nil, fmt.Errorf("获取审核记录失败: %w", err)
+// ListAuditHistory 查询报销单的全部审核记录（含重试历史），按创建时间倒序
+func (s *Service) ListAuditHistory(ctx context.Context, reimbursementID string) ([]*AuditResult, error) {
+	if reimbursementID == "" {
+		return nil, errors.New("报销单ID不能为空")
 	}
 
-	if audit.Status != AuditStatusFailed {
-		return nil, errors.New("只能重试失败的审核")
+	audits, _, err := s.repo.ListAudits(ctx, &AuditFilter{ReimbursementID: reimbursementID})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询审核历史失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("查询审核历史失败: %w", err)
 	}
 
-	return s.StartAudit(ctx, audit.ReimbursementID)
+	return audits, nil
 }
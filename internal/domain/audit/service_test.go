@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/domain/rule"
+)
+
+func TestStartAuditReimbursementStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		category   string
+		amount     float64
+		status     string
+		ragErr     error
+		wantErr    bool
+		wantAudit  AuditStatus
+		wantPass   bool
+		wantStatus string
+	}{
+		{name: "待提交报销单通过后已完成", category: "差旅费", amount: 300, status: reimbursement.StatusDraft, wantAudit: AuditStatusCompleted, wantPass: true, wantStatus: reimbursement.StatusApproved},
+		{name: "待审核报销单超限驳回", category: "差旅费", amount: 800, status: reimbursement.StatusPending, wantAudit: AuditStatusCompleted, wantStatus: reimbursement.StatusRejected},
+		{name: "已完成报销单重审未通过驳回", category: "差旅费", amount: 800, status: reimbursement.StatusApproved, wantAudit: AuditStatusCompleted, wantStatus: reimbursement.StatusRejected},
+		{name: "未加载规则转人工复核保持审核中", category: "办公费", amount: 300, status: reimbursement.StatusPending, wantAudit: AuditStatusManualReview, wantStatus: reimbursement.StatusAuditing},
+		{name: "大模型分析失败退回待审核", category: "差旅费", amount: 300, status: reimbursement.StatusPending, ragErr: errStoreFailure, wantErr: true, wantAudit: AuditStatusFailed, wantStatus: reimbursement.StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.putReimbursement(&reimbursement.Reimbursement{ID: "r1", Type: tt.category, TotalAmount: tt.amount, Status: tt.status})
+			service := newPipelineService(t, store, newMemRuleRepo(amountLimitRule("500")))
+			service.ragService = &fakeAnalyzer{confidence: 0.9, err: tt.ragErr}
+
+			result, err := service.StartAudit(context.Background(), "r1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StartAudit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			audits, _, _ := (&memAuditRepo{store}).ListAudits(context.Background(), &AuditFilter{ReimbursementID: "r1"})
+			if len(audits) != 1 {
+				t.Fatalf("审核记录数 = %d, want 1", len(audits))
+			}
+			if audits[0].Status != tt.wantAudit {
+				t.Errorf("审核状态 = %s, want %s", audits[0].Status, tt.wantAudit)
+			}
+			if result != nil && result.EffectivePass() != tt.wantPass {
+				t.Errorf("EffectivePass() = %v, want %v", result.EffectivePass(), tt.wantPass)
+			}
+			if status := store.reimbursement("r1").Status; status != tt.wantStatus {
+				t.Errorf("报销单状态 = %s, want %s", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStartAuditInvoiceValidation(t *testing.T) {
+	blacklist := rule.NewStaticSellerBlacklist(&rule.SellerBlacklistEntry{SellerName: "某某空壳商贸有限公司", Reason: "虚开发票"})
+
+	tests := []struct {
+		name            string
+		seller          string
+		validateErr     error
+		wantPass        bool
+		wantAuditStatus AuditStatus
+		wantStatus      string
+		wantReason      string
+	}{
+		{name: "正常销售方通过", seller: "某某酒店有限公司", wantPass: true, wantAuditStatus: AuditStatusCompleted, wantStatus: reimbursement.StatusApproved},
+		{name: "黑名单销售方不通过", seller: "某某空壳商贸有限公司", wantAuditStatus: AuditStatusCompleted, wantStatus: reimbursement.StatusRejected, wantReason: "销售方黑名单"},
+		{name: "发票校验失败转人工复核", seller: "某某酒店有限公司", validateErr: errStoreFailure, wantAuditStatus: AuditStatusManualReview, wantStatus: reimbursement.StatusAuditing, wantReason: "发票校验执行失败"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.putReimbursement(&reimbursement.Reimbursement{
+				ID: "r1", Type: "差旅费", TotalAmount: 300, Status: reimbursement.StatusPending,
+				Invoices: []*ocr.Invoice{{ID: "i1", Number: "0001", SellerName: tt.seller}},
+			})
+			service := newPipelineService(t, store, newMemRuleRepo(amountLimitRule("500")))
+			service.SetInvoiceValidator(&blacklistValidator{blacklist: blacklist, err: tt.validateErr})
+
+			result, err := service.StartAudit(context.Background(), "r1")
+			if err != nil {
+				t.Fatalf("StartAudit() error = %v", err)
+			}
+			if !result.RulePass || !result.RAGPass {
+				t.Fatalf("RulePass/RAGPass = %v/%v, want true/true", result.RulePass, result.RAGPass)
+			}
+			if result.FinalPass != tt.wantPass || result.Status != tt.wantAuditStatus {
+				t.Errorf("FinalPass/Status = %v/%s, want %v/%s", result.FinalPass, result.Status, tt.wantPass, tt.wantAuditStatus)
+			}
+			if !strings.Contains(result.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want contains %q", result.Reason, tt.wantReason)
+			}
+			if status := store.reimbursement("r1").Status; status != tt.wantStatus {
+				t.Errorf("报销单状态 = %s, want %s", status, tt.wantStatus)
+			}
+		})
+	}
+}
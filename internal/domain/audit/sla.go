@@ -0,0 +1,27 @@
+// sla.go 审核时效(SLA)跟踪
+// 功能点：
+// 1. 计算报销单提交到审核完成的耗时
+// 2. 根据配置的SLA时长判断是否超时
+// 3. 审核结果记录提交时间、处理耗时和超时标记
+
+package audit
+
+import "time"
+
+// EvaluateSLA 计算提交到完成的耗时并判断是否超出SLA
+// sla<=0表示未配置SLA，此时不标记超时
+func EvaluateSLA(submittedAt, completedAt time.Time, sla time.Duration) (time.Duration, bool) {
+	if submittedAt.IsZero() || completedAt.Before(submittedAt) {
+		return 0, false
+	}
+
+	turnaround := completedAt.Sub(submittedAt)
+	return turnaround, sla > 0 && turnaround > sla
+}
+
+// applySLA 在审核结果上记录处理耗时和SLA超时标记
+func applySLA(audit *AuditResult, completedAt time.Time, sla time.Duration) {
+	turnaround, breached := EvaluateSLA(audit.SubmittedAt, completedAt, sla)
+	audit.TurnaroundTime = turnaround.Milliseconds()
+	audit.SLABreached = breached
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+)
+
+func TestEvaluateSLA(t *testing.T) {
+	submittedAt := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		submittedAt    time.Time
+		completedAt    time.Time
+		sla            time.Duration
+		wantTurnaround time.Duration
+		wantBreached   bool
+	}{
+		{name: "在SLA内完成", submittedAt: submittedAt, completedAt: submittedAt.Add(20 * time.Minute), sla: 30 * time.Minute, wantTurnaround: 20 * time.Minute},
+		{name: "恰好等于SLA不算超时", submittedAt: submittedAt, completedAt: submittedAt.Add(30 * time.Minute), sla: 30 * time.Minute, wantTurnaround: 30 * time.Minute},
+		{name: "超出SLA", submittedAt: submittedAt, completedAt: submittedAt.Add(31 * time.Minute), sla: 30 * time.Minute, wantTurnaround: 31 * time.Minute, wantBreached: true},
+		{name: "未配置SLA只记录耗时", submittedAt: submittedAt, completedAt: submittedAt.Add(48 * time.Hour), wantTurnaround: 48 * time.Hour},
+		{name: "缺少提交时间", completedAt: submittedAt, sla: time.Minute},
+		{name: "完成时间早于提交时间", submittedAt: submittedAt, completedAt: submittedAt.Add(-time.Minute), sla: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			turnaround, breached := EvaluateSLA(tt.submittedAt, tt.completedAt, tt.sla)
+			if turnaround != tt.wantTurnaround || breached != tt.wantBreached {
+				t.Errorf("EvaluateSLA() = %s/%v, want %s/%v", turnaround, breached, tt.wantTurnaround, tt.wantBreached)
+			}
+		})
+	}
+}
+
+func TestStartAuditSLA(t *testing.T) {
+	store := newMemStore()
+	now := time.Now()
+	submitted := map[string]time.Time{
+		"r-late":   now.Add(-2 * time.Hour),
+		"r-timely": now.Add(-5 * time.Minute),
+	}
+	for id, createdAt := range submitted {
+		store.putReimbursement(&reimbursement.Reimbursement{ID: id, Type: "差旅费", TotalAmount: 300, Status: reimbursement.StatusPending, CreatedAt: createdAt})
+	}
+	service := newPipelineService(t, store, newMemRuleRepo(amountLimitRule("500")))
+	service.SetSLA(30 * time.Minute)
+
+	for id, createdAt := range submitted {
+		result, err := service.StartAudit(context.Background(), id)
+		if err != nil {
+			t.Fatalf("StartAudit(%s) error = %v", id, err)
+		}
+		if !result.SubmittedAt.Equal(createdAt) {
+			t.Errorf("%s SubmittedAt = %s, want 报销单创建时间%s", id, result.SubmittedAt, createdAt)
+		}
+		if wantBreached := id == "r-late"; result.SLABreached != wantBreached {
+			t.Errorf("%s SLABreached = %v, want %v", id, result.SLABreached, wantBreached)
+		}
+		if wantMin := time.Since(createdAt) - time.Minute; time.Duration(result.TurnaroundTime)*time.Millisecond < wantMin {
+			t.Errorf("%s TurnaroundTime = %dms, want 不少于%s", id, result.TurnaroundTime, wantMin)
+		}
+	}
+
+	breaches, total, err := service.ListSLABreaches(context.Background(), 1, 10)
+	if err != nil {
+		t.Fatalf("ListSLABreaches() error = %v", err)
+	}
+	if total != 1 || len(breaches) != 1 || breaches[0].ReimbursementID != "r-late" {
+		t.Errorf("超出SLA的审核 = %d条(total %d), want 仅r-late", len(breaches), total)
+	}
+}
@@ -0,0 +1,251 @@
+// verdict_template.go 审核结论措辞模板
+// 功能点：
+// 1. 定义审核结论和审核建议的措辞模板，使用text/template语法
+// 2. 支持按业务单元（部门）配置不同措辞，未配置的模板回退到默认措辞
+// 3. 创建时预先解析全部模板，模板语法错误在启动时暴露
+
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ErrInvalidVerdictTemplate 审核结论模板不合法
+var ErrInvalidVerdictTemplate = errors.New("审核结论模板不合法")
+
+// VerdictTemplates 审核结论措辞模板，为空的模板使用默认措辞
+// 模板可引用的字段见verdictData
+type VerdictTemplates struct {
+	Pass                 string `json:"pass"`                   // 审核通过结论
+	Reject               string `json:"reject"`                 // 审核未通过结论，{{.Reason}}为首个未通过原因
+	RuleFailedReason     string `json:"rule_failed_reason"`     // 规则校验未通过原因
+	RAGFailedReason      string `json:"rag_failed_reason"`      // RAG分析未通过原因
+	PassSuggestion       string `json:"pass_suggestion"`        // 审核通过时的建议
+	RuleFailedSuggestion string `json:"rule_failed_suggestion"` // 规则校验未通过时的建议
+	RuleItemSuggestion   string `json:"rule_item_suggestion"`   // 单条未通过规则的建议，{{.RuleName}}/{{.Message}}为规则名称和结果说明
+	RAGFailedSuggestion  string `json:"rag_failed_suggestion"`  // RAG分析未通过时的建议
+	HighRiskSuggestion   string `json:"high_risk_suggestion"`   // 高风险时的建议，{{.RiskScore}}为风险分数
+}
+
+// DefaultVerdictTemplates 默认审核结论措辞
+func DefaultVerdictTemplates() VerdictTemplates {
+	return VerdictTemplates{
+		Pass:                 "审核通过",
+		Reject:               "审核未通过{{if .Reason}}: {{.Reason}}{{end}}",
+		RuleFailedReason:     "规则校验未通过",
+		RAGFailedReason:      "RAG分析未通过",
+		PassSuggestion:       "审核通过，可以继续后续流程",
+		RuleFailedSuggestion: "请检查规则校验不通过的项目",
+		RuleItemSuggestion:   "- {{.RuleName}}: {{.Message}}",
+		RAGFailedSuggestion:  "请检查RAG分析结果，建议人工复核",
+		HighRiskSuggestion:   "该报销单风险较高，建议进行详细审核",
+	}
+}
+
+// verdictData 渲染审核结论模板的数据
+type verdictData struct {
+	ReimbursementID string  // 报销单ID
+	BusinessUnit    string  // 业务单元
+	Reason          string  // 首个未通过原因
+	RuleName        string  // 未通过的规则名称
+	Message         string  // 未通过规则的结果说明
+	RiskLevel       string  // 风险等级
+	RiskScore       float64 // 风险分数
+}
+
+// verdictTemplateSet 一个业务单元解析后的结论模板
+type verdictTemplateSet struct {
+	pass                 *template.Template
+	reject               *template.Template
+	ruleFailedReason     *template.Template
+	ragFailedReason      *template.Template
+	passSuggestion       *template.Template
+	ruleFailedSuggestion *template.Template
+	ruleItemSuggestion   *template.Template
+	ragFailedSuggestion  *template.Template
+	highRiskSuggestion   *template.Template
+}
+
+// VerdictRenderer 审核结论渲染器
+type VerdictRenderer struct {
+	defaults      *verdictTemplateSet
+	businessUnits map[string]*verdictTemplateSet
+}
+
+// NewVerdictRenderer 创建审核结论渲染器
+// defaults中为空的模板使用内置默认措辞，businessUnits中为空的模板使用defaults
+func NewVerdictRenderer(defaults VerdictTemplates, businessUnits map[string]VerdictTemplates) (*VerdictRenderer, error) {
+	defaults = mergeVerdictTemplates(DefaultVerdictTemplates(), defaults)
+	defaultSet, err := parseVerdictTemplates("default", defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	renderer := &VerdictRenderer{
+		defaults:      defaultSet,
+		businessUnits: make(map[string]*verdictTemplateSet, len(businessUnits)),
+	}
+	for unit, templates := range businessUnits {
+		unit = strings.TrimSpace(unit)
+		if unit == "" {
+			continue
+		}
+		set, err := parseVerdictTemplates(unit, mergeVerdictTemplates(defaults, templates))
+		if err != nil {
+			return nil, err
+		}
+		renderer.businessUnits[unit] = set
+	}
+	return renderer, nil
+}
+
+// defaultVerdictRenderer 使用内置默认措辞的渲染器
+func defaultVerdictRenderer() *VerdictRenderer {
+	renderer, err := NewVerdictRenderer(VerdictTemplates{}, nil)
+	if err != nil {
+		panic(fmt.Sprintf("解析默认审核结论模板失败: %v", err))
+	}
+	return renderer
+}
+
+// SetVerdictRenderer 设置审核结论渲染器，为nil时使用默认措辞
+func (s *Service) SetVerdictRenderer(renderer *VerdictRenderer) {
+	if renderer == nil {
+		renderer = defaultVerdictRenderer()
+	}
+	s.verdictRenderer = renderer
+}
+
+// templatesFor 获取业务单元的结论模板，未配置时使用默认模板
+func (r *VerdictRenderer) templatesFor(businessUnit string) *verdictTemplateSet {
+	if set, ok := r.businessUnits[businessUnit]; ok {
+		return set
+	}
+	return r.defaults
+}
+
+// Reason 渲染审核结论
+func (r *VerdictRenderer) Reason(audit *AuditResult, businessUnit string) string {
+	set := r.templatesFor(businessUnit)
+	data := newVerdictData(audit, businessUnit)
+	if audit.FinalPass {
+		return render(set.pass, data)
+	}
+
+	// 按规则校验、RAG分析的顺序取首个未通过原因
+	if !audit.RulePass {
+		data.Reason = render(set.ruleFailedReason, data)
+	} else if !audit.RAGPass {
+		data.Reason = render(set.ragFailedReason, data)
+	}
+	return render(set.reject, data)
+}
+
+// Suggestions 渲染审核建议
+func (r *VerdictRenderer) Suggestions(audit *AuditResult, businessUnit string) []string {
+	set := r.templatesFor(businessUnit)
+	data := newVerdictData(audit, businessUnit)
+
+	var suggestions []string
+	if !audit.RulePass {
+		suggestions = append(suggestions, render(set.ruleFailedSuggestion, data))
+		for _, result := range audit.RuleResults {
+			if !result.Passed && !result.Overridden {
+				item := data
+				item.RuleName, item.Message = result.RuleName, result.Message
+				suggestions = append(suggestions, render(set.ruleItemSuggestion, item))
+			}
+		}
+	}
+
+	if !audit.RAGPass && audit.RAGResults != nil {
+		suggestions = append(suggestions, render(set.ragFailedSuggestion, data))
+	}
+
+	if audit.RiskLevel == RiskLevelHigh {
+		suggestions = append(suggestions, render(set.highRiskSuggestion, data))
+	}
+
+	if len(suggestions) == 0 {
+		suggestions = append(suggestions, render(set.passSuggestion, data))
+	}
+
+	return suggestions
+}
+
+// newVerdictData 构建模板渲染数据
+func newVerdictData(audit *AuditResult, businessUnit string) verdictData {
+	return verdictData{
+		ReimbursementID: audit.ReimbursementID,
+		BusinessUnit:    businessUnit,
+		RiskLevel:       audit.RiskLevel,
+		RiskScore:       audit.RiskScore,
+	}
+}
+
+// render 渲染模板，模板已在创建时校验，执行失败时返回模板名称
+func render(tmpl *template.Template, data verdictData) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return tmpl.Name()
+	}
+	return buf.String()
+}
+
+// parseVerdictTemplates 解析一组结论模板
+func parseVerdictTemplates(unit string, templates VerdictTemplates) (*verdictTemplateSet, error) {
+	var set verdictTemplateSet
+	fields := []struct {
+		name    string
+		content string
+		target  **template.Template
+	}{
+		{"pass", templates.Pass, &set.pass},
+		{"reject", templates.Reject, &set.reject},
+		{"rule_failed_reason", templates.RuleFailedReason, &set.ruleFailedReason},
+		{"rag_failed_reason", templates.RAGFailedReason, &set.ragFailedReason},
+		{"pass_suggestion", templates.PassSuggestion, &set.passSuggestion},
+		{"rule_failed_suggestion", templates.RuleFailedSuggestion, &set.ruleFailedSuggestion},
+		{"rule_item_suggestion", templates.RuleItemSuggestion, &set.ruleItemSuggestion},
+		{"rag_failed_suggestion", templates.RAGFailedSuggestion, &set.ragFailedSuggestion},
+		{"high_risk_suggestion", templates.HighRiskSuggestion, &set.highRiskSuggestion},
+	}
+
+	for _, field := range fields {
+		tmpl, err := template.New(field.name).Option("missingkey=error").Parse(field.content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s.%s: %v", ErrInvalidVerdictTemplate, unit, field.name, err)
+		}
+		// 使用示例数据试渲染，提前发现引用不存在字段等错误
+		if err := tmpl.Execute(&bytes.Buffer{}, verdictData{}); err != nil {
+			return nil, fmt.Errorf("%w: %s.%s: %v", ErrInvalidVerdictTemplate, unit, field.name, err)
+		}
+		*field.target = tmpl
+	}
+	return &set, nil
+}
+
+// mergeVerdictTemplates 使用override中非空的模板覆盖base
+func mergeVerdictTemplates(base, override VerdictTemplates) VerdictTemplates {
+	pick := func(value, fallback string) string {
+		if strings.TrimSpace(value) == "" {
+			return fallback
+		}
+		return value
+	}
+	return VerdictTemplates{
+		Pass:                 pick(override.Pass, base.Pass),
+		Reject:               pick(override.Reject, base.Reject),
+		RuleFailedReason:     pick(override.RuleFailedReason, base.RuleFailedReason),
+		RAGFailedReason:      pick(override.RAGFailedReason, base.RAGFailedReason),
+		PassSuggestion:       pick(override.PassSuggestion, base.PassSuggestion),
+		RuleFailedSuggestion: pick(override.RuleFailedSuggestion, base.RuleFailedSuggestion),
+		RuleItemSuggestion:   pick(override.RuleItemSuggestion, base.RuleItemSuggestion),
+		RAGFailedSuggestion:  pick(override.RAGFailedSuggestion, base.RAGFailedSuggestion),
+		HighRiskSuggestion:   pick(override.HighRiskSuggestion, base.HighRiskSuggestion),
+	}
+}
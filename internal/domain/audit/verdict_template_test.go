@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewVerdictRenderer(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaults      VerdictTemplates
+		businessUnits map[string]VerdictTemplates
+		wantErr       bool
+	}{
+		{name: "全部使用默认措辞"},
+		{name: "业务单元覆盖部分模板", businessUnits: map[string]VerdictTemplates{"销售部": {Pass: "{{.BusinessUnit}}审核通过"}}},
+		{name: "模板语法错误", defaults: VerdictTemplates{Pass: "{{.Reason"}, wantErr: true},
+		{name: "引用不存在的字段", businessUnits: map[string]VerdictTemplates{"销售部": {Reject: "{{.Amount}}"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVerdictRenderer(tt.defaults, tt.businessUnits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewVerdictRenderer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidVerdictTemplate) {
+				t.Errorf("NewVerdictRenderer() error = %v, want ErrInvalidVerdictTemplate", err)
+			}
+		})
+	}
+}
+
+func TestVerdictRenderer(t *testing.T) {
+	renderer, err := NewVerdictRenderer(VerdictTemplates{}, map[string]VerdictTemplates{
+		" 销售部 ": {
+			Pass:               "{{.BusinessUnit}}审核通过",
+			RuleItemSuggestion: "请修正{{.RuleName}}",
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewVerdictRenderer() error = %v", err)
+	}
+	ruleResults := []*RuleValidationResult{
+		{RuleName: "住宿限额", Passed: false, Message: "超出限额"},
+		{RuleName: "发票抬头", Passed: false, Overridden: true, Message: "已改判"},
+		{RuleName: "发票真伪", Passed: true},
+	}
+
+	tests := []struct {
+		name            string
+		audit           *AuditResult
+		businessUnit    string
+		wantReason      string
+		wantSuggestions []string
+	}{
+		{
+			name:            "默认措辞审核通过",
+			audit:           &AuditResult{FinalPass: true, RulePass: true, RAGPass: true},
+			wantReason:      "审核通过",
+			wantSuggestions: []string{"审核通过，可以继续后续流程"},
+		},
+		{
+			name:            "业务单元措辞",
+			audit:           &AuditResult{FinalPass: true, RulePass: true, RAGPass: true},
+			businessUnit:    "销售部",
+			wantReason:      "销售部审核通过",
+			wantSuggestions: []string{"审核通过，可以继续后续流程"},
+		},
+		{
+			name:            "未配置的业务单元使用默认措辞",
+			audit:           &AuditResult{FinalPass: true, RulePass: true, RAGPass: true},
+			businessUnit:    "财务部",
+			wantReason:      "审核通过",
+			wantSuggestions: []string{"审核通过，可以继续后续流程"},
+		},
+		{
+			name:            "规则未通过时跳过已改判的规则",
+			audit:           &AuditResult{RulePass: false, RAGPass: true, RuleResults: ruleResults},
+			wantReason:      "审核未通过: 规则校验未通过",
+			wantSuggestions: []string{"请检查规则校验不通过的项目", "- 住宿限额: 超出限额"},
+		},
+		{
+			name:            "业务单元的单条规则建议",
+			audit:           &AuditResult{RulePass: false, RAGPass: true, RuleResults: ruleResults},
+			businessUnit:    "销售部",
+			wantReason:      "审核未通过: 规则校验未通过",
+			wantSuggestions: []string{"请检查规则校验不通过的项目", "请修正住宿限额"},
+		},
+		{
+			name:            "RAG未通过且高风险",
+			audit:           &AuditResult{RulePass: true, RAGPass: false, RAGResults: &RAGAnalysisResult{}, RiskLevel: RiskLevelHigh},
+			wantReason:      "审核未通过: RAG分析未通过",
+			wantSuggestions: []string{"请检查RAG分析结果，建议人工复核", "该报销单风险较高，建议进行详细审核"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderer.Reason(tt.audit, tt.businessUnit); got != tt.wantReason {
+				t.Errorf("Reason() = %q, want %q", got, tt.wantReason)
+			}
+			if got := renderer.Suggestions(tt.audit, tt.businessUnit); !reflect.DeepEqual(got, tt.wantSuggestions) {
+				t.Errorf("Suggestions() = %q, want %q", got, tt.wantSuggestions)
+			}
+		})
+	}
+}
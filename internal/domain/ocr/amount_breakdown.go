@@ -0,0 +1,77 @@
+// amount_breakdown.go 报销单金额按发票类别拆分
+// 功能点：
+// 1. 按发票子类别(住宿费/交通费/餐饮费等)汇总报销单的发票金额，无子类别时使用发票类别，均缺失时归入未分类
+// 2. 每个类别给出金额、发票张数和占发票合计的比例，按金额降序排列
+// 3. 内存汇总与仓储分组聚合使用同一归类规则，结果一致
+
+package ocr
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// UncategorizedInvoiceCategory 未标注类别的发票归入的类别
+const UncategorizedInvoiceCategory = "未分类"
+
+// CategoryAmount 一个发票类别的金额汇总
+type CategoryAmount struct {
+	Category     string  `json:"category"`      // 发票类别
+	Amount       float64 `json:"amount"`        // 发票金额合计(人民币)
+	InvoiceCount int     `json:"invoice_count"` // 发票张数
+	Ratio        float64 `json:"ratio"`         // 占发票合计的比例(0-1)
+}
+
+// BreakdownCategory 获取发票在金额拆分中的类别，优先使用子类别
+func BreakdownCategory(invoice *Invoice) string {
+	if category := strings.TrimSpace(invoice.SubCategory); category != "" {
+		return category
+	}
+	if category := strings.TrimSpace(invoice.Category); category != "" {
+		return category
+	}
+	return UncategorizedInvoiceCategory
+}
+
+// BuildAmountBreakdown 按类别汇总发票金额，发票金额已按汇率折算为人民币
+func BuildAmountBreakdown(invoices []*Invoice) []*CategoryAmount {
+	byCategory := make(map[string]*CategoryAmount)
+	breakdown := make([]*CategoryAmount, 0)
+	for _, invoice := range invoices {
+		if invoice == nil {
+			continue
+		}
+		category := BreakdownCategory(invoice)
+		item, ok := byCategory[category]
+		if !ok {
+			item = &CategoryAmount{Category: category}
+			byCategory[category] = item
+			breakdown = append(breakdown, item)
+		}
+		item.Amount += invoice.Amount
+		item.InvoiceCount++
+	}
+	return FinalizeAmountBreakdown(breakdown)
+}
+
+// FinalizeAmountBreakdown 对各类别金额取两位小数、计算占比并按金额降序排列，金额相同时按类别名排序
+func FinalizeAmountBreakdown(breakdown []*CategoryAmount) []*CategoryAmount {
+	total := 0.0
+	for _, item := range breakdown {
+		total += item.Amount
+	}
+	for _, item := range breakdown {
+		if total > 0 {
+			item.Ratio = math.Round(item.Amount/total*10000) / 10000
+		}
+		item.Amount = math.Round(item.Amount*100) / 100
+	}
+	sort.SliceStable(breakdown, func(i, j int) bool {
+		if breakdown[i].Amount != breakdown[j].Amount {
+			return breakdown[i].Amount > breakdown[j].Amount
+		}
+		return breakdown[i].Category < breakdown[j].Category
+	})
+	return breakdown
+}
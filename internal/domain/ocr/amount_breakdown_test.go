@@ -0,0 +1,75 @@
+package ocr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBreakdownCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		invoice *Invoice
+		want    string
+	}{
+		{name: "优先使用子类别", invoice: &Invoice{Category: "差旅费", SubCategory: " 住宿费 "}, want: "住宿费"},
+		{name: "无子类别时使用类别", invoice: &Invoice{Category: "办公费", SubCategory: " "}, want: "办公费"},
+		{name: "均缺失时归入未分类", invoice: &Invoice{}, want: UncategorizedInvoiceCategory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BreakdownCategory(tt.invoice); got != tt.want {
+				t.Errorf("BreakdownCategory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAmountBreakdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		invoices []*Invoice
+		want     []*CategoryAmount
+	}{
+		{name: "没有发票", invoices: nil, want: []*CategoryAmount{}},
+		{
+			name: "按类别汇总并按金额降序",
+			invoices: []*Invoice{
+				{SubCategory: "交通费", Amount: 100},
+				nil,
+				{SubCategory: "住宿费", Amount: 500.125},
+				{SubCategory: "交通费", Amount: 200},
+				{Amount: 199.875},
+			},
+			want: []*CategoryAmount{
+				{Category: "住宿费", Amount: 500.13, InvoiceCount: 1, Ratio: 0.5001},
+				{Category: "交通费", Amount: 300, InvoiceCount: 2, Ratio: 0.3},
+				{Category: UncategorizedInvoiceCategory, Amount: 199.88, InvoiceCount: 1, Ratio: 0.1999},
+			},
+		},
+		{
+			name:     "金额相同时按类别名排序",
+			invoices: []*Invoice{{SubCategory: "餐饮费", Amount: 50}, {SubCategory: "交通费", Amount: 50}},
+			want: []*CategoryAmount{
+				{Category: "交通费", Amount: 50, InvoiceCount: 1, Ratio: 0.5},
+				{Category: "餐饮费", Amount: 50, InvoiceCount: 1, Ratio: 0.5},
+			},
+		},
+		{
+			name:     "合计为0时占比为0",
+			invoices: []*Invoice{{SubCategory: "交通费"}},
+			want:     []*CategoryAmount{{Category: "交通费", InvoiceCount: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildAmountBreakdown(tt.invoices); !reflect.DeepEqual(got, tt.want) {
+				for _, item := range got {
+					t.Logf("%+v", *item)
+				}
+				t.Errorf("BuildAmountBreakdown() 结果不符")
+			}
+		})
+	}
+}
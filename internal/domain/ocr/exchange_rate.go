@@ -0,0 +1,173 @@
+// exchange_rate.go 外币发票汇率换算
+// 功能点：
+// 1. 定义汇率数据源接口，按币种和日期查询当日生效的汇率
+// 2. 提供基于汇率历史表的静态实现（配置文件），取开票日期当天或之前最近一次生效的汇率
+// 3. 外币发票按开票日期的汇率折算为人民币金额，保留原币金额、原币种和所用汇率
+
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// BaseCurrency 本位币，发票金额统一折算为人民币
+const BaseCurrency = "CNY"
+
+// exchangeRateDateLayout 汇率生效日期格式
+const exchangeRateDateLayout = "2006-01-02"
+
+var (
+	// ErrExchangeRateUnavailable 未配置汇率数据源
+	ErrExchangeRateUnavailable = errors.New("未配置汇率数据源")
+	// ErrExchangeRateNotFound 开票日期没有生效的汇率
+	ErrExchangeRateNotFound = errors.New("开票日期没有生效的汇率")
+	// ErrInvoiceDateMissing 外币发票缺少开票日期，无法确定汇率
+	ErrInvoiceDateMissing = errors.New("外币发票缺少开票日期，无法确定汇率")
+)
+
+// ExchangeRate 汇率记录，1单位外币折合的人民币金额
+type ExchangeRate struct {
+	Currency      string    `json:"currency"`       // 币种(ISO 4217，如USD)
+	Rate          float64   `json:"rate"`           // 汇率(1单位外币折合人民币)
+	EffectiveDate time.Time `json:"effective_date"` // 生效日期，至下一条记录生效前有效
+}
+
+// ExchangeRateProvider 汇率数据源接口
+type ExchangeRateProvider interface {
+	// GetRate 获取币种在指定日期生效的汇率，没有生效的汇率时返回ErrExchangeRateNotFound
+	GetRate(ctx context.Context, currency string, date time.Time) (*ExchangeRate, error)
+}
+
+// StaticExchangeRateProvider 基于汇率历史表的汇率数据源
+type StaticExchangeRateProvider struct {
+	rates map[string][]ExchangeRate // 币种 -> 按生效日期升序排列的汇率
+}
+
+// NewStaticExchangeRateProvider 根据汇率历史记录创建汇率数据源
+func NewStaticExchangeRateProvider(rates ...ExchangeRate) (*StaticExchangeRateProvider, error) {
+	provider := &StaticExchangeRateProvider{rates: make(map[string][]ExchangeRate)}
+	for _, rate := range rates {
+		currency := normalizeCurrency(rate.Currency)
+		if currency == "" || currency == BaseCurrency {
+			return nil, fmt.Errorf("汇率币种不合法: %s", rate.Currency)
+		}
+		if rate.Rate <= 0 {
+			return nil, fmt.Errorf("%s汇率必须大于0: %v", currency, rate.Rate)
+		}
+		if rate.EffectiveDate.IsZero() {
+			return nil, fmt.Errorf("%s汇率缺少生效日期", currency)
+		}
+		rate.Currency = currency
+		provider.rates[currency] = append(provider.rates[currency], rate)
+	}
+
+	for _, history := range provider.rates {
+		sort.SliceStable(history, func(i, j int) bool {
+			return history[i].EffectiveDate.Before(history[j].EffectiveDate)
+		})
+	}
+	return provider, nil
+}
+
+// ParseExchangeRate 解析汇率配置，生效日期格式为YYYY-MM-DD
+func ParseExchangeRate(currency string, rate float64, effectiveDate string) (ExchangeRate, error) {
+	date, err := time.Parse(exchangeRateDateLayout, strings.TrimSpace(effectiveDate))
+	if err != nil {
+		return ExchangeRate{}, fmt.Errorf("%s汇率生效日期格式错误，应为YYYY-MM-DD: %s", currency, effectiveDate)
+	}
+	return ExchangeRate{Currency: currency, Rate: rate, EffectiveDate: date}, nil
+}
+
+// GetRate 获取开票日期当天或之前最近一次生效的汇率
+func (p *StaticExchangeRateProvider) GetRate(ctx context.Context, currency string, date time.Time) (*ExchangeRate, error) {
+	currency = normalizeCurrency(currency)
+	// 按自然日比较，开票日期当天生效的汇率也适用
+	day := date.Format(exchangeRateDateLayout)
+
+	history := p.rates[currency]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].EffectiveDate.Format(exchangeRateDateLayout) <= day {
+			rate := history[i]
+			return &rate, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrExchangeRateNotFound, currency, day)
+}
+
+// ConvertToBaseCurrency 按指定日期生效的汇率将外币金额折算为人民币，金额保留两位小数
+func ConvertToBaseCurrency(ctx context.Context, provider ExchangeRateProvider, amount float64, currency string, date time.Time) (float64, *ExchangeRate, error) {
+	if provider == nil {
+		return 0, nil, ErrExchangeRateUnavailable
+	}
+	if date.IsZero() {
+		return 0, nil, ErrInvoiceDateMissing
+	}
+	rate, err := provider.GetRate(ctx, currency, date)
+	if err != nil {
+		return 0, nil, err
+	}
+	return math.Round(amount*rate.Rate*100) / 100, rate, nil
+}
+
+// IsForeignCurrency 判断发票是否为外币发票
+func (i *Invoice) IsForeignCurrency() bool {
+	currency := normalizeCurrency(i.OriginalCurrency)
+	return currency != "" && currency != BaseCurrency
+}
+
+// normalizeCurrency 统一币种代码格式
+func normalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// recognizedAmount 获取发票的识别金额，外币发票为原币金额
+func recognizedAmount(invoice *Invoice) float64 {
+	if invoice.IsForeignCurrency() {
+		return invoice.OriginalAmount
+	}
+	return invoice.Amount
+}
+
+// setRecognizedAmount 设置发票的识别金额，外币发票写入原币金额，待按汇率折算后写入发票金额
+func setRecognizedAmount(invoice *Invoice, amount float64) {
+	if invoice.IsForeignCurrency() {
+		invoice.OriginalAmount = amount
+		return
+	}
+	invoice.Amount = amount
+}
+
+// SetExchangeRateProvider 设置汇率数据源，未设置时外币发票无法折算
+func (s *ParserService) SetExchangeRateProvider(provider ExchangeRateProvider) {
+	s.rateProvider = provider
+}
+
+// normalizeInvoiceCurrency 外币发票按开票日期生效的汇率折算为人民币金额，人民币发票不处理
+func (s *ParserService) normalizeInvoiceCurrency(ctx context.Context, invoice *Invoice) error {
+	if !invoice.IsForeignCurrency() {
+		return nil
+	}
+
+	amount, rate, err := ConvertToBaseCurrency(ctx, s.rateProvider, invoice.OriginalAmount, invoice.OriginalCurrency, invoice.Date)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("外币发票汇率折算失败",
+			logger.NewField("invoice_id", invoice.ID),
+			logger.NewField("currency", invoice.OriginalCurrency),
+			logger.NewField("invoice_date", invoice.Date.Format(exchangeRateDateLayout)),
+			logger.NewField("error", err))
+		return fmt.Errorf("外币发票汇率折算失败: %w", err)
+	}
+
+	invoice.OriginalCurrency = rate.Currency
+	invoice.ExchangeRate = rate.Rate
+	invoice.Amount = amount
+	return nil
+}
@@ -0,0 +1,119 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewStaticExchangeRateProvider(t *testing.T) {
+	date := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rate    ExchangeRate
+		wantErr bool
+	}{
+		{name: "合法汇率", rate: ExchangeRate{Currency: " usd ", Rate: 7.1, EffectiveDate: date}},
+		{name: "币种为空", rate: ExchangeRate{Rate: 7.1, EffectiveDate: date}, wantErr: true},
+		{name: "本位币不能配置汇率", rate: ExchangeRate{Currency: "cny", Rate: 1, EffectiveDate: date}, wantErr: true},
+		{name: "汇率必须大于0", rate: ExchangeRate{Currency: "USD", EffectiveDate: date}, wantErr: true},
+		{name: "缺少生效日期", rate: ExchangeRate{Currency: "USD", Rate: 7.1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStaticExchangeRateProvider(tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewStaticExchangeRateProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConvertToBaseCurrency(t *testing.T) {
+	rate := func(currency string, value float64, effectiveDate string) ExchangeRate {
+		r, err := ParseExchangeRate(currency, value, effectiveDate)
+		if err != nil {
+			t.Fatalf("ParseExchangeRate() error = %v", err)
+		}
+		return r
+	}
+	// 故意乱序传入，验证按生效日期排序
+	provider, err := NewStaticExchangeRateProvider(
+		rate("USD", 7.2, "2024-07-01"),
+		rate("USD", 7.1, "2024-01-01"),
+		rate("eur", 7.8, "2024-01-01"),
+	)
+	if err != nil {
+		t.Fatalf("NewStaticExchangeRateProvider() error = %v", err)
+	}
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 15, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name     string
+		provider ExchangeRateProvider
+		amount   float64
+		currency string
+		date     time.Time
+		want     float64
+		wantRate float64
+		wantErr  error
+	}{
+		{name: "使用开票日期之前最近的汇率", provider: provider, amount: 100, currency: "USD", date: day(time.June, 30), want: 710, wantRate: 7.1},
+		{name: "生效当天使用新汇率", provider: provider, amount: 100, currency: "usd", date: day(time.July, 1), want: 720, wantRate: 7.2},
+		{name: "金额保留两位小数", provider: provider, amount: 10.555, currency: "EUR", date: day(time.March, 1), want: 82.33, wantRate: 7.8},
+		{name: "开票日期早于全部汇率", provider: provider, amount: 100, currency: "USD", date: time.Date(2023, time.December, 31, 0, 0, 0, 0, time.Local), wantErr: ErrExchangeRateNotFound},
+		{name: "未配置的币种", provider: provider, amount: 100, currency: "JPY", date: day(time.March, 1), wantErr: ErrExchangeRateNotFound},
+		{name: "缺少开票日期", provider: provider, amount: 100, currency: "USD", wantErr: ErrInvoiceDateMissing},
+		{name: "未配置汇率数据源", amount: 100, currency: "USD", date: day(time.March, 1), wantErr: ErrExchangeRateUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotRate, err := ConvertToBaseCurrency(context.Background(), tt.provider, tt.amount, tt.currency, tt.date)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConvertToBaseCurrency() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got != tt.want || gotRate.Rate != tt.wantRate {
+				t.Errorf("ConvertToBaseCurrency() = (%v, %v), want (%v, %v)", got, gotRate.Rate, tt.want, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestRecognizedAmount(t *testing.T) {
+	tests := []struct {
+		name         string
+		invoice      *Invoice
+		wantForeign  bool
+		wantAmount   float64
+		wantOriginal float64
+	}{
+		{name: "人民币发票", invoice: &Invoice{}, wantAmount: 100},
+		{name: "显式人民币币种", invoice: &Invoice{OriginalCurrency: " cny "}, wantAmount: 100},
+		{name: "外币发票写入原币金额", invoice: &Invoice{OriginalCurrency: "USD"}, wantForeign: true, wantOriginal: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invoice.IsForeignCurrency(); got != tt.wantForeign {
+				t.Errorf("IsForeignCurrency() = %v, want %v", got, tt.wantForeign)
+			}
+			setRecognizedAmount(tt.invoice, 100)
+			if tt.invoice.Amount != tt.wantAmount || tt.invoice.OriginalAmount != tt.wantOriginal {
+				t.Errorf("Amount = %v, OriginalAmount = %v, want %v, %v",
+					tt.invoice.Amount, tt.invoice.OriginalAmount, tt.wantAmount, tt.wantOriginal)
+			}
+			if got := recognizedAmount(tt.invoice); got != 100 {
+				t.Errorf("recognizedAmount() = %v, want 100", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// invoice_format.go 发票号码格式校验规则
+// 功能点：
+// 1. 定义发票代码、发票号码的格式规则，区分传统发票（8位号码）与全电发票（20位号码）
+// 2. 根据发票类型选择对应规则，类型未识别时按号码长度匹配
+// 3. 全电发票没有发票代码，不要求识别发票代码
+// 4. 格式规则可通过配置替换或扩展
+
+package ocr
+
+import (
+	"strings"
+	"sync"
+)
+
+// InvoiceFormatRule 发票格式规则
+type InvoiceFormatRule struct {
+	Name          string   `json:"name" yaml:"name"`                     // 规则名称
+	TypeKeywords  []string `json:"type_keywords" yaml:"type_keywords"`   // 发票类型包含任一关键字时适用，为空表示默认规则
+	NumberLengths []int    `json:"number_lengths" yaml:"number_lengths"` // 发票号码允许的位数
+	CodeLengths   []int    `json:"code_lengths" yaml:"code_lengths"`     // 发票代码允许的位数，为空表示不校验位数
+	CodeRequired  bool     `json:"code_required" yaml:"code_required"`   // 是否必须有发票代码
+}
+
+// DefaultInvoiceFormatRules 默认发票格式规则：全电发票20位号码、无发票代码；传统发票8位号码、10或12位发票代码
+func DefaultInvoiceFormatRules() []InvoiceFormatRule {
+	return []InvoiceFormatRule{
+		{
+			Name:          "全电发票",
+			TypeKeywords:  []string{"全电", "数电", "电子发票（", "电子发票("},
+			NumberLengths: []int{20},
+		},
+		{
+			Name:          "传统发票",
+			NumberLengths: []int{8},
+			CodeLengths:   []int{10, 12},
+			CodeRequired:  true,
+		},
+	}
+}
+
+var (
+	formatRulesMu sync.RWMutex
+	formatRules   = DefaultInvoiceFormatRules()
+)
+
+// SetInvoiceFormatRules 设置发票格式规则，按顺序匹配；为空时恢复默认规则
+func SetInvoiceFormatRules(rules []InvoiceFormatRule) {
+	if len(rules) == 0 {
+		rules = DefaultInvoiceFormatRules()
+	}
+
+	formatRulesMu.Lock()
+	defer formatRulesMu.Unlock()
+	formatRules = append([]InvoiceFormatRule(nil), rules...)
+}
+
+// FormatRule 选择发票适用的格式规则
+// 优先按发票类型关键字匹配；发票类型为空时按号码位数匹配；都未匹配时使用默认规则（未配置关键字的规则）
+func (i *InvoiceInfo) FormatRule() InvoiceFormatRule {
+	formatRulesMu.RLock()
+	defer formatRulesMu.RUnlock()
+
+	invoiceType := strings.TrimSpace(i.InvoiceType)
+	if invoiceType != "" {
+		for _, rule := range formatRules {
+			if rule.matchesType(invoiceType) {
+				return rule
+			}
+		}
+	} else if number := strings.TrimSpace(i.InvoiceNumber); number != "" {
+		for _, rule := range formatRules {
+			if containsLength(rule.NumberLengths, len(number)) {
+				return rule
+			}
+		}
+	}
+
+	for _, rule := range formatRules {
+		if len(rule.TypeKeywords) == 0 {
+			return rule
+		}
+	}
+
+	// 未配置默认规则时只校验号码和代码为纯数字
+	return InvoiceFormatRule{}
+}
+
+// matchesType 判断发票类型是否包含规则的任一关键字
+func (r InvoiceFormatRule) matchesType(invoiceType string) bool {
+	for _, keyword := range r.TypeKeywords {
+		if keyword != "" && strings.Contains(invoiceType, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidNumber 校验发票号码格式：纯数字且位数符合规则
+func (r InvoiceFormatRule) ValidNumber(number string) bool {
+	return isNumeric(number) && (len(r.NumberLengths) == 0 || containsLength(r.NumberLengths, len(number)))
+}
+
+// ValidCode 校验发票代码格式：纯数字且位数符合规则
+func (r InvoiceFormatRule) ValidCode(code string) bool {
+	return isNumeric(code) && (len(r.CodeLengths) == 0 || containsLength(r.CodeLengths, len(code)))
+}
+
+// containsLength 判断位数是否在允许列表中
+func containsLength(lengths []int, length int) bool {
+	for _, l := range lengths {
+		if l == length {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// invoice_item.go 发票商品明细
+// 功能点：
+// 1. 定义OCR识别的商品明细行结构
+// 2. 定义发票商品明细持久化模型
+// 3. 将OCR商品明细转换为持久化模型
+// 4. 根据商品明细汇总发票的商品名称、规格、数量、单价和税率
+
+package ocr
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// commodityNameMaxLength 发票商品名称字段的最大字符数
+const commodityNameMaxLength = 200
+
+// InvoiceItemInfo OCR识别的发票商品明细行
+type InvoiceItemInfo struct {
+	LineNo        int     `json:"line_no"`       // 行号
+	Name          string  `json:"name"`          // 商品名称
+	Specification string  `json:"specification"` // 规格型号
+	Unit          string  `json:"unit"`          // 单位
+	Quantity      float64 `json:"quantity"`      // 数量
+	Price         float64 `json:"price"`         // 单价
+	Amount        float64 `json:"amount"`        // 金额(不含税)
+	TaxRate       float64 `json:"tax_rate"`      // 税率(%)
+	TaxAmount     float64 `json:"tax_amount"`    // 税额
+}
+
+// InvoiceItem 发票商品明细模型
+type InvoiceItem struct {
+	ID            string    `json:"id" gorm:"primaryKey;type:varchar(36);column:id"`                                              // 明细ID
+	InvoiceID     string    `json:"invoice_id" gorm:"type:varchar(36);not null;index:idx_invoice_item_invoice;column:invoice_id"` // 发票ID
+	LineNo        int       `json:"line_no" gorm:"column:line_no"`                                                                // 行号
+	Name          string    `json:"name" gorm:"type:varchar(200);column:name"`                                                    // 商品名称
+	Specification string    `json:"specification" gorm:"type:varchar(100);column:specification"`                                  // 规格型号
+	Unit          string    `json:"unit" gorm:"type:varchar(20);column:unit"`                                                     // 单位
+	Quantity      float64   `json:"quantity" gorm:"type:decimal(12,4);column:quantity"`                                           // 数量
+	Price         float64   `json:"price" gorm:"type:decimal(12,4);column:price"`                                                 // 单价
+	Amount        float64   `json:"amount" gorm:"type:decimal(10,2);column:amount"`                                               // 金额(不含税)
+	TaxRate       float64   `json:"tax_rate" gorm:"type:decimal(5,2);column:tax_rate"`                                            // 税率(%)
+	TaxAmount     float64   `json:"tax_amount" gorm:"type:decimal(10,2);column:tax_amount"`                                       // 税额
+	CreatedAt     time.Time `json:"created_at" gorm:"type:datetime;not null;column:created_at"`                                   // 创建时间
+	UpdatedAt     time.Time `json:"updated_at" gorm:"type:datetime;not null;column:updated_at"`                                   // 更新时间
+}
+
+// TableName 指定发票商品明细表名
+func (InvoiceItem) TableName() string {
+	return "invoice_items"
+}
+
+// NewInvoiceItems 将OCR识别的商品明细转换为发票商品明细，跳过没有商品名称和金额的空行
+func NewInvoiceItems(invoiceID string, infos []*InvoiceItemInfo) []*InvoiceItem {
+	now := time.Now()
+	items := make([]*InvoiceItem, 0, len(infos))
+	for _, info := range infos {
+		if info == nil || (strings.TrimSpace(info.Name) == "" && info.Amount == 0) {
+			continue
+		}
+
+		lineNo := info.LineNo
+		if lineNo <= 0 {
+			lineNo = len(items) + 1
+		}
+
+		items = append(items, &InvoiceItem{
+			ID:            uuid.New().String(),
+			InvoiceID:     invoiceID,
+			LineNo:        lineNo,
+			Name:          strings.TrimSpace(info.Name),
+			Specification: strings.TrimSpace(info.Specification),
+			Unit:          strings.TrimSpace(info.Unit),
+			Quantity:      info.Quantity,
+			Price:         info.Price,
+			Amount:        info.Amount,
+			TaxRate:       info.TaxRate,
+			TaxAmount:     info.TaxAmount,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	return items
+}
+
+// applyInvoiceItems 根据商品明细汇总发票的商品字段，未识别到的字段保留原值
+// 商品名称按行合并；规格、单位、数量、单价仅在单行明细时回填；税率取首个识别到的税率
+func applyInvoiceItems(invoice *Invoice, infos []*InvoiceItemInfo) {
+	var names []string
+	var lines []*InvoiceItemInfo
+	for _, info := range infos {
+		if info == nil {
+			continue
+		}
+		lines = append(lines, info)
+		if name := strings.TrimSpace(info.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	if len(names) > 0 {
+		invoice.CommodityName = truncateRunes(strings.Join(names, "、"), commodityNameMaxLength)
+	}
+
+	if len(lines) == 1 {
+		line := lines[0]
+		if spec := strings.TrimSpace(line.Specification); spec != "" {
+			invoice.Specification = spec
+		}
+		if unit := strings.TrimSpace(line.Unit); unit != "" {
+			invoice.Unit = unit
+		}
+		if line.Quantity > 0 {
+			invoice.Quantity = line.Quantity
+		}
+		if line.Price > 0 {
+			invoice.Price = line.Price
+		}
+	}
+
+	for _, line := range lines {
+		if line.TaxRate > 0 {
+			invoice.VATRate = line.TaxRate
+			break
+		}
+	}
+}
+
+// ParseTaxRate 解析税率文本（如"6%"、"13"、"0.06"），返回百分比数值；免税、不征税等返回0
+func ParseTaxRate(text string) float64 {
+	cleaned := strings.TrimSpace(text)
+	cleaned = strings.TrimSuffix(cleaned, "%")
+	cleaned = strings.TrimSuffix(cleaned, "％")
+	if cleaned == "" {
+		return 0
+	}
+
+	rate, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || rate < 0 {
+		return 0
+	}
+	if rate > 0 && rate < 1 && !strings.Contains(text, "%") && !strings.Contains(text, "％") {
+		rate *= 100
+	}
+	return rate
+}
+
+// truncateRunes 按字符数截断字符串
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
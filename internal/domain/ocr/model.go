@@ -3,10 +3,16 @@
 // 1. 定义OCR解析的发票信息结构
 // 2. 定义OCR配置结构
 // 3. 提供领域相关的验证方法
+// 4. 定义识别字段在发票图片中的位置框，供前端叠加显示
+// 5. 发票识别结果携带商品明细行
+// 6. 记录各识别字段的OCR置信度，供校验规则拦截识别质量差的发票
 
 package ocr
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"strconv"
 	"time"
 )
@@ -23,6 +29,7 @@ type InvoiceInfo struct {
 	TotalAmount  float64 `json:"total_amount"`   // 金额合计(不含税)
 	TaxAmount    float64 `json:"tax_amount"`     // 税额
 	TotalWithTax float64 `json:"total_with_tax"` // 价税合计
+	Currency     string  `json:"currency"`       // 币种(ISO 4217)，为空表示人民币
 
 	// 购方信息
 	BuyerName      string `json:"buyer_name"`       // 购买方名称
@@ -36,39 +43,226 @@ type InvoiceInfo struct {
 	CheckCode    string `json:"check_code"`    // 校验码
 	PasswordArea string `json:"password_area"` // 密码区
 
+	// 商品明细
+	Items []*InvoiceItemInfo `json:"items"` // 商品明细行
+
 	// 其他信息
+	Remarks      string    `json:"remarks"`       // 备注
 	IsValid      bool      `json:"is_valid"`      // 是否有效
 	ErrorMessage string    `json:"error_message"` // 错误信息
 	RawText      string    `json:"raw_text"`      // OCR原始文本
 	ParseTime    time.Time `json:"parse_time"`    // 解析时间
+
+	// 字段位置和置信度信息
+	FieldBoxes       FieldBoxes       `json:"field_boxes"`       // 识别字段在图片中的位置框
+	FieldConfidences FieldConfidences `json:"field_confidences"` // 识别字段的置信度，OCR未返回置信度的字段不记录
+}
+
+// Point 图片像素坐标点（左上角为原点）
+type Point struct {
+	X int64 `json:"x"` // 横坐标
+	Y int64 `json:"y"` // 纵坐标
+}
+
+// FieldBox 识别字段在发票图片中的位置框
+type FieldBox struct {
+	X       int64   `json:"x"`                 // 外接矩形左上角横坐标
+	Y       int64   `json:"y"`                 // 外接矩形左上角纵坐标
+	Width   int64   `json:"width"`             // 外接矩形宽度
+	Height  int64   `json:"height"`            // 外接矩形高度
+	Polygon []Point `json:"polygon,omitempty"` // 原始四边形顶点(左上/右上/右下/左下)
+}
+
+// FieldBoxes 字段位置框集合，键为InvoiceInfo字段的JSON名称(如invoice_code)
+type FieldBoxes map[string]*FieldBox
+
+// NewFieldBoxFromPolygon 根据多边形顶点计算外接矩形位置框
+func NewFieldBoxFromPolygon(points []Point) *FieldBox {
+	if len(points) == 0 {
+		return nil
+	}
+
+	minX, minY := points[0].X, points[0].Y
+	maxX, maxY := points[0].X, points[0].Y
+	for _, point := range points[1:] {
+		if point.X < minX {
+			minX = point.X
+		}
+		if point.Y < minY {
+			minY = point.Y
+		}
+		if point.X > maxX {
+			maxX = point.X
+		}
+		if point.Y > maxY {
+			maxY = point.Y
+		}
+	}
+
+	return &FieldBox{
+		X:       minX,
+		Y:       minY,
+		Width:   maxX - minX,
+		Height:  maxY - minY,
+		Polygon: points,
+	}
+}
+
+// Scan 实现 sql.Scanner 接口
+func (b *FieldBoxes) Scan(value interface{}) error {
+	if value == nil {
+		*b = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("无法扫描字段位置数据")
+	}
+
+	if len(data) == 0 {
+		*b = nil
+		return nil
+	}
+
+	var result FieldBoxes
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	*b = result
+	return nil
+}
+
+// Value 实现 driver.Valuer 接口
+func (b FieldBoxes) Value() (driver.Value, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// FieldConfidences 字段置信度集合，键为InvoiceInfo字段的JSON名称，值为0-1的置信度
+type FieldConfidences map[string]float64
+
+// ConfidenceConfirmed 人工补全或确认的字段置信度
+const ConfidenceConfirmed = 1.0
+
+// NormalizeConfidence 将OCR返回的置信度统一为0-1，百分制(大于1)的按百分比换算
+func NormalizeConfidence(confidence float64) float64 {
+	if confidence > 1 {
+		confidence /= 100
+	}
+	if confidence < 0 {
+		return 0
+	}
+	if confidence > 1 {
+		return 1
+	}
+	return confidence
+}
+
+// Get 获取字段置信度，OCR未返回该字段置信度时返回false
+func (c FieldConfidences) Get(field string) (float64, bool) {
+	confidence, ok := c[field]
+	return confidence, ok
+}
+
+// Confirm 将人工补全或确认的字段置信度置为1，返回更新后的集合
+func (c FieldConfidences) Confirm(fields ...string) FieldConfidences {
+	if len(fields) == 0 {
+		return c
+	}
+	if c == nil {
+		c = make(FieldConfidences, len(fields))
+	}
+	for _, field := range fields {
+		c[field] = ConfidenceConfirmed
+	}
+	return c
+}
+
+// Scan 实现 sql.Scanner 接口
+func (c *FieldConfidences) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("无法扫描字段置信度数据")
+	}
+
+	if len(data) == 0 {
+		*c = nil
+		return nil
+	}
+
+	var result FieldConfidences
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	*c = result
+	return nil
+}
+
+// Value 实现 driver.Valuer 接口
+func (c FieldConfidences) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
 }
 
 // Invoice 发票模型
 type Invoice struct {
-	ID              string    `json:"id" gorm:"primaryKey;type:varchar(36);column:id"`                                                      // 发票ID
-	ReimbursementID string    `json:"reimbursement_id" gorm:"type:varchar(36);not null;index:idx_reimbursement_id;column:reimbursement_id"` // 报销单ID
-	Type            string    `json:"type" gorm:"type:varchar(50);column:type"`                                                             // 发票类型(增值税发票/定额发票等)
-	Code            string    `json:"code" gorm:"type:varchar(50);column:code"`                                                             // 发票代码
-	Number          string    `json:"number" gorm:"type:varchar(50);column:number"`                                                         // 发票号码
-	Date            time.Time `json:"date" gorm:"type:date;column:date"`                                                                    // 开票日期
-	Amount          float64   `json:"amount" gorm:"type:decimal(10,2);not null;column:amount"`                                              // 发票金额
-	TaxAmount       float64   `json:"tax_amount" gorm:"type:decimal(10,2);column:tax_amount"`                                               // 税额
-	Payer           string    `json:"payer" gorm:"type:varchar(100);column:payer"`                                                          // 付款方
-	Payee           string    `json:"payee" gorm:"type:varchar(100);column:payee"`                                                          // 收款方
-	BuyerName       string    `json:"buyer_name" gorm:"type:varchar(100);column:buyer_name"`                                                // 购买方名称
-	BuyerTaxNo      string    `json:"buyer_tax_no" gorm:"type:varchar(50);column:buyer_tax_no"`                                             // 购买方税号
-	SellerName      string    `json:"seller_name" gorm:"type:varchar(100);column:seller_name"`                                              // 销售方名称
-	SellerTaxNo     string    `json:"seller_tax_no" gorm:"type:varchar(50);column:seller_tax_no"`                                           // 销售方税号
-	CommodityName   string    `json:"commodity_name" gorm:"type:varchar(200);column:commodity_name"`                                        // 商品名称
-	Specification   string    `json:"specification" gorm:"type:varchar(100);column:specification"`                                          // 规格型号
-	Unit            string    `json:"unit" gorm:"type:varchar(20);column:unit"`                                                             // 单位
-	Quantity        float64   `json:"quantity" gorm:"type:decimal(10,2);column:quantity"`                                                   // 数量
-	Price           float64   `json:"price" gorm:"type:decimal(10,2);column:price"`                                                         // 单价
-	ImagePath       string    `json:"image_path" gorm:"type:varchar(500);column:image_path"`                                                // 发票图片路径
-	OCRResult       string    `json:"ocr_result" gorm:"type:text;column:ocr_result"`                                                        // OCR识别结果
-	Status          string    `json:"status" gorm:"type:varchar(20);not null;default:'待识别';column:status"`                                  // 状态(待识别/已识别/识别失败)
-	CreatedAt       time.Time `json:"created_at" gorm:"type:datetime;not null;column:created_at"`                                           // 创建时间
-	UpdatedAt       time.Time `json:"updated_at" gorm:"type:datetime;not null;column:updated_at"`                                           // 更新时间
+	ID               string           `json:"id" gorm:"primaryKey;type:varchar(36);column:id"`                                                      // 发票ID
+	ReimbursementID  string           `json:"reimbursement_id" gorm:"type:varchar(36);not null;index:idx_reimbursement_id;column:reimbursement_id"` // 报销单ID
+	Type             string           `json:"type" gorm:"type:varchar(50);column:type"`                                                             // 发票类型(增值税发票/定额发票等)
+	Code             string           `json:"code" gorm:"type:varchar(50);column:code"`                                                             // 发票代码
+	Number           string           `json:"number" gorm:"type:varchar(50);column:number"`                                                         // 发票号码
+	Date             time.Time        `json:"date" gorm:"type:date;column:date"`                                                                    // 开票日期
+	Amount           float64          `json:"amount" gorm:"type:decimal(10,2);not null;column:amount"`                                              // 发票金额
+	TaxAmount        float64          `json:"tax_amount" gorm:"type:decimal(10,2);column:tax_amount"`                                               // 税额
+	Payer            string           `json:"payer" gorm:"type:varchar(100);column:payer"`                                                          // 付款方
+	Payee            string           `json:"payee" gorm:"type:varchar(100);column:payee"`                                                          // 收款方
+	BuyerName        string           `json:"buyer_name" gorm:"type:varchar(100);column:buyer_name"`                                                // 购买方名称
+	BuyerTaxNo       string           `json:"buyer_tax_no" gorm:"type:varchar(50);column:buyer_tax_no"`                                             // 购买方税号
+	SellerName       string           `json:"seller_name" gorm:"type:varchar(100);column:seller_name"`                                              // 销售方名称
+	SellerTaxNo      string           `json:"seller_tax_no" gorm:"type:varchar(50);column:seller_tax_no"`                                           // 销售方税号
+	CommodityName    string           `json:"commodity_name" gorm:"type:varchar(200);column:commodity_name"`                                        // 商品名称
+	Specification    string           `json:"specification" gorm:"type:varchar(100);column:specification"`                                          // 规格型号
+	Unit             string           `json:"unit" gorm:"type:varchar(20);column:unit"`                                                             // 单位
+	Quantity         float64          `json:"quantity" gorm:"type:decimal(10,2);column:quantity"`                                                   // 数量
+	Price            float64          `json:"price" gorm:"type:decimal(10,2);column:price"`                                                         // 单价
+	ImagePath        string           `json:"image_path" gorm:"type:varchar(500);column:image_path"`                                                // 发票图片路径
+	OCRResult        string           `json:"ocr_result" gorm:"type:text;column:ocr_result"`                                                        // OCR识别结果
+	FieldBoxes       FieldBoxes       `json:"field_boxes" gorm:"type:text;column:field_boxes"`                                                      // 识别字段位置框(JSON)
+	FieldConfidences FieldConfidences `json:"field_confidences" gorm:"type:text;column:field_confidences"`                                          // 识别字段置信度(JSON)
+	Status           string           `json:"status" gorm:"type:varchar(20);not null;default:'待识别';column:status"`                                  // 状态(待识别/已识别/部分识别/解析失败/识别失败)
+	MissingFields    string           `json:"missing_fields" gorm:"type:varchar(200);column:missing_fields"`                                        // 部分识别时缺失的字段(逗号分隔)
+	OCRAttempts      int              `json:"ocr_attempts" gorm:"default:0;column:ocr_attempts"`                                                    // 已识别次数
+	OCRError         string           `json:"ocr_error" gorm:"type:varchar(500);column:ocr_error"`                                                  // 最近一次识别失败原因
+	CreatedAt        time.Time        `json:"created_at" gorm:"type:datetime;not null;column:created_at"`                                           // 创建时间
+	UpdatedAt        time.Time        `json:"updated_at" gorm:"type:datetime;not null;column:updated_at"`                                           // 更新时间
 
 	// 扩展字段 - 支持更丰富的报销规则
 	Category           string    `json:"category" gorm:"type:varchar(50);column:category"`                                     // 发票类别(差旅费/办公费/招待费/培训费等)
@@ -106,11 +300,18 @@ type Invoice struct {
 	VerificationStatus string    `json:"verification_status" gorm:"type:varchar(20);default:'未验证';column:verification_status"` // 验证状态
 	VerificationTime   time.Time `json:"verification_time" gorm:"type:datetime;column:verification_time"`                      // 验证时间
 	Remarks            string    `json:"remarks" gorm:"type:text;column:remarks"`                                              // 备注
+
+	// 商品明细，单独存储在invoice_items表
+	Items []*InvoiceItem `json:"items,omitempty" gorm:"-"`
 }
 
 // Config OCR服务配置
 type Config struct {
-	// 腾讯云OCR配置
+	// 提供商配置
+	Provider string `json:"provider" yaml:"provider"` // OCR提供商(tencent/aliyun)，为空时使用腾讯云
+	Endpoint string `json:"endpoint" yaml:"endpoint"` // 接口地址，为空时按提供商和地域确定
+
+	// 提供商凭证配置（腾讯云SecretId/SecretKey，阿里云AccessKeyId/AccessKeySecret）
 	SecretID  string `json:"secret_id" yaml:"secret_id"`
 	SecretKey string `json:"secret_key" yaml:"secret_key"`
 	Region    string `json:"region" yaml:"region"`
@@ -118,12 +319,19 @@ type Config struct {
 	// 请求配置
 	Timeout    int `json:"timeout" yaml:"timeout"`         // 超时时间(秒)
 	MaxRetries int `json:"max_retries" yaml:"max_retries"` // 最大重试次数
+
+	// 图片来源配置
+	UseImageURL     bool `json:"use_image_url" yaml:"use_image_url"`       // URL图片直接传给OCR服务，不在本地下载
+	DownloadTimeout int  `json:"download_timeout" yaml:"download_timeout"` // URL图片下载超时时间(秒)
+	MaxImageSize    int  `json:"max_image_size" yaml:"max_image_size"`     // 图片大小上限(MB)
 }
 
 // Validate 验证发票信息是否有效
 func (i *InvoiceInfo) Validate() (bool, string) {
-	// 检查必填字段
-	if i.InvoiceCode == "" {
+	rule := i.FormatRule()
+
+	// 检查必填字段，全电发票没有发票代码
+	if i.InvoiceCode == "" && rule.CodeRequired {
 		return false, "发票代码为空"
 	}
 	if i.InvoiceNumber == "" {
@@ -136,13 +344,11 @@ func (i *InvoiceInfo) Validate() (bool, string) {
 		return false, "金额无效"
 	}
 
-	// 验证发票代码格式（通常为10位或12位数字）
-	if !isNumeric(i.InvoiceCode) || (len(i.InvoiceCode) != 10 && len(i.InvoiceCode) != 12) {
+	// 按发票类型对应的规则验证发票代码和号码格式（传统发票8位号码，全电发票20位号码）
+	if i.InvoiceCode != "" && !rule.ValidCode(i.InvoiceCode) {
 		return false, "发票代码格式不正确"
 	}
-
-	// 验证发票号码格式（通常为8位数字）
-	if !isNumeric(i.InvoiceNumber) || len(i.InvoiceNumber) != 8 {
+	if !rule.ValidNumber(i.InvoiceNumber) {
 		return false, "发票号码格式不正确"
 	}
 
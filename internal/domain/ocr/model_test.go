@@ -0,0 +1,80 @@
+package ocr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewFieldBoxFromPolygon(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+		want   *FieldBox
+	}{
+		{name: "无顶点返回nil", points: nil, want: nil},
+		{
+			name:   "四边形计算外接矩形",
+			points: []Point{{X: 10, Y: 20}, {X: 110, Y: 18}, {X: 112, Y: 50}, {X: 8, Y: 52}},
+			want: &FieldBox{
+				X: 8, Y: 18, Width: 104, Height: 34,
+				Polygon: []Point{{X: 10, Y: 20}, {X: 110, Y: 18}, {X: 112, Y: 50}, {X: 8, Y: 52}},
+			},
+		},
+		{
+			name:   "单个顶点宽高为0",
+			points: []Point{{X: 5, Y: 6}},
+			want:   &FieldBox{X: 5, Y: 6, Polygon: []Point{{X: 5, Y: 6}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewFieldBoxFromPolygon(tt.points); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewFieldBoxFromPolygon() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldBoxesScan(t *testing.T) {
+	boxes := FieldBoxes{"invoice_code": {X: 1, Y: 2, Width: 3, Height: 4}}
+	stored, err := boxes.Value()
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    FieldBoxes
+		wantErr bool
+	}{
+		{name: "字符串往返", value: stored, want: boxes},
+		{name: "字节切片", value: []byte(stored.(string)), want: boxes},
+		{name: "nil为空", value: nil, want: nil},
+		{name: "空字节为空", value: []byte{}, want: nil},
+		{name: "非法JSON返回错误", value: "{", wantErr: true},
+		{name: "不支持的类型返回错误", value: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got FieldBoxes
+			err := got.Scan(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldBoxesValueEmpty(t *testing.T) {
+	for _, boxes := range []FieldBoxes{nil, {}} {
+		if value, err := boxes.Value(); value != nil || err != nil {
+			t.Errorf("Value() = (%v, %v), want (nil, nil)", value, err)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// ocr_retry.go 发票OCR识别失败重试
+// 功能点：
+// 1. 区分可重试错误（网络异常、限流、服务端错误）与不可重试错误（图片损坏、图片不存在、图片过大）
+// 2. 可重试的失败置为"解析失败"，不可重试或重试次数用尽时置为终态"识别失败"
+// 3. 提供按发票ID重新识别"解析失败"发票的接口
+// 4. 后台周期扫描"解析失败"的发票，按识别次数指数退避后自动重试，重试次数有上限
+
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// OCR识别失败状态
+const (
+	// InvoiceStatusParseFailed 解析失败，可重试
+	InvoiceStatusParseFailed = "解析失败"
+	// InvoiceStatusRecognitionFailed 识别失败，图片无效或重试次数用尽，不再自动重试
+	InvoiceStatusRecognitionFailed = "识别失败"
+)
+
+// 自动重试默认配置
+const (
+	DefaultOCRMaxRetries      = 3
+	DefaultOCRRetryInterval   = time.Minute
+	DefaultOCRRetryBackoff    = 30 * time.Second
+	DefaultOCRRetryMaxBackoff = 30 * time.Minute
+	DefaultOCRRetryBatchSize  = 50
+)
+
+// ocrErrorMaxLength 发票记录中保存的识别失败原因最大字符数
+const ocrErrorMaxLength = 500
+
+// 识别重试错误
+var (
+	// ErrImageInvalid 发票图片无效（损坏、不存在、格式不支持或过大），重试不会成功
+	ErrImageInvalid = errors.New("发票图片无效")
+	// ErrInvoiceNotRetryable 发票不处于可重试的"解析失败"状态
+	ErrInvoiceNotRetryable = errors.New("发票不处于解析失败状态，无法重试")
+)
+
+// IsRetryableError 判断识别失败是否可重试，发票图片无效时不可重试，其余错误（网络、限流、服务端错误等）可重试
+func IsRetryableError(err error) bool {
+	return err != nil && !errors.Is(err, ErrImageInvalid)
+}
+
+// SetMaxRetries 设置识别失败后最多重试次数，非正数使用默认值
+func (s *ParserService) SetMaxRetries(maxRetries int) {
+	if maxRetries <= 0 {
+		maxRetries = DefaultOCRMaxRetries
+	}
+	s.maxRetries = maxRetries
+}
+
+// RetryInvoiceOCR 重新识别"解析失败"的发票
+func (s *ParserService) RetryInvoiceOCR(ctx context.Context, invoiceID string) error {
+	invoice, err := s.repo.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		return fmt.Errorf("获取发票信息失败: %w", err)
+	}
+	if invoice.Status != InvoiceStatusParseFailed {
+		return fmt.Errorf("%w: 当前状态为%s", ErrInvoiceNotRetryable, invoice.Status)
+	}
+
+	s.logger.WithContext(ctx).Info("重新识别发票",
+		logger.NewField("invoice_id", invoiceID),
+		logger.NewField("attempts", invoice.OCRAttempts))
+	return s.ParseInvoiceImage(ctx, invoiceID)
+}
+
+// failedStatus 根据识别错误和已识别次数确定失败状态，首次识别加重试次数用尽后不再重试
+func (s *ParserService) failedStatus(invoice *Invoice, err error) string {
+	if !IsRetryableError(err) || invoice.OCRAttempts > s.maxRetries {
+		return InvoiceStatusRecognitionFailed
+	}
+	return InvoiceStatusParseFailed
+}
+
+// truncateOCRError 截断识别失败原因
+func truncateOCRError(err error) string {
+	return truncateRunes(err.Error(), ocrErrorMaxLength)
+}
+
+// RetryConfig 识别失败自动重试配置
+type RetryConfig struct {
+	Enabled    bool          `json:"enabled"`     // 是否启用自动重试
+	Interval   time.Duration `json:"interval"`    // 扫描"解析失败"发票的周期
+	Backoff    time.Duration `json:"backoff"`     // 首次重试前的等待时长，之后按识别次数翻倍
+	MaxBackoff time.Duration `json:"max_backoff"` // 单次退避等待时长上限
+	BatchSize  int           `json:"batch_size"`  // 每次扫描的最大发票数
+}
+
+// normalize 规范化自动重试配置，非法值回退为默认值
+func (c RetryConfig) normalize() RetryConfig {
+	if c.Interval <= 0 {
+		c.Interval = DefaultOCRRetryInterval
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = DefaultOCRRetryBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultOCRRetryMaxBackoff
+	}
+	if c.MaxBackoff < c.Backoff {
+		c.MaxBackoff = c.Backoff
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultOCRRetryBatchSize
+	}
+	return c
+}
+
+// backoff 计算识别attempts次后的退避时长：Backoff * 2^(attempts-1)，不超过MaxBackoff
+func (c RetryConfig) backoff(attempts int) time.Duration {
+	backoff := c.Backoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// AutoRetrier 识别失败发票自动重试器
+type AutoRetrier struct {
+	service *ParserService
+	queue   *TaskQueue
+	config  RetryConfig
+	logger  logger.Logger
+	now     func() time.Time
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewAutoRetrier 创建识别失败发票自动重试器，queue不为空时重试任务进入识别任务队列，受队列并发上限约束
+func NewAutoRetrier(service *ParserService, queue *TaskQueue, config RetryConfig, logger logger.Logger) *AutoRetrier {
+	return &AutoRetrier{
+		service: service,
+		queue:   queue,
+		config:  config.normalize(),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Start 启动后台重试，未启用或已启动时不做任何操作
+func (r *AutoRetrier) Start(ctx context.Context) {
+	if !r.config.Enabled {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go r.run(ctx, r.done)
+
+	r.logger.WithContext(ctx).Info("发票识别失败自动重试已启动",
+		logger.NewField("max_retries", r.service.maxRetries),
+		logger.NewField("interval", r.config.Interval.String()))
+}
+
+// Stop 停止后台重试并等待当前扫描结束
+func (r *AutoRetrier) Stop() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// run 周期扫描"解析失败"的发票
+func (r *AutoRetrier) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.RetryOnce(ctx)
+		}
+	}
+}
+
+// RetryOnce 扫描一次"解析失败"的发票并重试退避时间已到的发票，返回发起重试的数量
+func (r *AutoRetrier) RetryOnce(ctx context.Context) int {
+	invoices, err := r.service.repo.ListInvoicesByStatus(ctx, InvoiceStatusParseFailed, r.config.BatchSize)
+	if err != nil {
+		r.logger.WithContext(ctx).Error("查询解析失败的发票失败", logger.NewField("error", err))
+		return 0
+	}
+
+	retried := 0
+	for _, invoice := range invoices {
+		if ctx.Err() != nil {
+			break
+		}
+		if r.now().Before(invoice.UpdatedAt.Add(r.config.backoff(invoice.OCRAttempts))) {
+			continue
+		}
+
+		retried++
+		if r.queue != nil {
+			if _, err := r.queue.Enqueue(ctx, invoice.ID); err != nil && !errors.Is(err, ErrTaskInProgress) {
+				r.logger.WithContext(ctx).Warn("发票重试任务入队失败",
+					logger.NewField("invoice_id", invoice.ID),
+					logger.NewField("error", err))
+			}
+			continue
+		}
+
+		if err := r.service.RetryInvoiceOCR(ctx, invoice.ID); err != nil {
+			r.logger.WithContext(ctx).Warn("自动重试发票识别失败",
+				logger.NewField("invoice_id", invoice.ID),
+				logger.NewField("attempt", invoice.OCRAttempts+1),
+				logger.NewField("error", err))
+		}
+	}
+
+	return retried
+}
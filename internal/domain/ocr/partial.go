@@ -0,0 +1,144 @@
+// partial.go OCR部分识别处理
+// 功能点：
+// 1. 定义发票必填字段及其中文名称
+// 2. 检测OCR结果中缺失的必填字段
+// 3. 定义部分识别策略，非关键字段缺失时标记为"部分识别"而非"无效"
+// 4. 对已识别字段进行格式校验
+// 5. 全电发票没有发票代码，发票代码不计为缺失字段
+
+package ocr
+
+import (
+	"strings"
+)
+
+// InvoiceStatusPartial 部分识别状态，缺失非关键字段，待人工补全
+const InvoiceStatusPartial = "部分识别"
+
+// missingFieldsSeparator 缺失字段列表分隔符
+const missingFieldsSeparator = ","
+
+// requiredField 发票必填字段
+type requiredField struct {
+	Key  string // 字段键(InvoiceInfo字段的JSON名称)
+	Name string // 字段中文名称
+}
+
+// requiredInvoiceFields 发票必填字段列表
+var requiredInvoiceFields = []requiredField{
+	{Key: "invoice_code", Name: "发票代码"},
+	{Key: "invoice_number", Name: "发票号码"},
+	{Key: "invoice_date", Name: "开票日期"},
+	{Key: "total_amount", Name: "金额"},
+}
+
+// PartialRecognitionPolicy 部分识别策略
+type PartialRecognitionPolicy struct {
+	Enabled        bool     `json:"enabled"`         // 是否允许部分识别
+	CriticalFields []string `json:"critical_fields"` // 关键字段，缺失时仍判定为无效
+}
+
+// DefaultCriticalFields 默认关键字段：发票号码和金额
+func DefaultCriticalFields() []string {
+	return []string{"invoice_number", "total_amount"}
+}
+
+// allows 判断缺失字段是否可按部分识别处理
+// 必填字段全部缺失或缺失关键字段时不允许
+func (p PartialRecognitionPolicy) allows(missing []string) bool {
+	if !p.Enabled || len(missing) == 0 || len(missing) >= len(requiredInvoiceFields) {
+		return false
+	}
+
+	critical := p.CriticalFields
+	if critical == nil {
+		critical = DefaultCriticalFields()
+	}
+	for _, key := range missing {
+		for _, criticalKey := range critical {
+			if key == criticalKey {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MissingRequiredFields 返回OCR结果中缺失的必填字段键
+func (i *InvoiceInfo) MissingRequiredFields() []string {
+	var missing []string
+	for _, field := range requiredInvoiceFields {
+		if i.isFieldMissing(field.Key) {
+			missing = append(missing, field.Key)
+		}
+	}
+	return missing
+}
+
+// isFieldMissing 判断必填字段是否缺失
+func (i *InvoiceInfo) isFieldMissing(key string) bool {
+	switch key {
+	case "invoice_code":
+		return strings.TrimSpace(i.InvoiceCode) == "" && i.FormatRule().CodeRequired
+	case "invoice_number":
+		return strings.TrimSpace(i.InvoiceNumber) == ""
+	case "invoice_date":
+		return strings.TrimSpace(i.InvoiceDate) == ""
+	case "total_amount":
+		return i.TotalAmount == 0
+	default:
+		return false
+	}
+}
+
+// ValidatePartial 校验部分识别的发票，仅对已识别的字段进行格式校验
+func (i *InvoiceInfo) ValidatePartial() (bool, string) {
+	if i.TotalAmount < 0 {
+		return false, "金额无效"
+	}
+
+	// 按发票类型对应的规则验证发票代码和号码格式
+	rule := i.FormatRule()
+	if i.InvoiceCode != "" && !rule.ValidCode(i.InvoiceCode) {
+		return false, "发票代码格式不正确"
+	}
+	if i.InvoiceNumber != "" && !rule.ValidNumber(i.InvoiceNumber) {
+		return false, "发票号码格式不正确"
+	}
+
+	// 验证开票日期格式
+	if i.InvoiceDate != "" && !isValidDate(i.InvoiceDate) {
+		return false, "开票日期格式不正确"
+	}
+
+	return true, ""
+}
+
+// RequiredFieldNames 将字段键转换为中文名称
+func RequiredFieldNames(keys []string) []string {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		name := key
+		for _, field := range requiredInvoiceFields {
+			if field.Key == key {
+				name = field.Name
+				break
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// joinMissingFields 将缺失字段键列表拼接为存储格式
+func joinMissingFields(keys []string) string {
+	return strings.Join(keys, missingFieldsSeparator)
+}
+
+// GetMissingFields 获取发票缺失的必填字段键列表
+func (i *Invoice) GetMissingFields() []string {
+	if i.MissingFields == "" {
+		return nil
+	}
+	return strings.Split(i.MissingFields, missingFieldsSeparator)
+}
@@ -0,0 +1,127 @@
+package ocr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartialRecognitionPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PartialRecognitionPolicy
+		missing []string
+		want    bool
+	}{
+		{name: "未启用时不允许", policy: PartialRecognitionPolicy{}, missing: []string{"invoice_date"}, want: false},
+		{name: "无缺失字段时不适用", policy: PartialRecognitionPolicy{Enabled: true}, want: false},
+		{name: "缺失非关键字段允许", policy: PartialRecognitionPolicy{Enabled: true}, missing: []string{"invoice_code", "invoice_date"}, want: true},
+		{name: "缺失默认关键字段不允许", policy: PartialRecognitionPolicy{Enabled: true}, missing: []string{"total_amount"}, want: false},
+		{
+			name:    "必填字段全部缺失不允许",
+			policy:  PartialRecognitionPolicy{Enabled: true, CriticalFields: []string{}},
+			missing: []string{"invoice_code", "invoice_number", "invoice_date", "total_amount"},
+			want:    false,
+		},
+		{
+			name:    "自定义关键字段",
+			policy:  PartialRecognitionPolicy{Enabled: true, CriticalFields: []string{"invoice_date"}},
+			missing: []string{"invoice_date"},
+			want:    false,
+		},
+		{
+			name:    "关键字段配置为空时均可部分识别",
+			policy:  PartialRecognitionPolicy{Enabled: true, CriticalFields: []string{}},
+			missing: []string{"total_amount"},
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.allows(tt.missing); got != tt.want {
+				t.Errorf("allows(%v) = %v, want %v", tt.missing, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingRequiredFields(t *testing.T) {
+	tests := []struct {
+		name string
+		info *InvoiceInfo
+		want []string
+	}{
+		{
+			name: "传统发票字段齐全",
+			info: &InvoiceInfo{InvoiceCode: "1100221130", InvoiceNumber: "12345678", InvoiceDate: "2024-01-01", TotalAmount: 100},
+			want: nil,
+		},
+		{
+			name: "传统发票缺失发票代码和日期",
+			info: &InvoiceInfo{InvoiceNumber: "12345678", TotalAmount: 100},
+			want: []string{"invoice_code", "invoice_date"},
+		},
+		{
+			name: "全电发票无发票代码不计为缺失",
+			info: &InvoiceInfo{InvoiceType: "电子发票（普通发票）", InvoiceNumber: "24112000000012345678", InvoiceDate: "2024-01-01", TotalAmount: 100},
+			want: nil,
+		},
+		{
+			name: "空白视为缺失",
+			info: &InvoiceInfo{InvoiceCode: " ", InvoiceNumber: " ", InvoiceDate: "2024-01-01", TotalAmount: 100},
+			want: []string{"invoice_code", "invoice_number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.MissingRequiredFields(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MissingRequiredFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePartial(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       *InvoiceInfo
+		wantValid  bool
+		wantReason string
+	}{
+		{name: "只校验已识别字段", info: &InvoiceInfo{InvoiceNumber: "12345678"}, wantValid: true},
+		{name: "金额为负", info: &InvoiceInfo{TotalAmount: -1}, wantReason: "金额无效"},
+		{name: "发票代码格式错误", info: &InvoiceInfo{InvoiceCode: "123"}, wantReason: "发票代码格式不正确"},
+		{name: "发票号码格式错误", info: &InvoiceInfo{InvoiceNumber: "12AB"}, wantReason: "发票号码格式不正确"},
+		{name: "开票日期格式错误", info: &InvoiceInfo{InvoiceDate: "2024/13/01"}, wantReason: "开票日期格式不正确"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, reason := tt.info.ValidatePartial()
+			if valid != tt.wantValid || reason != tt.wantReason {
+				t.Errorf("ValidatePartial() = (%v, %q), want (%v, %q)", valid, reason, tt.wantValid, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRequiredFieldNames(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{name: "转换为中文名称", keys: []string{"invoice_code", "total_amount"}, want: []string{"发票代码", "金额"}},
+		{name: "未知字段保留原键", keys: []string{"seller_name"}, want: []string{"seller_name"}},
+		{name: "空列表", keys: nil, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiredFieldNames(tt.keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RequiredFieldNames(%v) = %v, want %v", tt.keys, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+// aliyun.go 阿里云OCR提供商实现
+// 功能点：
+// 1. 调用阿里云读光OCR发票识别接口（RecognizeInvoice）
+// 2. 使用AccessKey按RPC签名方式（HMAC-SHA1）签名请求
+// 3. 支持本地文件与http(s) URL两种图片来源
+// 4. 将阿里云返回字段映射为统一的InvoiceInfo
+// 5. 解析识别字段的位置坐标和商品明细行
+// 6. 图片内容、大小、类型不合法的错误码标记为图片无效，识别失败后不再重试
+// 7. 解析识别字段的置信度
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// 阿里云OCR接口默认配置
+const (
+	aliyunDefaultRegion = "cn-hangzhou"
+	aliyunAPIVersion    = "2021-07-07"
+	aliyunActionInvoice = "RecognizeInvoice"
+)
+
+// AliyunProvider 阿里云OCR提供商
+type AliyunProvider struct {
+	config     ocr.Config
+	logger     logger.Logger
+	httpClient *http.Client
+}
+
+// aliyunFieldKeys 阿里云发票字段名称到InvoiceInfo字段JSON名称的映射
+var aliyunFieldKeys = map[string]string{
+	"invoiceCode":         "invoice_code",
+	"invoiceNumber":       "invoice_number",
+	"title":               "invoice_type",
+	"invoiceDate":         "invoice_date",
+	"invoiceAmountPreTax": "total_amount",
+	"invoiceTax":          "tax_amount",
+	"totalAmount":         "total_with_tax",
+	"purchaserName":       "buyer_name",
+	"purchaserTaxNumber":  "buyer_tax_number",
+	"sellerName":          "seller_name",
+	"sellerTaxNumber":     "seller_tax_number",
+	"checkCode":           "check_code",
+	"passwordArea":        "password_area",
+	"remarks":             "remarks",
+}
+
+// aliyunResponse 阿里云OCR接口响应，Data为识别结果的JSON字符串
+type aliyunResponse struct {
+	RequestID string `json:"RequestId"`
+	Data      string `json:"Data"`
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+}
+
+// aliyunInvoiceResult 发票识别结果
+type aliyunInvoiceResult struct {
+	Data         map[string]interface{} `json:"data"`
+	KeyValueInfo []*aliyunKeyValue      `json:"prism_keyValueInfo"`
+}
+
+// aliyunKeyValue 识别字段及其位置
+type aliyunKeyValue struct {
+	Key       string         `json:"key"`
+	Value     string         `json:"value"`
+	ValuePos  []*aliyunPoint `json:"valuePos"`
+	ValueProb *float64       `json:"valueProb"` // 字段值置信度(0-100)
+}
+
+// aliyunPoint 阿里云返回的坐标点
+type aliyunPoint struct {
+	X int64 `json:"x"`
+	Y int64 `json:"y"`
+}
+
+// NewAliyunProvider 创建阿里云OCR提供商
+func NewAliyunProvider(config ocr.Config, logger logger.Logger) *AliyunProvider {
+	timeout := 30 * time.Second
+	if config.Timeout > 0 {
+		timeout = time.Duration(config.Timeout) * time.Second
+	}
+
+	return &AliyunProvider{
+		config:     config,
+		logger:     logger,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// ParseInvoice 解析发票图片
+func (p *AliyunProvider) ParseInvoice(ctx context.Context, imagePath string) (*ocr.InvoiceInfo, error) {
+	p.logger.WithContext(ctx).Info("开始解析发票图片", logger.NewField("image_path", imagePath))
+
+	// 从环境变量获取凭证，优先使用环境变量
+	accessKeyID := os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_ID")
+	accessKeySecret := os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_SECRET")
+
+	// 如果环境变量不存在，则使用配置中的值
+	if accessKeyID == "" {
+		accessKeyID = p.config.SecretID
+	}
+	if accessKeySecret == "" {
+		accessKeySecret = p.config.SecretKey
+	}
+
+	// 按图片来源设置Url参数或二进制请求体
+	params := map[string]string{}
+	var body []byte
+	imagePath = strings.TrimSpace(imagePath)
+	if isImageURL(imagePath) && p.config.UseImageURL {
+		params["Url"] = imagePath
+	} else {
+		imageData, err := loadImage(ctx, p.httpClient, p.config, imagePath)
+		if err != nil {
+			p.logger.WithContext(ctx).Error("读取图片失败",
+				logger.NewField("error", err.Error()),
+				logger.NewField("image_path", imagePath))
+			return nil, fmt.Errorf("读取图片失败: %w", err)
+		}
+		body = imageData
+	}
+
+	response, err := p.send(ctx, accessKeyID, accessKeySecret, params, body)
+	if err != nil {
+		p.logger.WithContext(ctx).Error("发送OCR请求失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("image_path", imagePath))
+		return nil, fmt.Errorf("发送OCR请求失败: %w", err)
+	}
+
+	// 解析响应
+	invoiceInfo, err := p.parseResponse(response)
+	if err != nil {
+		p.logger.WithContext(ctx).Error("解析OCR响应失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("image_path", imagePath))
+		return nil, fmt.Errorf("解析OCR响应失败: %w", err)
+	}
+
+	p.logger.WithContext(ctx).Info("发票图片解析成功",
+		logger.NewField("image_path", imagePath),
+		logger.NewField("invoice_number", invoiceInfo.InvoiceNumber),
+		logger.NewField("total_amount", invoiceInfo.TotalAmount))
+
+	return invoiceInfo, nil
+}
+
+// endpoint 获取接口地址，未配置时按地域拼接
+func (p *AliyunProvider) endpoint() string {
+	if p.config.Endpoint != "" {
+		return p.config.Endpoint
+	}
+	region := p.config.Region
+	if region == "" || !strings.HasPrefix(region, "cn-") {
+		region = aliyunDefaultRegion
+	}
+	return fmt.Sprintf("ocr-api.%s.aliyuncs.com", region)
+}
+
+// send 签名并发送识别请求
+func (p *AliyunProvider) send(ctx context.Context, accessKeyID, accessKeySecret string, params map[string]string, body []byte) (*aliyunResponse, error) {
+	params["Action"] = aliyunActionInvoice
+	params["Version"] = aliyunAPIVersion
+	params["Format"] = "JSON"
+	params["AccessKeyId"] = accessKeyID
+	params["SignatureMethod"] = "HMAC-SHA1"
+	params["SignatureVersion"] = "1.0"
+	params["SignatureNonce"] = uuid.New().String()
+	params["Timestamp"] = time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	params["Signature"] = signAliyunRPC(http.MethodPost, params, accessKeySecret)
+
+	requestURL := fmt.Sprintf("https://%s/?%s", p.endpoint(), canonicalizeAliyunQuery(params))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var response aliyunResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("解析响应失败: 状态码%d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if isAliyunInvalidImageCode(response.Code) {
+			return nil, fmt.Errorf("%w: 状态码%d, %s: %s", ocr.ErrImageInvalid, resp.StatusCode, response.Code, response.Message)
+		}
+		return nil, fmt.Errorf("请求失败: 状态码%d, %s: %s", resp.StatusCode, response.Code, response.Message)
+	}
+
+	return &response, nil
+}
+
+// parseResponse 解析OCR响应
+func (p *AliyunProvider) parseResponse(response *aliyunResponse) (*ocr.InvoiceInfo, error) {
+	if response == nil || response.Data == "" {
+		return nil, fmt.Errorf("OCR响应内容为空")
+	}
+
+	var result aliyunInvoiceResult
+	if err := json.Unmarshal([]byte(response.Data), &result); err != nil {
+		return nil, fmt.Errorf("解析识别结果失败: %w", err)
+	}
+
+	// 创建发票信息结构体
+	invoiceInfo := &ocr.InvoiceInfo{
+		ParseTime:        time.Now(),
+		IsValid:          true,
+		RawText:          response.Data,
+		FieldBoxes:       make(ocr.FieldBoxes),
+		FieldConfidences: make(ocr.FieldConfidences),
+	}
+
+	data := result.Data
+	invoiceInfo.InvoiceCode = aliyunString(data, "invoiceCode")
+	invoiceInfo.InvoiceNumber = aliyunString(data, "invoiceNumber")
+	invoiceInfo.InvoiceType = aliyunString(data, "title")
+	invoiceInfo.InvoiceDate = aliyunString(data, "invoiceDate")
+	invoiceInfo.TotalAmount = parseAmount(aliyunString(data, "invoiceAmountPreTax"))
+	invoiceInfo.TaxAmount = parseAmount(aliyunString(data, "invoiceTax"))
+	invoiceInfo.TotalWithTax = parseAmount(aliyunString(data, "totalAmount"))
+	invoiceInfo.BuyerName = aliyunString(data, "purchaserName")
+	invoiceInfo.BuyerTaxNumber = aliyunString(data, "purchaserTaxNumber")
+	invoiceInfo.SellerName = aliyunString(data, "sellerName")
+	invoiceInfo.SellerTaxNumber = aliyunString(data, "sellerTaxNumber")
+	invoiceInfo.CheckCode = aliyunString(data, "checkCode")
+	invoiceInfo.PasswordArea = aliyunString(data, "passwordArea")
+	invoiceInfo.Remarks = aliyunString(data, "remarks")
+
+	// 记录字段位置框和置信度
+	for _, kv := range result.KeyValueInfo {
+		if kv == nil {
+			continue
+		}
+		key, ok := aliyunFieldKeys[kv.Key]
+		if !ok {
+			continue
+		}
+		points := make([]ocr.Point, 0, len(kv.ValuePos))
+		for _, pos := range kv.ValuePos {
+			if pos != nil {
+				points = append(points, ocr.Point{X: pos.X, Y: pos.Y})
+			}
+		}
+		if box := ocr.NewFieldBoxFromPolygon(points); box != nil {
+			invoiceInfo.FieldBoxes[key] = box
+		}
+		if kv.ValueProb != nil {
+			invoiceInfo.FieldConfidences[key] = ocr.NormalizeConfidence(*kv.ValueProb)
+		}
+	}
+
+	// 解析商品明细
+	invoiceInfo.Items = p.parseItems(data["invoiceDetails"])
+
+	return invoiceInfo, nil
+}
+
+// parseItems 解析发票商品明细行
+func (p *AliyunProvider) parseItems(value interface{}) []*ocr.InvoiceItemInfo {
+	details, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	infos := make([]*ocr.InvoiceItemInfo, 0, len(details))
+	for i, detail := range details {
+		item, ok := detail.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		infos = append(infos, &ocr.InvoiceItemInfo{
+			LineNo:        i + 1,
+			Name:          aliyunString(item, "itemName"),
+			Specification: aliyunString(item, "specification"),
+			Unit:          aliyunString(item, "unit"),
+			Quantity:      parseAmount(aliyunString(item, "quantity")),
+			Price:         parseAmount(aliyunString(item, "unitPrice")),
+			Amount:        parseAmount(aliyunString(item, "amount")),
+			TaxRate:       ocr.ParseTaxRate(aliyunString(item, "taxRate")),
+			TaxAmount:     parseAmount(aliyunString(item, "tax")),
+		})
+	}
+	return infos
+}
+
+// aliyunString 获取识别结果中的字符串字段，数字类型转换为字符串
+func aliyunString(data map[string]interface{}, key string) string {
+	switch value := data[key].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return fmt.Sprintf("%v", value)
+	default:
+		return ""
+	}
+}
+
+// parseAmount 解析金额、数量等数值文本，去除千分位逗号、空格和货币符号
+func parseAmount(s string) float64 {
+	replacer := strings.NewReplacer(",", "", " ", "", "¥", "", "￥", "")
+	result, err := strconv.ParseFloat(replacer.Replace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return result
+}
+
+// signAliyunRPC 计算阿里云RPC风格请求签名
+func signAliyunRPC(method string, params map[string]string, accessKeySecret string) string {
+	stringToSign := method + "&" + aliyunPercentEncode("/") + "&" + aliyunPercentEncode(canonicalizeAliyunQuery(params))
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalizeAliyunQuery 按参数名排序并编码请求参数
+func canonicalizeAliyunQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, aliyunPercentEncode(key)+"="+aliyunPercentEncode(params[key]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// aliyunPercentEncode 按阿里云签名规范进行URL编码
+func aliyunPercentEncode(s string) string {
+	encoded := url.QueryEscape(s)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	encoded = strings.ReplaceAll(encoded, "%7E", "~")
+	return encoded
+}
+
+// isAliyunInvalidImageCode 判断阿里云错误码是否表示图片本身无效（如illegalImageContent、illegalImageSize、unmatchedImageType）
+func isAliyunInvalidImageCode(code string) bool {
+	return strings.HasPrefix(code, "illegalImage") || code == "unmatchedImageType"
+}
@@ -0,0 +1,85 @@
+// factory.go OCR提供商工厂
+// 功能点：
+// 1. 定义支持的OCR提供商名称
+// 2. 按配置的提供商名称创建对应的发票解析器
+// 3. 支持注册新的提供商实现，切换厂商只需修改配置
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// OCR提供商名称
+const (
+	ProviderTencent = "tencent"
+	ProviderAliyun  = "aliyun"
+	ProviderBaidu   = "baidu" // 百度智能云，暂未实现
+)
+
+// ErrUnsupportedProvider 不支持的OCR提供商
+var ErrUnsupportedProvider = errors.New("不支持的OCR提供商")
+
+// Constructor OCR提供商构造函数
+type Constructor func(config ocr.Config, logger logger.Logger) ocr.InvoiceParser
+
+var (
+	constructorsMu sync.RWMutex
+	constructors   = map[string]Constructor{
+		ProviderTencent: func(config ocr.Config, logger logger.Logger) ocr.InvoiceParser {
+			return NewTencentProvider(config, logger)
+		},
+		ProviderAliyun: func(config ocr.Config, logger logger.Logger) ocr.InvoiceParser {
+			return NewAliyunProvider(config, logger)
+		},
+	}
+)
+
+// Register 注册OCR提供商实现，同名提供商会被覆盖
+func Register(name string, constructor Constructor) {
+	constructorsMu.Lock()
+	defer constructorsMu.Unlock()
+	constructors[normalizeProviderName(name)] = constructor
+}
+
+// NewProvider 根据配置的提供商名称创建发票解析器，未配置时使用腾讯云
+func NewProvider(config ocr.Config, logger logger.Logger) (ocr.InvoiceParser, error) {
+	name := normalizeProviderName(config.Provider)
+	if name == "" {
+		name = ProviderTencent
+	}
+
+	constructorsMu.RLock()
+	constructor, ok := constructors[name]
+	constructorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s（可选: %s）", ErrUnsupportedProvider, name, strings.Join(Providers(), "/"))
+	}
+
+	return constructor(config, logger), nil
+}
+
+// Providers 返回已注册的OCR提供商名称（按名称排序）
+func Providers() []string {
+	constructorsMu.RLock()
+	defer constructorsMu.RUnlock()
+
+	names := make([]string, 0, len(constructors))
+	for name := range constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeProviderName 规范化提供商名称
+func normalizeProviderName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,111 @@
+// image_source.go 发票图片来源处理
+// 功能点：
+// 1. 自动判别图片来源为本地文件路径或http(s) URL
+// 2. 下载URL图片，下载有超时时间限制
+// 3. 读取和下载图片均有大小限制，避免超大图片拖垮服务
+// 4. 图片不存在或超过大小限制时返回ocr.ErrImageInvalid，识别失败后不再重试
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/domain/ocr"
+)
+
+// 图片来源默认配置
+const (
+	DefaultImageDownloadTimeout = 10 * time.Second
+	DefaultMaxImageSize         = 5 * 1024 * 1024 // 腾讯云要求Base64编码后不超过7MB
+)
+
+// isImageURL 判断图片路径是否为http(s) URL
+func isImageURL(imagePath string) bool {
+	lower := strings.ToLower(strings.TrimSpace(imagePath))
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return false
+	}
+	parsed, err := url.Parse(strings.TrimSpace(imagePath))
+	return err == nil && parsed.Host != ""
+}
+
+// loadImage 按图片来源读取图片内容：URL图片下载，本地路径直接读取，大小上限和下载超时取自配置
+func loadImage(ctx context.Context, client *http.Client, config ocr.Config, imagePath string) ([]byte, error) {
+	maxSize := int64(DefaultMaxImageSize)
+	if config.MaxImageSize > 0 {
+		maxSize = int64(config.MaxImageSize) * 1024 * 1024
+	}
+
+	if isImageURL(imagePath) {
+		timeout := DefaultImageDownloadTimeout
+		if config.DownloadTimeout > 0 {
+			timeout = time.Duration(config.DownloadTimeout) * time.Second
+		}
+		return downloadImage(ctx, client, imagePath, timeout, maxSize)
+	}
+	return readLocalImage(imagePath, maxSize)
+}
+
+// readLocalImage 读取本地图片文件，超过maxSize时返回错误
+func readLocalImage(imagePath string, maxSize int64) ([]byte, error) {
+	info, err := os.Stat(imagePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: 图片文件不存在: %s", ocr.ErrImageInvalid, imagePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取图片文件失败: %w", err)
+	}
+	if info.Size() > maxSize {
+		return nil, fmt.Errorf("%w: 图片大小超过限制，最大允许 %d 字节", ocr.ErrImageInvalid, maxSize)
+	}
+
+	imageData, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取图片文件失败: %w", err)
+	}
+	return imageData, nil
+}
+
+// downloadImage 下载URL图片，超过timeout或maxSize时返回错误
+func downloadImage(ctx context.Context, client *http.Client, imageURL string, timeout time.Duration, maxSize int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建图片下载请求失败: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载图片失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: 图片不存在: 状态码%d", ocr.ErrImageInvalid, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载图片失败: 状态码%d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: 图片大小超过限制，最大允许 %d 字节", ocr.ErrImageInvalid, maxSize)
+	}
+
+	// 多读取一个字节，用于判断未声明长度的响应是否超出限制
+	imageData, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("下载图片失败: %w", err)
+	}
+	if int64(len(imageData)) > maxSize {
+		return nil, fmt.Errorf("%w: 图片大小超过限制，最大允许 %d 字节", ocr.ErrImageInvalid, maxSize)
+	}
+	return imageData, nil
+}
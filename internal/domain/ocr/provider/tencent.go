@@ -4,13 +4,20 @@
 // 2. 处理图片Base64编码
 // 3. 使用SDK处理API签名和认证
 // 4. 解析OCR响应结果
+// 5. 解析识别字段的位置坐标
+// 6. 解析商品明细行
+// 7. 支持本地文件与http(s) URL两种图片来源，URL可下载后编码或直接传给腾讯云
+// 8. 图片解码失败、无文字、过大等错误码标记为图片无效，识别失败后不再重试
+// 9. 解析识别字段的置信度
 
 package provider
 
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -20,21 +27,76 @@ import (
 	"reimbursement-audit/internal/pkg/logger"
 
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tcerrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	tccr "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/ocr/v20181119"
 )
 
 // TencentProvider 腾讯云OCR提供商
 type TencentProvider struct {
-	config ocr.Config
-	logger logger.Logger
+	config     ocr.Config
+	logger     logger.Logger
+	httpClient *http.Client
+}
+
+// tencentFieldKeys 腾讯云发票字段名称到InvoiceInfo字段JSON名称的映射
+var tencentFieldKeys = map[string]string{
+	"发票代码":   "invoice_code",
+	"发票号码":   "invoice_number",
+	"发票类型":   "invoice_type",
+	"开票日期":   "invoice_date",
+	"合计金额":   "total_amount",
+	"合计税额":   "tax_amount",
+	"价税合计":   "total_with_tax",
+	"购买方名称":  "buyer_name",
+	"购买方识别号": "buyer_tax_number",
+	"销售方名称":  "seller_name",
+	"销售方识别号": "seller_tax_number",
+	"校验码":    "check_code",
+	"密码区":    "password_area",
+	"备注":     "remarks",
+}
+
+// vatInvoiceOCRResponse 增值税发票识别响应
+// SDK自带的响应结构不包含字段坐标和置信度，这里扩展Polygon和Confidence以获取位置和置信度信息
+type vatInvoiceOCRResponse struct {
+	*tchttp.BaseResponse
+	Response *struct {
+		VatInvoiceInfos []*vatInvoiceField     `json:"VatInvoiceInfos,omitempty"`
+		Items           []*tccr.VatInvoiceItem `json:"Items,omitempty"`
+		RequestId       *string                `json:"RequestId,omitempty"`
+	} `json:"Response"`
+}
+
+// vatInvoiceField 增值税发票识别字段
+type vatInvoiceField struct {
+	Name       *string         `json:"Name,omitempty"`
+	Value      *string         `json:"Value,omitempty"`
+	Polygon    *tencentPolygon `json:"Polygon,omitempty"`
+	Confidence *float64        `json:"Confidence,omitempty"` // 置信度(0-100)
+}
+
+// tencentPolygon 腾讯云返回的字段四边形坐标
+type tencentPolygon struct {
+	LeftTop     *tencentCoord `json:"LeftTop,omitempty"`
+	RightTop    *tencentCoord `json:"RightTop,omitempty"`
+	RightBottom *tencentCoord `json:"RightBottom,omitempty"`
+	LeftBottom  *tencentCoord `json:"LeftBottom,omitempty"`
+}
+
+// tencentCoord 腾讯云返回的坐标点
+type tencentCoord struct {
+	X *int64 `json:"X,omitempty"`
+	Y *int64 `json:"Y,omitempty"`
 }
 
 // NewTencentProvider 创建腾讯云OCR提供商
 func NewTencentProvider(config ocr.Config, logger logger.Logger) *TencentProvider {
 	return &TencentProvider{
-		config: config,
-		logger: logger,
+		config:     config,
+		logger:     logger,
+		httpClient: &http.Client{},
 	}
 }
 
@@ -70,26 +132,22 @@ func (p *TencentProvider) ParseInvoice(ctx context.Context, imagePath string) (*
 		return nil, fmt.Errorf("创建OCR客户端失败: %w", err)
 	}
 
-	// 读取图片文件并转换为Base64
-	imageBase64, err := p.imageToBase64(imagePath)
-	if err != nil {
-		p.logger.WithContext(ctx).Error("读取图片文件失败",
+	// 创建请求，按图片来源设置ImageUrl或ImageBase64
+	request := tccr.NewVatInvoiceOCRRequest()
+	if err := p.setImageSource(ctx, request, imagePath); err != nil {
+		p.logger.WithContext(ctx).Error("读取图片失败",
 			logger.NewField("error", err.Error()),
 			logger.NewField("image_path", imagePath))
-		return nil, fmt.Errorf("读取图片文件失败: %w", err)
+		return nil, fmt.Errorf("读取图片失败: %w", err)
 	}
 
-	// 创建请求
-	request := tccr.NewVatInvoiceOCRRequest()
-	request.ImageBase64 = common.StringPtr(imageBase64)
-
-	// 发送请求
-	response, err := client.VatInvoiceOCR(request)
-	if err != nil {
+	// 发送请求（使用扩展的响应结构以获取字段坐标）
+	response := &vatInvoiceOCRResponse{BaseResponse: &tchttp.BaseResponse{}}
+	if err := client.Send(request, response); err != nil {
 		p.logger.WithContext(ctx).Error("发送OCR请求失败",
 			logger.NewField("error", err.Error()),
 			logger.NewField("image_path", imagePath))
-		return nil, fmt.Errorf("发送OCR请求失败: %w", err)
+		return nil, fmt.Errorf("发送OCR请求失败: %w", classifyTencentError(err))
 	}
 
 	// 解析响应
@@ -109,17 +167,28 @@ func (p *TencentProvider) ParseInvoice(ctx context.Context, imagePath string) (*
 	return invoiceInfo, nil
 }
 
-// imageToBase64 将图片文件转换为Base64编码
-func (p *TencentProvider) imageToBase64(imagePath string) (string, error) {
-	// 检查文件是否存在
-	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("图片文件不存在: %s", imagePath)
+// setImageSource 根据图片来源设置请求图片
+// URL图片在开启UseImageURL时直接传给腾讯云，否则下载后Base64编码；本地文件读取后Base64编码
+func (p *TencentProvider) setImageSource(ctx context.Context, request *tccr.VatInvoiceOCRRequest, imagePath string) error {
+	imagePath = strings.TrimSpace(imagePath)
+	if isImageURL(imagePath) && p.config.UseImageURL {
+		request.ImageUrl = common.StringPtr(imagePath)
+		return nil
+	}
+
+	imageBase64, err := p.imageToBase64(ctx, imagePath)
+	if err != nil {
+		return err
 	}
+	request.ImageBase64 = common.StringPtr(imageBase64)
+	return nil
+}
 
-	// 读取图片文件
-	imageData, err := os.ReadFile(imagePath)
+// imageToBase64 读取本地图片或下载URL图片并转换为Base64编码
+func (p *TencentProvider) imageToBase64(ctx context.Context, imagePath string) (string, error) {
+	imageData, err := loadImage(ctx, p.httpClient, p.config, imagePath)
 	if err != nil {
-		return "", fmt.Errorf("读取图片文件失败: %w", err)
+		return "", err
 	}
 
 	// 转换为Base64编码
@@ -128,12 +197,18 @@ func (p *TencentProvider) imageToBase64(imagePath string) (string, error) {
 }
 
 // parseResponse 解析OCR响应
-func (p *TencentProvider) parseResponse(response *tccr.VatInvoiceOCRResponse) (*ocr.InvoiceInfo, error) {
+func (p *TencentProvider) parseResponse(response *vatInvoiceOCRResponse) (*ocr.InvoiceInfo, error) {
+	if response.Response == nil {
+		return nil, fmt.Errorf("OCR响应内容为空")
+	}
+
 	// 创建发票信息结构体
 	invoiceInfo := &ocr.InvoiceInfo{
-		ParseTime: time.Now(),
-		IsValid:   true,
-		RawText:   p.getRawText(response),
+		ParseTime:        time.Now(),
+		IsValid:          true,
+		RawText:          p.getRawText(response),
+		FieldBoxes:       make(ocr.FieldBoxes),
+		FieldConfidences: make(ocr.FieldConfidences),
 	}
 
 	// 解析发票基本信息
@@ -143,6 +218,16 @@ func (p *TencentProvider) parseResponse(response *tccr.VatInvoiceOCRResponse) (*
 				name := *item.Name
 				value := *item.Value
 
+				// 记录字段位置框
+				if key, ok := tencentFieldKeys[name]; ok {
+					if box := p.parseFieldBox(item.Polygon); box != nil {
+						invoiceInfo.FieldBoxes[key] = box
+					}
+					if item.Confidence != nil {
+						invoiceInfo.FieldConfidences[key] = ocr.NormalizeConfidence(*item.Confidence)
+					}
+				}
+
 				switch name {
 				case "发票代码":
 					invoiceInfo.InvoiceCode = value
@@ -170,16 +255,75 @@ func (p *TencentProvider) parseResponse(response *tccr.VatInvoiceOCRResponse) (*
 					invoiceInfo.CheckCode = value
 				case "密码区":
 					invoiceInfo.PasswordArea = value
+				case "备注":
+					invoiceInfo.Remarks = value
 				}
 			}
 		}
 	}
 
+	// 解析商品明细
+	invoiceInfo.Items = p.parseItems(response.Response.Items)
+
 	return invoiceInfo, nil
 }
 
+// parseItems 解析发票商品明细行
+func (p *TencentProvider) parseItems(items []*tccr.VatInvoiceItem) []*ocr.InvoiceItemInfo {
+	infos := make([]*ocr.InvoiceItemInfo, 0, len(items))
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+
+		lineNo, err := strconv.Atoi(strings.TrimSpace(stringValue(item.LineNo)))
+		if err != nil || lineNo <= 0 {
+			lineNo = i + 1
+		}
+
+		infos = append(infos, &ocr.InvoiceItemInfo{
+			LineNo:        lineNo,
+			Name:          stringValue(item.Name),
+			Specification: stringValue(item.Spec),
+			Unit:          stringValue(item.Unit),
+			Quantity:      p.parseFloat(stringValue(item.Quantity)),
+			Price:         p.parseFloat(stringValue(item.UnitPrice)),
+			Amount:        p.parseFloat(stringValue(item.AmountWithoutTax)),
+			TaxRate:       ocr.ParseTaxRate(stringValue(item.TaxRate)),
+			TaxAmount:     p.parseFloat(stringValue(item.TaxAmount)),
+		})
+	}
+	return infos
+}
+
+// stringValue 获取字符串指针的值，nil返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// parseFieldBox 将腾讯云四边形坐标转换为字段位置框
+func (p *TencentProvider) parseFieldBox(polygon *tencentPolygon) *ocr.FieldBox {
+	if polygon == nil {
+		return nil
+	}
+
+	corners := []*tencentCoord{polygon.LeftTop, polygon.RightTop, polygon.RightBottom, polygon.LeftBottom}
+	points := make([]ocr.Point, 0, len(corners))
+	for _, corner := range corners {
+		if corner == nil || corner.X == nil || corner.Y == nil {
+			continue
+		}
+		points = append(points, ocr.Point{X: *corner.X, Y: *corner.Y})
+	}
+
+	return ocr.NewFieldBoxFromPolygon(points)
+}
+
 // getRawText 获取OCR原始文本
-func (p *TencentProvider) getRawText(response *tccr.VatInvoiceOCRResponse) string {
+func (p *TencentProvider) getRawText(response *vatInvoiceOCRResponse) string {
 	// 将整个响应转换为JSON字符串作为原始文本
 	// 这里简化处理，实际应用中可以根据需要调整
 	rawText := fmt.Sprintf("%+v", response.Response)
@@ -199,3 +343,22 @@ func (p *TencentProvider) parseFloat(s string) float64 {
 	}
 	return result
 }
+
+// tencentInvalidImageCodes 腾讯云表示图片本身无效的错误码，重试不会成功
+var tencentInvalidImageCodes = map[string]bool{
+	"FailedOperation.ImageDecodeFailed":                true, // 图片解码失败
+	"FailedOperation.ImageNoText":                      true, // 图片中未检测到文本
+	"FailedOperation.ImageSizeTooLarge":                true, // 图片尺寸过大
+	"FailedOperation.UnKnowFileTypeError":              true, // 未知的文件类型
+	"InvalidParameterValue.InvalidParameterValueLimit": true, // 图片参数值有误
+	"LimitExceeded.TooLargeFileError":                  true, // 文件内容太大
+}
+
+// classifyTencentError 将图片无效的错误码包装为ocr.ErrImageInvalid，其余错误（网络、限流等）原样返回
+func classifyTencentError(err error) error {
+	var sdkErr *tcerrors.TencentCloudSDKError
+	if errors.As(err, &sdkErr) && tencentInvalidImageCodes[sdkErr.Code] {
+		return fmt.Errorf("%w: %w", ocr.ErrImageInvalid, err)
+	}
+	return err
+}
@@ -2,6 +2,8 @@
 // 功能点：
 // 1. 定义OCR结果存储接口
 // 2. 提供OCR查询方法
+// 3. 按多个报销单批量查询发票和发票数量，避免列表场景逐单查询
+// 4. 按发票类别分组汇总报销单的发票金额
 
 package ocr
 
@@ -18,4 +20,20 @@ type Repository interface {
 	UpdateInvoice(ctx context.Context, invoice *Invoice) error
 	DeleteInvoice(ctx context.Context, id string) error
 	ListInvoicesByReimbursementID(ctx context.Context, reimbursementID string) ([]*Invoice, error)
+	// ListInvoicesByReimbursementIDs 批量查询多个报销单的发票，按报销单ID分组，每组按创建时间升序
+	ListInvoicesByReimbursementIDs(ctx context.Context, reimbursementIDs []string) (map[string][]*Invoice, error)
+	// CountInvoicesByReimbursementIDs 批量统计多个报销单的发票数量，没有发票的报销单不在结果中
+	CountInvoicesByReimbursementIDs(ctx context.Context, reimbursementIDs []string) (map[string]int, error)
+	// SumInvoiceAmountsByCategory 按发票类别分组汇总报销单的发票金额，归类规则与BreakdownCategory一致
+	SumInvoiceAmountsByCategory(ctx context.Context, reimbursementID string) ([]*CategoryAmount, error)
+	// ListInvoicesByCodeAndNumber 按发票代码和号码查询发票，reimbursementStatuses非空时仅返回所属报销单处于这些状态的发票
+	ListInvoicesByCodeAndNumber(ctx context.Context, code, number string, reimbursementStatuses []string) ([]*Invoice, error)
+	// ListInvoicesByStatus 按状态查询发票，按更新时间升序，limit为最大返回条数
+	ListInvoicesByStatus(ctx context.Context, status string, limit int) ([]*Invoice, error)
+
+	// 发票商品明细相关方法
+	// ReplaceInvoiceItems 替换发票的全部商品明细
+	ReplaceInvoiceItems(ctx context.Context, invoiceID string, items []*InvoiceItem) error
+	// ListInvoiceItems 查询发票的商品明细，按行号排序
+	ListInvoiceItems(ctx context.Context, invoiceID string) ([]*InvoiceItem, error)
 }
@@ -3,6 +3,11 @@
 // 1. 定义OCR服务接口
 // 2. 定义OCR解析服务
 // 3. 提供OCR结果验证和转换方法
+// 4. 支持部分识别策略及人工补全缺失字段
+// 5. 保存OCR识别的商品明细及扩展字段
+// 6. 记录识别次数和失败原因，区分可重试的解析失败与不可重试的识别失败
+// 7. 外币发票按开票日期生效的汇率折算为人民币金额
+// 8. 保存各识别字段的OCR置信度，人工补全的字段置信度置为1
 
 package ocr
 
@@ -24,20 +29,29 @@ type InvoiceParser interface {
 
 // ParserService OCR解析领域服务
 type ParserService struct {
-	parser InvoiceParser
-	repo   Repository
-	logger logger.Logger
+	parser        InvoiceParser
+	repo          Repository
+	logger        logger.Logger
+	partialPolicy PartialRecognitionPolicy
+	maxRetries    int
+	rateProvider  ExchangeRateProvider
 }
 
 // NewParserService 创建OCR解析服务
 func NewParserService(parser InvoiceParser, repo Repository, logger logger.Logger) *ParserService {
 	return &ParserService{
-		parser: parser,
-		repo:   repo,
-		logger: logger,
+		parser:     parser,
+		repo:       repo,
+		logger:     logger,
+		maxRetries: DefaultOCRMaxRetries,
 	}
 }
 
+// SetPartialRecognitionPolicy 设置部分识别策略
+func (s *ParserService) SetPartialRecognitionPolicy(policy PartialRecognitionPolicy) {
+	s.partialPolicy = policy
+}
+
 // ParseInvoiceImage 解析发票图片并更新数据库
 func (s *ParserService) ParseInvoiceImage(ctx context.Context, invoiceID string) error {
 	// 从数据库获取发票信息
@@ -54,16 +68,22 @@ func (s *ParserService) ParseInvoiceImage(ctx context.Context, invoiceID string)
 		logger.Field{Key: "image_path", Value: invoice.ImagePath})
 
 	// 调用OCR服务解析发票
+	invoice.OCRAttempts++
 	ocrResult, err := s.parser.ParseInvoice(ctx, invoice.ImagePath)
 	if err != nil {
+		// 可重试的错误置为解析失败等待重试，图片无效或重试次数用尽时置为识别失败
+		invoice.Status = s.failedStatus(invoice, err)
+		invoice.OCRError = truncateOCRError(err)
+		invoice.UpdatedAt = time.Now()
+
 		s.logger.WithContext(ctx).Error("OCR解析失败",
 			logger.Field{Key: "error", Value: err.Error()},
 			logger.Field{Key: "invoice_id", Value: invoiceID},
-			logger.Field{Key: "image_path", Value: invoice.ImagePath})
+			logger.Field{Key: "image_path", Value: invoice.ImagePath},
+			logger.Field{Key: "attempts", Value: invoice.OCRAttempts},
+			logger.Field{Key: "status", Value: invoice.Status})
 
-		// 更新发票状态为解析失败
-		invoice.Status = "解析失败"
-		invoice.UpdatedAt = time.Now()
+		// 更新发票状态
 		if updateErr := s.repo.UpdateInvoice(ctx, invoice); updateErr != nil {
 			s.logger.WithContext(ctx).Error("更新发票状态失败",
 				logger.Field{Key: "error", Value: updateErr.Error()},
@@ -76,6 +96,13 @@ func (s *ParserService) ParseInvoiceImage(ctx context.Context, invoiceID string)
 	// 验证OCR解析结果
 	isValid, errMsg := ocrResult.Validate()
 	if !isValid {
+		// 仅缺失非关键字段时按部分识别处理，等待人工补全
+		if missing := ocrResult.MissingRequiredFields(); s.partialPolicy.allows(missing) {
+			if ok, _ := ocrResult.ValidatePartial(); ok {
+				return s.savePartialInvoice(ctx, invoice, ocrResult, missing)
+			}
+		}
+
 		s.logger.WithContext(ctx).Warn("OCR解析结果验证失败",
 			logger.Field{Key: "error", Value: errMsg},
 			logger.Field{Key: "invoice_id", Value: invoiceID})
@@ -94,7 +121,23 @@ func (s *ParserService) ParseInvoiceImage(ctx context.Context, invoiceID string)
 
 	// 更新发票信息
 	s.updateInvoiceFromOCR(invoice, ocrResult)
+
+	// 外币发票按开票日期的汇率折算，折算失败时按解析失败处理，补充汇率后可重试
+	if err := s.normalizeInvoiceCurrency(ctx, invoice); err != nil {
+		invoice.Status = s.failedStatus(invoice, err)
+		invoice.OCRError = truncateOCRError(err)
+		invoice.UpdatedAt = time.Now()
+		if updateErr := s.repo.UpdateInvoice(ctx, invoice); updateErr != nil {
+			s.logger.WithContext(ctx).Error("更新发票状态失败",
+				logger.Field{Key: "error", Value: updateErr.Error()},
+				logger.Field{Key: "invoice_id", Value: invoiceID})
+		}
+		return err
+	}
+
 	invoice.Status = "已识别"
+	invoice.MissingFields = ""
+	invoice.OCRError = ""
 	invoice.UpdatedAt = time.Now()
 
 	// 保存更新后的发票信息
@@ -105,6 +148,10 @@ func (s *ParserService) ParseInvoiceImage(ctx context.Context, invoiceID string)
 		return fmt.Errorf("更新发票信息失败: %w", err)
 	}
 
+	if err := s.saveInvoiceItems(ctx, invoice, ocrResult); err != nil {
+		return err
+	}
+
 	s.logger.WithContext(ctx).Info("发票解析完成",
 		logger.Field{Key: "invoice_id", Value: invoiceID},
 		logger.Field{Key: "invoice_code", Value: invoice.Code},
@@ -114,6 +161,105 @@ func (s *ParserService) ParseInvoiceImage(ctx context.Context, invoiceID string)
 	return nil
 }
 
+// savePartialInvoice 保存部分识别的发票，记录缺失字段
+func (s *ParserService) savePartialInvoice(ctx context.Context, invoice *Invoice, ocrResult *InvoiceInfo, missing []string) error {
+	s.updateInvoiceFromOCR(invoice, ocrResult)
+	invoice.Status = InvoiceStatusPartial
+	invoice.MissingFields = joinMissingFields(missing)
+	invoice.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateInvoice(ctx, invoice); err != nil {
+		s.logger.WithContext(ctx).Error("更新发票信息失败",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "invoice_id", Value: invoice.ID})
+		return fmt.Errorf("更新发票信息失败: %w", err)
+	}
+
+	if err := s.saveInvoiceItems(ctx, invoice, ocrResult); err != nil {
+		return err
+	}
+
+	s.logger.WithContext(ctx).Warn("发票部分识别，等待人工补全",
+		logger.Field{Key: "invoice_id", Value: invoice.ID},
+		logger.Field{Key: "missing_fields", Value: strings.Join(RequiredFieldNames(missing), "、")})
+
+	return nil
+}
+
+// CompletePartialInvoice 人工补全部分识别发票的缺失字段
+// fields仅需填写缺失字段，补全后按完整发票重新校验，通过则状态更新为已识别
+func (s *ParserService) CompletePartialInvoice(ctx context.Context, invoiceID string, fields *InvoiceInfo) (*Invoice, error) {
+	if fields == nil {
+		return nil, fmt.Errorf("补全字段不能为空")
+	}
+
+	invoice, err := s.repo.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取发票信息失败",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "invoice_id", Value: invoiceID})
+		return nil, fmt.Errorf("获取发票信息失败: %w", err)
+	}
+
+	if invoice.Status != InvoiceStatusPartial {
+		return nil, fmt.Errorf("发票状态为%s，无需补全", invoice.Status)
+	}
+
+	// 以已识别字段为基础，合并人工补全的字段
+	merged := &InvoiceInfo{
+		InvoiceCode:   invoice.Code,
+		InvoiceNumber: invoice.Number,
+		TotalAmount:   recognizedAmount(invoice),
+	}
+	if !invoice.Date.IsZero() {
+		merged.InvoiceDate = invoice.Date.Format("2006-01-02")
+	}
+	missing := invoice.GetMissingFields()
+	for _, key := range missing {
+		switch key {
+		case "invoice_code":
+			merged.InvoiceCode = fields.InvoiceCode
+		case "invoice_number":
+			merged.InvoiceNumber = fields.InvoiceNumber
+		case "invoice_date":
+			merged.InvoiceDate = fields.InvoiceDate
+		case "total_amount":
+			merged.TotalAmount = fields.TotalAmount
+		}
+	}
+
+	if ok, errMsg := merged.Validate(); !ok {
+		return nil, fmt.Errorf("补全后发票校验失败: %s", errMsg)
+	}
+
+	invoice.Code = merged.InvoiceCode
+	invoice.Number = merged.InvoiceNumber
+	setRecognizedAmount(invoice, merged.TotalAmount)
+	if parsedDate, err := s.parseDate(merged.InvoiceDate); err == nil {
+		invoice.Date = parsedDate
+	}
+	if err := s.normalizeInvoiceCurrency(ctx, invoice); err != nil {
+		return nil, err
+	}
+	invoice.FieldConfidences = invoice.FieldConfidences.Confirm(missing...)
+	invoice.Status = "已识别"
+	invoice.MissingFields = ""
+	invoice.OCRError = ""
+	invoice.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateInvoice(ctx, invoice); err != nil {
+		s.logger.WithContext(ctx).Error("更新发票信息失败",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "invoice_id", Value: invoiceID})
+		return nil, fmt.Errorf("更新发票信息失败: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("发票缺失字段补全完成",
+		logger.Field{Key: "invoice_id", Value: invoiceID})
+
+	return invoice, nil
+}
+
 // ParseInvoice 解析发票图片，实现InvoiceParser接口
 func (s *ParserService) ParseInvoice(ctx context.Context, imagePath string) (*InvoiceInfo, error) {
 	return s.parser.ParseInvoice(ctx, imagePath)
@@ -158,12 +304,12 @@ func (s *ParserService) ParseInvoicesByReimbursementID(ctx context.Context, reim
 	return nil
 }
 
-// updateInvoiceFromOCR 使用OCR结果更新发票信息
+// updateInvoiceFromOCR 使用OCR结果更新发票信息，未识别到的字段保留原值
 func (s *ParserService) updateInvoiceFromOCR(invoice *Invoice, ocrResult *InvoiceInfo) {
 	// 更新发票基本信息
-	invoice.Code = ocrResult.InvoiceCode
-	invoice.Number = ocrResult.InvoiceNumber
-	invoice.Type = ocrResult.InvoiceType
+	setIfNotEmpty(&invoice.Code, ocrResult.InvoiceCode)
+	setIfNotEmpty(&invoice.Number, ocrResult.InvoiceNumber)
+	setIfNotEmpty(&invoice.Type, ocrResult.InvoiceType)
 
 	// 解析日期字符串为time.Time
 	if ocrResult.InvoiceDate != "" {
@@ -172,20 +318,67 @@ func (s *ParserService) updateInvoiceFromOCR(invoice *Invoice, ocrResult *Invoic
 		}
 	}
 
-	// 更新金额信息
-	invoice.Amount = ocrResult.TotalAmount
-	invoice.TaxAmount = ocrResult.TaxAmount
+	// 更新金额信息，外币发票的识别金额为原币金额
+	setIfNotEmpty(&invoice.OriginalCurrency, normalizeCurrency(ocrResult.Currency))
+	if ocrResult.TotalAmount > 0 {
+		setRecognizedAmount(invoice, ocrResult.TotalAmount)
+	}
+	if ocrResult.TaxAmount > 0 {
+		invoice.TaxAmount = ocrResult.TaxAmount
+	}
 
 	// 更新购方信息
-	invoice.BuyerName = ocrResult.BuyerName
-	invoice.BuyerTaxNo = ocrResult.BuyerTaxNumber
+	setIfNotEmpty(&invoice.BuyerName, ocrResult.BuyerName)
+	setIfNotEmpty(&invoice.BuyerTaxNo, ocrResult.BuyerTaxNumber)
 
 	// 更新销方信息
-	invoice.SellerName = ocrResult.SellerName
-	invoice.SellerTaxNo = ocrResult.SellerTaxNumber
+	setIfNotEmpty(&invoice.SellerName, ocrResult.SellerName)
+	setIfNotEmpty(&invoice.SellerTaxNo, ocrResult.SellerTaxNumber)
+
+	// 更新商品明细汇总字段
+	applyInvoiceItems(invoice, ocrResult.Items)
+
+	// 更新发票属性
+	if strings.Contains(ocrResult.InvoiceType, "增值税") {
+		invoice.IsVAT = true
+	}
+	if strings.Contains(ocrResult.InvoiceType, "电子") {
+		invoice.IsElectronic = true
+	}
+	setIfNotEmpty(&invoice.Remarks, ocrResult.Remarks)
 
 	// 更新OCR识别结果
-	invoice.OCRResult = ocrResult.RawText
+	setIfNotEmpty(&invoice.OCRResult, ocrResult.RawText)
+	if len(ocrResult.FieldBoxes) > 0 {
+		invoice.FieldBoxes = ocrResult.FieldBoxes
+	}
+	if len(ocrResult.FieldConfidences) > 0 {
+		invoice.FieldConfidences = ocrResult.FieldConfidences
+	}
+}
+
+// saveInvoiceItems 保存OCR识别的商品明细，未识别到明细时保留已有明细
+func (s *ParserService) saveInvoiceItems(ctx context.Context, invoice *Invoice, ocrResult *InvoiceInfo) error {
+	items := NewInvoiceItems(invoice.ID, ocrResult.Items)
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := s.repo.ReplaceInvoiceItems(ctx, invoice.ID, items); err != nil {
+		s.logger.WithContext(ctx).Error("保存发票商品明细失败",
+			logger.Field{Key: "error", Value: err.Error()},
+			logger.Field{Key: "invoice_id", Value: invoice.ID})
+		return fmt.Errorf("保存发票商品明细失败: %w", err)
+	}
+	invoice.Items = items
+	return nil
+}
+
+// setIfNotEmpty 值非空时赋值，避免未识别的字段覆盖已有值
+func setIfNotEmpty(target *string, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		*target = value
+	}
 }
 
 // parseDate 解析日期字符串为time.Time
@@ -0,0 +1,265 @@
+// task_queue.go OCR识别任务队列
+// 功能点：
+// 1. 上传发票后将OCR识别任务入队，接口无需等待识别完成
+// 2. 固定数量的worker并发执行识别任务，防止打爆OCR服务配额
+// 3. 任务使用独立的上下文执行，不受HTTP请求结束影响，保留traceId便于追踪
+// 4. 记录任务状态（排队中/识别中/已完成/失败），支持按发票ID查询进度
+// 5. 支持重新识别失败的发票
+
+package ocr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// 任务队列默认配置
+const (
+	DefaultTaskWorkers     = 4
+	DefaultTaskQueueSize   = 1000
+	DefaultTaskTimeout     = 2 * time.Minute
+	DefaultMaxTrackedTasks = 10000
+)
+
+// TaskStatus OCR识别任务状态
+type TaskStatus string
+
+const (
+	TaskStatusQueued     TaskStatus = "queued"     // 排队中
+	TaskStatusProcessing TaskStatus = "processing" // 识别中
+	TaskStatusCompleted  TaskStatus = "completed"  // 已完成
+	TaskStatusFailed     TaskStatus = "failed"     // 失败
+)
+
+// 任务队列错误
+var (
+	ErrTaskQueueFull  = errors.New("OCR任务队列已满，请稍后重试")
+	ErrTaskInProgress = errors.New("发票正在排队或识别中")
+)
+
+// Task OCR识别任务状态
+type Task struct {
+	InvoiceID  string     `json:"invoice_id"`
+	Status     TaskStatus `json:"status"`
+	Attempts   int        `json:"attempts"`              // 已执行次数
+	Error      string     `json:"error,omitempty"`       // 最近一次失败原因
+	EnqueuedAt time.Time  `json:"enqueued_at"`           // 最近一次入队时间
+	StartedAt  *time.Time `json:"started_at,omitempty"`  // 最近一次开始识别时间
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // 最近一次识别结束时间
+}
+
+// TaskProcessor OCR识别任务处理函数
+type TaskProcessor func(ctx context.Context, invoiceID string) error
+
+// TaskQueueConfig 任务队列配置
+type TaskQueueConfig struct {
+	Workers   int           `json:"workers"`    // 并发worker数量
+	QueueSize int           `json:"queue_size"` // 队列容量，队列满时拒绝入队
+	Timeout   time.Duration `json:"timeout"`    // 单个任务执行超时时间
+}
+
+// normalize 规范化任务队列配置，非法值回退为默认值
+func (c TaskQueueConfig) normalize() TaskQueueConfig {
+	if c.Workers <= 0 {
+		c.Workers = DefaultTaskWorkers
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultTaskQueueSize
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTaskTimeout
+	}
+	return c
+}
+
+// queuedTask 队列中的任务
+type queuedTask struct {
+	ctx       context.Context
+	invoiceID string
+}
+
+// TaskQueue OCR识别任务队列
+type TaskQueue struct {
+	processor TaskProcessor
+	config    TaskQueueConfig
+	logger    logger.Logger
+	queue     chan *queuedTask
+	now       func() time.Time
+
+	mu     sync.Mutex
+	tasks  map[string]*Task
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTaskQueue 创建OCR识别任务队列
+func NewTaskQueue(processor TaskProcessor, config TaskQueueConfig, logger logger.Logger) *TaskQueue {
+	config = config.normalize()
+	return &TaskQueue{
+		processor: processor,
+		config:    config,
+		logger:    logger,
+		queue:     make(chan *queuedTask, config.QueueSize),
+		now:       time.Now,
+		tasks:     make(map[string]*Task),
+	}
+}
+
+// Start 启动worker，已启动时不做任何操作
+func (q *TaskQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.cancel != nil {
+		return
+	}
+
+	ctx, q.cancel = context.WithCancel(ctx)
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+
+	q.logger.WithContext(ctx).Info("OCR任务队列已启动",
+		logger.NewField("workers", q.config.Workers),
+		logger.NewField("queue_size", q.config.QueueSize))
+}
+
+// Stop 停止worker并等待正在执行的任务结束，未执行的任务保持排队状态
+func (q *TaskQueue) Stop() {
+	q.mu.Lock()
+	cancel := q.cancel
+	q.cancel = nil
+	q.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	q.wg.Wait()
+}
+
+// Enqueue 将发票识别任务入队，发票已在排队或识别中时返回ErrTaskInProgress
+// 任务上下文与ctx的取消解耦，仅保留其中的值（如traceId）
+func (q *TaskQueue) Enqueue(ctx context.Context, invoiceID string) (*Task, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	task, exists := q.tasks[invoiceID]
+	if exists && (task.Status == TaskStatusQueued || task.Status == TaskStatusProcessing) {
+		return copyTask(task), ErrTaskInProgress
+	}
+
+	select {
+	case q.queue <- &queuedTask{ctx: context.WithoutCancel(ctx), invoiceID: invoiceID}:
+	default:
+		return nil, ErrTaskQueueFull
+	}
+
+	if !exists {
+		q.evictFinished()
+		task = &Task{InvoiceID: invoiceID}
+		q.tasks[invoiceID] = task
+	}
+	task.Status = TaskStatusQueued
+	task.EnqueuedAt = q.now()
+	task.StartedAt = nil
+	task.FinishedAt = nil
+
+	return copyTask(task), nil
+}
+
+// Status 查询发票识别任务状态，未经过队列的发票返回false
+func (q *TaskQueue) Status(invoiceID string) (*Task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	task, ok := q.tasks[invoiceID]
+	if !ok {
+		return nil, false
+	}
+	return copyTask(task), true
+}
+
+// work 从队列中取出任务执行
+func (q *TaskQueue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case queued := <-q.queue:
+			q.process(ctx, queued)
+		}
+	}
+}
+
+// process 执行单个识别任务，队列停止时取消正在执行的任务
+func (q *TaskQueue) process(ctx context.Context, queued *queuedTask) {
+	taskCtx, cancel := context.WithTimeout(queued.ctx, q.config.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	q.update(queued.invoiceID, func(task *Task) {
+		startedAt := q.now()
+		task.Status = TaskStatusProcessing
+		task.Attempts++
+		task.StartedAt = &startedAt
+	})
+
+	err := q.processor(taskCtx, queued.invoiceID)
+
+	q.update(queued.invoiceID, func(task *Task) {
+		finishedAt := q.now()
+		task.FinishedAt = &finishedAt
+		if err != nil {
+			task.Status = TaskStatusFailed
+			task.Error = err.Error()
+			return
+		}
+		task.Status = TaskStatusCompleted
+		task.Error = ""
+	})
+
+	if err != nil {
+		q.logger.WithContext(taskCtx).Error("OCR识别任务失败",
+			logger.NewField("invoice_id", queued.invoiceID),
+			logger.NewField("error", err.Error()))
+	}
+}
+
+// update 更新任务状态
+func (q *TaskQueue) update(invoiceID string, fn func(task *Task)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	task, ok := q.tasks[invoiceID]
+	if !ok {
+		task = &Task{InvoiceID: invoiceID, EnqueuedAt: q.now()}
+		q.tasks[invoiceID] = task
+	}
+	fn(task)
+}
+
+// evictFinished 记录的任务数达到上限时清理已结束的任务
+func (q *TaskQueue) evictFinished() {
+	if len(q.tasks) < DefaultMaxTrackedTasks {
+		return
+	}
+	for invoiceID, task := range q.tasks {
+		if task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed {
+			delete(q.tasks, invoiceID)
+		}
+	}
+}
+
+// copyTask 复制任务状态，避免调用方修改内部状态
+func copyTask(task *Task) *Task {
+	copied := *task
+	return &copied
+}
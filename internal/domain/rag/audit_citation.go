@@ -0,0 +1,46 @@
+// audit_citation.go 审核结论引用出处
+// 功能点：
+// 1. 审核提示词中的制度文档片段按检索结果顺序编号，要求大模型用[编号]标注结论依据
+// 2. 审核结果保留每个被检索分片的文档ID、分片ID、原文片段和相似度分数
+// 3. 解析审核结论中的引用编号，标记结论实际引用的制度片段
+
+package rag
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// citationMarkerPattern 回答中的引用编号标注，如[1]、[1,3]、[2、4]
+var citationMarkerPattern = regexp.MustCompile(`\[(\d+(?:\s*[,，、]\s*\d+)*)\]`)
+
+// citationNumberPattern 引用编号标注中的单个编号
+var citationNumberPattern = regexp.MustCompile(`\d+`)
+
+// parseCitedIndexes 解析回答中标注的引用编号
+func parseCitedIndexes(content string) map[int]bool {
+	indexes := make(map[int]bool)
+	for _, match := range citationMarkerPattern.FindAllStringSubmatch(content, -1) {
+		for _, number := range citationNumberPattern.FindAllString(match[1], -1) {
+			if index, err := strconv.Atoi(number); err == nil {
+				indexes[index] = true
+			}
+		}
+	}
+	return indexes
+}
+
+// markCitedReferences 按回答中标注的引用编号标记被引用的制度片段，超出范围的编号忽略
+func markCitedReferences(content string, citations []*Citation) {
+	indexes := parseCitedIndexes(content)
+	for _, citation := range citations {
+		citation.Cited = indexes[citation.Index]
+	}
+}
+
+// buildAuditCitations 构建审核结论的引用列表，并标记结论中标注了编号的制度片段
+func buildAuditCitations(content string, references []*VectorSearchResult) []*Citation {
+	citations := buildCitations(references)
+	markCitedReferences(content, citations)
+	return citations
+}
@@ -0,0 +1,78 @@
+// canary.go RAG启动自检
+// 功能点：
+// 1. 导入一份内容已知的金丝雀文档
+// 2. 以文档内容检索并确认能召回该文档
+// 3. 校验向量生成与向量库读写链路是否正常
+// 4. 自检结束后清理金丝雀文档
+
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// canaryTopK 金丝雀检索数量
+const canaryTopK = 5
+
+// ErrCanaryNotRetrieved 金丝雀文档未被检索召回
+var ErrCanaryNotRetrieved = errors.New("金丝雀文档未被检索召回")
+
+// RunCanary 执行金丝雀自检：导入已知文档→检索→确认召回→清理
+func (rs *RAGService) RunCanary(ctx context.Context) error {
+	marker := "canary-" + uuid.New().String()
+	content := fmt.Sprintf("报销系统启动自检文档（%s）：本文档用于校验向量生成与向量检索链路，自检完成后自动删除。", marker)
+
+	file, err := os.CreateTemp("", "rag-canary-*.txt")
+	if err != nil {
+		return fmt.Errorf("创建金丝雀文档失败: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("写入金丝雀文档失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入金丝雀文档失败: %w", err)
+	}
+
+	document, err := rs.IngestDocument(ctx, file.Name())
+	if err != nil {
+		return fmt.Errorf("导入金丝雀文档失败: %w", err)
+	}
+
+	// 无论检索是否成功都清理金丝雀文档，避免污染知识库
+	defer func() {
+		if err := rs.DeleteDocument(context.WithoutCancel(ctx), document.ID); err != nil {
+			rs.logger.Warn("清理金丝雀文档失败",
+				logger.NewField("document_id", document.ID),
+				logger.NewField("error", err))
+		}
+	}()
+
+	results, err := rs.SearchDocuments(ctx, content, canaryTopK)
+	if err != nil {
+		return fmt.Errorf("检索金丝雀文档失败: %w", err)
+	}
+
+	for _, result := range results {
+		if result != nil && result.DocumentID == document.ID {
+			rs.logger.Info("RAG金丝雀自检通过",
+				logger.NewField("document_id", document.ID),
+				logger.NewField("score", result.Score))
+			return nil
+		}
+	}
+
+	rs.logger.Error("RAG金丝雀自检失败",
+		logger.NewField("document_id", document.ID),
+		logger.NewField("result_count", len(results)))
+	return ErrCanaryNotRetrieved
+}
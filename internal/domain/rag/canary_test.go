@@ -0,0 +1,78 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+)
+
+func TestRunCanary(t *testing.T) {
+	tests := []struct {
+		name      string
+		retrieved bool // 检索结果是否包含金丝雀文档
+		wantErr   error
+	}{
+		{name: "召回金丝雀文档时自检通过", retrieved: true},
+		{name: "未召回金丝雀文档时自检失败", retrieved: false, wantErr: ErrCanaryNotRetrieved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			t.Setenv("TMPDIR", tempDir)
+			server := newFakeEmbeddingServer(t)
+			service, mock, stored := newIngestTestService(t, server.URL, 1, 100)
+
+			// 检索结果在金丝雀文档写入后按实际文档ID生成
+			searchRows := sqlmock.NewRows([]string{"id", "file_name", "file_type", "category", "language", "chunk_id", "chunk_index", "chunk_content", "distance"})
+			err := service.vectorStore.db.Callback().Create().After("gorm:create").Register("test:canary_rows", func(db *gorm.DB) {
+				for _, doc := range db.Statement.Dest.([]*DocumentModel) {
+					fileName := "其他制度文档"
+					if tt.retrieved {
+						fileName = doc.FileName
+					}
+					searchRows.AddRow("v1", fileName, "text", "", "zh", doc.ChunkID, 0, doc.ChunkContent, 0.1)
+				}
+			})
+			if err != nil {
+				t.Fatalf("注册GORM回调失败: %v", err)
+			}
+			mock.ExpectQuery(`SELECT id, file_name, .* FROM reimbursement_documents`).WillReturnRows(searchRows)
+			// 无论自检是否通过都清理金丝雀文档
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM "reimbursement_documents"`).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err = service.RunCanary(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RunCanary() error = %v, want %v", err, tt.wantErr)
+			}
+			if stored.inserted != 1 {
+				t.Errorf("写入金丝雀分片数 = %d, want 1", stored.inserted)
+			}
+			if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+				t.Errorf("自检结束后临时目录残留%d个文件", len(entries))
+			}
+		})
+	}
+}
+
+func TestRunCanaryEmbeddingUnavailable(t *testing.T) {
+	server := httptest.NewServer(nil)
+	server.Close()
+	service, _, stored := newIngestTestService(t, server.URL, 0, 100)
+
+	err := service.RunCanary(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "导入金丝雀文档失败") {
+		t.Fatalf("RunCanary() error = %v, want 导入金丝雀文档失败", err)
+	}
+	if stored.inserted != 0 {
+		t.Errorf("向量生成失败时不应写入分片, 写入%d个", stored.inserted)
+	}
+}
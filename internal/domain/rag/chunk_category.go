@@ -0,0 +1,156 @@
+// chunk_category.go 导入文档时推断分片类别
+// 功能点：
+// 1. 定义分片类别分类器接口，默认提供关键词分类器（可配置类别关键词）
+// 2. 文档元数据指定了具体类别时全部分片使用该类别
+// 3. 否则按分片内容分类，无法判断时使用整篇文档的分类结果，仍无法判断时使用通用类别
+// 4. 分片类别写入向量，使按类别检索对导入的制度文档生效
+
+package rag
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// DefaultChunkCategory 无法判断类别时使用的通用类别
+const DefaultChunkCategory = "通用"
+
+// genericDocumentCategory 文档处理器写入的默认元数据类别，不代表具体报销类别
+const genericDocumentCategory = "reimbursement"
+
+// DefaultCategoryKeywords 默认的类别关键词，类别名称与报销单类别一致
+func DefaultCategoryKeywords() map[string][]string {
+	return map[string][]string{
+		"差旅费": {"差旅", "出差", "住宿费", "伙食补助", "机票", "火车票", "高铁"},
+		"招待费": {"招待", "宴请", "客户", "礼品", "娱乐"},
+		"办公费": {"办公用品", "办公设备", "办公费", "快递费", "打印机"},
+		"通讯费": {"通讯费", "话费", "网络费", "手机"},
+		"交通费": {"交通费", "打车", "出租车", "地铁", "公交", "市内交通"},
+		"培训费": {"培训", "讲师", "课程"},
+		"会议费": {"会议", "场地费", "会务"},
+	}
+}
+
+// CategoryClassifier 分片类别分类器
+type CategoryClassifier interface {
+	// Classify 返回文本所属类别，无法判断时返回空
+	Classify(ctx context.Context, text string) string
+}
+
+// categoryKeywords 类别及其关键词
+type categoryKeywords struct {
+	category string
+	keywords []string
+}
+
+// KeywordCategoryClassifier 关键词分类器，按关键词出现次数选择类别
+type KeywordCategoryClassifier struct {
+	categories []categoryKeywords
+}
+
+// NewKeywordCategoryClassifier 创建关键词分类器，keywords为空时使用默认类别关键词
+func NewKeywordCategoryClassifier(keywords map[string][]string) *KeywordCategoryClassifier {
+	if len(keywords) == 0 {
+		keywords = DefaultCategoryKeywords()
+	}
+
+	categories := make([]categoryKeywords, 0, len(keywords))
+	for category, words := range keywords {
+		category = strings.TrimSpace(category)
+		if category == "" {
+			continue
+		}
+		filtered := make([]string, 0, len(words))
+		for _, word := range words {
+			if word = strings.TrimSpace(word); word != "" {
+				filtered = append(filtered, word)
+			}
+		}
+		if len(filtered) > 0 {
+			categories = append(categories, categoryKeywords{category: category, keywords: filtered})
+		}
+	}
+	// 按类别名称排序，出现次数相同时结果稳定
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].category < categories[j].category
+	})
+
+	return &KeywordCategoryClassifier{categories: categories}
+}
+
+// Classify 返回关键词出现次数最多的类别，没有命中任何关键词时返回空
+func (c *KeywordCategoryClassifier) Classify(ctx context.Context, text string) string {
+	bestCategory := ""
+	bestCount := 0
+	for _, item := range c.categories {
+		count := 0
+		for _, keyword := range item.keywords {
+			count += strings.Count(text, keyword)
+		}
+		if count > bestCount {
+			bestCategory = item.category
+			bestCount = count
+		}
+	}
+	return bestCategory
+}
+
+// SetCategoryClassifier 设置分片类别分类器，为nil时不推断类别，只使用文档元数据类别
+func (rs *RAGService) SetCategoryClassifier(classifier CategoryClassifier) {
+	rs.chunkClassifier = classifier
+}
+
+// assignChunkCategories 为文档的全部分片确定类别
+func (rs *RAGService) assignChunkCategories(ctx context.Context, document *Document) {
+	if category := specificDocumentCategory(document); category != "" {
+		for _, chunk := range document.Chunks {
+			chunk.Category = category
+		}
+		return
+	}
+
+	// 整篇文档的分类结果，作为无法判断类别的分片的默认值
+	fallback := ""
+	if rs.chunkClassifier != nil {
+		fallback = rs.chunkClassifier.Classify(ctx, document.Title+"\n"+document.Content)
+	}
+	for _, chunk := range document.Chunks {
+		category := ""
+		if rs.chunkClassifier != nil {
+			category = rs.chunkClassifier.Classify(ctx, chunk.Content)
+		}
+		if category == "" {
+			category = fallback
+		}
+		if category == "" {
+			category = DefaultChunkCategory
+		}
+		chunk.Category = category
+	}
+}
+
+// specificDocumentCategory 获取文档元数据中指定的具体类别，未指定或为默认类别时返回空
+func specificDocumentCategory(document *Document) string {
+	category := strings.TrimSpace(documentCategory(document))
+	if category == genericDocumentCategory {
+		return ""
+	}
+	return category
+}
+
+// chunkCategories 获取文档分片涉及的类别（去重），用于使查询缓存失效
+func chunkCategories(document *Document) []string {
+	seen := make(map[string]bool)
+	categories := make([]string, 0, 1)
+	for _, chunk := range document.Chunks {
+		if !seen[chunk.Category] {
+			seen[chunk.Category] = true
+			categories = append(categories, chunk.Category)
+		}
+	}
+	if len(categories) == 0 {
+		categories = append(categories, documentCategory(document))
+	}
+	return categories
+}
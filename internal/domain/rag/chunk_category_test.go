@@ -0,0 +1,128 @@
+package rag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestKeywordCategoryClassifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		keywords map[string][]string
+		text     string
+		want     string
+	}{
+		{name: "默认关键词识别差旅", text: "员工出差期间的住宿费标准", want: "差旅费"},
+		{name: "按关键词出现次数选择", text: "宴请客户时可在出差地招待客户", want: "招待费"},
+		{name: "未命中关键词返回空", text: "本制度自发布之日起施行", want: ""},
+		{
+			name:     "次数相同时按类别名称排序取第一个",
+			keywords: map[string][]string{"乙类": {"报销"}, "甲类": {"报销"}},
+			text:     "报销",
+			want:     "乙类",
+		},
+		{
+			name:     "忽略空类别和空关键词",
+			keywords: map[string][]string{" ": {"报销"}, "空关键词": {" "}, "软件费": {" 订阅 "}},
+			text:     "软件订阅报销",
+			want:     "软件费",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier := NewKeywordCategoryClassifier(tt.keywords)
+			if got := classifier.Classify(context.Background(), tt.text); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignChunkCategories(t *testing.T) {
+	newDocument := func(category string, contents ...string) *Document {
+		document := &Document{Title: "费用报销制度", Metadata: &DocumentMetadata{Category: category}}
+		for _, content := range contents {
+			document.Chunks = append(document.Chunks, &DocumentChunk{Content: content})
+			document.Content += content + "\n"
+		}
+		return document
+	}
+
+	tests := []struct {
+		name       string
+		classifier CategoryClassifier
+		document   *Document
+		want       []string
+	}{
+		{
+			name:       "元数据指定具体类别时全部分片使用该类别",
+			classifier: NewKeywordCategoryClassifier(nil),
+			document:   newDocument("招待费", "出差住宿标准", "会议安排"),
+			want:       []string{"招待费", "招待费"},
+		},
+		{
+			name:       "按分片内容分类，无法判断时使用整篇文档的分类",
+			classifier: NewKeywordCategoryClassifier(nil),
+			document:   newDocument(genericDocumentCategory, "出差住宿标准", "机票和火车票", "报销时限"),
+			want:       []string{"差旅费", "差旅费", "差旅费"},
+		},
+		{
+			name:       "整篇文档也无法判断时使用通用类别",
+			classifier: NewKeywordCategoryClassifier(nil),
+			document:   newDocument("", "报销时限", "审批流程"),
+			want:       []string{DefaultChunkCategory, DefaultChunkCategory},
+		},
+		{
+			name:     "未设置分类器时使用通用类别",
+			document: newDocument(genericDocumentCategory, "出差住宿标准"),
+			want:     []string{DefaultChunkCategory},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &RAGService{}
+			rs.SetCategoryClassifier(tt.classifier)
+			rs.assignChunkCategories(context.Background(), tt.document)
+
+			got := make([]string, 0, len(tt.document.Chunks))
+			for _, chunk := range tt.document.Chunks {
+				got = append(got, chunk.Category)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("分片类别 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkCategories(t *testing.T) {
+	tests := []struct {
+		name     string
+		document *Document
+		want     []string
+	}{
+		{
+			name: "分片类别去重并保持顺序",
+			document: &Document{Chunks: []*DocumentChunk{
+				{Category: "差旅费"}, {Category: "通用"}, {Category: "差旅费"},
+			}},
+			want: []string{"差旅费", "通用"},
+		},
+		{
+			name:     "没有分片时使用文档类别",
+			document: &Document{Metadata: &DocumentMetadata{Category: "招待费"}},
+			want:     []string{"招待费"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chunkCategories(tt.document); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkCategories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
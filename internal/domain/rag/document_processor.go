@@ -6,6 +6,7 @@
 // 4. 文档元数据提取
 // 5. 文档版本管理
 // 6. 文档索引构建
+// 7. 按文档路径和分片序号生成确定性的文档ID和分片ID，重复导入同一文档时ID不变
 
 package rag
 
@@ -13,6 +14,7 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -24,6 +26,22 @@ import (
 	"github.com/google/uuid"
 )
 
+// documentIDNamespace 生成文档ID和分片ID的UUID命名空间
+var documentIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("reimbursement-audit/rag/document"))
+
+// documentIDFromPath 按文档绝对路径生成确定性的文档ID，同一文档重复导入时ID不变
+func documentIDFromPath(documentPath string) string {
+	if absPath, err := filepath.Abs(documentPath); err == nil {
+		documentPath = absPath
+	}
+	return uuid.NewSHA1(documentIDNamespace, []byte(filepath.Clean(documentPath))).String()
+}
+
+// chunkIDFromIndex 按文档ID和分片序号生成确定性的分片ID
+func chunkIDFromIndex(documentID string, index int) string {
+	return uuid.NewSHA1(documentIDNamespace, []byte(fmt.Sprintf("%s#%d", documentID, index))).String()
+}
+
 // DocumentProcessor 文档处理器结构体
 type DocumentProcessor struct {
 	chunkSize    int
@@ -61,9 +79,12 @@ func (dp *DocumentProcessor) ProcessDocument(ctx context.Context, documentPath s
 		dp.logger.Error("提取元数据失败", logger.NewField("document_path", documentPath), logger.NewField("error", err))
 		return nil, err
 	}
+	if language := DetectLanguage(cleanedContent); language != "" {
+		metadata.Language = language
+	}
 
 	document := &Document{
-		ID:        uuid.New().String(),
+		ID:        documentIDFromPath(documentPath),
 		Title:     filepath.Base(documentPath),
 		Content:   cleanedContent,
 		Type:      dp.GetDocumentType(documentPath),
@@ -192,10 +213,11 @@ func (dp *DocumentProcessor) CreateDocumentChunks(ctx context.Context, document
 	documentChunks := make([]*DocumentChunk, 0, len(chunks))
 	position := 0
 
-	for _, chunkContent := range chunks {
+	for i, chunkContent := range chunks {
 		chunk := &DocumentChunk{
-			ID:         uuid.New().String(),
+			ID:         chunkIDFromIndex(document.ID, i),
 			DocumentID: document.ID,
+			Index:      i,
 			Content:    chunkContent,
 			StartPos:   position,
 			EndPos:     position + len(chunkContent),
@@ -0,0 +1,174 @@
+// embedding_cache.go 向量嵌入结果缓存
+// 功能点：
+// 1. 按嵌入模型名和文本内容哈希缓存向量，相同文本（重复导入的制度段落、反复出现的查询）不再调用向量生成接口
+// 2. 缓存键包含嵌入模型名，更换模型后旧向量自然失效
+// 3. 提供容量上限的内存LRU缓存实现，超出容量时淘汰最久未访问的向量
+// 4. 同一批次中重复的文本只生成一次向量
+// 5. 统计缓存命中和未命中次数，计算命中率
+
+package rag
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DefaultEmbeddingCacheSize 内存向量缓存默认最大条数
+const DefaultEmbeddingCacheSize = 10000
+
+// EmbeddingCache 向量嵌入缓存接口
+type EmbeddingCache interface {
+	// Get 获取缓存的向量
+	Get(key string) ([]float64, bool)
+
+	// Set 缓存向量
+	Set(key string, embedding []float64)
+}
+
+// EmbeddingCacheStats 向量缓存命中统计
+type EmbeddingCacheStats struct {
+	Enabled     bool      `json:"enabled"`      // 是否启用向量缓存
+	Hits        int64     `json:"hits"`         // 命中次数
+	Misses      int64     `json:"misses"`       // 未命中次数(调用接口生成的文本数)
+	HitRate     float64   `json:"hit_rate"`     // 命中率(0-1)，没有请求时为0
+	GeneratedAt time.Time `json:"generated_at"` // 统计时间
+}
+
+// embeddingCacheEntry 向量缓存项
+type embeddingCacheEntry struct {
+	key       string
+	embedding []float64
+}
+
+// MemoryEmbeddingCache 内存LRU向量缓存
+type MemoryEmbeddingCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // 按访问时间排序，表头为最近访问
+	maxSize int
+}
+
+// NewMemoryEmbeddingCache 创建内存向量缓存，maxSize非正数时使用默认值
+func NewMemoryEmbeddingCache(maxSize int) *MemoryEmbeddingCache {
+	if maxSize <= 0 {
+		maxSize = DefaultEmbeddingCacheSize
+	}
+	return &MemoryEmbeddingCache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		maxSize: maxSize,
+	}
+}
+
+// Get 获取缓存的向量，命中时标记为最近访问
+func (c *MemoryEmbeddingCache) Get(key string) ([]float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*embeddingCacheEntry).embedding, true
+}
+
+// Set 缓存向量，超出容量时淘汰最久未访问的向量
+func (c *MemoryEmbeddingCache) Set(key string, embedding []float64) {
+	if len(embedding) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &embeddingCacheEntry{key: key, embedding: embedding}
+	if element, exists := c.entries[key]; exists {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	for c.lru.Len() >= c.maxSize {
+		back := c.lru.Back()
+		c.lru.Remove(back)
+		delete(c.entries, back.Value.(*embeddingCacheEntry).key)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+}
+
+// embeddingCacheKey 生成向量缓存键：嵌入模型名和文本内容的哈希，文本取哈希避免长段落占用过多内存
+func embeddingCacheKey(model, text string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + text))
+	return model + ":" + hex.EncodeToString(sum[:])
+}
+
+// SetEmbeddingCache 设置向量缓存，为nil时不缓存
+func (c *LLMClient) SetEmbeddingCache(cache EmbeddingCache) {
+	c.embeddingCache = cache
+}
+
+// EmbeddingCacheStats 获取向量缓存命中统计
+func (c *LLMClient) EmbeddingCacheStats() *EmbeddingCacheStats {
+	stats := &EmbeddingCacheStats{
+		Enabled:     c.embeddingCache != nil,
+		Hits:        c.cacheHits.Load(),
+		Misses:      c.cacheMisses.Load(),
+		GeneratedAt: time.Now(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// cachedEmbeddings 先从缓存获取向量，只为未命中的文本调用generate生成并写入缓存，结果顺序与输入一致
+// 同一批次中重复的文本只生成一次，未设置缓存时直接调用generate
+func (c *LLMClient) cachedEmbeddings(texts []string, generate func(texts []string) ([][]float64, error)) ([][]float64, error) {
+	cache := c.embeddingCache
+	if cache == nil {
+		return generate(texts)
+	}
+
+	embeddings := make([][]float64, len(texts))
+	pending := make([]string, 0, len(texts))
+	positions := make(map[string][]int, len(texts))
+	for i, text := range texts {
+		if indexes, ok := positions[text]; ok {
+			positions[text] = append(indexes, i)
+			c.cacheHits.Add(1)
+			continue
+		}
+		if embedding, ok := cache.Get(embeddingCacheKey(c.embeddingModel, text)); ok {
+			embeddings[i] = embedding
+			c.cacheHits.Add(1)
+			continue
+		}
+		positions[text] = []int{i}
+		pending = append(pending, text)
+	}
+	if len(pending) == 0 {
+		return embeddings, nil
+	}
+
+	c.cacheMisses.Add(int64(len(pending)))
+	generated, err := generate(pending)
+	if err != nil {
+		return nil, err
+	}
+	for i, text := range pending {
+		for _, index := range positions[text] {
+			embeddings[index] = generated[i]
+		}
+		cache.Set(embeddingCacheKey(c.embeddingModel, text), generated[i])
+	}
+	return embeddings, nil
+}
+
+// EmbeddingCacheStats 获取向量缓存命中统计
+func (rs *RAGService) EmbeddingCacheStats() *EmbeddingCacheStats {
+	return rs.llmClient.EmbeddingCacheStats()
+}
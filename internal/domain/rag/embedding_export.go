@@ -0,0 +1,64 @@
+// embedding_export.go 分片向量导出
+// 功能点：
+// 1. 按分片类别(知识库集合)导出分片ID及其向量，供数据分析人员做聚类等离线分析
+// 2. 按分片记录ID游标分页，逐条回调输出，不在内存中保留整页或全部向量
+// 3. 仅允许数据分析和审核管理员角色导出，角色由API层路由校验
+
+package rag
+
+import (
+	"context"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// 分片向量导出分页大小
+const (
+	DefaultEmbeddingPageSize = 100  // 默认每页条数
+	MaxEmbeddingPageSize     = 1000 // 每页最大条数
+)
+
+// EmbeddingFilter 分片向量导出条件
+type EmbeddingFilter struct {
+	Collection string `json:"collection"` // 分片类别(知识库集合)，为空时导出全部分片
+	After      string `json:"after"`      // 游标：上一页最后一条分片记录ID，为空时从头开始
+	Size       int    `json:"size"`       // 每页条数
+}
+
+// normalize 校正每页条数，非正数时使用默认值，超过上限时取上限
+func (f *EmbeddingFilter) normalize() {
+	if f.Size <= 0 {
+		f.Size = DefaultEmbeddingPageSize
+	}
+	if f.Size > MaxEmbeddingPageSize {
+		f.Size = MaxEmbeddingPageSize
+	}
+}
+
+// EmbeddingRecord 分片向量记录
+type EmbeddingRecord struct {
+	ID         string    `json:"id"`          // 分片记录ID，作为分页游标
+	ChunkID    string    `json:"chunk_id"`    // 分片ID
+	DocumentID string    `json:"document_id"` // 文档ID
+	ChunkIndex int       `json:"chunk_index"` // 分片序号
+	Category   string    `json:"category"`    // 分片类别
+	Embedding  []float64 `json:"embedding"`   // 分片向量
+}
+
+// StreamEmbeddings 导出一页分片向量，逐条回调fn；返回下一页游标，没有更多数据时返回空字符串
+func (rs *RAGService) StreamEmbeddings(ctx context.Context, filter *EmbeddingFilter, fn func(*EmbeddingRecord) error) (string, error) {
+	if filter == nil {
+		filter = &EmbeddingFilter{}
+	}
+	filter.normalize()
+
+	next, err := rs.vectorStore.StreamEmbeddings(ctx, filter, fn)
+	if err != nil {
+		rs.logger.Error("导出分片向量失败",
+			logger.NewField("collection", filter.Collection),
+			logger.NewField("after", filter.After),
+			logger.NewField("error", err))
+		return "", err
+	}
+	return next, nil
+}
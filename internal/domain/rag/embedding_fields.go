@@ -0,0 +1,111 @@
+// embedding_fields.go 向量查询字段脱敏
+// 功能点：
+// 1. 配置允许写入向量查询文本的报销字段（白名单），未列入的字段不会发送给外部向量服务
+// 2. 配置始终剔除的个人信息字段（如申请人姓名、工号），优先于白名单
+// 3. 对写入查询的文本字段脱敏手机号、身份证号、银行卡号等敏感信息
+
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEmbeddingFields 默认允许写入向量查询的报销字段
+func DefaultEmbeddingFields() []string {
+	return []string{"type", "amount", "category"}
+}
+
+// DefaultRedactedFields 默认始终剔除的个人信息字段
+func DefaultRedactedFields() []string {
+	return []string{"user_id", "user_name"}
+}
+
+// amountFields 金额字段，写入查询时带"金额"前缀
+var amountFields = map[string]bool{
+	"amount":       true,
+	"total_amount": true,
+}
+
+// sensitivePatterns 文本字段中需要脱敏的敏感信息
+var sensitivePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{17}[Xx]`),                 // 末位为X的身份证号
+	regexp.MustCompile(`\d{16,19}`),                  // 银行卡号、纯数字身份证号
+	regexp.MustCompile(`1[3-9]\d{9}`),                // 手机号
+	regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`), // 邮箱
+}
+
+// redactedPlaceholder 脱敏后的占位文本
+const redactedPlaceholder = "***"
+
+// EmbeddingFieldPolicy 向量查询字段策略
+type EmbeddingFieldPolicy struct {
+	AllowedFields  []string `json:"allowed_fields"`  // 允许写入查询的字段（按顺序拼接），为nil时使用默认字段
+	RedactedFields []string `json:"redacted_fields"` // 始终剔除的字段，优先于AllowedFields，为nil时使用默认字段
+}
+
+// SetEmbeddingFieldPolicy 设置向量查询字段策略
+func (rs *RAGService) SetEmbeddingFieldPolicy(policy EmbeddingFieldPolicy) {
+	rs.embeddingFields = policy
+}
+
+// fields 获取实际写入查询的字段
+func (p EmbeddingFieldPolicy) fields() []string {
+	allowed := p.AllowedFields
+	if allowed == nil {
+		allowed = DefaultEmbeddingFields()
+	}
+	redacted := p.RedactedFields
+	if redacted == nil {
+		redacted = DefaultRedactedFields()
+	}
+
+	blocked := make(map[string]bool, len(redacted))
+	for _, field := range redacted {
+		blocked[strings.TrimSpace(field)] = true
+	}
+
+	fields := make([]string, 0, len(allowed))
+	for _, field := range allowed {
+		field = strings.TrimSpace(field)
+		if field != "" && !blocked[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// formatEmbeddingField 格式化写入查询的字段值，不支持的类型或空值返回空
+func formatEmbeddingField(field string, value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return redactSensitiveText(strings.TrimSpace(v))
+	case float64:
+		if amountFields[field] {
+			return "金额" + strconv.FormatFloat(v, 'f', 2, 64)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02")
+	case fmt.Stringer:
+		return redactSensitiveText(strings.TrimSpace(v.String()))
+	default:
+		return ""
+	}
+}
+
+// redactSensitiveText 脱敏文本中的身份证号、银行卡号、手机号和邮箱
+func redactSensitiveText(text string) string {
+	for _, pattern := range sensitivePatterns {
+		text = pattern.ReplaceAllString(text, redactedPlaceholder)
+	}
+	return text
+}
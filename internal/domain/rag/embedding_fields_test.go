@@ -0,0 +1,84 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEmbeddingFieldPolicyFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy EmbeddingFieldPolicy
+		want   []string
+	}{
+		{name: "未配置时使用默认字段", want: []string{"type", "amount", "category"}},
+		{
+			name:   "剔除字段优先于白名单",
+			policy: EmbeddingFieldPolicy{AllowedFields: []string{"type", " user_name ", "description"}},
+			want:   []string{"type", "description"},
+		},
+		{
+			name:   "自定义剔除字段",
+			policy: EmbeddingFieldPolicy{AllowedFields: []string{"type", "description", ""}, RedactedFields: []string{" description"}},
+			want:   []string{"type"},
+		},
+		{name: "空白名单不写入任何字段", policy: EmbeddingFieldPolicy{AllowedFields: []string{}}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.fields(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatEmbeddingField(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+		want  string
+	}{
+		{name: "金额字段带前缀", field: "amount", value: 1200.5, want: "金额1200.50"},
+		{name: "普通数值", field: "count", value: 1.5, want: "1.5"},
+		{name: "整数", field: "count", value: 3, want: "3"},
+		{name: "日期", field: "date", value: time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local), want: "2024-07-01"},
+		{name: "零值日期为空", field: "date", value: time.Time{}, want: ""},
+		{name: "文本脱敏手机号", field: "description", value: " 联系13812345678 ", want: "联系***"},
+		{name: "不支持的类型为空", field: "tags", value: []string{"差旅"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatEmbeddingField(tt.field, tt.value); got != tt.want {
+				t.Errorf("formatEmbeddingField() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactSensitiveText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "身份证号", text: "身份证11010519491231002X", want: "身份证***"},
+		{name: "纯数字身份证号", text: "身份证110105194912310021", want: "身份证***"},
+		{name: "银行卡号", text: "卡号6222021234567890123", want: "卡号***"},
+		{name: "手机号", text: "电话13912345678", want: "电话***"},
+		{name: "邮箱", text: "邮箱zhang.san@example.com", want: "邮箱***"},
+		{name: "无敏感信息保持不变", text: "北京出差住宿", want: "北京出差住宿"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactSensitiveText(tt.text); got != tt.want {
+				t.Errorf("redactSensitiveText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
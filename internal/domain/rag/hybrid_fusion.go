@@ -0,0 +1,96 @@
+// hybrid_fusion.go 混合检索结果加权融合
+// 功能点：
+// 1. 按关键词权重融合向量检索和关键词检索结果：融合分数 = (1-权重)×向量相似度 + 权重×关键词相关度
+// 2. 关键词相关度按关键词密度归一化到0-1，与向量相似度处于同一量纲
+// 3. 融合前后的分量记录在结果元数据中，便于排查排序原因
+
+package rag
+
+import (
+	"sort"
+
+	"reimbursement-audit/internal/pkg/utils"
+)
+
+// DefaultKeywordWeight 混合检索默认的关键词权重，向量和关键词各占一半
+const DefaultKeywordWeight = 0.5
+
+// normalizeKeywordWeight 校验关键词权重，超出0-1范围时使用默认权重
+func normalizeKeywordWeight(keywordWeight float64) float64 {
+	if keywordWeight < 0 || keywordWeight > 1 {
+		return DefaultKeywordWeight
+	}
+	return keywordWeight
+}
+
+// fusedResult 融合中的检索结果及其分量
+type fusedResult struct {
+	result       *VectorSearchResult
+	vectorScore  float64
+	keywordScore float64
+}
+
+// fuseResults 按关键词权重融合向量检索和关键词检索结果，按融合分数降序返回最多topK条
+// 同一分片同时被两路命中时两个分量都计入，只被一路命中时另一分量为0
+func fuseResults(vectorResults, keywordResults []*VectorSearchResult, keywordWeight float64, topK int) []*VectorSearchResult {
+	keywordWeight = normalizeKeywordWeight(keywordWeight)
+
+	fused := make(map[string]*fusedResult, len(vectorResults)+len(keywordResults))
+	order := make([]string, 0, len(vectorResults)+len(keywordResults))
+	entry := func(result *VectorSearchResult) *fusedResult {
+		item, ok := fused[result.ID]
+		if !ok {
+			item = &fusedResult{result: result}
+			fused[result.ID] = item
+			order = append(order, result.ID)
+		}
+		return item
+	}
+
+	for _, result := range vectorResults {
+		if item := entry(result); result.Score > item.vectorScore {
+			item.vectorScore = result.Score
+		}
+	}
+
+	maxDensity := 0.0
+	for _, result := range keywordResults {
+		if density := resultKeywordDensity(result); density > maxDensity {
+			maxDensity = density
+		}
+	}
+	for _, result := range keywordResults {
+		// 未计算密度时(如直接传入的结果)使用原分数作为关键词相关度
+		score := result.Score
+		if maxDensity > 0 {
+			score = resultKeywordDensity(result) / maxDensity
+		}
+		if item := entry(result); score > item.keywordScore {
+			item.keywordScore = score
+		}
+	}
+
+	combined := make([]*VectorSearchResult, 0, len(order))
+	for _, id := range order {
+		item := fused[id]
+		result := item.result
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		result.Metadata["vector_score"] = item.vectorScore
+		result.Metadata["keyword_score"] = item.keywordScore
+		result.Score = (1-keywordWeight)*item.vectorScore + keywordWeight*item.keywordScore
+		combined = append(combined, result)
+	}
+
+	sort.SliceStable(combined, func(i, j int) bool {
+		return combined[i].Score > combined[j].Score
+	})
+	return utils.SafeTruncate(combined, topK)
+}
+
+// resultKeywordDensity 获取关键词检索结果的关键词密度
+func resultKeywordDensity(result *VectorSearchResult) float64 {
+	density, _ := result.Metadata["keyword_density"].(float64)
+	return density
+}
@@ -0,0 +1,106 @@
+package rag
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFuseResults(t *testing.T) {
+	// 每个用例使用新的结果，fuseResults会改写分数和元数据
+	vectorResults := func() []*VectorSearchResult {
+		return []*VectorSearchResult{{ID: "a", Score: 0.8}, {ID: "b", Score: 0.6}}
+	}
+	keywordResults := func() []*VectorSearchResult {
+		return []*VectorSearchResult{
+			{ID: "b", Score: 3, Metadata: map[string]interface{}{"keyword_density": 2.0}},
+			{ID: "c", Score: 1, Metadata: map[string]interface{}{"keyword_density": 1.0}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		vector        []*VectorSearchResult
+		keyword       []*VectorSearchResult
+		keywordWeight float64
+		topK          int
+		wantIDs       []string
+		wantScores    []float64
+	}{
+		{
+			name:          "向量和关键词各占一半",
+			vector:        vectorResults(),
+			keyword:       keywordResults(),
+			keywordWeight: 0.5,
+			topK:          10,
+			wantIDs:       []string{"b", "a", "c"},
+			wantScores:    []float64{0.8, 0.4, 0.25},
+		},
+		{
+			name:          "权重为0时只按向量相似度排序",
+			vector:        vectorResults(),
+			keyword:       keywordResults(),
+			keywordWeight: 0,
+			topK:          10,
+			wantIDs:       []string{"a", "b", "c"},
+			wantScores:    []float64{0.8, 0.6, 0},
+		},
+		{
+			name:          "权重为1时只按关键词相关度排序",
+			vector:        vectorResults(),
+			keyword:       keywordResults(),
+			keywordWeight: 1,
+			topK:          10,
+			wantIDs:       []string{"b", "c", "a"},
+			wantScores:    []float64{1, 0.5, 0},
+		},
+		{
+			name:          "权重超出范围时使用默认权重",
+			vector:        vectorResults(),
+			keyword:       keywordResults(),
+			keywordWeight: 1.5,
+			topK:          10,
+			wantIDs:       []string{"b", "a", "c"},
+			wantScores:    []float64{0.8, 0.4, 0.25},
+		},
+		{
+			name:          "按topK截断",
+			vector:        vectorResults(),
+			keyword:       keywordResults(),
+			keywordWeight: 0.5,
+			topK:          2,
+			wantIDs:       []string{"b", "a"},
+			wantScores:    []float64{0.8, 0.4},
+		},
+		{
+			name: "未计算关键词密度时使用原分数",
+			vector: []*VectorSearchResult{{ID: "a",
+				Score: 0.8}},
+			keyword: []*VectorSearchResult{{ID: "c",
+				Score: 0.9}},
+			keywordWeight: 0.5,
+			topK:          10,
+			wantIDs:       []string{"c", "a"},
+			wantScores:    []float64{0.45, 0.4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fuseResults(tt.vector, tt.keyword, tt.keywordWeight, tt.topK)
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("fuseResults() = %d条, want %d条", len(got), len(tt.wantIDs))
+			}
+			for i, result := range got {
+				if result.ID != tt.wantIDs[i] || math.Abs(result.Score-tt.wantScores[i]) > 1e-9 {
+					t.Errorf("fuseResults()[%d] = (%s, %v), want (%s, %v)", i, result.ID, result.Score, tt.wantIDs[i], tt.wantScores[i])
+				}
+				if _, ok := result.Metadata["vector_score"]; !ok {
+					t.Errorf("fuseResults()[%d] 缺少vector_score元数据", i)
+				}
+				if _, ok := result.Metadata["keyword_score"]; !ok {
+					t.Errorf("fuseResults()[%d] 缺少keyword_score元数据", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+)
+
+// fakeEmbedding 模拟向量：首维为文本的校验和，用于确认向量回填到了对应分片
+func fakeEmbedding(text string) []float64 {
+	embedding := make([]float64, VectorDimension)
+	for i := range embedding {
+		embedding[i] = 0.01
+	}
+	embedding[0] = float64(crc32.ChecksumIEEE([]byte(text)))
+	return embedding
+}
+
+// fakeEmbeddingServer 模拟向量生成接口，按输入文本返回768维向量并统计请求次数
+type fakeEmbeddingServer struct {
+	*httptest.Server
+	requests atomic.Int64 // 向量生成请求次数
+	texts    atomic.Int64 // 已生成向量的文本数
+}
+
+// newFakeEmbeddingServer 启动模拟向量生成接口，测试结束时关闭
+func newFakeEmbeddingServer(t *testing.T) *fakeEmbeddingServer {
+	t.Helper()
+	server := &fakeEmbeddingServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Input json.RawMessage `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var inputs []string
+		if err := json.Unmarshal(request.Input, &inputs); err != nil {
+			var input string
+			if err := json.Unmarshal(request.Input, &input); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			inputs = []string{input}
+		}
+		server.requests.Add(1)
+		server.texts.Add(int64(len(inputs)))
+
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		}
+		data := make([]item, len(inputs))
+		for i, input := range inputs {
+			data[i] = item{Index: i, Embedding: fakeEmbedding(input)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeTestDocuments 在临时目录生成count个内容各不相同的文本文档，每个文档words个词
+func writeTestDocuments(t *testing.T, count, words int) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, count)
+	for i := range paths {
+		content := make([]string, words)
+		for j := range content {
+			content[j] = fmt.Sprintf("制度%d差旅费第%d条", i, j)
+		}
+		paths[i] = filepath.Join(dir, fmt.Sprintf("制度%03d.txt", i))
+		if err := os.WriteFile(paths[i], []byte(strings.Join(content, " ")), 0o644); err != nil {
+			t.Fatalf("写入测试文档失败: %v", err)
+		}
+	}
+	return paths
+}
+
+// storedChunks 统计写入文档表的分片，按文档ID分组
+type storedChunks struct {
+	mu       sync.Mutex
+	byDoc    map[string]map[string]bool
+	inserted int
+}
+
+// newIngestTestService 创建使用模拟向量接口和sqlmock存储的RAG服务，每个分片chunkSize个词
+// 预期documents个文档的替换事务，SQL按任意顺序匹配，写入的分片通过GORM回调统计
+func newIngestTestService(t *testing.T, embeddingURL string, documents, chunkSize int) (*RAGService, sqlmock.Sqlmock, *storedChunks) {
+	t.Helper()
+	log := loggertest.New(t)
+	store, mock := newMockVectorStore(t)
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < documents; i++ {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT DISTINCT "category" FROM "reimbursement_documents"`).
+			WillReturnRows(sqlmock.NewRows([]string{"category"}))
+		mock.ExpectExec(`DELETE FROM "reimbursement_documents"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "reimbursement_documents"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("SQL不符合预期: %v", err)
+		}
+	})
+
+	stored := &storedChunks{byDoc: make(map[string]map[string]bool)}
+	err := store.db.Callback().Create().After("gorm:create").Register("test:stored_chunks", func(db *gorm.DB) {
+		docs, ok := db.Statement.Dest.([]*DocumentModel)
+		if !ok {
+			return
+		}
+		stored.mu.Lock()
+		defer stored.mu.Unlock()
+		for _, doc := range docs {
+			if stored.byDoc[doc.FileName] == nil {
+				stored.byDoc[doc.FileName] = make(map[string]bool)
+			}
+			stored.byDoc[doc.FileName][doc.ID] = true
+			stored.inserted++
+		}
+	})
+	if err != nil {
+		t.Fatalf("注册GORM回调失败: %v", err)
+	}
+
+	llmClient := NewLLMClient("test-key", embeddingURL, "test-model", 5, log)
+	service := NewRAGService(log, llmClient, NewDocumentProcessor(chunkSize, 0, log), store, nil)
+	return service, mock, stored
+}
+
+func TestBatchIngestDocumentsConcurrently(t *testing.T) {
+	const (
+		documents = 12
+		words     = 10
+		chunkSize = 4 // 每个文档3个分片
+	)
+	server := newFakeEmbeddingServer(t)
+	service, _, stored := newIngestTestService(t, server.URL, documents, chunkSize)
+	service.SetIngestConcurrency(4)
+
+	paths := writeTestDocuments(t, documents, words)
+	results, err := service.BatchIngestDocumentsWithResults(context.Background(), paths)
+	if err != nil {
+		t.Fatalf("BatchIngestDocumentsWithResults() error = %v", err)
+	}
+
+	if len(results) != documents {
+		t.Fatalf("len(results) = %d, want %d", len(results), documents)
+	}
+	for i, result := range results {
+		// 结果顺序与输入路径一致
+		if result.Path != paths[i] {
+			t.Errorf("results[%d].Path = %s, want %s", i, result.Path, paths[i])
+		}
+		if result.Error != nil || result.Document == nil {
+			t.Errorf("results[%d] error = %v", i, result.Error)
+			continue
+		}
+		if got := len(stored.byDoc[result.Document.ID]); got != 3 {
+			t.Errorf("文档%s写入分片数 = %d, want 3", result.Path, got)
+		}
+	}
+	if stored.inserted != documents*3 || len(stored.byDoc) != documents {
+		t.Errorf("写入分片/文档数 = %d/%d, want %d/%d", stored.inserted, len(stored.byDoc), documents*3, documents)
+	}
+	if got := server.texts.Load(); got != documents*3 {
+		t.Errorf("生成向量的分片数 = %d, want %d", got, documents*3)
+	}
+}
+
+func TestBatchIngestDocumentsEmbeddingBatches(t *testing.T) {
+	const (
+		documents = 50
+		batchSize = 16
+	)
+	server := newFakeEmbeddingServer(t)
+	service, _, stored := newIngestTestService(t, server.URL, documents, 100)
+	service.llmClient.SetEmbeddingBatchSize(batchSize)
+
+	results, err := service.BatchIngestDocumentsWithResults(context.Background(), writeTestDocuments(t, documents, 3))
+	if err != nil {
+		t.Fatalf("BatchIngestDocumentsWithResults() error = %v", err)
+	}
+
+	// 每个文档只有一个分片，跨文档凑满批次后只需ceil(50/16)=4次请求
+	if got := server.requests.Load(); got != 4 {
+		t.Errorf("向量生成请求次数 = %d, want 4", got)
+	}
+	for i, result := range results {
+		if result.Error != nil {
+			t.Fatalf("results[%d] error = %v", i, result.Error)
+		}
+		for _, chunk := range result.Document.Chunks {
+			if chunk.Vector[0] != fakeEmbedding(chunk.Content)[0] {
+				t.Errorf("文档%d分片%d的向量未回填到对应分片", i, chunk.Index)
+			}
+		}
+	}
+	if stored.inserted != documents {
+		t.Errorf("写入分片数 = %d, want %d", stored.inserted, documents)
+	}
+}
@@ -0,0 +1,196 @@
+// invoice_details.go 审核提示词中的发票明细
+// 功能点：
+// 1. 报销信息中的发票数组按[发票N]编号逐张列出开票日期、金额、销售方、商品和类别，便于大模型针对具体发票给出意见
+// 2. 发票超过上限时只列出金额最高的发票（保留原编号），其余发票汇总张数和金额，控制提示词Token数
+// 3. 单个字段超长时截断，销售方、商品等OCR识别文本按提示词注入防护规则清洗
+
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 审核提示词发票明细默认配置
+const (
+	DefaultMaxPromptInvoices = 20 // 默认最多逐张列出的发票数
+	maxInvoiceFieldRunes     = 50 // 单个发票字段最多保留的字符数
+)
+
+// ReimbursementInvoicesKey 报销信息中发票明细数组的键
+const ReimbursementInvoicesKey = "invoices"
+
+// invoiceDetailFields 发票明细按顺序输出的字段及标签
+var invoiceDetailFields = []struct {
+	key   string
+	label string
+}{
+	{"date", "开票日期"},
+	{"amount", "金额"},
+	{"seller", "销售方"},
+	{"commodity", "商品"},
+	{"category", "类别"},
+	{"city", "消费城市"},
+	{"number", "发票号码"},
+}
+
+// promptInvoice 待输出的发票明细，index为发票在报销单中的编号(从1开始)
+type promptInvoice struct {
+	index  int
+	amount float64
+	fields map[string]interface{}
+}
+
+// SetMaxPromptInvoices 设置审核提示词中最多逐张列出的发票数，非正数时使用默认值
+func (pb *PromptBuilder) SetMaxPromptInvoices(maxInvoices int) {
+	if maxInvoices <= 0 {
+		maxInvoices = DefaultMaxPromptInvoices
+	}
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.maxPromptInvoices = maxInvoices
+}
+
+// maxInvoices 获取最多逐张列出的发票数
+func (pb *PromptBuilder) maxInvoices() int {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+	if pb.maxPromptInvoices <= 0 {
+		return DefaultMaxPromptInvoices
+	}
+	return pb.maxPromptInvoices
+}
+
+// formatInvoiceDetails 格式化发票明细，invoices为[]map[string]interface{}或JSON解码得到的[]interface{}
+// 发票超过上限时只列出金额最高的发票，按原编号顺序输出，并汇总未列出的发票
+func (pb *PromptBuilder) formatInvoiceDetails(invoices interface{}) string {
+	items := toPromptInvoices(invoices)
+	if len(items) == 0 {
+		return ""
+	}
+
+	listed := items
+	maxInvoices := pb.maxInvoices()
+	if len(items) > maxInvoices {
+		listed = make([]*promptInvoice, len(items))
+		copy(listed, items)
+		sort.SliceStable(listed, func(i, j int) bool {
+			return listed[i].amount > listed[j].amount
+		})
+		listed = listed[:maxInvoices]
+		sort.Slice(listed, func(i, j int) bool {
+			return listed[i].index < listed[j].index
+		})
+	}
+
+	guard := pb.promptGuard()
+	replaced := 0
+	var builder strings.Builder
+	builder.WriteString("【发票明细】共")
+	builder.WriteString(strconv.Itoa(len(items)))
+	builder.WriteString("张")
+	for _, item := range listed {
+		parts := make([]string, 0, len(invoiceDetailFields))
+		for _, field := range invoiceDetailFields {
+			value := formatInvoiceField(field.key, item.fields[field.key])
+			if value == "" {
+				continue
+			}
+			value, count := guard.Sanitize(value)
+			replaced += count
+			parts = append(parts, field.label+"："+value)
+		}
+		builder.WriteString("\n[发票")
+		builder.WriteString(strconv.Itoa(item.index))
+		builder.WriteString("] ")
+		builder.WriteString(strings.Join(parts, "；"))
+	}
+	pb.logInjection(replaced)
+
+	if omitted := len(items) - len(listed); omitted > 0 {
+		listedIndex := make(map[int]bool, len(listed))
+		for _, item := range listed {
+			listedIndex[item.index] = true
+		}
+		omittedAmount := 0.0
+		for _, item := range items {
+			if !listedIndex[item.index] {
+				omittedAmount += item.amount
+			}
+		}
+		builder.WriteString(fmt.Sprintf("\n（发票较多，仅列出金额最高的%d张，其余%d张合计金额%.2f元）", len(listed), omitted, omittedAmount))
+	}
+	return builder.String()
+}
+
+// toPromptInvoices 将发票数组转换为待输出的发票明细，无法识别的元素跳过但保留编号
+func toPromptInvoices(invoices interface{}) []*promptInvoice {
+	var raw []map[string]interface{}
+	switch value := invoices.(type) {
+	case []map[string]interface{}:
+		raw = value
+	case []interface{}:
+		raw = make([]map[string]interface{}, len(value))
+		for i, element := range value {
+			raw[i], _ = element.(map[string]interface{})
+		}
+	default:
+		return nil
+	}
+
+	items := make([]*promptInvoice, 0, len(raw))
+	for i, fields := range raw {
+		if fields == nil {
+			continue
+		}
+		amount, _ := toFloat(fields["amount"])
+		items = append(items, &promptInvoice{index: i + 1, amount: amount, fields: fields})
+	}
+	return items
+}
+
+// formatInvoiceField 格式化发票字段，金额保留两位小数，日期只保留年月日，文本超长时截断
+func formatInvoiceField(key string, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02")
+	}
+	if key == "amount" {
+		if amount, ok := toFloat(value); ok {
+			return fmt.Sprintf("%.2f元", amount)
+		}
+	}
+
+	text := strings.TrimSpace(fmt.Sprint(value))
+	if runes := []rune(text); len(runes) > maxInvoiceFieldRunes {
+		text = string(runes[:maxInvoiceFieldRunes]) + "…"
+	}
+	return text
+}
+
+// toFloat 将数值类型(含JSON数字)转换为float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
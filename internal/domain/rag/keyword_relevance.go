@@ -0,0 +1,81 @@
+// keyword_relevance.go 关键词检索结果相关度
+// 功能点：
+// 1. 按关键词在分片中的密度计算关键词检索结果的相关度
+// 2. 融合前过滤关键词只是偶然出现的弱命中分片
+// 3. 最低关键词密度可配置
+
+package rag
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"reimbursement-audit/internal/pkg/utils"
+)
+
+// DefaultMinKeywordDensity 关键词检索结果的默认最低关键词密度
+// 关键词字符数占分片字符数的比例，500字的分片中2字关键词需出现至少3次
+const DefaultMinKeywordDensity = 0.01
+
+// keywordCandidateFactor 关键词检索候选数量倍数，过滤弱命中后仍能凑满topK
+const keywordCandidateFactor = 3
+
+// SetMinKeywordDensity 设置关键词检索结果的最低关键词密度，非正数使用默认值
+func (vs *VectorStore) SetMinKeywordDensity(density float64) {
+	if density <= 0 {
+		density = DefaultMinKeywordDensity
+	}
+	vs.minKeywordDensity = density
+}
+
+// keywordDensity 计算关键词密度：各关键词出现次数乘以关键词字符数之和，占内容字符数的比例
+func keywordDensity(content string, keywords []string) float64 {
+	total := utf8.RuneCountInString(content)
+	if total == 0 {
+		return 0
+	}
+
+	matched := 0
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" {
+			continue
+		}
+		matched += strings.Count(content, keyword) * utf8.RuneCountInString(keyword)
+	}
+
+	density := float64(matched) / float64(total)
+	if density > 1 {
+		density = 1
+	}
+	return density
+}
+
+// filterKeywordResults 过滤关键词密度低于下限的结果，按密度从高到低排序并截取前topK条
+func filterKeywordResults(results []*VectorSearchResult, keywords []string, minDensity float64, topK int) []*VectorSearchResult {
+	filtered := make([]*VectorSearchResult, 0, len(results))
+	densities := make(map[*VectorSearchResult]float64, len(results))
+	for _, result := range results {
+		density := keywordDensity(result.Content, keywords)
+		if density < minDensity {
+			continue
+		}
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		result.Metadata["keyword_density"] = density
+		densities[result] = density
+		filtered = append(filtered, result)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return densities[filtered[i]] > densities[filtered[j]]
+	})
+
+	// topK非正数时不截断
+	if topK > 0 {
+		filtered = utils.SafeTruncate(filtered, topK)
+	}
+	return filtered
+}
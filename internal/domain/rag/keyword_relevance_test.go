@@ -0,0 +1,69 @@
+package rag
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestKeywordDensity(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		keywords []string
+		want     float64
+	}{
+		{name: "空内容", content: "", keywords: []string{"住宿"}, want: 0},
+		{name: "按字符数计算", content: "住宿标准住宿", keywords: []string{"住宿"}, want: 4.0 / 6},
+		{name: "多个关键词累加", content: "住宿和交通", keywords: []string{"住宿", "交通"}, want: 4.0 / 5},
+		{name: "忽略空关键词", content: "住宿", keywords: []string{" ", ""}, want: 0},
+		{name: "重叠关键词不超过1", content: "住宿", keywords: []string{"住宿", "住", "宿"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keywordDensity(tt.content, tt.keywords); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("keywordDensity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterKeywordResults(t *testing.T) {
+	newResults := func() []*VectorSearchResult {
+		return []*VectorSearchResult{
+			{ChunkID: "weak", Content: "住宿" + strings.Repeat("其他内容", 100)},
+			{ChunkID: "medium", Content: "住宿标准按城市分级"},
+			{ChunkID: "strong", Content: "住宿住宿标准"},
+			{ChunkID: "none", Content: "交通费用"},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		minDensity float64
+		topK       int
+		want       []string
+	}{
+		{name: "过滤弱命中并按密度排序", minDensity: DefaultMinKeywordDensity, topK: 10, want: []string{"strong", "medium"}},
+		{name: "截取topK", minDensity: DefaultMinKeywordDensity, topK: 1, want: []string{"strong"}},
+		{name: "下限为0时保留全部命中", minDensity: 0, topK: 0, want: []string{"strong", "medium", "weak", "none"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterKeywordResults(newResults(), []string{"住宿"}, tt.minDensity, tt.topK)
+			if len(got) != len(tt.want) {
+				t.Fatalf("filterKeywordResults() = %d条, want %v", len(got), tt.want)
+			}
+			for i, result := range got {
+				if result.ChunkID != tt.want[i] {
+					t.Errorf("结果[%d] = %s, want %s", i, result.ChunkID, tt.want[i])
+				}
+				if _, ok := result.Metadata["keyword_density"]; !ok {
+					t.Errorf("结果[%d]未记录keyword_density", i)
+				}
+			}
+		})
+	}
+}
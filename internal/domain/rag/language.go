@@ -0,0 +1,107 @@
+// language.go 多语言制度文档检索
+// 功能点：
+// 1. 定义知识库支持的文档语言
+// 2. 根据文本内容检测语言（中文/英文）
+// 3. 规范化调用方指定的语言
+// 4. 导入时按分片内容标注语言
+// 5. 检索结果按查询语言加权，同语言分片优先
+
+package rag
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"reimbursement-audit/internal/pkg/utils"
+)
+
+// 文档语言
+const (
+	LanguageChinese = "zh-CN"
+	LanguageEnglish = "en"
+)
+
+// DefaultLanguageBoost 与查询语言相同的分片默认加权分值
+const DefaultLanguageBoost = 0.1
+
+// latinLettersPerWord 英文单词平均字母数，用于与汉字数量折算比较
+const latinLettersPerWord = 4
+
+// DetectLanguage 根据文本内容检测语言，汉字占比不低于英文单词时判定为中文，无法判断时返回空
+func DetectLanguage(text string) string {
+	han, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+
+	if han == 0 && latin == 0 {
+		return ""
+	}
+	if han*latinLettersPerWord >= latin {
+		return LanguageChinese
+	}
+	return LanguageEnglish
+}
+
+// NormalizeLanguage 规范化语言标识，不支持的语言返回空
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	language = strings.ReplaceAll(language, "_", "-")
+
+	switch {
+	case language == "":
+		return ""
+	case language == "zh" || strings.HasPrefix(language, "zh-") || language == "chinese" || language == "中文":
+		return LanguageChinese
+	case language == "en" || strings.HasPrefix(language, "en-") || language == "english" || language == "英文":
+		return LanguageEnglish
+	default:
+		return ""
+	}
+}
+
+// resolveQueryLanguage 确定查询语言：优先使用调用方指定的语言，否则根据查询内容检测
+func resolveQueryLanguage(query, language string) string {
+	if normalized := NormalizeLanguage(language); normalized != "" {
+		return normalized
+	}
+	return DetectLanguage(query)
+}
+
+// chunkLanguage 确定分片语言：优先按分片内容检测，无法判断时使用文档语言
+func chunkLanguage(document *Document, chunk *DocumentChunk) string {
+	if language := DetectLanguage(chunk.Content); language != "" {
+		return language
+	}
+	if document.Metadata != nil {
+		return NormalizeLanguage(document.Metadata.Language)
+	}
+	return ""
+}
+
+// applyLanguageBoost 对与查询语言相同的检索结果加权并重新排序，截取前topK条
+// 未标注语言的历史分片不加权也不降权
+func applyLanguageBoost(results []*VectorSearchResult, language string, boost float64, topK int) []*VectorSearchResult {
+	if language != "" && boost > 0 {
+		for _, result := range results {
+			if resultLanguage, _ := result.Metadata["language"].(string); resultLanguage == language {
+				result.Score += boost
+			}
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+	}
+
+	// topK非正数时不截断
+	if topK > 0 {
+		results = utils.SafeTruncate(results, topK)
+	}
+	return results
+}
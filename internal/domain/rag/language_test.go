@@ -0,0 +1,123 @@
+package rag
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "中文", text: "差旅住宿标准", want: LanguageChinese},
+		{name: "英文", text: "Travel accommodation policy", want: LanguageEnglish},
+		{name: "中文夹杂少量英文", text: "出差乘坐taxi的报销标准", want: LanguageChinese},
+		{name: "英文夹杂个别汉字", text: "Hotel allowance for Beijing 北京 trips", want: LanguageEnglish},
+		{name: "无文字", text: "123 456", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.want {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		want     string
+	}{
+		{name: "空值", language: " ", want: ""},
+		{name: "zh", language: "zh", want: LanguageChinese},
+		{name: "下划线与大小写", language: "ZH_cn", want: LanguageChinese},
+		{name: "中文名称", language: "中文", want: LanguageChinese},
+		{name: "en-US", language: "en-US", want: LanguageEnglish},
+		{name: "english", language: "English", want: LanguageEnglish},
+		{name: "不支持的语言", language: "ja", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeLanguage(tt.language); got != tt.want {
+				t.Errorf("NormalizeLanguage(%q) = %q, want %q", tt.language, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveQueryLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		language string
+		want     string
+	}{
+		{name: "优先使用指定语言", query: "住宿标准", language: "en", want: LanguageEnglish},
+		{name: "指定语言不支持时按内容检测", query: "住宿标准", language: "fr", want: LanguageChinese},
+		{name: "未指定时按内容检测", query: "hotel policy", want: LanguageEnglish},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveQueryLanguage(tt.query, tt.language); got != tt.want {
+				t.Errorf("resolveQueryLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyLanguageBoost(t *testing.T) {
+	newResults := func() []*VectorSearchResult {
+		return []*VectorSearchResult{
+			{ChunkID: "en", Score: 0.80, Metadata: map[string]interface{}{"language": LanguageEnglish}},
+			{ChunkID: "zh", Score: 0.75, Metadata: map[string]interface{}{"language": LanguageChinese}},
+			{ChunkID: "legacy", Score: 0.70},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		language   string
+		boost      float64
+		topK       int
+		wantChunks []string
+		wantScores []float64
+	}{
+		{
+			name: "同语言分片加权后排前", language: LanguageChinese, boost: 0.1, topK: 3,
+			wantChunks: []string{"zh", "en", "legacy"}, wantScores: []float64{0.85, 0.80, 0.70},
+		},
+		{
+			name: "未识别查询语言时不加权", language: "", boost: 0.1, topK: 3,
+			wantChunks: []string{"en", "zh", "legacy"}, wantScores: []float64{0.80, 0.75, 0.70},
+		},
+		{
+			name: "加权为0时不加权", language: LanguageChinese, boost: 0, topK: 3,
+			wantChunks: []string{"en", "zh", "legacy"}, wantScores: []float64{0.80, 0.75, 0.70},
+		},
+		{
+			name: "加权后截取topK", language: LanguageChinese, boost: 0.1, topK: 1,
+			wantChunks: []string{"zh"}, wantScores: []float64{0.85},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyLanguageBoost(newResults(), tt.language, tt.boost, tt.topK)
+			if len(got) != len(tt.wantChunks) {
+				t.Fatalf("applyLanguageBoost() = %d条, want %d条", len(got), len(tt.wantChunks))
+			}
+			for i, result := range got {
+				if result.ChunkID != tt.wantChunks[i] || math.Abs(result.Score-tt.wantScores[i]) > 1e-9 {
+					t.Errorf("结果[%d] = (%s, %v), want (%s, %v)", i, result.ChunkID, result.Score, tt.wantChunks[i], tt.wantScores[i])
+				}
+			}
+		})
+	}
+}
@@ -5,22 +5,85 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"reimbursement-audit/internal/pkg/logger"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// DefaultEmbeddingConcurrency 默认全局向量生成并发数
+const DefaultEmbeddingConcurrency = 8
+
+// DefaultEmbeddingBatchSize 默认单次向量生成请求的最大文本数
+const DefaultEmbeddingBatchSize = 64
+
+// DefaultEmbeddingModel 默认嵌入模型
+const DefaultEmbeddingModel = "text-embedding-ada-002"
+
+// DefaultContextWindow 默认模型上下文窗口大小(Token)
+const DefaultContextWindow = 8192
+
+// 聊天请求Token估算的固定开销：每条消息的角色和分隔符，以及回复的起始标记
+const (
+	messageTokenOverhead = 4
+	replyTokenOverhead   = 3
+)
+
+// APIError 大模型接口返回非200状态码时的错误
+type APIError struct {
+	StatusCode int // HTTP状态码
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	return fmt.Sprintf("请求失败: 状态码%d", e.StatusCode)
+}
+
+// Temporary 是否为临时性错误（限流或服务端错误），可稍后重试
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// RequestTooLargeError 聊天请求预估Token数超过模型上下文窗口时的错误
+// 调用方可根据Excess裁剪消息后重试
+type RequestTooLargeError struct {
+	EstimatedTokens int // 消息预估Token数
+	MaxTokens       int // 为回复预留的Token数
+	ContextWindow   int // 模型上下文窗口大小
+}
+
+// Error 实现error接口
+func (e *RequestTooLargeError) Error() string {
+	return fmt.Sprintf("请求超过模型上下文窗口: 预估%d个Token，预留回复%d个Token，上下文窗口%d个Token",
+		e.EstimatedTokens, e.MaxTokens, e.ContextWindow)
+}
+
+// Excess 需要裁剪的Token数
+func (e *RequestTooLargeError) Excess() int {
+	return e.EstimatedTokens + e.MaxTokens - e.ContextWindow
+}
+
 // LLMClient 大模型客户端结构体
 type LLMClient struct {
-	apiKey     string
-	baseURL    string
-	model      string
-	httpClient *http.Client
-	timeout    time.Duration
-	logger     logger.Logger
+	apiKey        string
+	baseURL       string
+	model         string
+	httpClient    *http.Client
+	timeout       time.Duration
+	logger        logger.Logger
+	embeddingSem  chan struct{} // 全局向量生成并发信号量，所有调用方共享
+	batchSize     int           // 单次向量生成请求的最大文本数
+	contextWindow int           // 模型上下文窗口大小(Token)，发送前据此校验请求大小
+
+	embeddingModel string         // 嵌入模型名称
+	embeddingCache EmbeddingCache // 向量缓存，为nil时不缓存
+	cacheHits      atomic.Int64   // 向量缓存命中次数
+	cacheMisses    atomic.Int64   // 向量缓存未命中次数
 }
 
 // NewLLMClient 创建大模型客户端实例
@@ -32,11 +95,56 @@ func NewLLMClient(apiKey, baseURL, model string, timeout int, log logger.Logger)
 		httpClient: &http.Client{
 			Timeout: time.Duration(timeout) * time.Second,
 		},
-		timeout: time.Duration(timeout) * time.Second,
-		logger:  log,
+		timeout:       time.Duration(timeout) * time.Second,
+		logger:        log,
+		embeddingSem:  make(chan struct{}, DefaultEmbeddingConcurrency),
+		batchSize:     DefaultEmbeddingBatchSize,
+		contextWindow: DefaultContextWindow,
+
+		embeddingModel: DefaultEmbeddingModel,
 	}
 }
 
+// SetEmbeddingConcurrency 设置全局向量生成并发数，应在服务启动时调用
+func (c *LLMClient) SetEmbeddingConcurrency(concurrency int) {
+	if concurrency <= 0 {
+		concurrency = DefaultEmbeddingConcurrency
+	}
+	c.embeddingSem = make(chan struct{}, concurrency)
+}
+
+// SetEmbeddingBatchSize 设置单次向量生成请求的最大文本数
+func (c *LLMClient) SetEmbeddingBatchSize(size int) {
+	if size <= 0 {
+		size = DefaultEmbeddingBatchSize
+	}
+	c.batchSize = size
+}
+
+// SetContextWindow 设置模型上下文窗口大小(Token)
+func (c *LLMClient) SetContextWindow(contextWindow int) {
+	if contextWindow <= 0 {
+		contextWindow = DefaultContextWindow
+	}
+	c.contextWindow = contextWindow
+}
+
+// SetEmbeddingModel 设置嵌入模型，为空时使用默认模型；向量缓存键包含模型名，更换模型后旧缓存不再命中
+func (c *LLMClient) SetEmbeddingModel(model string) {
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	c.embeddingModel = model
+}
+
+// EmbeddingBatchSize 获取单次向量生成请求的最大文本数
+func (c *LLMClient) EmbeddingBatchSize() int {
+	if c.batchSize <= 0 {
+		return DefaultEmbeddingBatchSize
+	}
+	return c.batchSize
+}
+
 // ChatMessage 聊天消息结构体
 type ChatMessage struct {
 	Role    string `json:"role"`
@@ -83,6 +191,11 @@ func (c *LLMClient) Chat(ctx context.Context, messages []ChatMessage, temperatur
 		return nil, errors.New("消息列表不能为空")
 	}
 
+	if err := c.checkRequestSize(messages, maxTokens); err != nil {
+		c.logger.Error("请求超过模型上下文窗口", logger.NewField("model", c.model), logger.NewField("error", err))
+		return nil, err
+	}
+
 	request := ChatRequest{
 		Model:       c.model,
 		Messages:    messages,
@@ -121,7 +234,7 @@ func (c *LLMClient) Chat(ctx context.Context, messages []ChatMessage, temperatur
 
 	if resp.StatusCode != http.StatusOK {
 		c.logger.Error("请求失败", logger.NewField("status_code", resp.StatusCode), logger.NewField("response", string(body)))
-		return nil, errors.New("请求失败")
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var chatResponse ChatResponse
@@ -133,6 +246,36 @@ func (c *LLMClient) Chat(ctx context.Context, messages []ChatMessage, temperatur
 	return &chatResponse, nil
 }
 
+// checkRequestSize 发送前校验请求大小：消息预估Token数加上预留的回复Token数不能超过上下文窗口
+func (c *LLMClient) checkRequestSize(messages []ChatMessage, maxTokens int) error {
+	contextWindow := c.contextWindow
+	if contextWindow <= 0 {
+		contextWindow = DefaultContextWindow
+	}
+
+	estimated := EstimateMessageTokens(messages)
+	if maxTokens < 0 {
+		maxTokens = 0
+	}
+	if estimated+maxTokens > contextWindow {
+		return &RequestTooLargeError{
+			EstimatedTokens: estimated,
+			MaxTokens:       maxTokens,
+			ContextWindow:   contextWindow,
+		}
+	}
+	return nil
+}
+
+// EstimateMessageTokens 估算聊天消息列表的Token数
+func EstimateMessageTokens(messages []ChatMessage) int {
+	tokens := replyTokenOverhead
+	for _, message := range messages {
+		tokens += messageTokenOverhead + estimateTextTokens(message.Role) + estimateTextTokens(message.Content)
+	}
+	return tokens
+}
+
 // GenerateLLMResponse 生成大模型响应
 func (c *LLMClient) GenerateLLMResponse(ctx context.Context, prompt string) (*LLMResponse, error) {
 	messages := []ChatMessage{
@@ -179,9 +322,56 @@ func calculateCost(tokens int) float64 {
 
 // GenerateEmbedding 生成向量嵌入
 func (c *LLMClient) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := c.cachedEmbeddings([]string{text}, func(texts []string) ([][]float64, error) {
+		return c.requestEmbeddings(ctx, texts[0], 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// BatchGenerateEmbeddings 批量生成向量嵌入
+// 按EmbeddingBatchSize切分为多个请求，每个请求一次提交多条文本，结果顺序与输入一致
+func (c *LLMClient) BatchGenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	return c.cachedEmbeddings(texts, func(texts []string) ([][]float64, error) {
+		return c.batchRequestEmbeddings(ctx, texts)
+	})
+}
+
+// batchRequestEmbeddings 按EmbeddingBatchSize切分文本，分批调用向量生成接口
+func (c *LLMClient) batchRequestEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, 0, len(texts))
+	batchSize := c.EmbeddingBatchSize()
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch, err := c.requestEmbeddings(ctx, texts[start:end], end-start)
+		if err != nil {
+			c.logger.Error("批量生成文本嵌入失败", logger.NewField("start", start), logger.NewField("count", end-start), logger.NewField("error", err))
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+// requestEmbeddings 调用向量生成接口，input为单条文本或文本列表，expected为期望返回的向量数
+func (c *LLMClient) requestEmbeddings(ctx context.Context, input interface{}, expected int) ([][]float64, error) {
+	// 获取全局并发配额，限制同时进行的向量生成请求数
+	select {
+	case c.embeddingSem <- struct{}{}:
+		defer func() { <-c.embeddingSem }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
 	embeddingRequest := map[string]interface{}{
-		"model": "text-embedding-ada-002",
-		"input": text,
+		"model": c.embeddingModel,
+		"input": input,
 	}
 
 	requestBody, err := json.Marshal(embeddingRequest)
@@ -214,11 +404,12 @@ func (c *LLMClient) GenerateEmbedding(ctx context.Context, text string) ([]float
 
 	if resp.StatusCode != http.StatusOK {
 		c.logger.Error("请求失败", logger.NewField("status_code", resp.StatusCode), logger.NewField("response", string(body)))
-		return nil, errors.New("请求失败")
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	var embeddingResponse struct {
 		Data []struct {
+			Index     int       `json:"index"`
 			Embedding []float64 `json:"embedding"`
 		} `json:"data"`
 	}
@@ -233,19 +424,19 @@ func (c *LLMClient) GenerateEmbedding(ctx context.Context, text string) ([]float
 		return nil, errors.New("响应中没有嵌入向量")
 	}
 
-	return embeddingResponse.Data[0].Embedding, nil
-}
+	if len(embeddingResponse.Data) != expected {
+		c.logger.Error("响应中嵌入向量数量不匹配", logger.NewField("expected", expected), logger.NewField("actual", len(embeddingResponse.Data)))
+		return nil, errors.New("响应中嵌入向量数量不匹配")
+	}
 
-// BatchGenerateEmbeddings 批量生成向量嵌入
-func (c *LLMClient) BatchGenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
-	embeddings := make([][]float64, len(texts))
-	for i, text := range texts {
-		embedding, err := c.GenerateEmbedding(ctx, text)
-		if err != nil {
-			c.logger.Error("生成文本嵌入失败", logger.NewField("index", i), logger.NewField("text", text), logger.NewField("error", err))
-			return nil, err
-		}
-		embeddings[i] = embedding
+	// 按index还原输入顺序
+	sort.SliceStable(embeddingResponse.Data, func(i, j int) bool {
+		return embeddingResponse.Data[i].Index < embeddingResponse.Data[j].Index
+	})
+
+	embeddings := make([][]float64, len(embeddingResponse.Data))
+	for i, item := range embeddingResponse.Data {
+		embeddings[i] = item.Embedding
 	}
 	return embeddings, nil
 }
@@ -0,0 +1,73 @@
+package rag
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEstimateMessageTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []ChatMessage
+		want     int
+	}{
+		{name: "无消息只计回复开销", want: replyTokenOverhead},
+		{
+			name:     "每条消息计入固定开销",
+			messages: []ChatMessage{{Role: "user", Content: ""}, {Role: "", Content: ""}},
+			want:     replyTokenOverhead + 2*messageTokenOverhead + estimateTextTokens("user"),
+		},
+		{
+			name:     "角色和内容都计入",
+			messages: []ChatMessage{{Role: "system", Content: "差旅住宿标准"}},
+			want:     replyTokenOverhead + messageTokenOverhead + estimateTextTokens("system") + estimateTextTokens("差旅住宿标准"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateMessageTokens(tt.messages); got != tt.want {
+				t.Errorf("EstimateMessageTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRequestSize(t *testing.T) {
+	messages := []ChatMessage{{Role: "user", Content: strings.Repeat("报销", 100)}}
+	estimated := EstimateMessageTokens(messages)
+
+	tests := []struct {
+		name          string
+		contextWindow int
+		maxTokens     int
+		wantErr       bool
+		wantExcess    int
+	}{
+		{name: "恰好不超过上下文窗口", contextWindow: estimated + 100, maxTokens: 100},
+		{name: "预留回复后超过上下文窗口", contextWindow: estimated + 100, maxTokens: 150, wantErr: true, wantExcess: 50},
+		{name: "负数预留按0处理", contextWindow: estimated, maxTokens: -10},
+		{name: "未设置上下文窗口使用默认值", contextWindow: 0, maxTokens: DefaultContextWindow, wantErr: true, wantExcess: estimated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &LLMClient{contextWindow: tt.contextWindow}
+			err := client.checkRequestSize(messages, tt.maxTokens)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkRequestSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			var tooLarge *RequestTooLargeError
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("checkRequestSize() error = %T, want *RequestTooLargeError", err)
+			}
+			if tooLarge.Excess() != tt.wantExcess {
+				t.Errorf("Excess() = %d, want %d", tooLarge.Excess(), tt.wantExcess)
+			}
+		})
+	}
+}
@@ -52,11 +52,15 @@ type DocumentChunk struct {
 	ID         string    `json:"id"`          // 分片ID
 	DocumentID string    `json:"document_id"` // 文档ID
 	Content    string    `json:"content"`     // 分片内容
+	Index      int       `json:"index"`       // 分片序号
 	StartPos   int       `json:"start_pos"`   // 起始位置
 	EndPos     int       `json:"end_pos"`     // 结束位置
 	Vector     []float64 `json:"vector"`      // 向量表示
+	Category   string    `json:"category"`    // 分片类别，导入时推断
 	CreatedAt  time.Time `json:"created_at"`  // 创建时间
 	UpdatedAt  time.Time `json:"updated_at"`  // 更新时间
+
+	Score float64 `json:"score,omitempty"` // 检索相似度分数，仅检索结果中的分片携带
 }
 
 // Vector 向量模型
@@ -64,10 +68,12 @@ type Vector struct {
 	ID           string                 `json:"id"`            // 向量ID
 	DocumentID   string                 `json:"document_id"`   // 文档ID
 	ChunkID      string                 `json:"chunk_id"`      // 分片ID
+	ChunkIndex   int                    `json:"chunk_index"`   // 分片序号
 	ChunkContent string                 `json:"chunk_content"` // 分片内容
 	Values       []float64              `json:"values"`        // 向量值
 	Dimension    int                    `json:"dimension"`     // 向量维度
 	Category     string                 `json:"category"`      // 类别（差旅费/招待费/发票校验）
+	Language     string                 `json:"language"`      // 分片语言(zh-CN/en)
 	Metadata     map[string]interface{} `json:"metadata"`      // 元数据
 	CreatedAt    time.Time              `json:"created_at"`    // 创建时间
 	UpdatedAt    time.Time              `json:"updated_at"`    // 更新时间
@@ -99,6 +105,29 @@ type Prompt struct {
 	Tags      []string               `json:"tags"`       // 标签
 }
 
+// PromptTemplate Prompt模板持久化模型
+type PromptTemplate struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`                   // 模板ID
+	Name      string    `json:"name" gorm:"type:varchar(100);uniqueIndex:idx_name_type"` // 模板名称
+	Type      string    `json:"type" gorm:"type:varchar(20);uniqueIndex:idx_name_type"`  // 模板类型(system/user)
+	Content   string    `json:"content" gorm:"type:text"`                                // 模板内容
+	Enabled   bool      `json:"enabled" gorm:"default:true"`                             // 是否启用
+	UpdatedBy string    `json:"updated_by" gorm:"type:varchar(50)"`                      // 更新人
+	CreatedAt time.Time `json:"created_at"`                                              // 创建时间
+	UpdatedAt time.Time `json:"updated_at"`                                              // 更新时间
+}
+
+// TableName 指定Prompt模板表名
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
+
+// Prompt模板类型常量
+const (
+	PromptTemplateTypeSystem = "system" // 系统提示词模板
+	PromptTemplateTypeUser   = "user"   // 用户提示词模板
+)
+
 // ConversationMessage 对话消息模型
 type ConversationMessage struct {
 	Role      string    `json:"role"`      // 角色(system/user/assistant)
@@ -108,14 +137,18 @@ type ConversationMessage struct {
 
 // RAGResult RAG结果模型
 type RAGResult struct {
-	Query          string           `json:"query"`           // 查询内容
-	Documents      []*Document      `json:"documents"`       // 检索到的文档
-	Chunks         []*DocumentChunk `json:"chunks"`          // 检索到的分片
-	Prompt         string           `json:"prompt"`          // 构建的Prompt
-	Response       *LLMResponse     `json:"response"`        // 大模型响应
-	AnalysisResult *AnalysisResult  `json:"analysis_result"` // 分析结果
-	ExecutionTime  int64            `json:"execution_time"`  // 执行时间(毫秒)
-	CreatedAt      time.Time        `json:"created_at"`      // 创建时间
+	Query          string           `json:"query"`            // 查询内容
+	Documents      []*Document      `json:"documents"`        // 检索到的文档
+	Chunks         []*DocumentChunk `json:"chunks"`           // 检索到的分片
+	Prompt         string           `json:"prompt"`           // 构建的Prompt
+	Response       *LLMResponse     `json:"response"`         // 大模型响应
+	AnalysisResult *AnalysisResult  `json:"analysis_result"`  // 分析结果
+	Format         OutputFormat     `json:"format"`           // 输出格式
+	Output         string           `json:"output"`           // 按输出格式处理后的回答
+	Answer         *QueryAnswer     `json:"answer,omitempty"` // 结构化回答(仅json格式)
+	ExecutionTime  int64            `json:"execution_time"`   // 执行时间(毫秒)
+	Cached         bool             `json:"cached"`           // 是否命中查询缓存
+	CreatedAt      time.Time        `json:"created_at"`       // 创建时间
 }
 
 // LLMResponse 大模型响应模型
@@ -137,6 +170,7 @@ type AnalysisResult struct {
 	Reasoning   string                 `json:"reasoning"`   // 推理过程
 	Suggestions []string               `json:"suggestions"` // 建议
 	Confidence  float64                `json:"confidence"`  // 置信度
+	Citations   []*Citation            `json:"citations"`   // 结论依据的制度文档片段，按提示词中的引用编号排列
 	Data        map[string]interface{} `json:"data"`        // 相关数据
 	CreatedAt   time.Time              `json:"created_at"`  // 创建时间
 }
@@ -154,13 +188,45 @@ type DocumentFilter struct {
 	Size       int       `json:"size"`       // 每页大小
 }
 
+// IngestResult 单个文档导入结果
+type IngestResult struct {
+	Path     string    `json:"path"`     // 文档路径
+	Document *Document `json:"document"` // 导入成功的文档
+	Error    error     `json:"-"`        // 导入失败原因
+	Duration int64     `json:"duration"` // 导入耗时(毫秒)
+}
+
+// ChunkFilter 文档分片查询过滤器
+type ChunkFilter struct {
+	DocumentID string `json:"document_id"` // 文档ID
+	Keyword    string `json:"keyword"`     // 内容关键词
+	Page       int    `json:"page"`        // 页码
+	Size       int    `json:"size"`        // 每页大小
+}
+
+// ChunkRecord 已入库的文档分片记录
+type ChunkRecord struct {
+	ID         string    `json:"id"`          // 记录ID
+	DocumentID string    `json:"document_id"` // 文档ID
+	ChunkID    string    `json:"chunk_id"`    // 分片ID
+	ChunkIndex int       `json:"chunk_index"` // 分片序号
+	Category   string    `json:"category"`    // 类别
+	FileType   string    `json:"file_type"`   // 文件类型
+	Content    string    `json:"content"`     // 分片内容
+	CreatedAt  time.Time `json:"created_at"`  // 创建时间
+	UpdatedAt  time.Time `json:"updated_at"`  // 更新时间
+}
+
 // VectorStoreStatistics 向量存储统计模型
 type VectorStoreStatistics struct {
 	DocumentCount int64     `json:"document_count"` // 文档数量
 	ChunkCount    int64     `json:"chunk_count"`    // 分片数量
 	VectorCount   int64     `json:"vector_count"`   // 向量数量
-	IndexSize     int64     `json:"index_size"`     // 索引大小
-	StorageSize   int64     `json:"storage_size"`   // 存储大小
+	IndexSize     int64     `json:"index_size"`     // 索引大小(字节)，文档表全部索引占用
+	StorageSize   int64     `json:"storage_size"`   // 存储大小(字节)，文档表含索引和TOAST的总占用
+	TableSize     int64     `json:"table_size"`     // 表数据大小(字节)，不含索引
+	AvgDimension  float64   `json:"avg_dimension"`  // 平均向量维度
+	LastWriteAt   time.Time `json:"last_write_at"`  // 最近一次写入时间，没有数据时为零值
 	LastUpdated   time.Time `json:"last_updated"`   // 最后更新时间
 }
 
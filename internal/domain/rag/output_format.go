@@ -0,0 +1,184 @@
+// output_format.go 查询结果输出格式
+// 功能点：
+// 1. 定义查询结果输出格式（markdown/plain/json）
+// 2. 生成对应格式的大模型输出约束说明
+// 3. 将大模型原始回答后处理为目标格式
+// 4. json格式输出{answer, citations}结构化对象
+
+package rag
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OutputFormat 查询结果输出格式
+type OutputFormat string
+
+const (
+	// OutputFormatMarkdown Markdown格式，附带参考依据列表
+	OutputFormatMarkdown OutputFormat = "markdown"
+	// OutputFormatPlain 纯文本格式，去除Markdown标记
+	OutputFormatPlain OutputFormat = "plain"
+	// OutputFormatJSON JSON格式，输出{answer, citations}对象
+	OutputFormatJSON OutputFormat = "json"
+)
+
+// citationSnippetLength 引用原文片段的最大字符数
+const citationSnippetLength = 200
+
+var (
+	markdownCodeFence = regexp.MustCompile("(?m)^```[a-zA-Z]*\\s*$")
+	markdownHeading   = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownQuote     = regexp.MustCompile(`(?m)^>\s?`)
+	markdownListItem  = regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`)
+	markdownLink      = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownEmphasis  = regexp.MustCompile(`(\*\*|__|\*|~~|` + "`" + `)([^*~` + "`" + `\n]+)(\*\*|__|\*|~~|` + "`" + `)`)
+	markdownRule      = regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$`)
+	blankLines        = regexp.MustCompile(`\n{3,}`)
+)
+
+// ParseOutputFormat 解析输出格式，空值默认为markdown
+func ParseOutputFormat(format string) (OutputFormat, error) {
+	switch OutputFormat(strings.ToLower(strings.TrimSpace(format))) {
+	case "", OutputFormatMarkdown:
+		return OutputFormatMarkdown, nil
+	case OutputFormatPlain:
+		return OutputFormatPlain, nil
+	case OutputFormatJSON:
+		return OutputFormatJSON, nil
+	default:
+		return "", fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// Citation 回答引用的制度文档片段
+type Citation struct {
+	Index      int     `json:"index"`       // 引用编号
+	DocumentID string  `json:"document_id"` // 文档ID
+	ChunkID    string  `json:"chunk_id"`    // 分片ID
+	Content    string  `json:"content"`     // 原文片段
+	Score      float64 `json:"score"`       // 相似度分数
+
+	Category string `json:"category,omitempty"` // 制度文档类别
+	Cited    bool   `json:"cited,omitempty"`    // 回答中是否标注了该引用编号
+}
+
+// QueryAnswer 结构化查询结果
+type QueryAnswer struct {
+	Answer    string      `json:"answer"`    // 回答内容
+	Citations []*Citation `json:"citations"` // 引用列表
+}
+
+// formatInstruction 返回追加到系统提示词中的输出格式约束
+func formatInstruction(format OutputFormat) string {
+	switch format {
+	case OutputFormatPlain:
+		return "输出格式要求：使用纯文本回答，不要使用任何Markdown标记（如#、*、-、`、表格等）。"
+	case OutputFormatJSON:
+		return `输出格式要求：只输出一个JSON对象，不要输出其他内容，格式为{"answer": "回答内容"}，answer中使用纯文本。`
+	default:
+		return "输出格式要求：使用Markdown格式回答，可使用标题、列表和加粗突出重点。"
+	}
+}
+
+// buildCitations 根据检索结果构建引用列表
+func buildCitations(references []*VectorSearchResult) []*Citation {
+	return newCitations(references, citationSnippetLength)
+}
+
+// newCitations 根据检索结果构建按顺序编号的引用列表，maxRunes为原文片段最大字符数，非正数表示保留全文
+func newCitations(references []*VectorSearchResult, maxRunes int) []*Citation {
+	citations := make([]*Citation, 0, len(references))
+	for _, reference := range references {
+		if reference == nil {
+			continue
+		}
+		content := strings.TrimSpace(reference.Content)
+		if maxRunes > 0 {
+			content = truncateContent(content, maxRunes)
+		}
+		citations = append(citations, &Citation{
+			Index:      len(citations) + 1,
+			DocumentID: reference.DocumentID,
+			ChunkID:    reference.ChunkID,
+			Content:    content,
+			Score:      reference.Score,
+			Category:   searchResultCategory(reference),
+		})
+	}
+	return citations
+}
+
+// FormatAnswer 将大模型原始回答后处理为目标格式
+// 返回格式化后的文本；json格式下同时返回结构化对象，文本为其序列化结果
+func FormatAnswer(content string, format OutputFormat, references []*VectorSearchResult) (string, *QueryAnswer, error) {
+	citations := buildCitations(references)
+
+	switch format {
+	case OutputFormatPlain:
+		return stripMarkdown(content), nil, nil
+	case OutputFormatJSON:
+		answer := &QueryAnswer{
+			Answer:    extractJSONAnswer(content),
+			Citations: citations,
+		}
+		data, err := json.Marshal(answer)
+		if err != nil {
+			return "", nil, fmt.Errorf("序列化查询结果失败: %w", err)
+		}
+		return string(data), answer, nil
+	default:
+		return appendMarkdownCitations(strings.TrimSpace(content), citations), nil, nil
+	}
+}
+
+// extractJSONAnswer 从大模型输出中提取answer字段，无法解析时回退为去除Markdown的原文
+func extractJSONAnswer(content string) string {
+	text := strings.TrimSpace(markdownCodeFence.ReplaceAllString(content, ""))
+
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start >= 0 && end > start {
+		var parsed struct {
+			Answer string `json:"answer"`
+		}
+		if err := json.Unmarshal([]byte(text[start:end+1]), &parsed); err == nil && parsed.Answer != "" {
+			return strings.TrimSpace(parsed.Answer)
+		}
+	}
+
+	return stripMarkdown(content)
+}
+
+// appendMarkdownCitations 在Markdown回答末尾追加参考依据列表
+func appendMarkdownCitations(content string, citations []*Citation) string {
+	if len(citations) == 0 {
+		return content
+	}
+
+	var builder strings.Builder
+	builder.WriteString(content)
+	builder.WriteString("\n\n### 参考依据\n")
+	for _, citation := range citations {
+		builder.WriteString(fmt.Sprintf("%d. `%s` %s\n", citation.Index, citation.DocumentID,
+			strings.ReplaceAll(citation.Content, "\n", " ")))
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// stripMarkdown 去除文本中的Markdown标记
+func stripMarkdown(content string) string {
+	text := markdownCodeFence.ReplaceAllString(content, "")
+	text = markdownRule.ReplaceAllString(text, "")
+	text = markdownHeading.ReplaceAllString(text, "")
+	text = markdownQuote.ReplaceAllString(text, "")
+	text = markdownListItem.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = markdownEmphasis.ReplaceAllString(text, "$2")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
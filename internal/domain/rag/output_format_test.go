@@ -0,0 +1,118 @@
+package rag
+
+import "testing"
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		want    OutputFormat
+		wantErr bool
+	}{
+		{name: "空值默认为markdown", format: "", want: OutputFormatMarkdown},
+		{name: "忽略大小写和空白", format: " JSON ", want: OutputFormatJSON},
+		{name: "纯文本", format: "plain", want: OutputFormatPlain},
+		{name: "不支持的格式", format: "html", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutputFormat(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOutputFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOutputFormat(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "去除标题和加粗", content: "## 差旅标准\n住宿**不超过500元**", want: "差旅标准\n住宿不超过500元"},
+		{name: "去除列表标记", content: "- 机票\n- 火车票", want: "机票\n火车票"},
+		{name: "链接保留文字", content: "详见[差旅制度](http://example.com)", want: "详见差旅制度"},
+		{name: "合并多余空行", content: "第一段\n\n\n\n第二段", want: "第一段\n\n第二段"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripMarkdown(tt.content); got != tt.want {
+				t.Errorf("stripMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractJSONAnswer(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "直接输出JSON", content: `{"answer": "可以报销"}`, want: "可以报销"},
+		{name: "代码块包裹的JSON", content: "```json\n{\"answer\": \"可以报销\"}\n```", want: "可以报销"},
+		{name: "JSON前后有说明文字", content: `回答如下：{"answer": " 可以报销 "}以上`, want: "可以报销"},
+		{name: "无法解析时回退为去除Markdown的原文", content: "**可以报销**", want: "可以报销"},
+		{name: "answer为空时回退为原文", content: `{"answer": ""}`, want: `{"answer": ""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractJSONAnswer(tt.content); got != tt.want {
+				t.Errorf("extractJSONAnswer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAnswer(t *testing.T) {
+	references := []*VectorSearchResult{
+		nil,
+		{DocumentID: "doc1", ChunkID: "c1", Content: "住宿标准\n500元", Score: 0.9},
+	}
+
+	tests := []struct {
+		name       string
+		format     OutputFormat
+		references []*VectorSearchResult
+		want       string
+		wantAnswer bool
+	}{
+		{
+			name:       "markdown追加参考依据",
+			format:     OutputFormatMarkdown,
+			references: references,
+			want:       "可以报销\n\n### 参考依据\n1. `doc1` 住宿标准 500元",
+		},
+		{name: "markdown无引用时不追加", format: OutputFormatMarkdown, want: "可以报销"},
+		{name: "纯文本不追加引用", format: OutputFormatPlain, references: references, want: "可以报销"},
+		{
+			name:       "json输出结构化结果",
+			format:     OutputFormatJSON,
+			references: references,
+			want:       `{"answer":"可以报销","citations":[{"index":1,"document_id":"doc1","chunk_id":"c1","content":"住宿标准\n500元","score":0.9}]}`,
+			wantAnswer: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, answer, err := FormatAnswer("可以报销", tt.format, tt.references)
+			if err != nil {
+				t.Fatalf("FormatAnswer() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FormatAnswer() = %q, want %q", got, tt.want)
+			}
+			if (answer != nil) != tt.wantAnswer {
+				t.Errorf("FormatAnswer() answer = %+v, wantAnswer %v", answer, tt.wantAnswer)
+			}
+		})
+	}
+}
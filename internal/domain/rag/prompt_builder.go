@@ -5,19 +5,33 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
+	"unicode"
 
 	"reimbursement-audit/internal/pkg/logger"
 )
 
+// templateFileExt 模板文件扩展名
+const templateFileExt = ".tmpl"
+
 // PromptBuilder Prompt构造器
 type PromptBuilder struct {
 	logger          logger.Logger
+	mu              sync.RWMutex
 	systemTemplates map[string]string
 	userTemplates   map[string]string
+	templateDir     string                   // 模板目录（用于热重载）
+	templateRepo    PromptTemplateRepository // 模板仓储（用于热重载）
+	guard           *PromptGuard             // 检索内容提示词注入防护
+
+	maxPromptInvoices int // 审核提示词中最多逐张列出的发票数
 }
 
 // NewPromptBuilder 创建Prompt构造器实例
@@ -26,14 +40,17 @@ func NewPromptBuilder(log logger.Logger) *PromptBuilder {
 		logger:          log,
 		systemTemplates: make(map[string]string),
 		userTemplates:   make(map[string]string),
+		guard:           defaultPromptGuard(),
+
+		maxPromptInvoices: DefaultMaxPromptInvoices,
 	}
-	builder.initDefaultTemplates()
+	builder.initDefaultTemplates(builder.systemTemplates, builder.userTemplates)
 	return builder
 }
 
 // initDefaultTemplates 初始化默认模板
-func (pb *PromptBuilder) initDefaultTemplates() {
-	pb.systemTemplates["default"] = `你是一个专业的报销审核助手，能够根据报销制度文档对报销单据进行审核和分析。
+func (pb *PromptBuilder) initDefaultTemplates(systemTemplates, userTemplates map[string]string) {
+	systemTemplates["default"] = `你是一个专业的报销审核助手，能够根据报销制度文档对报销单据进行审核和分析。
 请基于提供的报销制度文档内容，对用户的报销问题进行准确、详细的回答。
 回答时请注意：
 1. 严格依据报销制度文档中的规定
@@ -41,16 +58,18 @@ func (pb *PromptBuilder) initDefaultTemplates() {
 3. 如果文档中没有相关信息，请明确说明
 4. 提供清晰、有条理的回答`
 
-	pb.systemTemplates["audit"] = `你是一个专业的报销审核专家，负责审核员工的报销申请。
+	systemTemplates["audit"] = `你是一个专业的报销审核专家，负责审核员工的报销申请。
 请根据提供的报销制度文档，对报销申请进行严格审核。
 审核要点：
 1. 检查报销金额是否符合标准
 2. 检查报销类型是否在允许范围内
 3. 检查审批流程是否完整
 4. 检查附件是否齐全
-5. 给出明确的审核结论（通过/驳回/需补充材料）`
+5. 给出明确的审核结论（通过/驳回/需补充材料）
+6. 结论和理由中引用制度规定时，用[编号]标注所依据的制度文档片段，如[1]、[2]
+7. 逐张核对发票明细，发现具体某张发票有问题时，用[发票编号]指明该发票（如[发票2]）并说明问题`
 
-	pb.systemTemplates["query"] = `你是一个报销制度查询助手，帮助用户快速了解报销政策和规定。
+	systemTemplates["query"] = `你是一个报销制度查询助手，帮助用户快速了解报销政策和规定。
 请基于提供的报销制度文档，准确回答用户关于报销政策的问题。
 回答要求：
 1. 准确引用相关条款
@@ -58,7 +77,7 @@ func (pb *PromptBuilder) initDefaultTemplates() {
 3. 说明适用的条件和场景
 4. 如有例外情况，请一并说明`
 
-	pb.userTemplates["rag_query"] = `基于以下报销制度文档内容，回答用户的问题：
+	userTemplates["rag_query"] = `基于以下报销制度文档内容，回答用户的问题：
 
 【报销制度文档】
 {{range .Documents}}
@@ -72,54 +91,259 @@ func (pb *PromptBuilder) initDefaultTemplates() {
 
 请基于上述文档内容，准确回答用户的问题。如果文档中没有相关信息，请明确说明。`
 
-	pb.userTemplates["audit"] = `请审核以下报销申请：
+	userTemplates["audit"] = `请审核以下报销申请：
 
 【报销制度文档】
-{{range .Documents}}
-文档标题：{{.Title}}
-文档内容：
+{{range .References}}
+[{{.Index}}] 文档：{{.DocumentID}}
 {{.Content}}
 {{end}}
 
 【报销申请信息】
 {{.ReimbursementInfo}}
 
-请根据报销制度文档，对上述报销申请进行审核，并给出审核结论和理由。`
+请根据报销制度文档，对上述报销申请进行审核，并给出审核结论和理由。引用制度规定时请用[编号]标注对应的文档片段。`
 
-	pb.userTemplates["simple_query"] = `用户问题：{{.Query}}
+	userTemplates["simple_query"] = `用户问题：{{.Query}}
 
 请回答这个问题。`
 }
 
 // RegisterSystemTemplate 注册系统提示词模板
 func (pb *PromptBuilder) RegisterSystemTemplate(name, template string) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
 	pb.systemTemplates[name] = template
 }
 
 // RegisterUserTemplate 注册用户提示词模板
 func (pb *PromptBuilder) RegisterUserTemplate(name, template string) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
 	pb.userTemplates[name] = template
 }
 
-// BuildSystemPrompt 构造系统提示词
+// LoadTemplatesFromDir 从模板目录加载模板
+// 目录结构：system/<name>.tmpl 为系统模板，user/<name>.tmpl 为用户模板，同名模板覆盖默认模板
+func (pb *PromptBuilder) LoadTemplatesFromDir(path string) error {
+	systemTemplates, userTemplates, err := pb.readTemplatesFromDir(path)
+	if err != nil {
+		return err
+	}
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	for name, content := range systemTemplates {
+		pb.systemTemplates[name] = content
+	}
+	for name, content := range userTemplates {
+		pb.userTemplates[name] = content
+	}
+	pb.templateDir = path
+
+	pb.logger.Info("从目录加载Prompt模板完成",
+		logger.NewField("path", path),
+		logger.NewField("system_count", len(systemTemplates)),
+		logger.NewField("user_count", len(userTemplates)))
+
+	return nil
+}
+
+// LoadTemplatesFromDB 从数据库加载模板，同名模板覆盖默认模板和目录模板
+func (pb *PromptBuilder) LoadTemplatesFromDB(ctx context.Context, repo PromptTemplateRepository) error {
+	if repo == nil {
+		return errors.New("模板仓储不能为空")
+	}
+
+	systemTemplates, userTemplates, err := pb.readTemplatesFromDB(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	for name, content := range systemTemplates {
+		pb.systemTemplates[name] = content
+	}
+	for name, content := range userTemplates {
+		pb.userTemplates[name] = content
+	}
+	pb.templateRepo = repo
+
+	pb.logger.WithContext(ctx).Info("从数据库加载Prompt模板完成",
+		logger.NewField("system_count", len(systemTemplates)),
+		logger.NewField("user_count", len(userTemplates)))
+
+	return nil
+}
+
+// ReloadTemplates 热重载模板
+// 按 默认模板 -> 目录模板 -> 数据库模板 的顺序重新构建，全部加载成功后才替换当前模板
+func (pb *PromptBuilder) ReloadTemplates(ctx context.Context) error {
+	pb.mu.RLock()
+	dir := pb.templateDir
+	repo := pb.templateRepo
+	pb.mu.RUnlock()
+
+	systemTemplates := make(map[string]string)
+	userTemplates := make(map[string]string)
+	pb.initDefaultTemplates(systemTemplates, userTemplates)
+
+	if dir != "" {
+		dirSystem, dirUser, err := pb.readTemplatesFromDir(dir)
+		if err != nil {
+			return fmt.Errorf("重载目录模板失败: %w", err)
+		}
+		for name, content := range dirSystem {
+			systemTemplates[name] = content
+		}
+		for name, content := range dirUser {
+			userTemplates[name] = content
+		}
+	}
+
+	if repo != nil {
+		dbSystem, dbUser, err := pb.readTemplatesFromDB(ctx, repo)
+		if err != nil {
+			return fmt.Errorf("重载数据库模板失败: %w", err)
+		}
+		for name, content := range dbSystem {
+			systemTemplates[name] = content
+		}
+		for name, content := range dbUser {
+			userTemplates[name] = content
+		}
+	}
+
+	pb.mu.Lock()
+	pb.systemTemplates = systemTemplates
+	pb.userTemplates = userTemplates
+	pb.mu.Unlock()
+
+	pb.logger.WithContext(ctx).Info("Prompt模板重载完成",
+		logger.NewField("system_count", len(systemTemplates)),
+		logger.NewField("user_count", len(userTemplates)))
+
+	return nil
+}
+
+// readTemplatesFromDir 读取并校验目录中的模板
+func (pb *PromptBuilder) readTemplatesFromDir(path string) (map[string]string, map[string]string, error) {
+	if _, err := os.Stat(path); err != nil {
+		pb.logger.Error("模板目录不存在", logger.NewField("path", path), logger.NewField("error", err))
+		return nil, nil, fmt.Errorf("模板目录不存在: %s", path)
+	}
+
+	systemTemplates, err := pb.readTemplateFiles(filepath.Join(path, PromptTemplateTypeSystem))
+	if err != nil {
+		return nil, nil, err
+	}
+	userTemplates, err := pb.readTemplateFiles(filepath.Join(path, PromptTemplateTypeUser))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return systemTemplates, userTemplates, nil
+}
+
+// readTemplateFiles 读取目录下的模板文件，子目录不存在时视为无模板
+func (pb *PromptBuilder) readTemplateFiles(dir string) (map[string]string, error) {
+	templates := make(map[string]string)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return templates, nil
+		}
+		pb.logger.Error("读取模板目录失败", logger.NewField("dir", dir), logger.NewField("error", err))
+		return nil, fmt.Errorf("读取模板目录失败: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != templateFileExt {
+			continue
+		}
+
+		filePath := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			pb.logger.Error("读取模板文件失败", logger.NewField("file", filePath), logger.NewField("error", err))
+			return nil, fmt.Errorf("读取模板文件失败: %w", err)
+		}
+
+		content := string(data)
+		if err := validateTemplate(content); err != nil {
+			return nil, fmt.Errorf("模板文件 %s 语法错误: %w", filePath, err)
+		}
+
+		templates[strings.TrimSuffix(entry.Name(), templateFileExt)] = content
+	}
+
+	return templates, nil
+}
+
+// readTemplatesFromDB 读取并校验数据库中的模板
+func (pb *PromptBuilder) readTemplatesFromDB(ctx context.Context, repo PromptTemplateRepository) (map[string]string, map[string]string, error) {
+	records, err := repo.ListPromptTemplates(ctx)
+	if err != nil {
+		pb.logger.WithContext(ctx).Error("查询Prompt模板失败", logger.NewField("error", err))
+		return nil, nil, fmt.Errorf("查询Prompt模板失败: %w", err)
+	}
+
+	systemTemplates := make(map[string]string)
+	userTemplates := make(map[string]string)
+	for _, record := range records {
+		if !record.Enabled {
+			continue
+		}
+		if err := validateTemplate(record.Content); err != nil {
+			return nil, nil, fmt.Errorf("模板 %s 语法错误: %w", record.Name, err)
+		}
+
+		switch record.Type {
+		case PromptTemplateTypeSystem:
+			systemTemplates[record.Name] = record.Content
+		case PromptTemplateTypeUser:
+			userTemplates[record.Name] = record.Content
+		default:
+			pb.logger.WithContext(ctx).Warn("未知的模板类型，已跳过",
+				logger.NewField("name", record.Name),
+				logger.NewField("type", record.Type))
+		}
+	}
+
+	return systemTemplates, userTemplates, nil
+}
+
+// validateTemplate 校验模板语法
+func validateTemplate(content string) error {
+	_, err := template.New("validate").Option("missingkey=error").Parse(content)
+	return err
+}
+
+// BuildSystemPrompt 构造系统提示词，启用提示词注入防护时追加防护条款
 func (pb *PromptBuilder) BuildSystemPrompt(templateName string, variables map[string]interface{}) (string, error) {
-	templateContent, ok := pb.systemTemplates[templateName]
+	templateContent, ok := pb.GetSystemTemplate(templateName)
 	if !ok {
-		templateContent = pb.systemTemplates["default"]
+		templateContent, _ = pb.GetSystemTemplate("default")
 	}
 
 	if len(variables) == 0 {
-		return templateContent, nil
+		return pb.promptGuard().GuardSystemPrompt(templateContent), nil
 	}
 
-	return pb.renderTemplate(templateContent, variables)
+	systemPrompt, err := pb.renderTemplate(templateContent, variables)
+	if err != nil {
+		return "", err
+	}
+	return pb.promptGuard().GuardSystemPrompt(systemPrompt), nil
 }
 
 // BuildUserPrompt 构造用户提示词
 func (pb *PromptBuilder) BuildUserPrompt(templateName string, variables map[string]interface{}) (string, error) {
-	templateContent, ok := pb.userTemplates[templateName]
+	templateContent, ok := pb.GetUserTemplate(templateName)
 	if !ok {
-		templateContent = pb.userTemplates["simple_query"]
+		templateContent, _ = pb.GetUserTemplate("simple_query")
 	}
 
 	return pb.renderTemplate(templateContent, variables)
@@ -133,6 +357,8 @@ func (pb *PromptBuilder) BuildRAGPrompt(ctx context.Context, query string, docum
 		return nil, errors.New("构造系统提示词失败")
 	}
 
+	// 检索内容清洗后用分隔符包裹，防止制度文本中的指令劫持模型
+	documents, chunks = pb.guardDocuments(documents, chunks)
 	variables := map[string]interface{}{
 		"Query":     query,
 		"Documents": documents,
@@ -162,17 +388,20 @@ func (pb *PromptBuilder) BuildRAGPrompt(ctx context.Context, query string, docum
 	return prompt, nil
 }
 
-// BuildAuditPrompt 构造审核提示词
-func (pb *PromptBuilder) BuildAuditPrompt(ctx context.Context, reimbursementInfo string, documents []*Document) (*Prompt, error) {
+// BuildAuditPrompt 构造审核提示词，references为按编号排列的制度文档片段，供大模型标注引用编号
+func (pb *PromptBuilder) BuildAuditPrompt(ctx context.Context, reimbursementInfo string, documents []*Document, references []*Citation) (*Prompt, error) {
 	systemPrompt, err := pb.BuildSystemPrompt("audit", nil)
 	if err != nil {
 		pb.logger.Error("构造系统提示词失败", logger.NewField("error", err))
 		return nil, errors.New("构造系统提示词失败")
 	}
 
+	// 检索内容清洗后用分隔符包裹，防止制度文本中的指令劫持模型
+	documents, _ = pb.guardDocuments(documents, nil)
 	variables := map[string]interface{}{
 		"ReimbursementInfo": reimbursementInfo,
 		"Documents":         documents,
+		"References":        pb.guardReferences(references),
 	}
 
 	userPrompt, err := pb.BuildUserTemplate("audit", variables)
@@ -200,7 +429,7 @@ func (pb *PromptBuilder) BuildAuditPrompt(ctx context.Context, reimbursementInfo
 
 // BuildUserTemplate 构造用户模板
 func (pb *PromptBuilder) BuildUserTemplate(templateName string, variables map[string]interface{}) (string, error) {
-	templateContent, ok := pb.userTemplates[templateName]
+	templateContent, ok := pb.GetUserTemplate(templateName)
 	if !ok {
 		pb.logger.Error("模板不存在", logger.NewField("template_name", templateName))
 		return "", errors.New("模板不存在")
@@ -249,27 +478,126 @@ func (pb *PromptBuilder) BuildConversationWithHistory(systemPrompt string, histo
 
 // renderTemplate 渲染模板
 func (pb *PromptBuilder) renderTemplate(templateContent string, variables map[string]interface{}) (string, error) {
-	tmpl, err := template.New("prompt").Parse(templateContent)
+	// 未定义的变量直接报错，避免渲染出 <no value>
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(templateContent)
 	if err != nil {
 		pb.logger.Error("解析模板失败", logger.NewField("error", err))
-		return "", errors.New("解析模板失败")
+		return "", fmt.Errorf("解析模板失败: %w", err)
 	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, variables); err != nil {
 		pb.logger.Error("渲染模板失败", logger.NewField("error", err))
-		return "", errors.New("渲染模板失败")
+		return "", fmt.Errorf("渲染模板失败（请检查模板变量是否已定义）: %w", err)
 	}
 
 	return buf.String(), nil
 }
 
-// estimateTokens 估算Token数量
+// Token估算权重：中文约每字0.6个Token，英文及其他字符约每4个字符1个Token
+const (
+	cjkTokenWeight   = 0.6
+	otherTokenWeight = 0.25
+)
+
+// truncateEllipsis 截断时插入的省略标记
+const truncateEllipsis = "\n...\n"
+
+// estimateTokens 估算Token数量（按rune计数，区分中英文权重）
 func (pb *PromptBuilder) estimateTokens(text string) int {
+	return estimateTextTokens(text)
+}
+
+// estimateTextTokens 估算文本Token数量，供Prompt构造和请求大小校验共用
+func estimateTextTokens(text string) int {
 	if text == "" {
 		return 0
 	}
-	return len(text) / 4
+
+	var tokens float64
+	for _, r := range text {
+		if isCJK(r) {
+			tokens += cjkTokenWeight
+		} else {
+			tokens += otherTokenWeight
+		}
+	}
+
+	if tokens < 1 {
+		return 1
+	}
+	return int(tokens + 0.5)
+}
+
+// isCJK 判断是否为中日韩文字或全角标点
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		(r >= 0x3000 && r <= 0x303F) ||
+		(r >= 0xFF00 && r <= 0xFFEF)
+}
+
+// isSentenceBoundary 判断是否为自然截断边界（句末标点或换行）
+func isSentenceBoundary(r rune) bool {
+	switch r {
+	case '。', '！', '？', '；', '\n', '.', '!', '?', ';':
+		return true
+	}
+	return false
+}
+
+// truncateHead 保留前limit个rune，并尽量在句子边界处截断
+func truncateHead(runes []rune, limit int) []rune {
+	if limit >= len(runes) {
+		return runes
+	}
+	if limit <= 0 {
+		return nil
+	}
+	// 只在后半段内回退寻找边界，避免丢失过多内容
+	for i := limit - 1; i >= limit/2; i-- {
+		if isSentenceBoundary(runes[i]) {
+			return runes[:i+1]
+		}
+	}
+	return runes[:limit]
+}
+
+// truncateTail 保留末尾limit个rune，并尽量从句子边界之后开始
+func truncateTail(runes []rune, limit int) []rune {
+	if limit >= len(runes) {
+		return runes
+	}
+	if limit <= 0 {
+		return nil
+	}
+	start := len(runes) - limit
+	// 只在前半段内前移寻找边界，避免丢失过多内容
+	for i := start; i < start+limit/2; i++ {
+		if isSentenceBoundary(runes[i]) {
+			return runes[i+1:]
+		}
+	}
+	return runes[start:]
+}
+
+// truncateContent 按rune截断内容，保留头尾并在自然边界处截断
+func truncateContent(content string, maxRunes int) string {
+	runes := []rune(content)
+	if len(runes) <= maxRunes {
+		return content
+	}
+
+	// 头部保留约2/3，尾部保留约1/3
+	headLimit := maxRunes * 2 / 3
+	tailLimit := maxRunes - headLimit
+
+	head := truncateHead(runes, headLimit)
+	tail := truncateTail(runes, tailLimit)
+
+	return string(head) + truncateEllipsis + string(tail)
 }
 
 // FormatDocuments 格式化文档列表
@@ -311,17 +639,31 @@ func (pb *PromptBuilder) FormatChunks(chunks []*DocumentChunk) string {
 	return builder.String()
 }
 
-// FormatReimbursementInfo 格式化报销信息
+// FormatReimbursementInfo 格式化报销信息，发票明细数组按[发票N]编号逐张列出在汇总信息之后
 func (pb *PromptBuilder) FormatReimbursementInfo(info map[string]interface{}) string {
 	if len(info) == 0 {
 		return "无报销信息"
 	}
 
+	invoices, hasInvoices := info[ReimbursementInvoicesKey]
+	if hasInvoices {
+		summary := make(map[string]interface{}, len(info)-1)
+		for key, value := range info {
+			if key != ReimbursementInvoicesKey {
+				summary[key] = value
+			}
+		}
+		info = summary
+	}
+
 	jsonBytes, err := json.MarshalIndent(info, "", "  ")
 	if err != nil {
 		pb.logger.Error("序列化报销信息失败", logger.NewField("error", err))
 		return "无法格式化报销信息"
 	}
+	if details := pb.formatInvoiceDetails(invoices); details != "" {
+		return string(jsonBytes) + "\n\n" + details
+	}
 	return string(jsonBytes)
 }
 
@@ -384,14 +726,19 @@ func (pb *PromptBuilder) OptimizePrompt(prompt *Prompt, maxTokens int) (*Prompt,
 	}
 
 	ratio := float64(maxTokens) / float64(prompt.Tokens)
-	newLength := int(float64(len(prompt.Content)) * ratio * 0.9)
+	newLength := int(float64(len([]rune(prompt.Content))) * ratio * 0.9)
 
 	if newLength < 100 {
 		pb.logger.Error("优化后的Prompt太短", logger.NewField("new_length", newLength))
 		return nil, errors.New("优化后的Prompt太短")
 	}
 
-	optimizedContent := prompt.Content[:newLength] + "..."
+	optimizedContent := truncateContent(prompt.Content, newLength)
+	// 中英文混排时按比例估算可能仍超出限制，逐步收缩直至满足
+	for pb.estimateTokens(optimizedContent) > maxTokens && newLength >= 100 {
+		newLength = newLength * 9 / 10
+		optimizedContent = truncateContent(prompt.Content, newLength)
+	}
 
 	optimizedPrompt := &Prompt{
 		ID:        prompt.ID,
@@ -417,18 +764,24 @@ func generatePromptID() string {
 
 // GetSystemTemplate 获取系统模板
 func (pb *PromptBuilder) GetSystemTemplate(name string) (string, bool) {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
 	template, ok := pb.systemTemplates[name]
 	return template, ok
 }
 
 // GetUserTemplate 获取用户模板
 func (pb *PromptBuilder) GetUserTemplate(name string) (string, bool) {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
 	template, ok := pb.userTemplates[name]
 	return template, ok
 }
 
 // ListSystemTemplates 列出所有系统模板
 func (pb *PromptBuilder) ListSystemTemplates() []string {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
 	templates := make([]string, 0, len(pb.systemTemplates))
 	for name := range pb.systemTemplates {
 		templates = append(templates, name)
@@ -438,6 +791,8 @@ func (pb *PromptBuilder) ListSystemTemplates() []string {
 
 // ListUserTemplates 列出所有用户模板
 func (pb *PromptBuilder) ListUserTemplates() []string {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
 	templates := make([]string, 0, len(pb.userTemplates))
 	for name := range pb.userTemplates {
 		templates = append(templates, name)
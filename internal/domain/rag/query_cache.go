@@ -0,0 +1,201 @@
+// query_cache.go RAG查询结果缓存
+// 功能点：
+// 1. 定义查询结果缓存接口，支持按类别失效
+// 2. 提供带过期时间和容量上限的内存LRU缓存实现，超出容量时淘汰最久未访问的缓存项
+// 3. 导入、删除制度文档时使相关缓存失效，保证新制度立即生效
+// 4. 缓存键使用归一化后的查询文本，全半角、大小写、多余空白和句末标点不同的同一问题命中同一缓存
+
+package rag
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 查询结果缓存默认配置
+const (
+	DefaultQueryCacheTTL  = 10 * time.Minute
+	DefaultQueryCacheSize = 1000
+)
+
+// QueryCache 查询结果缓存接口
+type QueryCache interface {
+	// Get 获取缓存的查询结果
+	Get(key string) (*RAGResult, bool)
+
+	// Set 缓存查询结果，category为结果依赖的制度类别，为空表示依赖全部类别
+	Set(key, category string, result *RAGResult)
+
+	// Invalidate 使依赖指定类别的缓存失效，category为空时清空全部缓存，返回失效的条数
+	Invalidate(category string) int
+}
+
+// queryCacheEntry 查询结果缓存项
+type queryCacheEntry struct {
+	key       string
+	result    *RAGResult
+	category  string
+	expiresAt time.Time
+}
+
+// MemoryQueryCache 内存LRU查询结果缓存
+type MemoryQueryCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // 按访问时间排序，表头为最近访问
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+}
+
+// NewMemoryQueryCache 创建内存查询结果缓存，非正数参数使用默认值
+func NewMemoryQueryCache(ttl time.Duration, maxSize int) *MemoryQueryCache {
+	if ttl <= 0 {
+		ttl = DefaultQueryCacheTTL
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultQueryCacheSize
+	}
+	return &MemoryQueryCache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		ttl:     ttl,
+		maxSize: maxSize,
+		now:     time.Now,
+	}
+}
+
+// Get 获取缓存的查询结果，过期的缓存项视为未命中，命中时标记为最近访问
+func (c *MemoryQueryCache) Get(key string) (*RAGResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*queryCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.result, true
+}
+
+// Set 缓存查询结果，超出容量时淘汰最久未访问的缓存项
+func (c *MemoryQueryCache) Set(key, category string, result *RAGResult) {
+	if result == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &queryCacheEntry{
+		key:       key,
+		result:    result,
+		category:  category,
+		expiresAt: c.now().Add(c.ttl),
+	}
+	if element, exists := c.entries[key]; exists {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	for c.lru.Len() >= c.maxSize {
+		c.remove(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+}
+
+// Invalidate 使依赖指定类别的缓存失效
+// 依赖全部类别的缓存项（category为空）在任意类别变更时都会失效
+func (c *MemoryQueryCache) Invalidate(category string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if category == "" {
+		count := c.lru.Len()
+		c.entries = make(map[string]*list.Element)
+		c.lru.Init()
+		return count
+	}
+
+	count := 0
+	for _, element := range c.entries {
+		entry := element.Value.(*queryCacheEntry)
+		if entry.category == "" || entry.category == category {
+			c.remove(element)
+			count++
+		}
+	}
+	return count
+}
+
+// remove 删除缓存项
+func (c *MemoryQueryCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*queryCacheEntry).key)
+}
+
+// queryCacheKey 生成查询结果缓存键
+func queryCacheKey(query, language string, topK int, format OutputFormat) string {
+	return fmt.Sprintf("%s|%s|%d|%s", normalizeQueryText(query), language, topK, format)
+}
+
+// normalizeQueryText 归一化查询文本：全角字符转半角、英文转小写、去除句末标点
+// 连续空白合并为一个空格，与中文相邻的空白直接去除
+func normalizeQueryText(query string) string {
+	var builder strings.Builder
+	space := false
+	var last rune
+	for _, r := range query {
+		switch {
+		case r == '\u3000':
+			r = ' '
+		case r >= '\uff01' && r <= '\uff5e':
+			r -= 0xfee0
+		}
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space && builder.Len() > 0 && !unicode.Is(unicode.Han, last) && !unicode.Is(unicode.Han, r) {
+			builder.WriteByte(' ')
+		}
+		space = false
+		last = r
+		builder.WriteRune(unicode.ToLower(r))
+	}
+	return strings.TrimRight(builder.String(), "?!.。…~")
+}
+
+// documentCategory 获取文档类别，未设置时返回空
+func documentCategory(document *Document) string {
+	if document == nil || document.Metadata == nil {
+		return ""
+	}
+	return document.Metadata.Category
+}
+
+// vectorCategories 获取向量涉及的类别（去重），没有向量时返回空类别
+func vectorCategories(vectors []*Vector) []string {
+	seen := make(map[string]bool)
+	categories := make([]string, 0, 1)
+	for _, vector := range vectors {
+		if !seen[vector.Category] {
+			seen[vector.Category] = true
+			categories = append(categories, vector.Category)
+		}
+	}
+	if len(categories) == 0 {
+		categories = append(categories, "")
+	}
+	return categories
+}
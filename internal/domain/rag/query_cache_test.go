@@ -0,0 +1,125 @@
+package rag
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryQueryCacheInvalidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		category  string
+		wantCount int
+		wantKeys  []string
+	}{
+		{name: "按类别失效同时清除依赖全部类别的缓存", category: "差旅", wantCount: 2, wantKeys: []string{"hotel"}},
+		{name: "类别为空时清空全部", category: "", wantCount: 3},
+		{name: "无匹配类别时只清除依赖全部类别的缓存", category: "办公", wantCount: 1, wantKeys: []string{"travel", "hotel"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMemoryQueryCache(time.Minute, 10)
+			cache.Set("travel", "差旅", &RAGResult{})
+			cache.Set("hotel", "住宿", &RAGResult{})
+			cache.Set("all", "", &RAGResult{})
+
+			if got := cache.Invalidate(tt.category); got != tt.wantCount {
+				t.Fatalf("Invalidate(%q) = %d, want %d", tt.category, got, tt.wantCount)
+			}
+			remaining := map[string]bool{}
+			for _, key := range tt.wantKeys {
+				remaining[key] = true
+			}
+			for _, key := range []string{"travel", "hotel", "all"} {
+				if _, ok := cache.Get(key); ok != remaining[key] {
+					t.Errorf("Get(%q) 命中 = %v, want %v", key, ok, remaining[key])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryQueryCacheExpiryAndEviction(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewMemoryQueryCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", "", &RAGResult{})
+	cache.Set("b", "", &RAGResult{})
+	cache.Get("a")
+	cache.Set("c", "", &RAGResult{})
+	cache.Set("nil", "", nil)
+
+	tests := []struct {
+		name    string
+		key     string
+		advance time.Duration
+		wantHit bool
+	}{
+		{name: "最近访问的缓存保留", key: "a", wantHit: true},
+		{name: "超出容量淘汰最久未访问的缓存", key: "b", wantHit: false},
+		{name: "新写入的缓存命中", key: "c", wantHit: true},
+		{name: "nil结果不缓存", key: "nil", wantHit: false},
+		{name: "过期后未命中", key: "c", advance: 2 * time.Minute, wantHit: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if _, ok := cache.Get(tt.key); ok != tt.wantHit {
+				t.Errorf("Get(%q) 命中 = %v, want %v", tt.key, ok, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestNormalizeQueryText(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "去除句末标点", query: "住宿标准是多少？", want: "住宿标准是多少"},
+		{name: "全角转半角并转小写", query: "ＴＡＸＩ费用", want: "taxi费用"},
+		{name: "合并英文间的连续空白", query: "hotel   \t policy", want: "hotel policy"},
+		{name: "去除与中文相邻的空白", query: " 差旅 标准 ", want: "差旅标准"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeQueryText(tt.query); got != tt.want {
+				t.Errorf("normalizeQueryText(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVectorCategories(t *testing.T) {
+	tests := []struct {
+		name    string
+		vectors []*Vector
+		want    []string
+	}{
+		{name: "无向量返回空类别", want: []string{""}},
+		{
+			name:    "按出现顺序去重",
+			vectors: []*Vector{{Category: "差旅"}, {Category: "住宿"}, {Category: "差旅"}},
+			want:    []string{"差旅", "住宿"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := vectorCategories(tt.vectors)
+			if len(got) != len(tt.want) {
+				t.Fatalf("vectorCategories() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("vectorCategories() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// redis_embedding_cache.go 基于Redis的向量嵌入缓存
+// 功能点：
+// 1. 向量序列化为JSON写入Redis并设置过期时间，多个服务实例和服务重启后共享缓存
+// 2. Redis不可用或数据无法解析时记录日志并视为未命中，回退为调用向量生成接口
+
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis向量缓存配置
+const (
+	DefaultEmbeddingCacheTTL   = 7 * 24 * time.Hour // 向量缓存默认过期时间
+	redisEmbeddingCachePrefix  = "rag:embedding:"   // 向量缓存键前缀
+	redisEmbeddingCacheTimeout = 2 * time.Second    // 单次Redis操作超时时间
+)
+
+// RedisEmbeddingCache 基于Redis的向量嵌入缓存
+type RedisEmbeddingCache struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+// NewRedisEmbeddingCache 创建基于Redis的向量缓存，ttl非正数时使用默认值
+func NewRedisEmbeddingCache(client redis.UniversalClient, ttl time.Duration, log logger.Logger) *RedisEmbeddingCache {
+	if ttl <= 0 {
+		ttl = DefaultEmbeddingCacheTTL
+	}
+	return &RedisEmbeddingCache{
+		client: client,
+		ttl:    ttl,
+		logger: log,
+	}
+}
+
+// Get 获取缓存的向量，Redis不可用或数据无法解析时视为未命中
+func (c *RedisEmbeddingCache) Get(key string) ([]float64, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisEmbeddingCacheTimeout)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, redisEmbeddingCachePrefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			c.logger.Warn("读取向量缓存失败", logger.NewField("error", err))
+		}
+		return nil, false
+	}
+
+	var embedding []float64
+	if err := json.Unmarshal(data, &embedding); err != nil || len(embedding) == 0 {
+		c.logger.Warn("解析向量缓存失败", logger.NewField("error", err))
+		return nil, false
+	}
+	return embedding, true
+}
+
+// Set 缓存向量
+func (c *RedisEmbeddingCache) Set(key string, embedding []float64) {
+	if len(embedding) == 0 {
+		return
+	}
+	data, err := json.Marshal(embedding)
+	if err != nil {
+		c.logger.Warn("序列化向量缓存失败", logger.NewField("error", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisEmbeddingCacheTimeout)
+	defer cancel()
+	if err := c.client.Set(ctx, redisEmbeddingCachePrefix+key, data, c.ttl).Err(); err != nil {
+		c.logger.Warn("写入向量缓存失败", logger.NewField("error", err))
+	}
+}
@@ -0,0 +1,171 @@
+// redis_query_cache.go 基于Redis的RAG查询结果缓存
+// 功能点：
+// 1. 查询结果序列化为JSON写入Redis并设置过期时间，多个服务实例共享缓存
+// 2. 按制度类别维护缓存键索引，制度文档变更时按类别使缓存失效
+// 3. Redis不可用时记录日志并视为未命中，不影响查询
+
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis查询缓存键
+const (
+	redisQueryCachePrefix      = "rag:query:"           // 查询缓存键前缀
+	redisQueryResultPrefix     = "rag:query:result:"    // 查询结果键前缀
+	redisQueryCategoryPrefix   = "rag:query:category:"  // 类别索引键前缀
+	redisQueryAllCategoriesKey = "rag:query:category:*" // 依赖全部类别的查询结果索引
+	redisQueryCacheTimeout     = 2 * time.Second        // 单次Redis操作超时时间
+	redisQueryScanCount        = 100                    // 清空缓存时每次扫描的键数
+)
+
+// RedisQueryCache 基于Redis的查询结果缓存
+type RedisQueryCache struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+// NewRedisQueryCache 创建基于Redis的查询结果缓存，ttl非正数时使用默认值
+func NewRedisQueryCache(client redis.UniversalClient, ttl time.Duration, log logger.Logger) *RedisQueryCache {
+	if ttl <= 0 {
+		ttl = DefaultQueryCacheTTL
+	}
+	return &RedisQueryCache{
+		client: client,
+		ttl:    ttl,
+		logger: log,
+	}
+}
+
+// Get 获取缓存的查询结果，Redis不可用或数据无法解析时视为未命中
+func (c *RedisQueryCache) Get(key string) (*RAGResult, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryCacheTimeout)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, redisResultKey(key)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			c.logger.Warn("读取查询缓存失败", logger.NewField("error", err))
+		}
+		return nil, false
+	}
+
+	var result RAGResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		c.logger.Warn("解析查询缓存失败", logger.NewField("error", err))
+		return nil, false
+	}
+	return &result, true
+}
+
+// Set 缓存查询结果，并把缓存键登记到类别索引
+func (c *RedisQueryCache) Set(key, category string, result *RAGResult) {
+	if result == nil {
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		c.logger.Warn("序列化查询缓存失败", logger.NewField("error", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryCacheTimeout)
+	defer cancel()
+
+	resultKey := redisResultKey(key)
+	indexKey := redisCategoryKey(category)
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, resultKey, data, c.ttl)
+		pipe.SAdd(ctx, indexKey, resultKey)
+		// 索引随最近写入的缓存项续期，过期后残留的索引成员在失效时一并删除
+		pipe.Expire(ctx, indexKey, c.ttl)
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn("写入查询缓存失败", logger.NewField("error", err))
+	}
+}
+
+// Invalidate 使依赖指定类别和依赖全部类别的缓存失效，category为空时清空全部缓存
+func (c *RedisQueryCache) Invalidate(category string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryCacheTimeout)
+	defer cancel()
+
+	if category == "" {
+		return c.invalidateAll(ctx)
+	}
+
+	indexKeys := []string{redisCategoryKey(category), redisQueryAllCategoriesKey}
+	resultKeys, err := c.client.SUnion(ctx, indexKeys...).Result()
+	if err != nil {
+		c.logger.Warn("读取查询缓存索引失败", logger.NewField("category", category), logger.NewField("error", err))
+		return 0
+	}
+
+	count := 0
+	if len(resultKeys) > 0 {
+		deleted, err := c.client.Del(ctx, resultKeys...).Result()
+		if err != nil {
+			c.logger.Warn("删除查询缓存失败", logger.NewField("category", category), logger.NewField("error", err))
+			return 0
+		}
+		count = int(deleted)
+	}
+	if err := c.client.Del(ctx, indexKeys...).Err(); err != nil {
+		c.logger.Warn("删除查询缓存索引失败", logger.NewField("category", category), logger.NewField("error", err))
+	}
+	return count
+}
+
+// invalidateAll 清空全部查询缓存和类别索引，返回删除的查询结果条数
+func (c *RedisQueryCache) invalidateAll(ctx context.Context) int {
+	count := 0
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, redisQueryCachePrefix+"*", redisQueryScanCount).Result()
+		if err != nil {
+			c.logger.Warn("扫描查询缓存失败", logger.NewField("error", err))
+			return count
+		}
+		if len(keys) > 0 {
+			if err := c.client.Del(ctx, keys...).Err(); err != nil {
+				c.logger.Warn("删除查询缓存失败", logger.NewField("error", err))
+				return count
+			}
+			for _, key := range keys {
+				if strings.HasPrefix(key, redisQueryResultPrefix) {
+					count++
+				}
+			}
+		}
+		if next == 0 {
+			return count
+		}
+		cursor = next
+	}
+}
+
+// redisResultKey 生成查询结果的Redis键，查询文本取哈希避免键过长
+func redisResultKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return redisQueryResultPrefix + hex.EncodeToString(sum[:])
+}
+
+// redisCategoryKey 生成类别索引的Redis键，类别为空表示依赖全部类别
+func redisCategoryKey(category string) string {
+	if category == "" {
+		return redisQueryAllCategoriesKey
+	}
+	return redisQueryCategoryPrefix + category
+}
@@ -0,0 +1,17 @@
+// repository.go RAG仓储接口
+// 功能点：
+// 1. 定义Prompt模板仓储接口
+// 2. 支持从数据库加载模板
+
+package rag
+
+import "context"
+
+// PromptTemplateRepository Prompt模板仓储接口
+type PromptTemplateRepository interface {
+	// ListPromptTemplates 获取所有启用的Prompt模板
+	ListPromptTemplates(ctx context.Context) ([]*PromptTemplate, error)
+
+	// SavePromptTemplate 保存Prompt模板（存在则更新）
+	SavePromptTemplate(ctx context.Context, tpl *PromptTemplate) error
+}
@@ -6,12 +6,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reimbursement-audit/internal/pkg/logger"
+	"reimbursement-audit/internal/pkg/utils"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultIngestConcurrency 默认批量导入文档并发数
+const DefaultIngestConcurrency = 4
+
 // RAGService RAG服务结构体
 type RAGService struct {
 	logger            logger.Logger
@@ -19,6 +26,14 @@ type RAGService struct {
 	documentProcessor *DocumentProcessor
 	vectorStore       *VectorStore
 	promptBuilder     *PromptBuilder
+	ingestConcurrency int
+	languageBoost     float64
+	queryCache        QueryCache
+	embeddingFields   EmbeddingFieldPolicy
+	indexPolicy       VectorIndexPolicy
+	chunkClassifier   CategoryClassifier
+	ingestedChunks    atomic.Int64 // 上次重建向量索引后累计导入的分片数
+	indexRebuilding   atomic.Bool  // 是否正在后台重建向量索引
 }
 
 // NewRAGService 创建RAG服务实例
@@ -29,11 +44,45 @@ func NewRAGService(log logger.Logger, llmClient *LLMClient, documentProcessor *D
 		documentProcessor: documentProcessor,
 		vectorStore:       vectorStore,
 		promptBuilder:     promptBuilder,
+		languageBoost:     DefaultLanguageBoost,
+		ingestConcurrency: DefaultIngestConcurrency,
+		indexPolicy:       VectorIndexPolicy{VectorSchemaOptions: VectorSchemaOptions{IndexName: DefaultVectorIndexName}},
+		chunkClassifier:   NewKeywordCategoryClassifier(nil),
+	}
+}
+
+// SetIngestConcurrency 设置批量导入文档并发数
+// 向量生成的总并发由LLMClient的全局配额控制，与单文档导入共享
+func (rs *RAGService) SetIngestConcurrency(concurrency int) {
+	if concurrency <= 0 {
+		concurrency = DefaultIngestConcurrency
+	}
+	rs.ingestConcurrency = concurrency
+}
+
+// SetLanguageBoost 设置与查询语言相同的分片加权分值，非正数时使用默认值
+func (rs *RAGService) SetLanguageBoost(boost float64) {
+	if boost <= 0 {
+		boost = DefaultLanguageBoost
 	}
+	rs.languageBoost = boost
+}
+
+// SetQueryCache 设置查询结果缓存，为nil时不缓存
+// 导入或删除制度文档时按类别使缓存失效
+func (rs *RAGService) SetQueryCache(cache QueryCache) {
+	rs.queryCache = cache
 }
 
 // Query 查询报销政策（RAG查询）
-func (rs *RAGService) Query(ctx context.Context, query string, topK int) (*RAGResult, error) {
+// format指定输出格式（markdown/plain/json），为空时默认markdown
+func (rs *RAGService) Query(ctx context.Context, query string, topK int, format OutputFormat) (*RAGResult, error) {
+	return rs.QueryWithLanguage(ctx, query, "", topK, format)
+}
+
+// QueryWithLanguage 按指定语言查询报销政策，language为空时根据查询内容检测
+// 与查询语言相同的制度分片优先返回
+func (rs *RAGService) QueryWithLanguage(ctx context.Context, query, language string, topK int, format OutputFormat) (*RAGResult, error) {
 	startTime := time.Now()
 
 	if query == "" {
@@ -41,21 +90,38 @@ func (rs *RAGService) Query(ctx context.Context, query string, topK int) (*RAGRe
 		return nil, errors.New("查询内容不能为空")
 	}
 
+	format, err := ParseOutputFormat(string(format))
+	if err != nil {
+		rs.logger.Error("输出格式无效", logger.NewField("format", format), logger.NewField("error", err))
+		return nil, err
+	}
+
 	if topK <= 0 {
 		topK = 5
 	}
 
+	language = resolveQueryLanguage(query, language)
+	cacheKey := queryCacheKey(query, language, topK, format)
+	if rs.queryCache != nil {
+		if cached, ok := rs.queryCache.Get(cacheKey); ok {
+			result := *cached
+			result.Cached = true
+			return &result, nil
+		}
+	}
+
 	embedding, err := rs.llmClient.GenerateEmbedding(ctx, query)
 	if err != nil {
 		rs.logger.Error("生成查询向量失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("生成查询向量失败")
+		return nil, fmt.Errorf("生成查询向量失败: %w", err)
 	}
 
-	searchResults, err := rs.vectorStore.SearchVector(ctx, embedding, topK)
+	searchResults, err := rs.vectorStore.SearchVector(ctx, embedding, topK*2)
 	if err != nil {
 		rs.logger.Error("搜索相关文档失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("搜索相关文档失败")
+		return nil, searchError("搜索相关文档失败", err)
 	}
+	searchResults = applyLanguageBoost(searchResults, language, rs.languageBoost, topK)
 
 	if len(searchResults) == 0 {
 		rs.logger.Error("未找到相关文档", logger.NewField("query", query))
@@ -76,13 +142,14 @@ func (rs *RAGService) Query(ctx context.Context, query string, topK int) (*RAGRe
 		rs.logger.Error("构造系统提示词失败", logger.NewField("error", err))
 		return nil, errors.New("构造系统提示词失败")
 	}
+	systemPrompt += "\n" + formatInstruction(format)
 
 	messages := rs.promptBuilder.BuildConversationMessages(systemPrompt, prompt.Content)
 
 	llmResponse, err := rs.llmClient.Chat(ctx, rs.convertToChatMessages(messages), 0.7, 2000)
 	if err != nil {
 		rs.logger.Error("调用大模型失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("调用大模型失败")
+		return nil, fmt.Errorf("调用大模型失败: %w", err)
 	}
 
 	if err := rs.validateLLMResponse(llmResponse); err != nil {
@@ -92,6 +159,12 @@ func (rs *RAGService) Query(ctx context.Context, query string, topK int) (*RAGRe
 
 	analysisResult := rs.parseAnalysisResult(query, llmResponse, searchResults)
 
+	output, answer, err := FormatAnswer(llmResponse.Choices[0].Message.Content, format, searchResults)
+	if err != nil {
+		rs.logger.Error("格式化查询结果失败", logger.NewField("query", query), logger.NewField("error", err))
+		return nil, errors.New("格式化查询结果失败")
+	}
+
 	ragResult := &RAGResult{
 		Query:          query,
 		Documents:      documents,
@@ -99,10 +172,18 @@ func (rs *RAGService) Query(ctx context.Context, query string, topK int) (*RAGRe
 		Prompt:         prompt.Content,
 		Response:       rs.convertToLLMResponse(llmResponse),
 		AnalysisResult: analysisResult,
+		Format:         format,
+		Output:         output,
+		Answer:         answer,
 		ExecutionTime:  time.Since(startTime).Milliseconds(),
 		CreatedAt:      time.Now(),
 	}
 
+	// 查询不按类别过滤，结果依赖全部类别的制度
+	if rs.queryCache != nil {
+		rs.queryCache.Set(cacheKey, "", ragResult)
+	}
+
 	return ragResult, nil
 }
 
@@ -126,22 +207,23 @@ func (rs *RAGService) AuditReimbursement(ctx context.Context, reimbursementInfo
 	embedding, err := rs.llmClient.GenerateEmbedding(ctx, query)
 	if err != nil {
 		rs.logger.Error("生成查询向量失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("生成查询向量失败")
+		return nil, fmt.Errorf("生成查询向量失败: %w", err)
 	}
 
-	// 步骤4：混合检索 → 向量检索+关键词检索，提升检索准确度
+	// 步骤4：混合检索 → 向量检索+关键词检索，提升检索准确度；按报销类别限定制度文档范围
 	keywords := rs.extractReimbursementKeywords(reimbursementInfo)
-	searchResults, err := rs.vectorStore.HybridSearch(ctx, embedding, keywords, topK)
+	searchResults, err := rs.searchByReimbursementCategory(ctx, embedding, keywords, reimbursementCategory(reimbursementInfo), topK*2)
 	if err != nil {
 		rs.logger.Error("混合检索失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("混合检索失败")
+		return nil, searchError("混合检索失败", err)
 	}
+	searchResults = applyLanguageBoost(searchResults, DetectLanguage(query), rs.languageBoost, topK)
 
 	// 步骤5：构建Prompt → 把报销单信息+检索到的制度片段拼到Prompt里（保证AI只看自有知识库）
 	documents := rs.buildDocumentsFromSearchResults(searchResults)
 
 	reimbursementInfoJSON := rs.promptBuilder.FormatReimbursementInfo(reimbursementInfo)
-	prompt, err := rs.promptBuilder.BuildAuditPrompt(ctx, reimbursementInfoJSON, documents)
+	prompt, err := rs.promptBuilder.BuildAuditPrompt(ctx, reimbursementInfoJSON, documents, newCitations(searchResults, 0))
 	if err != nil {
 		rs.logger.Error("构造提示词失败", logger.NewField("error", err))
 		return nil, errors.New("构造提示词失败")
@@ -159,7 +241,7 @@ func (rs *RAGService) AuditReimbursement(ctx context.Context, reimbursementInfo
 	llmResponse, err := rs.llmClient.Chat(ctx, rs.convertToChatMessages(messages), 0.7, 2000)
 	if err != nil {
 		rs.logger.Error("调用大模型失败", logger.NewField("error", err))
-		return nil, errors.New("调用大模型失败")
+		return nil, fmt.Errorf("调用大模型失败: %w", err)
 	}
 
 	if err := rs.validateLLMResponse(llmResponse); err != nil {
@@ -173,6 +255,7 @@ func (rs *RAGService) AuditReimbursement(ctx context.Context, reimbursementInfo
 	ragResult := &RAGResult{
 		Query:          query,
 		Documents:      documents,
+		Chunks:         rs.buildChunksFromSearchResults(searchResults),
 		Prompt:         prompt.Content,
 		Response:       rs.convertToLLMResponse(llmResponse),
 		AnalysisResult: analysisResult,
@@ -183,6 +266,39 @@ func (rs *RAGService) AuditReimbursement(ctx context.Context, reimbursementInfo
 	return ragResult, nil
 }
 
+// searchByReimbursementCategory 只在报销类别对应的制度文档中混合检索
+// 类别缺失或该类别下没有制度文档时回退到全库检索
+func (rs *RAGService) searchByReimbursementCategory(ctx context.Context, embedding []float64, keywords []string, category string, topK int) ([]*VectorSearchResult, error) {
+	if category == "" {
+		return rs.vectorStore.HybridSearch(ctx, embedding, keywords, topK, DefaultKeywordWeight)
+	}
+
+	results, err := rs.vectorStore.HybridSearchByCategory(ctx, embedding, keywords, category, topK, DefaultKeywordWeight)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	rs.logger.Info("报销类别下没有制度文档，回退到全库检索", logger.NewField("category", category))
+	return rs.vectorStore.HybridSearch(ctx, embedding, keywords, topK, DefaultKeywordWeight)
+}
+
+// searchError 包装检索错误，查询向量不合法时保留原因以便调用方识别，其它错误不暴露存储层细节
+func searchError(message string, err error) error {
+	if errors.Is(err, ErrInvalidQueryVector) {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	return errors.New(message)
+}
+
+// reimbursementCategory 获取报销信息中的类别
+func reimbursementCategory(info map[string]interface{}) string {
+	category, _ := info["category"].(string)
+	return strings.TrimSpace(category)
+}
+
 // IngestDocument 导入文档到RAG系统  解析→分片→向量化→存储
 func (rs *RAGService) IngestDocument(ctx context.Context, documentPath string) (*Document, error) {
 	document, err := rs.documentProcessor.ProcessDocument(ctx, documentPath)
@@ -190,64 +306,251 @@ func (rs *RAGService) IngestDocument(ctx context.Context, documentPath string) (
 		rs.logger.Error("处理文档失败", logger.NewField("document_path", documentPath), logger.NewField("error", err))
 		return nil, errors.New("处理文档失败")
 	}
+	rs.assignChunkCategories(ctx, document)
 
-	for _, chunk := range document.Chunks {
-		embedding, err := rs.llmClient.GenerateEmbedding(ctx, chunk.Content)
-		if err != nil {
-			rs.logger.Error("生成向量失败", logger.NewField("document_id", document.ID), logger.NewField("error", err))
-			return nil, errors.New("生成向量失败")
-		}
+	if err := rs.embedChunks(ctx, document.Chunks); err != nil {
+		rs.logger.Error("生成向量失败", logger.NewField("document_id", document.ID), logger.NewField("error", err))
+		return nil, errors.New("生成向量失败")
+	}
 
-		chunk.Vector = embedding
+	if err := rs.storeDocumentVectors(ctx, document); err != nil {
+		return nil, err
+	}
+
+	for _, category := range chunkCategories(document) {
+		rs.invalidateQueryCache(category)
+	}
+	rs.recordIngestedChunks(ctx, len(document.Chunks))
+
+	return document, nil
+}
 
-		err = rs.vectorStore.StoreVector(ctx, &Vector{
-			ID:         generateVectorID(),
-			DocumentID: document.ID,
-			ChunkID:    chunk.ID,
-			Values:     embedding,
-			Dimension:  len(embedding),
+// embedChunks 批量生成分片向量并回填到分片
+func (rs *RAGService) embedChunks(ctx context.Context, chunks []*DocumentChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Content
+	}
+
+	embeddings, err := rs.llmClient.BatchGenerateEmbeddings(ctx, texts)
+	if err != nil {
+		return err
+	}
+
+	for i, chunk := range chunks {
+		chunk.Vector = embeddings[i]
+	}
+	return nil
+}
+
+// storeDocumentVectors 存储文档各分片的向量，先删除文档的旧向量再写入，失败时整体回滚
+// 重复导入同一文档时旧分片的类别一并使查询缓存失效
+func (rs *RAGService) storeDocumentVectors(ctx context.Context, document *Document) error {
+	vectors := make([]*Vector, 0, len(document.Chunks))
+	for _, chunk := range document.Chunks {
+		vectors = append(vectors, &Vector{
+			ID:           chunk.ID,
+			DocumentID:   document.ID,
+			ChunkID:      chunk.ID,
+			ChunkIndex:   chunk.Index,
+			ChunkContent: chunk.Content,
+			Values:       chunk.Vector,
+			Dimension:    len(chunk.Vector),
+			Category:     chunk.Category,
+			Language:     chunkLanguage(document, chunk),
 			Metadata: map[string]interface{}{
 				"document_title": document.Title,
-				"chunk_index":    chunk.StartPos,
+				"chunk_index":    chunk.Index,
 			},
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		})
-		if err != nil {
-			rs.logger.Error("存储向量失败", logger.NewField("document_id", document.ID), logger.NewField("error", err))
-			return nil, errors.New("存储向量失败")
-		}
 	}
 
-	return document, nil
+	replacedCategories, err := rs.vectorStore.ReplaceDocumentVectors(ctx, document.ID, vectors)
+	if err != nil {
+		rs.logger.Error("存储向量失败", logger.NewField("document_id", document.ID), logger.NewField("error", err))
+		return errors.New("存储向量失败")
+	}
+	for _, category := range replacedCategories {
+		rs.invalidateQueryCache(category)
+	}
+	return nil
 }
 
 // BatchIngestDocuments 批量导入文档
+// 返回导入成功的文档（按输入顺序），存在失败时同时返回错误
 func (rs *RAGService) BatchIngestDocuments(ctx context.Context, documentPaths []string) ([]*Document, error) {
+	results, err := rs.BatchIngestDocumentsWithResults(ctx, documentPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]*Document, 0, len(results))
+	failedCount := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failedCount++
+			continue
+		}
+		documents = append(documents, result.Document)
+	}
+
+	if failedCount > 0 {
+		rs.logger.Error("部分文档导入失败", logger.NewField("error_count", failedCount))
+		return documents, fmt.Errorf("部分文档导入失败: %d/%d", failedCount, len(results))
+	}
+
+	return documents, nil
+}
+
+// BatchIngestDocumentsWithResults 并发批量导入文档并返回每个文件的导入结果
+// 解析与存储的并发数由ingestConcurrency限制；向量生成时跨文档合并分片，
+// 按LLMClient的最大批次凑满后统一请求，再将结果分发回各文档分片。结果顺序与输入路径顺序一致
+func (rs *RAGService) BatchIngestDocumentsWithResults(ctx context.Context, documentPaths []string) ([]*IngestResult, error) {
 	if len(documentPaths) == 0 {
 		rs.logger.Error("文档路径列表不能为空")
 		return nil, errors.New("文档路径列表不能为空")
 	}
 
-	documents := make([]*Document, 0, len(documentPaths))
-	errorList := make([]error, 0)
+	startTime := time.Now()
+	results := make([]*IngestResult, len(documentPaths))
+	for i, path := range documentPaths {
+		results[i] = &IngestResult{Path: path}
+	}
 
-	for _, path := range documentPaths {
-		document, err := rs.IngestDocument(ctx, path)
+	// 阶段一：并发解析、分片
+	documents := make([]*Document, len(documentPaths))
+	rs.forEachConcurrently(ctx, results, func(i int, result *IngestResult) {
+		document, err := rs.documentProcessor.ProcessDocument(ctx, result.Path)
 		if err != nil {
-			rs.logger.Error("导入文档失败", logger.NewField("path", path), logger.NewField("error", err))
-			errorList = append(errorList, err)
+			rs.logger.Error("处理文档失败", logger.NewField("document_path", result.Path), logger.NewField("error", err))
+			result.Error = errors.New("处理文档失败")
+			return
+		}
+		rs.assignChunkCategories(ctx, document)
+		documents[i] = document
+	})
+
+	// 阶段二：跨文档合并分片，按最大批次生成向量
+	rs.embedDocumentsInBatches(ctx, documents, results)
+
+	// 阶段三：并发存储向量
+	rs.forEachConcurrently(ctx, results, func(i int, result *IngestResult) {
+		if result.Error != nil || documents[i] == nil {
+			return
+		}
+		if err := rs.storeDocumentVectors(ctx, documents[i]); err != nil {
+			result.Error = err
+			return
+		}
+		result.Document = documents[i]
+	})
+
+	// 按类别使查询缓存失效，同一类别只处理一次
+	invalidated := make(map[string]bool)
+	ingestedChunks := 0
+	for _, result := range results {
+		if result.Document == nil {
 			continue
 		}
-		documents = append(documents, document)
+		ingestedChunks += len(result.Document.Chunks)
+		for _, category := range chunkCategories(result.Document) {
+			if !invalidated[category] {
+				invalidated[category] = true
+				rs.invalidateQueryCache(category)
+			}
+		}
 	}
 
-	if len(errorList) > 0 {
-		rs.logger.Error("部分文档导入失败", logger.NewField("error_count", len(errorList)))
-		return documents, errors.New("部分文档导入失败")
+	rs.recordIngestedChunks(ctx, ingestedChunks)
+
+	for _, result := range results {
+		result.Duration = time.Since(startTime).Milliseconds()
+		if result.Error != nil {
+			rs.logger.Error("导入文档失败", logger.NewField("path", result.Path), logger.NewField("error", result.Error))
+		}
 	}
 
-	return documents, nil
+	return results, nil
+}
+
+// chunkRef 分片在批量导入中的位置
+type chunkRef struct {
+	docIndex int
+	chunk    *DocumentChunk
+}
+
+// embedDocumentsInBatches 跨文档合并分片生成向量，某批失败时该批涉及的文档均标记为失败
+func (rs *RAGService) embedDocumentsInBatches(ctx context.Context, documents []*Document, results []*IngestResult) {
+	batchSize := rs.llmClient.EmbeddingBatchSize()
+
+	var refs []*chunkRef
+	for i, document := range documents {
+		if document == nil || results[i].Error != nil {
+			continue
+		}
+		for _, chunk := range document.Chunks {
+			refs = append(refs, &chunkRef{docIndex: i, chunk: chunk})
+		}
+	}
+
+	for start := 0; start < len(refs); start += batchSize {
+		end := start + batchSize
+		if end > len(refs) {
+			end = len(refs)
+		}
+
+		batch := refs[start:end]
+		chunks := make([]*DocumentChunk, len(batch))
+		for i, ref := range batch {
+			chunks[i] = ref.chunk
+		}
+
+		if err := rs.embedChunks(ctx, chunks); err != nil {
+			rs.logger.Error("批量生成向量失败", logger.NewField("chunk_count", len(chunks)), logger.NewField("error", err))
+			for _, ref := range batch {
+				results[ref.docIndex].Error = errors.New("生成向量失败")
+			}
+		}
+	}
+}
+
+// forEachConcurrently 以ingestConcurrency为上限并发处理每个导入结果，上下文取消后未开始的任务记为失败
+func (rs *RAGService) forEachConcurrently(ctx context.Context, results []*IngestResult, fn func(i int, result *IngestResult)) {
+	concurrency := rs.ingestConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultIngestConcurrency
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, result := range results {
+		if result.Error != nil {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			result.Error = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, result *IngestResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i, result)
+		}(i, result)
+	}
+
+	wg.Wait()
 }
 
 // DeleteDocument 删除文档
@@ -257,15 +560,38 @@ func (rs *RAGService) DeleteDocument(ctx context.Context, documentID string) err
 		return errors.New("文档ID不能为空")
 	}
 
+	// 删除前查询文档涉及的类别，查询失败时清空全部查询缓存
+	categories := []string{""}
+	if rs.queryCache != nil {
+		if vectors, err := rs.vectorStore.GetVectorsByDocumentID(ctx, documentID); err == nil {
+			categories = vectorCategories(vectors)
+		}
+	}
+
 	err := rs.vectorStore.DeleteVectorByDocument(ctx, documentID)
 	if err != nil {
 		rs.logger.Error("删除文档向量失败", logger.NewField("document_id", documentID), logger.NewField("error", err))
 		return errors.New("删除文档向量失败")
 	}
 
+	for _, category := range categories {
+		rs.invalidateQueryCache(category)
+	}
+
 	return nil
 }
 
+// invalidateQueryCache 制度文档变更后使对应类别的查询缓存失效
+func (rs *RAGService) invalidateQueryCache(category string) {
+	if rs.queryCache == nil {
+		return
+	}
+	count := rs.queryCache.Invalidate(category)
+	if count > 0 {
+		rs.logger.Info("制度文档变更，查询缓存已失效", logger.NewField("category", category), logger.NewField("count", count))
+	}
+}
+
 // SearchDocuments 搜索文档
 func (rs *RAGService) SearchDocuments(ctx context.Context, query string, topK int) ([]*VectorSearchResult, error) {
 	if query == "" {
@@ -280,19 +606,19 @@ func (rs *RAGService) SearchDocuments(ctx context.Context, query string, topK in
 	embedding, err := rs.llmClient.GenerateEmbedding(ctx, query)
 	if err != nil {
 		rs.logger.Error("生成查询向量失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("生成查询向量失败")
+		return nil, fmt.Errorf("生成查询向量失败: %w", err)
 	}
 
-	results, err := rs.vectorStore.SearchVector(ctx, embedding, topK)
+	results, err := rs.vectorStore.SearchVector(ctx, embedding, topK*2)
 	if err != nil {
 		rs.logger.Error("搜索文档失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("搜索文档失败")
+		return nil, searchError("搜索文档失败", err)
 	}
 
-	return results, nil
+	return applyLanguageBoost(results, DetectLanguage(query), rs.languageBoost, topK), nil
 }
 
-// HybridSearch 混合搜索（向量+关键词）
+// HybridSearch 混合搜索（向量+关键词），keywordWeight为关键词检索结果的权重，超出0-1范围时使用默认权重
 func (rs *RAGService) HybridSearch(ctx context.Context, query string, topK int, keywordWeight float64) ([]*VectorSearchResult, error) {
 	if query == "" {
 		rs.logger.Error("查询内容不能为空")
@@ -303,25 +629,23 @@ func (rs *RAGService) HybridSearch(ctx context.Context, query string, topK int,
 		topK = 5
 	}
 
-	if keywordWeight < 0 || keywordWeight > 1 {
-		keywordWeight = 0.5
-	}
+	keywordWeight = normalizeKeywordWeight(keywordWeight)
 
 	embedding, err := rs.llmClient.GenerateEmbedding(ctx, query)
 	if err != nil {
 		rs.logger.Error("生成查询向量失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("生成查询向量失败")
+		return nil, fmt.Errorf("生成查询向量失败: %w", err)
 	}
 
 	keywords := rs.extractKeywords(query)
 
-	results, err := rs.vectorStore.HybridSearch(ctx, embedding, keywords, topK)
+	results, err := rs.vectorStore.HybridSearch(ctx, embedding, keywords, topK*2, keywordWeight)
 	if err != nil {
 		rs.logger.Error("混合搜索失败", logger.NewField("query", query), logger.NewField("error", err))
-		return nil, errors.New("混合搜索失败")
+		return nil, searchError("混合搜索失败", err)
 	}
 
-	return results, nil
+	return applyLanguageBoost(results, DetectLanguage(query), rs.languageBoost, topK), nil
 }
 
 // GetStatistics 获取RAG系统统计信息
@@ -334,6 +658,16 @@ func (rs *RAGService) GetStatistics(ctx context.Context) (*VectorStoreStatistics
 	return stats, nil
 }
 
+// ListChunks 分页查询已入库的文档分片，供管理员复核知识库内容
+func (rs *RAGService) ListChunks(ctx context.Context, filter *ChunkFilter) ([]*ChunkRecord, int64, error) {
+	chunks, total, err := rs.vectorStore.ListChunks(ctx, filter)
+	if err != nil {
+		rs.logger.Error("查询文档分片失败", logger.NewField("error", err))
+		return nil, 0, errors.New("查询文档分片失败")
+	}
+	return chunks, total, nil
+}
+
 // buildDocumentsFromSearchResults 从搜索结果构建文档列表
 func (rs *RAGService) buildDocumentsFromSearchResults(results []*VectorSearchResult) []*Document {
 	docMap := make(map[string]*Document)
@@ -341,11 +675,12 @@ func (rs *RAGService) buildDocumentsFromSearchResults(results []*VectorSearchRes
 	for _, result := range results {
 		if _, exists := docMap[result.DocumentID]; !exists {
 			docMap[result.DocumentID] = &Document{
-				ID:      result.DocumentID,
-				Title:   result.DocumentID,
-				Content: result.Content,
-				Type:    "txt",
-				Status:  "processed",
+				ID:       result.DocumentID,
+				Title:    result.DocumentID,
+				Content:  result.Content,
+				Type:     "txt",
+				Status:   "processed",
+				Metadata: &DocumentMetadata{Category: searchResultCategory(result)},
 			}
 		}
 	}
@@ -367,8 +702,10 @@ func (rs *RAGService) buildChunksFromSearchResults(results []*VectorSearchResult
 			ID:         result.ChunkID,
 			DocumentID: result.DocumentID,
 			Content:    result.Content,
+			Category:   searchResultCategory(result),
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
+			Score:      result.Score,
 		}
 		chunks = append(chunks, chunk)
 	}
@@ -376,20 +713,21 @@ func (rs *RAGService) buildChunksFromSearchResults(results []*VectorSearchResult
 	return chunks
 }
 
-// buildQueryFromReimbursementInfo 从报销信息构建查询
+// searchResultCategory 获取检索结果所属的制度文档类别，元数据缺失时返回空
+func searchResultCategory(result *VectorSearchResult) string {
+	category, _ := result.Metadata["category"].(string)
+	return category
+}
+
+// buildQueryFromReimbursementInfo 从报销信息构建查询，字段范围由向量查询字段策略控制
 func (rs *RAGService) buildQueryFromReimbursementInfo(info map[string]interface{}) string {
 	var query string
 
-	if reimbursementType, ok := info["type"].(string); ok {
-		query += reimbursementType + " "
-	}
-
-	if amount, ok := info["amount"].(float64); ok {
-		query += "金额" + strconv.FormatFloat(amount, 'f', 2, 64) + " "
-	}
-
-	if category, ok := info["category"].(string); ok {
-		query += category + " "
+	// 仅拼接白名单内的字段，避免申请人姓名、工号等个人信息发送给外部向量服务
+	for _, field := range rs.embeddingFields.fields() {
+		if value := formatEmbeddingField(field, info[field]); value != "" {
+			query += value + " "
+		}
 	}
 
 	if query == "" {
@@ -486,6 +824,7 @@ func (rs *RAGService) parseAuditResult(query string, response *ChatResponse, ref
 		Conclusion: content,
 		Reasoning:  "基于报销制度文档进行审核",
 		Confidence: confidence,
+		Citations:  buildAuditCitations(content, references),
 		Data: map[string]interface{}{
 			"references_count": len(references),
 			"avg_score":        rs.calculateAverageScore(references),
@@ -546,11 +885,6 @@ func (rs *RAGService) calculateAverageScore(references []*VectorSearchResult) fl
 	return totalScore / float64(len(references))
 }
 
-// generateVectorID 生成向量ID
-func generateVectorID() string {
-	return "vec_" + strconv.FormatInt(time.Now().UnixNano(), 10)
-}
-
 // generateAnalysisResultID 生成分析结果ID
 func generateAnalysisResultID() string {
 	return "analysis_" + strconv.FormatInt(time.Now().UnixNano(), 10)
@@ -678,11 +1012,7 @@ func (rs *RAGService) extractReimbursementKeywords(info map[string]interface{})
 		keywords = append(keywords, city)
 	}
 
-	if len(keywords) > 5 {
-		keywords = keywords[:5]
-	}
-
-	return keywords
+	return utils.SafeTruncate(keywords, 5)
 }
 
 // extractKeywords 从查询中提取关键词
@@ -709,9 +1039,5 @@ func (rs *RAGService) extractKeywords(query string) []string {
 		}
 	}
 
-	if len(keywords) > 5 {
-		keywords = keywords[:5]
-	}
-
-	return keywords
+	return utils.SafeTruncate(keywords, 5)
 }
@@ -0,0 +1,193 @@
+// vector_index.go 向量索引参数推荐与自动重建
+// 功能点：
+// 1. 按向量行数推荐IVFFlat索引参数(lists约为行数的平方根，probes约为lists的平方根)
+// 2. 累计导入的分片数达到阈值后按推荐参数自动重建向量索引，并记录所选参数
+// 3. 阈值为0时不自动重建
+
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// 向量索引默认配置
+const (
+	DefaultVectorIndexName    = "idx_reimbursement_documents_embedding" // 默认向量索引名称
+	vectorIndexRebuildTimeout = 10 * time.Minute                        // 重建索引超时时间，大表建索引耗时较长
+	vectorIndexRebuildSuffix  = "_rebuild"                              // 重建时新索引的临时名称后缀
+	minIVFFlatLists           = 1                                       // lists最小值
+	minIVFFlatProbes          = 1                                       // probes最小值
+)
+
+// sqlIdentifierPattern 索引名称、列名只允许字母、数字和下划线，避免拼接SQL时注入
+var sqlIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// VectorIndexParams 向量索引参数
+type VectorIndexParams struct {
+	RowCount int64 `json:"row_count"` // 已向量化的分片数
+	Lists    int   `json:"lists"`     // IVFFlat聚类中心数
+	Probes   int   `json:"probes"`    // 查询时建议探测的聚类数(ivfflat.probes)
+}
+
+// VectorIndexPolicy 向量索引自动重建策略
+type VectorIndexPolicy struct {
+	VectorSchemaOptions     // 索引名称、类型和参数，需与迁移时创建索引的配置一致
+	RebuildThreshold    int // 累计导入多少个分片后重建索引，非正数表示不自动重建
+}
+
+// RecommendIVFFlatLists 按行数推荐IVFFlat的lists，约为行数的平方根，最小为1
+func RecommendIVFFlatLists(rowCount int64) int {
+	if rowCount <= 0 {
+		return minIVFFlatLists
+	}
+	lists := int(math.Round(math.Sqrt(float64(rowCount))))
+	if lists < minIVFFlatLists {
+		return minIVFFlatLists
+	}
+	return lists
+}
+
+// RecommendVectorIndexParams 按行数推荐向量索引参数
+func RecommendVectorIndexParams(rowCount int64) VectorIndexParams {
+	lists := RecommendIVFFlatLists(rowCount)
+	probes := int(math.Round(math.Sqrt(float64(lists))))
+	if probes < minIVFFlatProbes {
+		probes = minIVFFlatProbes
+	}
+	return VectorIndexParams{
+		RowCount: rowCount,
+		Lists:    lists,
+		Probes:   probes,
+	}
+}
+
+// CountVectors 统计已向量化的分片数
+func (vs *VectorStore) CountVectors(ctx context.Context) (int64, error) {
+	var count int64
+	result := vs.db.WithContext(ctx).
+		Model(&DocumentModel{}).
+		Where("embedding IS NOT NULL").
+		Count(&count)
+	if result.Error != nil {
+		vs.logger.Error("查询向量数量失败", logger.NewField("error", result.Error))
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// vectorIndexRebuildSQL 生成重建向量索引的SQL：清理上次失败残留的临时索引，并发创建新索引，删除旧索引后将新索引改为原名称
+// CONCURRENTLY不能在事务中执行，各语句需单独执行
+func vectorIndexRebuildSQL(o VectorSchemaOptions) []string {
+	tempName := o.IndexName + vectorIndexRebuildSuffix
+	return []string{
+		fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", tempName),
+		createVectorIndexSQL("CREATE INDEX CONCURRENTLY", tempName, o),
+		fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", o.IndexName),
+		fmt.Sprintf("ALTER INDEX %s RENAME TO %s", tempName, o.IndexName),
+	}
+}
+
+// RebuildVectorIndex 按索引类型和参数重建向量索引，不锁表，重建期间检索仍使用旧索引
+// 各语句单独执行，不能放在事务中；中途失败时旧索引保留，残留的临时索引在下次重建时清理
+func (vs *VectorStore) RebuildVectorIndex(ctx context.Context, opts VectorSchemaOptions) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		vs.logger.Error("向量索引参数不合法", logger.NewField("index_name", opts.IndexName), logger.NewField("error", err))
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, vectorIndexRebuildTimeout)
+	defer cancel()
+
+	for _, statement := range vectorIndexRebuildSQL(opts) {
+		if err := vs.db.WithContext(ctx).Exec(statement).Error; err != nil {
+			vs.logger.Error("重建向量索引失败",
+				logger.NewField("index_name", opts.IndexName),
+				logger.NewField("index_method", opts.IndexMethod),
+				logger.NewField("statement", statement),
+				logger.NewField("error", err))
+			return err
+		}
+	}
+	return nil
+}
+
+// SetVectorIndexPolicy 设置向量索引自动重建策略，索引类型和参数需与迁移配置一致，否则重建会改变索引类型
+func (rs *RAGService) SetVectorIndexPolicy(policy VectorIndexPolicy) {
+	if policy.IndexName == "" {
+		policy.IndexName = DefaultVectorIndexName
+	}
+	rs.indexPolicy = policy
+}
+
+// RecommendVectorIndexParams 按当前向量行数推荐向量索引参数
+func (rs *RAGService) RecommendVectorIndexParams(ctx context.Context) (*VectorIndexParams, error) {
+	rowCount, err := rs.vectorStore.CountVectors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询向量数量失败: %w", err)
+	}
+	params := RecommendVectorIndexParams(rowCount)
+	return &params, nil
+}
+
+// recordIngestedChunks 记录导入完成的分片数，累计达到阈值时在后台重建向量索引，不阻塞导入请求
+func (rs *RAGService) recordIngestedChunks(ctx context.Context, chunkCount int) {
+	threshold := int64(rs.indexPolicy.RebuildThreshold)
+	if threshold <= 0 || chunkCount <= 0 {
+		return
+	}
+	if rs.ingestedChunks.Add(int64(chunkCount)) < threshold {
+		return
+	}
+	// 已有重建在执行时不重复重建，累计值保留到下次导入时再判断
+	if !rs.indexRebuilding.CompareAndSwap(false, true) {
+		return
+	}
+	// 并发导入时只由一个调用方重建，其余调用方看到的累计值已被清零
+	if rs.ingestedChunks.Swap(0) < threshold {
+		rs.indexRebuilding.Store(false)
+		return
+	}
+
+	// 重建耗时较长，不随导入请求结束而取消
+	go func() {
+		defer rs.indexRebuilding.Store(false)
+		rs.rebuildVectorIndex(context.WithoutCancel(ctx))
+	}()
+}
+
+// rebuildVectorIndex 按迁移配置的索引类型重建向量索引，IVFFlat按当前行数推荐lists
+// 重建失败只记录日志，不影响导入结果
+func (rs *RAGService) rebuildVectorIndex(ctx context.Context) {
+	opts, err := rs.indexPolicy.VectorSchemaOptions.normalize()
+	if err != nil {
+		rs.logger.Error("向量索引参数不合法", logger.NewField("error", err))
+		return
+	}
+	params, err := rs.RecommendVectorIndexParams(ctx)
+	if err != nil {
+		rs.logger.Error("推荐向量索引参数失败", logger.NewField("error", err))
+		return
+	}
+	if opts.IndexMethod == VectorIndexMethodIVFFlat {
+		opts.Lists = params.Lists
+	}
+
+	startTime := time.Now()
+	if err := rs.vectorStore.RebuildVectorIndex(ctx, opts); err != nil {
+		return
+	}
+	rs.logger.Info("导入分片数达到阈值，已重建向量索引",
+		logger.NewField("index_name", opts.IndexName),
+		logger.NewField("index_method", opts.IndexMethod),
+		logger.NewField("row_count", params.RowCount),
+		logger.NewField("lists", opts.Lists),
+		logger.NewField("probes", params.Probes),
+		logger.NewField("duration_ms", time.Since(startTime).Milliseconds()))
+}
@@ -0,0 +1,121 @@
+package rag
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRecommendVectorIndexParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		rowCount   int64
+		wantLists  int
+		wantProbes int
+	}{
+		{name: "空表取最小值", rowCount: 0, wantLists: 1, wantProbes: 1},
+		{name: "负数取最小值", rowCount: -5, wantLists: 1, wantProbes: 1},
+		{name: "一万行", rowCount: 10000, wantLists: 100, wantProbes: 10},
+		{name: "平方根四舍五入", rowCount: 1000, wantLists: 32, wantProbes: 6},
+		{name: "一百万行", rowCount: 1000000, wantLists: 1000, wantProbes: 32},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RecommendVectorIndexParams(tt.rowCount)
+			if got.RowCount != tt.rowCount || got.Lists != tt.wantLists || got.Probes != tt.wantProbes {
+				t.Errorf("RecommendVectorIndexParams(%d) = %+v, want lists %d probes %d", tt.rowCount, got, tt.wantLists, tt.wantProbes)
+			}
+		})
+	}
+}
+
+func TestSQLIdentifierPattern(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "默认索引名称", value: DefaultVectorIndexName, want: true},
+		{name: "下划线开头", value: "_idx1", want: true},
+		{name: "数字开头", value: "1idx", want: false},
+		{name: "包含空格和分号", value: "idx; DROP TABLE x", want: false},
+		{name: "空字符串", value: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqlIdentifierPattern.MatchString(tt.value); got != tt.want {
+				t.Errorf("sqlIdentifierPattern.MatchString(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordIngestedChunksBelowThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    VectorIndexPolicy
+		chunks    []int
+		wantTotal int64
+	}{
+		{name: "阈值为0时不累计", policy: VectorIndexPolicy{}, chunks: []int{5, 10}, wantTotal: 0},
+		{name: "忽略非正数的分片数", policy: VectorIndexPolicy{RebuildThreshold: 100}, chunks: []int{0, -3}, wantTotal: 0},
+		{name: "未达到阈值时累计", policy: VectorIndexPolicy{RebuildThreshold: 100}, chunks: []int{30, 40}, wantTotal: 70},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &RAGService{}
+			rs.SetVectorIndexPolicy(tt.policy)
+			if rs.indexPolicy.IndexName != DefaultVectorIndexName {
+				t.Errorf("IndexName = %s, want %s", rs.indexPolicy.IndexName, DefaultVectorIndexName)
+			}
+			for _, n := range tt.chunks {
+				rs.recordIngestedChunks(context.Background(), n)
+			}
+			if got := rs.ingestedChunks.Load(); got != tt.wantTotal {
+				t.Errorf("ingestedChunks = %d, want %d", got, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestVectorIndexRebuildSQL(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       VectorSchemaOptions
+		wantCreate string
+	}{
+		{
+			name:       "默认沿用HNSW",
+			opts:       VectorSchemaOptions{},
+			wantCreate: "CREATE INDEX CONCURRENTLY idx_reimbursement_documents_embedding_rebuild ON reimbursement_documents USING hnsw (embedding vector_l2_ops) WITH (m = 16, ef_construction = 64)",
+		},
+		{
+			name:       "IVFFlat按指定lists",
+			opts:       VectorSchemaOptions{IndexName: "idx_docs", IndexMethod: "IVFFlat", Lists: 32},
+			wantCreate: "CREATE INDEX CONCURRENTLY idx_docs_rebuild ON reimbursement_documents USING ivfflat (embedding vector_l2_ops) WITH (lists = 32)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := tt.opts.normalize()
+			if err != nil {
+				t.Fatalf("normalize() error = %v", err)
+			}
+			statements := vectorIndexRebuildSQL(opts)
+			temp := opts.IndexName + vectorIndexRebuildSuffix
+			want := []string{
+				"DROP INDEX CONCURRENTLY IF EXISTS " + temp,
+				tt.wantCreate,
+				"DROP INDEX CONCURRENTLY IF EXISTS " + opts.IndexName,
+				"ALTER INDEX " + temp + " RENAME TO " + opts.IndexName,
+			}
+			if !reflect.DeepEqual(statements, want) {
+				t.Errorf("vectorIndexRebuildSQL() = %q, want %q", statements, want)
+			}
+		})
+	}
+}
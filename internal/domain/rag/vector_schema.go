@@ -0,0 +1,115 @@
+// vector_schema.go 向量库表结构迁移
+// 功能点：
+// 1. 建表前创建pgvector扩展，全新库无需手工执行CREATE EXTENSION
+// 2. 建表后创建向量索引，默认HNSW，也可选IVFFlat，索引已存在时跳过
+// 3. 索引参数(lists/m/ef_construction)可配置，未配置时使用pgvector推荐的默认值
+// 4. 向量索引的运算符类与检索使用的距离运算符一致，保证检索走索引
+
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 向量索引类型
+const (
+	VectorIndexMethodHNSW    = "hnsw"
+	VectorIndexMethodIVFFlat = "ivfflat"
+)
+
+// 向量索引参数默认值
+const (
+	DefaultHNSWM              = 16  // HNSW每个节点的最大连接数
+	DefaultHNSWEfConstruction = 64  // HNSW建索引时的候选列表大小
+	DefaultIVFFlatLists       = 100 // IVFFlat聚类中心数
+)
+
+// vectorDistanceOps 向量索引的运算符类，需与检索SQL使用的<->(L2距离)一致，否则查询不会走索引
+const vectorDistanceOps = "vector_l2_ops"
+
+// VectorSchemaOptions 向量库迁移参数
+type VectorSchemaOptions struct {
+	IndexName      string // 向量索引名称，为空时使用默认名称
+	IndexMethod    string // 向量索引类型(hnsw/ivfflat)，为空时使用hnsw
+	Lists          int    // IVFFlat聚类中心数，非正数时使用默认值
+	M              int    // HNSW每个节点的最大连接数，非正数时使用默认值
+	EfConstruction int    // HNSW建索引时的候选列表大小，非正数时使用默认值
+}
+
+// normalize 校验迁移参数并补全默认值
+func (o VectorSchemaOptions) normalize() (VectorSchemaOptions, error) {
+	if o.IndexName == "" {
+		o.IndexName = DefaultVectorIndexName
+	}
+	if !sqlIdentifierPattern.MatchString(o.IndexName) {
+		return o, errors.New("索引名称不合法")
+	}
+
+	o.IndexMethod = strings.ToLower(strings.TrimSpace(o.IndexMethod))
+	switch o.IndexMethod {
+	case "":
+		o.IndexMethod = VectorIndexMethodHNSW
+	case VectorIndexMethodHNSW, VectorIndexMethodIVFFlat:
+	default:
+		return o, fmt.Errorf("不支持的向量索引类型: %s", o.IndexMethod)
+	}
+
+	if o.Lists <= 0 {
+		o.Lists = DefaultIVFFlatLists
+	}
+	if o.M <= 0 {
+		o.M = DefaultHNSWM
+	}
+	if o.EfConstruction <= 0 {
+		o.EfConstruction = DefaultHNSWEfConstruction
+	}
+	// pgvector要求ef_construction不小于2*m
+	if o.EfConstruction < 2*o.M {
+		o.EfConstruction = 2 * o.M
+	}
+	return o, nil
+}
+
+// vectorIndexSQL 生成创建向量索引的SQL，索引已存在时跳过
+func vectorIndexSQL(o VectorSchemaOptions) string {
+	return createVectorIndexSQL("CREATE INDEX IF NOT EXISTS", o.IndexName, o)
+}
+
+// createVectorIndexSQL 按索引类型和参数生成建索引SQL，DDL语句不支持参数绑定，参数均为整数可直接拼接
+func createVectorIndexSQL(create, indexName string, o VectorSchemaOptions) string {
+	if o.IndexMethod == VectorIndexMethodIVFFlat {
+		return fmt.Sprintf("%s %s ON reimbursement_documents USING ivfflat (embedding %s) WITH (lists = %d)",
+			create, indexName, vectorDistanceOps, o.Lists)
+	}
+	return fmt.Sprintf("%s %s ON reimbursement_documents USING hnsw (embedding %s) WITH (m = %d, ef_construction = %d)",
+		create, indexName, vectorDistanceOps, o.M, o.EfConstruction)
+}
+
+// MigrateVectorSchema 迁移向量库表结构：创建pgvector扩展、建表并创建向量索引
+func MigrateVectorSchema(ctx context.Context, db *gorm.DB, opts VectorSchemaOptions) error {
+	opts, err := opts.normalize()
+	if err != nil {
+		return err
+	}
+
+	// vector类型由扩展提供，必须在建表前创建
+	if err := db.WithContext(ctx).Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+		return fmt.Errorf("创建pgvector扩展失败: %w", err)
+	}
+	if err := db.WithContext(ctx).AutoMigrate(&DocumentModel{}); err != nil {
+		return fmt.Errorf("迁移表结构失败: %w", err)
+	}
+
+	// AutoMigrate不会创建向量索引，没有索引时检索会走全表扫描
+	ctx, cancel := context.WithTimeout(ctx, vectorIndexRebuildTimeout)
+	defer cancel()
+	if err := db.WithContext(ctx).Exec(vectorIndexSQL(opts)).Error; err != nil {
+		return fmt.Errorf("创建向量索引失败: %w", err)
+	}
+	return nil
+}
@@ -6,6 +6,14 @@
 // 4. 向量数据增删改查
 // 5. 批量向量操作
 // 6. 向量检索性能优化
+// 7. 关键词检索结果按关键词密度过滤弱命中
+// 8. 混合搜索支持按制度文档类别限定检索范围
+// 9. 分片元数据以JSONB存储，过滤搜索在数据库层按元数据过滤并按向量距离排序
+// 10. 批量写入在事务中完成，失败整体回滚；按文档替换向量时先删除旧向量再写入
+// 11. 混合搜索按调用方指定的关键词权重融合向量和关键词检索结果
+// 12. 查询向量全为零或包含NaN/Inf时在查询数据库前返回错误
+// 13. 按分片记录ID游标逐行读取分片向量，用于导出
+// 14. 检查PostgreSQL连接和pgvector扩展是否可用，用于就绪检查
 
 package rag
 
@@ -14,8 +22,11 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"reimbursement-audit/internal/pkg/logger"
+	"reimbursement-audit/internal/pkg/utils"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,6 +39,15 @@ const (
 	VectorDimension = 768
 )
 
+// vectorBatchWriteTimeout 批量写入向量的事务超时时间
+const vectorBatchWriteTimeout = 30 * time.Second
+
+// documentVectorUpdateColumns 向量ID冲突时更新的列
+var documentVectorUpdateColumns = []string{"embedding", "chunk_content", "chunk_index", "category", "language", "metadata", "updated_at"}
+
+// ErrInvalidQueryVector 查询向量全为零或包含NaN/Inf，余弦距离无定义，检索结果没有意义
+var ErrInvalidQueryVector = errors.New("查询向量不合法")
+
 // VectorData 向量数据类型
 type VectorData []float64
 
@@ -79,18 +99,72 @@ func (v VectorData) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
 	}
 }
 
+// MetadataJSON 分片元数据，以JSONB存储
+type MetadataJSON map[string]interface{}
+
+// Scan 实现 sql.Scanner 接口
+func (m *MetadataJSON) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("无法扫描元数据")
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	*m = result
+	return nil
+}
+
+// Value 实现 driver.Valuer 接口
+func (m MetadataJSON) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// GormDataType 元数据列类型
+func (m MetadataJSON) GormDataType() string {
+	return "jsonb"
+}
+
+// toMap 转换为元数据映射，为空时返回空映射
+func (m MetadataJSON) toMap() map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
 // DocumentModel 文档模型
 type DocumentModel struct {
 	ID           string     `gorm:"primaryKey;column:id"`
 	FileName     string     `gorm:"column:file_name;index"`
 	FileType     string     `gorm:"column:file_type"`
 	Category     string     `gorm:"column:category"`
+	Language     string     `gorm:"column:language;index"`
 	ChunkID      string     `gorm:"column:chunk_id;index"`
 	ChunkIndex   int        `gorm:"column:chunk_index"`
 	ChunkContent string     `gorm:"column:chunk_content"`
 	Embedding    VectorData `gorm:"column:embedding;type:vector(768)"`
 	CreatedAt    time.Time  `gorm:"column:created_at"`
 	UpdatedAt    time.Time  `gorm:"column:updated_at"`
+
+	Metadata MetadataJSON `gorm:"column:metadata;type:jsonb"` // 分片元数据
 }
 
 // TableName 指定表名
@@ -100,8 +174,9 @@ func (DocumentModel) TableName() string {
 
 // VectorStore 向量存储结构体
 type VectorStore struct {
-	db     *gorm.DB
-	logger logger.Logger
+	db                *gorm.DB
+	logger            logger.Logger
+	minKeywordDensity float64 // 关键词检索结果的最低关键词密度
 }
 
 // NewVectorStore 创建向量存储实例
@@ -114,24 +189,55 @@ func NewVectorStore(dsn string, log logger.Logger) (*VectorStore, error) {
 		return nil, err
 	}
 
-	// 自动迁移表结构
-	if err := db.AutoMigrate(&DocumentModel{}); err != nil {
+	// 迁移表结构，包括pgvector扩展和向量索引
+	if err := MigrateVectorSchema(context.Background(), db, VectorSchemaOptions{}); err != nil {
 		log.Error("迁移表结构失败", logger.NewField("error", err))
 		return nil, err
 	}
 
 	return &VectorStore{
-		db:     db,
-		logger: log,
+		db:                db,
+		logger:            log,
+		minKeywordDensity: DefaultMinKeywordDensity,
 	}, nil
 }
 
 // NewVectorStoreWithDB 使用已有的 GORM DB 实例创建向量存储
 func NewVectorStoreWithDB(db *gorm.DB, log logger.Logger) *VectorStore {
 	return &VectorStore{
-		db:     db,
-		logger: log,
+		db:                db,
+		logger:            log,
+		minKeywordDensity: DefaultMinKeywordDensity,
+	}
+}
+
+// Close 关闭向量数据库连接
+func (vs *VectorStore) Close() error {
+	sqlDB, err := vs.db.DB()
+	if err != nil {
+		return fmt.Errorf("获取底层SQL数据库连接失败: %w", err)
 	}
+	return sqlDB.Close()
+}
+
+// Ping 检查PostgreSQL连接是否可用且已安装pgvector扩展
+func (vs *VectorStore) Ping(ctx context.Context) error {
+	sqlDB, err := vs.db.DB()
+	if err != nil {
+		return fmt.Errorf("获取底层SQL数据库连接失败: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("连接向量数据库失败: %w", err)
+	}
+
+	var count int64
+	if err := vs.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM pg_extension WHERE extname = 'vector'").Scan(&count).Error; err != nil {
+		return fmt.Errorf("查询pgvector扩展失败: %w", err)
+	}
+	if count == 0 {
+		return errors.New("pgvector扩展未安装")
+	}
+	return nil
 }
 
 func (vs *VectorStore) validateVector(vector *Vector) error {
@@ -179,23 +285,10 @@ func (vs *VectorStore) StoreVector(ctx context.Context, vector *Vector) error {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		doc := &DocumentModel{
-			ID:           vector.ID,
-			FileName:     vector.DocumentID,
-			FileType:     "text",
-			Category:     vector.Category,
-			ChunkID:      vector.ChunkID,
-			ChunkIndex:   0,
-			ChunkContent: vector.ChunkContent,
-			Embedding:    VectorData(vector.Values),
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		}
-
 		result := vs.db.WithContext(ctx).Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"embedding", "chunk_content", "category", "updated_at"}),
-		}).Create(doc)
+			DoUpdates: clause.AssignmentColumns(documentVectorUpdateColumns),
+		}).Create(newDocumentModel(vector))
 
 		return result.Error
 	}
@@ -208,7 +301,7 @@ func (vs *VectorStore) StoreVector(ctx context.Context, vector *Vector) error {
 	return nil
 }
 
-// StoreVectors 批量存储向量
+// StoreVectors 批量存储向量，全部向量在同一事务中写入，失败时整体回滚
 func (vs *VectorStore) StoreVectors(ctx context.Context, vectors []*Vector) error {
 	if len(vectors) == 0 {
 		return nil
@@ -226,19 +319,7 @@ func (vs *VectorStore) StoreVectors(ctx context.Context, vectors []*Vector) erro
 			continue
 		}
 
-		doc := &DocumentModel{
-			ID:           vector.ID,
-			FileName:     vector.DocumentID,
-			FileType:     "text",
-			Category:     vector.Category,
-			ChunkID:      vector.ChunkID,
-			ChunkIndex:   0,
-			ChunkContent: vector.ChunkContent,
-			Embedding:    VectorData(vector.Values),
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
-		}
-		docs = append(docs, doc)
+		docs = append(docs, newDocumentModel(vector))
 	}
 
 	if len(docs) == 0 {
@@ -246,15 +327,12 @@ func (vs *VectorStore) StoreVectors(ctx context.Context, vectors []*Vector) erro
 	}
 
 	operation := func() error {
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		ctx, cancel := context.WithTimeout(ctx, vectorBatchWriteTimeout)
 		defer cancel()
 
-		result := vs.db.WithContext(ctx).Clauses(clause.OnConflict{
-			Columns:   []clause.Column{{Name: "id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"embedding", "chunk_content", "category", "updated_at"}),
-		}).CreateInBatches(docs, 100)
-
-		return result.Error
+		return vs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return createDocumentModels(tx, docs)
+		})
 	}
 
 	if err := vs.retryOperation(operation, 2); err != nil {
@@ -265,6 +343,105 @@ func (vs *VectorStore) StoreVectors(ctx context.Context, vectors []*Vector) erro
 	return nil
 }
 
+// ReplaceDocumentVectors 替换文档的全部向量：在同一事务中删除文档的旧向量并写入新向量，失败时整体回滚
+// 返回被替换的旧向量涉及的类别，用于使查询缓存失效
+func (vs *VectorStore) ReplaceDocumentVectors(ctx context.Context, documentID string, vectors []*Vector) ([]string, error) {
+	if documentID == "" {
+		vs.logger.Error("文档ID不能为空")
+		return nil, errors.New("文档ID不能为空")
+	}
+
+	docs := make([]*DocumentModel, 0, len(vectors))
+	for _, vector := range vectors {
+		if err := vs.validateVector(vector); err != nil {
+			vs.logger.Error("向量校验失败", logger.NewField("vector_id", vector.ID), logger.NewField("error", err))
+			return nil, err
+		}
+		if vector.DocumentID != documentID {
+			vs.logger.Error("向量不属于该文档", logger.NewField("vector_id", vector.ID), logger.NewField("document_id", documentID))
+			return nil, errors.New("向量不属于该文档")
+		}
+		if vector.ChunkContent == "" {
+			vs.logger.Error("分片内容不能为空", logger.NewField("vector_id", vector.ID))
+			return nil, errors.New("分片内容不能为空")
+		}
+		docs = append(docs, newDocumentModel(vector))
+	}
+
+	var replacedCategories []string
+	operation := func() error {
+		ctx, cancel := context.WithTimeout(ctx, vectorBatchWriteTimeout)
+		defer cancel()
+
+		return vs.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			replacedCategories = nil
+			if err := tx.Model(&DocumentModel{}).
+				Where("file_name = ?", documentID).
+				Distinct().
+				Pluck("category", &replacedCategories).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("file_name = ?", documentID).Delete(&DocumentModel{}).Error; err != nil {
+				return err
+			}
+			return createDocumentModels(tx, docs)
+		})
+	}
+
+	if err := vs.retryOperation(operation, 2); err != nil {
+		vs.logger.Error("替换文档向量失败", logger.NewField("document_id", documentID), logger.NewField("count", len(docs)), logger.NewField("error", err))
+		return nil, err
+	}
+
+	return replacedCategories, nil
+}
+
+// newDocumentModel 将向量转换为文档表记录
+func newDocumentModel(vector *Vector) *DocumentModel {
+	return &DocumentModel{
+		ID:           vector.ID,
+		FileName:     vector.DocumentID,
+		FileType:     "text",
+		Category:     vector.Category,
+		Language:     vector.Language,
+		ChunkID:      vector.ChunkID,
+		ChunkIndex:   vector.ChunkIndex,
+		ChunkContent: vector.ChunkContent,
+		Embedding:    VectorData(vector.Values),
+		Metadata:     MetadataJSON(vector.Metadata),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// createDocumentModels 分批写入文档表记录，向量ID冲突时更新已有记录
+func createDocumentModels(tx *gorm.DB, docs []*DocumentModel) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(documentVectorUpdateColumns),
+	}).CreateInBatches(docs, 100).Error
+}
+
+// validateQueryVector 校验查询向量可用于余弦相似度检索
+func validateQueryVector(queryVector []float64) error {
+	allZero := true
+	for i, value := range queryVector {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("%w: 第%d维为%v", ErrInvalidQueryVector, i, value)
+		}
+		if value != 0 {
+			allZero = false
+		}
+	}
+	if allZero {
+		return fmt.Errorf("%w: 向量全为零，可能是向量生成失败", ErrInvalidQueryVector)
+	}
+	return nil
+}
+
 // SearchVector 搜索相似向量
 func (vs *VectorStore) SearchVector(ctx context.Context, queryVector []float64, topK int) ([]*VectorSearchResult, error) {
 	if len(queryVector) == 0 {
@@ -277,6 +454,11 @@ func (vs *VectorStore) SearchVector(ctx context.Context, queryVector []float64,
 		return nil, errors.New("查询向量维度必须为768维")
 	}
 
+	if err := validateQueryVector(queryVector); err != nil {
+		vs.logger.Error("查询向量不合法", logger.NewField("error", err))
+		return nil, err
+	}
+
 	if topK <= 0 {
 		topK = 10
 	}
@@ -290,6 +472,7 @@ func (vs *VectorStore) SearchVector(ctx context.Context, queryVector []float64,
 			FileName     string
 			FileType     string
 			Category     string
+			Language     string
 			ChunkID      string
 			ChunkIndex   int
 			ChunkContent string
@@ -300,7 +483,7 @@ func (vs *VectorStore) SearchVector(ctx context.Context, queryVector []float64,
 		queryVectorJSON, _ := json.Marshal(queryVector)
 
 		err := vs.db.WithContext(ctx).Raw(`
-			SELECT id, file_name, file_type, category, chunk_id, chunk_index, chunk_content, 
+			SELECT id, file_name, file_type, category, language, chunk_id, chunk_index, chunk_content, 
 				   embedding <-> ?::vector AS distance
 			FROM reimbursement_documents
 			WHERE embedding IS NOT NULL
@@ -323,6 +506,7 @@ func (vs *VectorStore) SearchVector(ctx context.Context, queryVector []float64,
 				Metadata: map[string]interface{}{
 					"category":  result.Category,
 					"file_type": result.FileType,
+					"language":  result.Language,
 				},
 			})
 		}
@@ -350,6 +534,11 @@ func (vs *VectorStore) SearchVectorByCategory(ctx context.Context, queryVector [
 		return nil, errors.New("查询向量维度必须为768维")
 	}
 
+	if err := validateQueryVector(queryVector); err != nil {
+		vs.logger.Error("查询向量不合法", logger.NewField("error", err))
+		return nil, err
+	}
+
 	if topK <= 0 {
 		topK = 10
 	}
@@ -363,6 +552,7 @@ func (vs *VectorStore) SearchVectorByCategory(ctx context.Context, queryVector [
 			FileName     string
 			FileType     string
 			Category     string
+			Language     string
 			ChunkID      string
 			ChunkIndex   int
 			ChunkContent string
@@ -373,7 +563,7 @@ func (vs *VectorStore) SearchVectorByCategory(ctx context.Context, queryVector [
 		queryVectorJSON, _ := json.Marshal(queryVector)
 
 		err := vs.db.WithContext(ctx).Raw(`
-			SELECT id, file_name, file_type, category, chunk_id, chunk_index, chunk_content, 
+			SELECT id, file_name, file_type, category, language, chunk_id, chunk_index, chunk_content, 
 				   embedding <-> ?::vector AS distance
 			FROM reimbursement_documents
 			WHERE embedding IS NOT NULL AND category = ?
@@ -396,6 +586,7 @@ func (vs *VectorStore) SearchVectorByCategory(ctx context.Context, queryVector [
 				Metadata: map[string]interface{}{
 					"category":  result.Category,
 					"file_type": result.FileType,
+					"language":  result.Language,
 				},
 			})
 		}
@@ -440,7 +631,8 @@ func (vs *VectorStore) GetVectorByID(ctx context.Context, id string) (*Vector, e
 			Values:       doc.Embedding,
 			Dimension:    len(doc.Embedding),
 			Category:     doc.Category,
-			Metadata:     map[string]interface{}{},
+			Language:     doc.Language,
+			Metadata:     doc.Metadata.toMap(),
 			CreatedAt:    doc.CreatedAt,
 			UpdatedAt:    doc.UpdatedAt,
 		}
@@ -472,15 +664,20 @@ func (vs *VectorStore) UpdateVector(ctx context.Context, vector *Vector) error {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
+		updates := map[string]interface{}{
+			"embedding":     VectorData(vector.Values),
+			"chunk_content": vector.ChunkContent,
+			"category":      vector.Category,
+			"updated_at":    time.Now(),
+		}
+		if vector.Metadata != nil {
+			updates["metadata"] = MetadataJSON(vector.Metadata)
+		}
+
 		result := vs.db.WithContext(ctx).
 			Model(&DocumentModel{}).
 			Where("id = ?", vector.ID).
-			Updates(map[string]interface{}{
-				"embedding":     VectorData(vector.Values),
-				"chunk_content": vector.ChunkContent,
-				"category":      vector.Category,
-				"updated_at":    time.Now(),
-			})
+			Updates(updates)
 
 		if result.Error != nil {
 			return result.Error
@@ -624,7 +821,8 @@ func (vs *VectorStore) GetVectorsByDocumentID(ctx context.Context, documentID st
 				Values:       doc.Embedding,
 				Dimension:    len(doc.Embedding),
 				Category:     doc.Category,
-				Metadata:     map[string]interface{}{},
+				Language:     doc.Language,
+				Metadata:     doc.Metadata.toMap(),
 				CreatedAt:    doc.CreatedAt,
 				UpdatedAt:    doc.UpdatedAt,
 			}
@@ -643,18 +841,161 @@ func (vs *VectorStore) GetVectorsByDocumentID(ctx context.Context, documentID st
 	return vectors, nil
 }
 
-// CreateIndex 创建向量索引
-func (vs *VectorStore) CreateIndex(ctx context.Context, indexName string, indexType string) error {
-	if indexName == "" {
-		vs.logger.Error("索引名称不能为空")
-		return errors.New("索引名称不能为空")
+// ListChunks 分页查询已入库的文档分片，支持按文档ID和内容关键词过滤
+func (vs *VectorStore) ListChunks(ctx context.Context, filter *ChunkFilter) ([]*ChunkRecord, int64, error) {
+	if filter == nil {
+		filter = &ChunkFilter{}
+	}
+
+	page := filter.Page
+	if page <= 0 {
+		page = 1
+	}
+	size := filter.Size
+	if size <= 0 {
+		size = 20
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := vs.db.WithContext(ctx).Model(&DocumentModel{})
+	if filter.DocumentID != "" {
+		query = query.Where("file_name = ?", filter.DocumentID)
+	}
+	if keyword := strings.TrimSpace(filter.Keyword); keyword != "" {
+		query = query.Where("chunk_content LIKE ?", "%"+keyword+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		vs.logger.Error("查询分片总数失败", logger.NewField("document_id", filter.DocumentID), logger.NewField("error", err))
+		return nil, 0, err
+	}
+
+	var docs []*DocumentModel
+	err := query.
+		Select("id", "file_name", "file_type", "category", "chunk_id", "chunk_index", "chunk_content", "created_at", "updated_at").
+		Order("file_name ASC, chunk_index ASC, id ASC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&docs).Error
+	if err != nil {
+		vs.logger.Error("查询分片列表失败", logger.NewField("document_id", filter.DocumentID), logger.NewField("error", err))
+		return nil, 0, err
+	}
+
+	chunks := make([]*ChunkRecord, 0, len(docs))
+	for _, doc := range docs {
+		chunks = append(chunks, &ChunkRecord{
+			ID:         doc.ID,
+			DocumentID: doc.FileName,
+			ChunkID:    doc.ChunkID,
+			ChunkIndex: doc.ChunkIndex,
+			Category:   doc.Category,
+			FileType:   doc.FileType,
+			Content:    doc.ChunkContent,
+			CreatedAt:  doc.CreatedAt,
+			UpdatedAt:  doc.UpdatedAt,
+		})
+	}
+
+	return chunks, total, nil
+}
+
+// StreamEmbeddings 按分片记录ID升序逐行读取一页分片向量并回调fn，不在内存中保留整页数据
+// 返回本页最后一条记录ID作为下一页游标，本页不足一页时返回空字符串；fn返回错误时停止读取
+func (vs *VectorStore) StreamEmbeddings(ctx context.Context, filter *EmbeddingFilter, fn func(*EmbeddingRecord) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := vs.db.WithContext(ctx).Model(&DocumentModel{}).
+		Select("id", "file_name", "category", "chunk_id", "chunk_index", "embedding")
+	if filter.Collection != "" {
+		query = query.Where("category = ?", filter.Collection)
+	}
+	if filter.After != "" {
+		query = query.Where("id > ?", filter.After)
+	}
+
+	rows, err := query.Order("id ASC").Limit(filter.Size).Rows()
+	if err != nil {
+		return "", fmt.Errorf("查询分片向量失败: %w", err)
+	}
+	defer rows.Close()
+
+	lastID := ""
+	count := 0
+	for rows.Next() {
+		var doc DocumentModel
+		if err := vs.db.ScanRows(rows, &doc); err != nil {
+			return "", fmt.Errorf("读取分片向量失败: %w", err)
+		}
+		if err := fn(&EmbeddingRecord{
+			ID:         doc.ID,
+			ChunkID:    doc.ChunkID,
+			DocumentID: doc.FileName,
+			ChunkIndex: doc.ChunkIndex,
+			Category:   doc.Category,
+			Embedding:  doc.Embedding,
+		}); err != nil {
+			return "", err
+		}
+		lastID = doc.ID
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("读取分片向量失败: %w", err)
+	}
+
+	if count < filter.Size {
+		return "", nil
+	}
+	return lastID, nil
+}
+
+// indexMethods 支持的普通索引方法
+var indexMethods = map[string]bool{
+	"btree": true,
+	"hash":  true,
+	"gin":   true,
+	"gist":  true,
+	"brin":  true,
+}
+
+// CreateIndex 在指定列上创建普通索引，索引已存在时忽略
+// columns为空时默认为chunk_content，method为空时使用btree
+func (vs *VectorStore) CreateIndex(ctx context.Context, indexName string, columns []string, method string) error {
+	if !sqlIdentifierPattern.MatchString(indexName) {
+		vs.logger.Error("索引名称不合法", logger.NewField("index_name", indexName))
+		return errors.New("索引名称不合法")
+	}
+
+	if len(columns) == 0 {
+		columns = []string{"chunk_content"}
+	}
+	for _, column := range columns {
+		if !sqlIdentifierPattern.MatchString(column) {
+			vs.logger.Error("索引列名不合法", logger.NewField("column", column))
+			return fmt.Errorf("索引列名不合法: %s", column)
+		}
+	}
+
+	method = strings.ToLower(strings.TrimSpace(method))
+	if method == "" {
+		method = "btree"
+	}
+	if !indexMethods[method] {
+		vs.logger.Error("不支持的索引方法", logger.NewField("method", method))
+		return fmt.Errorf("不支持的索引方法: %s", method)
 	}
 
 	operation := func() error {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		query := "CREATE INDEX " + indexName + " ON reimbursement_documents(chunk_content)"
+		query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON reimbursement_documents USING %s (%s)",
+			indexName, method, strings.Join(columns, ", "))
 		result := vs.db.WithContext(ctx).Exec(query)
 
 		return result.Error
@@ -668,22 +1009,25 @@ func (vs *VectorStore) CreateIndex(ctx context.Context, indexName string, indexT
 	return nil
 }
 
+// CreateVectorIndex 创建IVFFlat向量索引，索引已存在时忽略，lists非正数时使用100
 func (vs *VectorStore) CreateVectorIndex(ctx context.Context, indexName string, lists int) error {
-	if indexName == "" {
-		vs.logger.Error("索引名称不能为空")
-		return errors.New("索引名称不能为空")
+	if !sqlIdentifierPattern.MatchString(indexName) {
+		vs.logger.Error("索引名称不合法", logger.NewField("index_name", indexName))
+		return errors.New("索引名称不合法")
 	}
 
 	if lists <= 0 {
-		lists = 100
+		lists = DefaultIVFFlatLists
 	}
 
 	operation := func() error {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		query := "CREATE INDEX " + indexName + " ON reimbursement_documents USING ivfflat (embedding vector_cosine_ops) WITH (lists = ?)"
-		result := vs.db.WithContext(ctx).Exec(query, lists)
+		// DDL语句不支持参数绑定，lists为整数可直接拼接
+		query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON reimbursement_documents USING ivfflat (embedding %s) WITH (lists = %d)",
+			indexName, vectorDistanceOps, lists)
+		result := vs.db.WithContext(ctx).Exec(query)
 
 		return result.Error
 	}
@@ -696,18 +1040,18 @@ func (vs *VectorStore) CreateVectorIndex(ctx context.Context, indexName string,
 	return nil
 }
 
-// DropIndex 删除向量索引
+// DropIndex 删除索引，索引不存在时忽略
 func (vs *VectorStore) DropIndex(ctx context.Context, indexName string) error {
-	if indexName == "" {
-		vs.logger.Error("索引名称不能为空")
-		return errors.New("索引名称不能为空")
+	if !sqlIdentifierPattern.MatchString(indexName) {
+		vs.logger.Error("索引名称不合法", logger.NewField("index_name", indexName))
+		return errors.New("索引名称不合法")
 	}
 
 	operation := func() error {
 		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 
-		query := "DROP INDEX " + indexName
+		query := "DROP INDEX IF EXISTS " + indexName
 		result := vs.db.WithContext(ctx).Exec(query)
 
 		return result.Error
@@ -721,16 +1065,16 @@ func (vs *VectorStore) DropIndex(ctx context.Context, indexName string) error {
 	return nil
 }
 
-// ListIndexes 列出所有索引
+// ListIndexes 列出文档表的所有索引
 func (vs *VectorStore) ListIndexes(ctx context.Context) ([]string, error) {
 	query := `
-		SELECT INDEX_NAME 
-		FROM INFORMATION_SCHEMA.STATISTICS 
-		WHERE TABLE_NAME = 'reimbursement_documents'
-		GROUP BY INDEX_NAME
+		SELECT indexname
+		FROM pg_indexes
+		WHERE tablename = ?
+		ORDER BY indexname
 	`
 
-	rows, err := vs.db.WithContext(ctx).Raw(query).Rows()
+	rows, err := vs.db.WithContext(ctx).Raw(query, DocumentModel{}.TableName()).Rows()
 	if err != nil {
 		vs.logger.Error("查询索引失败", logger.NewField("error", err))
 		return nil, err
@@ -754,9 +1098,9 @@ func (vs *VectorStore) ListIndexes(ctx context.Context) ([]string, error) {
 	return indexes, nil
 }
 
-// OptimizeIndex 优化向量索引
+// OptimizeIndex 更新文档表的统计信息，使查询规划器正确使用索引
 func (vs *VectorStore) OptimizeIndex(ctx context.Context, indexName string) error {
-	query := "ANALYZE TABLE reimbursement_documents"
+	query := "ANALYZE reimbursement_documents"
 	result := vs.db.WithContext(ctx).Exec(query)
 
 	if result.Error != nil {
@@ -768,91 +1112,122 @@ func (vs *VectorStore) OptimizeIndex(ctx context.Context, indexName string) erro
 }
 
 // GetStatistics 获取向量存储统计信息
+// 数量、占用空间和最近写入时间在同一条语句中查询，基于同一快照，结果一致
 func (vs *VectorStore) GetStatistics(ctx context.Context) (*VectorStoreStatistics, error) {
-	stats := &VectorStoreStatistics{
-		LastUpdated: time.Now(),
-	}
-
-	var documentCount int64
-	result := vs.db.WithContext(ctx).
-		Model(&DocumentModel{}).
-		Distinct("file_name").
-		Count(&documentCount)
+	var row struct {
+		DocumentCount int64
+		ChunkCount    int64
+		VectorCount   int64
+		AvgDimension  float64
+		LastWriteAt   *time.Time
+		TableSize     int64
+		IndexSize     int64
+		StorageSize   int64
+	}
+
+	result := vs.db.WithContext(ctx).Raw(`
+		SELECT COUNT(DISTINCT file_name) AS document_count,
+			   COUNT(*) AS chunk_count,
+			   COUNT(embedding) AS vector_count,
+			   COALESCE(AVG(vector_dims(embedding)), 0) AS avg_dimension,
+			   MAX(updated_at) AS last_write_at,
+			   pg_relation_size('reimbursement_documents') AS table_size,
+			   pg_indexes_size('reimbursement_documents') AS index_size,
+			   pg_total_relation_size('reimbursement_documents') AS storage_size
+		FROM reimbursement_documents
+	`).Scan(&row)
 
 	if result.Error != nil {
-		vs.logger.Error("查询文档数量失败", logger.NewField("error", result.Error))
+		vs.logger.Error("查询向量存储统计信息失败", logger.NewField("error", result.Error))
 		return nil, result.Error
 	}
-	stats.DocumentCount = documentCount
 
-	var chunkCount int64
-	result = vs.db.WithContext(ctx).
-		Model(&DocumentModel{}).
-		Count(&chunkCount)
-
-	if result.Error != nil {
-		vs.logger.Error("查询分片数量失败", logger.NewField("error", result.Error))
-		return nil, result.Error
+	stats := &VectorStoreStatistics{
+		DocumentCount: row.DocumentCount,
+		ChunkCount:    row.ChunkCount,
+		VectorCount:   row.VectorCount,
+		IndexSize:     row.IndexSize,
+		StorageSize:   row.StorageSize,
+		TableSize:     row.TableSize,
+		AvgDimension:  row.AvgDimension,
+		LastUpdated:   time.Now(),
 	}
-	stats.ChunkCount = chunkCount
-
-	var vectorCount int64
-	result = vs.db.WithContext(ctx).
-		Model(&DocumentModel{}).
-		Where("embedding IS NOT NULL").
-		Count(&vectorCount)
-
-	if result.Error != nil {
-		vs.logger.Error("查询向量数量失败", logger.NewField("error", result.Error))
-		return nil, result.Error
+	if row.LastWriteAt != nil {
+		stats.LastWriteAt = *row.LastWriteAt
 	}
-	stats.VectorCount = vectorCount
 
 	return stats, nil
 }
 
-// HybridSearch 混合搜索（向量+关键词）
-func (vs *VectorStore) HybridSearch(ctx context.Context, queryVector []float64, keywords []string, topK int) ([]*VectorSearchResult, error) {
-	vectorResults, err := vs.SearchVector(ctx, queryVector, topK*2)
+// HybridSearch 混合搜索（向量+关键词），keywordWeight为关键词检索结果在融合分数中的权重(0-1)
+func (vs *VectorStore) HybridSearch(ctx context.Context, queryVector []float64, keywords []string, topK int, keywordWeight float64) ([]*VectorSearchResult, error) {
+	return vs.HybridSearchByCategory(ctx, queryVector, keywords, "", topK, keywordWeight)
+}
+
+// HybridSearchByCategory 在指定类别的制度文档内混合搜索（向量+关键词），类别为空时检索全库
+func (vs *VectorStore) HybridSearchByCategory(ctx context.Context, queryVector []float64, keywords []string, category string, topK int, keywordWeight float64) ([]*VectorSearchResult, error) {
+	if topK <= 0 {
+		topK = 10
+	}
+
+	category = strings.TrimSpace(category)
+	var vectorResults []*VectorSearchResult
+	var err error
+	if category == "" {
+		vectorResults, err = vs.SearchVector(ctx, queryVector, topK*2)
+	} else {
+		vectorResults, err = vs.SearchVectorByCategory(ctx, queryVector, category, topK*2)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	if len(keywords) == 0 {
-		if len(vectorResults) > topK {
-			return vectorResults[:topK], nil
-		}
-		return vectorResults, nil
+		return utils.SafeTruncate(vectorResults, topK), nil
 	}
 
-	keywordResults, err := vs.KeywordSearch(ctx, keywords, topK*2)
+	keywordResults, err := vs.keywordSearch(ctx, keywords, category, topK*2)
 	if err != nil {
 		return nil, err
 	}
 
-	combined := vs.CombineResults(vectorResults, keywordResults, topK)
+	combined := vs.CombineResults(vectorResults, keywordResults, keywordWeight, topK)
 	return combined, nil
 }
 
-// KeywordSearch 关键词搜索
+// KeywordSearch 关键词搜索，关键词密度低于下限的弱命中分片不返回
 func (vs *VectorStore) KeywordSearch(ctx context.Context, keywords []string, topK int) ([]*VectorSearchResult, error) {
+	return vs.keywordSearch(ctx, keywords, "", topK)
+}
+
+// keywordSearch 关键词搜索，category不为空时只检索该类别的分片
+func (vs *VectorStore) keywordSearch(ctx context.Context, keywords []string, category string, topK int) ([]*VectorSearchResult, error) {
 	if len(keywords) == 0 {
 		return nil, nil
 	}
 
-	query := vs.db.WithContext(ctx).
-		Model(&DocumentModel{}).
-		Where("chunk_content LIKE ?", "%"+keywords[0]+"%")
+	if topK <= 0 {
+		topK = 10
+	}
 
+	// 关键词条件作为一组，避免与类别条件组合时OR的优先级问题
+	keywordCondition := vs.db.Where("chunk_content LIKE ?", "%"+keywords[0]+"%")
 	for i := 1; i < len(keywords); i++ {
-		query = query.Or("chunk_content LIKE ?", "%"+keywords[i]+"%")
+		keywordCondition = keywordCondition.Or("chunk_content LIKE ?", "%"+keywords[i]+"%")
+	}
+
+	query := vs.db.WithContext(ctx).
+		Model(&DocumentModel{}).
+		Where(keywordCondition)
+	if category != "" {
+		query = query.Where("category = ?", category)
 	}
 
 	var docs []*DocumentModel
-	result := query.Limit(topK).Find(&docs)
+	result := query.Limit(topK * keywordCandidateFactor).Find(&docs)
 
 	if result.Error != nil {
-		vs.logger.Error("关键词搜索失败", logger.NewField("keywords", strings.Join(keywords, ",")), logger.NewField("error", result.Error))
+		vs.logger.Error("关键词搜索失败", logger.NewField("keywords", strings.Join(keywords, ",")), logger.NewField("category", category), logger.NewField("error", result.Error))
 		return nil, result.Error
 	}
 
@@ -864,81 +1239,131 @@ func (vs *VectorStore) KeywordSearch(ctx context.Context, keywords []string, top
 			ChunkID:    doc.ChunkID,
 			Content:    doc.ChunkContent,
 			Score:      0.5,
-			Metadata:   map[string]interface{}{},
+			Metadata: map[string]interface{}{
+				"category":  doc.Category,
+				"file_type": doc.FileType,
+				"language":  doc.Language,
+			},
 		})
 	}
 
-	return results, nil
+	return filterKeywordResults(results, keywords, vs.minKeywordDensity, topK), nil
 }
 
-// CombineResults 合并搜索结果
-func (vs *VectorStore) CombineResults(vectorResults, keywordResults []*VectorSearchResult, topK int) []*VectorSearchResult {
-	scoreMap := make(map[string]*VectorSearchResult)
+// CombineResults 按关键词权重融合向量检索和关键词检索结果
+func (vs *VectorStore) CombineResults(vectorResults, keywordResults []*VectorSearchResult, keywordWeight float64, topK int) []*VectorSearchResult {
+	return fuseResults(vectorResults, keywordResults, keywordWeight, topK)
+}
 
-	for _, result := range vectorResults {
-		if existing, ok := scoreMap[result.ID]; ok {
-			existing.Score = (existing.Score + result.Score) / 2
-		} else {
-			scoreMap[result.ID] = result
-		}
+// metadataColumns 按列存储的元数据键，过滤时直接比较列值
+var metadataColumns = map[string]string{
+	"category":  "category",
+	"file_type": "file_type",
+	"language":  "language",
+}
+
+// FilterSearch 过滤搜索，在数据库层按元数据过滤（多个条件为AND）后按向量距离排序，返回最多topK条
+func (vs *VectorStore) FilterSearch(ctx context.Context, queryVector []float64, filters map[string]interface{}, topK int) ([]*VectorSearchResult, error) {
+	if len(queryVector) != VectorDimension {
+		vs.logger.Error("查询向量维度必须为768维", logger.NewField("dimension", len(queryVector)))
+		return nil, errors.New("查询向量维度必须为768维")
 	}
 
-	for _, result := range keywordResults {
-		if existing, ok := scoreMap[result.ID]; ok {
-			existing.Score = (existing.Score + result.Score) / 2
-		} else {
-			scoreMap[result.ID] = result
-		}
+	if err := validateQueryVector(queryVector); err != nil {
+		vs.logger.Error("查询向量不合法", logger.NewField("error", err))
+		return nil, err
+	}
+
+	if topK <= 0 {
+		topK = 10
 	}
 
-	var combined []*VectorSearchResult
-	for _, result := range scoreMap {
-		combined = append(combined, result)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	type SearchResult struct {
+		ID           string
+		FileName     string
+		FileType     string
+		Category     string
+		Language     string
+		ChunkID      string
+		ChunkIndex   int
+		ChunkContent string
+		Metadata     MetadataJSON
+		Distance     float64
 	}
 
-	for i := 0; i < len(combined)-1; i++ {
-		for j := i + 1; j < len(combined); j++ {
-			if combined[i].Score < combined[j].Score {
-				combined[i], combined[j] = combined[j], combined[i]
-			}
-		}
+	queryVectorJSON, _ := json.Marshal(queryVector)
+	query := vs.db.WithContext(ctx).
+		Model(&DocumentModel{}).
+		Select("id, file_name, file_type, category, language, chunk_id, chunk_index, chunk_content, metadata, embedding <-> ?::vector AS distance", string(queryVectorJSON)).
+		Where("embedding IS NOT NULL")
+	query = applyMetadataFilters(query, filters)
+
+	var results []SearchResult
+	if err := query.Order("distance ASC").Limit(topK).Scan(&results).Error; err != nil {
+		vs.logger.Error("过滤搜索失败", logger.NewField("top_k", topK), logger.NewField("error", err))
+		return nil, err
 	}
 
-	if len(combined) > topK {
-		combined = combined[:topK]
+	vectorResults := make([]*VectorSearchResult, 0, len(results))
+	for _, result := range results {
+		metadata := result.Metadata.toMap()
+		metadata["category"] = result.Category
+		metadata["file_type"] = result.FileType
+		metadata["language"] = result.Language
+		vectorResults = append(vectorResults, &VectorSearchResult{
+			ID:         result.ID,
+			DocumentID: result.FileName,
+			ChunkID:    result.ChunkID,
+			Content:    result.ChunkContent,
+			Score:      1.0 - result.Distance,
+			Metadata:   metadata,
+		})
 	}
 
-	return combined
+	return vectorResults, nil
 }
 
-// FilterSearch 过滤搜索
-func (vs *VectorStore) FilterSearch(ctx context.Context, queryVector []float64, filters map[string]interface{}, topK int) ([]*VectorSearchResult, error) {
-	vectorResults, err := vs.SearchVector(ctx, queryVector, topK*5)
-	if err != nil {
-		return nil, err
+// applyMetadataFilters 追加元数据过滤条件，按键排序保证生成的SQL稳定
+// 按列存储的键直接比较列值，其它键比较JSONB中的文本值，值为nil表示该键不存在或为null
+func applyMetadataFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	var filtered []*VectorSearchResult
-	for _, result := range vectorResults {
-		match := true
-		for key, value := range filters {
-			if resultVal, ok := result.Metadata[key]; ok {
-				if resultVal != value {
-					match = false
-					break
-				}
-			}
+	for _, key := range keys {
+		value := filters[key]
+		if column, ok := metadataColumns[key]; ok {
+			query = query.Where(column+" = ?", metadataFilterText(value))
+			continue
 		}
-		if match {
-			filtered = append(filtered, result)
+		if value == nil {
+			query = query.Where("metadata->>? IS NULL", key)
+			continue
 		}
+		query = query.Where("metadata->>? = ?", key, metadataFilterText(value))
 	}
+	return query
+}
 
-	if len(filtered) > topK {
-		filtered = filtered[:topK]
+// metadataFilterText 将过滤值转换为与JSONB ->>取值一致的文本
+func metadataFilterText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
 	}
-
-	return filtered, nil
 }
 
 // CalculateSimilarity 计算向量相似度
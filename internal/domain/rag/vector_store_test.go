@@ -0,0 +1,196 @@
+package rag
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// newMockVectorStore 创建基于sqlmock的向量存储，SQL按正则匹配
+func newMockVectorStore(t *testing.T) (*VectorStore, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("创建sqlmock失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{
+		Logger: gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("创建GORM实例失败: %v", err)
+	}
+	return NewVectorStoreWithDB(gormDB, loggertest.New(t)), mock
+}
+
+func TestValidateQueryVector(t *testing.T) {
+	tests := []struct {
+		name    string
+		vector  []float64
+		wantErr bool
+	}{
+		{name: "合法向量", vector: []float64{0, 0.1, -0.2}},
+		{name: "全为零", vector: []float64{0, 0, 0}, wantErr: true},
+		{name: "空向量视为全零", vector: nil, wantErr: true},
+		{name: "包含NaN", vector: []float64{0.1, math.NaN()}, wantErr: true},
+		{name: "包含正无穷", vector: []float64{math.Inf(1), 0.1}, wantErr: true},
+		{name: "包含负无穷", vector: []float64{0.1, math.Inf(-1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateQueryVector(tt.vector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateQueryVector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidQueryVector) {
+				t.Errorf("validateQueryVector() error = %v, want ErrInvalidQueryVector", err)
+			}
+		})
+	}
+}
+
+func TestSearchError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		want        string
+		wantInvalid bool
+	}{
+		{name: "查询向量不合法时保留原因", err: validateQueryVector([]float64{0}), want: "搜索文档失败: 查询向量不合法: 向量全为零，可能是向量生成失败", wantInvalid: true},
+		{name: "其它错误不暴露存储层细节", err: errors.New("pq: connection refused"), want: "搜索文档失败"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := searchError("搜索文档失败", tt.err)
+			if got.Error() != tt.want {
+				t.Errorf("searchError() = %q, want %q", got.Error(), tt.want)
+			}
+			if errors.Is(got, ErrInvalidQueryVector) != tt.wantInvalid {
+				t.Errorf("errors.Is(ErrInvalidQueryVector) = %v, want %v", !tt.wantInvalid, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestListChunks(t *testing.T) {
+	const (
+		columns = `"id","file_name","file_type","category","chunk_id","chunk_index","chunk_content","created_at","updated_at"`
+		order   = ` ORDER BY file_name ASC, chunk_index ASC, id ASC`
+	)
+	createdAt := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    *ChunkFilter
+		where     string
+		args      []driver.Value // 过滤条件参数
+		paging    string
+		pageArgs  []driver.Value // 分页参数
+		total     int64
+		rowsCount int
+	}{
+		{
+			name:      "未指定过滤条件时使用默认分页",
+			paging:    ` LIMIT $1`,
+			pageArgs:  []driver.Value{20},
+			total:     2,
+			rowsCount: 2,
+		},
+		{
+			name:      "按文档ID过滤",
+			filter:    &ChunkFilter{DocumentID: "差旅费管理办法.pdf"},
+			where:     ` WHERE file_name = $1`,
+			args:      []driver.Value{"差旅费管理办法.pdf"},
+			paging:    ` LIMIT $2`,
+			pageArgs:  []driver.Value{20},
+			total:     2,
+			rowsCount: 2,
+		},
+		{
+			name:      "按文档ID和关键词过滤并分页",
+			filter:    &ChunkFilter{DocumentID: "差旅费管理办法.pdf", Keyword: " 住宿 ", Page: 3, Size: 2},
+			where:     ` WHERE file_name = $1 AND chunk_content LIKE $2`,
+			args:      []driver.Value{"差旅费管理办法.pdf", "%住宿%"},
+			paging:    ` LIMIT $3 OFFSET $4`,
+			pageArgs:  []driver.Value{2, 4},
+			total:     6,
+			rowsCount: 2,
+		},
+		{
+			name:     "关键词无匹配",
+			filter:   &ChunkFilter{Keyword: "不存在"},
+			where:    ` WHERE chunk_content LIKE $1`,
+			args:     []driver.Value{"%不存在%"},
+			paging:   ` LIMIT $2`,
+			pageArgs: []driver.Value{20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, mock := newMockVectorStore(t)
+			mock.ExpectQuery("^" + regexp.QuoteMeta(`SELECT count(*) FROM "reimbursement_documents"`+tt.where) + "$").
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.total))
+			rows := sqlmock.NewRows([]string{"id", "file_name", "file_type", "category", "chunk_id", "chunk_index", "chunk_content", "created_at", "updated_at"})
+			for i := 0; i < tt.rowsCount; i++ {
+				rows.AddRow(fmt.Sprintf("v%d", i), "差旅费管理办法.pdf", "text", "差旅费", fmt.Sprintf("chunk_%d", i), i, "住宿费标准", createdAt, createdAt)
+			}
+			mock.ExpectQuery("^" + regexp.QuoteMeta(`SELECT `+columns+` FROM "reimbursement_documents"`+tt.where+order+tt.paging) + "$").
+				WithArgs(append(append([]driver.Value{}, tt.args...), tt.pageArgs...)...).
+				WillReturnRows(rows)
+
+			chunks, total, err := store.ListChunks(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("ListChunks() error = %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("SQL不符合预期: %v", err)
+			}
+			if total != tt.total || len(chunks) != tt.rowsCount {
+				t.Fatalf("total/len(chunks) = %d/%d, want %d/%d", total, len(chunks), tt.total, tt.rowsCount)
+			}
+			for i, chunk := range chunks {
+				want := &ChunkRecord{
+					ID:         fmt.Sprintf("v%d", i),
+					DocumentID: "差旅费管理办法.pdf",
+					ChunkID:    fmt.Sprintf("chunk_%d", i),
+					ChunkIndex: i,
+					Category:   "差旅费",
+					FileType:   "text",
+					Content:    "住宿费标准",
+					CreatedAt:  createdAt,
+					UpdatedAt:  createdAt,
+				}
+				if !reflect.DeepEqual(chunk, want) {
+					t.Errorf("chunks[%d] = %+v, want %+v", i, chunk, want)
+				}
+			}
+		})
+	}
+}
+
+func TestListChunksError(t *testing.T) {
+	store, mock := newMockVectorStore(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "reimbursement_documents"`)).WillReturnError(errors.New("连接已断开"))
+
+	if _, _, err := store.ListChunks(context.Background(), &ChunkFilter{}); err == nil {
+		t.Errorf("查询总数失败时应返回错误")
+	}
+}
@@ -0,0 +1,95 @@
+// date_validation.go 报销单日期一致性校验
+// 功能点：
+// 1. 费用发生日期不能晚于申请日期
+// 2. 出差开始日期和结束日期需同时填写，开始日期不能晚于结束日期，结束日期不能晚于申请日期
+// 3. 填写出差日期时，费用发生日期需在出差期间内
+// 4. 违规以字段错误列表返回，调用方可按字段定位问题
+
+package reimbursement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidDates 报销单日期不合法
+var ErrInvalidDates = errors.New("报销单日期不合法")
+
+// 报销单日期字段名，与JSON字段名一致
+const (
+	FieldApplyDate   = "apply_date"
+	FieldExpenseDate = "expense_date"
+	FieldStartDate   = "start_date"
+	FieldEndDate     = "end_date"
+)
+
+// DateFieldError 单个日期字段的校验错误
+type DateFieldError struct {
+	Field   string `json:"field"`   // 字段名
+	Message string `json:"message"` // 错误信息
+}
+
+// DateValidationError 报销单日期校验错误，包含全部不合法的字段
+type DateValidationError struct {
+	Fields []DateFieldError `json:"fields"`
+}
+
+// Error 实现error接口
+func (e *DateValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidDates, strings.Join(messages, "；"))
+}
+
+// Unwrap 支持errors.Is(err, ErrInvalidDates)
+func (e *DateValidationError) Unwrap() error {
+	return ErrInvalidDates
+}
+
+// add 记录一个字段错误
+func (e *DateValidationError) add(field, message string) {
+	e.Fields = append(e.Fields, DateFieldError{Field: field, Message: message})
+}
+
+// ValidateDates 校验报销单日期之间的一致性，日期按自然日比较，零值日期视为未填写
+// 全部日期合法时返回nil，否则返回*DateValidationError
+func ValidateDates(r *Reimbursement) error {
+	result := &DateValidationError{}
+
+	if !r.ExpenseDate.IsZero() && !r.ApplyDate.IsZero() && dateAfter(r.ExpenseDate, r.ApplyDate) {
+		result.add(FieldExpenseDate, "费用发生日期不能晚于申请日期")
+	}
+
+	switch {
+	case r.StartDate.IsZero() && r.EndDate.IsZero():
+	case r.StartDate.IsZero():
+		result.add(FieldStartDate, "填写出差结束日期时需同时填写出差开始日期")
+	case r.EndDate.IsZero():
+		result.add(FieldEndDate, "填写出差开始日期时需同时填写出差结束日期")
+	case dateAfter(r.StartDate, r.EndDate):
+		result.add(FieldEndDate, "出差结束日期不能早于出差开始日期")
+	default:
+		if !r.ApplyDate.IsZero() && dateAfter(r.EndDate, r.ApplyDate) {
+			result.add(FieldEndDate, "出差结束日期不能晚于申请日期")
+		}
+		if !r.ExpenseDate.IsZero() && (dateAfter(r.StartDate, r.ExpenseDate) || dateAfter(r.ExpenseDate, r.EndDate)) {
+			result.add(FieldExpenseDate, "费用发生日期应在出差期间内")
+		}
+	}
+
+	if len(result.Fields) == 0 {
+		return nil
+	}
+	return result
+}
+
+// dateAfter 判断日期a是否晚于日期b，只比较年月日
+func dateAfter(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC).After(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,108 @@
+// filter.go 报销单列表查询条件
+// 功能点：
+// 1. 定义报销单组合查询条件：用户、状态、部门、标签、申请日期范围、关键词、分页和排序
+// 2. 查询条件规范化：去除首尾空白，校验日期格式、排序字段和排序方向
+// 3. 排序字段限定在白名单内，生成的排序子句可直接用于数据库查询
+
+package reimbursement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidFilter 报销单查询条件不合法
+var ErrInvalidFilter = errors.New("报销单查询条件不合法")
+
+// 报销单列表排序方向
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// DefaultSortField 报销单列表默认排序字段
+const DefaultSortField = "created_at"
+
+// sortableFields 允许排序的字段
+var sortableFields = map[string]bool{
+	"created_at":   true,
+	"updated_at":   true,
+	"apply_date":   true,
+	"expense_date": true,
+	"total_amount": true,
+}
+
+// ReimbursementFilter 报销单列表查询条件，各条件为空时不限制
+type ReimbursementFilter struct {
+	UserID     string `json:"user_id"`    // 用户ID
+	Status     string `json:"status"`     // 报销单状态
+	Department string `json:"department"` // 所属部门
+	Tag        string `json:"tag"`        // 标签
+	StartDate  string `json:"start_date"` // 申请日期起(含)，格式：YYYY-MM-DD
+	EndDate    string `json:"end_date"`   // 申请日期止(含)，格式：YYYY-MM-DD
+	Keyword    string `json:"keyword"`    // 关键词，匹配申请人姓名、标题和描述
+	SortBy     string `json:"sort_by"`    // 排序字段，默认created_at
+	SortOrder  string `json:"sort_order"` // 排序方向(asc/desc)，默认desc
+	Page       int    `json:"page"`
+	Size       int    `json:"size"`
+}
+
+// Normalize 规范化查询条件，日期格式、排序字段或排序方向不合法时返回ErrInvalidFilter
+func (f *ReimbursementFilter) Normalize() error {
+	f.UserID = strings.TrimSpace(f.UserID)
+	f.Status = strings.TrimSpace(f.Status)
+	f.Department = strings.TrimSpace(f.Department)
+	f.Tag = strings.TrimSpace(f.Tag)
+	f.StartDate = strings.TrimSpace(f.StartDate)
+	f.EndDate = strings.TrimSpace(f.EndDate)
+	f.Keyword = strings.TrimSpace(f.Keyword)
+	f.SortBy = strings.ToLower(strings.TrimSpace(f.SortBy))
+	f.SortOrder = strings.ToLower(strings.TrimSpace(f.SortOrder))
+
+	var start, end time.Time
+	var err error
+	if f.StartDate != "" {
+		if start, err = time.Parse("2006-01-02", f.StartDate); err != nil {
+			return fmt.Errorf("%w: 开始日期格式不正确，应为YYYY-MM-DD", ErrInvalidFilter)
+		}
+	}
+	if f.EndDate != "" {
+		if end, err = time.Parse("2006-01-02", f.EndDate); err != nil {
+			return fmt.Errorf("%w: 结束日期格式不正确，应为YYYY-MM-DD", ErrInvalidFilter)
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		return fmt.Errorf("%w: 开始日期不能晚于结束日期", ErrInvalidFilter)
+	}
+
+	if f.SortBy == "" {
+		f.SortBy = DefaultSortField
+	}
+	if !sortableFields[f.SortBy] {
+		return fmt.Errorf("%w: 不支持按%s排序", ErrInvalidFilter, f.SortBy)
+	}
+	if f.SortOrder == "" {
+		f.SortOrder = SortDesc
+	}
+	if f.SortOrder != SortAsc && f.SortOrder != SortDesc {
+		return fmt.Errorf("%w: 排序方向只能为asc或desc", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// OrderClause 生成排序子句，排序字段不在白名单内时使用默认排序
+func (f *ReimbursementFilter) OrderClause() string {
+	field, order := DefaultSortField, SortDesc
+	if f != nil {
+		if sortBy := strings.ToLower(strings.TrimSpace(f.SortBy)); sortableFields[sortBy] {
+			field = sortBy
+		}
+		if strings.EqualFold(strings.TrimSpace(f.SortOrder), SortAsc) {
+			order = SortAsc
+		}
+	}
+	// 按id兜底排序，保证排序字段相同时分页结果稳定
+	return fmt.Sprintf("%s %s, id %s", field, strings.ToUpper(order), strings.ToUpper(order))
+}
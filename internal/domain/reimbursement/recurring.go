@@ -0,0 +1,98 @@
+// recurring.go 周期性报销（订阅类费用）
+// 功能点：
+// 1. 定义周期性报销的周期类型（按月/按季/按年）
+// 2. 规范化周期性报销标记：校验周期类型，订阅标识为空时使用报销事由
+// 3. 计算报销所属周期，用于区分正常的按期报销和同一周期内的重复报销
+
+package reimbursement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 周期性报销的周期类型
+const (
+	RecurrencePeriodMonthly   = "monthly"   // 按月
+	RecurrencePeriodQuarterly = "quarterly" // 按季
+	RecurrencePeriodYearly    = "yearly"    // 按年
+)
+
+// MaxRecurrenceKeyLength 订阅标识最大字符数
+const MaxRecurrenceKeyLength = 100
+
+// ErrInvalidRecurrence 周期性报销标记不合法
+var ErrInvalidRecurrence = errors.New("周期性报销标记不合法")
+
+// NormalizeRecurrence 规范化周期性报销标记，返回周期类型和订阅标识
+// 非周期性报销返回空值；周期类型为空时按月，订阅标识为空时使用fallbackKey（通常为报销事由）
+func NormalizeRecurrence(recurring bool, period, key, fallbackKey string) (string, string, error) {
+	if !recurring {
+		return "", "", nil
+	}
+
+	period = strings.ToLower(strings.TrimSpace(period))
+	switch period {
+	case "":
+		period = RecurrencePeriodMonthly
+	case RecurrencePeriodMonthly, RecurrencePeriodQuarterly, RecurrencePeriodYearly:
+	default:
+		return "", "", fmt.Errorf("%w: 不支持的周期类型[%s]（可选: %s/%s/%s）", ErrInvalidRecurrence,
+			period, RecurrencePeriodMonthly, RecurrencePeriodQuarterly, RecurrencePeriodYearly)
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		key = strings.TrimSpace(fallbackKey)
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("%w: 订阅标识不能为空", ErrInvalidRecurrence)
+	}
+	if utf8.RuneCountInString(key) > MaxRecurrenceKeyLength {
+		return "", "", fmt.Errorf("%w: 订阅标识超过%d个字符", ErrInvalidRecurrence, MaxRecurrenceKeyLength)
+	}
+
+	return period, key, nil
+}
+
+// RecurrencePeriodStart 获取日期所属周期的起始日期，未知周期类型按月计算
+func RecurrencePeriodStart(period string, date time.Time) time.Time {
+	year, month, _ := date.Date()
+	switch period {
+	case RecurrencePeriodQuarterly:
+		month = month - (month-1)%3
+	case RecurrencePeriodYearly:
+		month = time.January
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+}
+
+// RecurrenceDate 获取判断报销所属周期的日期，优先使用费用发生日期
+func (r *Reimbursement) RecurrenceDate() time.Time {
+	if !r.ExpenseDate.IsZero() {
+		return r.ExpenseDate
+	}
+	return r.ApplyDate
+}
+
+// IsSameSubscription 判断两张报销单是否为同一用户的同一订阅
+func (r *Reimbursement) IsSameSubscription(other *Reimbursement) bool {
+	if other == nil || !r.IsRecurring || !other.IsRecurring {
+		return false
+	}
+	return r.UserID == other.UserID &&
+		r.RecurrencePeriod == other.RecurrencePeriod &&
+		strings.EqualFold(r.RecurrenceKey, other.RecurrenceKey)
+}
+
+// InSameRecurrencePeriod 判断同一订阅的两张报销单是否落在同一周期内
+func (r *Reimbursement) InSameRecurrencePeriod(other *Reimbursement) bool {
+	if !r.IsSameSubscription(other) {
+		return false
+	}
+	return RecurrencePeriodStart(r.RecurrencePeriod, r.RecurrenceDate()).
+		Equal(RecurrencePeriodStart(other.RecurrencePeriod, other.RecurrenceDate()))
+}
@@ -0,0 +1,122 @@
+package reimbursement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRecurrence(t *testing.T) {
+	tests := []struct {
+		name        string
+		recurring   bool
+		period      string
+		key         string
+		fallbackKey string
+		wantPeriod  string
+		wantKey     string
+		wantErr     bool
+	}{
+		{name: "非周期性报销返回空值", period: "yearly", key: "云服务"},
+		{name: "周期为空时按月", recurring: true, key: " 云服务 ", wantPeriod: RecurrencePeriodMonthly, wantKey: "云服务"},
+		{name: "周期忽略大小写", recurring: true, period: " Quarterly ", key: "云服务", wantPeriod: RecurrencePeriodQuarterly, wantKey: "云服务"},
+		{name: "订阅标识为空时使用报销事由", recurring: true, period: "yearly", fallbackKey: "软件订阅", wantPeriod: RecurrencePeriodYearly, wantKey: "软件订阅"},
+		{name: "不支持的周期类型", recurring: true, period: "weekly", key: "云服务", wantErr: true},
+		{name: "订阅标识和事由都为空", recurring: true, key: " ", wantErr: true},
+		{name: "订阅标识超长", recurring: true, key: strings.Repeat("长", MaxRecurrenceKeyLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, key, err := NormalizeRecurrence(tt.recurring, tt.period, tt.key, tt.fallbackKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidRecurrence) {
+				t.Errorf("NormalizeRecurrence() error = %v, want ErrInvalidRecurrence", err)
+			}
+			if period != tt.wantPeriod || key != tt.wantKey {
+				t.Errorf("NormalizeRecurrence() = (%q, %q), want (%q, %q)", period, key, tt.wantPeriod, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestRecurrencePeriodStart(t *testing.T) {
+	date := time.Date(2024, time.August, 15, 10, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		period string
+		want   time.Time
+	}{
+		{name: "按月", period: RecurrencePeriodMonthly, want: time.Date(2024, time.August, 1, 0, 0, 0, 0, time.Local)},
+		{name: "按季", period: RecurrencePeriodQuarterly, want: time.Date(2024, time.July, 1, 0, 0, 0, 0, time.Local)},
+		{name: "按年", period: RecurrencePeriodYearly, want: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.Local)},
+		{name: "未知周期按月", period: "weekly", want: time.Date(2024, time.August, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RecurrencePeriodStart(tt.period, date); !got.Equal(tt.want) {
+				t.Errorf("RecurrencePeriodStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInSameRecurrencePeriod(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.Local)
+	}
+	current := &Reimbursement{
+		UserID: "u1", IsRecurring: true, RecurrencePeriod: RecurrencePeriodMonthly, RecurrenceKey: "云服务",
+		ExpenseDate: day(time.August, 20), ApplyDate: day(time.September, 2),
+	}
+
+	tests := []struct {
+		name             string
+		other            *Reimbursement
+		wantSubscription bool
+		wantSamePeriod   bool
+	}{
+		{
+			name:             "同一订阅同一周期",
+			other:            &Reimbursement{UserID: "u1", IsRecurring: true, RecurrencePeriod: RecurrencePeriodMonthly, RecurrenceKey: "云服务", ExpenseDate: day(time.August, 1)},
+			wantSubscription: true,
+			wantSamePeriod:   true,
+		},
+		{
+			name:             "未填费用日期时按申请日期判断周期",
+			other:            &Reimbursement{UserID: "u1", IsRecurring: true, RecurrencePeriod: RecurrencePeriodMonthly, RecurrenceKey: "云服务", ApplyDate: day(time.August, 31)},
+			wantSubscription: true,
+			wantSamePeriod:   true,
+		},
+		{
+			name:             "同一订阅不同周期",
+			other:            &Reimbursement{UserID: "u1", IsRecurring: true, RecurrencePeriod: RecurrencePeriodMonthly, RecurrenceKey: "云服务", ExpenseDate: day(time.July, 20)},
+			wantSubscription: true,
+		},
+		{
+			name:  "不同用户",
+			other: &Reimbursement{UserID: "u2", IsRecurring: true, RecurrencePeriod: RecurrencePeriodMonthly, RecurrenceKey: "云服务", ExpenseDate: day(time.August, 1)},
+		},
+		{
+			name:  "非周期性报销",
+			other: &Reimbursement{UserID: "u1", RecurrencePeriod: RecurrencePeriodMonthly, RecurrenceKey: "云服务", ExpenseDate: day(time.August, 1)},
+		},
+		{name: "nil报销单", other: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := current.IsSameSubscription(tt.other); got != tt.wantSubscription {
+				t.Errorf("IsSameSubscription() = %v, want %v", got, tt.wantSubscription)
+			}
+			if got := current.InSameRecurrencePeriod(tt.other); got != tt.wantSamePeriod {
+				t.Errorf("InSameRecurrencePeriod() = %v, want %v", got, tt.wantSamePeriod)
+			}
+		})
+	}
+}
@@ -7,9 +7,12 @@ package reimbursement
 // 4. 提供数据访问抽象层
 // 5. 支持事务管理
 // 6. 支持查询和分页
+// 7. 支持报销单标签维护和按标签筛选
+// 8. 支持按用户查询近期报销单，用于周期性报销和频次校验
 
 import (
 	"context"
+	"time"
 )
 
 // Repository 报销单仓储接口
@@ -23,6 +26,11 @@ type Repository interface {
 	ListReimbursementsByDateRange(ctx context.Context, startDate, endDate string, page, size int) ([]*Reimbursement, int64, error)
 	ListReimbursementsByStatus(ctx context.Context, status string, page, size int) ([]*Reimbursement, int64, error)
 	SearchReimbursements(ctx context.Context, keyword string, page, size int) ([]*Reimbursement, int64, error)
+	ListReimbursements(ctx context.Context, filter *ReimbursementFilter) ([]*Reimbursement, int64, error)
+	ListUserReimbursementsSince(ctx context.Context, userID string, since time.Time, statuses []string) ([]*Reimbursement, error)
+
+	// 标签相关方法
+	ReplaceTags(ctx context.Context, reimbursementID string, tags []string) error
 
 	// 审核结果相关方法
 	// CreateAuditResult(ctx context.Context, result *AuditResult) error
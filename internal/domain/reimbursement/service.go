@@ -4,6 +4,10 @@
 // 2. 处理发票相关的业务逻辑
 // 3. 提供领域模型验证
 // 4. 封装复杂的业务计算
+// 5. 维护报销单标签，支持按标签筛选报销单列表
+// 6. 支持标记周期性报销（订阅类费用）
+// 7. 创建报销单时校验费用日期、申请日期和出差日期之间的一致性
+// 8. 报销单列表支持用户、状态、部门、标签、日期范围、关键词组合筛选和排序
 
 package reimbursement
 
@@ -28,19 +32,32 @@ type Service interface {
 
 	// ValidateInvoice 验证发票
 	ValidateInvoice(ctx context.Context, invoice *ocr.Invoice) error
+
+	// UpdateTags 更新报销单标签（整体替换）
+	UpdateTags(ctx context.Context, reimbursementID string, tags []string) (*Reimbursement, error)
+
+	// ListReimbursements 按条件分页查询报销单
+	ListReimbursements(ctx context.Context, filter *ReimbursementFilter) ([]*Reimbursement, int64, error)
 }
 
 // CreateReimbursementRequest 创建报销单请求
 type CreateReimbursementRequest struct {
-	UserID      string  `json:"user_id"`
-	UserName    string  `json:"user_name"`
-	Department  string  `json:"department"`
-	Category    string  `json:"category"`
-	Reason      string  `json:"reason"`
-	Description string  `json:"description"`
-	TotalAmount float64 `json:"total_amount"`
-	ApplyDate   string  `json:"apply_date"`
-	ExpenseDate string  `json:"expense_date"`
+	UserID      string   `json:"user_id"`
+	UserName    string   `json:"user_name"`
+	Department  string   `json:"department"`
+	Category    string   `json:"category"`
+	Reason      string   `json:"reason"`
+	Description string   `json:"description"`
+	TotalAmount float64  `json:"total_amount"`
+	ApplyDate   string   `json:"apply_date"`
+	ExpenseDate string   `json:"expense_date"`
+	StartDate   string   `json:"start_date"`
+	EndDate     string   `json:"end_date"`
+	Tags        []string `json:"tags"`
+
+	IsRecurring      bool   `json:"is_recurring"`
+	RecurrencePeriod string `json:"recurrence_period"`
+	RecurrenceKey    string `json:"recurrence_key"`
 }
 
 // DomainService 报销单领域服务实现
@@ -76,6 +93,20 @@ func (s *DomainService) CreateReimbursement(ctx context.Context, req *CreateReim
 			logger.NewField("expense_date", req.ExpenseDate))
 		return nil, err
 	}
+	startDate, endDate, err := s.parseTripDates(ctx, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	recurrencePeriod, recurrenceKey, err := NormalizeRecurrence(req.IsRecurring, req.RecurrencePeriod, req.RecurrenceKey, req.Reason)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建报销单领域模型
 	now := time.Now()
@@ -91,9 +122,16 @@ func (s *DomainService) CreateReimbursement(ctx context.Context, req *CreateReim
 		Currency:    "CNY", // 默认使用人民币
 		ApplyDate:   applyDate,
 		ExpenseDate: expenseDate,
-		Status:      "待提交", // 初始状态为"待提交"
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Status:      StatusDraft, // 初始状态为"待提交"
+		Tags:        tags,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		IsRecurring:      req.IsRecurring,
+		RecurrencePeriod: recurrencePeriod,
+		RecurrenceKey:    recurrenceKey,
 	}
 
 	// 验证报销单
@@ -115,6 +153,54 @@ func (s *DomainService) CreateReimbursement(ctx context.Context, req *CreateReim
 	return reimbursement, nil
 }
 
+// UpdateTags 更新报销单标签，传入的标签整体替换原有标签
+func (s *DomainService) UpdateTags(ctx context.Context, reimbursementID string, tags []string) (*Reimbursement, error) {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	reimbursement, err := s.repo.GetReimbursementByID(ctx, reimbursementID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceTags(ctx, reimbursementID, normalized); err != nil {
+		s.logger.WithContext(ctx).Error("更新报销单标签失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("reimbursement_id", reimbursementID))
+		return nil, err
+	}
+
+	reimbursement.Tags = normalized
+	return reimbursement, nil
+}
+
+// ListReimbursements 按条件分页查询报销单，分页参数非法时使用默认值，其他查询条件不合法时返回ErrInvalidFilter
+func (s *DomainService) ListReimbursements(ctx context.Context, filter *ReimbursementFilter) ([]*Reimbursement, int64, error) {
+	if filter == nil {
+		filter = &ReimbursementFilter{}
+	}
+	if err := filter.Normalize(); err != nil {
+		return nil, 0, err
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Size <= 0 || filter.Size > 100 {
+		filter.Size = 20
+	}
+
+	reimbursements, total, err := s.repo.ListReimbursements(ctx, filter)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询报销单列表失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("tag", filter.Tag))
+		return nil, 0, err
+	}
+	return reimbursements, total, nil
+}
+
 // ValidateReimbursement 验证报销单
 func (s *DomainService) ValidateReimbursement(ctx context.Context, reimbursement *Reimbursement) error {
 	// 基本字段验证
@@ -137,8 +223,9 @@ func (s *DomainService) ValidateReimbursement(ctx context.Context, reimbursement
 		return errors.New("费用发生日期不能是未来日期")
 	}
 
-	if reimbursement.ExpenseDate.After(reimbursement.ApplyDate) {
-		return errors.New("费用发生日期不能晚于申请日期")
+	// 日期一致性验证（费用日期、申请日期、出差日期）
+	if err := ValidateDates(reimbursement); err != nil {
+		return err
 	}
 
 	// 可以添加更多业务规则验证...
@@ -199,3 +286,31 @@ func (s *DomainService) parseDates(ctx context.Context, applyDateStr, expenseDat
 
 	return applyDate, expenseDate, nil
 }
+
+// parseTripDates 解析出差开始日期和结束日期，未填写时为零值
+func (s *DomainService) parseTripDates(ctx context.Context, startDateStr, endDateStr string) (time.Time, time.Time, error) {
+	var startDate, endDate time.Time
+	var err error
+
+	if startDateStr != "" {
+		startDate, err = time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("出差开始日期格式不正确",
+				logger.NewField("error", err.Error()),
+				logger.NewField("start_date", startDateStr))
+			return time.Time{}, time.Time{}, errors.New("出差开始日期格式不正确，应为YYYY-MM-DD")
+		}
+	}
+
+	if endDateStr != "" {
+		endDate, err = time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("出差结束日期格式不正确",
+				logger.NewField("error", err.Error()),
+				logger.NewField("end_date", endDateStr))
+			return time.Time{}, time.Time{}, errors.New("出差结束日期格式不正确，应为YYYY-MM-DD")
+		}
+	}
+
+	return startDate, endDate, nil
+}
@@ -0,0 +1,124 @@
+// status.go 报销单审批流状态机
+// 功能点：
+// 1. 定义报销单状态（待提交/待审核/审核中/已完成/已驳回）
+// 2. 集中管理允许的状态转移，非法转移返回明确错误
+// 3. 仓储更新报销单时按允许的前置状态条件更新，无法绕过状态机写入非法状态
+// 4. 计算开始审核、得出审批结论需要依次经过的状态，由审核流程逐步流转
+
+package reimbursement
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 报销单状态
+const (
+	StatusDraft    = "待提交"
+	StatusPending  = "待审核"
+	StatusAuditing = "审核中"
+	StatusApproved = "已完成"
+	StatusRejected = "已驳回"
+)
+
+// ErrInvalidStatusTransition 报销单状态流转不合法
+var ErrInvalidStatusTransition = errors.New("报销单状态流转不合法")
+
+// statusTransitions 各状态允许转移到的状态
+// 审批结论(已完成/已驳回)只能由审核中得出，已审结的报销单需重新进入审核中才能改判
+var statusTransitions = map[string][]string{
+	StatusDraft:    {StatusPending},
+	StatusPending:  {StatusAuditing, StatusDraft},                   // 开始审核，或撤回修改
+	StatusAuditing: {StatusApproved, StatusRejected, StatusPending}, // 审核失败时退回待审核，等待重试
+	StatusApproved: {StatusAuditing},                                // 人工改判时重新进入审核
+	StatusRejected: {StatusAuditing, StatusDraft},                   // 人工改判时重新进入审核，或退回修改后重新提交
+}
+
+// IsValidStatus 判断是否为已定义的报销单状态
+func IsValidStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+// ValidateStatusTransition 校验状态转移是否合法，状态不变视为合法
+func ValidateStatusTransition(from, to string) error {
+	if !IsValidStatus(to) {
+		return fmt.Errorf("%w: 未知状态%s", ErrInvalidStatusTransition, to)
+	}
+	if from == to {
+		return nil
+	}
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: 不能从%s变更为%s", ErrInvalidStatusTransition, from, to)
+}
+
+// StatusPredecessors 获取可以转移到指定状态的状态（含该状态本身），未知状态返回空
+func StatusPredecessors(to string) []string {
+	if !IsValidStatus(to) {
+		return nil
+	}
+	predecessors := []string{to}
+	for _, from := range []string{StatusDraft, StatusPending, StatusAuditing, StatusApproved, StatusRejected} {
+		if from != to && ValidateStatusTransition(from, to) == nil {
+			predecessors = append(predecessors, from)
+		}
+	}
+	return predecessors
+}
+
+// CanTransitionTo 判断报销单能否转移到指定状态
+func (r *Reimbursement) CanTransitionTo(status string) bool {
+	return ValidateStatusTransition(r.Status, status) == nil
+}
+
+// AuditStartPath 获取报销单开始审核需要依次经过的状态
+// 待提交的报销单视为提交后进入审核；已审结的报销单重新进入审核中；当前已在审核中时返回空
+func (r *Reimbursement) AuditStartPath() ([]string, error) {
+	switch r.Status {
+	case StatusAuditing:
+		return nil, nil
+	case StatusDraft:
+		return []string{StatusPending, StatusAuditing}, nil
+	}
+	if err := ValidateStatusTransition(r.Status, StatusAuditing); err != nil {
+		return nil, err
+	}
+	return []string{StatusAuditing}, nil
+}
+
+// DecisionPath 获取报销单从当前状态得出审批结论需要依次经过的状态
+// 当前不在审核中时先进入审核中；当前已是该结论时返回空；路径不合法时返回错误
+func (r *Reimbursement) DecisionPath(decision string) ([]string, error) {
+	if decision != StatusApproved && decision != StatusRejected {
+		return nil, fmt.Errorf("%w: %s不是审批结论", ErrInvalidStatusTransition, decision)
+	}
+	if r.Status == decision {
+		return nil, nil
+	}
+
+	path := []string{decision}
+	if r.Status != StatusAuditing {
+		path = []string{StatusAuditing, decision}
+	}
+	from := r.Status
+	for _, to := range path {
+		if err := ValidateStatusTransition(from, to); err != nil {
+			return nil, err
+		}
+		from = to
+	}
+	return path, nil
+}
+
+// TransitionTo 将报销单转移到指定状态，非法转移时返回错误且不修改状态
+func (r *Reimbursement) TransitionTo(status string) error {
+	if err := ValidateStatusTransition(r.Status, status); err != nil {
+		return err
+	}
+	r.Status = status
+	return nil
+}
@@ -0,0 +1,128 @@
+package reimbursement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  bool
+	}{
+		{StatusDraft, StatusPending, false},
+		{StatusDraft, StatusAuditing, true},
+		{StatusPending, StatusAuditing, false},
+		{StatusPending, StatusDraft, false},
+		{StatusPending, StatusApproved, true},
+		{StatusPending, StatusRejected, true},
+		{StatusAuditing, StatusApproved, false},
+		{StatusAuditing, StatusRejected, false},
+		{StatusAuditing, StatusPending, false},
+		{StatusApproved, StatusRejected, true},
+		{StatusApproved, StatusAuditing, false},
+		{StatusApproved, StatusDraft, true},
+		{StatusRejected, StatusApproved, true},
+		{StatusRejected, StatusAuditing, false},
+		{StatusRejected, StatusDraft, false},
+		{StatusApproved, StatusApproved, false},
+		{StatusPending, "未知", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			err := ValidateStatusTransition(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateStatusTransition(%s, %s) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidStatusTransition) {
+				t.Fatalf("错误应包装ErrInvalidStatusTransition: %v", err)
+			}
+		})
+	}
+}
+
+func TestStatusPredecessors(t *testing.T) {
+	tests := []struct {
+		to   string
+		want []string
+	}{
+		{StatusApproved, []string{StatusApproved, StatusAuditing}},
+		{StatusRejected, []string{StatusRejected, StatusAuditing}},
+		{StatusAuditing, []string{StatusAuditing, StatusPending, StatusApproved, StatusRejected}},
+		{StatusDraft, []string{StatusDraft, StatusPending, StatusRejected}},
+		{"未知", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.to, func(t *testing.T) {
+			if got := StatusPredecessors(tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("StatusPredecessors(%s) = %v, want %v", tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditStartPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "待提交先提交再进入审核中", status: StatusDraft, want: []string{StatusPending, StatusAuditing}},
+		{name: "待审核进入审核中", status: StatusPending, want: []string{StatusAuditing}},
+		{name: "已在审核中无需流转", status: StatusAuditing, want: nil},
+		{name: "已完成重新进入审核中", status: StatusApproved, want: []string{StatusAuditing}},
+		{name: "已驳回重新进入审核中", status: StatusRejected, want: []string{StatusAuditing}},
+		{name: "未知状态", status: "已归档", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reimbursement{Status: tt.status}
+			got, err := r.AuditStartPath()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuditStartPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("AuditStartPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		decision string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "审核中直接得出结论", status: StatusAuditing, decision: StatusApproved, want: []string{StatusApproved}},
+		{name: "待审核先进入审核中", status: StatusPending, decision: StatusRejected, want: []string{StatusAuditing, StatusRejected}},
+		{name: "已完成改判为驳回经过审核中", status: StatusApproved, decision: StatusRejected, want: []string{StatusAuditing, StatusRejected}},
+		{name: "已驳回改判为通过经过审核中", status: StatusRejected, decision: StatusApproved, want: []string{StatusAuditing, StatusApproved}},
+		{name: "结论未变化无需流转", status: StatusApproved, decision: StatusApproved, want: nil},
+		{name: "待提交不能得出结论", status: StatusDraft, decision: StatusApproved, wantErr: true},
+		{name: "非审批结论", status: StatusAuditing, decision: StatusPending, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reimbursement{Status: tt.status}
+			got, err := r.DecisionPath(tt.decision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecisionPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("DecisionPath() = %v, want %v", got, tt.want)
+			}
+			if r.Status != tt.status {
+				t.Fatalf("DecisionPath()不应修改报销单状态")
+			}
+		})
+	}
+}
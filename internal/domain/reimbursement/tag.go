@@ -0,0 +1,59 @@
+// tag.go 报销单标签
+// 功能点：
+// 1. 定义报销单标签持久化模型
+// 2. 标签规范化：去除首尾空白、去重，限制单个标签长度和标签数量
+
+package reimbursement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 标签限制
+const (
+	MaxTagLength = 50 // 单个标签最大字符数
+	MaxTagCount  = 20 // 单个报销单最多标签数
+)
+
+// ErrInvalidTags 标签不合法
+var ErrInvalidTags = errors.New("标签不合法")
+
+// ReimbursementTag 报销单标签模型，用于按标签（如"年会"）做专项分析
+type ReimbursementTag struct {
+	ID              string    `json:"id" gorm:"primaryKey;type:varchar(36);column:id"`                                                            // 标签记录ID
+	ReimbursementID string    `json:"reimbursement_id" gorm:"type:varchar(36);not null;uniqueIndex:uk_reimbursement_tag;column:reimbursement_id"` // 报销单ID
+	Tag             string    `json:"tag" gorm:"type:varchar(50);not null;uniqueIndex:uk_reimbursement_tag;index:idx_tag;column:tag"`             // 标签
+	CreatedAt       time.Time `json:"created_at" gorm:"type:datetime;not null;column:created_at"`                                                 // 创建时间
+}
+
+// TableName 指定报销单标签表名
+func (ReimbursementTag) TableName() string {
+	return "reimbursement_tags"
+}
+
+// NormalizeTags 规范化标签：去除首尾空白、忽略空标签并去重（保持原有顺序）
+// 单个标签超过MaxTagLength个字符或标签数超过MaxTagCount时返回ErrInvalidTags
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, fmt.Errorf("%w: 标签[%s]超过%d个字符", ErrInvalidTags, tag, MaxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxTagCount {
+		return nil, fmt.Errorf("%w: 标签数量不能超过%d个", ErrInvalidTags, MaxTagCount)
+	}
+	return normalized, nil
+}
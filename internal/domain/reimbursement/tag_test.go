@@ -0,0 +1,48 @@
+package reimbursement
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, MaxTagCount+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("标签%d", i)
+	}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "去除空白并去重，保持顺序", tags: []string{" 年会 ", "差旅", "年会", ""}, want: []string{"年会", "差旅"}},
+		{name: "空列表", tags: nil, want: []string{}},
+		{name: "标签恰好为最大长度", tags: []string{strings.Repeat("长", MaxTagLength)}, want: []string{strings.Repeat("长", MaxTagLength)}},
+		{name: "标签超过最大长度", tags: []string{strings.Repeat("长", MaxTagLength+1)}, wantErr: true},
+		{name: "标签数量超过上限", tags: tooMany, wantErr: true},
+		{name: "重复标签去重后不超过上限", tags: append(tooMany[:MaxTagCount:MaxTagCount], tooMany[0]), want: tooMany[:MaxTagCount]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTags(tt.tags)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTags) {
+					t.Fatalf("NormalizeTags() error = %v, want ErrInvalidTags", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeTags() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
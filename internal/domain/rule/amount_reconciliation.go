@@ -0,0 +1,177 @@
+// amount_reconciliation.go 报销单总额与发票价税合计的一致性校验
+// 功能点：
+// 1. 汇总报销单全部发票的价税合计，与报销单总额比对，允许可配置的误差阈值(默认0.01元)
+// 2. 外币发票和外币报销单先按汇率折算为人民币再比较
+// 3. 不一致时违规信息中列出报销单总额、发票合计和差额
+// 4. 对账结果属于报销单级校验，只在报销单的第一张发票上报告，避免逐张发票重复违规
+
+package rule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/domain/reimbursement"
+)
+
+// DefaultAmountTolerance 报销单总额与发票合计默认允许的误差(元)
+const DefaultAmountTolerance = 0.01
+
+// amountReconciliationRuleCode 报销单总额与发票合计一致性规则编码
+const amountReconciliationRuleCode = "RULE_INVOICE_AMOUNT_RECONCILIATION"
+
+// AmountReconciliation 报销单总额与发票价税合计的对账结果，金额均为人民币
+type AmountReconciliation struct {
+	ReimbursementTotal float64 `json:"reimbursement_total"` // 报销单总额
+	InvoiceTotal       float64 `json:"invoice_total"`       // 发票价税合计之和
+	Difference         float64 `json:"difference"`          // 差额(报销单总额-发票合计)
+	Tolerance          float64 `json:"tolerance"`           // 允许的误差
+	Matched            bool    `json:"matched"`             // 差额是否在误差范围内
+	Error              string  `json:"error,omitempty"`     // 无法对账的原因(如缺少汇率)
+}
+
+// Mismatched 判断报销单总额与发票合计是否不一致，无法对账时不视为不一致
+func (r *AmountReconciliation) Mismatched() bool {
+	return r != nil && r.Error == "" && !r.Matched
+}
+
+// ReconcileInvoiceAmounts 比对报销单总额与发票价税合计之和
+// 外币发票按开票日期的汇率折算，外币报销单按申请日期的汇率折算；tolerance非正数时使用默认误差
+func ReconcileInvoiceAmounts(ctx context.Context, provider ocr.ExchangeRateProvider, reim *reimbursement.Reimbursement, tolerance float64) (*AmountReconciliation, error) {
+	if reim == nil {
+		return nil, errors.New("缺少报销单")
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultAmountTolerance
+	}
+
+	reimbursementTotal, err := reimbursementBaseAmount(ctx, provider, reim)
+	if err != nil {
+		return nil, err
+	}
+
+	invoiceTotal := 0.0
+	for _, invoice := range reim.Invoices {
+		if invoice == nil {
+			continue
+		}
+		amount, err := invoiceBaseAmount(ctx, provider, invoice)
+		if err != nil {
+			return nil, fmt.Errorf("发票%s金额折算失败: %w", invoice.Number, err)
+		}
+		invoiceTotal += amount
+	}
+
+	reimbursementTotal = roundAmount(reimbursementTotal)
+	invoiceTotal = roundAmount(invoiceTotal)
+	difference := roundAmount(reimbursementTotal - invoiceTotal)
+	return &AmountReconciliation{
+		ReimbursementTotal: reimbursementTotal,
+		InvoiceTotal:       invoiceTotal,
+		Difference:         difference,
+		Tolerance:          tolerance,
+		// 差额已舍入到分，加极小值避免浮点误差导致恰好等于阈值时误判
+		Matched: math.Abs(difference) <= tolerance+1e-9,
+	}, nil
+}
+
+// reimbursementBaseAmount 获取报销单总额的人民币金额
+func reimbursementBaseAmount(ctx context.Context, provider ocr.ExchangeRateProvider, reim *reimbursement.Reimbursement) (float64, error) {
+	currency := strings.ToUpper(strings.TrimSpace(reim.Currency))
+	if currency == "" || currency == ocr.BaseCurrency {
+		return reim.TotalAmount, nil
+	}
+	amount, _, err := ocr.ConvertToBaseCurrency(ctx, provider, reim.TotalAmount, currency, reim.ApplyDate)
+	if err != nil {
+		return 0, fmt.Errorf("报销单总额折算失败: %w", err)
+	}
+	return amount, nil
+}
+
+// invoiceBaseAmount 获取发票价税合计的人民币金额
+// 外币发票优先按开票日期的汇率重新折算原币金额；未配置汇率数据源时使用识别时已折算的金额
+func invoiceBaseAmount(ctx context.Context, provider ocr.ExchangeRateProvider, invoice *ocr.Invoice) (float64, error) {
+	if !invoice.IsForeignCurrency() {
+		return invoice.Amount, nil
+	}
+	if provider == nil && invoice.ExchangeRate > 0 && invoice.Amount > 0 {
+		return invoice.Amount, nil
+	}
+	amount, _, err := ocr.ConvertToBaseCurrency(ctx, provider, invoice.OriginalAmount, invoice.OriginalCurrency, invoice.Date)
+	return amount, err
+}
+
+// roundAmount 金额保留两位小数
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// SetAmountTolerance 设置报销单总额与发票合计允许的误差(元)，非正数时使用默认误差
+func (v *InvoiceValidatorImpl) SetAmountTolerance(tolerance float64) {
+	if tolerance <= 0 {
+		tolerance = DefaultAmountTolerance
+	}
+	v.amountTolerance = tolerance
+}
+
+// SetExchangeRateProvider 设置汇率数据源，用于外币发票和外币报销单的金额折算
+func (v *InvoiceValidatorImpl) SetExchangeRateProvider(provider ocr.ExchangeRateProvider) {
+	v.rateProvider = provider
+}
+
+// reconcileAmounts 对待校验发票所在的报销单对账
+// 只在报销单的第一张发票上对账，其余发票及无报销单时返回nil；无法对账时在结果中记录原因
+func (v *InvoiceValidatorImpl) reconcileAmounts(ctx context.Context, current *ocr.Invoice, reim *reimbursement.Reimbursement) *AmountReconciliation {
+	if reim == nil || !isFirstInvoice(current, reim) {
+		return nil
+	}
+	reconciliation, err := ReconcileInvoiceAmounts(ctx, v.rateProvider, reim, v.amountTolerance)
+	if err != nil {
+		return &AmountReconciliation{Tolerance: v.amountTolerance, Error: err.Error()}
+	}
+	return reconciliation
+}
+
+// isFirstInvoice 判断待校验发票是否为报销单的第一张发票
+func isFirstInvoice(current *ocr.Invoice, reim *reimbursement.Reimbursement) bool {
+	for _, invoice := range reim.Invoices {
+		if invoice == nil {
+			continue
+		}
+		return current != nil && invoice.ID == current.ID
+	}
+	return false
+}
+
+// annotateAmountViolation 金额不一致违规时在违规描述中列出报销单总额、发票合计和差额
+func annotateAmountViolation(rule *RuleDefinition, violation *InvoiceViolation, reconciliation *AmountReconciliation) {
+	if !reconciliation.Mismatched() || !isAmountReconciliationRule(rule) {
+		return
+	}
+	violation.Message = fmt.Sprintf("%s（报销单总额%.2f元，发票合计%.2f元，差额%.2f元）",
+		violation.Message, reconciliation.ReimbursementTotal, reconciliation.InvoiceTotal, reconciliation.Difference)
+}
+
+// isAmountReconciliationRule 判断是否为报销单总额与发票合计一致性规则
+func isAmountReconciliationRule(rule *RuleDefinition) bool {
+	return rule != nil && rule.RuleCode == amountReconciliationRuleCode
+}
+
+// amountVariables 金额一致性相关的违规说明模板变量
+func amountVariables(data *InvoiceValidationData) map[string]interface{} {
+	variables := map[string]interface{}{
+		"ReimbursementTotal": data.ReimbursementTotal,
+		"InvoiceTotal":       0.0,
+		"AmountDifference":   0.0,
+	}
+	if data.AmountReconciliation != nil {
+		variables["ReimbursementTotal"] = data.AmountReconciliation.ReimbursementTotal
+		variables["InvoiceTotal"] = data.AmountReconciliation.InvoiceTotal
+		variables["AmountDifference"] = data.AmountReconciliation.Difference
+	}
+	return variables
+}
@@ -0,0 +1,57 @@
+// applicable_rules.go 报销单适用规则筛选
+// 功能点：
+// 1. 按报销类别筛选规则：归属某一报销类别的规则只对该类别生效，其余规则对所有类别生效
+// 2. 按启用状态、生效时间段和报销类别筛选出审核时执行的规则，按执行顺序（优先级）排列
+// 3. 只执行适用规则的规则校验，与适用规则预览使用同一筛选逻辑
+
+package rule
+
+import (
+	"context"
+	"time"
+)
+
+// AppliesToCategory 判断规则是否适用于报销类别
+// 规则分类为报销类别时仅对该类别生效；分类为空或非报销类别(如发票校验)时对所有类别生效
+func (r *Rule) AppliesToCategory(category string) bool {
+	if r.Category == "" || category == "" || r.Category == category {
+		return true
+	}
+	return !isReimbursementCategory(r.Category)
+}
+
+// isReimbursementCategory 判断分类是否为报销类别
+func isReimbursementCategory(category string) bool {
+	for _, c := range DefaultReimbursementCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// ListApplicableRules 获取报销类别在指定日期（通常为报销申请日期）适用的规则，按执行顺序排列
+func (s *RuleService) ListApplicableRules(ctx context.Context, category string, date time.Time) ([]*Rule, error) {
+	rules, err := s.listEnabledRules(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	applicable := make([]*Rule, 0, len(rules))
+	for _, rule := range filterEffectiveRules(rules, date) {
+		if rule.AppliesToCategory(category) {
+			applicable = append(applicable, rule)
+		}
+	}
+	return s.SortRulesByPriority(applicable), nil
+}
+
+// ValidateApplicableRules 执行报销类别在指定日期适用的全部规则校验
+func (s *RuleService) ValidateApplicableRules(ctx context.Context, data interface{}, category string, date time.Time) ([]*RuleValidationResult, error) {
+	rules, err := s.ListApplicableRules(ctx, category, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeRules(ctx, rules, data)
+}
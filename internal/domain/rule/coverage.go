@@ -0,0 +1,132 @@
+// coverage.go 规则覆盖度报告
+// 功能点：
+// 1. 按报销类别统计已启用的规则
+// 2. 标记没有任何专属规则的类别（此类审核仅依赖RAG分析）
+// 3. 汇总多个类别的覆盖情况
+
+package rule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// DefaultReimbursementCategories 默认参与覆盖度统计的报销类别
+var DefaultReimbursementCategories = []string{"差旅费", "办公费", "招待费", "通讯费", "交通费", "培训费"}
+
+// CoverageRule 覆盖度报告中的规则摘要
+type CoverageRule struct {
+	ID       string `json:"id"`        // 规则ID
+	RuleCode string `json:"rule_code"` // 规则编码
+	Name     string `json:"name"`      // 规则名称
+	Type     string `json:"type"`      // 规则类型
+	Priority int    `json:"priority"`  // 优先级
+}
+
+// CategoryCoverage 单个报销类别的规则覆盖情况
+type CategoryCoverage struct {
+	Category  string          `json:"category"`   // 报销类别
+	RuleCount int             `json:"rule_count"` // 已启用规则数量
+	Rules     []*CoverageRule `json:"rules"`      // 已启用规则列表
+	Covered   bool            `json:"covered"`    // 是否存在已启用规则
+	Warning   string          `json:"warning"`    // 覆盖不足提示
+}
+
+// CoverageReport 规则覆盖度报告
+type CoverageReport struct {
+	Categories          []*CategoryCoverage `json:"categories"`           // 各类别覆盖情况
+	UncoveredCount      int                 `json:"uncovered_count"`      // 无规则覆盖的类别数量
+	UncoveredCategories []string            `json:"uncovered_categories"` // 无规则覆盖的类别
+}
+
+// GetCategoryCoverage 获取指定报销类别的规则覆盖情况
+func (s *RuleService) GetCategoryCoverage(ctx context.Context, category string) (*CategoryCoverage, error) {
+	if category == "" {
+		return nil, fmt.Errorf("报销类别不能为空")
+	}
+
+	enabled := true
+	rules, _, err := s.repo.ListRules(ctx, &RuleFilter{Category: category, Enabled: &enabled})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("查询类别规则失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("category", category))
+		return nil, fmt.Errorf("查询类别规则失败: %w", err)
+	}
+
+	return buildCategoryCoverage(category, rules), nil
+}
+
+// GetCoverageReport 获取多个报销类别的规则覆盖度报告，categories为空时统计默认类别
+func (s *RuleService) GetCoverageReport(ctx context.Context, categories []string) (*CoverageReport, error) {
+	if len(categories) == 0 {
+		categories = DefaultReimbursementCategories
+	}
+
+	report := &CoverageReport{
+		Categories:          make([]*CategoryCoverage, 0, len(categories)),
+		UncoveredCategories: make([]string, 0),
+	}
+
+	seen := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+
+		coverage, err := s.GetCategoryCoverage(ctx, category)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Categories = append(report.Categories, coverage)
+		if !coverage.Covered {
+			report.UncoveredCount++
+			report.UncoveredCategories = append(report.UncoveredCategories, category)
+		}
+	}
+
+	if report.UncoveredCount > 0 {
+		s.logger.WithContext(ctx).Warn("存在无规则覆盖的报销类别",
+			logger.NewField("categories", report.UncoveredCategories))
+	}
+
+	return report, nil
+}
+
+// buildCategoryCoverage 根据已启用规则构建类别覆盖情况
+func buildCategoryCoverage(category string, rules []*Rule) *CategoryCoverage {
+	coverage := &CategoryCoverage{
+		Category: category,
+		Rules:    make([]*CoverageRule, 0, len(rules)),
+	}
+
+	for _, rule := range rules {
+		if rule == nil || !rule.Enabled {
+			continue
+		}
+		coverage.Rules = append(coverage.Rules, &CoverageRule{
+			ID:       rule.ID,
+			RuleCode: rule.RuleCode,
+			Name:     rule.Name,
+			Type:     rule.Type,
+			Priority: rule.Priority,
+		})
+	}
+
+	sort.SliceStable(coverage.Rules, func(i, j int) bool {
+		return coverage.Rules[i].Priority > coverage.Rules[j].Priority
+	})
+
+	coverage.RuleCount = len(coverage.Rules)
+	coverage.Covered = coverage.RuleCount > 0
+	if !coverage.Covered {
+		coverage.Warning = "该类别没有已启用的规则，审核仅依赖RAG分析"
+	}
+
+	return coverage
+}
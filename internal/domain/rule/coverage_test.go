@@ -0,0 +1,75 @@
+package rule
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+)
+
+func TestGetCoverageReport(t *testing.T) {
+	repo := newMemRuleRepo(
+		&Rule{ID: "r1", RuleCode: "TRAVEL_HOTEL", Name: "住宿费标准", Category: "差旅费", Priority: 1, Enabled: true},
+		&Rule{ID: "r2", RuleCode: "TRAVEL_TICKET", Name: "机票舱位", Category: "差旅费", Priority: 5, Enabled: true},
+		&Rule{ID: "r3", RuleCode: "TRAVEL_OLD", Name: "已停用规则", Category: "差旅费", Priority: 9, Enabled: false},
+		&Rule{ID: "r4", RuleCode: "OFFICE_LIMIT", Name: "办公用品限额", Category: "办公费", Priority: 1, Enabled: false},
+	)
+	service := NewRuleService(repo, loggertest.New(t), nil)
+
+	report, err := service.GetCoverageReport(context.Background(), []string{"差旅费", "办公费", "招待费", "差旅费", ""})
+	if err != nil {
+		t.Fatalf("GetCoverageReport() error = %v", err)
+	}
+
+	// 空类别和重复类别只统计一次
+	if len(report.Categories) != 3 {
+		t.Fatalf("len(Categories) = %d, want 3", len(report.Categories))
+	}
+	if want := []string{"办公费", "招待费"}; report.UncoveredCount != 2 || !reflect.DeepEqual(report.UncoveredCategories, want) {
+		t.Errorf("无规则覆盖的类别 = %d/%q, want 2/%q", report.UncoveredCount, report.UncoveredCategories, want)
+	}
+
+	tests := []struct {
+		name        string
+		coverage    *CategoryCoverage
+		wantCovered bool
+		wantRules   []string // 按优先级从高到低的规则ID
+	}{
+		{name: "只统计已启用规则并按优先级排序", coverage: report.Categories[0], wantCovered: true, wantRules: []string{"r2", "r1"}},
+		{name: "规则全部停用视为无覆盖", coverage: report.Categories[1], wantRules: []string{}},
+		{name: "没有任何规则的类别", coverage: report.Categories[2], wantRules: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make([]string, 0, len(tt.coverage.Rules))
+			for _, rule := range tt.coverage.Rules {
+				ids = append(ids, rule.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantRules) || tt.coverage.RuleCount != len(tt.wantRules) {
+				t.Errorf("规则 = %q(RuleCount %d), want %q", ids, tt.coverage.RuleCount, tt.wantRules)
+			}
+			if tt.coverage.Covered != tt.wantCovered {
+				t.Errorf("Covered = %v, want %v", tt.coverage.Covered, tt.wantCovered)
+			}
+			if (tt.coverage.Warning == "") != tt.wantCovered {
+				t.Errorf("Warning = %q, 无规则覆盖时应提示仅依赖RAG分析", tt.coverage.Warning)
+			}
+		})
+	}
+}
+
+func TestGetCoverageReportDefaultCategories(t *testing.T) {
+	service := NewRuleService(newMemRuleRepo(), loggertest.New(t), nil)
+
+	report, err := service.GetCoverageReport(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("GetCoverageReport() error = %v", err)
+	}
+	if !reflect.DeepEqual(report.UncoveredCategories, DefaultReimbursementCategories) {
+		t.Errorf("UncoveredCategories = %q, want 默认类别%q", report.UncoveredCategories, DefaultReimbursementCategories)
+	}
+	if _, err := service.GetCategoryCoverage(context.Background(), ""); err == nil {
+		t.Errorf("报销类别为空时应返回错误")
+	}
+}
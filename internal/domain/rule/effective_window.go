@@ -0,0 +1,91 @@
+// effective_window.go 规则生效时间段
+// 功能点：
+// 1. 规则可配置生效起止日期（如仅年末生效的临时限额），未配置的一端不限制
+// 2. 按报销申请日期判断规则是否生效，起止日期均包含当天
+// 3. 解析和校验规则请求中的生效日期
+
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidEffectiveWindow 规则生效时间段不合法
+var ErrInvalidEffectiveWindow = errors.New("规则生效时间段不合法")
+
+// effectiveDateLayout 生效日期格式
+const effectiveDateLayout = "2006-01-02"
+
+// IsEffectiveAt 判断规则在指定日期是否生效，日期为零值时视为生效
+func (r *Rule) IsEffectiveAt(date time.Time) bool {
+	return isEffectiveAt(r.EffectiveFrom, r.EffectiveTo, date)
+}
+
+// IsEffectiveAt 判断规则在指定日期是否生效，日期为零值时视为生效
+func (d *RuleDefinition) IsEffectiveAt(date time.Time) bool {
+	return isEffectiveAt(d.EffectiveFrom, d.EffectiveTo, date)
+}
+
+// isEffectiveAt 按自然日比较日期是否落在[from, to]内，from/to为nil表示不限制
+func isEffectiveAt(from, to *time.Time, date time.Time) bool {
+	if date.IsZero() {
+		return true
+	}
+	day := truncateToDay(date)
+	if from != nil && day.Before(truncateToDay(from.In(date.Location()))) {
+		return false
+	}
+	if to != nil && day.After(truncateToDay(to.In(date.Location()))) {
+		return false
+	}
+	return true
+}
+
+// truncateToDay 截断到当天零点
+func truncateToDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// filterEffectiveRules 过滤出在指定日期生效的规则
+func filterEffectiveRules(rules []*Rule, date time.Time) []*Rule {
+	effective := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.IsEffectiveAt(date) {
+			effective = append(effective, rule)
+		}
+	}
+	return effective
+}
+
+// parseEffectiveWindow 解析生效起止日期(YYYY-MM-DD)，为空表示不限制，开始日期不能晚于结束日期
+func parseEffectiveWindow(from, to string) (*time.Time, *time.Time, error) {
+	effectiveFrom, err := parseEffectiveDate(from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: 生效开始日期%v", ErrInvalidEffectiveWindow, err)
+	}
+	effectiveTo, err := parseEffectiveDate(to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: 生效结束日期%v", ErrInvalidEffectiveWindow, err)
+	}
+	if effectiveFrom != nil && effectiveTo != nil && effectiveFrom.After(*effectiveTo) {
+		return nil, nil, fmt.Errorf("%w: 生效开始日期%s晚于结束日期%s", ErrInvalidEffectiveWindow, from, to)
+	}
+	return effectiveFrom, effectiveTo, nil
+}
+
+// parseEffectiveDate 解析生效日期，为空时返回nil
+func parseEffectiveDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.ParseInLocation(effectiveDateLayout, value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("格式不正确，应为YYYY-MM-DD: %s", value)
+	}
+	return &date, nil
+}
@@ -0,0 +1,122 @@
+package rule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsEffectiveAt(t *testing.T) {
+	date := func(value string) *time.Time {
+		d, err := time.ParseInLocation(effectiveDateLayout, value, time.Local)
+		if err != nil {
+			t.Fatalf("解析日期失败: %v", err)
+		}
+		return &d
+	}
+	at := func(value string, hour int) time.Time {
+		return date(value).Add(time.Duration(hour) * time.Hour)
+	}
+
+	tests := []struct {
+		name string
+		from *time.Time
+		to   *time.Time
+		date time.Time
+		want bool
+	}{
+		{name: "未配置生效日期", date: at("2024-12-01", 0), want: true},
+		{name: "日期为零值视为生效", from: date("2024-12-01"), to: date("2024-12-31"), want: true},
+		{name: "开始日期当天生效", from: date("2024-12-01"), date: at("2024-12-01", 9), want: true},
+		{name: "结束日期当天晚些时候仍生效", to: date("2024-12-31"), date: at("2024-12-31", 23), want: true},
+		{name: "开始日期之前不生效", from: date("2024-12-01"), to: date("2024-12-31"), date: at("2024-11-30", 23), want: false},
+		{name: "结束日期之后不生效", from: date("2024-12-01"), to: date("2024-12-31"), date: at("2025-01-01", 0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rule{EffectiveFrom: tt.from, EffectiveTo: tt.to}
+			if got := r.IsEffectiveAt(tt.date); got != tt.want {
+				t.Errorf("Rule.IsEffectiveAt() = %v, want %v", got, tt.want)
+			}
+			d := &RuleDefinition{EffectiveFrom: tt.from, EffectiveTo: tt.to}
+			if got := d.IsEffectiveAt(tt.date); got != tt.want {
+				t.Errorf("RuleDefinition.IsEffectiveAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterEffectiveRules(t *testing.T) {
+	yearEnd := time.Date(2024, time.December, 1, 0, 0, 0, 0, time.Local)
+	rules := []*Rule{
+		{ID: "always"},
+		{ID: "year-end", EffectiveFrom: &yearEnd},
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want []string
+	}{
+		{name: "年末临时规则生效", date: time.Date(2024, time.December, 15, 0, 0, 0, 0, time.Local), want: []string{"always", "year-end"}},
+		{name: "年末之前只保留长期规则", date: time.Date(2024, time.June, 15, 0, 0, 0, 0, time.Local), want: []string{"always"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterEffectiveRules(rules, tt.date)
+			if len(got) != len(tt.want) {
+				t.Fatalf("filterEffectiveRules() = %d条, want %d条", len(got), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if got[i].ID != id {
+					t.Errorf("filterEffectiveRules()[%d] = %s, want %s", i, got[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestParseEffectiveWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{name: "都为空表示不限制"},
+		{name: "只配置开始日期", from: " 2024-12-01 ", wantFrom: "2024-12-01"},
+		{name: "起止日期相同", from: "2024-12-01", to: "2024-12-01", wantFrom: "2024-12-01", wantTo: "2024-12-01"},
+		{name: "开始日期格式错误", from: "2024/12/01", wantErr: true},
+		{name: "结束日期格式错误", to: "12-31", wantErr: true},
+		{name: "开始日期晚于结束日期", from: "2024-12-31", to: "2024-12-01", wantErr: true},
+	}
+
+	format := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(effectiveDateLayout)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parseEffectiveWindow(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEffectiveWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEffectiveWindow) {
+					t.Errorf("parseEffectiveWindow() error = %v, want ErrInvalidEffectiveWindow", err)
+				}
+				return
+			}
+			if format(from) != tt.wantFrom || format(to) != tt.wantTo {
+				t.Errorf("parseEffectiveWindow() = (%s, %s), want (%s, %s)", format(from), format(to), tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
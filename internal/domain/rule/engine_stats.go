@@ -0,0 +1,71 @@
+// engine_stats.go 规则引擎执行统计快照
+// 功能点：
+// 1. 在持锁状态下复制执行统计，生成带时间戳的一致快照
+// 2. 可按规则ID筛选快照内容，为空时包含全部规则
+// 3. 将快照以JSON格式导出，便于落盘或上报监控
+
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// EngineStatsSnapshot 规则引擎执行统计快照
+type EngineStatsSnapshot struct {
+	GeneratedAt     time.Time          `json:"generated_at"`     // 快照生成时间
+	RuleCount       int                `json:"rule_count"`       // 快照包含的规则数量
+	TotalExecutions int                `json:"total_executions"` // 快照内规则的总执行次数
+	TotalFailures   int                `json:"total_failures"`   // 快照内规则的总失败次数
+	Rules           []*EngineRuleStats `json:"rules"`            // 各规则执行统计，按规则ID排序
+}
+
+// StatisticsSnapshot 生成执行统计快照，ruleIDs为空时包含全部规则，未执行过的规则不出现在快照中
+func (e *GRuleEngine) StatisticsSnapshot(ruleIDs ...string) *EngineStatsSnapshot {
+	e.mu.RLock()
+	rules := make([]*EngineRuleStats, 0, len(e.stats))
+	if len(ruleIDs) == 0 {
+		for _, v := range e.stats {
+			stat := *v
+			rules = append(rules, &stat)
+		}
+	} else {
+		seen := make(map[string]bool, len(ruleIDs))
+		for _, ruleID := range ruleIDs {
+			if v, ok := e.stats[ruleID]; ok && !seen[ruleID] {
+				seen[ruleID] = true
+				stat := *v
+				rules = append(rules, &stat)
+			}
+		}
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].RuleID < rules[j].RuleID
+	})
+
+	snapshot := &EngineStatsSnapshot{
+		GeneratedAt: time.Now(),
+		RuleCount:   len(rules),
+		Rules:       rules,
+	}
+	for _, stat := range rules {
+		snapshot.TotalExecutions += stat.ExecutionCount
+		snapshot.TotalFailures += stat.FailureCount
+	}
+	return snapshot
+}
+
+// ExportStatisticsSnapshot 生成执行统计快照并以JSON格式写入w，ruleIDs为空时导出全部规则
+func (e *GRuleEngine) ExportStatisticsSnapshot(w io.Writer, ruleIDs ...string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(e.StatisticsSnapshot(ruleIDs...)); err != nil {
+		return fmt.Errorf("导出规则执行统计失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package rule
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+)
+
+func TestStatisticsSnapshot(t *testing.T) {
+	engine := NewGRuleEngine(nil, loggertest.New(t))
+	start := time.Now()
+	engine.recordExecution("r2", start, true)
+	engine.recordExecution("r1", start, true)
+	engine.recordExecution("r1", start, false)
+
+	tests := []struct {
+		name           string
+		ruleIDs        []string
+		wantRuleIDs    []string
+		wantExecutions int
+		wantFailures   int
+	}{
+		{name: "未指定规则时包含全部规则", wantRuleIDs: []string{"r1", "r2"}, wantExecutions: 3, wantFailures: 1},
+		{name: "按规则ID筛选", ruleIDs: []string{"r2"}, wantRuleIDs: []string{"r2"}, wantExecutions: 1},
+		{name: "重复的规则ID只计一次", ruleIDs: []string{"r1", "r1"}, wantRuleIDs: []string{"r1"}, wantExecutions: 2, wantFailures: 1},
+		{name: "未执行过的规则不出现在快照中", ruleIDs: []string{"r3"}, wantRuleIDs: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := engine.StatisticsSnapshot(tt.ruleIDs...)
+			if snapshot.RuleCount != len(tt.wantRuleIDs) || len(snapshot.Rules) != len(tt.wantRuleIDs) {
+				t.Fatalf("RuleCount = %d, Rules = %d, want %d", snapshot.RuleCount, len(snapshot.Rules), len(tt.wantRuleIDs))
+			}
+			for i, ruleID := range tt.wantRuleIDs {
+				if snapshot.Rules[i].RuleID != ruleID {
+					t.Errorf("Rules[%d].RuleID = %s, want %s", i, snapshot.Rules[i].RuleID, ruleID)
+				}
+			}
+			if snapshot.TotalExecutions != tt.wantExecutions || snapshot.TotalFailures != tt.wantFailures {
+				t.Errorf("TotalExecutions = %d, TotalFailures = %d, want %d, %d",
+					snapshot.TotalExecutions, snapshot.TotalFailures, tt.wantExecutions, tt.wantFailures)
+			}
+		})
+	}
+}
+
+// TestStatisticsSnapshotConcurrent 并发更新统计时读取快照，需配合-race运行
+func TestStatisticsSnapshotConcurrent(t *testing.T) {
+	const (
+		writers    = 8
+		executions = 200
+	)
+	engine := NewGRuleEngine(nil, loggertest.New(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ruleID := fmt.Sprintf("r%d", i%2)
+			for j := 0; j < executions; j++ {
+				engine.recordExecution(ruleID, time.Now(), j%4 != 0)
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				snapshot := engine.StatisticsSnapshot()
+				for _, stat := range snapshot.Rules {
+					if stat.SuccessCount+stat.FailureCount != stat.ExecutionCount {
+						t.Errorf("规则%s快照不一致: 成功%d + 失败%d != 执行%d",
+							stat.RuleID, stat.SuccessCount, stat.FailureCount, stat.ExecutionCount)
+						return
+					}
+				}
+				var buf bytes.Buffer
+				if err := engine.ExportStatisticsSnapshot(&buf, "r0"); err != nil {
+					t.Errorf("ExportStatisticsSnapshot() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	var buf bytes.Buffer
+	if err := engine.ExportStatisticsSnapshot(&buf); err != nil {
+		t.Fatalf("ExportStatisticsSnapshot() error = %v", err)
+	}
+	var snapshot EngineStatsSnapshot
+	if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+		t.Fatalf("解析导出的快照失败: %v", err)
+	}
+	if want := writers * executions; snapshot.TotalExecutions != want {
+		t.Errorf("TotalExecutions = %d, want %d", snapshot.TotalExecutions, want)
+	}
+	if want := writers * executions / 4; snapshot.TotalFailures != want {
+		t.Errorf("TotalFailures = %d, want %d", snapshot.TotalFailures, want)
+	}
+}
@@ -6,6 +6,9 @@
 // 4. 规则库管理
 // 5. 规则执行上下文管理
 // 6. 规则性能监控
+// 7. 规则执行超时控制（引擎级/规则级），规则在独立goroutine中执行，超时立即返回
+// 8. 执行统计返回深拷贝，避免调用方读取时与并发更新竞争
+// 9. 规则按版本号编译加载，重新加载时先编译校验新版本再切换，失败的规则继续使用旧版本
 
 package rule
 
@@ -27,14 +30,30 @@ import (
 
 // GRuleEngine Grule规则引擎结构体
 type GRuleEngine struct {
-	ruleLibrary      map[string]*ast.KnowledgeBase // 规则库
-	knowledgeLibrary *ast.KnowledgeLibrary         // Grule知识库
-	repository       Repository                    // 规则仓库接口
-	logger           logger.Logger                 // 日志记录器
-	mu               sync.RWMutex                  // 读写锁
-	stats            map[string]*EngineRuleStats   // 规则执行统计
+	ruleLibrary    map[string]*ast.KnowledgeBase              // 规则库(各规则当前生效版本)
+	ruleVersions   map[string]map[string]*compiledRuleVersion // 各规则已加载的版本
+	activeVersions map[string]string                          // 各规则当前生效版本号
+	repository     Repository                                 // 规则仓库接口
+	logger         logger.Logger                              // 日志记录器
+	mu             sync.RWMutex                               // 读写锁
+	stats          map[string]*EngineRuleStats                // 规则执行统计
+	gruleEngine    *engine.GruleEngine                        // 复用的Grule引擎实例
+	defaultTimeout time.Duration                              // 引擎级默认执行超时
+	ruleTimeouts   map[string]time.Duration                   // 规则级执行超时
 }
 
+// DefaultRuleMaxCycle 默认规则最大执行周期，防止规则死循环
+const DefaultRuleMaxCycle uint64 = 500
+
+// DefaultRuleExecutionTimeout 默认单条规则执行超时时间
+const DefaultRuleExecutionTimeout = 3 * time.Second
+
+// ErrRuleExecutionTimeout 规则执行超时错误
+var ErrRuleExecutionTimeout = errors.New("规则执行超时")
+
+// ErrRuleSyntax 规则语法错误
+var ErrRuleSyntax = errors.New("规则语法错误")
+
 // EngineRuleStats 引擎规则执行统计
 type EngineRuleStats struct {
 	RuleID         string        `json:"rule_id"`
@@ -48,12 +67,69 @@ type EngineRuleStats struct {
 // NewGRuleEngine 创建Grule规则引擎实例
 func NewGRuleEngine(repository Repository, log logger.Logger) *GRuleEngine {
 	return &GRuleEngine{
-		ruleLibrary:      make(map[string]*ast.KnowledgeBase),
-		knowledgeLibrary: ast.NewKnowledgeLibrary(),
-		repository:       repository,
-		logger:           log,
-		stats:            make(map[string]*EngineRuleStats),
+		ruleLibrary:    make(map[string]*ast.KnowledgeBase),
+		ruleVersions:   make(map[string]map[string]*compiledRuleVersion),
+		activeVersions: make(map[string]string),
+		repository:     repository,
+		logger:         log,
+		stats:          make(map[string]*EngineRuleStats),
+		gruleEngine:    newGruleEngine(DefaultRuleMaxCycle),
+		defaultTimeout: DefaultRuleExecutionTimeout,
+		ruleTimeouts:   make(map[string]time.Duration),
+	}
+}
+
+// newGruleEngine 创建指定最大执行周期的Grule引擎实例
+func newGruleEngine(maxCycle uint64) *engine.GruleEngine {
+	gruleEngine := engine.NewGruleEngine()
+	gruleEngine.MaxCycle = maxCycle
+	return gruleEngine
+}
+
+// SetMaxCycle 设置规则最大执行周期
+func (e *GRuleEngine) SetMaxCycle(maxCycle uint64) {
+	if maxCycle == 0 {
+		maxCycle = DefaultRuleMaxCycle
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// 替换为新实例，避免与正在执行的规则产生数据竞争
+	e.gruleEngine = newGruleEngine(maxCycle)
+}
+
+// GetMaxCycle 获取规则最大执行周期
+func (e *GRuleEngine) GetMaxCycle() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.gruleEngine.MaxCycle
+}
+
+// SetDefaultTimeout 设置引擎级默认执行超时，规则未单独配置超时时使用
+func (e *GRuleEngine) SetDefaultTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultRuleExecutionTimeout
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.defaultTimeout = timeout
+}
+
+// GetDefaultTimeout 获取引擎级默认执行超时
+func (e *GRuleEngine) GetDefaultTimeout() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.defaultTimeout
+}
+
+// getRuleTimeout 获取规则的执行超时，规则级配置优先于引擎级配置
+// 调用方需持有读锁
+func (e *GRuleEngine) getRuleTimeout(ruleID string) time.Duration {
+	if timeout, ok := e.ruleTimeouts[ruleID]; ok && timeout > 0 {
+		return timeout
 	}
+	return e.defaultTimeout
 }
 
 // Initialize 初始化引擎，加载数据库中启用的规则
@@ -104,42 +180,19 @@ func (e *GRuleEngine) LoadRule(ctx context.Context, rule *Rule) error {
 		return nil
 	}
 
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	// 验证规则语法
-	if err := e.ValidateRule(rule.Definition); err != nil {
-		e.logger.WithContext(ctx).Error("规则语法验证失败",
-			logger.NewField("规则ID", rule.ID),
-			logger.NewField("error", err.Error()))
-		return fmt.Errorf("规则语法验证失败: %w", err)
-	}
-
-	// 使用builder编译规则
-	ruleBuilder := builder.NewRuleBuilder(e.knowledgeLibrary)
-
-	// 创建规则资源
-	ruleResource := pkg.NewBytesResource([]byte(rule.Definition))
-
-	// 解析规则定义
-	err := ruleBuilder.BuildRuleFromResource(rule.RuleCode, "1.0", ruleResource)
+	// 先编译新版本，编译失败时当前生效版本保持不变
+	compiled, err := e.compileRuleVersion(rule)
 	if err != nil {
 		e.logger.WithContext(ctx).Error("编译规则失败",
 			logger.NewField("规则ID", rule.ID),
+			logger.NewField("版本", engineRuleVersion(rule)),
 			logger.NewField("error", err.Error()))
-		return fmt.Errorf("编译规则失败: %w", err)
+		return err
 	}
 
-	// 获取知识库实例
-	knowledgeBase := e.knowledgeLibrary.GetKnowledgeBase(rule.RuleCode, "1.0")
-	if knowledgeBase == nil {
-		e.logger.WithContext(ctx).Error("获取知识库实例失败",
-			logger.NewField("规则ID", rule.ID))
-		return fmt.Errorf("获取知识库实例失败")
-	}
-
-	// 保存知识库到本地规则库
-	e.ruleLibrary[rule.ID] = knowledgeBase
+	e.mu.Lock()
+	e.addRuleVersion(rule.ID, compiled)
+	e.activateRuleVersion(rule.ID, compiled)
 
 	// 初始化统计信息
 	e.stats[rule.ID] = &EngineRuleStats{
@@ -148,10 +201,12 @@ func (e *GRuleEngine) LoadRule(ctx context.Context, rule *Rule) error {
 		SuccessCount:   0,
 		FailureCount:   0,
 	}
+	e.mu.Unlock()
 
 	e.logger.WithContext(ctx).Info("规则加载成功",
 		logger.NewField("规则ID", rule.ID),
-		logger.NewField("规则名称", rule.Name))
+		logger.NewField("规则名称", rule.Name),
+		logger.NewField("版本", compiled.version))
 
 	return nil
 }
@@ -169,11 +224,8 @@ func (e *GRuleEngine) UnloadRule(ctx context.Context, ruleID string) error {
 		return fmt.Errorf("规则不存在: %s", ruleID)
 	}
 
-	// 从规则库中移除
-	delete(e.ruleLibrary, ruleID)
-
-	// 从统计信息中移除
-	delete(e.stats, ruleID)
+	// 从规则库中移除全部版本
+	e.removeRule(ruleID)
 
 	e.logger.WithContext(ctx).Info("规则卸载成功",
 		logger.NewField("规则ID", ruleID))
@@ -183,62 +235,118 @@ func (e *GRuleEngine) UnloadRule(ctx context.Context, ruleID string) error {
 
 // ExecuteRule 执行单个规则
 func (e *GRuleEngine) ExecuteRule(ctx context.Context, ruleID string, data interface{}) (*RuleValidationResult, error) {
+	return e.ExecuteRuleWithDataContext(ctx, ruleID, map[string]interface{}{"data": data})
+}
+
+// ExecuteRuleWithDataContext 执行单个规则，支持自定义数据上下文
+func (e *GRuleEngine) ExecuteRuleWithDataContext(ctx context.Context, ruleID string, dataContext map[string]interface{}) (*RuleValidationResult, error) {
+	return e.executeRuleVersion(ctx, ruleID, "", dataContext)
+}
+
+// executeRuleVersion 执行规则的指定版本，version为空时执行当前生效版本
+// 非生效版本(灰度版本)的执行统计单独记录，不影响生效版本的统计
+func (e *GRuleEngine) executeRuleVersion(ctx context.Context, ruleID, version string, dataContext map[string]interface{}) (*RuleValidationResult, error) {
 	if ruleID == "" {
 		return nil, errors.New("规则ID不能为空")
 	}
 
 	e.mu.RLock()
-	knowledgeBase, exists := e.ruleLibrary[ruleID]
+	activeVersion, exists := e.activeVersions[ruleID]
+	if version == "" {
+		version = activeVersion
+	}
+	compiled, versionExists := e.ruleVersions[ruleID][version]
+	gruleEngine := e.gruleEngine
+	timeout := e.getRuleTimeout(ruleID)
+	defaultTimeout := e.defaultTimeout
 	e.mu.RUnlock()
 
-	if !exists {
+	if !exists && !versionExists {
 		return nil, fmt.Errorf("规则不存在: %s", ruleID)
 	}
+	if !versionExists {
+		return nil, fmt.Errorf("%w: %s(版本%s)", ErrRuleVersionNotLoaded, ruleID, version)
+	}
+	statsKey := ruleID
+	if version != activeVersion {
+		timeout = defaultTimeout
+		if compiled.timeout > 0 {
+			timeout = compiled.timeout
+		}
+		statsKey = versionStatsKey(ruleID, version)
+	}
 
 	// 记录执行开始时间
 	startTime := time.Now()
 
-	// 更新统计信息
-	e.updateStatistics(ruleID, true, startTime, false)
-
-	// 创建数据上下文
-	dataContext := ast.NewDataContext()
-	err := dataContext.Add("data", data)
+	// 基于已编译的规则克隆知识库实例，避免并发执行时共享工作内存
+	knowledgeBase, err := compiled.knowledgeLibrary.NewKnowledgeBaseInstance(compiled.knowledgeBase.Name, compiled.knowledgeBase.Version)
 	if err != nil {
-		e.updateStatistics(ruleID, false, startTime, true)
-		e.logger.WithContext(ctx).Error("创建数据上下文失败",
+		e.recordExecution(statsKey, startTime, false)
+		e.logger.WithContext(ctx).Error("创建知识库实例失败",
 			logger.NewField("规则ID", ruleID),
 			logger.NewField("error", err.Error()))
-		return nil, fmt.Errorf("创建数据上下文失败: %w", err)
+		return nil, fmt.Errorf("创建知识库实例失败: %w", err)
 	}
 
-	// 创建结果对象
-	result := &RuleValidationResult{
-		RuleID:    ruleID,
-		Passed:    true,
-		Message:   "规则执行初始化",
-		Timestamp: time.Now(),
-	}
+	// 创建数据上下文
+	dc := ast.NewDataContext()
 
-	// 添加结果对象到上下文
-	err = dataContext.Add("result", result)
-	if err != nil {
-		e.updateStatistics(ruleID, false, startTime, true)
-		e.logger.WithContext(ctx).Error("添加结果对象到上下文失败",
-			logger.NewField("规则ID", ruleID),
-			logger.NewField("error", err.Error()))
-		return nil, fmt.Errorf("添加结果对象到上下文失败: %w", err)
+	// 添加所有数据上下文项
+	for key, value := range dataContext {
+		err := dc.Add(key, value)
+		if err != nil {
+			e.recordExecution(statsKey, startTime, false)
+			e.logger.WithContext(ctx).Error("添加数据上下文项失败",
+				logger.NewField("规则ID", ruleID),
+				logger.NewField("上下文键", key),
+				logger.NewField("error", err.Error()))
+			return nil, fmt.Errorf("添加数据上下文项失败: %w", err)
+		}
 	}
 
-	// 创建引擎实例
-	gruleEngine := engine.NewGruleEngine()
+	// 创建结果对象（调用方已提供时复用）
+	result, ok := dataContext["result"].(*RuleValidationResult)
+	if !ok {
+		result = &RuleValidationResult{
+			RuleID:    ruleID,
+			Passed:    true,
+			Message:   "规则执行初始化",
+			Timestamp: time.Now(),
+		}
+
+		// 添加结果对象到上下文
+		if err := dc.Add("result", result); err != nil {
+			e.recordExecution(statsKey, startTime, false)
+			e.logger.WithContext(ctx).Error("添加结果对象到上下文失败",
+				logger.NewField("规则ID", ruleID),
+				logger.NewField("error", err.Error()))
+			return nil, fmt.Errorf("添加结果对象到上下文失败: %w", err)
+		}
+	}
 
-	// 执行规则
-	err = gruleEngine.Execute(dataContext, knowledgeBase)
+	// 执行规则（复用引擎实例，MaxCycle防止规则死循环）
+	err = executeWithTimeout(ctx, gruleEngine, dc, knowledgeBase, timeout)
 	executionTime := time.Since(startTime)
 
+	if errors.Is(err, ErrRuleExecutionTimeout) {
+		e.recordExecution(statsKey, startTime, false)
+		e.logger.WithContext(ctx).Error("规则执行超时",
+			logger.NewField("规则ID", ruleID),
+			logger.NewField("超时时间", timeout.String()))
+		return nil, fmt.Errorf("%w: %s(超时时间%s)", ErrRuleExecutionTimeout, ruleID, timeout)
+	}
+
+	if ctx.Err() != nil {
+		e.recordExecution(statsKey, startTime, false)
+		e.logger.WithContext(ctx).Warn("规则执行已取消",
+			logger.NewField("规则ID", ruleID),
+			logger.NewField("error", ctx.Err().Error()))
+		return nil, fmt.Errorf("规则执行已取消: %w", ctx.Err())
+	}
+
 	if err != nil {
-		e.updateStatistics(ruleID, false, startTime, true)
+		e.recordExecution(statsKey, startTime, false)
 		e.logger.WithContext(ctx).Error("规则执行失败",
 			logger.NewField("规则ID", ruleID),
 			logger.NewField("执行时间", executionTime.String()),
@@ -252,11 +360,11 @@ func (e *GRuleEngine) ExecuteRule(ctx context.Context, ruleID string, data inter
 		}, nil
 	}
 
-	// 更新统计信息
-	e.updateStatistics(ruleID, false, startTime, false)
+	// 仅在执行完成后记录一次统计
+	e.recordExecution(statsKey, startTime, true)
 
 	// 从上下文中获取结果
-	resultNode := dataContext.Get("result")
+	resultNode := dc.Get("result")
 	if resultNode != nil {
 		// 尝试将结果转换为 RuleValidationResult
 		if resultVal, ok := resultNode.(model.ValueNode); ok {
@@ -286,102 +394,101 @@ func (e *GRuleEngine) ExecuteRule(ctx context.Context, ruleID string, data inter
 	return result, nil
 }
 
-// ExecuteRuleWithDataContext 执行单个规则，支持自定义数据上下文
-func (e *GRuleEngine) ExecuteRuleWithDataContext(ctx context.Context, ruleID string, dataContext map[string]interface{}) (*RuleValidationResult, error) {
-	if ruleID == "" {
-		return nil, errors.New("规则ID不能为空")
+// executeWithTimeout 在独立goroutine中执行规则，通过select监听上下文，超时或调用方取消时立即返回；超时返回ErrRuleExecutionTimeout
+// 返回后执行上下文随之取消，Grule在当前周期结束时停止；规则条件中阻塞的函数调用无法中断，该goroutine在调用返回后退出，
+// 期间仍可能修改数据上下文中的对象，调用方在超时后不应再使用本次执行的结果
+func executeWithTimeout(ctx context.Context, gruleEngine *engine.GruleEngine, dc ast.IDataContext, knowledgeBase *ast.KnowledgeBase, timeout time.Duration) error {
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- gruleEngine.ExecuteWithContext(execCtx, dc, knowledgeBase)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && errors.Is(execCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return ErrRuleExecutionTimeout
+		}
+		return err
+	case <-execCtx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrRuleExecutionTimeout
+	}
+}
+
+// TestRule 空跑测试规则
+// 使用临时知识库编译并执行规则定义，不写入正式规则库，也不计入执行统计
+func (e *GRuleEngine) TestRule(ctx context.Context, rule *Rule, testData interface{}) (*RuleValidationResult, error) {
+	if rule == nil {
+		return nil, errors.New("规则不能为空")
+	}
+
+	// 验证规则语法
+	if err := e.ValidateRule(rule.Definition); err != nil {
+		return nil, err
 	}
 
 	e.mu.RLock()
-	knowledgeBase, exists := e.ruleLibrary[ruleID]
+	gruleEngine := e.gruleEngine
+	timeout := e.defaultTimeout
 	e.mu.RUnlock()
-
-	if !exists {
-		return nil, fmt.Errorf("规则不存在: %s", ruleID)
+	if rule.Timeout > 0 {
+		timeout = time.Duration(rule.Timeout) * time.Millisecond
 	}
 
-	// 记录执行开始时间
 	startTime := time.Now()
 
-	// 创建数据上下文
-	dc := ast.NewDataContext()
+	// 编译到临时知识库
+	tempKnowledgeLibrary := ast.NewKnowledgeLibrary()
+	ruleBuilder := builder.NewRuleBuilder(tempKnowledgeLibrary)
+	if err := ruleBuilder.BuildRuleFromResource("test", "1.0", pkg.NewBytesResource([]byte(rule.Definition))); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRuleSyntax, err)
+	}
 
-	// 添加所有数据上下文项
-	for key, value := range dataContext {
-		err := dc.Add(key, value)
-		if err != nil {
-			e.logger.WithContext(ctx).Error("添加数据上下文项失败",
-				logger.NewField("规则ID", ruleID),
-				logger.NewField("上下文键", key),
-				logger.NewField("error", err.Error()))
-			return nil, fmt.Errorf("添加数据上下文项失败: %w", err)
-		}
+	knowledgeBase, err := tempKnowledgeLibrary.NewKnowledgeBaseInstance("test", "1.0")
+	if err != nil {
+		return nil, fmt.Errorf("创建知识库实例失败: %w", err)
 	}
 
-	// 创建结果对象
 	result := &RuleValidationResult{
-		RuleID:    ruleID,
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		RuleType:  rule.Type,
+		Priority:  rule.Priority,
 		Passed:    true,
-		Message:   "规则执行初始化",
+		Message:   "规则执行成功",
 		Timestamp: time.Now(),
 	}
 
-	// 添加结果对象到上下文
-	err := dc.Add("result", result)
-	if err != nil {
-		e.logger.WithContext(ctx).Error("添加结果对象到上下文失败",
-			logger.NewField("规则ID", ruleID),
-			logger.NewField("error", err.Error()))
+	dc := ast.NewDataContext()
+	if err := dc.Add("data", testData); err != nil {
+		return nil, fmt.Errorf("添加测试数据到上下文失败: %w", err)
+	}
+	if err := dc.Add("result", result); err != nil {
 		return nil, fmt.Errorf("添加结果对象到上下文失败: %w", err)
 	}
 
-	// 创建引擎实例
-	gruleEngine := engine.NewGruleEngine()
-
-	// 执行规则
-	err = gruleEngine.Execute(dc, knowledgeBase)
-	executionTime := time.Since(startTime)
-
+	err = executeWithTimeout(ctx, gruleEngine, dc, knowledgeBase, timeout)
+	if errors.Is(err, ErrRuleExecutionTimeout) {
+		return nil, fmt.Errorf("%w(超时时间%s)", ErrRuleExecutionTimeout, timeout)
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("规则执行已取消: %w", ctx.Err())
+	}
 	if err != nil {
-		e.logger.WithContext(ctx).Error("规则执行失败",
-			logger.NewField("规则ID", ruleID),
-			logger.NewField("执行时间", executionTime.String()),
-			logger.NewField("error", err.Error()))
-
-		return &RuleValidationResult{
-			RuleID:    ruleID,
-			Passed:    false,
-			Message:   fmt.Sprintf("规则执行失败: %s", err.Error()),
-			Timestamp: time.Now(),
-		}, nil
+		result.Passed = false
+		result.Message = fmt.Sprintf("规则执行失败: %s", err.Error())
 	}
 
-	// 从上下文中获取结果
-	resultNode := dc.Get("result")
-	if resultNode != nil {
-		// 尝试将结果转换为 RuleValidationResult
-		if resultVal, ok := resultNode.(model.ValueNode); ok {
-			if resultObj, err := resultVal.GetValue(); err == nil {
-				if res, ok := resultObj.Interface().(*RuleValidationResult); ok {
-					result = res
-				}
-			}
-		}
-	} else {
-		// 如果获取结果失败，使用默认结果
-		e.logger.WithContext(ctx).Warn("获取规则执行结果失败，使用默认结果",
-			logger.NewField("规则ID", ruleID))
-		result = &RuleValidationResult{
-			RuleID:    ruleID,
-			Passed:    true,
-			Message:   "规则执行成功",
-			Timestamp: time.Now(),
-		}
-	}
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
 
-	e.logger.WithContext(ctx).Info("规则执行成功",
-		logger.NewField("规则ID", ruleID),
-		logger.NewField("执行时间", executionTime.String()),
+	e.logger.WithContext(ctx).Info("规则测试完成",
+		logger.NewField("规则ID", rule.ID),
+		logger.NewField("执行时间", time.Since(startTime).String()),
 		logger.NewField("结果", result.Passed))
 
 	return result, nil
@@ -396,6 +503,11 @@ func (e *GRuleEngine) ExecuteRules(ctx context.Context, ruleIDs []string, data i
 	results := make([]*RuleValidationResult, 0, len(ruleIDs))
 
 	for _, ruleID := range ruleIDs {
+		// 调用方已取消时停止执行剩余规则；单条规则超时不影响后续规则
+		if ctx.Err() != nil {
+			return results, fmt.Errorf("规则执行已取消: %w", ctx.Err())
+		}
+
 		result, err := e.ExecuteRule(ctx, ruleID, data)
 		if err != nil {
 			e.logger.WithContext(ctx).Error("执行规则失败",
@@ -448,7 +560,7 @@ func (e *GRuleEngine) ValidateRule(ruleDefinition string) error {
 	// 尝试构建规则
 	err := ruleBuilder.BuildRuleFromResource("validation", "1.0", ruleResource)
 	if err != nil {
-		return fmt.Errorf("规则语法错误: %w", err)
+		return fmt.Errorf("%w: %v", ErrRuleSyntax, err)
 	}
 
 	return nil
@@ -491,29 +603,64 @@ func (e *GRuleEngine) ClearRuleLibrary() {
 	defer e.mu.Unlock()
 
 	e.ruleLibrary = make(map[string]*ast.KnowledgeBase)
-	e.knowledgeLibrary = ast.NewKnowledgeLibrary()
+	e.ruleVersions = make(map[string]map[string]*compiledRuleVersion)
+	e.activeVersions = make(map[string]string)
 	e.stats = make(map[string]*EngineRuleStats)
+	e.ruleTimeouts = make(map[string]time.Duration)
 }
 
 // ReloadRuleLibrary 重新加载规则库
+// 先在锁外编译校验全部规则的新版本，再在一次加锁中切换，切换过程中不会出现规则库为空的窗口
+// 编译失败的规则继续使用旧版本并返回错误；本次未包含的规则(已停用或删除)被卸载
 func (e *GRuleEngine) ReloadRuleLibrary(ctx context.Context, rules []*Rule) error {
 	e.logger.WithContext(ctx).Info("重新加载规则库")
 
-	// 清空当前规则库
-	e.ClearRuleLibrary()
-
-	// 重新加载所有规则
+	compiled := make(map[string]*compiledRuleVersion, len(rules))
+	retained := make(map[string]bool)
+	var failures []error
 	for _, rule := range rules {
-		if err := e.LoadRule(ctx, rule); err != nil {
-			e.logger.WithContext(ctx).Error("重新加载规则失败",
+		if rule == nil || !rule.Enabled {
+			continue
+		}
+		version, err := e.compileRuleVersion(rule)
+		if err != nil {
+			e.logger.WithContext(ctx).Error("重新加载规则失败，保留旧版本",
 				logger.NewField("规则ID", rule.ID),
+				logger.NewField("版本", engineRuleVersion(rule)),
 				logger.NewField("error", err.Error()))
-			// 继续加载其他规则
+			retained[rule.ID] = true
+			failures = append(failures, fmt.Errorf("规则%s(版本%s): %w", rule.ID, engineRuleVersion(rule), err))
 			continue
 		}
+		compiled[rule.ID] = version
 	}
 
-	e.logger.WithContext(ctx).Info("规则库重新加载完成")
+	e.mu.Lock()
+	for ruleID := range e.ruleVersions {
+		if _, ok := compiled[ruleID]; !ok && !retained[ruleID] {
+			e.removeRule(ruleID)
+		}
+	}
+	switched := 0
+	for ruleID, version := range compiled {
+		previous, loaded := e.activeVersions[ruleID]
+		e.addRuleVersion(ruleID, version)
+		e.activateRuleVersion(ruleID, version)
+		if !loaded || previous != version.version {
+			e.stats[ruleID] = &EngineRuleStats{RuleID: ruleID}
+			switched++
+		}
+	}
+	e.mu.Unlock()
+
+	e.logger.WithContext(ctx).Info("规则库重新加载完成",
+		logger.NewField("加载规则数", len(compiled)),
+		logger.NewField("切换版本数", switched),
+		logger.NewField("失败规则数", len(failures)))
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%w: %w", ErrRuleReloadFailed, errors.Join(failures...))
+	}
 	return nil
 }
 
@@ -562,10 +709,11 @@ func (e *GRuleEngine) GetRuleStatistics() map[string]*EngineRuleStats {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	// 返回统计信息的副本
-	result := make(map[string]*EngineRuleStats)
+	// 返回统计信息的深拷贝，调用方读取时不会与并发执行中的统计更新竞争
+	result := make(map[string]*EngineRuleStats, len(e.stats))
 	for k, v := range e.stats {
-		result[k] = v
+		stat := *v
+		result[k] = &stat
 	}
 
 	return result
@@ -612,8 +760,11 @@ func (e *GRuleEngine) ResetStatistics() {
 	}
 }
 
-// updateStatistics 更新规则执行统计信息
-func (e *GRuleEngine) updateStatistics(ruleID string, isStart bool, startTime time.Time, isError bool) {
+// recordExecution 记录一次规则执行的统计信息
+// 每次执行只调用一次，在持锁状态下同时更新次数和平均耗时，保证并发下数据一致
+func (e *GRuleEngine) recordExecution(ruleID string, startTime time.Time, success bool) {
+	executionTime := time.Since(startTime)
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -626,29 +777,17 @@ func (e *GRuleEngine) updateStatistics(ruleID string, isStart bool, startTime ti
 		e.stats[ruleID] = stat
 	}
 
-	if isStart {
-		// 记录开始时间
-		stat.LastExecution = startTime
-		stat.ExecutionCount++
-	} else {
-		// 计算执行时间
-		executionTime := time.Since(startTime)
-
-		// 更新平均执行时间
-		if stat.ExecutionCount == 1 {
-			stat.AverageTime = executionTime
-		} else {
-			// 计算新的平均时间
-			totalTime := time.Duration(stat.ExecutionCount-1) * stat.AverageTime
-			stat.AverageTime = (totalTime + executionTime) / time.Duration(stat.ExecutionCount)
-		}
+	stat.ExecutionCount++
+	stat.LastExecution = startTime
 
-		// 更新成功/失败计数
-		if isError {
-			stat.FailureCount++
-		} else {
-			stat.SuccessCount++
-		}
+	// 增量计算平均执行时间
+	stat.AverageTime += (executionTime - stat.AverageTime) / time.Duration(stat.ExecutionCount)
+
+	// 更新成功/失败计数
+	if success {
+		stat.SuccessCount++
+	} else {
+		stat.FailureCount++
 	}
 }
 
@@ -0,0 +1,181 @@
+package rule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+)
+
+// loopRuleDefinition 每个周期都会命中的规则，只能靠最大执行周期或超时结束
+const loopRuleDefinition = `rule Loop "持续命中" salience 10 {
+	when
+		result.Passed == true
+	then
+		result.ExecutionTime = result.ExecutionTime + 1;
+}`
+
+// rejectRuleDefinition 金额超限时驳回
+const rejectRuleDefinition = `rule Reject "金额超限" salience 10 {
+	when
+		data.Amount > 100 && result.Passed == true
+	then
+		result.Passed = false;
+		result.Message = "金额超限";
+}`
+
+// blockingRuleDefinition 条件中的函数调用阻塞，单个执行周期内无法结束
+const blockingRuleDefinition = `rule Blocking "阻塞调用" salience 10 {
+	when
+		data.Blocked() && result.Passed == true
+	then
+		result.Passed = false;
+		Retract("Blocking");
+}`
+
+// testRuleData 规则测试数据
+type testRuleData struct {
+	Amount float64
+}
+
+// blockingRuleData 条件判断阻塞的规则测试数据，模拟执行周期内的长耗时调用
+type blockingRuleData struct {
+	Amount  float64
+	release chan struct{}
+}
+
+// Blocked 阻塞直到测试释放
+func (d *blockingRuleData) Blocked() bool {
+	<-d.release
+	return true
+}
+
+// newBlockingRuleData 创建阻塞测试数据，测试结束时释放阻塞的调用
+func newBlockingRuleData(t *testing.T, amount float64) *blockingRuleData {
+	data := &blockingRuleData{Amount: amount, release: make(chan struct{})}
+	t.Cleanup(func() { close(data.release) })
+	return data
+}
+
+func TestGRuleEngineTestRuleTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		blocking   bool
+		timeout    int
+		cancelled  bool
+		wantErr    error
+		wantPassed bool
+	}{
+		{name: "超过规则超时返回超时错误", definition: loopRuleDefinition, timeout: 1, wantErr: ErrRuleExecutionTimeout},
+		{name: "条件中的调用阻塞时按超时返回", definition: blockingRuleDefinition, blocking: true, timeout: 50, wantErr: ErrRuleExecutionTimeout},
+		{name: "调用阻塞时调用方取消立即返回", definition: blockingRuleDefinition, blocking: true, timeout: 1000, cancelled: true, wantErr: context.Canceled},
+		{name: "调用方取消不视为超时", definition: loopRuleDefinition, timeout: 1000, cancelled: true, wantErr: context.Canceled},
+		{name: "正常规则在超时前完成", definition: rejectRuleDefinition, timeout: 1000, wantPassed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewGRuleEngine(nil, loggertest.New(t))
+			engine.SetMaxCycle(1 << 40)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			var data interface{} = &testRuleData{Amount: 200}
+			if tt.blocking {
+				data = newBlockingRuleData(t, 200)
+			}
+
+			start := time.Now()
+			result, err := engine.TestRule(ctx, &Rule{ID: "r1", Definition: tt.definition, Timeout: tt.timeout}, data)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("规则执行耗时%s，超时未生效", elapsed)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("TestRule() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != ErrRuleExecutionTimeout && errors.Is(err, ErrRuleExecutionTimeout) {
+					t.Fatalf("TestRule() error = %v, 不应视为超时", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TestRule() error = %v", err)
+			}
+			if result.Passed != tt.wantPassed {
+				t.Fatalf("Passed = %v, want %v", result.Passed, tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestGRuleEngineExecuteRulesTimeout(t *testing.T) {
+	engine := NewGRuleEngine(nil, loggertest.New(t))
+	rules := []*Rule{
+		{ID: "blocking", RuleCode: "BLOCKING", Definition: blockingRuleDefinition, Enabled: true, Timeout: 50},
+		{ID: "reject", RuleCode: "REJECT", Definition: rejectRuleDefinition, Enabled: true},
+	}
+	for _, rule := range rules {
+		if err := engine.LoadRule(context.Background(), rule); err != nil {
+			t.Fatalf("LoadRule(%s) error = %v", rule.ID, err)
+		}
+	}
+
+	start := time.Now()
+	results, err := engine.ExecuteRules(context.Background(), []string{"blocking", "reject"}, newBlockingRuleData(t, 200))
+	if err != nil {
+		t.Fatalf("ExecuteRules() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("规则执行耗时%s，规则级超时未生效", elapsed)
+	}
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+
+	// 超时的规则记为失败，剩余规则继续执行
+	if results[0].Passed || !strings.Contains(results[0].Message, ErrRuleExecutionTimeout.Error()) {
+		t.Errorf("超时规则结果 = %v/%q, want 未通过且说明超时", results[0].Passed, results[0].Message)
+	}
+	if results[1].Passed || results[1].Message != "金额超限" {
+		t.Errorf("后续规则结果 = %v/%q, want 未通过/金额超限", results[1].Passed, results[1].Message)
+	}
+	if stats := engine.GetRuleStatistics()["blocking"]; stats.FailureCount != 1 || stats.SuccessCount != 0 {
+		t.Errorf("超时规则统计 失败/成功 = %d/%d, want 1/0", stats.FailureCount, stats.SuccessCount)
+	}
+}
+
+func TestGRuleEngineSettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxCycle     uint64
+		timeout      time.Duration
+		wantMaxCycle uint64
+		wantTimeout  time.Duration
+	}{
+		{name: "使用配置值", maxCycle: 100, timeout: 200 * time.Millisecond, wantMaxCycle: 100, wantTimeout: 200 * time.Millisecond},
+		{name: "零值回退为默认值", wantMaxCycle: DefaultRuleMaxCycle, wantTimeout: DefaultRuleExecutionTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewGRuleEngine(nil, loggertest.New(t))
+			engine.SetMaxCycle(tt.maxCycle)
+			engine.SetDefaultTimeout(tt.timeout)
+			if got := engine.GetMaxCycle(); got != tt.wantMaxCycle {
+				t.Fatalf("GetMaxCycle() = %d, want %d", got, tt.wantMaxCycle)
+			}
+			if got := engine.GetDefaultTimeout(); got != tt.wantTimeout {
+				t.Fatalf("GetDefaultTimeout() = %s, want %s", got, tt.wantTimeout)
+			}
+		})
+	}
+}
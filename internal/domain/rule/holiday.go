@@ -0,0 +1,212 @@
+// holiday.go 节假日数据源
+// 功能点：
+// 1. 定义节假日数据源接口
+// 2. 支持法定节假日和调休上班日
+// 3. 提供基于节假日表的静态实现（配置文件/内置表）
+// 4. 提供基于数据库的实现，按年份加载并缓存
+// 5. 内置当年法定节假日安排
+
+package rule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// holidayDateLayout 节假日日期格式
+const holidayDateLayout = "2006-01-02"
+
+// 节假日类型
+const (
+	HolidayTypeHoliday = "holiday" // 法定节假日
+	HolidayTypeWorkday = "workday" // 调休上班日
+)
+
+// HolidayProvider 节假日数据源接口
+type HolidayProvider interface {
+	// IsHoliday 判断日期是否为法定节假日（含调休放假的工作日）
+	IsHoliday(ctx context.Context, date time.Time) (bool, error)
+
+	// IsAdjustedWorkday 判断日期是否为调休上班日（如调休的周末）
+	IsAdjustedWorkday(ctx context.Context, date time.Time) (bool, error)
+}
+
+// HolidayCalendar 年度节假日安排
+type HolidayCalendar struct {
+	Year     int      `json:"year" yaml:"year"`         // 年份
+	Holidays []string `json:"holidays" yaml:"holidays"` // 法定节假日(YYYY-MM-DD)
+	Workdays []string `json:"workdays" yaml:"workdays"` // 调休上班日(YYYY-MM-DD)
+}
+
+// Holiday 节假日数据库记录
+type Holiday struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`                       // 记录ID
+	Year      int       `json:"year" gorm:"not null;index:idx_holiday_year"`                 // 年份
+	Date      time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_holiday_date"` // 日期
+	Type      string    `json:"type" gorm:"type:varchar(20);not null"`                       // 类型(holiday/workday)
+	Name      string    `json:"name" gorm:"type:varchar(50)"`                                // 节日名称
+	CreatedAt time.Time `json:"created_at"`                                                  // 创建时间
+	UpdatedAt time.Time `json:"updated_at"`                                                  // 更新时间
+}
+
+// TableName 指定节假日表名
+func (Holiday) TableName() string {
+	return "holidays"
+}
+
+// StaticHolidayProvider 基于节假日表的节假日数据源
+type StaticHolidayProvider struct {
+	holidays map[string]bool
+	workdays map[string]bool
+}
+
+// NewStaticHolidayProvider 根据年度节假日安排创建节假日数据源
+func NewStaticHolidayProvider(calendars ...HolidayCalendar) (*StaticHolidayProvider, error) {
+	provider := &StaticHolidayProvider{
+		holidays: make(map[string]bool),
+		workdays: make(map[string]bool),
+	}
+
+	for _, calendar := range calendars {
+		for _, day := range calendar.Holidays {
+			date, err := time.Parse(holidayDateLayout, day)
+			if err != nil {
+				return nil, fmt.Errorf("节假日日期格式错误: %s", day)
+			}
+			provider.holidays[date.Format(holidayDateLayout)] = true
+		}
+		for _, day := range calendar.Workdays {
+			date, err := time.Parse(holidayDateLayout, day)
+			if err != nil {
+				return nil, fmt.Errorf("调休上班日日期格式错误: %s", day)
+			}
+			provider.workdays[date.Format(holidayDateLayout)] = true
+		}
+	}
+
+	return provider, nil
+}
+
+// IsHoliday 判断日期是否为法定节假日
+func (p *StaticHolidayProvider) IsHoliday(ctx context.Context, date time.Time) (bool, error) {
+	return p.holidays[date.Format(holidayDateLayout)], nil
+}
+
+// IsAdjustedWorkday 判断日期是否为调休上班日
+func (p *StaticHolidayProvider) IsAdjustedWorkday(ctx context.Context, date time.Time) (bool, error) {
+	return p.workdays[date.Format(holidayDateLayout)], nil
+}
+
+// DatabaseHolidayProvider 基于数据库的节假日数据源，按年份加载并缓存
+type DatabaseHolidayProvider struct {
+	repo  HolidayRepository
+	mu    sync.RWMutex
+	years map[int]*StaticHolidayProvider
+}
+
+// NewDatabaseHolidayProvider 创建基于数据库的节假日数据源
+func NewDatabaseHolidayProvider(repo HolidayRepository) *DatabaseHolidayProvider {
+	return &DatabaseHolidayProvider{
+		repo:  repo,
+		years: make(map[int]*StaticHolidayProvider),
+	}
+}
+
+// IsHoliday 判断日期是否为法定节假日
+func (p *DatabaseHolidayProvider) IsHoliday(ctx context.Context, date time.Time) (bool, error) {
+	provider, err := p.loadYear(ctx, date.Year())
+	if err != nil {
+		return false, err
+	}
+	return provider.IsHoliday(ctx, date)
+}
+
+// IsAdjustedWorkday 判断日期是否为调休上班日
+func (p *DatabaseHolidayProvider) IsAdjustedWorkday(ctx context.Context, date time.Time) (bool, error) {
+	provider, err := p.loadYear(ctx, date.Year())
+	if err != nil {
+		return false, err
+	}
+	return provider.IsAdjustedWorkday(ctx, date)
+}
+
+// Invalidate 清除已缓存的节假日数据，节假日表更新后调用
+func (p *DatabaseHolidayProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.years = make(map[int]*StaticHolidayProvider)
+}
+
+// loadYear 加载指定年份的节假日安排
+func (p *DatabaseHolidayProvider) loadYear(ctx context.Context, year int) (*StaticHolidayProvider, error) {
+	p.mu.RLock()
+	provider, ok := p.years[year]
+	p.mu.RUnlock()
+	if ok {
+		return provider, nil
+	}
+
+	holidays, err := p.repo.ListHolidaysByYear(ctx, year)
+	if err != nil {
+		return nil, fmt.Errorf("加载节假日数据失败: %w", err)
+	}
+
+	calendar := HolidayCalendar{Year: year}
+	for _, holiday := range holidays {
+		day := holiday.Date.Format(holidayDateLayout)
+		switch holiday.Type {
+		case HolidayTypeHoliday:
+			calendar.Holidays = append(calendar.Holidays, day)
+		case HolidayTypeWorkday:
+			calendar.Workdays = append(calendar.Workdays, day)
+		}
+	}
+
+	provider, err = NewStaticHolidayProvider(calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.years[year] = provider
+	p.mu.Unlock()
+
+	return provider, nil
+}
+
+// builtinHolidayCalendar 内置2026年法定节假日安排（国务院办公厅发布）
+var builtinHolidayCalendar = HolidayCalendar{
+	Year: 2026,
+	Holidays: []string{
+		// 元旦
+		"2026-01-01", "2026-01-02", "2026-01-03",
+		// 春节
+		"2026-02-15", "2026-02-16", "2026-02-17", "2026-02-18", "2026-02-19",
+		"2026-02-20", "2026-02-21", "2026-02-22", "2026-02-23",
+		// 清明节
+		"2026-04-04", "2026-04-05", "2026-04-06",
+		// 劳动节
+		"2026-05-01", "2026-05-02", "2026-05-03", "2026-05-04", "2026-05-05",
+		// 端午节
+		"2026-06-19", "2026-06-20", "2026-06-21",
+		// 中秋节
+		"2026-09-25", "2026-09-26", "2026-09-27",
+		// 国庆节
+		"2026-10-01", "2026-10-02", "2026-10-03", "2026-10-04",
+		"2026-10-05", "2026-10-06", "2026-10-07",
+	},
+	Workdays: []string{
+		"2026-01-04",               // 元旦调休
+		"2026-02-14", "2026-02-28", // 春节调休
+		"2026-05-09",               // 劳动节调休
+		"2026-09-20", "2026-10-10", // 国庆节调休
+	},
+}
+
+// DefaultHolidayProvider 使用内置节假日表创建节假日数据源
+func DefaultHolidayProvider() HolidayProvider {
+	provider, _ := NewStaticHolidayProvider(builtinHolidayCalendar)
+	return provider
+}
@@ -3,30 +3,70 @@
 // 1. 实现规则优先级执行
 // 2. 实现错误聚合
 // 3. 提供规则执行结果汇总
+// 4. 按发票日期解析限额标准并记录到校验结果
+// 5. 提供识别周期性报销的频次校验辅助函数
+// 6. 按报销申请日期跳过未生效的规则
+// 7. 校验数据携带报销单总额、发票集合和对账结果，供金额一致性规则使用
+// 8. 校验数据携带按申请日期计算的发票时效校验结果，供时效规则使用
+// 9. 关键字段OCR识别置信度不足时追加需人工复核的违规，校验数据同时携带置信度校验结果
 
 package rule
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"reimbursement-audit/internal/domain/ocr"
 	"reimbursement-audit/internal/domain/reimbursement"
 	"reimbursement-audit/internal/pkg/logger"
+
+	"gorm.io/gorm"
 )
 
 // InvoiceValidationData 发票校验数据（用于规则引擎）
 type InvoiceValidationData struct {
-	Invoice       *ocr.Invoice                 `json:"invoice"`       // 待校验发票
-	Reimbursement *reimbursement.Reimbursement `json:"reimbursement"` // 关联报销单
-	CompanyNames  []string                     `json:"company_names"` // 允许的公司名称列表
-	InvoiceTypes  []string                     `json:"invoice_types"` // 允许的发票类型列表
-	ApplyDate     time.Time                    `json:"apply_date"`    // 报销申请日期
+	Invoice                   *ocr.Invoice                 `json:"invoice"`                     // 待校验发票
+	Reimbursement             *reimbursement.Reimbursement `json:"reimbursement"`               // 关联报销单
+	CompanyNames              []string                     `json:"company_names"`               // 允许的公司名称列表
+	InvoiceTypes              []string                     `json:"invoice_types"`               // 允许的发票类型列表
+	ApplyDate                 time.Time                    `json:"apply_date"`                  // 报销申请日期
+	SiblingInvoiceNumbers     []string                     `json:"sibling_invoice_numbers"`     // 同报销单其它发票号码
+	InvoiceNumbers            []string                     `json:"invoice_numbers"`             // 同报销单全部发票号码(含待校验发票)
+	ConsecutiveInvoiceNumbers []string                     `json:"consecutive_invoice_numbers"` // 与待校验发票连号的发票号码
+	RecurringExpense          bool                         `json:"recurring_expense"`           // 关联报销单是否标记为周期性报销
+	ReimbursementTotal        float64                      `json:"reimbursement_total"`         // 关联报销单总额
+	Invoices                  []*ocr.Invoice               `json:"invoices"`                    // 关联报销单全部发票
+	AmountReconciliation      *AmountReconciliation        `json:"amount_reconciliation"`       // 报销单总额与发票合计的对账结果，仅第一张发票上有值
+	InvoiceTimeliness         *InvoiceTimeliness           `json:"invoice_timeliness"`          // 发票时效校验结果(按申请日期计算)
+	OCRConfidence             *OCRConfidenceCheck          `json:"ocr_confidence"`              // 关键字段OCR识别置信度校验结果
 }
 
+// 报销单据类型，订单和收据与发票一同以单据形式存储
+const (
+	DocumentTypeOrder   = "订单"
+	DocumentTypeReceipt = "收据"
+)
+
+// approvedReimbursementStatuses 视为已通过的报销单状态
+var approvedReimbursementStatuses = []string{"已完成", "passed"}
+
+// consecutiveInvoiceRuleCode 连号发票规则编码
+const consecutiveInvoiceRuleCode = "RULE_CONSECUTIVE_INVOICE"
+
+// minConsecutiveInvoiceCount 视为连号发票的最少连续张数
+const minConsecutiveInvoiceCount = 3
+
+// maxInvoiceNumberDigits 参与连号比较的数字后缀最大位数，超出部分并入前缀
+const maxInvoiceNumberDigits = 18
+
 // executeRulesWithPriority 按优先级执行规则
 func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req *InvoiceValidationRequest, result *InvoiceValidationResult) error {
 	v.logger.WithContext(ctx).Info("按优先级执行发票校验规则",
@@ -42,12 +82,30 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 	})
 
 	// 创建校验数据
+	siblingNumbers := siblingInvoiceNumbers(req.Invoice, req.Reimbursement)
+	invoiceNumbers := append([]string{req.Invoice.Number}, siblingNumbers...)
+	// 时效按报销申请日期计算，请求未指定申请日期时使用报销单的申请日期
+	applyDate := req.ApplyDate
+	if applyDate.IsZero() && req.Reimbursement != nil {
+		applyDate = req.Reimbursement.ApplyDate
+	}
 	validationData := &InvoiceValidationData{
-		Invoice:       req.Invoice,
-		Reimbursement: req.Reimbursement,
-		CompanyNames:  req.CompanyNames,
-		InvoiceTypes:  req.InvoiceTypes,
-		ApplyDate:     req.ApplyDate,
+		Invoice:                   req.Invoice,
+		Reimbursement:             req.Reimbursement,
+		CompanyNames:              req.CompanyNames,
+		InvoiceTypes:              req.InvoiceTypes,
+		ApplyDate:                 req.ApplyDate,
+		SiblingInvoiceNumbers:     siblingNumbers,
+		InvoiceNumbers:            invoiceNumbers,
+		ConsecutiveInvoiceNumbers: findConsecutiveInvoiceNumbers(invoiceNumbers, req.Invoice.Number),
+		RecurringExpense:          req.Reimbursement != nil && req.Reimbursement.IsRecurring,
+		AmountReconciliation:      v.reconcileAmounts(ctx, req.Invoice, req.Reimbursement),
+		InvoiceTimeliness:         CheckInvoiceTimeliness(req.Invoice.Date, applyDate, v.invoiceMaxAge),
+		OCRConfidence:             CheckOCRConfidence(req.Invoice.FieldConfidences, v.ocrConfidenceThreshold),
+	}
+	if req.Reimbursement != nil {
+		validationData.ReimbursementTotal = req.Reimbursement.TotalAmount
+		validationData.Invoices = req.Reimbursement.Invoices
 	}
 
 	// 创建校验结果对象
@@ -57,25 +115,38 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 		Message:    "",
 	}
 
+	// 限额标准按开票日期解析，开票日期缺失时使用申请日期
+	referenceDate := req.Invoice.Date
+	if referenceDate.IsZero() {
+		referenceDate = req.ApplyDate
+	}
+	standards := newStandardRecorder(req.Invoice.ID, referenceDate)
+
+	// 申请人历史报销单，频次和周期性报销校验首次调用时查询
+	history := v.newReimbursementHistory(ctx, req.Reimbursement)
+
 	// 将校验结果添加到数据上下文中
 	dataContext := map[string]interface{}{
 		"data":   validationData,
 		"result": validationResult,
 		// 添加辅助函数 - 适配为Grule可用的函数
 		"IsDuplicateInvoice": func(invoiceCode, invoiceNumber string) bool {
-			result, _ := v.isDuplicateInvoice(ctx, invoiceCode, invoiceNumber)
+			result, _ := v.isDuplicateInvoice(ctx, req.Invoice, invoiceCode, invoiceNumber)
 			return result
 		},
 		"GetAccommodationLimit": func(cityLevel string) float64 {
-			return v.getAccommodationLimit(ctx, cityLevel)
+			return v.getAccommodationLimit(ctx, standards, cityLevel)
 		},
 		"GetEntertainmentLimit": func(level string) float64 {
-			return v.getEntertainmentLimit(ctx, level)
+			return v.getEntertainmentLimit(ctx, standards, level)
 		},
 		"IsConsecutiveInvoice": func(invoiceNumbers []string) bool {
 			result, _ := v.isConsecutiveInvoice(ctx, invoiceNumbers)
 			return result
 		},
+		"ConsecutiveInvoiceNumbers": func(invoiceNumbers []string) string {
+			return strings.Join(findConsecutiveInvoiceNumbers(invoiceNumbers, ""), "、")
+		},
 		"IsWeekendOrHoliday": func(date time.Time) bool {
 			result, _ := v.isWeekendOrHoliday(ctx, date)
 			return result
@@ -84,6 +155,13 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 			result, _ := v.isValidTaxNumber(ctx, taxNumber)
 			return result
 		},
+		"IsSelfDealing": func(buyerTaxNo, sellerTaxNo string) bool {
+			return IsSelfDealing(buyerTaxNo, sellerTaxNo)
+		},
+		"IsBlacklistedSeller": func(sellerName, sellerTaxNo string) bool {
+			result, _ := v.isBlacklistedSeller(ctx, sellerName, sellerTaxNo)
+			return result
+		},
 		"HasOrderAndReceipt": func(invoiceID string) bool {
 			result, _ := v.hasOrderAndReceipt(ctx, invoiceID)
 			return result
@@ -92,6 +170,24 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 			result, _ := v.isThreeDocumentMatching(ctx, invoiceID)
 			return result
 		},
+		"CountRecentReimbursements": func(days int) int {
+			return countRecentReimbursements(req.Reimbursement, history.get(), days)
+		},
+		"IsRecurrenceAnomaly": func() bool {
+			return isRecurrenceAnomaly(req.Reimbursement, history.get())
+		},
+		"IsExpectedRecurrence": func() bool {
+			return isExpectedRecurrence(req.Reimbursement, history.get())
+		},
+		"IsAmountMismatch": func() bool {
+			return validationData.AmountReconciliation.Mismatched()
+		},
+		"AmountDifference": func() float64 {
+			if validationData.AmountReconciliation == nil {
+				return 0
+			}
+			return validationData.AmountReconciliation.Difference
+		},
 	}
 
 	// 执行规则并收集结果
@@ -99,6 +195,9 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 		if !rule.Enabled {
 			continue // 跳过禁用的规则
 		}
+		if !rule.IsEffectiveAt(req.ApplyDate) {
+			continue // 跳过申请日期不在生效时间段内的规则
+		}
 
 		v.logger.WithContext(ctx).Debug("执行规则",
 			logger.NewField("规则ID", rule.ID),
@@ -132,6 +231,12 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 							Suggestion: getString(v, "Suggestion"),
 							Priority:   getInt(v, "Priority"),
 						}
+						annotateConsecutiveViolation(rule, violationObj, validationData.ConsecutiveInvoiceNumbers)
+						annotateAmountViolation(rule, violationObj, validationData.AmountReconciliation)
+						// 规则作者编写了违规说明时，优先使用插值后的说明
+						if explanation, ok := renderExplanation(rule.Explanation, ruleResult.Data, v, consecutiveVariables(validationData), amountVariables(validationData)); ok {
+							violationObj.Suggestion = explanation
+						}
 						result.Violations = append(result.Violations, violationObj)
 					}
 				}
@@ -146,11 +251,31 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 					Suggestion: generateSuggestion(ruleResult.RuleType, ruleResult.Message),
 					Priority:   ruleResult.Priority,
 				}
+				annotateConsecutiveViolation(rule, violation, validationData.ConsecutiveInvoiceNumbers)
+				annotateAmountViolation(rule, violation, validationData.AmountReconciliation)
+				if explanation, ok := renderExplanation(rule.Explanation, ruleResult.Data, map[string]interface{}{
+					"RuleID":   ruleResult.RuleID,
+					"RuleName": ruleResult.RuleName,
+					"RuleType": ruleResult.RuleType,
+					"Message":  ruleResult.Message,
+				}, consecutiveVariables(validationData), amountVariables(validationData)); ok {
+					violation.Suggestion = explanation
+				}
 				result.Violations = append(result.Violations, violation)
 			}
 		}
 	}
 
+	// 关键字段识别置信度不足时规则结论可能基于错误的识别结果，要求人工复核，不依赖规则库配置
+	if validationData.OCRConfidence.LowConfidence {
+		result.Passed = false
+		result.ManualReview = true
+		result.Violations = append(result.Violations, newOCRConfidenceViolation(validationData.OCRConfidence))
+		v.logger.WithContext(ctx).Warn("发票关键字段OCR识别置信度不足，需人工复核",
+			logger.NewField("发票ID", req.Invoice.ID),
+			logger.NewField("说明", validationData.OCRConfidence.Message))
+	}
+
 	// 按优先级排序违规信息
 	sort.Slice(result.Violations, func(i, j int) bool {
 		return result.Violations[i].Priority > result.Violations[j].Priority
@@ -158,6 +283,7 @@ func (v *InvoiceValidatorImpl) executeRulesWithPriority(ctx context.Context, req
 
 	// 生成校验结果摘要
 	generateValidationSummary(result)
+	result.AppliedStandards = standards.list()
 
 	v.logger.WithContext(ctx).Info("规则执行完成",
 		logger.NewField("发票ID", req.Invoice.ID),
@@ -198,37 +324,41 @@ func generateValidationSummary(result *InvoiceValidationResult) {
 		return
 	}
 
-	// 按严重程度统计违规
-	highCount := 0
-	mediumCount := 0
-	lowCount := 0
-
-	for _, violation := range result.Violations {
-		switch violation.Severity {
-		case "高":
-			highCount++
-		case "中":
-			mediumCount++
-		case "低":
-			lowCount++
-		}
-	}
+	countViolationsBySeverity(result)
 
 	// 生成摘要
 	summary := "发票校验未通过，发现"
-	if highCount > 0 {
-		summary += " " + string(rune(highCount+'0')) + "个高严重程度违规"
+	if result.HighCount > 0 {
+		summary += " " + strconv.Itoa(result.HighCount) + "个高严重程度违规"
 	}
-	if mediumCount > 0 {
-		summary += " " + string(rune(mediumCount+'0')) + "个中严重程度违规"
+	if result.MediumCount > 0 {
+		summary += " " + strconv.Itoa(result.MediumCount) + "个中严重程度违规"
 	}
-	if lowCount > 0 {
-		summary += " " + string(rune(lowCount+'0')) + "个低严重程度违规"
+	if result.LowCount > 0 {
+		summary += " " + strconv.Itoa(result.LowCount) + "个低严重程度违规"
 	}
 
 	result.Summary = summary
 }
 
+// countViolationsBySeverity 按严重程度统计违规数量并写入校验结果
+func countViolationsBySeverity(result *InvoiceValidationResult) {
+	result.HighCount = 0
+	result.MediumCount = 0
+	result.LowCount = 0
+
+	for _, violation := range result.Violations {
+		switch violation.Severity {
+		case "高":
+			result.HighCount++
+		case "中":
+			result.MediumCount++
+		case "低":
+			result.LowCount++
+		}
+	}
+}
+
 // determineSeverity 根据规则类型确定严重程度
 func determineSeverity(ruleType string) string {
 	switch ruleType {
@@ -251,7 +381,7 @@ func generateSuggestion(ruleType, message string) string {
 	case "金额校验":
 		return "请检查发票金额是否正确，确保不超过报销金额且总和匹配"
 	case "时效校验":
-		return "请确保发票开票日期距报销申请日期不超过180天"
+		return "请确保发票开票日期不晚于报销申请日期，且距申请日期不超过规定的报销时限（默认180天）"
 	case "抬头校验":
 		return "请确保发票抬头与报销人所在公司名称一致"
 	case "类型校验":
@@ -269,6 +399,33 @@ func generateSuggestion(ruleType, message string) string {
 	}
 }
 
+// renderExplanation 渲染规则作者编写的违规说明模板
+// 变量来源于规则执行数据和违规信息，后者优先；模板为空或渲染失败时返回false，由调用方回退到通用建议
+func renderExplanation(explanation string, sources ...map[string]interface{}) (string, bool) {
+	if strings.TrimSpace(explanation) == "" {
+		return "", false
+	}
+
+	variables := make(map[string]interface{})
+	for _, source := range sources {
+		for key, value := range source {
+			variables[key] = value
+		}
+	}
+
+	tmpl, err := template.New("explanation").Option("missingkey=error").Parse(explanation)
+	if err != nil {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", false
+	}
+
+	return buf.String(), true
+}
+
 // ExecuteAllRules 执行所有发票校验规则
 func (v *InvoiceValidatorImpl) ExecuteAllRules(ctx context.Context, req *InvoiceValidationRequest) (*InvoiceValidationResult, error) {
 	v.logger.WithContext(ctx).Info("执行所有发票校验规则",
@@ -323,218 +480,256 @@ func getInt(m map[string]interface{}, key string) int {
 }
 
 // isDuplicateInvoice 检查发票是否重复
-func (v *InvoiceValidatorImpl) isDuplicateInvoice(ctx context.Context, invoiceCode, invoiceNumber string) (bool, error) {
-	// 这里应该调用repository检查发票是否已存在
-	// 简化实现，实际应该查询数据库
+// 发票代码+号码已出现在其它已通过的报销单中即视为重复报销，current为当前待校验发票，用于排除自身
+func (v *InvoiceValidatorImpl) isDuplicateInvoice(ctx context.Context, current *ocr.Invoice, invoiceCode, invoiceNumber string) (bool, error) {
+	if invoiceCode == "" || invoiceNumber == "" {
+		return false, nil
+	}
+	if v.invoiceRepo == nil {
+		return false, errors.New("发票仓储未配置")
+	}
+
+	invoices, err := v.invoiceRepo.ListInvoicesByCodeAndNumber(ctx, invoiceCode, invoiceNumber, approvedReimbursementStatuses)
+	if err != nil {
+		v.logger.WithContext(ctx).Error("查询重复发票失败",
+			logger.NewField("发票代码", invoiceCode),
+			logger.NewField("发票号码", invoiceNumber),
+			logger.NewField("error", err.Error()))
+		return false, fmt.Errorf("查询重复发票失败: %w", err)
+	}
+
+	for _, invoice := range invoices {
+		if invoice == nil {
+			continue
+		}
+		if current != nil && (invoice.ID == current.ID || invoice.ReimbursementID == current.ReimbursementID) {
+			continue
+		}
+		return true, nil
+	}
+
 	return false, nil
 }
 
-// getAccommodationLimit 获取住宿限额
-func (v *InvoiceValidatorImpl) getAccommodationLimit(ctx context.Context, cityLevel string) float64 {
-	// 根据城市级别返回住宿限额
-	switch cityLevel {
-	case "一线城市":
-		return 600.0
-	case "二线城市":
-		return 400.0
-	case "三线城市":
-		return 300.0
-	default:
-		return 200.0
-	}
+// getAccommodationLimit 获取住宿限额，根据城市级别解析生效的限额标准并记录
+func (v *InvoiceValidatorImpl) getAccommodationLimit(ctx context.Context, standards *standardRecorder, cityLevel string) float64 {
+	return standards.resolve(v.limitStandards, LimitCategoryAccommodation, cityLevel, "")
 }
 
-// getEntertainmentLimit 获取招待费限额
-func (v *InvoiceValidatorImpl) getEntertainmentLimit(ctx context.Context, level string) float64 {
-	// 根据级别返回招待费限额
-	switch level {
-	case "高管":
-		return 500.0
-	case "经理":
-		return 300.0
-	case "员工":
-		return 100.0
-	default:
-		return 100.0
-	}
+// getEntertainmentLimit 获取招待费限额，根据职级解析生效的限额标准并记录
+func (v *InvoiceValidatorImpl) getEntertainmentLimit(ctx context.Context, standards *standardRecorder, level string) float64 {
+	return standards.resolve(v.limitStandards, LimitCategoryEntertainment, "", level)
 }
 
 // isConsecutiveInvoice 检查是否为连号发票
 func (v *InvoiceValidatorImpl) isConsecutiveInvoice(ctx context.Context, invoiceNumbers []string) (bool, error) {
-	if len(invoiceNumbers) < 2 {
-		return false, nil
+	return len(findConsecutiveInvoiceNumbers(invoiceNumbers, "")) > 0, nil
+}
+
+// siblingInvoiceNumbers 获取同一报销单中除待校验发票外的其它发票号码
+func siblingInvoiceNumbers(current *ocr.Invoice, reim *reimbursement.Reimbursement) []string {
+	if reim == nil {
+		return nil
 	}
 
-	// 提取发票号码中的数字部分
-	numbers := make([]int, 0, len(invoiceNumbers))
-	for _, numStr := range invoiceNumbers {
-		// 简化处理：提取字符串中的数字部分
-		var num int
-		_, err := fmt.Sscanf(numStr, "%d", &num)
-		if err != nil {
-			// 如果无法提取数字，尝试从字符串末尾提取
-			for i := len(numStr) - 1; i >= 0; i-- {
-				if numStr[i] < '0' || numStr[i] > '9' {
-					if i < len(numStr)-1 {
-						numStr = numStr[i+1:]
-						_, err = fmt.Sscanf(numStr, "%d", &num)
-						if err == nil {
-							break
-						}
+	numbers := make([]string, 0, len(reim.Invoices))
+	for _, invoice := range reim.Invoices {
+		if invoice == nil || invoice.Number == "" {
+			continue
+		}
+		if current != nil && (invoice.ID == current.ID || invoice.Number == current.Number) {
+			continue
+		}
+		numbers = append(numbers, invoice.Number)
+	}
+	return numbers
+}
+
+// invoiceNumberParts 发票号码拆分结果
+type invoiceNumberParts struct {
+	raw    string // 原始号码
+	prefix string // 非数字前缀（含超长数字的高位部分）
+	value  uint64 // 数字后缀
+}
+
+// splitInvoiceNumber 拆分发票号码为前缀和数字后缀，如"A00123"拆为"A"和123
+func splitInvoiceNumber(number string) (invoiceNumberParts, bool) {
+	trimmed := strings.ToUpper(strings.TrimSpace(number))
+
+	start := len(trimmed)
+	for start > 0 && trimmed[start-1] >= '0' && trimmed[start-1] <= '9' {
+		start--
+	}
+	if start == len(trimmed) {
+		return invoiceNumberParts{}, false
+	}
+
+	// 数字过长时高位并入前缀，避免溢出
+	if len(trimmed)-start > maxInvoiceNumberDigits {
+		start = len(trimmed) - maxInvoiceNumberDigits
+	}
+
+	value, err := strconv.ParseUint(trimmed[start:], 10, 64)
+	if err != nil {
+		return invoiceNumberParts{}, false
+	}
+
+	return invoiceNumberParts{raw: number, prefix: trimmed[:start], value: value}, true
+}
+
+// findConsecutiveInvoiceNumbers 查找连号发票号码
+// 按前缀分组、数字后缀排序后查找连续张数不少于minConsecutiveInvoiceCount的号码段；
+// target非空时只返回包含该号码的号码段，否则返回最长的号码段
+func findConsecutiveInvoiceNumbers(invoiceNumbers []string, target string) []string {
+	groups := make(map[string][]invoiceNumberParts)
+	seen := make(map[string]bool)
+	for _, number := range invoiceNumbers {
+		parts, ok := splitInvoiceNumber(number)
+		if !ok {
+			continue // 跳过无法解析的发票号码
+		}
+		key := parts.prefix + "#" + strconv.FormatUint(parts.value, 10)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		groups[parts.prefix] = append(groups[parts.prefix], parts)
+	}
+
+	prefixes := make([]string, 0, len(groups))
+	for prefix := range groups {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var best []string
+	for _, prefix := range prefixes {
+		group := groups[prefix]
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].value < group[j].value
+		})
+
+		start := 0
+		for i := 1; i <= len(group); i++ {
+			if i < len(group) && group[i].value == group[i-1].value+1 {
+				continue
+			}
+
+			// group[start:i]为一个连续号码段
+			if i-start >= minConsecutiveInvoiceCount {
+				run := make([]string, 0, i-start)
+				containsTarget := false
+				for _, parts := range group[start:i] {
+					run = append(run, parts.raw)
+					if parts.raw == target {
+						containsTarget = true
 					}
-					continue
+				}
+				if (target == "" || containsTarget) && len(run) > len(best) {
+					best = run
 				}
 			}
-			if err != nil {
-				continue // 跳过无法解析的发票号码
-			}
+			start = i
 		}
-		numbers = append(numbers, num)
 	}
 
-	if len(numbers) < 2 {
-		return false, nil
+	return best
+}
+
+// annotateConsecutiveViolation 连号发票违规时在违规描述中列出具体连号的发票号码
+func annotateConsecutiveViolation(rule *RuleDefinition, violation *InvoiceViolation, consecutive []string) {
+	if len(consecutive) == 0 || !isConsecutiveInvoiceRule(rule) {
+		return
 	}
 
-	// 按数字大小排序
-	sort.Ints(numbers)
+	numbers := strings.Join(consecutive, "、")
+	if strings.Contains(violation.Message, numbers) {
+		return
+	}
+	violation.Message = fmt.Sprintf("%s（连号发票：%s）", violation.Message, numbers)
+}
 
-	// 检查是否有连续的号码
-	consecutiveCount := 1
-	for i := 1; i < len(numbers); i++ {
-		if numbers[i] == numbers[i-1]+1 {
-			consecutiveCount++
-			if consecutiveCount >= 3 {
-				return true, nil // 3个或以上连续号码视为连号发票
-			}
-		} else {
-			consecutiveCount = 1
-		}
+// isConsecutiveInvoiceRule 判断是否为连号发票规则
+func isConsecutiveInvoiceRule(rule *RuleDefinition) bool {
+	if rule == nil {
+		return false
 	}
+	return rule.RuleCode == consecutiveInvoiceRuleCode || strings.Contains(rule.Name, "连号")
+}
 
-	return false, nil
+// consecutiveVariables 连号发票相关的违规说明模板变量
+func consecutiveVariables(data *InvoiceValidationData) map[string]interface{} {
+	return map[string]interface{}{
+		"ConsecutiveInvoiceNumbers": strings.Join(data.ConsecutiveInvoiceNumbers, "、"),
+	}
 }
 
 // isWeekendOrHoliday 检查是否为周末或节假日
+// 调休上班日（含调休的周末）不算节假日；节假日数据源不可用时退化为仅判断周末
 func (v *InvoiceValidatorImpl) isWeekendOrHoliday(ctx context.Context, date time.Time) (bool, error) {
-	// 检查日期是否为周末或节假日
 	weekday := date.Weekday()
+	isWeekend := weekday == time.Saturday || weekday == time.Sunday
 
-	// 首先检查是否为周末
-	if weekday == time.Saturday || weekday == time.Sunday {
-		return true, nil
+	if v.holidayProvider == nil {
+		return isWeekend, nil
 	}
 
-	// 检查是否为法定节假日
-	// 这里简化实现，实际应该查询节假日数据库或API
-	// 可以根据年份和日期判断是否为法定节假日
-	month := int(date.Month())
-	day := date.Day()
-
-	// 常见的固定法定节假日（简化版）
-	holidays := map[string]bool{
-		"0101": true, // 元旦
-		"0501": true, // 劳动节
-		"1001": true, // 国庆节
-		"1002": true, // 国庆节
-		"1003": true, // 国庆节
+	// 调休上班日优先，周末上班不算节假日
+	workday, err := v.holidayProvider.IsAdjustedWorkday(ctx, date)
+	if err != nil {
+		v.logger.WithContext(ctx).Warn("查询调休上班日失败，仅按周末判断",
+			logger.NewField("日期", date.Format(holidayDateLayout)),
+			logger.NewField("error", err.Error()))
+		return isWeekend, err
+	}
+	if workday {
+		return false, nil
 	}
 
-	// 检查固定节假日
-	monthDay := fmt.Sprintf("%02d%02d", month, day)
-	if isHoliday, exists := holidays[monthDay]; exists && isHoliday {
+	if isWeekend {
 		return true, nil
 	}
 
-	// 检查春节、清明节、端午节、中秋节等浮动节假日
-	// 这里简化处理，实际应该查询具体的节假日安排
-	if month == 1 || month == 2 || month == 4 || month == 6 || month == 9 {
-		// 这些月份可能有浮动节假日，需要更复杂的逻辑
-		// 简化实现：假设1-2月可能有春节，4月可能有清明，6月可能有端午，9月可能有中秋
-		// 实际应用中应该使用专门的节假日库或API
+	holiday, err := v.holidayProvider.IsHoliday(ctx, date)
+	if err != nil {
+		v.logger.WithContext(ctx).Warn("查询法定节假日失败，仅按周末判断",
+			logger.NewField("日期", date.Format(holidayDateLayout)),
+			logger.NewField("error", err.Error()))
+		return false, err
 	}
 
-	return false, nil
+	return holiday, nil
 }
 
 // isValidTaxNumber 检查税号是否有效
+// 18位税号按统一社会信用代码校验位算法校验，15位老税号仅校验地区码格式
 func (v *InvoiceValidatorImpl) isValidTaxNumber(ctx context.Context, taxNumber string) (bool, error) {
-	// 检查税号格式是否有效
-	if len(taxNumber) < 15 || len(taxNumber) > 20 {
-		return false, nil
-	}
-
-	// 去除空格和特殊字符
-	cleanedTaxNumber := strings.ReplaceAll(taxNumber, " ", "")
-	cleanedTaxNumber = strings.ReplaceAll(cleanedTaxNumber, "-", "")
-	cleanedTaxNumber = strings.ReplaceAll(cleanedTaxNumber, "_", "")
+	// 去除空格和特殊字符并统一大写
+	cleanedTaxNumber := NormalizeTaxNumber(taxNumber)
 
 	// 检查长度
 	if len(cleanedTaxNumber) < 15 || len(cleanedTaxNumber) > 20 {
 		return false, nil
 	}
 
-	// 检查是否全为数字或数字+字母
+	// 检查是否全为数字或字母
 	for _, char := range cleanedTaxNumber {
-		if !((char >= '0' && char <= '9') || (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z')) {
+		if !((char >= '0' && char <= '9') || (char >= 'A' && char <= 'Z')) {
 			return false, nil
 		}
 	}
 
-	// 根据长度判断税号类型并验证格式
-	if len(cleanedTaxNumber) == 15 {
-		// 15位税号（老版）
-		// 检查前6位是否为地区码（简化处理）
-		if len(cleanedTaxNumber) >= 6 {
-			areaCode := cleanedTaxNumber[0:6]
-			// 简化验证：检查前6位是否全为数字
-			for _, char := range areaCode {
-				if char < '0' || char > '9' {
-					return false, nil
-				}
-			}
-		}
-	} else if len(cleanedTaxNumber) == 18 {
-		// 18位税号（新版统一社会信用代码）
-		// 检查第1位为数字，第2位为字母或数字，第3-8位为数字，第9位为数字或字母
-		// 第10-17位为数字，第18位为数字或字母或校验码
-
-		// 检查第1位
-		if cleanedTaxNumber[0] < '0' || cleanedTaxNumber[0] > '9' {
-			return false, nil
-		}
-
-		// 检查第2位
-		char2 := cleanedTaxNumber[1]
-		if !((char2 >= '0' && char2 <= '9') || (char2 >= 'A' && char2 <= 'Z') || (char2 >= 'a' && char2 <= 'z')) {
-			return false, nil
-		}
-
-		// 检查第3-8位
-		for i := 2; i < 8; i++ {
-			if cleanedTaxNumber[i] < '0' || cleanedTaxNumber[i] > '9' {
-				return false, nil
-			}
-		}
-
-		// 检查第9位
-		char9 := cleanedTaxNumber[8]
-		if !((char9 >= '0' && char9 <= '9') || (char9 >= 'A' && char9 <= 'Z') || (char9 >= 'a' && char9 <= 'z')) {
-			return false, nil
-		}
-
-		// 检查第10-17位
-		for i := 9; i < 17; i++ {
-			if cleanedTaxNumber[i] < '0' || cleanedTaxNumber[i] > '9' {
+	switch len(cleanedTaxNumber) {
+	case 15:
+		// 15位税号（老版），前6位为地区码
+		for _, char := range cleanedTaxNumber[0:6] {
+			if char < '0' || char > '9' {
 				return false, nil
 			}
 		}
-
-		// 检查第18位（校验码）
-		char18 := cleanedTaxNumber[17]
-		if !((char18 >= '0' && char18 <= '9') || (char18 >= 'A' && char18 <= 'Z') || (char18 >= 'a' && char18 <= 'z')) {
-			return false, nil
-		}
+	case UnifiedCreditCodeLength:
+		// 18位税号（统一社会信用代码），校验字符集和校验位
+		return IsValidUnifiedCreditCode(cleanedTaxNumber), nil
 	}
 
 	return true, nil
@@ -542,61 +737,82 @@ func (v *InvoiceValidatorImpl) isValidTaxNumber(ctx context.Context, taxNumber s
 
 // hasOrderAndReceipt 检查是否有订单和收据
 func (v *InvoiceValidatorImpl) hasOrderAndReceipt(ctx context.Context, invoiceID string) (bool, error) {
-	// 检查发票是否有对应的订单和收据
-	// 实际应该查询订单和收据数据
-
-	// 从数据库查询发票对应的订单信息
-	// 这里简化实现，假设通过repository查询
-	// orders, err := v.orderRepository.GetOrdersByInvoiceID(ctx, invoiceID)
-	// receipts, err := v.receiptRepository.GetReceiptsByInvoiceID(ctx, invoiceID)
-
-	// 简化实现：假设查询结果
-	hasOrder := true   // 实际应该查询数据库
-	hasReceipt := true // 实际应该查询数据库
+	_, order, receipt, err := v.findRelatedDocuments(ctx, invoiceID)
+	if err != nil {
+		return false, err
+	}
 
-	// 如果两者都有，返回true
-	return hasOrder && hasReceipt, nil
+	return order != nil && receipt != nil, nil
 }
 
 // isThreeDocumentMatching 检查三单是否匹配
+// 发票、订单、收据金额需一致，且日期满足：订单日期 <= 发票日期 <= 收据日期
 func (v *InvoiceValidatorImpl) isThreeDocumentMatching(ctx context.Context, invoiceID string) (bool, error) {
-	// 检查发票、订单、收据三单是否匹配
-	// 实际应该比较三单信息
-
-	// 从数据库查询发票、订单、收据信息
-	// invoice, err := v.invoiceRepository.GetByID(ctx, invoiceID)
-	// orders, err := v.orderRepository.GetOrdersByInvoiceID(ctx, invoiceID)
-	// receipts, err := v.receiptRepository.GetReceiptsByInvoiceID(ctx, invoiceID)
-
-	// 简化实现：假设查询结果
-	// 实际应该检查以下信息是否一致：
-	// 1. 金额是否一致
-	// 2. 商品/服务名称是否一致
-	// 3. 数量是否一致
-	// 4. 日期是否合理（订单日期 <= 发票日期 <= 收据日期）
-
-	// 模拟数据
-	invoiceAmount := 1000.0 // 发票金额
-	orderAmount := 1000.0   // 订单金额
-	receiptAmount := 1000.0 // 收据金额
+	invoice, order, receipt, err := v.findRelatedDocuments(ctx, invoiceID)
+	if err != nil {
+		return false, err
+	}
+	if invoice == nil || order == nil || receipt == nil {
+		return false, nil
+	}
 
 	// 检查金额是否一致
-	amountMatch := invoiceAmount == orderAmount && orderAmount == receiptAmount
-
-	// 检查商品/服务名称是否一致
-	// invoiceItems := invoice.Items
-	// orderItems := order.Items
-	// receiptItems := receipt.Items
-	// itemsMatch := compareItems(invoiceItems, orderItems, receiptItems)
-	itemsMatch := true // 简化实现
+	amountMatch := amountEqual(invoice.Amount, order.Amount) && amountEqual(invoice.Amount, receipt.Amount)
 
 	// 检查日期是否合理
-	// invoiceDate := invoice.Date
-	// orderDate := order.Date
-	// receiptDate := receipt.Date
-	// dateValid := orderDate <= invoiceDate && invoiceDate <= receiptDate
-	dateValid := true // 简化实现
-
-	// 如果所有检查都通过，返回true
-	return amountMatch && itemsMatch && dateValid, nil
+	dateValid := !invoice.Date.IsZero() && !order.Date.IsZero() && !receipt.Date.IsZero() &&
+		!order.Date.After(invoice.Date) && !invoice.Date.After(receipt.Date)
+
+	return amountMatch && dateValid, nil
+}
+
+// findRelatedDocuments 查询发票及其所属报销单中对应的订单和收据
+// 订单按合同编号关联，收据按收据编号关联；发票未填写编号时取同一报销单中的首个对应单据
+func (v *InvoiceValidatorImpl) findRelatedDocuments(ctx context.Context, invoiceID string) (*ocr.Invoice, *ocr.Invoice, *ocr.Invoice, error) {
+	if invoiceID == "" {
+		return nil, nil, nil, nil
+	}
+	if v.invoiceRepo == nil {
+		return nil, nil, nil, errors.New("发票仓储未配置")
+	}
+
+	invoice, err := v.invoiceRepo.GetInvoiceByID(ctx, invoiceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, nil
+		}
+		return nil, nil, nil, fmt.Errorf("查询发票失败: %w", err)
+	}
+	if invoice == nil {
+		return nil, nil, nil, nil
+	}
+
+	documents, err := v.invoiceRepo.ListInvoicesByReimbursementID(ctx, invoice.ReimbursementID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("查询报销单据失败: %w", err)
+	}
+
+	var order, receipt *ocr.Invoice
+	for _, document := range documents {
+		if document == nil || document.ID == invoice.ID {
+			continue
+		}
+		switch document.Type {
+		case DocumentTypeOrder:
+			if order == nil && (invoice.ContractNumber == "" || document.ContractNumber == invoice.ContractNumber) {
+				order = document
+			}
+		case DocumentTypeReceipt:
+			if receipt == nil && (invoice.ReceiptNumber == "" || document.ReceiptNumber == invoice.ReceiptNumber) {
+				receipt = document
+			}
+		}
+	}
+
+	return invoice, order, receipt, nil
+}
+
+// amountEqual 判断两个金额是否一致（精确到分）
+func amountEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
 }
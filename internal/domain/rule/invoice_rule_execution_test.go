@@ -0,0 +1,100 @@
+package rule
+
+import "testing"
+
+func TestRenderExplanation(t *testing.T) {
+	tests := []struct {
+		name        string
+		explanation string
+		sources     []map[string]interface{}
+		want        string
+		wantOK      bool
+	}{
+		{
+			name:        "按执行数据插值",
+			explanation: "金额{{.Actual}}超过限额{{.Limit}}",
+			sources:     []map[string]interface{}{{"Actual": 1200, "Limit": 1000}},
+			want:        "金额1200超过限额1000",
+			wantOK:      true,
+		},
+		{
+			name:        "违规信息覆盖同名执行数据",
+			explanation: "{{.Message}}",
+			sources:     []map[string]interface{}{{"Message": "执行数据"}, {"Message": "违规信息"}},
+			want:        "违规信息",
+			wantOK:      true,
+		},
+		{name: "模板为空时回退", explanation: "  ", wantOK: false},
+		{name: "模板语法错误时回退", explanation: "{{.Limit", wantOK: false},
+		{
+			name:        "引用不存在的变量时回退",
+			explanation: "限额{{.Limit}}",
+			sources:     []map[string]interface{}{{"Actual": 1200}},
+			wantOK:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := renderExplanation(tt.explanation, tt.sources...)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("renderExplanation() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateValidationSummary(t *testing.T) {
+	violations := func(severities ...string) []*InvoiceViolation {
+		list := make([]*InvoiceViolation, 0, len(severities))
+		for _, severity := range severities {
+			list = append(list, &InvoiceViolation{Severity: severity})
+		}
+		return list
+	}
+	repeat := func(severity string, n int) []string {
+		list := make([]string, n)
+		for i := range list {
+			list[i] = severity
+		}
+		return list
+	}
+
+	tests := []struct {
+		name       string
+		result     *InvoiceValidationResult
+		want       string
+		wantCounts [3]int
+	}{
+		{
+			name:   "校验通过",
+			result: &InvoiceValidationResult{Passed: true},
+			want:   "发票校验通过，无违规项",
+		},
+		{
+			name:       "按严重程度汇总",
+			result:     &InvoiceValidationResult{Violations: violations("高", "中", "中", "低")},
+			want:       "发票校验未通过，发现 1个高严重程度违规 2个中严重程度违规 1个低严重程度违规",
+			wantCounts: [3]int{1, 2, 1},
+		},
+		{
+			name:       "违规数量超过9个时按十进制输出",
+			result:     &InvoiceValidationResult{Violations: violations(repeat("高", 12)...)},
+			want:       "发票校验未通过，发现 12个高严重程度违规",
+			wantCounts: [3]int{12, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generateValidationSummary(tt.result)
+			if tt.result.Summary != tt.want {
+				t.Errorf("Summary = %q, want %q", tt.result.Summary, tt.want)
+			}
+			counts := [3]int{tt.result.HighCount, tt.result.MediumCount, tt.result.LowCount}
+			if counts != tt.wantCounts {
+				t.Errorf("违规数量 = %v, want %v", counts, tt.wantCounts)
+			}
+		})
+	}
+}
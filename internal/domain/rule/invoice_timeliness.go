@@ -0,0 +1,84 @@
+// invoice_timeliness.go 发票时效校验
+// 功能点：
+// 1. 按报销申请日期（而非当前时间）计算开票日期距申请日期的天数，超过规定天数视为过期
+// 2. 规定天数可配置，默认180天
+// 3. 开票日期缺失或无法识别、开票日期晚于申请日期、申请日期缺失分别给出不同的违规信息
+
+package rule
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultInvoiceMaxAge 开票日期距申请日期默认的最长天数
+const DefaultInvoiceMaxAge = 180
+
+// 发票时效校验状态
+const (
+	InvoiceTimelinessValid            = "有效"
+	InvoiceTimelinessExpired          = "已过期"
+	InvoiceTimelinessFutureDate       = "开票日期晚于申请日期"
+	InvoiceTimelinessDateMissing      = "开票日期缺失"
+	InvoiceTimelinessApplyDateMissing = "申请日期缺失"
+)
+
+// InvoiceTimeliness 发票时效校验结果
+type InvoiceTimeliness struct {
+	Status  string `json:"status"`  // 校验状态
+	Valid   bool   `json:"valid"`   // 是否在有效期内
+	Days    int    `json:"days"`    // 开票日期距申请日期的天数，日期缺失时为0
+	MaxAge  int    `json:"max_age"` // 规定的最长天数
+	Message string `json:"message"` // 校验说明，未通过时为违规信息
+}
+
+// CheckInvoiceTimeliness 按申请日期校验发票时效，日期按自然日比较，maxAge非正数时使用默认天数
+// 开票日期为零值表示缺失或OCR未能识别
+func CheckInvoiceTimeliness(invoiceDate, applyDate time.Time, maxAge int) *InvoiceTimeliness {
+	if maxAge <= 0 {
+		maxAge = DefaultInvoiceMaxAge
+	}
+	result := &InvoiceTimeliness{MaxAge: maxAge}
+
+	switch {
+	case invoiceDate.IsZero():
+		result.Status = InvoiceTimelinessDateMissing
+		result.Message = "发票开票日期缺失或无法识别，无法校验发票时效"
+		return result
+	case applyDate.IsZero():
+		result.Status = InvoiceTimelinessApplyDateMissing
+		result.Message = "报销单缺少申请日期，无法校验发票时效"
+		return result
+	}
+
+	result.Days = daysBetween(invoiceDate, applyDate)
+	switch {
+	case result.Days < 0:
+		result.Status = InvoiceTimelinessFutureDate
+		result.Message = fmt.Sprintf("发票开票日期%s晚于报销申请日期%s，请核实开票日期",
+			invoiceDate.Format(holidayDateLayout), applyDate.Format(holidayDateLayout))
+	case result.Days > maxAge:
+		result.Status = InvoiceTimelinessExpired
+		result.Message = fmt.Sprintf("发票开票日期距报销申请日期%d天，超过%d天的报销时限", result.Days, maxAge)
+	default:
+		result.Status = InvoiceTimelinessValid
+		result.Valid = true
+		result.Message = fmt.Sprintf("发票开票日期距报销申请日期%d天，在%d天的报销时限内", result.Days, maxAge)
+	}
+	return result
+}
+
+// daysBetween 计算两个日期相差的自然日天数，to早于from时为负数
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// SetInvoiceMaxAge 设置开票日期距申请日期的最长天数，非正数时使用默认天数
+func (v *InvoiceValidatorImpl) SetInvoiceMaxAge(days int) {
+	if days <= 0 {
+		days = DefaultInvoiceMaxAge
+	}
+	v.invoiceMaxAge = days
+}
@@ -4,12 +4,19 @@
 // 2. 定义发票校验规则接口
 // 3. 实现基础刚性规则校验逻辑
 // 4. 提供规则优先级执行和错误聚合功能
+// 5. 限额标准可配置，校验结果记录实际采用的限额标准
+// 6. 频次校验识别周期性报销，同一订阅的按期报销不视为异常
+// 7. 校验报销单总额与发票价税合计之和一致，误差阈值和汇率数据源可配置
+// 8. 按报销申请日期校验发票时效，最长天数可配置
+// 9. 发票销售方命中黑名单时按高风险违规处理
+// 10. 关键字段OCR识别置信度低于阈值时标记发票需人工复核
 
 package rule
 
 import (
 	"context"
 	"errors"
+	"strconv"
 	"time"
 
 	"reimbursement-audit/internal/domain/ocr"
@@ -19,11 +26,17 @@ import (
 
 // InvoiceValidationResult 发票校验结果
 type InvoiceValidationResult struct {
-	Passed     bool                `json:"passed"`     // 是否通过校验
-	InvoiceID  string              `json:"invoice_id"` // 发票ID
-	Violations []*InvoiceViolation `json:"violations"` // 违规规则列表
-	Summary    string              `json:"summary"`    // 校验结果摘要
-	Timestamp  time.Time           `json:"timestamp"`  // 校验时间
+	Passed      bool                `json:"passed"`       // 是否通过校验
+	InvoiceID   string              `json:"invoice_id"`   // 发票ID
+	Violations  []*InvoiceViolation `json:"violations"`   // 违规规则列表
+	Summary     string              `json:"summary"`      // 校验结果摘要
+	HighCount   int                 `json:"high_count"`   // 高严重程度违规数量
+	MediumCount int                 `json:"medium_count"` // 中严重程度违规数量
+	LowCount    int                 `json:"low_count"`    // 低严重程度违规数量
+	Timestamp   time.Time           `json:"timestamp"`    // 校验时间
+
+	ManualReview     bool                    `json:"manual_review"`     // 是否需人工复核(关键字段OCR识别置信度不足)
+	AppliedStandards []*AppliedLimitStandard `json:"applied_standards"` // 校验时采用的限额标准
 }
 
 // InvoiceViolation 发票违规信息
@@ -70,26 +83,62 @@ type RuleDefinition struct {
 	Category    string `json:"category"`    // 规则分类
 	Description string `json:"description"` // 规则描述
 	Definition  string `json:"definition"`  // 规则定义(Grule语法)
+	Explanation string `json:"explanation"` // 违规说明模板
 	Priority    int    `json:"priority"`    // 优先级
+	Timeout     int    `json:"timeout"`     // 执行超时时间(毫秒)
 	Enabled     bool   `json:"enabled"`     // 是否启用
+
+	EffectiveFrom *time.Time `json:"effective_from"` // 生效开始日期
+	EffectiveTo   *time.Time `json:"effective_to"`   // 生效结束日期
 }
 
 // InvoiceValidatorImpl 发票校验器实现
 type InvoiceValidatorImpl struct {
-	ruleEngine *GRuleEngine
-	repository Repository
-	logger     logger.Logger
-	rules      []*RuleDefinition
+	ruleEngine             *GRuleEngine
+	repository             Repository
+	invoiceRepo            ocr.Repository
+	reimbursementRepo      reimbursement.Repository
+	holidayProvider        HolidayProvider
+	limitStandards         []*LimitStandard
+	amountTolerance        float64
+	rateProvider           ocr.ExchangeRateProvider
+	invoiceMaxAge          int
+	ocrConfidenceThreshold float64
+	sellerBlacklist        *SellerBlacklist
+	logger                 logger.Logger
+	rules                  []*RuleDefinition
 }
 
 // NewInvoiceValidator 创建发票校验器
-func NewInvoiceValidator(engine *GRuleEngine, repo Repository, log logger.Logger) InvoiceValidator {
+func NewInvoiceValidator(engine *GRuleEngine, repo Repository, invoiceRepo ocr.Repository, log logger.Logger) *InvoiceValidatorImpl {
 	return &InvoiceValidatorImpl{
-		ruleEngine: engine,
-		repository: repo,
-		logger:     log,
-		rules:      make([]*RuleDefinition, 0),
+		ruleEngine:             engine,
+		repository:             repo,
+		invoiceRepo:            invoiceRepo,
+		holidayProvider:        DefaultHolidayProvider(),
+		limitStandards:         DefaultLimitStandards(),
+		amountTolerance:        DefaultAmountTolerance,
+		invoiceMaxAge:          DefaultInvoiceMaxAge,
+		ocrConfidenceThreshold: DefaultOCRConfidenceThreshold,
+		logger:                 log,
+		rules:                  make([]*RuleDefinition, 0),
+	}
+}
+
+// SetHolidayProvider 设置节假日数据源，为空时使用内置节假日表
+func (v *InvoiceValidatorImpl) SetHolidayProvider(provider HolidayProvider) {
+	if provider == nil {
+		provider = DefaultHolidayProvider()
+	}
+	v.holidayProvider = provider
+}
+
+// SetLimitStandards 设置限额标准，为空时使用默认限额标准
+func (v *InvoiceValidatorImpl) SetLimitStandards(standards []*LimitStandard) {
+	if len(standards) == 0 {
+		standards = DefaultLimitStandards()
 	}
+	v.limitStandards = standards
 }
 
 // ValidateSingle 校验单个发票
@@ -187,13 +236,18 @@ func (v *InvoiceValidatorImpl) LoadRules(ctx context.Context) error {
 		}
 
 		rule := &Rule{
-			ID:         ruleDef.ID,
-			RuleCode:   ruleDef.RuleCode,
-			Name:       ruleDef.Name,
-			Type:       ruleDef.Type,
-			Definition: ruleDef.Definition,
-			Priority:   ruleDef.Priority,
-			Enabled:    ruleDef.Enabled,
+			ID:          ruleDef.ID,
+			RuleCode:    ruleDef.RuleCode,
+			Name:        ruleDef.Name,
+			Type:        ruleDef.Type,
+			Definition:  ruleDef.Definition,
+			Explanation: ruleDef.Explanation,
+			Priority:    ruleDef.Priority,
+			Timeout:     ruleDef.Timeout,
+			Enabled:     ruleDef.Enabled,
+
+			EffectiveFrom: ruleDef.EffectiveFrom,
+			EffectiveTo:   ruleDef.EffectiveTo,
 		}
 
 		if err := v.ruleEngine.LoadRule(ctx, rule); err != nil {
@@ -260,8 +314,13 @@ func (v *InvoiceValidatorImpl) loadRulesFromDatabase(ctx context.Context) ([]*Ru
 			Category:    rule.Category,
 			Description: rule.Description,
 			Definition:  rule.Definition,
+			Explanation: rule.Explanation,
 			Priority:    rule.Priority,
+			Timeout:     rule.Timeout,
 			Enabled:     rule.Enabled,
+
+			EffectiveFrom: rule.EffectiveFrom,
+			EffectiveTo:   rule.EffectiveTo,
 		}
 		ruleDefinitions = append(ruleDefinitions, ruleDef)
 	}
@@ -295,30 +354,16 @@ func (v *InvoiceValidatorImpl) generateSummary(result *InvoiceValidationResult)
 		return
 	}
 
-	// 按严重程度统计违规
-	highCount := 0
-	mediumCount := 0
-	lowCount := 0
-
-	for _, violation := range result.Violations {
-		switch violation.Severity {
-		case "高":
-			highCount++
-		case "中":
-			mediumCount++
-		case "低":
-			lowCount++
-		}
-	}
+	countViolationsBySeverity(result)
 
 	result.Summary = "发票校验未通过"
-	if highCount > 0 {
-		result.Summary += "，存在" + string(rune(highCount+'0')) + "项高风险违规"
+	if result.HighCount > 0 {
+		result.Summary += "，存在" + strconv.Itoa(result.HighCount) + "项高风险违规"
 	}
-	if mediumCount > 0 {
-		result.Summary += "，存在" + string(rune(mediumCount+'0')) + "项中风险违规"
+	if result.MediumCount > 0 {
+		result.Summary += "，存在" + strconv.Itoa(result.MediumCount) + "项中风险违规"
 	}
-	if lowCount > 0 {
-		result.Summary += "，存在" + string(rune(lowCount+'0')) + "项低风险违规"
+	if result.LowCount > 0 {
+		result.Summary += "，存在" + strconv.Itoa(result.LowCount) + "项低风险违规"
 	}
 }
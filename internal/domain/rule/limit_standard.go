@@ -0,0 +1,151 @@
+// limit_standard.go 报销限额标准
+// 功能点：
+// 1. 定义按类别/城市级别/职级/生效日期配置的限额标准
+// 2. 按发票日期解析生效的限额标准，越具体的标准优先，同等具体时取最近生效的标准
+// 3. 记录每次校验实际采用的限额标准，供审核结果追溯
+
+package rule
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 限额类别
+const (
+	LimitCategoryAccommodation = "住宿"
+	LimitCategoryEntertainment = "招待"
+)
+
+// LimitStandard 限额标准，City或Tier为空表示适用于所有城市级别或职级
+type LimitStandard struct {
+	Category      string    `json:"category"`       // 限额类别(住宿/招待)
+	City          string    `json:"city"`           // 城市级别(一线城市/二线城市/三线城市)
+	Tier          string    `json:"tier"`           // 职级(高管/经理/员工)
+	Limit         float64   `json:"limit"`          // 限额(元)
+	EffectiveFrom time.Time `json:"effective_from"` // 生效日期，零值表示一直有效
+}
+
+// ParseLimitStandard 按配置项创建限额标准，effectiveFrom为YYYY-MM-DD格式，为空表示一直有效
+func ParseLimitStandard(category, city, tier string, limit float64, effectiveFrom string) (*LimitStandard, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("%s限额必须大于0: %v", category, limit)
+	}
+	date, err := parseEffectiveDate(effectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("%s限额生效日期%v", category, err)
+	}
+
+	standard := &LimitStandard{Category: category, City: city, Tier: tier, Limit: limit}
+	if date != nil {
+		standard.EffectiveFrom = *date
+	}
+	return standard, nil
+}
+
+// AppliedLimitStandard 校验时实际采用的限额标准
+type AppliedLimitStandard struct {
+	InvoiceID     string    `json:"invoice_id"`     // 发票ID
+	Category      string    `json:"category"`       // 限额类别
+	City          string    `json:"city"`           // 查询的城市级别
+	Tier          string    `json:"tier"`           // 查询的职级
+	Limit         float64   `json:"limit"`          // 采用的限额(元)
+	EffectiveFrom time.Time `json:"effective_from"` // 采用标准的生效日期
+	ReferenceDate time.Time `json:"reference_date"` // 解析标准所依据的日期(开票日期或申请日期)
+	Matched       bool      `json:"matched"`        // 是否匹配到配置的标准，未匹配时限额为0
+}
+
+// DefaultLimitStandards 默认限额标准
+func DefaultLimitStandards() []*LimitStandard {
+	return []*LimitStandard{
+		{Category: LimitCategoryAccommodation, City: "一线城市", Limit: 600},
+		{Category: LimitCategoryAccommodation, City: "二线城市", Limit: 400},
+		{Category: LimitCategoryAccommodation, City: "三线城市", Limit: 300},
+		{Category: LimitCategoryAccommodation, Limit: 200},
+		{Category: LimitCategoryEntertainment, Tier: "高管", Limit: 500},
+		{Category: LimitCategoryEntertainment, Tier: "经理", Limit: 300},
+		{Category: LimitCategoryEntertainment, Tier: "员工", Limit: 100},
+		{Category: LimitCategoryEntertainment, Limit: 100},
+	}
+}
+
+// ResolveLimitStandard 解析在date生效的限额标准，未匹配时返回nil
+// 城市级别和职级都精确匹配的标准优先于通配标准；同等具体时取生效日期最近的标准
+func ResolveLimitStandard(standards []*LimitStandard, category, city, tier string, date time.Time) *LimitStandard {
+	var resolved *LimitStandard
+	resolvedRank := -1
+	for _, standard := range standards {
+		if standard == nil || standard.Category != category {
+			continue
+		}
+		if standard.City != "" && standard.City != city {
+			continue
+		}
+		if standard.Tier != "" && standard.Tier != tier {
+			continue
+		}
+		if !date.IsZero() && standard.EffectiveFrom.After(date) {
+			continue
+		}
+
+		rank := 0
+		if standard.City != "" {
+			rank += 2
+		}
+		if standard.Tier != "" {
+			rank++
+		}
+		if rank > resolvedRank || (rank == resolvedRank && standard.EffectiveFrom.After(resolved.EffectiveFrom)) {
+			resolved, resolvedRank = standard, rank
+		}
+	}
+	return resolved
+}
+
+// standardRecorder 记录一次发票校验中采用的限额标准，相同查询只记录一次
+type standardRecorder struct {
+	mu        sync.Mutex
+	invoiceID string
+	date      time.Time
+	applied   []*AppliedLimitStandard
+}
+
+// newStandardRecorder 创建限额标准记录器
+func newStandardRecorder(invoiceID string, date time.Time) *standardRecorder {
+	return &standardRecorder{invoiceID: invoiceID, date: date}
+}
+
+// resolve 解析限额标准并记录
+func (r *standardRecorder) resolve(standards []*LimitStandard, category, city, tier string) float64 {
+	standard := ResolveLimitStandard(standards, category, city, tier, r.date)
+	applied := &AppliedLimitStandard{
+		InvoiceID:     r.invoiceID,
+		Category:      category,
+		City:          city,
+		Tier:          tier,
+		ReferenceDate: r.date,
+	}
+	if standard != nil {
+		applied.Limit = standard.Limit
+		applied.EffectiveFrom = standard.EffectiveFrom
+		applied.Matched = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.applied {
+		if existing.Category == category && existing.City == city && existing.Tier == tier {
+			return applied.Limit
+		}
+	}
+	r.applied = append(r.applied, applied)
+	return applied.Limit
+}
+
+// list 获取已记录的限额标准
+func (r *standardRecorder) list() []*AppliedLimitStandard {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*AppliedLimitStandard(nil), r.applied...)
+}
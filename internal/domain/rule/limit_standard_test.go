@@ -0,0 +1,120 @@
+package rule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveLimitStandard(t *testing.T) {
+	date := func(value string) time.Time {
+		d, err := time.ParseInLocation(effectiveDateLayout, value, time.Local)
+		if err != nil {
+			t.Fatalf("解析日期失败: %v", err)
+		}
+		return d
+	}
+	standards := []*LimitStandard{
+		nil,
+		{Category: LimitCategoryAccommodation, Limit: 200},
+		{Category: LimitCategoryAccommodation, City: "一线城市", Limit: 600},
+		{Category: LimitCategoryAccommodation, City: "一线城市", Limit: 700, EffectiveFrom: date("2024-07-01")},
+		{Category: LimitCategoryAccommodation, City: "一线城市", Tier: "高管", Limit: 1000},
+		{Category: LimitCategoryEntertainment, Tier: "经理", Limit: 300},
+	}
+
+	tests := []struct {
+		name      string
+		category  string
+		city      string
+		tier      string
+		date      time.Time
+		wantLimit float64
+	}{
+		{name: "城市和职级都匹配的标准优先", category: LimitCategoryAccommodation, city: "一线城市", tier: "高管", date: date("2024-03-01"), wantLimit: 1000},
+		{name: "生效前使用旧标准", category: LimitCategoryAccommodation, city: "一线城市", tier: "员工", date: date("2024-06-30"), wantLimit: 600},
+		{name: "生效当天使用新标准", category: LimitCategoryAccommodation, city: "一线城市", tier: "员工", date: date("2024-07-01"), wantLimit: 700},
+		{name: "日期为零值时不按生效日期过滤", category: LimitCategoryAccommodation, city: "一线城市", wantLimit: 700},
+		{name: "未配置城市时使用通配标准", category: LimitCategoryAccommodation, city: "三线城市", date: date("2024-03-01"), wantLimit: 200},
+		{name: "按职级匹配", category: LimitCategoryEntertainment, tier: "经理", date: date("2024-03-01"), wantLimit: 300},
+		{name: "未匹配返回nil", category: LimitCategoryEntertainment, tier: "员工", date: date("2024-03-01")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveLimitStandard(standards, tt.category, tt.city, tt.tier, tt.date)
+			if tt.wantLimit == 0 {
+				if got != nil {
+					t.Fatalf("ResolveLimitStandard() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Limit != tt.wantLimit {
+				t.Fatalf("ResolveLimitStandard() = %+v, want limit %v", got, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestParseLimitStandard(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         float64
+		effectiveFrom string
+		wantErr       bool
+		wantDate      bool
+	}{
+		{name: "一直有效", limit: 600},
+		{name: "指定生效日期", limit: 600, effectiveFrom: "2024-07-01", wantDate: true},
+		{name: "限额必须大于0", limit: 0, wantErr: true},
+		{name: "生效日期格式错误", limit: 600, effectiveFrom: "2024/07/01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimitStandard(LimitCategoryAccommodation, "一线城市", "", tt.limit, tt.effectiveFrom)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimitStandard() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Limit != tt.limit || got.City != "一线城市" || got.EffectiveFrom.IsZero() == tt.wantDate {
+				t.Errorf("ParseLimitStandard() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestStandardRecorder(t *testing.T) {
+	recorder := newStandardRecorder("i1", time.Time{})
+	standards := DefaultLimitStandards()
+
+	tests := []struct {
+		name      string
+		category  string
+		city      string
+		tier      string
+		wantLimit float64
+		wantCount int
+	}{
+		{name: "记录匹配的标准", category: LimitCategoryAccommodation, city: "一线城市", wantLimit: 600, wantCount: 1},
+		{name: "相同查询只记录一次", category: LimitCategoryAccommodation, city: "一线城市", wantLimit: 600, wantCount: 1},
+		{name: "记录未匹配的查询", category: "交通", wantLimit: 0, wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recorder.resolve(standards, tt.category, tt.city, tt.tier); got != tt.wantLimit {
+				t.Errorf("resolve() = %v, want %v", got, tt.wantLimit)
+			}
+			applied := recorder.list()
+			if len(applied) != tt.wantCount {
+				t.Fatalf("list() = %d条, want %d条", len(applied), tt.wantCount)
+			}
+			last := applied[len(applied)-1]
+			if last.InvoiceID != "i1" || last.Matched != (tt.wantLimit > 0) {
+				t.Errorf("list()[%d] = %+v", len(applied)-1, last)
+			}
+		})
+	}
+}
@@ -21,7 +21,9 @@ type Rule struct {
 	Category    string                 `json:"category"`                     // 规则分类
 	Status      string                 `json:"status"`                       // 规则状态(启用/禁用/草稿)
 	Definition  string                 `json:"definition"`                   // 规则定义(Grule语法)
+	Explanation string                 `json:"explanation"`                  // 违规说明模板(支持{{.Limit}}、{{.Actual}}等变量插值)
 	Priority    int                    `json:"priority"`                     // 优先级(数字越大优先级越高)
+	Timeout     int                    `json:"timeout"`                      // 执行超时时间(毫秒，0表示使用引擎默认值)
 	Enabled     bool                   `json:"enabled"`                      // 是否启用
 	CreatedBy   string                 `json:"created_by"`                   // 创建人
 	UpdatedBy   string                 `json:"updated_by"`                   // 更新人
@@ -30,6 +32,9 @@ type Rule struct {
 	Version     int                    `json:"version"`                      // 版本号
 	Tags        []string               `json:"tags"`                         // 标签
 	Metadata    map[string]interface{} `json:"metadata"`                     // 元数据
+
+	EffectiveFrom *time.Time `json:"effective_from"` // 生效开始日期(含当天)，为空表示不限制
+	EffectiveTo   *time.Time `json:"effective_to"`   // 生效结束日期(含当天)，为空表示不限制
 }
 
 // RuleValidationResult 规则校验结果模型
@@ -46,8 +51,21 @@ type RuleValidationResult struct {
 	Data          map[string]interface{} `json:"data"`           // 相关数据
 	Timestamp     time.Time              `json:"timestamp"`      // 校验时间
 	Violations    []interface{}          `json:"violations"`     // 违规信息列表
+	Overridden    bool                   `json:"overridden"`     // 是否因规则冲突被更高优先级规则覆盖
+	OverriddenBy  string                 `json:"overridden_by"`  // 覆盖本结论的规则ID
+	ConflictNote  string                 `json:"conflict_note"`  // 冲突解决说明
+
+	ConflictGroup   string `json:"conflict_group"`   // 冲突组，只有同组规则的结论才会互相覆盖，未声明时为规则编码
+	ExecutionError  bool   `json:"execution_error"`  // 规则未能正常加载或执行，不参与冲突解决
+	OverrideFailure bool   `json:"override_failure"` // 通过结论是否允许覆盖同组低优先级规则的未通过结论
 }
 
+// 规则元数据键
+const (
+	RuleMetadataConflictGroup   = "conflict_group"   // 冲突组(string)，检查同一事项的不同规则声明相同冲突组
+	RuleMetadataOverrideFailure = "override_failure" // 通过结论是否允许覆盖同组低优先级规则的未通过结论(bool)，如豁免规则
+)
+
 // RuleFilter 规则过滤器模型
 type RuleFilter struct {
 	RuleCode string   `json:"rule_code"` // 规则编码
@@ -0,0 +1,109 @@
+// ocr_confidence.go OCR识别置信度校验
+// 功能点：
+// 1. 检查金额、发票号码、税号等关键字段的OCR识别置信度，低于阈值视为识别质量差
+// 2. 置信度阈值可配置，默认0.8；OCR未返回置信度的字段不参与校验
+// 3. 识别质量差的发票生成“需人工复核”违规，不依据可能识别错误的字段直接通过或驳回
+
+package rule
+
+import (
+	"fmt"
+	"strings"
+
+	"reimbursement-audit/internal/domain/ocr"
+)
+
+// DefaultOCRConfidenceThreshold 关键字段默认的最低OCR识别置信度
+const DefaultOCRConfidenceThreshold = 0.8
+
+// OCR识别置信度违规信息
+const (
+	ocrConfidenceRuleID     = "ocr_low_confidence"
+	ocrConfidenceRuleName   = "OCR识别置信度不足"
+	ocrConfidenceSeverity   = "中"
+	ocrConfidencePriority   = 100
+	ocrConfidenceSuggestion = "请对照发票原件人工确认上述字段的识别结果，确认无误后重新审核"
+)
+
+// ocrKeyFields 需要校验置信度的关键字段及名称，键为InvoiceInfo字段的JSON名称
+var ocrKeyFields = []struct {
+	key   string
+	label string
+}{
+	{"invoice_number", "发票号码"},
+	{"total_amount", "金额"},
+	{"total_with_tax", "价税合计"},
+	{"buyer_tax_number", "购买方税号"},
+	{"seller_tax_number", "销售方税号"},
+}
+
+// LowConfidenceField 置信度低于阈值的字段
+type LowConfidenceField struct {
+	Field      string  `json:"field"`      // 字段JSON名称
+	Label      string  `json:"label"`      // 字段名称
+	Confidence float64 `json:"confidence"` // 识别置信度(0-1)
+}
+
+// OCRConfidenceCheck OCR识别置信度校验结果
+type OCRConfidenceCheck struct {
+	Threshold     float64               `json:"threshold"`      // 最低置信度阈值
+	LowConfidence bool                  `json:"low_confidence"` // 是否存在置信度低于阈值的关键字段
+	Fields        []*LowConfidenceField `json:"fields"`         // 置信度低于阈值的关键字段
+	Message       string                `json:"message"`        // 校验说明，未通过时为违规信息
+}
+
+// CheckOCRConfidence 校验关键字段的OCR识别置信度，threshold非正数时使用默认阈值
+func CheckOCRConfidence(confidences ocr.FieldConfidences, threshold float64) *OCRConfidenceCheck {
+	if threshold <= 0 {
+		threshold = DefaultOCRConfidenceThreshold
+	}
+	threshold = ocr.NormalizeConfidence(threshold)
+	result := &OCRConfidenceCheck{
+		Threshold: threshold,
+		Fields:    make([]*LowConfidenceField, 0),
+	}
+
+	labels := make([]string, 0, len(ocrKeyFields))
+	for _, field := range ocrKeyFields {
+		confidence, ok := confidences.Get(field.key)
+		if !ok || confidence >= threshold {
+			continue
+		}
+		result.Fields = append(result.Fields, &LowConfidenceField{
+			Field:      field.key,
+			Label:      field.label,
+			Confidence: confidence,
+		})
+		labels = append(labels, fmt.Sprintf("%s(%.0f%%)", field.label, confidence*100))
+	}
+
+	if len(result.Fields) == 0 {
+		result.Message = "关键字段OCR识别置信度均达到阈值"
+		return result
+	}
+	result.LowConfidence = true
+	result.Message = fmt.Sprintf("发票%s的OCR识别置信度低于%.0f%%，识别结果可能有误，需人工复核",
+		strings.Join(labels, "、"), threshold*100)
+	return result
+}
+
+// newOCRConfidenceViolation 根据置信度校验结果生成需人工复核的违规信息
+func newOCRConfidenceViolation(check *OCRConfidenceCheck) *InvoiceViolation {
+	return &InvoiceViolation{
+		RuleID:     ocrConfidenceRuleID,
+		RuleName:   ocrConfidenceRuleName,
+		RuleType:   RuleTypeInvoice,
+		Severity:   ocrConfidenceSeverity,
+		Message:    check.Message,
+		Suggestion: ocrConfidenceSuggestion,
+		Priority:   ocrConfidencePriority,
+	}
+}
+
+// SetOCRConfidenceThreshold 设置关键字段的最低OCR识别置信度(0-1，也可按百分制配置)，非正数时使用默认阈值
+func (v *InvoiceValidatorImpl) SetOCRConfidenceThreshold(threshold float64) {
+	if threshold <= 0 {
+		threshold = DefaultOCRConfidenceThreshold
+	}
+	v.ocrConfidenceThreshold = ocr.NormalizeConfidence(threshold)
+}
@@ -0,0 +1,119 @@
+// recurring_expense.go 周期性报销的重复/频次校验
+// 功能点：
+// 1. 按报销周期识别同一订阅的正常按期报销，频次统计时不计入
+// 2. 同一订阅在同一周期内出现多次报销时判定为异常（如一个月报销两次）
+// 3. 提供规则引擎可调用的频次统计和周期性报销判断辅助函数
+
+package rule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// recurrenceLookback 查询历史报销单的时间范围，覆盖最长的按年周期
+const recurrenceLookback = 366 * 24 * time.Hour
+
+// SetReimbursementRepository 设置报销单仓储，用于查询历史报销单做频次和周期性报销校验
+func (v *InvoiceValidatorImpl) SetReimbursementRepository(repo reimbursement.Repository) {
+	v.reimbursementRepo = repo
+}
+
+// reimbursementHistory 当前报销单申请人的历史已通过报销单，首次使用时加载
+type reimbursementHistory struct {
+	load   func() ([]*reimbursement.Reimbursement, error)
+	items  []*reimbursement.Reimbursement
+	loaded bool
+}
+
+// get 获取历史报销单，加载失败时返回空列表
+func (h *reimbursementHistory) get() []*reimbursement.Reimbursement {
+	if !h.loaded {
+		h.items, _ = h.load()
+		h.loaded = true
+	}
+	return h.items
+}
+
+// newReimbursementHistory 创建报销单历史，查询范围为参考日期前一年
+func (v *InvoiceValidatorImpl) newReimbursementHistory(ctx context.Context, current *reimbursement.Reimbursement) *reimbursementHistory {
+	return &reimbursementHistory{
+		load: func() ([]*reimbursement.Reimbursement, error) {
+			return v.listReimbursementHistory(ctx, current)
+		},
+	}
+}
+
+// listReimbursementHistory 查询申请人一年内已通过的其它报销单
+func (v *InvoiceValidatorImpl) listReimbursementHistory(ctx context.Context, current *reimbursement.Reimbursement) ([]*reimbursement.Reimbursement, error) {
+	if current == nil || current.UserID == "" {
+		return nil, nil
+	}
+	if v.reimbursementRepo == nil {
+		return nil, errors.New("报销单仓储未配置")
+	}
+
+	since := current.RecurrenceDate().Add(-recurrenceLookback)
+	items, err := v.reimbursementRepo.ListUserReimbursementsSince(ctx, current.UserID, since, approvedReimbursementStatuses)
+	if err != nil {
+		v.logger.WithContext(ctx).Error("查询历史报销单失败",
+			logger.NewField("报销单ID", current.ID),
+			logger.NewField("用户ID", current.UserID),
+			logger.NewField("error", err.Error()))
+		return nil, fmt.Errorf("查询历史报销单失败: %w", err)
+	}
+
+	history := make([]*reimbursement.Reimbursement, 0, len(items))
+	for _, item := range items {
+		if item != nil && item.ID != current.ID {
+			history = append(history, item)
+		}
+	}
+	return history, nil
+}
+
+// countRecentReimbursements 统计days天内申请人同类型的其它报销单数量
+// 同一订阅在其它周期的按期报销属于正常周期性报销，不计入
+func countRecentReimbursements(current *reimbursement.Reimbursement, history []*reimbursement.Reimbursement, days int) int {
+	if current == nil || days <= 0 {
+		return 0
+	}
+
+	referenceDate := current.RecurrenceDate()
+	since := referenceDate.AddDate(0, 0, -days)
+	count := 0
+	for _, item := range history {
+		date := item.RecurrenceDate()
+		if item.Type != current.Type || date.Before(since) || date.After(referenceDate) {
+			continue
+		}
+		if current.IsSameSubscription(item) && !current.InSameRecurrencePeriod(item) {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// isRecurrenceAnomaly 判断周期性报销在同一周期内是否已有同一订阅的报销
+func isRecurrenceAnomaly(current *reimbursement.Reimbursement, history []*reimbursement.Reimbursement) bool {
+	if current == nil || !current.IsRecurring {
+		return false
+	}
+	for _, item := range history {
+		if current.InSameRecurrencePeriod(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExpectedRecurrence 判断是否为正常的按期报销：标记为周期性报销且本周期内没有同一订阅的其它报销
+func isExpectedRecurrence(current *reimbursement.Reimbursement, history []*reimbursement.Reimbursement) bool {
+	return current != nil && current.IsRecurring && !isRecurrenceAnomaly(current, history)
+}
@@ -0,0 +1,115 @@
+package rule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/domain/reimbursement"
+)
+
+func TestRecurringExpenseHistory(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.Local)
+	}
+	subscription := func(id string, date time.Time) *reimbursement.Reimbursement {
+		return &reimbursement.Reimbursement{
+			ID: id, UserID: "u1", Type: "软件", ExpenseDate: date,
+			IsRecurring: true, RecurrencePeriod: reimbursement.RecurrencePeriodMonthly, RecurrenceKey: "云服务",
+		}
+	}
+	current := subscription("current", day(time.August, 20))
+	oneOff := &reimbursement.Reimbursement{ID: "one-off", UserID: "u1", Type: "软件", ExpenseDate: day(time.August, 20)}
+
+	tests := []struct {
+		name         string
+		current      *reimbursement.Reimbursement
+		history      []*reimbursement.Reimbursement
+		days         int
+		wantCount    int
+		wantAnomaly  bool
+		wantExpected bool
+	}{
+		{
+			name:         "上一周期的按期报销不计入频次",
+			current:      current,
+			history:      []*reimbursement.Reimbursement{subscription("r1", day(time.July, 20))},
+			days:         60,
+			wantCount:    0,
+			wantExpected: true,
+		},
+		{
+			name:        "同一周期重复报销计入频次并判定异常",
+			current:     current,
+			history:     []*reimbursement.Reimbursement{subscription("r1", day(time.August, 2))},
+			days:        60,
+			wantCount:   1,
+			wantAnomaly: true,
+		},
+		{
+			name:    "统计范围外和其它类型的报销不计入",
+			current: oneOff,
+			history: []*reimbursement.Reimbursement{
+				{ID: "r1", UserID: "u1", Type: "软件", ExpenseDate: day(time.March, 1)},
+				{ID: "r2", UserID: "u1", Type: "差旅", ExpenseDate: day(time.August, 10)},
+				{ID: "r3", UserID: "u1", Type: "软件", ExpenseDate: day(time.August, 25)},
+				{ID: "r4", UserID: "u1", Type: "软件", ExpenseDate: day(time.August, 10)},
+			},
+			days:      30,
+			wantCount: 1,
+		},
+		{
+			name:      "非周期性报销的同订阅历史照常计数",
+			current:   oneOff,
+			history:   []*reimbursement.Reimbursement{subscription("r1", day(time.August, 2))},
+			days:      30,
+			wantCount: 1,
+		},
+		{name: "统计天数为0", current: current, history: []*reimbursement.Reimbursement{subscription("r1", day(time.August, 2))}, wantAnomaly: true},
+		{name: "当前报销单为nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countRecentReimbursements(tt.current, tt.history, tt.days); got != tt.wantCount {
+				t.Errorf("countRecentReimbursements() = %d, want %d", got, tt.wantCount)
+			}
+			if got := isRecurrenceAnomaly(tt.current, tt.history); got != tt.wantAnomaly {
+				t.Errorf("isRecurrenceAnomaly() = %v, want %v", got, tt.wantAnomaly)
+			}
+			if got := isExpectedRecurrence(tt.current, tt.history); got != tt.wantExpected {
+				t.Errorf("isExpectedRecurrence() = %v, want %v", got, tt.wantExpected)
+			}
+		})
+	}
+}
+
+func TestReimbursementHistoryLoadsOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		items     []*reimbursement.Reimbursement
+		err       error
+		wantItems int
+	}{
+		{name: "加载成功", items: []*reimbursement.Reimbursement{{ID: "r1"}}, wantItems: 1},
+		{name: "加载失败返回空列表", err: errors.New("数据库不可用")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			history := &reimbursementHistory{load: func() ([]*reimbursement.Reimbursement, error) {
+				calls++
+				return tt.items, tt.err
+			}}
+			for i := 0; i < 2; i++ {
+				if got := history.get(); len(got) != tt.wantItems {
+					t.Errorf("get() = %d条, want %d条", len(got), tt.wantItems)
+				}
+			}
+			if calls != 1 {
+				t.Errorf("load调用%d次, want 1次", calls)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@
 // 1. 定义规则仓储接口
 // 2. 提供规则CRUD操作抽象
 // 3. 提供规则查询和筛选功能
+// 4. 定义节假日仓储接口
+// 5. 定义销售方黑名单仓储接口
 
 package rule
 
@@ -40,3 +42,19 @@ type Repository interface {
 	// CheckRuleCodeExists 检查规则编码是否存在
 	CheckRuleCodeExists(ctx context.Context, ruleCode string, excludeID string) (bool, error)
 }
+
+// HolidayRepository 节假日仓储接口
+type HolidayRepository interface {
+	// ListHolidaysByYear 获取指定年份的节假日和调休上班日
+	ListHolidaysByYear(ctx context.Context, year int) ([]*Holiday, error)
+}
+
+// SellerBlacklistRepository 销售方黑名单仓储接口
+type SellerBlacklistRepository interface {
+	// ListSellerBlacklist 获取全部黑名单条目，按创建时间倒序
+	ListSellerBlacklist(ctx context.Context) ([]*SellerBlacklistEntry, error)
+	// CreateSellerBlacklistEntry 新增黑名单条目
+	CreateSellerBlacklistEntry(ctx context.Context, entry *SellerBlacklistEntry) error
+	// DeleteSellerBlacklistEntry 删除黑名单条目，条目不存在时返回记录不存在错误
+	DeleteSellerBlacklistEntry(ctx context.Context, id string) error
+}
@@ -0,0 +1,34 @@
+package rule
+
+import (
+	"context"
+	"sync"
+)
+
+// memRuleRepo 内存规则仓储，只实现测试用到的方法
+type memRuleRepo struct {
+	Repository
+	mu    sync.Mutex
+	rules []*Rule
+}
+
+func newMemRuleRepo(rules ...*Rule) *memRuleRepo {
+	return &memRuleRepo{rules: rules}
+}
+
+func (r *memRuleRepo) ListRules(_ context.Context, filter *RuleFilter) ([]*Rule, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rules := make([]*Rule, 0, len(r.rules))
+	for _, item := range r.rules {
+		if filter != nil && filter.Category != "" && item.Category != filter.Category {
+			continue
+		}
+		if filter != nil && filter.Enabled != nil && item.Enabled != *filter.Enabled {
+			continue
+		}
+		c := *item
+		rules = append(rules, &c)
+	}
+	return rules, int64(len(rules)), nil
+}
@@ -0,0 +1,249 @@
+// rule_version.go 规则版本管理
+// 功能点：
+// 1. 以规则版本号作为Grule知识库版本编译规则，每个版本使用独立的知识库，编译新版本不影响正在执行的版本
+// 2. 支持按版本加载、切换和卸载规则，每条规则保留最近的若干个版本用于回滚
+// 3. 支持执行指定的非生效版本(灰度验证)，灰度执行统计与生效版本分开记录
+
+package rule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/hyperjumptech/grule-rule-engine/ast"
+	"github.com/hyperjumptech/grule-rule-engine/builder"
+	"github.com/hyperjumptech/grule-rule-engine/pkg"
+)
+
+// maxRetainedRuleVersions 每条规则最多保留的已加载版本数(含生效版本)
+const maxRetainedRuleVersions = 3
+
+// ErrRuleVersionNotLoaded 规则版本未加载错误
+var ErrRuleVersionNotLoaded = errors.New("规则版本未加载")
+
+// ErrActiveRuleVersion 不能卸载生效版本错误
+var ErrActiveRuleVersion = errors.New("不能卸载规则当前生效的版本")
+
+// ErrRuleReloadFailed 规则库重新加载时部分规则编译失败错误
+var ErrRuleReloadFailed = errors.New("部分规则重新加载失败，已保留旧版本")
+
+// compiledRuleVersion 已编译的规则版本
+type compiledRuleVersion struct {
+	version          string
+	knowledgeLibrary *ast.KnowledgeLibrary // 版本独立的知识库
+	knowledgeBase    *ast.KnowledgeBase
+	timeout          time.Duration // 规则级执行超时，0表示使用引擎级默认值
+	loadedAt         time.Time
+}
+
+// engineRuleVersion 获取规则在引擎中的版本号，版本号非正数时视为1
+func engineRuleVersion(rule *Rule) string {
+	if rule.Version <= 0 {
+		return "1"
+	}
+	return strconv.Itoa(rule.Version)
+}
+
+// versionStatsKey 非生效版本执行统计的键
+func versionStatsKey(ruleID, version string) string {
+	return ruleID + "@v" + version
+}
+
+// compileRuleVersion 校验并编译规则到独立的知识库，不修改引擎状态
+func (e *GRuleEngine) compileRuleVersion(rule *Rule) (*compiledRuleVersion, error) {
+	if err := e.ValidateRule(rule.Definition); err != nil {
+		return nil, fmt.Errorf("规则语法验证失败: %w", err)
+	}
+
+	version := engineRuleVersion(rule)
+	knowledgeLibrary := ast.NewKnowledgeLibrary()
+	ruleBuilder := builder.NewRuleBuilder(knowledgeLibrary)
+	if err := ruleBuilder.BuildRuleFromResource(rule.RuleCode, version, pkg.NewBytesResource([]byte(rule.Definition))); err != nil {
+		return nil, fmt.Errorf("编译规则失败: %w", err)
+	}
+
+	knowledgeBase := knowledgeLibrary.GetKnowledgeBase(rule.RuleCode, version)
+	if knowledgeBase == nil {
+		return nil, fmt.Errorf("获取知识库实例失败")
+	}
+
+	compiled := &compiledRuleVersion{
+		version:          version,
+		knowledgeLibrary: knowledgeLibrary,
+		knowledgeBase:    knowledgeBase,
+		loadedAt:         time.Now(),
+	}
+	if rule.Timeout > 0 {
+		compiled.timeout = time.Duration(rule.Timeout) * time.Millisecond
+	}
+	return compiled, nil
+}
+
+// addRuleVersion 登记规则版本，同一版本重复加载时替换，超出保留数时淘汰最早加载的非生效版本
+// 调用方需持有写锁
+func (e *GRuleEngine) addRuleVersion(ruleID string, compiled *compiledRuleVersion) {
+	versions, ok := e.ruleVersions[ruleID]
+	if !ok {
+		versions = make(map[string]*compiledRuleVersion)
+		e.ruleVersions[ruleID] = versions
+	}
+	versions[compiled.version] = compiled
+
+	for len(versions) > maxRetainedRuleVersions {
+		var oldest *compiledRuleVersion
+		for version, loaded := range versions {
+			if version == compiled.version || version == e.activeVersions[ruleID] {
+				continue
+			}
+			if oldest == nil || loaded.loadedAt.Before(oldest.loadedAt) {
+				oldest = loaded
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(versions, oldest.version)
+		delete(e.stats, versionStatsKey(ruleID, oldest.version))
+	}
+}
+
+// activateRuleVersion 将已登记的版本切换为生效版本
+// 调用方需持有写锁
+func (e *GRuleEngine) activateRuleVersion(ruleID string, compiled *compiledRuleVersion) {
+	e.activeVersions[ruleID] = compiled.version
+	e.ruleLibrary[ruleID] = compiled.knowledgeBase
+	if compiled.timeout > 0 {
+		e.ruleTimeouts[ruleID] = compiled.timeout
+	} else {
+		delete(e.ruleTimeouts, ruleID)
+	}
+	delete(e.stats, versionStatsKey(ruleID, compiled.version))
+}
+
+// removeRule 移除规则的全部版本及统计信息
+// 调用方需持有写锁
+func (e *GRuleEngine) removeRule(ruleID string) {
+	for version := range e.ruleVersions[ruleID] {
+		delete(e.stats, versionStatsKey(ruleID, version))
+	}
+	delete(e.ruleVersions, ruleID)
+	delete(e.activeVersions, ruleID)
+	delete(e.ruleLibrary, ruleID)
+	delete(e.ruleTimeouts, ruleID)
+	delete(e.stats, ruleID)
+}
+
+// LoadRuleVersion 加载规则版本但不切换生效版本，用于灰度验证
+// 规则尚无生效版本时，需调用ActivateRuleVersion后才会参与正常执行
+func (e *GRuleEngine) LoadRuleVersion(ctx context.Context, rule *Rule) error {
+	if rule == nil {
+		return errors.New("规则不能为空")
+	}
+
+	compiled, err := e.compileRuleVersion(rule)
+	if err != nil {
+		e.logger.WithContext(ctx).Error("编译规则版本失败",
+			logger.NewField("规则ID", rule.ID),
+			logger.NewField("版本", engineRuleVersion(rule)),
+			logger.NewField("error", err.Error()))
+		return err
+	}
+
+	e.mu.Lock()
+	e.addRuleVersion(rule.ID, compiled)
+	e.mu.Unlock()
+
+	e.logger.WithContext(ctx).Info("规则版本加载成功",
+		logger.NewField("规则ID", rule.ID),
+		logger.NewField("版本", compiled.version))
+
+	return nil
+}
+
+// ActivateRuleVersion 将已加载的规则版本切换为生效版本，可用于灰度转正或回滚到旧版本
+func (e *GRuleEngine) ActivateRuleVersion(ctx context.Context, ruleID, version string) error {
+	e.mu.Lock()
+	compiled, ok := e.ruleVersions[ruleID][version]
+	if !ok {
+		e.mu.Unlock()
+		return fmt.Errorf("%w: %s(版本%s)", ErrRuleVersionNotLoaded, ruleID, version)
+	}
+	previous := e.activeVersions[ruleID]
+	e.activateRuleVersion(ruleID, compiled)
+	if previous != version {
+		e.stats[ruleID] = &EngineRuleStats{RuleID: ruleID}
+	}
+	e.mu.Unlock()
+
+	e.logger.WithContext(ctx).Info("规则版本切换成功",
+		logger.NewField("规则ID", ruleID),
+		logger.NewField("原版本", previous),
+		logger.NewField("新版本", version))
+
+	return nil
+}
+
+// UnloadRuleVersion 卸载规则的非生效版本，卸载整条规则请使用UnloadRule
+func (e *GRuleEngine) UnloadRuleVersion(ctx context.Context, ruleID, version string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.ruleVersions[ruleID][version]; !ok {
+		return fmt.Errorf("%w: %s(版本%s)", ErrRuleVersionNotLoaded, ruleID, version)
+	}
+	if e.activeVersions[ruleID] == version {
+		return fmt.Errorf("%w: %s(版本%s)", ErrActiveRuleVersion, ruleID, version)
+	}
+
+	delete(e.ruleVersions[ruleID], version)
+	delete(e.stats, versionStatsKey(ruleID, version))
+	if len(e.ruleVersions[ruleID]) == 0 {
+		delete(e.ruleVersions, ruleID)
+	}
+
+	e.logger.WithContext(ctx).Info("规则版本卸载成功",
+		logger.NewField("规则ID", ruleID),
+		logger.NewField("版本", version))
+
+	return nil
+}
+
+// GetActiveRuleVersion 获取规则当前生效的版本号，规则未加载时返回false
+func (e *GRuleEngine) GetActiveRuleVersion(ruleID string) (string, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	version, ok := e.activeVersions[ruleID]
+	return version, ok
+}
+
+// GetLoadedRuleVersions 获取规则已加载的版本号，按版本号升序排列
+func (e *GRuleEngine) GetLoadedRuleVersions(ruleID string) []string {
+	e.mu.RLock()
+	versions := make([]string, 0, len(e.ruleVersions[ruleID]))
+	for version := range e.ruleVersions[ruleID] {
+		versions = append(versions, version)
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(versions, func(i, j int) bool {
+		vi, errI := strconv.Atoi(versions[i])
+		vj, errJ := strconv.Atoi(versions[j])
+		if errI != nil || errJ != nil {
+			return versions[i] < versions[j]
+		}
+		return vi < vj
+	})
+	return versions
+}
+
+// ExecuteRuleVersion 执行规则的指定版本，version为空时执行当前生效版本
+func (e *GRuleEngine) ExecuteRuleVersion(ctx context.Context, ruleID, version string, data interface{}) (*RuleValidationResult, error) {
+	return e.executeRuleVersion(ctx, ruleID, version, map[string]interface{}{"data": data})
+}
@@ -0,0 +1,16 @@
+// self_dealing.go 自开自报发票识别
+// 功能点：
+// 1. 比较规范化后的购买方税号和销售方税号，两者相同视为同一主体自己给自己开票
+// 2. 税号中的空格、分隔符和大小写差异不影响比较，任一税号缺失时不判定
+
+package rule
+
+// IsSelfDealing 判断发票的购买方和销售方是否为同一主体（税号规范化后相同）
+func IsSelfDealing(buyerTaxNo, sellerTaxNo string) bool {
+	buyer := NormalizeTaxNumber(buyerTaxNo)
+	seller := NormalizeTaxNumber(sellerTaxNo)
+	if buyer == "" || seller == "" {
+		return false
+	}
+	return buyer == seller
+}
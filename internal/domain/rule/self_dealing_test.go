@@ -0,0 +1,26 @@
+package rule
+
+import "testing"
+
+func TestIsSelfDealing(t *testing.T) {
+	tests := []struct {
+		name        string
+		buyerTaxNo  string
+		sellerTaxNo string
+		want        bool
+	}{
+		{name: "税号相同", buyerTaxNo: "91350100M000100Y43", sellerTaxNo: "91350100M000100Y43", want: true},
+		{name: "忽略空格分隔符和大小写", buyerTaxNo: "9135 0100-m000_100y43", sellerTaxNo: "91350100M000100Y43", want: true},
+		{name: "税号不同", buyerTaxNo: "91350100M000100Y43", sellerTaxNo: "91110000600037341L", want: false},
+		{name: "购买方税号缺失", buyerTaxNo: " ", sellerTaxNo: "91350100M000100Y43", want: false},
+		{name: "双方税号都缺失", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSelfDealing(tt.buyerTaxNo, tt.sellerTaxNo); got != tt.want {
+				t.Errorf("IsSelfDealing(%q, %q) = %v, want %v", tt.buyerTaxNo, tt.sellerTaxNo, got, tt.want)
+			}
+		})
+	}
+}
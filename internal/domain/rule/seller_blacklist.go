@@ -0,0 +1,241 @@
+// seller_blacklist.go 销售方黑名单
+// 功能点：
+// 1. 维护已知不合规销售方的黑名单，按销售方税号或名称登记
+// 2. 税号按规范化后比较，名称忽略空格、全半角括号和大小写差异
+// 3. 黑名单从数据库加载后缓存，新增或移除条目后清除缓存
+// 4. 提供IsBlacklistedSeller规则辅助函数，命中黑名单的发票按高风险违规处理
+// 5. 新增和移除黑名单条目需要auditor_admin角色，由API层路由校验
+
+package rule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"reimbursement-audit/internal/api/request"
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidBlacklistEntry 黑名单条目不合法
+	ErrInvalidBlacklistEntry = errors.New("黑名单条目不合法")
+	// ErrSellerBlacklistDisabled 未配置销售方黑名单
+	ErrSellerBlacklistDisabled = errors.New("未配置销售方黑名单")
+)
+
+// SellerBlacklistEntry 销售方黑名单条目，税号和名称至少填写一项
+type SellerBlacklistEntry struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`                         // 条目ID
+	SellerName  string    `json:"seller_name" gorm:"type:varchar(100);index:idx_seller_name"`    // 销售方名称
+	SellerTaxNo string    `json:"seller_tax_no" gorm:"type:varchar(50);index:idx_seller_tax_no"` // 销售方税号(规范化后存储)
+	Reason      string    `json:"reason" gorm:"type:varchar(500)"`                               // 列入黑名单的原因
+	CreatedBy   string    `json:"created_by" gorm:"type:varchar(50)"`                            // 创建人
+	CreatedAt   time.Time `json:"created_at"`                                                    // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`                                                    // 更新时间
+}
+
+// TableName 指定销售方黑名单表名
+func (SellerBlacklistEntry) TableName() string {
+	return "seller_blacklist"
+}
+
+// sellerNameReplacer 规范化销售方名称时统一全角括号并去除空白
+var sellerNameReplacer = strings.NewReplacer("（", "(", "）", ")", " ", "", "　", "", "\t", "")
+
+// NormalizeSellerName 规范化销售方名称，去除空白、统一全半角括号并转为大写
+func NormalizeSellerName(name string) string {
+	return strings.ToUpper(sellerNameReplacer.Replace(strings.TrimSpace(name)))
+}
+
+// SellerBlacklist 销售方黑名单，repo为空时只使用创建时传入的条目
+type SellerBlacklist struct {
+	repo   SellerBlacklistRepository
+	mu     sync.RWMutex
+	loaded bool
+	taxNos map[string]*SellerBlacklistEntry
+	names  map[string]*SellerBlacklistEntry
+}
+
+// NewSellerBlacklist 创建基于数据库的销售方黑名单，首次匹配时加载
+func NewSellerBlacklist(repo SellerBlacklistRepository) *SellerBlacklist {
+	return &SellerBlacklist{repo: repo}
+}
+
+// NewStaticSellerBlacklist 根据给定条目创建销售方黑名单
+func NewStaticSellerBlacklist(entries ...*SellerBlacklistEntry) *SellerBlacklist {
+	blacklist := &SellerBlacklist{}
+	blacklist.index(entries)
+	return blacklist
+}
+
+// index 按规范化后的税号和名称建立索引
+func (b *SellerBlacklist) index(entries []*SellerBlacklistEntry) {
+	b.taxNos = make(map[string]*SellerBlacklistEntry, len(entries))
+	b.names = make(map[string]*SellerBlacklistEntry, len(entries))
+	for _, entry := range entries {
+		if taxNo := NormalizeTaxNumber(entry.SellerTaxNo); taxNo != "" {
+			b.taxNos[taxNo] = entry
+		}
+		if name := NormalizeSellerName(entry.SellerName); name != "" {
+			b.names[name] = entry
+		}
+	}
+	b.loaded = true
+}
+
+// Match 查找命中的黑名单条目，税号优先于名称，未命中时返回nil
+func (b *SellerBlacklist) Match(ctx context.Context, sellerName, sellerTaxNo string) (*SellerBlacklistEntry, error) {
+	if err := b.load(ctx); err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if taxNo := NormalizeTaxNumber(sellerTaxNo); taxNo != "" {
+		if entry, ok := b.taxNos[taxNo]; ok {
+			return entry, nil
+		}
+	}
+	if name := NormalizeSellerName(sellerName); name != "" {
+		if entry, ok := b.names[name]; ok {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// IsBlacklistedSeller 判断销售方是否在黑名单中
+func (b *SellerBlacklist) IsBlacklistedSeller(ctx context.Context, sellerName, sellerTaxNo string) (bool, error) {
+	entry, err := b.Match(ctx, sellerName, sellerTaxNo)
+	return entry != nil, err
+}
+
+// Invalidate 清除已缓存的黑名单，黑名单表更新后调用
+func (b *SellerBlacklist) Invalidate() {
+	if b.repo == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loaded = false
+}
+
+// load 从数据库加载黑名单，已加载时直接返回
+func (b *SellerBlacklist) load(ctx context.Context) error {
+	b.mu.RLock()
+	loaded := b.loaded
+	b.mu.RUnlock()
+	if loaded || b.repo == nil {
+		return nil
+	}
+
+	entries, err := b.repo.ListSellerBlacklist(ctx)
+	if err != nil {
+		return fmt.Errorf("加载销售方黑名单失败: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.index(entries)
+	return nil
+}
+
+// SetSellerBlacklist 设置销售方黑名单，为空时不校验
+func (v *InvoiceValidatorImpl) SetSellerBlacklist(blacklist *SellerBlacklist) {
+	v.sellerBlacklist = blacklist
+}
+
+// isBlacklistedSeller 判断发票销售方是否在黑名单中，未配置黑名单时视为未命中
+func (v *InvoiceValidatorImpl) isBlacklistedSeller(ctx context.Context, sellerName, sellerTaxNo string) (bool, error) {
+	if v.sellerBlacklist == nil {
+		return false, nil
+	}
+	blacklisted, err := v.sellerBlacklist.IsBlacklistedSeller(ctx, sellerName, sellerTaxNo)
+	if err != nil {
+		v.logger.WithContext(ctx).Error("查询销售方黑名单失败", logger.NewField("error", err))
+	}
+	return blacklisted, err
+}
+
+// SetSellerBlacklist 设置规则服务管理的销售方黑名单
+func (s *RuleService) SetSellerBlacklist(repo SellerBlacklistRepository, blacklist *SellerBlacklist) {
+	s.blacklistRepo = repo
+	s.sellerBlacklist = blacklist
+}
+
+// ListSellerBlacklist 获取销售方黑名单
+func (s *RuleService) ListSellerBlacklist(ctx context.Context) ([]*SellerBlacklistEntry, error) {
+	if s.blacklistRepo == nil {
+		return nil, ErrSellerBlacklistDisabled
+	}
+	entries, err := s.blacklistRepo.ListSellerBlacklist(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取销售方黑名单失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("获取销售方黑名单失败: %w", err)
+	}
+	return entries, nil
+}
+
+// AddSellerBlacklistEntry 新增销售方黑名单条目，auditor_admin角色由API层路由校验
+func (s *RuleService) AddSellerBlacklistEntry(ctx context.Context, req *request.AddSellerBlacklistRequest) (*SellerBlacklistEntry, error) {
+	if s.blacklistRepo == nil {
+		return nil, ErrSellerBlacklistDisabled
+	}
+
+	entry := &SellerBlacklistEntry{
+		ID:          uuid.New().String(),
+		SellerName:  strings.TrimSpace(req.SellerName),
+		SellerTaxNo: NormalizeTaxNumber(strings.TrimSpace(req.SellerTaxNo)),
+		Reason:      strings.TrimSpace(req.Reason),
+		CreatedBy:   strings.TrimSpace(req.CreatedBy),
+	}
+	if entry.SellerName == "" && entry.SellerTaxNo == "" {
+		return nil, fmt.Errorf("%w: 销售方名称和税号至少填写一项", ErrInvalidBlacklistEntry)
+	}
+	if entry.Reason == "" {
+		return nil, fmt.Errorf("%w: 列入黑名单的原因不能为空", ErrInvalidBlacklistEntry)
+	}
+
+	if err := s.blacklistRepo.CreateSellerBlacklistEntry(ctx, entry); err != nil {
+		s.logger.WithContext(ctx).Error("新增销售方黑名单失败", logger.NewField("error", err))
+		return nil, fmt.Errorf("新增销售方黑名单失败: %w", err)
+	}
+	s.invalidateSellerBlacklist()
+
+	s.logger.WithContext(ctx).Info("销售方已列入黑名单",
+		logger.NewField("entry_id", entry.ID),
+		logger.NewField("seller_name", entry.SellerName),
+		logger.NewField("seller_tax_no", entry.SellerTaxNo))
+	return entry, nil
+}
+
+// RemoveSellerBlacklistEntry 移除销售方黑名单条目，auditor_admin角色由API层路由校验
+func (s *RuleService) RemoveSellerBlacklistEntry(ctx context.Context, id string) error {
+	if s.blacklistRepo == nil {
+		return ErrSellerBlacklistDisabled
+	}
+
+	if err := s.blacklistRepo.DeleteSellerBlacklistEntry(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("移除销售方黑名单失败",
+			logger.NewField("entry_id", id),
+			logger.NewField("error", err))
+		return fmt.Errorf("移除销售方黑名单失败: %w", err)
+	}
+	s.invalidateSellerBlacklist()
+
+	s.logger.WithContext(ctx).Info("销售方已移出黑名单", logger.NewField("entry_id", id))
+	return nil
+}
+
+// invalidateSellerBlacklist 黑名单变更后清除校验使用的缓存
+func (s *RuleService) invalidateSellerBlacklist() {
+	if s.sellerBlacklist != nil {
+		s.sellerBlacklist.Invalidate()
+	}
+}
@@ -6,6 +6,7 @@
 // 4. 规则校验结果整合
 // 5. 规则动态加载和更新
 // 6. 规则测试和验证
+// 7. 按报销申请日期过滤未生效的规则
 
 package rule
 
@@ -13,6 +14,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"reimbursement-audit/internal/api/request"
@@ -26,6 +29,9 @@ type RuleService struct {
 	repo   Repository
 	logger logger.Logger
 	engine *GRuleEngine
+
+	blacklistRepo   SellerBlacklistRepository // 销售方黑名单仓储
+	sellerBlacklist *SellerBlacklist          // 校验使用的销售方黑名单，变更后清除缓存
 }
 
 // NewRuleService 创建规则服务实例
@@ -58,10 +64,14 @@ func (s *RuleService) CreateRule(ctx context.Context, req *request.CreateRuleReq
 		return nil, errors.New("规则类型不能为空")
 	}
 
+	effectiveFrom, effectiveTo, err := parseEffectiveWindow(req.EffectiveFrom, req.EffectiveTo)
+	if err != nil {
+		return nil, err
+	}
+
 	// 生成规则编码，最多重试3次
 	var ruleCode string
 	var exists bool
-	var err error
 
 	for i := 0; i < 3; i++ {
 		ruleCode = s.generateRuleCode()
@@ -93,12 +103,17 @@ func (s *RuleService) CreateRule(ctx context.Context, req *request.CreateRuleReq
 		Category:    req.Category,
 		Status:      RuleStatusDraft, // 默认状态为草稿
 		Definition:  req.Definition,
+		Explanation: req.Explanation,
 		Priority:    req.Priority,
+		Timeout:     req.Timeout,
 		Enabled:     false, // 默认禁用
 		CreatedBy:   req.CreatedBy,
 		UpdatedAt:   now,
 		CreatedAt:   now,
 		Version:     1,
+
+		EffectiveFrom: effectiveFrom,
+		EffectiveTo:   effectiveTo,
 	}
 
 	// 保存规则
@@ -124,6 +139,11 @@ func (s *RuleService) UpdateRule(ctx context.Context, req *request.UpdateRuleReq
 		return nil, errors.New("规则ID不能为空")
 	}
 
+	effectiveFrom, effectiveTo, err := parseEffectiveWindow(req.EffectiveFrom, req.EffectiveTo)
+	if err != nil {
+		return nil, err
+	}
+
 	// 获取现有规则
 	existingRule, err := s.repo.GetRuleByID(ctx, req.ID)
 	if err != nil {
@@ -183,7 +203,11 @@ func (s *RuleService) UpdateRule(ctx context.Context, req *request.UpdateRuleReq
 	existingRule.Category = req.Category
 	existingRule.Status = req.Status
 	existingRule.Definition = req.Definition
+	existingRule.Explanation = req.Explanation
 	existingRule.Priority = req.Priority
+	existingRule.Timeout = req.Timeout
+	existingRule.EffectiveFrom = effectiveFrom
+	existingRule.EffectiveTo = effectiveTo
 	existingRule.UpdatedBy = req.UpdatedBy
 	existingRule.Version = existingRule.Version + 1
 
@@ -376,38 +400,228 @@ func (s *RuleService) DisableRule(ctx context.Context, id string) error {
 
 // ValidateRules 执行规则校验
 func (s *RuleService) ValidateRules(ctx context.Context, data interface{}, ruleIDs []string) ([]*RuleValidationResult, error) {
-	// TODO: 实现规则校验逻辑
-	return nil, nil
+	if len(ruleIDs) == 0 {
+		return nil, errors.New("规则ID列表不能为空")
+	}
+
+	rules := make([]*Rule, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		rule, err := s.repo.GetRuleByID(ctx, ruleID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("获取规则失败",
+				logger.NewField("error", err.Error()),
+				logger.NewField("rule_id", ruleID))
+			return nil, fmt.Errorf("获取规则失败: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return s.executeRules(ctx, rules, data)
 }
 
-// ValidateAllRules 执行所有规则校验
+// ValidateAllRules 执行所有当前生效的规则校验
 func (s *RuleService) ValidateAllRules(ctx context.Context, data interface{}) ([]*RuleValidationResult, error) {
-	// TODO: 实现所有规则校验逻辑
-	return nil, nil
+	return s.ValidateAllRulesAt(ctx, data, time.Now())
+}
+
+// ValidateAllRulesAt 执行在指定日期（通常为报销申请日期）生效的全部规则校验
+func (s *RuleService) ValidateAllRulesAt(ctx context.Context, data interface{}, date time.Time) ([]*RuleValidationResult, error) {
+	rules, err := s.listEnabledRules(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeRules(ctx, filterEffectiveRules(rules, date), data)
 }
 
 // ValidateRuleByType 按类型执行规则校验
 func (s *RuleService) ValidateRuleByType(ctx context.Context, data interface{}, ruleType string) ([]*RuleValidationResult, error) {
-	// TODO: 实现按类型规则校验逻辑
-	return nil, nil
+	if ruleType == "" {
+		s.logger.WithContext(ctx).Error("规则类型不能为空")
+		return nil, errors.New("规则类型不能为空")
+	}
+
+	rules, err := s.listEnabledRules(ctx, ruleType)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executeRules(ctx, filterEffectiveRules(rules, time.Now()), data)
+}
+
+// listEnabledRules 查询启用的规则，ruleType为空时返回全部类型
+func (s *RuleService) listEnabledRules(ctx context.Context, ruleType string) ([]*Rule, error) {
+	filter := &RuleFilter{
+		Type:    ruleType,
+		Enabled: &[]bool{true}[0], // 获取启用的规则
+		Size:    1000,             // 设置较大的页面大小以获取所有规则
+	}
+
+	rules, _, err := s.repo.ListRules(ctx, filter)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("获取启用规则失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("rule_type", ruleType))
+		return nil, fmt.Errorf("获取启用规则失败: %w", err)
+	}
+
+	return rules, nil
+}
+
+// executeRules 通过规则引擎按优先级逐条执行规则并聚合校验结果
+// 单条规则加载或执行失败记为未通过，不中断其余规则；调用方取消时停止执行
+// 聚合后的结果会进行冲突解决
+func (s *RuleService) executeRules(ctx context.Context, rules []*Rule, data interface{}) ([]*RuleValidationResult, error) {
+	if s.engine == nil {
+		s.logger.WithContext(ctx).Error("规则引擎未初始化")
+		return nil, errors.New("规则引擎未初始化")
+	}
+
+	results := make([]*RuleValidationResult, 0, len(rules))
+	for _, rule := range s.SortRulesByPriority(rules) {
+		if ctx.Err() != nil {
+			return results, fmt.Errorf("规则校验已取消: %w", ctx.Err())
+		}
+
+		startTime := time.Now()
+
+		// 规则尚未加载到引擎或已更新版本时按需加载，新版本加载失败时继续执行旧版本
+		activeVersion, loaded := s.engine.GetActiveRuleVersion(rule.ID)
+		if !loaded || activeVersion != engineRuleVersion(rule) {
+			if err := s.engine.LoadRule(ctx, rule); err != nil {
+				if !loaded {
+					results = append(results, s.buildFailedResult(rule, startTime, fmt.Sprintf("规则加载失败: %s", err.Error())))
+					continue
+				}
+				s.logger.WithContext(ctx).Warn("加载规则新版本失败，继续执行旧版本",
+					logger.NewField("rule_id", rule.ID),
+					logger.NewField("active_version", activeVersion),
+					logger.NewField("error", err.Error()))
+			}
+		}
+
+		result, err := s.engine.ExecuteRule(ctx, rule.ID, data)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("执行规则失败",
+				logger.NewField("rule_id", rule.ID),
+				logger.NewField("error", err.Error()))
+			results = append(results, s.buildFailedResult(rule, startTime, fmt.Sprintf("规则执行失败: %s", err.Error())))
+			continue
+		}
+
+		result.RuleID = rule.ID
+		result.RuleName = rule.Name
+		result.RuleType = rule.Type
+		result.Priority = rule.Priority
+		result.Severity = ruleSeverity(result.Severity, rule.Type)
+		result.ConflictGroup = ruleConflictGroup(rule)
+		result.OverrideFailure = ruleOverridesFailure(rule)
+		result.ExecutionTime = time.Since(startTime).Milliseconds()
+		results = append(results, result)
+	}
+
+	s.logger.WithContext(ctx).Info("规则校验完成",
+		logger.NewField("rule_count", len(rules)),
+		logger.NewField("result_count", len(results)))
+
+	return s.ResolveRuleConflicts(results), nil
+}
+
+// buildFailedResult 构建规则未能正常执行时的校验结果
+func (s *RuleService) buildFailedResult(rule *Rule, startTime time.Time, message string) *RuleValidationResult {
+	return &RuleValidationResult{
+		RuleID:         rule.ID,
+		RuleName:       rule.Name,
+		RuleType:       rule.Type,
+		Passed:         false,
+		Message:        message,
+		Severity:       ruleSeverity("", rule.Type),
+		Priority:       rule.Priority,
+		ExecutionTime:  time.Since(startTime).Milliseconds(),
+		Timestamp:      time.Now(),
+		ConflictGroup:  ruleConflictGroup(rule),
+		ExecutionError: true,
+	}
+}
+
+// ruleConflictGroup 获取规则的冲突组：元数据声明的冲突组，未声明时为规则编码(规则编码为空时为规则ID)
+func ruleConflictGroup(rule *Rule) string {
+	if group, ok := rule.Metadata[RuleMetadataConflictGroup].(string); ok && strings.TrimSpace(group) != "" {
+		return strings.TrimSpace(group)
+	}
+	if rule.RuleCode != "" {
+		return rule.RuleCode
+	}
+	return rule.ID
+}
+
+// ruleOverridesFailure 规则元数据是否声明其通过结论可覆盖同组的未通过结论
+func ruleOverridesFailure(rule *Rule) bool {
+	override, _ := rule.Metadata[RuleMetadataOverrideFailure].(bool)
+	return override
+}
+
+// ruleSeverity 将规则给出的严重程度(low/medium/high)转换为高/中/低
+// 规则未给出时按规则类型确定：金额、合规规则为高，发票、频次、自定义规则为中，
+// 其他类型返回空，由风险权重中未标注严重程度的分值计入
+func ruleSeverity(severity, ruleType string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case RuleSeverityHigh, "高":
+		return "高"
+	case RuleSeverityMedium, "中":
+		return "中"
+	case RuleSeverityLow, "低":
+		return "低"
+	}
+
+	switch ruleType {
+	case RuleTypeAmount, RuleTypeCompliance:
+		return "高"
+	case RuleTypeInvoice, RuleTypeFrequency, RuleTypeCustom:
+		return "中"
+	default:
+		return ""
+	}
 }
 
 // TestRule 测试规则
+// 使用临时知识库对测试数据空跑规则，不影响正式规则库
 func (s *RuleService) TestRule(ctx context.Context, rule *Rule, testData interface{}) (*RuleValidationResult, error) {
-	// TODO: 实现测试规则逻辑
-	return nil, nil
+	if rule == nil || rule.Definition == "" {
+		s.logger.WithContext(ctx).Error("规则定义不能为空")
+		return nil, errors.New("规则定义不能为空")
+	}
+
+	if s.engine == nil {
+		s.logger.WithContext(ctx).Error("规则引擎未初始化")
+		return nil, errors.New("规则引擎未初始化")
+	}
+
+	result, err := s.engine.TestRule(ctx, rule, testData)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("测试规则失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("rule_id", rule.ID))
+		return nil, err
+	}
+
+	return result, nil
 }
 
 // LoadRules 加载规则
 func (s *RuleService) LoadRules(ctx context.Context) error {
-	// TODO: 实现加载规则逻辑
-	return nil
+	if s.engine == nil {
+		return errors.New("规则引擎未初始化")
+	}
+	return s.engine.Initialize(ctx)
 }
 
 // ReloadRules 重新加载规则
 func (s *RuleService) ReloadRules(ctx context.Context) error {
-	// TODO: 实现重新加载规则逻辑
-	return nil
+	if s.engine == nil {
+		return errors.New("规则引擎未初始化")
+	}
+	return s.engine.ReloadRulesFromDatabase(ctx)
 }
 
 // GetRuleTypes 获取规则类型列表
@@ -417,13 +631,100 @@ func (s *RuleService) GetRuleTypes(ctx context.Context) ([]string, error) {
 }
 
 // ResolveRuleConflicts 解决规则冲突
+// 只有同一冲突组(同一规则编码或声明了相同冲突组)的规则结论相反时才视为冲突，规则加载或执行出错的结果不参与；
+// 以优先级最高的规则结论为准（优先级相同时以未通过为准），但通过结论只有在规则声明允许覆盖未通过时
+// 才能覆盖未通过结论，否则以同组优先级最高的未通过结论为准；
+// 结论相反的规则标记为被覆盖并记录覆盖来源，结果保持原有顺序
 func (s *RuleService) ResolveRuleConflicts(results []*RuleValidationResult) []*RuleValidationResult {
-	// TODO: 实现解决规则冲突逻辑
-	return nil
+	groups := make(map[string][]*RuleValidationResult)
+	groupOrder := make([]string, 0)
+	for _, result := range results {
+		if result == nil || result.ExecutionError {
+			continue
+		}
+		group := result.ConflictGroup
+		if group == "" {
+			group = result.RuleID
+		}
+		if _, ok := groups[group]; !ok {
+			groupOrder = append(groupOrder, group)
+		}
+		groups[group] = append(groups[group], result)
+	}
+
+	for _, conflictGroup := range groupOrder {
+		group := groups[conflictGroup]
+		if !hasConflict(group) {
+			continue
+		}
+
+		winner := conflictWinner(group)
+		for _, result := range group {
+			if result.Passed == winner.Passed {
+				continue
+			}
+			result.Overridden = true
+			result.OverriddenBy = winner.RuleID
+			result.ConflictNote = fmt.Sprintf("与规则%s(优先级%d)结论冲突，按优先级采用其%s结论",
+				winner.RuleID, winner.Priority, passedText(winner.Passed))
+		}
+
+		s.logger.Info("检测到规则冲突",
+			logger.NewField("conflict_group", conflictGroup),
+			logger.NewField("winner_rule_id", winner.RuleID),
+			logger.NewField("winner_passed", winner.Passed))
+	}
+
+	return results
+}
+
+// conflictWinner 选出同组冲突中的决定性结论
+// 优先级最高者胜出，优先级相同时未通过优先；胜出者为通过但未声明允许覆盖未通过时，改以优先级最高的未通过结论为准
+func conflictWinner(group []*RuleValidationResult) *RuleValidationResult {
+	var winner, failedWinner *RuleValidationResult
+	for _, result := range group {
+		if winner == nil || result.Priority > winner.Priority ||
+			(result.Priority == winner.Priority && winner.Passed && !result.Passed) {
+			winner = result
+		}
+		if !result.Passed && (failedWinner == nil || result.Priority > failedWinner.Priority) {
+			failedWinner = result
+		}
+	}
+	if winner.Passed && !winner.OverrideFailure && failedWinner != nil {
+		return failedWinner
+	}
+	return winner
+}
+
+// hasConflict 判断同组规则结论是否存在冲突
+func hasConflict(results []*RuleValidationResult) bool {
+	passed, failed := false, false
+	for _, result := range results {
+		if result.Passed {
+			passed = true
+		} else {
+			failed = true
+		}
+	}
+	return passed && failed
+}
+
+// passedText 返回结论的中文描述
+func passedText(passed bool) string {
+	if passed {
+		return "通过"
+	}
+	return "不通过"
 }
 
 // SortRulesByPriority 按优先级排序规则
+// 按Priority降序稳定排序，返回新切片，不修改入参
 func (s *RuleService) SortRulesByPriority(rules []*Rule) []*Rule {
-	// TODO: 实现按优先级排序规则逻辑
-	return nil
+	sorted := make([]*Rule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
 }
@@ -0,0 +1,146 @@
+package rule
+
+import (
+	"testing"
+
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+)
+
+func TestRuleSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity string
+		ruleType string
+		want     string
+	}{
+		{name: "规则给出high", severity: RuleSeverityHigh, ruleType: RuleTypeCustom, want: "高"},
+		{name: "规则给出medium(大小写与空白)", severity: " Medium ", ruleType: RuleTypeAmount, want: "中"},
+		{name: "规则给出low", severity: RuleSeverityLow, ruleType: RuleTypeAmount, want: "低"},
+		{name: "规则给出中文", severity: "高", ruleType: RuleTypeInvoice, want: "高"},
+		{name: "金额规则默认高", ruleType: RuleTypeAmount, want: "高"},
+		{name: "合规规则默认高", ruleType: RuleTypeCompliance, want: "高"},
+		{name: "发票规则默认中", ruleType: RuleTypeInvoice, want: "中"},
+		{name: "频次规则默认中", ruleType: RuleTypeFrequency, want: "中"},
+		{name: "自定义规则默认中", ruleType: RuleTypeCustom, want: "中"},
+		{name: "未知严重程度按类型确定", severity: "critical", ruleType: RuleTypeAmount, want: "高"},
+		{name: "未知类型不标注", ruleType: "other", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleSeverity(tt.severity, tt.ruleType); got != tt.want {
+				t.Fatalf("ruleSeverity(%q, %q) = %q, want %q", tt.severity, tt.ruleType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleConflictGroup(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *Rule
+		want string
+	}{
+		{name: "声明冲突组", rule: &Rule{ID: "r1", RuleCode: "AMOUNT_LIMIT", Metadata: map[string]interface{}{RuleMetadataConflictGroup: " meal-limit "}}, want: "meal-limit"},
+		{name: "未声明时使用规则编码", rule: &Rule{ID: "r1", RuleCode: "AMOUNT_LIMIT"}, want: "AMOUNT_LIMIT"},
+		{name: "冲突组类型错误时使用规则编码", rule: &Rule{ID: "r1", RuleCode: "AMOUNT_LIMIT", Metadata: map[string]interface{}{RuleMetadataConflictGroup: 1}}, want: "AMOUNT_LIMIT"},
+		{name: "规则编码为空时使用规则ID", rule: &Rule{ID: "r1"}, want: "r1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleConflictGroup(tt.rule); got != tt.want {
+				t.Fatalf("ruleConflictGroup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRuleConflicts(t *testing.T) {
+	tests := []struct {
+		name           string
+		results        []*RuleValidationResult
+		wantOverridden map[string]string // 被覆盖的规则ID -> 覆盖来源
+	}{
+		{
+			name: "同类型不同检查项不视为冲突",
+			results: []*RuleValidationResult{
+				{RuleID: "a", RuleType: RuleTypeAmount, ConflictGroup: "A", Passed: true, Priority: 10},
+				{RuleID: "b", RuleType: RuleTypeAmount, ConflictGroup: "B", Passed: false, Priority: 1},
+			},
+			wantOverridden: map[string]string{},
+		},
+		{
+			name: "同冲突组高优先级未通过覆盖通过",
+			results: []*RuleValidationResult{
+				{RuleID: "a", ConflictGroup: "G", Passed: true, Priority: 1},
+				{RuleID: "b", ConflictGroup: "G", Passed: false, Priority: 10},
+			},
+			wantOverridden: map[string]string{"a": "b"},
+		},
+		{
+			name: "高优先级通过未声明允许时不覆盖未通过",
+			results: []*RuleValidationResult{
+				{RuleID: "a", ConflictGroup: "G", Passed: true, Priority: 10},
+				{RuleID: "b", ConflictGroup: "G", Passed: false, Priority: 1},
+			},
+			wantOverridden: map[string]string{"a": "b"},
+		},
+		{
+			name: "高优先级通过声明允许时覆盖未通过",
+			results: []*RuleValidationResult{
+				{RuleID: "a", ConflictGroup: "G", Passed: true, Priority: 10, OverrideFailure: true},
+				{RuleID: "b", ConflictGroup: "G", Passed: false, Priority: 1},
+			},
+			wantOverridden: map[string]string{"b": "a"},
+		},
+		{
+			name: "同优先级允许覆盖的通过也不覆盖未通过",
+			results: []*RuleValidationResult{
+				{RuleID: "a", ConflictGroup: "G", Passed: true, Priority: 5, OverrideFailure: true},
+				{RuleID: "b", ConflictGroup: "G", Passed: false, Priority: 5},
+			},
+			wantOverridden: map[string]string{"a": "b"},
+		},
+		{
+			name: "执行出错的结果不参与冲突解决",
+			results: []*RuleValidationResult{
+				{RuleID: "a", ConflictGroup: "G", Passed: true, Priority: 10, OverrideFailure: true},
+				{RuleID: "b", ConflictGroup: "G", Passed: false, Priority: 1, ExecutionError: true},
+			},
+			wantOverridden: map[string]string{},
+		},
+		{
+			name: "未设置冲突组时按规则ID分组",
+			results: []*RuleValidationResult{
+				{RuleID: "a", Passed: true, Priority: 10},
+				{RuleID: "b", Passed: false, Priority: 1},
+				nil,
+			},
+			wantOverridden: map[string]string{},
+		},
+	}
+
+	service := NewRuleService(nil, loggertest.New(t), nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := service.ResolveRuleConflicts(tt.results)
+			if len(results) != len(tt.results) {
+				t.Fatalf("结果数量 = %d, want %d", len(results), len(tt.results))
+			}
+			for _, result := range results {
+				if result == nil {
+					continue
+				}
+				wantBy, wantOverridden := tt.wantOverridden[result.RuleID]
+				if result.Overridden != wantOverridden || result.OverriddenBy != wantBy {
+					t.Fatalf("规则%s Overridden=%v OverriddenBy=%q, want %v %q",
+						result.RuleID, result.Overridden, result.OverriddenBy, wantOverridden, wantBy)
+				}
+				if wantOverridden && result.ConflictNote == "" {
+					t.Fatalf("规则%s缺少冲突解决说明", result.RuleID)
+				}
+			}
+		})
+	}
+}
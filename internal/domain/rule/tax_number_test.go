@@ -0,0 +1,70 @@
+package rule
+
+import "testing"
+
+func TestNormalizeTaxNumber(t *testing.T) {
+	tests := []struct {
+		name      string
+		taxNumber string
+		want      string
+	}{
+		{name: "转为大写", taxNumber: "91110000600037341l", want: "91110000600037341L"},
+		{name: "去除空格和分隔符", taxNumber: " 9135-0100_M000 100Y43", want: "91350100M000100Y43"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeTaxNumber(tt.taxNumber); got != tt.want {
+				t.Errorf("NormalizeTaxNumber(%q) = %q, want %q", tt.taxNumber, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedCreditCodeCheckChar(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    byte
+		wantErr bool
+	}{
+		{name: "校验位为数字", code: "91350100M000100Y4", want: '3'},
+		{name: "校验位为字母", code: "91110000600037341", want: 'L'},
+		{name: "长度不足17位", code: "9135010", wantErr: true},
+		{name: "包含非法字符", code: "91350100M000100I4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnifiedCreditCodeCheckChar(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnifiedCreditCodeCheckChar(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("UnifiedCreditCodeCheckChar(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidUnifiedCreditCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{name: "合法代码", code: "91350100M000100Y43", want: true},
+		{name: "小写和分隔符规范化后合法", code: "9111-0000-6000-3734-1l", want: true},
+		{name: "校验位错误", code: "91350100M000100Y44", want: false},
+		{name: "长度不是18位", code: "91350100M000100Y4", want: false},
+		{name: "包含非法字符", code: "91350100M000100O43", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsValidUnifiedCreditCode(tt.code); got != tt.want {
+				t.Errorf("IsValidUnifiedCreditCode(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// factory.go 文件存储工厂
+// 功能点：
+// 1. 定义支持的存储类型
+// 2. 按配置的存储类型创建存储实现，切换存储后端只需修改配置
+// 3. 支持本地存储和MinIO存储
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 存储类型
+const (
+	StorageTypeLocal = "local"
+	StorageTypeMinIO = "minio"
+)
+
+// ErrUnsupportedStorage 不支持的存储类型
+var ErrUnsupportedStorage = errors.New("不支持的存储类型")
+
+// Options 存储创建参数
+type Options struct {
+	Type      string       // 存储类型，为空时使用本地存储
+	LocalPath string       // 本地存储路径
+	BaseURL   string       // 文件访问URL前缀
+	MinIO     MinIOOptions // MinIO存储参数
+}
+
+// NewStorage 按存储类型创建存储实现
+func NewStorage(options Options) (Storage, error) {
+	storageType := strings.ToLower(strings.TrimSpace(options.Type))
+	if storageType == "" {
+		storageType = StorageTypeLocal
+	}
+
+	switch storageType {
+	case StorageTypeLocal:
+		if options.LocalPath == "" {
+			return nil, errors.New("本地存储路径不能为空")
+		}
+		if err := os.MkdirAll(options.LocalPath, 0755); err != nil {
+			return nil, fmt.Errorf("创建本地存储目录失败: %w", err)
+		}
+		return NewLocalStorage(options.LocalPath, options.BaseURL), nil
+	case StorageTypeMinIO:
+		return NewMinIOStorage(options.MinIO)
+	default:
+		return nil, fmt.Errorf("%w: %s（可选: %s/%s）", ErrUnsupportedStorage, storageType, StorageTypeLocal, StorageTypeMinIO)
+	}
+}
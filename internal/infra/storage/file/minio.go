@@ -0,0 +1,210 @@
+// minio.go MinIO文件存储实现
+// 功能点：
+// 1. 实现MinIO对象存储，接口与本地存储兼容
+// 2. 上传返回的文件路径为对象key
+// 3. 配置公开桶URL时返回固定访问URL，否则生成预签名URL
+// 4. 创建时检查存储桶，不存在时自动创建
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// MinIO存储默认配置
+const (
+	DefaultMinIOURLExpiry      = 24 * time.Hour     // 预签名URL默认有效期
+	MaxMinIOURLExpiry          = 7 * 24 * time.Hour // 预签名URL最长有效期(S3协议限制)
+	defaultMinIOConnectTimeout = 10 * time.Second   // 创建时检查存储桶的超时时间
+)
+
+// MinIOOptions MinIO存储创建参数
+type MinIOOptions struct {
+	Endpoint  string        // MinIO端点(host:port)
+	AccessKey string        // 访问密钥
+	SecretKey string        // 秘密密钥
+	Bucket    string        // 存储桶
+	Region    string        // 地域，可为空
+	UseSSL    bool          // 是否使用SSL
+	PublicURL string        // 公开桶访问URL前缀，为空时生成预签名URL
+	URLExpiry time.Duration // 预签名URL有效期，非正数时使用默认值
+}
+
+// MinIOStorage MinIO存储实现
+type MinIOStorage struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+	urlExpiry time.Duration
+}
+
+// NewMinIOStorage 创建MinIO存储实例，存储桶不存在时自动创建
+func NewMinIOStorage(options MinIOOptions) (*MinIOStorage, error) {
+	if strings.TrimSpace(options.Endpoint) == "" {
+		return nil, errors.New("MinIO端点不能为空")
+	}
+	if strings.TrimSpace(options.Bucket) == "" {
+		return nil, errors.New("MinIO存储桶不能为空")
+	}
+
+	client, err := minio.New(options.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(options.AccessKey, options.SecretKey, ""),
+		Secure: options.UseSSL,
+		Region: options.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建MinIO客户端失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultMinIOConnectTimeout)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, options.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("检查MinIO存储桶失败(%s/%s): %w", options.Endpoint, options.Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, options.Bucket, minio.MakeBucketOptions{Region: options.Region}); err != nil {
+			return nil, fmt.Errorf("创建MinIO存储桶失败(%s/%s): %w", options.Endpoint, options.Bucket, err)
+		}
+	}
+
+	urlExpiry := options.URLExpiry
+	if urlExpiry <= 0 {
+		urlExpiry = DefaultMinIOURLExpiry
+	}
+
+	return &MinIOStorage{
+		client:    client,
+		bucket:    options.Bucket,
+		publicURL: strings.TrimSuffix(options.PublicURL, "/"),
+		urlExpiry: urlExpiry,
+	}, nil
+}
+
+// UploadFile 上传文件
+func (ms *MinIOStorage) UploadFile(ctx context.Context, file *multipart.FileHeader, path string) (*FileInfo, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开上传文件失败: %w", err)
+	}
+	defer src.Close()
+
+	return ms.putObject(ctx, src, file.Size, file.Filename, path, file.Header.Get("Content-Type"))
+}
+
+// UploadFileFromBytes 从字节数组上传文件
+func (ms *MinIOStorage) UploadFileFromBytes(ctx context.Context, data []byte, filename, path, mimeType string) (*FileInfo, error) {
+	return ms.putObject(ctx, bytes.NewReader(data), int64(len(data)), filename, path, mimeType)
+}
+
+// GetFile 获取文件
+func (ms *MinIOStorage) GetFile(ctx context.Context, path string) (io.ReadCloser, *FileInfo, error) {
+	key := objectKey(path)
+	object, err := ms.client.GetObject(ctx, ms.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取文件失败: %w", err)
+	}
+
+	// GetObject不会立即请求，通过Stat确认对象存在并获取文件信息
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, nil, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+
+	url, err := ms.GetFileURL(ctx, key, 0)
+	if err != nil {
+		object.Close()
+		return nil, nil, err
+	}
+
+	fileInfo := &FileInfo{
+		ID:         generateFileID(key),
+		Name:       baseName(key),
+		Size:       stat.Size,
+		Path:       key,
+		URL:        url,
+		MimeType:   stat.ContentType,
+		UploadedAt: stat.LastModified,
+	}
+
+	return object, fileInfo, nil
+}
+
+// DeleteFile 删除文件
+func (ms *MinIOStorage) DeleteFile(ctx context.Context, path string) error {
+	if err := ms.client.RemoveObject(ctx, ms.bucket, objectKey(path), minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// GetFileURL 获取文件访问URL
+// 配置公开桶URL时返回固定URL，否则生成预签名URL，expires非正数时使用默认有效期
+func (ms *MinIOStorage) GetFileURL(ctx context.Context, path string, expires time.Duration) (string, error) {
+	key := objectKey(path)
+	if ms.publicURL != "" {
+		return ms.publicURL + "/" + key, nil
+	}
+
+	if expires <= 0 {
+		expires = ms.urlExpiry
+	}
+	if expires > MaxMinIOURLExpiry {
+		expires = MaxMinIOURLExpiry
+	}
+
+	presignedURL, err := ms.client.PresignedGetObject(ctx, ms.bucket, key, expires, nil)
+	if err != nil {
+		return "", fmt.Errorf("生成文件访问URL失败: %w", err)
+	}
+	return presignedURL.String(), nil
+}
+
+// putObject 上传对象并构建文件信息
+func (ms *MinIOStorage) putObject(ctx context.Context, reader io.Reader, size int64, filename, path, mimeType string) (*FileInfo, error) {
+	key := objectKey(path)
+	if _, err := ms.client.PutObject(ctx, ms.bucket, key, reader, size, minio.PutObjectOptions{
+		ContentType: mimeType,
+	}); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	url, err := ms.GetFileURL(ctx, key, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo := &FileInfo{
+		ID:         generateFileID(key),
+		Name:       filename,
+		Size:       size,
+		Path:       key,
+		URL:        url,
+		MimeType:   mimeType,
+		UploadedAt: time.Now(),
+	}
+
+	return fileInfo, nil
+}
+
+// objectKey 将存储路径转换为对象key，统一使用/分隔且不以/开头
+func objectKey(filePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(filePath, "\\", "/")), "/")
+}
+
+// baseName 获取对象key中的文件名
+func baseName(key string) string {
+	return path.Base(key)
+}
@@ -3,34 +3,54 @@
 // 1. 文件格式和大小校验
 // 2. 生成文件UUID
 // 3. 处理文件上传和存储
+// 4. 允许的文件类型和单文件大小上限可配置，并按文件内容校验实际类型
 
 package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"path/filepath"
 	"reimbursement-audit/internal/api/middleware"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// 文件校验错误
+var (
+	ErrFileTooLarge       = errors.New("文件大小超过限制")
+	ErrFileTypeNotAllowed = errors.New("不支持的文件类型")
+)
+
 // Service 文件服务
 type Service struct {
-	storage Storage // 文件存储接口
+	storage      Storage         // 文件存储接口
+	maxFileSize  int64           // 单文件大小上限(字节)
+	allowedTypes map[string]bool // 允许的文件扩展名
 }
 
 // NewService 创建文件服务实例
 func NewService(storage Storage) *Service {
+	allowedTypes := make(map[string]bool, len(AllowedFileTypes))
+	for ext, allowed := range AllowedFileTypes {
+		allowedTypes[ext] = allowed
+	}
+
 	return &Service{
-		storage: storage,
+		storage:      storage,
+		maxFileSize:  MaxFileSize,
+		allowedTypes: allowedTypes,
 	}
 }
 
-// AllowedFileTypes 允许的文件类型
+// AllowedFileTypes 默认允许的文件类型
 var AllowedFileTypes = map[string]bool{
 	".jpg":  true,
 	".jpeg": true,
@@ -38,25 +58,110 @@ var AllowedFileTypes = map[string]bool{
 	".pdf":  true,
 }
 
-// MaxFileSize 最大文件大小 (10MB)
+// fileMimeTypes 扩展名对应的文件内容类型，用于识别伪造扩展名的文件
+var fileMimeTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".pdf":  "application/pdf",
+}
+
+// MaxFileSize 默认最大文件大小 (10MB)
 const MaxFileSize = 10 * 1024 * 1024
 
-// ValidateFile 校验文件
+// sniffSize 检测文件内容类型读取的字节数
+const sniffSize = 512
+
+// SetMaxFileSize 设置单文件大小上限(字节)，非正数使用默认值
+func (s *Service) SetMaxFileSize(size int64) {
+	if size <= 0 {
+		size = MaxFileSize
+	}
+	s.maxFileSize = size
+}
+
+// SetAllowedFileTypes 设置允许的文件扩展名（如".jpg"或"jpg"），为空时使用默认值
+func (s *Service) SetAllowedFileTypes(types []string) {
+	allowedTypes := make(map[string]bool, len(types))
+	for _, ext := range types {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		allowedTypes[ext] = true
+	}
+
+	if len(allowedTypes) == 0 {
+		for ext, allowed := range AllowedFileTypes {
+			allowedTypes[ext] = allowed
+		}
+	}
+	s.allowedTypes = allowedTypes
+}
+
+// ValidateFile 校验文件大小、扩展名和文件内容类型
 func (s *Service) ValidateFile(file *multipart.FileHeader) error {
 	// 检查文件大小
-	if file.Size > MaxFileSize {
-		return fmt.Errorf("文件大小超过限制，最大允许 %d MB", MaxFileSize/(1024*1024))
+	if file.Size > s.maxFileSize {
+		return fmt.Errorf("%w，最大允许 %.1f MB", ErrFileTooLarge, float64(s.maxFileSize)/(1024*1024))
 	}
 
 	// 检查文件类型
 	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if !AllowedFileTypes[ext] {
-		return fmt.Errorf("不支持的文件类型: %s，仅支持 JPG、PNG、PDF", ext)
+	if !s.allowedTypes[ext] {
+		return fmt.Errorf("%w: %s，仅支持 %s", ErrFileTypeNotAllowed, ext, s.allowedTypeNames())
+	}
+
+	// 检查文件内容与扩展名是否一致，未登记内容类型的扩展名不做检查
+	expected, ok := fileMimeTypes[ext]
+	if !ok {
+		return nil
+	}
+	actual, err := detectContentType(file)
+	if err != nil {
+		return fmt.Errorf("读取文件内容失败: %w", err)
+	}
+	if actual != expected {
+		return fmt.Errorf("%w: 文件内容(%s)与扩展名(%s)不符", ErrFileTypeNotAllowed, actual, ext)
 	}
 
 	return nil
 }
 
+// allowedTypeNames 允许的文件类型名称，用于错误提示
+func (s *Service) allowedTypeNames() string {
+	names := make([]string, 0, len(s.allowedTypes))
+	for ext := range s.allowedTypes {
+		names = append(names, strings.ToUpper(strings.TrimPrefix(ext, ".")))
+	}
+	sort.Strings(names)
+	return strings.Join(names, "、")
+}
+
+// detectContentType 根据文件头部内容检测文件类型
+func detectContentType(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	buf := make([]byte, sniffSize)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(buf[:n])
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	return contentType, nil
+}
+
 // GenerateFileUUID 生成文件UUID
 func (s *Service) GenerateFileUUID() string {
 	return uuid.New().String()
@@ -0,0 +1,184 @@
+// audit_repository.go MySQL审核结果仓储实现
+// 功能点：
+// 1. 实现审核结果仓储接口
+// 2. 规则校验结果、RAG分析结果以JSON序列化落库，查询时完整还原
+// 3. 支持按报销单、状态、SLA超时和时间范围筛选
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"reimbursement-audit/internal/domain/audit"
+	"reimbursement-audit/internal/pkg/errs"
+	"reimbursement-audit/internal/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository 审核结果仓储实现
+type AuditRepository struct {
+	client *Client
+	logger logger.Logger
+}
+
+// NewAuditRepository 创建审核结果仓储实例
+func NewAuditRepository(client *Client, logger logger.Logger) audit.Repository {
+	return &AuditRepository{client: client, logger: logger}
+}
+
+// CreateAudit 创建审核记录
+func (r *AuditRepository) CreateAudit(ctx context.Context, result *audit.AuditResult) error {
+	now := time.Now()
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = now
+	}
+	result.UpdatedAt = now
+
+	if err := r.client.DB(ctx).Create(result).Error; err != nil {
+		r.logger.WithContext(ctx).Error("创建审核记录失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("audit_id", result.ID),
+			logger.NewField("reimbursement_id", result.ReimbursementID))
+		return err
+	}
+
+	return nil
+}
+
+// GetAuditByID 根据ID获取审核记录
+func (r *AuditRepository) GetAuditByID(ctx context.Context, id string) (*audit.AuditResult, error) {
+	var result audit.AuditResult
+
+	err := r.client.DB(ctx).Where("id = ?", id).First(&result).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.logger.WithContext(ctx).Warn("审核记录不存在",
+				logger.NewField("audit_id", id))
+			return nil, errs.NotFound("审核记录不存在")
+		}
+		r.logger.WithContext(ctx).Error("获取审核记录失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("audit_id", id))
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetAuditByReimbursementID 根据报销单ID获取最近一次审核记录
+func (r *AuditRepository) GetAuditByReimbursementID(ctx context.Context, reimbursementID string) (*audit.AuditResult, error) {
+	var result audit.AuditResult
+
+	err := r.client.DB(ctx).
+		Where("reimbursement_id = ?", reimbursementID).
+		Order("created_at DESC").
+		First(&result).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.logger.WithContext(ctx).Warn("审核记录不存在",
+				logger.NewField("reimbursement_id", reimbursementID))
+			return nil, errs.NotFound("审核记录不存在")
+		}
+		r.logger.WithContext(ctx).Error("获取审核记录失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("reimbursement_id", reimbursementID))
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// UpdateAudit 更新审核记录（整行保存，包含JSON序列化字段）
+func (r *AuditRepository) UpdateAudit(ctx context.Context, result *audit.AuditResult) error {
+	result.UpdatedAt = time.Now()
+
+	if err := r.client.DB(ctx).Save(result).Error; err != nil {
+		r.logger.WithContext(ctx).Error("更新审核记录失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("audit_id", result.ID))
+		return err
+	}
+
+	return nil
+}
+
+// Transaction 在同一事务中执行fn，审核和报销单仓储共用同一MySQL客户端，fn内的写操作一起提交或回滚
+func (r *AuditRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.client.Transaction(ctx, fn)
+}
+
+// ListAudits 查询审核列表
+func (r *AuditRepository) ListAudits(ctx context.Context, filter *audit.AuditFilter) ([]*audit.AuditResult, int64, error) {
+	var results []*audit.AuditResult
+	var total int64
+
+	db := r.client.DB(ctx).Model(&audit.AuditResult{})
+
+	// 应用过滤条件
+	if filter != nil {
+		if filter.ReimbursementID != "" {
+			db = db.Where("reimbursement_id = ?", filter.ReimbursementID)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		if filter.RiskLevel != "" {
+			db = db.Where("risk_level = ?", filter.RiskLevel)
+		}
+		if filter.SLABreached != nil {
+			db = db.Where("sla_breached = ?", *filter.SLABreached)
+		}
+		if filter.Transient != nil {
+			db = db.Where("transient = ?", *filter.Transient)
+		}
+		if filter.StartTime != nil {
+			db = db.Where("created_at >= ?", *filter.StartTime)
+		}
+		if filter.EndTime != nil {
+			db = db.Where("created_at <= ?", *filter.EndTime)
+		}
+	}
+
+	// 获取总数
+	if err := db.Count(&total).Error; err != nil {
+		r.logger.WithContext(ctx).Error("统计审核记录数量失败",
+			logger.NewField("error", err.Error()))
+		return nil, 0, err
+	}
+
+	// 应用分页
+	if filter != nil && filter.Page > 0 && filter.Size > 0 {
+		offset := (filter.Page - 1) * filter.Size
+		db = db.Offset(offset).Limit(filter.Size)
+	}
+
+	if err := db.Order("created_at DESC").Find(&results).Error; err != nil {
+		r.logger.WithContext(ctx).Error("查询审核列表失败",
+			logger.NewField("error", err.Error()))
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// DeleteAudit 删除审核记录
+func (r *AuditRepository) DeleteAudit(ctx context.Context, id string) error {
+	result := r.client.DB(ctx).Delete(&audit.AuditResult{}, "id = ?", id)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("删除审核记录失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("audit_id", id))
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		r.logger.WithContext(ctx).Warn("审核记录不存在，删除失败",
+			logger.NewField("audit_id", id))
+		return errs.NotFound("审核记录不存在")
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ package mysql
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 
 	"reimbursement-audit/internal/pkg/logger"
@@ -37,10 +38,18 @@ func NewClient(logger logger.Logger) *Client {
 }
 
 // Connect 连接数据库
+// 连接失败时返回包含连接目标（不含密码）和原因的错误
 func (c *Client) Connect(ctx context.Context, config *Config) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("数据库配置不合法: %w", err)
+	}
+
 	// 构建数据源名称
 	dsn := config.GetDSN()
 
@@ -65,8 +74,9 @@ func (c *Client) Connect(ctx context.Context, config *Config) error {
 	})
 	if err != nil {
 		c.logger.WithContext(ctx).Error("打开数据库连接失败",
-			logger.NewField("error", err.Error()))
-		return errors.New("打开数据库连接失败")
+			logger.NewField("error", err.Error()),
+			logger.NewField("target", config.String()))
+		return fmt.Errorf("打开数据库连接失败(%s): %w", config, err)
 	}
 
 	// 获取底层sql.DB对象以配置连接池
@@ -74,7 +84,7 @@ func (c *Client) Connect(ctx context.Context, config *Config) error {
 	if err != nil {
 		c.logger.WithContext(ctx).Error("获取底层SQL数据库连接失败",
 			logger.NewField("error", err.Error()))
-		return errors.New("获取底层SQL数据库连接失败")
+		return fmt.Errorf("获取底层SQL数据库连接失败: %w", err)
 	}
 
 	// 设置连接池参数
@@ -86,10 +96,17 @@ func (c *Client) Connect(ctx context.Context, config *Config) error {
 	// 测试连接
 	if err := sqlDB.PingContext(ctx); err != nil {
 		c.logger.WithContext(ctx).Error("数据库连接测试失败",
-			logger.NewField("error", err.Error()))
-		return errors.New("数据库连接测试失败")
+			logger.NewField("error", err.Error()),
+			logger.NewField("target", config.String()))
+		sqlDB.Close()
+		return fmt.Errorf("数据库连接测试失败(%s): %w", config, err)
 	}
 
+	c.logger.WithContext(ctx).Info("数据库连接成功",
+		logger.NewField("target", config.String()),
+		logger.NewField("max_open_conns", config.MaxOpenConns),
+		logger.NewField("max_idle_conns", config.MaxIdleConns))
+
 	c.db = db
 	c.config = config
 
@@ -125,6 +142,28 @@ func (c *Client) Begin(ctx context.Context) *gorm.DB {
 	return c.GetDB().Begin()
 }
 
+// txKey 上下文中进行中事务的键
+type txKey struct{}
+
+// Transaction 在事务中执行fn，fn内通过传入的ctx调用的仓储操作共用该事务，fn返回错误时全部回滚
+// ctx中已有进行中的事务时直接在该事务中执行
+func (c *Client) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return c.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// DB 获取绑定上下文的数据库连接，ctx中有进行中的事务时返回该事务
+func (c *Client) DB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return c.GetDB().WithContext(ctx)
+}
+
 // Execute 执行SQL语句（使用GORM）
 func (c *Client) Execute(ctx context.Context, query string, args ...interface{}) (int64, error) {
 	result := c.GetDB().Exec(query, args...)
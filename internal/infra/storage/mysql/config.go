@@ -10,7 +10,9 @@
 package mysql
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"time"
 )
 
@@ -59,14 +61,37 @@ func DefaultConfig() *Config {
 
 // Validate 验证配置
 func (c *Config) Validate() error {
-	// TODO: 实现配置验证逻辑
+	if c.Host == "" {
+		return errors.New("数据库主机不能为空")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("数据库端口不合法: %d", c.Port)
+	}
+	if c.Username == "" {
+		return errors.New("数据库用户名不能为空")
+	}
+	if c.DBName == "" {
+		return errors.New("数据库名不能为空")
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		return fmt.Errorf("最大空闲连接数(%d)不能大于最大打开连接数(%d)", c.MaxIdleConns, c.MaxOpenConns)
+	}
 	return nil
 }
 
 // GetDSN 获取数据源名称
 func (c *Config) GetDSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s",
-		c.Username, c.Password, c.Host, c.Port, c.DBName, c.Charset, c.ParseTime, c.Loc)
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s",
+		c.Username, c.Password, c.Host, c.Port, c.DBName, c.Charset, c.ParseTime, url.QueryEscape(c.Loc))
+	if c.Collation != "" {
+		dsn += "&collation=" + c.Collation
+	}
+	return dsn
+}
+
+// String 返回不含密码的连接描述，用于日志和错误信息
+func (c *Config) String() string {
+	return fmt.Sprintf("%s@%s:%d/%s", c.Username, c.Host, c.Port, c.DBName)
 }
 
 // GetConnectionURL 获取连接URL
@@ -77,14 +102,72 @@ func (c *Config) GetConnectionURL() string {
 
 // Clone 克隆配置
 func (c *Config) Clone() *Config {
-	// TODO: 实现配置克隆逻辑
-	return nil
+	if c == nil {
+		return nil
+	}
+	cloned := *c
+	return &cloned
 }
 
-// Merge 合并配置
+// Merge 合并配置，other中的非零值覆盖当前配置，返回合并后的新配置
 func (c *Config) Merge(other *Config) *Config {
-	// TODO: 实现配置合并逻辑
-	return nil
+	merged := c.Clone()
+	if merged == nil {
+		merged = DefaultConfig()
+	}
+	if other == nil {
+		return merged
+	}
+
+	if other.Host != "" {
+		merged.Host = other.Host
+	}
+	if other.Port > 0 {
+		merged.Port = other.Port
+	}
+	if other.Username != "" {
+		merged.Username = other.Username
+	}
+	if other.Password != "" {
+		merged.Password = other.Password
+	}
+	if other.DBName != "" {
+		merged.DBName = other.DBName
+	}
+	if other.Charset != "" {
+		merged.Charset = other.Charset
+	}
+	if other.Collation != "" {
+		merged.Collation = other.Collation
+	}
+	if other.Loc != "" {
+		merged.Loc = other.Loc
+	}
+	if other.MaxOpenConns > 0 {
+		merged.MaxOpenConns = other.MaxOpenConns
+	}
+	if other.MaxIdleConns > 0 {
+		merged.MaxIdleConns = other.MaxIdleConns
+	}
+	if other.ConnMaxLifetime > 0 {
+		merged.ConnMaxLifetime = other.ConnMaxLifetime
+	}
+	if other.ConnMaxIdleTime > 0 {
+		merged.ConnMaxIdleTime = other.ConnMaxIdleTime
+	}
+	if other.LogLevel != "" {
+		merged.LogLevel = other.LogLevel
+	}
+	if other.SlowThreshold > 0 {
+		merged.SlowThreshold = other.SlowThreshold
+	}
+	if other.MaxRetries > 0 {
+		merged.MaxRetries = other.MaxRetries
+	}
+	if other.RetryDelay > 0 {
+		merged.RetryDelay = other.RetryDelay
+	}
+	return merged
 }
 
 // FromEnv 从环境变量加载配置
@@ -0,0 +1,42 @@
+// holiday_repository.go MySQL节假日仓储实现
+// 功能点：
+// 1. 实现节假日仓储接口
+// 2. 按年份查询法定节假日和调休上班日
+
+package mysql
+
+import (
+	"context"
+
+	"reimbursement-audit/internal/domain/rule"
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// HolidayRepository 节假日仓储实现
+type HolidayRepository struct {
+	client *Client
+	logger logger.Logger
+}
+
+// NewHolidayRepository 创建节假日仓储实例
+func NewHolidayRepository(client *Client, logger logger.Logger) rule.HolidayRepository {
+	return &HolidayRepository{client: client, logger: logger}
+}
+
+// ListHolidaysByYear 获取指定年份的节假日和调休上班日
+func (r *HolidayRepository) ListHolidaysByYear(ctx context.Context, year int) ([]*rule.Holiday, error) {
+	var holidays []*rule.Holiday
+
+	result := r.client.GetDB().WithContext(ctx).
+		Where("year = ?", year).
+		Order("date ASC").
+		Find(&holidays)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("查询节假日失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("year", year))
+		return nil, result.Error
+	}
+
+	return holidays, nil
+}
@@ -4,22 +4,45 @@
 // 2. 支持数据库表结构自动更新
 // 3. 支持迁移版本管理
 // 4. 提供迁移状态查询
+// 5. 从迁移目录加载版本化SQL迁移（<版本>_<描述>.up.sql / .down.sql），按版本顺序执行
+// 6. 回滚按步数只回退最近N个已应用的迁移版本
+// 7. 迁移记录保存迁移文件校验和，文件在应用后被修改时拒绝执行并告警
 
 package mysql
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"reimbursement-audit/internal/domain/audit"
 	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/domain/rag"
 	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/domain/rule"
 	"reimbursement-audit/internal/infra/storage/mysql"
 
 	"gorm.io/gorm"
 )
 
+// DefaultMigrationsDir 默认的版本化迁移文件目录
+const DefaultMigrationsDir = "sql/migrations"
+
+var (
+	// ErrChecksumMismatch 已应用的迁移文件被修改
+	ErrChecksumMismatch = errors.New("迁移文件校验和不一致")
+	// ErrInvalidSteps 回滚步数不合法
+	ErrInvalidSteps = errors.New("回滚步数必须大于0")
+)
+
 // Migration 迁移结构体
 type Migration struct {
 	Version     string `json:"version"`     // 迁移版本
@@ -28,10 +51,44 @@ type Migration struct {
 	Down        string `json:"down"`        // 降级SQL
 }
 
+// Checksum 计算迁移内容的校验和（升级SQL和降级SQL的SHA-256）
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up + "\n--down--\n" + m.Down))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationRecord 迁移记录
+type MigrationRecord struct {
+	ID          string    `gorm:"primaryKey;type:varchar(36)"`
+	Version     string    `gorm:"type:varchar(50);not null;uniqueIndex"`
+	Description string    `gorm:"type:varchar(255)"`
+	Checksum    string    `gorm:"type:varchar(64)"`
+	AppliedAt   time.Time `gorm:"type:datetime;not null"`
+}
+
+// MigrationStatus 单个迁移的状态
+type MigrationStatus struct {
+	Version     string     `json:"version"`              // 迁移版本
+	Description string     `json:"description"`          // 迁移描述
+	Applied     bool       `json:"applied"`              // 是否已应用
+	AppliedAt   *time.Time `json:"applied_at,omitempty"` // 应用时间
+	Tampered    bool       `json:"tampered"`             // 应用后迁移文件是否被修改
+	Missing     bool       `json:"missing"`              // 已应用但迁移文件已不存在
+}
+
+// StatusReport 迁移状态报告
+type StatusReport struct {
+	Status     string             `json:"status"`          // 数据库连接状态
+	Error      string             `json:"error,omitempty"` // 连接失败原因
+	Tables     []string           `json:"tables"`          // 数据库表
+	Migrations []*MigrationStatus `json:"migrations"`      // 各迁移版本状态，按版本升序
+}
+
 // MigrationManager 迁移管理器
 type MigrationManager struct {
-	client *mysql.Client
-	db     *gorm.DB
+	client     *mysql.Client
+	db         *gorm.DB
+	migrations []Migration
 }
 
 // NewMigrationManager 创建迁移管理器
@@ -42,13 +99,97 @@ func NewMigrationManager(client *mysql.Client) *MigrationManager {
 	}
 }
 
+// LoadMigrations 从目录加载版本化迁移文件，目录不存在时视为没有版本化迁移
+// 文件名格式为<版本>_<描述>.up.sql和<版本>_<描述>.down.sql，版本按字符串升序执行
+func (m *MigrationManager) LoadMigrations(dir string) error {
+	migrations, err := loadMigrations(dir)
+	if err != nil {
+		return err
+	}
+	m.migrations = migrations
+	return nil
+}
+
+// loadMigrations 读取迁移目录中的迁移文件
+func loadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
+	}
+
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		version, description, _ := strings.Cut(base, "_")
+		if version == "" {
+			return nil, fmt.Errorf("迁移文件名不合法: %s", name)
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件%s失败: %w", name, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Description: description}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("迁移%s缺少升级SQL", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
 // Up 执行迁移
 func (m *MigrationManager) Up(ctx context.Context) error {
 	// 使用GORM的AutoMigrate功能自动创建和更新表结构
 	err := m.db.WithContext(ctx).AutoMigrate(
 		// 报销单相关模型
 		&reimbursement.Reimbursement{},
+		&reimbursement.ReimbursementTag{},
 		&ocr.Invoice{},
+		&ocr.InvoiceItem{},
+		// Prompt模板
+		&rag.PromptTemplate{},
+		// 节假日
+		&rule.Holiday{},
+		// 销售方黑名单
+		&rule.SellerBlacklistEntry{},
+		// 审核结果
+		&audit.AuditResult{},
 		// &reimbursement.AuditResult{},
 		// &reimbursement.AuditStatus{},
 	)
@@ -57,20 +198,83 @@ func (m *MigrationManager) Up(ctx context.Context) error {
 		return fmt.Errorf("执行数据库迁移失败: %w", err)
 	}
 
+	// 执行未应用的版本化迁移，已应用的迁移文件被修改时拒绝执行
+	records, err := m.appliedRecords(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.verifyChecksums(records); err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if _, applied := records[migration.Version]; applied {
+			continue
+		}
+		if err := m.ExecuteMigration(ctx, migration); err != nil {
+			return err
+		}
+	}
+
 	log.Println("数据库迁移完成")
 	return nil
 }
 
-// Down 回滚迁移
-func (m *MigrationManager) Down(ctx context.Context) error {
-	// GORM的AutoMigrate不支持自动回滚，需要手动处理
-	// 这里只是记录日志，实际生产环境可能需要更复杂的回滚逻辑
-	log.Println("警告: GORM的AutoMigrate不支持自动回滚，请手动处理数据库表结构回滚")
+// PlanDown 获取回滚steps步将要回滚的迁移，按回滚顺序（版本降序）返回，不执行回滚
+func (m *MigrationManager) PlanDown(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, ErrInvalidSteps
+	}
+
+	records, err := m.appliedRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verifyChecksums(records); err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(records))
+	for version := range records {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	if steps < len(versions) {
+		versions = versions[:steps]
+	}
+
+	plan := make([]Migration, 0, len(versions))
+	for _, version := range versions {
+		migration, ok := m.findMigration(version)
+		if !ok {
+			return nil, fmt.Errorf("已应用的迁移%s缺少迁移文件，无法回滚", version)
+		}
+		plan = append(plan, migration)
+	}
+	return plan, nil
+}
+
+// Down 回滚最近steps个已应用的迁移版本，按版本降序逐个回滚
+// GORM的AutoMigrate创建的表结构不在回滚范围内，需要手动处理
+func (m *MigrationManager) Down(ctx context.Context, steps int) error {
+	plan, err := m.PlanDown(ctx, steps)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		log.Println("没有可回滚的迁移版本")
+		return nil
+	}
+
+	for _, migration := range plan {
+		if err := m.RollbackMigration(ctx, migration); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Status 获取迁移状态
-func (m *MigrationManager) Status(ctx context.Context) (interface{}, error) {
+// Status 获取迁移状态，包括数据库连接状态和各迁移版本的应用情况
+func (m *MigrationManager) Status(ctx context.Context) (*StatusReport, error) {
 	// 检查数据库连接状态
 	sqlDB, err := m.db.DB()
 	if err != nil {
@@ -79,9 +283,9 @@ func (m *MigrationManager) Status(ctx context.Context) (interface{}, error) {
 
 	// 测试数据库连接
 	if err := sqlDB.PingContext(ctx); err != nil {
-		return map[string]interface{}{
-			"status": "disconnected",
-			"error":  err.Error(),
+		return &StatusReport{
+			Status: "disconnected",
+			Error:  err.Error(),
 		}, nil
 	}
 
@@ -92,28 +296,69 @@ func (m *MigrationManager) Status(ctx context.Context) (interface{}, error) {
 		return nil, fmt.Errorf("获取数据库表信息失败: %w", err)
 	}
 
-	return map[string]interface{}{
-		"status":      "connected",
-		"tables":      tables,
-		"table_count": len(tables),
+	records, err := m.appliedRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := records[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.Tampered = record.Checksum != "" && record.Checksum != migration.Checksum()
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range records {
+		if _, ok := m.findMigration(version); ok {
+			continue
+		}
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, &MigrationStatus{
+			Version:     version,
+			Description: record.Description,
+			Applied:     true,
+			AppliedAt:   &appliedAt,
+			Missing:     true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return &StatusReport{
+		Status:     "connected",
+		Tables:     tables,
+		Migrations: statuses,
 	}, nil
 }
 
-// Version 获取当前版本
+// Version 获取当前版本，即最近应用的迁移版本
 func (m *MigrationManager) Version(ctx context.Context) (string, error) {
-	// GORM的AutoMigrate不维护版本信息，这里返回当前时间作为版本标识
-	return fmt.Sprintf("gorm-auto-migrate-%s", time.Now().Format("20060102-150405")), nil
+	records, err := m.appliedRecords(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	current := ""
+	for version := range records {
+		if version > current {
+			current = version
+		}
+	}
+	if current == "" {
+		// 没有版本化迁移时只有GORM的AutoMigrate，不维护版本信息，返回当前时间作为版本标识
+		return fmt.Sprintf("gorm-auto-migrate-%s", time.Now().Format("20060102-150405")), nil
+	}
+	return current, nil
 }
 
 // CreateMigrationsTable 创建迁移表
 func (m *MigrationManager) CreateMigrationsTable(ctx context.Context) error {
 	// 创建迁移记录表，用于跟踪迁移历史
-	type MigrationRecord struct {
-		ID        string    `gorm:"primaryKey;type:varchar(36)"`
-		Version   string    `gorm:"type:varchar(50);not null;uniqueIndex"`
-		AppliedAt time.Time `gorm:"type:datetime;not null"`
-	}
-
 	err := m.db.WithContext(ctx).AutoMigrate(&MigrationRecord{})
 	if err != nil {
 		return fmt.Errorf("创建迁移记录表失败: %w", err)
@@ -122,53 +367,59 @@ func (m *MigrationManager) CreateMigrationsTable(ctx context.Context) error {
 	return nil
 }
 
-// GetMigrations 获取所有迁移
+// GetMigrations 获取所有迁移，按版本升序
 func (m *MigrationManager) GetMigrations() []Migration {
-	// 由于使用GORM的AutoMigrate，这里返回一个空的迁移列表
-	// 实际生产环境可能需要更复杂的迁移管理
-	return []Migration{}
+	return m.migrations
 }
 
-// ExecuteMigration 执行单个迁移
+// ExecuteMigration 执行单个迁移并记录
+// MySQL的DDL语句会隐式提交事务，迁移中途失败时已执行的DDL不会回滚，需要手动处理
 func (m *MigrationManager) ExecuteMigration(ctx context.Context, migration Migration) error {
-	// 由于使用GORM的AutoMigrate，这里只是记录日志
 	log.Printf("执行迁移: %s - %s\n", migration.Version, migration.Description)
-	return nil
+
+	if err := m.execStatements(ctx, migration.Up); err != nil {
+		return fmt.Errorf("执行迁移%s失败: %w", migration.Version, err)
+	}
+	return m.RecordMigration(ctx, migration)
 }
 
-// RollbackMigration 回滚单个迁移
+// RollbackMigration 回滚单个迁移并移除迁移记录
 func (m *MigrationManager) RollbackMigration(ctx context.Context, migration Migration) error {
-	// 由于使用GORM的AutoMigrate，这里只是记录日志
-	log.Printf("警告: 无法回滚迁移: %s - %s\n", migration.Version, migration.Description)
-	log.Println("GORM的AutoMigrate不支持自动回滚，请手动处理数据库表结构回滚")
-	return nil
+	if strings.TrimSpace(migration.Down) == "" {
+		return fmt.Errorf("迁移%s缺少回滚SQL，无法回滚", migration.Version)
+	}
+
+	log.Printf("回滚迁移: %s - %s\n", migration.Version, migration.Description)
+
+	if err := m.execStatements(ctx, migration.Down); err != nil {
+		return fmt.Errorf("回滚迁移%s失败: %w", migration.Version, err)
+	}
+	return m.RemoveMigrationRecord(ctx, migration.Version)
 }
 
 // IsMigrationApplied 检查迁移是否已应用
 func (m *MigrationManager) IsMigrationApplied(ctx context.Context, version string) (bool, error) {
-	// 由于使用GORM的AutoMigrate，这里返回true表示已应用
-	// 实际生产环境可能需要查询迁移记录表
-	return true, nil
+	records, err := m.appliedRecords(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, ok := records[version]
+	return ok, nil
 }
 
-// RecordMigration 记录迁移
-func (m *MigrationManager) RecordMigration(ctx context.Context, version string) error {
+// RecordMigration 记录迁移及其校验和
+func (m *MigrationManager) RecordMigration(ctx context.Context, migration Migration) error {
 	// 创建迁移记录表
 	if err := m.CreateMigrationsTable(ctx); err != nil {
 		return err
 	}
 
-	// 记录迁移
-	type MigrationRecord struct {
-		ID        string    `gorm:"primaryKey;type:varchar(36)"`
-		Version   string    `gorm:"type:varchar(50);not null;uniqueIndex"`
-		AppliedAt time.Time `gorm:"type:datetime;not null"`
-	}
-
 	record := MigrationRecord{
-		ID:        fmt.Sprintf("migration-%s", version),
-		Version:   version,
-		AppliedAt: time.Now(),
+		ID:          fmt.Sprintf("migration-%s", migration.Version),
+		Version:     migration.Version,
+		Description: migration.Description,
+		Checksum:    migration.Checksum(),
+		AppliedAt:   time.Now(),
 	}
 
 	err := m.db.WithContext(ctx).Create(&record).Error
@@ -187,12 +438,6 @@ func (m *MigrationManager) RemoveMigrationRecord(ctx context.Context, version st
 	}
 
 	// 移除迁移记录
-	type MigrationRecord struct {
-		ID        string    `gorm:"primaryKey;type:varchar(36)"`
-		Version   string    `gorm:"type:varchar(50);not null;uniqueIndex"`
-		AppliedAt time.Time `gorm:"type:datetime;not null"`
-	}
-
 	err := m.db.WithContext(ctx).Where("version = ?", version).Delete(&MigrationRecord{}).Error
 	if err != nil {
 		return fmt.Errorf("移除迁移记录失败: %w", err)
@@ -200,3 +445,97 @@ func (m *MigrationManager) RemoveMigrationRecord(ctx context.Context, version st
 
 	return nil
 }
+
+// appliedRecords 获取已应用的迁移记录，按版本索引
+func (m *MigrationManager) appliedRecords(ctx context.Context) (map[string]*MigrationRecord, error) {
+	if err := m.CreateMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var records []*MigrationRecord
+	if err := m.db.WithContext(ctx).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+
+	applied := make(map[string]*MigrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// verifyChecksums 校验已应用迁移的文件是否被修改，被修改时告警并返回ErrChecksumMismatch
+// 早期没有记录校验和的迁移不校验
+func (m *MigrationManager) verifyChecksums(records map[string]*MigrationRecord) error {
+	var tampered []string
+	for _, migration := range m.migrations {
+		record, ok := records[migration.Version]
+		if !ok || record.Checksum == "" {
+			continue
+		}
+		if record.Checksum != migration.Checksum() {
+			log.Printf("警告: 迁移%s在应用后被修改，记录的校验和为%s，当前文件校验和为%s\n",
+				migration.Version, record.Checksum, migration.Checksum())
+			tampered = append(tampered, migration.Version)
+		}
+	}
+	if len(tampered) > 0 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(tampered, ", "))
+	}
+	return nil
+}
+
+// findMigration 按版本查找迁移
+func (m *MigrationManager) findMigration(version string) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// execStatements 逐条执行SQL语句
+func (m *MigrationManager) execStatements(ctx context.Context, script string) error {
+	for _, statement := range splitStatements(script) {
+		if err := m.db.WithContext(ctx).Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements 按分号拆分SQL脚本，忽略引号内的分号和以--开头的注释行
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+
+	for _, line := range strings.Split(script, "\n") {
+		if quote == 0 && strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		for _, r := range line {
+			switch {
+			case quote != 0:
+				if r == quote {
+					quote = 0
+				}
+			case r == '\'' || r == '"' || r == '`':
+				quote = r
+			case r == ';':
+				if statement := strings.TrimSpace(current.String()); statement != "" {
+					statements = append(statements, statement)
+				}
+				current.Reset()
+				continue
+			}
+			current.WriteRune(r)
+		}
+		current.WriteRune('\n')
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/pkg/errs"
 	"reimbursement-audit/internal/pkg/logger"
 
 	"gorm.io/gorm"
@@ -64,7 +65,7 @@ func (r *OCRRepository) GetInvoiceByID(ctx context.Context, id string) (*ocr.Inv
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			r.logger.WithContext(ctx).Warn("发票不存在",
 				logger.NewField("invoice_id", id))
-			return nil, result.Error
+			return nil, errs.NotFound("发票不存在")
 		}
 		r.logger.WithContext(ctx).Error("查询发票失败",
 			logger.NewField("error", result.Error.Error()),
@@ -81,28 +82,35 @@ func (r *OCRRepository) UpdateInvoice(ctx context.Context, invoice *ocr.Invoice)
 	result := r.client.GetDB().WithContext(ctx).Model(invoice).
 		Where("id = ?", invoice.ID).
 		Updates(map[string]interface{}{
-			"reimbursement_id": invoice.ReimbursementID,
-			"type":             invoice.Type,
-			"code":             invoice.Code,
-			"number":           invoice.Number,
-			"date":             invoice.Date,
-			"amount":           invoice.Amount,
-			"tax_amount":       invoice.TaxAmount,
-			"payer":            invoice.Payer,
-			"payee":            invoice.Payee,
-			"buyer_name":       invoice.BuyerName,
-			"buyer_tax_no":     invoice.BuyerTaxNo,
-			"seller_name":      invoice.SellerName,
-			"seller_tax_no":    invoice.SellerTaxNo,
-			"commodity_name":   invoice.CommodityName,
-			"specification":    invoice.Specification,
-			"unit":             invoice.Unit,
-			"quantity":         invoice.Quantity,
-			"price":            invoice.Price,
-			"image_path":       invoice.ImagePath,
-			"ocr_result":       invoice.OCRResult,
-			"status":           invoice.Status,
-			"updated_at":       invoice.UpdatedAt,
+			"reimbursement_id":  invoice.ReimbursementID,
+			"type":              invoice.Type,
+			"code":              invoice.Code,
+			"number":            invoice.Number,
+			"date":              invoice.Date,
+			"amount":            invoice.Amount,
+			"tax_amount":        invoice.TaxAmount,
+			"payer":             invoice.Payer,
+			"payee":             invoice.Payee,
+			"buyer_name":        invoice.BuyerName,
+			"buyer_tax_no":      invoice.BuyerTaxNo,
+			"seller_name":       invoice.SellerName,
+			"seller_tax_no":     invoice.SellerTaxNo,
+			"commodity_name":    invoice.CommodityName,
+			"specification":     invoice.Specification,
+			"unit":              invoice.Unit,
+			"quantity":          invoice.Quantity,
+			"price":             invoice.Price,
+			"vat_rate":          invoice.VATRate,
+			"is_vat":            invoice.IsVAT,
+			"is_electronic":     invoice.IsElectronic,
+			"remarks":           invoice.Remarks,
+			"image_path":        invoice.ImagePath,
+			"ocr_result":        invoice.OCRResult,
+			"field_boxes":       invoice.FieldBoxes,
+			"field_confidences": invoice.FieldConfidences,
+			"status":            invoice.Status,
+			"missing_fields":    invoice.MissingFields,
+			"updated_at":        invoice.UpdatedAt,
 		})
 
 	if result.Error != nil {
@@ -136,7 +144,7 @@ func (r *OCRRepository) DeleteInvoice(ctx context.Context, id string) error {
 	if result.RowsAffected == 0 {
 		r.logger.WithContext(ctx).Warn("发票不存在，删除失败",
 			logger.NewField("invoice_id", id))
-		return result.Error
+		return errs.NotFound("发票不存在")
 	}
 
 	return nil
@@ -161,3 +169,179 @@ func (r *OCRRepository) ListInvoicesByReimbursementID(ctx context.Context, reimb
 
 	return invoices, nil
 }
+
+// ListInvoicesByReimbursementIDs 批量查询多个报销单的发票，一次查询按报销单ID分组返回
+func (r *OCRRepository) ListInvoicesByReimbursementIDs(ctx context.Context, reimbursementIDs []string) (map[string][]*ocr.Invoice, error) {
+	grouped := make(map[string][]*ocr.Invoice, len(reimbursementIDs))
+	if len(reimbursementIDs) == 0 {
+		return grouped, nil
+	}
+
+	var invoices []*ocr.Invoice
+	result := r.client.GetDB().WithContext(ctx).
+		Where("reimbursement_id IN ?", reimbursementIDs).
+		Order("created_at ASC").
+		Find(&invoices)
+
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("批量获取发票列表失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("reimbursement_count", len(reimbursementIDs)))
+		return nil, result.Error
+	}
+
+	for _, invoice := range invoices {
+		grouped[invoice.ReimbursementID] = append(grouped[invoice.ReimbursementID], invoice)
+	}
+	return grouped, nil
+}
+
+// CountInvoicesByReimbursementIDs 批量统计多个报销单的发票数量，一次分组查询完成
+func (r *OCRRepository) CountInvoicesByReimbursementIDs(ctx context.Context, reimbursementIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(reimbursementIDs))
+	if len(reimbursementIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ReimbursementID string
+		InvoiceCount    int
+	}
+	result := r.client.GetDB().WithContext(ctx).
+		Model(&ocr.Invoice{}).
+		Select("reimbursement_id, COUNT(*) AS invoice_count").
+		Where("reimbursement_id IN ?", reimbursementIDs).
+		Group("reimbursement_id").
+		Scan(&rows)
+
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("批量统计发票数量失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("reimbursement_count", len(reimbursementIDs)))
+		return nil, result.Error
+	}
+
+	for _, row := range rows {
+		counts[row.ReimbursementID] = row.InvoiceCount
+	}
+	return counts, nil
+}
+
+// SumInvoiceAmountsByCategory 按发票类别分组汇总报销单的发票金额，一次分组查询完成
+// 优先按子类别分组，无子类别时使用类别，均为空时归入未分类，与ocr.BreakdownCategory一致
+func (r *OCRRepository) SumInvoiceAmountsByCategory(ctx context.Context, reimbursementID string) ([]*ocr.CategoryAmount, error) {
+	var rows []struct {
+		BreakdownCategory string
+		Amount            float64
+		InvoiceCount      int
+	}
+	result := r.client.GetDB().WithContext(ctx).
+		Model(&ocr.Invoice{}).
+		Select("COALESCE(NULLIF(TRIM(sub_category), ''), NULLIF(TRIM(category), ''), ?) AS breakdown_category, SUM(amount) AS amount, COUNT(*) AS invoice_count",
+			ocr.UncategorizedInvoiceCategory).
+		Where("reimbursement_id = ?", reimbursementID).
+		Group("breakdown_category").
+		Scan(&rows)
+
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("按类别汇总发票金额失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("reimbursement_id", reimbursementID))
+		return nil, result.Error
+	}
+
+	breakdown := make([]*ocr.CategoryAmount, 0, len(rows))
+	for _, row := range rows {
+		breakdown = append(breakdown, &ocr.CategoryAmount{
+			Category:     row.BreakdownCategory,
+			Amount:       row.Amount,
+			InvoiceCount: row.InvoiceCount,
+		})
+	}
+	return ocr.FinalizeAmountBreakdown(breakdown), nil
+}
+
+// ListInvoicesByCodeAndNumber 按发票代码和号码查询发票
+func (r *OCRRepository) ListInvoicesByCodeAndNumber(ctx context.Context, code, number string, reimbursementStatuses []string) ([]*ocr.Invoice, error) {
+	var invoices []*ocr.Invoice
+
+	db := r.client.GetDB().WithContext(ctx).
+		Model(&ocr.Invoice{}).
+		Select("invoices.*").
+		Joins("JOIN reimbursements ON reimbursements.id = invoices.reimbursement_id").
+		Where("invoices.code = ? AND invoices.number = ?", code, number)
+
+	if len(reimbursementStatuses) > 0 {
+		db = db.Where("reimbursements.status IN ?", reimbursementStatuses)
+	}
+
+	result := db.Order("invoices.created_at ASC").Find(&invoices)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("按代码和号码查询发票失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("invoice_code", code),
+			logger.NewField("invoice_number", number))
+		return nil, result.Error
+	}
+
+	return invoices, nil
+}
+
+// ListInvoicesByStatus 按状态查询发票，最早更新的发票排在前面
+func (r *OCRRepository) ListInvoicesByStatus(ctx context.Context, status string, limit int) ([]*ocr.Invoice, error) {
+	var invoices []*ocr.Invoice
+
+	result := r.client.GetDB().WithContext(ctx).
+		Where("status = ?", status).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&invoices)
+
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("按状态查询发票失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("status", status))
+		return nil, result.Error
+	}
+
+	return invoices, nil
+}
+
+// ReplaceInvoiceItems 替换发票的全部商品明细
+func (r *OCRRepository) ReplaceInvoiceItems(ctx context.Context, invoiceID string, items []*ocr.InvoiceItem) error {
+	err := r.client.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("invoice_id = ?", invoiceID).Delete(&ocr.InvoiceItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(items).Error
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Error("保存发票商品明细失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("invoice_id", invoiceID))
+		return err
+	}
+
+	return nil
+}
+
+// ListInvoiceItems 查询发票的商品明细，按行号排序
+func (r *OCRRepository) ListInvoiceItems(ctx context.Context, invoiceID string) ([]*ocr.InvoiceItem, error) {
+	var items []*ocr.InvoiceItem
+
+	result := r.client.GetDB().WithContext(ctx).
+		Where("invoice_id = ?", invoiceID).
+		Order("line_no ASC").
+		Find(&items)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("查询发票商品明细失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("invoice_id", invoiceID))
+		return nil, result.Error
+	}
+
+	return items, nil
+}
@@ -0,0 +1,69 @@
+// prompt_template_repository.go MySQL Prompt模板仓储实现
+// 功能点：
+// 1. 实现Prompt模板仓储接口
+// 2. 支持模板查询和保存
+
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"reimbursement-audit/internal/domain/rag"
+	"reimbursement-audit/internal/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// PromptTemplateRepository Prompt模板仓储实现
+type PromptTemplateRepository struct {
+	client *Client
+	logger logger.Logger
+}
+
+// NewPromptTemplateRepository 创建Prompt模板仓储实例
+func NewPromptTemplateRepository(client *Client, logger logger.Logger) rag.PromptTemplateRepository {
+	return &PromptTemplateRepository{client: client, logger: logger}
+}
+
+// ListPromptTemplates 获取所有启用的Prompt模板
+func (r *PromptTemplateRepository) ListPromptTemplates(ctx context.Context) ([]*rag.PromptTemplate, error) {
+	var templates []*rag.PromptTemplate
+
+	result := r.client.GetDB().WithContext(ctx).
+		Where("enabled = ?", true).
+		Order("updated_at ASC").
+		Find(&templates)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("查询Prompt模板失败",
+			logger.NewField("error", result.Error.Error()))
+		return nil, result.Error
+	}
+
+	return templates, nil
+}
+
+// SavePromptTemplate 保存Prompt模板（按名称和类型去重）
+func (r *PromptTemplateRepository) SavePromptTemplate(ctx context.Context, tpl *rag.PromptTemplate) error {
+	now := time.Now()
+	if tpl.ID == "" {
+		tpl.ID = uuid.New().String()
+		tpl.CreatedAt = now
+	}
+	tpl.UpdatedAt = now
+
+	result := r.client.GetDB().WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content", "enabled", "updated_by", "updated_at"}),
+	}).Create(tpl)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("保存Prompt模板失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("name", tpl.Name),
+			logger.NewField("type", tpl.Type))
+		return result.Error
+	}
+
+	return nil
+}
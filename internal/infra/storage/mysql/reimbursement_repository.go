@@ -6,6 +6,10 @@
 // 4. 提供MySQL数据访问实现
 // 5. 支持事务管理
 // 6. 支持查询和分页
+// 7. 报销单标签单独存储，支持按标签筛选
+// 8. 按用户查询近期报销单，用于周期性报销和频次校验
+// 9. 更新报销单时按状态机校验状态流转，非法转移不写入
+// 10. 报销单列表按用户、状态、部门、标签、申请日期范围、关键词组合筛选，各单条件查询复用组合查询
 
 package mysql
 
@@ -14,7 +18,10 @@ import (
 	"errors"
 	"time"
 
+	"github.com/google/uuid"
+
 	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/pkg/errs"
 	"reimbursement-audit/internal/pkg/logger"
 
 	"gorm.io/gorm"
@@ -33,13 +40,21 @@ func NewReimbursementRepository(client *Client, logger logger.Logger) reimbursem
 
 // CreateReimbursement 创建报销单
 func (r *ReimbursementRepository) CreateReimbursement(ctx context.Context, reimbursement *reimbursement.Reimbursement) error {
-	// 使用GORM创建报销单记录
-	result := r.client.GetDB().WithContext(ctx).Create(reimbursement)
-	if result.Error != nil {
+	// 使用GORM创建报销单记录，有标签时在同一事务中保存
+	err := r.client.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reimbursement).Error; err != nil {
+			return err
+		}
+		if len(reimbursement.Tags) == 0 {
+			return nil
+		}
+		return tx.Create(newTagRecords(reimbursement.ID, reimbursement.Tags)).Error
+	})
+	if err != nil {
 		r.logger.WithContext(ctx).Error("创建报销单失败",
-			logger.NewField("error", result.Error.Error()),
+			logger.NewField("error", err.Error()),
 			logger.NewField("user_id", reimbursement.UserID))
-		return result.Error
+		return err
 	}
 
 	return nil
@@ -50,12 +65,12 @@ func (r *ReimbursementRepository) GetReimbursementByID(ctx context.Context, id s
 	var reimbursement reimbursement.Reimbursement
 
 	// 使用GORM查询报销单
-	result := r.client.GetDB().WithContext(ctx).Where("id = ?", id).First(&reimbursement)
+	result := r.client.DB(ctx).Where("id = ?", id).First(&reimbursement)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			r.logger.WithContext(ctx).Warn("报销单不存在",
 				logger.NewField("reimbursement_id", id))
-			return nil, result.Error
+			return nil, errs.NotFound("报销单不存在")
 		}
 		r.logger.WithContext(ctx).Error("获取报销单失败",
 			logger.NewField("error", result.Error.Error()),
@@ -66,49 +81,74 @@ func (r *ReimbursementRepository) GetReimbursementByID(ctx context.Context, id s
 	// 不在此处加载发票列表，保持聚合根的独立性
 	// 发票列表应由应用服务在需要时通过OCRRepository单独加载
 
+	if err := r.loadTags(ctx, &reimbursement); err != nil {
+		return nil, err
+	}
+
 	return &reimbursement, nil
 }
 
 // UpdateReimbursement 更新报销单
-func (r *ReimbursementRepository) UpdateReimbursement(ctx context.Context, reimbursement *reimbursement.Reimbursement) error {
+// 只有当前状态可以转移到目标状态时才更新（条件更新），并发更新或直接写入非法状态都会被拒绝
+func (r *ReimbursementRepository) UpdateReimbursement(ctx context.Context, reim *reimbursement.Reimbursement) error {
+	allowedStatuses := reimbursement.StatusPredecessors(reim.Status)
+	if len(allowedStatuses) == 0 {
+		return reimbursement.ValidateStatusTransition("", reim.Status)
+	}
+
 	// 使用GORM更新报销单
-	result := r.client.GetDB().WithContext(ctx).Model(reimbursement).
-		Where("id = ?", reimbursement.ID).
+	result := r.client.DB(ctx).Model(reim).
+		Where("id = ? AND status IN ?", reim.ID, allowedStatuses).
 		Updates(map[string]interface{}{
-			"user_id":      reimbursement.UserID,
-			"user_name":    reimbursement.UserName,
-			"department":   reimbursement.Department,
-			"type":         reimbursement.Type,
-			"title":        reimbursement.Title,
-			"description":  reimbursement.Description,
-			"total_amount": reimbursement.TotalAmount,
-			"currency":     reimbursement.Currency,
-			"apply_date":   reimbursement.ApplyDate,
-			"expense_date": reimbursement.ExpenseDate,
-			"status":       reimbursement.Status,
+			"user_id":      reim.UserID,
+			"user_name":    reim.UserName,
+			"department":   reim.Department,
+			"type":         reim.Type,
+			"title":        reim.Title,
+			"description":  reim.Description,
+			"total_amount": reim.TotalAmount,
+			"currency":     reim.Currency,
+			"apply_date":   reim.ApplyDate,
+			"expense_date": reim.ExpenseDate,
+			"status":       reim.Status,
 			"updated_at":   time.Now(),
 		})
 
 	if result.Error != nil {
 		r.logger.WithContext(ctx).Error("更新报销单失败",
 			logger.NewField("error", result.Error.Error()),
-			logger.NewField("reimbursement_id", reimbursement.ID))
+			logger.NewField("reimbursement_id", reim.ID))
 		return result.Error
 	}
 
 	if result.RowsAffected == 0 {
-		r.logger.WithContext(ctx).Warn("报销单不存在，更新失败",
-			logger.NewField("reimbursement_id", reimbursement.ID))
-		return result.Error
+		return r.statusTransitionError(ctx, reim.ID, reim.Status)
 	}
 
 	return nil
 }
 
+// statusTransitionError 条件更新未命中时确定原因：报销单不存在，或当前状态不能转移到目标状态
+func (r *ReimbursementRepository) statusTransitionError(ctx context.Context, id, status string) error {
+	current, err := r.GetReimbursementByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := reimbursement.ValidateStatusTransition(current.Status, status); err != nil {
+		r.logger.WithContext(ctx).Warn("报销单状态流转不合法，更新失败",
+			logger.NewField("reimbursement_id", id),
+			logger.NewField("from", current.Status),
+			logger.NewField("to", status))
+		return err
+	}
+	// 条件更新后状态又被其它请求修改，由调用方重新读取后重试
+	return errors.New("报销单状态已变更，请重试")
+}
+
 // DeleteReimbursement 删除报销单
 func (r *ReimbursementRepository) DeleteReimbursement(ctx context.Context, id string) error {
 	// 使用GORM删除报销单
-	result := r.client.GetDB().WithContext(ctx).Where("id = ?", id).Delete(&reimbursement.Reimbursement{})
+	result := r.client.DB(ctx).Where("id = ?", id).Delete(&reimbursement.Reimbursement{})
 
 	if result.Error != nil {
 		r.logger.WithContext(ctx).Error("删除报销单失败",
@@ -120,7 +160,7 @@ func (r *ReimbursementRepository) DeleteReimbursement(ctx context.Context, id st
 	if result.RowsAffected == 0 {
 		r.logger.WithContext(ctx).Warn("报销单不存在，删除失败",
 			logger.NewField("reimbursement_id", id))
-		return result.Error
+		return errs.NotFound("报销单不存在")
 	}
 
 	return nil
@@ -131,7 +171,7 @@ func (r *ReimbursementRepository) GetReimbursementsByUserID(ctx context.Context,
 	var reimbursements []*reimbursement.Reimbursement
 
 	// 使用GORM查询报销单列表
-	result := r.client.GetDB().WithContext(ctx).
+	result := r.client.DB(ctx).
 		Where("user_id = ?", userID).
 		Limit(limit).
 		Offset(offset).
@@ -152,160 +192,184 @@ func (r *ReimbursementRepository) GetReimbursementsByUserID(ctx context.Context,
 
 // ListReimbursementsByUserID 根据用户ID获取报销单列表
 func (r *ReimbursementRepository) ListReimbursementsByUserID(ctx context.Context, userID string, page, size int) ([]*reimbursement.Reimbursement, int64, error) {
-	// 获取总数
-	var total int64
-	countResult := r.client.GetDB().WithContext(ctx).Model(&reimbursement.Reimbursement{}).Where("user_id = ?", userID).Count(&total)
-	if countResult.Error != nil {
-		r.logger.WithContext(ctx).Error("获取报销单总数失败",
-			logger.NewField("error", countResult.Error.Error()),
-			logger.NewField("user_id", userID))
-		return nil, 0, countResult.Error
-	}
-
-	// 获取分页数据
-	offset := (page - 1) * size
-	var reimbursements []*reimbursement.Reimbursement
-	result := r.client.GetDB().WithContext(ctx).
-		Where("user_id = ?", userID).
-		Order("created_at DESC").
-		Limit(size).
-		Offset(offset).
-		Find(&reimbursements)
+	return r.ListReimbursements(ctx, &reimbursement.ReimbursementFilter{UserID: userID, Page: page, Size: size})
+}
 
-	if result.Error != nil {
-		r.logger.WithContext(ctx).Error("获取报销单列表失败",
-			logger.NewField("error", result.Error.Error()),
-			logger.NewField("user_id", userID),
-			logger.NewField("page", page),
-			logger.NewField("size", size))
-		return nil, 0, result.Error
-	}
+// ListReimbursementsByDateRange 根据申请日期范围获取报销单列表，按申请日期降序
+func (r *ReimbursementRepository) ListReimbursementsByDateRange(ctx context.Context, startDate, endDate string, page, size int) ([]*reimbursement.Reimbursement, int64, error) {
+	return r.ListReimbursements(ctx, &reimbursement.ReimbursementFilter{
+		StartDate: startDate,
+		EndDate:   endDate,
+		SortBy:    "apply_date",
+		SortOrder: reimbursement.SortDesc,
+		Page:      page,
+		Size:      size,
+	})
+}
 
-	// 不在此处加载发票列表，保持聚合根的独立性
-	// 发票列表应由应用服务在需要时通过OCRRepository单独加载
+// ListReimbursementsByStatus 根据状态获取报销单列表
+func (r *ReimbursementRepository) ListReimbursementsByStatus(ctx context.Context, status string, page, size int) ([]*reimbursement.Reimbursement, int64, error) {
+	return r.ListReimbursements(ctx, &reimbursement.ReimbursementFilter{Status: status, Page: page, Size: size})
+}
 
-	return reimbursements, total, nil
+// SearchReimbursements 按关键词搜索报销单，匹配申请人姓名、标题和描述
+func (r *ReimbursementRepository) SearchReimbursements(ctx context.Context, keyword string, page, size int) ([]*reimbursement.Reimbursement, int64, error) {
+	return r.ListReimbursements(ctx, &reimbursement.ReimbursementFilter{Keyword: keyword, Page: page, Size: size})
 }
 
-// ListReimbursementsByDateRange 根据日期范围获取报销单列表
-func (r *ReimbursementRepository) ListReimbursementsByDateRange(ctx context.Context, startDate, endDate string, page, size int) ([]*reimbursement.Reimbursement, int64, error) {
-	// 获取总数
+// ListReimbursements 按组合条件分页查询报销单，各条件为空时不限制
+// 按标签筛选时只返回带有该标签的报销单，日期范围按申请日期筛选(含首尾)
+func (r *ReimbursementRepository) ListReimbursements(ctx context.Context, filter *reimbursement.ReimbursementFilter) ([]*reimbursement.Reimbursement, int64, error) {
+	var reimbursements []*reimbursement.Reimbursement
 	var total int64
-	countResult := r.client.GetDB().WithContext(ctx).Model(&reimbursement.Reimbursement{}).
-		Where("apply_date BETWEEN ? AND ?", startDate, endDate).
-		Count(&total)
 
-	if countResult.Error != nil {
+	db := r.applyFilter(r.client.DB(ctx).Model(&reimbursement.Reimbursement{}), filter)
+
+	// 获取总数
+	if err := db.Count(&total).Error; err != nil {
 		r.logger.WithContext(ctx).Error("获取报销单总数失败",
-			logger.NewField("error", countResult.Error.Error()),
-			logger.NewField("start_date", startDate),
-			logger.NewField("end_date", endDate))
-		return nil, 0, countResult.Error
+			logger.NewField("error", err.Error()))
+		return nil, 0, err
 	}
 
-	// 获取分页数据
-	offset := (page - 1) * size
-	var reimbursements []*reimbursement.Reimbursement
-	result := r.client.GetDB().WithContext(ctx).
-		Where("apply_date BETWEEN ? AND ?", startDate, endDate).
-		Order("apply_date DESC").
-		Limit(size).
-		Offset(offset).
-		Find(&reimbursements)
+	// 应用分页
+	if filter != nil && filter.Page > 0 && filter.Size > 0 {
+		offset := (filter.Page - 1) * filter.Size
+		db = db.Offset(offset).Limit(filter.Size)
+	}
 
-	if result.Error != nil {
+	if err := db.Order(filter.OrderClause()).Find(&reimbursements).Error; err != nil {
 		r.logger.WithContext(ctx).Error("获取报销单列表失败",
-			logger.NewField("error", result.Error.Error()),
-			logger.NewField("start_date", startDate),
-			logger.NewField("end_date", endDate),
-			logger.NewField("page", page),
-			logger.NewField("size", size))
-		return nil, 0, result.Error
+			logger.NewField("error", err.Error()))
+		return nil, 0, err
 	}
 
-	// 不在此处加载发票列表，保持聚合根的独立性
-	// 发票列表应由应用服务在需要时通过OCRRepository单独加载
+	if err := r.loadTags(ctx, reimbursements...); err != nil {
+		return nil, 0, err
+	}
 
 	return reimbursements, total, nil
 }
 
-// ListReimbursementsByStatus 根据状态获取报销单列表
-func (r *ReimbursementRepository) ListReimbursementsByStatus(ctx context.Context, status string, page, size int) ([]*reimbursement.Reimbursement, int64, error) {
-	// 获取总数
-	var total int64
-	countResult := r.client.GetDB().WithContext(ctx).Model(&reimbursement.Reimbursement{}).
-		Where("status = ?", status).
-		Count(&total)
+// applyFilter 将查询条件拼接到查询上
+func (r *ReimbursementRepository) applyFilter(db *gorm.DB, filter *reimbursement.ReimbursementFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	if filter.UserID != "" {
+		db = db.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.Department != "" {
+		db = db.Where("department = ?", filter.Department)
+	}
+	if filter.Tag != "" {
+		db = db.Where("id IN (?)", r.client.GetDB().Model(&reimbursement.ReimbursementTag{}).
+			Select("reimbursement_id").
+			Where("tag = ?", filter.Tag))
+	}
+	if filter.StartDate != "" {
+		db = db.Where("apply_date >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		db = db.Where("apply_date <= ?", filter.EndDate)
+	}
+	if filter.Keyword != "" {
+		searchPattern := "%" + filter.Keyword + "%"
+		db = db.Where("(user_name LIKE ? OR title LIKE ? OR description LIKE ?)", searchPattern, searchPattern, searchPattern)
+	}
+	return db
+}
 
-	if countResult.Error != nil {
-		r.logger.WithContext(ctx).Error("获取报销单总数失败",
-			logger.NewField("error", countResult.Error.Error()),
-			logger.NewField("status", status))
-		return nil, 0, countResult.Error
+// ListUserReimbursementsSince 获取用户申请日期不早于since的报销单，statuses为空时不限状态
+func (r *ReimbursementRepository) ListUserReimbursementsSince(ctx context.Context, userID string, since time.Time, statuses []string) ([]*reimbursement.Reimbursement, error) {
+	db := r.client.DB(ctx).
+		Where("user_id = ? AND apply_date >= ?", userID, since)
+	if len(statuses) > 0 {
+		db = db.Where("status IN ?", statuses)
 	}
 
-	// 获取分页数据
-	offset := (page - 1) * size
 	var reimbursements []*reimbursement.Reimbursement
-	result := r.client.GetDB().WithContext(ctx).
-		Where("status = ?", status).
-		Order("created_at DESC").
-		Limit(size).
-		Offset(offset).
-		Find(&reimbursements)
-
-	if result.Error != nil {
-		r.logger.WithContext(ctx).Error("获取报销单列表失败",
-			logger.NewField("error", result.Error.Error()),
-			logger.NewField("status", status),
-			logger.NewField("page", page),
-			logger.NewField("size", size))
-		return nil, 0, result.Error
+	if err := db.Order("apply_date DESC").Find(&reimbursements).Error; err != nil {
+		r.logger.WithContext(ctx).Error("查询用户近期报销单失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("user_id", userID),
+			logger.NewField("since", since))
+		return nil, err
 	}
 
-	// 不在此处加载发票列表，保持聚合根的独立性
-	// 发票列表应由应用服务在需要时通过OCRRepository单独加载
-
-	return reimbursements, total, nil
+	return reimbursements, nil
 }
 
-// SearchReimbursements 搜索报销单
-func (r *ReimbursementRepository) SearchReimbursements(ctx context.Context, keyword string, page, size int) ([]*reimbursement.Reimbursement, int64, error) {
-	// 获取总数
-	var total int64
-	searchPattern := "%" + keyword + "%"
-	countResult := r.client.GetDB().WithContext(ctx).Model(&reimbursement.Reimbursement{}).
-		Where("user_name LIKE ? OR title LIKE ? OR description LIKE ?", searchPattern, searchPattern, searchPattern).
-		Count(&total)
+// ReplaceTags 替换报销单的全部标签
+func (r *ReimbursementRepository) ReplaceTags(ctx context.Context, reimbursementID string, tags []string) error {
+	err := r.client.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reimbursement_id = ?", reimbursementID).Delete(&reimbursement.ReimbursementTag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		return tx.Create(newTagRecords(reimbursementID, tags)).Error
+	})
+	if err != nil {
+		r.logger.WithContext(ctx).Error("保存报销单标签失败",
+			logger.NewField("error", err.Error()),
+			logger.NewField("reimbursement_id", reimbursementID))
+		return err
+	}
 
-	if countResult.Error != nil {
-		r.logger.WithContext(ctx).Error("获取报销单总数失败",
-			logger.NewField("error", countResult.Error.Error()),
-			logger.NewField("keyword", keyword))
-		return nil, 0, countResult.Error
+	return nil
+}
+
+// loadTags 批量加载报销单标签
+func (r *ReimbursementRepository) loadTags(ctx context.Context, reimbursements ...*reimbursement.Reimbursement) error {
+	if len(reimbursements) == 0 {
+		return nil
 	}
 
-	// 获取分页数据
-	offset := (page - 1) * size
-	var reimbursements []*reimbursement.Reimbursement
-	result := r.client.GetDB().WithContext(ctx).
-		Where("user_name LIKE ? OR title LIKE ? OR description LIKE ?", searchPattern, searchPattern, searchPattern).
-		Order("created_at DESC").
-		Limit(size).
-		Offset(offset).
-		Find(&reimbursements)
+	ids := make([]string, 0, len(reimbursements))
+	for _, item := range reimbursements {
+		ids = append(ids, item.ID)
+	}
 
-	if result.Error != nil {
-		r.logger.WithContext(ctx).Error("获取报销单列表失败",
-			logger.NewField("error", result.Error.Error()),
-			logger.NewField("keyword", keyword),
-			logger.NewField("page", page),
-			logger.NewField("size", size))
-		return nil, 0, result.Error
+	var records []*reimbursement.ReimbursementTag
+	err := r.client.DB(ctx).
+		Where("reimbursement_id IN ?", ids).
+		Order("created_at ASC, tag ASC").
+		Find(&records).Error
+	if err != nil {
+		r.logger.WithContext(ctx).Error("获取报销单标签失败",
+			logger.NewField("error", err.Error()))
+		return err
 	}
 
-	// 不在此处加载发票列表，保持聚合根的独立性
-	// 发票列表应由应用服务在需要时通过OCRRepository单独加载
+	tagsByID := make(map[string][]string, len(reimbursements))
+	for _, record := range records {
+		tagsByID[record.ReimbursementID] = append(tagsByID[record.ReimbursementID], record.Tag)
+	}
+	for _, item := range reimbursements {
+		item.Tags = tagsByID[item.ID]
+		if item.Tags == nil {
+			item.Tags = []string{}
+		}
+	}
+	return nil
+}
 
-	return reimbursements, total, nil
+// newTagRecords 创建报销单标签记录
+func newTagRecords(reimbursementID string, tags []string) []*reimbursement.ReimbursementTag {
+	now := time.Now()
+	records := make([]*reimbursement.ReimbursementTag, 0, len(tags))
+	for _, tag := range tags {
+		records = append(records, &reimbursement.ReimbursementTag{
+			ID:              uuid.New().String(),
+			ReimbursementID: reimbursementID,
+			Tag:             tag,
+			CreatedAt:       now,
+		})
+	}
+	return records
 }
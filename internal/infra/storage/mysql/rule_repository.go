@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"reimbursement-audit/internal/domain/rule"
+	"reimbursement-audit/internal/pkg/errs"
 	"reimbursement-audit/internal/pkg/logger"
 
 	"gorm.io/gorm"
@@ -81,7 +82,7 @@ func (r *RuleRepository) GetRuleByID(ctx context.Context, id string) (*rule.Rule
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			r.logger.WithContext(ctx).Warn("规则不存在",
 				logger.NewField("rule_id", id))
-			return nil, result.Error
+			return nil, errs.NotFound("规则不存在")
 		}
 		r.logger.WithContext(ctx).Error("获取规则失败",
 			logger.NewField("error", result.Error.Error()),
@@ -102,7 +103,7 @@ func (r *RuleRepository) GetRuleByCode(ctx context.Context, ruleCode string) (*r
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			r.logger.WithContext(ctx).Warn("规则不存在",
 				logger.NewField("rule_code", ruleCode))
-			return nil, result.Error
+			return nil, errs.NotFound("规则不存在")
 		}
 		r.logger.WithContext(ctx).Error("获取规则失败",
 			logger.NewField("error", result.Error.Error()),
@@ -145,7 +146,7 @@ func (r *RuleRepository) UpdateRule(ctx context.Context, rule *rule.Rule) error
 	if result.RowsAffected == 0 {
 		r.logger.WithContext(ctx).Warn("规则不存在，更新失败",
 			logger.NewField("rule_id", rule.ID))
-		return errors.New("规则不存在")
+		return errs.NotFound("规则不存在")
 	}
 
 	r.logger.WithContext(ctx).Info("更新规则成功",
@@ -169,7 +170,7 @@ func (r *RuleRepository) DeleteRule(ctx context.Context, id string) error {
 	if result.RowsAffected == 0 {
 		r.logger.WithContext(ctx).Warn("规则不存在，删除失败",
 			logger.NewField("rule_id", id))
-		return errors.New("规则不存在")
+		return errs.NotFound("规则不存在")
 	}
 
 	r.logger.WithContext(ctx).Info("删除规则成功",
@@ -292,7 +293,7 @@ func (r *RuleRepository) EnableRule(ctx context.Context, id string) error {
 	if result.RowsAffected == 0 {
 		r.logger.WithContext(ctx).Warn("规则不存在，启用失败",
 			logger.NewField("rule_id", id))
-		return errors.New("规则不存在")
+		return errs.NotFound("规则不存在")
 	}
 
 	r.logger.WithContext(ctx).Info("启用规则成功",
@@ -322,7 +323,7 @@ func (r *RuleRepository) DisableRule(ctx context.Context, id string) error {
 	if result.RowsAffected == 0 {
 		r.logger.WithContext(ctx).Warn("规则不存在，禁用失败",
 			logger.NewField("rule_id", id))
-		return errors.New("规则不存在")
+		return errs.NotFound("规则不存在")
 	}
 
 	r.logger.WithContext(ctx).Info("禁用规则成功",
@@ -0,0 +1,74 @@
+// seller_blacklist_repository.go MySQL销售方黑名单仓储实现
+// 功能点：
+// 1. 实现销售方黑名单仓储接口
+// 2. 查询、新增和删除黑名单条目
+
+package mysql
+
+import (
+	"context"
+
+	"reimbursement-audit/internal/domain/rule"
+	"reimbursement-audit/internal/pkg/errs"
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// SellerBlacklistRepository 销售方黑名单仓储实现
+type SellerBlacklistRepository struct {
+	client *Client
+	logger logger.Logger
+}
+
+// NewSellerBlacklistRepository 创建销售方黑名单仓储实例
+func NewSellerBlacklistRepository(client *Client, logger logger.Logger) rule.SellerBlacklistRepository {
+	return &SellerBlacklistRepository{client: client, logger: logger}
+}
+
+// ListSellerBlacklist 获取全部黑名单条目，按创建时间倒序
+func (r *SellerBlacklistRepository) ListSellerBlacklist(ctx context.Context) ([]*rule.SellerBlacklistEntry, error) {
+	var entries []*rule.SellerBlacklistEntry
+
+	result := r.client.GetDB().WithContext(ctx).
+		Order("created_at DESC").
+		Find(&entries)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("查询销售方黑名单失败",
+			logger.NewField("error", result.Error.Error()))
+		return nil, result.Error
+	}
+
+	return entries, nil
+}
+
+// CreateSellerBlacklistEntry 新增黑名单条目
+func (r *SellerBlacklistRepository) CreateSellerBlacklistEntry(ctx context.Context, entry *rule.SellerBlacklistEntry) error {
+	result := r.client.GetDB().WithContext(ctx).Create(entry)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("新增销售方黑名单失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("seller_name", entry.SellerName),
+			logger.NewField("seller_tax_no", entry.SellerTaxNo))
+		return result.Error
+	}
+
+	return nil
+}
+
+// DeleteSellerBlacklistEntry 删除黑名单条目
+func (r *SellerBlacklistRepository) DeleteSellerBlacklistEntry(ctx context.Context, id string) error {
+	result := r.client.GetDB().WithContext(ctx).Delete(&rule.SellerBlacklistEntry{}, "id = ?", id)
+	if result.Error != nil {
+		r.logger.WithContext(ctx).Error("删除销售方黑名单失败",
+			logger.NewField("error", result.Error.Error()),
+			logger.NewField("entry_id", id))
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		r.logger.WithContext(ctx).Warn("黑名单条目不存在，删除失败",
+			logger.NewField("entry_id", id))
+		return errs.NotFound("黑名单条目不存在")
+	}
+
+	return nil
+}
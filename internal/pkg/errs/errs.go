@@ -0,0 +1,31 @@
+package errs
+
+import "errors"
+
+// ErrNotFound 记录不存在，仓储层将数据库的记录不存在错误统一转换为该错误
+var ErrNotFound = errors.New("记录不存在")
+
+// notFoundError 带提示信息的记录不存在错误
+type notFoundError struct {
+	message string
+}
+
+// Error 返回提示信息
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+// Unwrap 返回ErrNotFound，支持errors.Is判断
+func (e *notFoundError) Unwrap() error {
+	return ErrNotFound
+}
+
+// NotFound 创建记录不存在错误，message为提示信息（如"报销单不存在"）
+func NotFound(message string) error {
+	return &notFoundError{message: message}
+}
+
+// IsNotFound 判断错误是否为记录不存在
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,35 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "ErrNotFound", err: ErrNotFound, want: true},
+		{name: "带提示信息", err: NotFound("报销单不存在"), want: true},
+		{name: "多层包装", err: fmt.Errorf("获取发票失败: %w", NotFound("发票不存在")), want: true},
+		{name: "其他错误", err: errors.New("数据库连接失败"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNotFound(tt.err); got != tt.want {
+				t.Errorf("IsNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotFoundMessage(t *testing.T) {
+	if got := NotFound("规则不存在").Error(); got != "规则不存在" {
+		t.Errorf("Error() = %q, want %q", got, "规则不存在")
+	}
+}
@@ -2,7 +2,9 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strings"
 )
 
 // Level 日志级别
@@ -39,6 +41,24 @@ func (l Level) String() string {
 	}
 }
 
+// ParseLevel 解析日志级别字符串(不区分大小写)，为空时返回InfoLevel
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return DebugLevel, nil
+	case "", "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	default:
+		return InfoLevel, fmt.Errorf("unsupported level: %s", level)
+	}
+}
+
 // Field 日志字段
 type Field struct {
 	Key   string
@@ -103,7 +123,23 @@ func DefaultConfig() *Config {
 
 // Validate 验证配置
 func (c *Config) Validate() error {
-	// TODO: 实现配置验证逻辑
+	if c.Level < DebugLevel || c.Level > FatalLevel {
+		return fmt.Errorf("unsupported level: %d", c.Level)
+	}
+	switch c.Format {
+	case "json", "text":
+	default:
+		return fmt.Errorf("unsupported format: %s", c.Format)
+	}
+	switch c.Output {
+	case "stdout", "stderr":
+	case "file":
+		if c.Filename == "" {
+			return fmt.Errorf("filename is required when output is file")
+		}
+	default:
+		return fmt.Errorf("unsupported output: %s", c.Output)
+	}
 	return nil
 }
 
@@ -0,0 +1,20 @@
+package loggertest
+
+import (
+	"testing"
+
+	"reimbursement-audit/internal/pkg/logger"
+)
+
+// New 创建测试用日志器，只输出致命日志
+func New(t testing.TB) logger.Logger {
+	t.Helper()
+	config := logger.DefaultConfig()
+	config.Level = logger.FatalLevel
+	config.Output = "stderr"
+	log, err := logger.NewLogger(config)
+	if err != nil {
+		t.Fatalf("创建日志器失败: %v", err)
+	}
+	return log
+}
@@ -0,0 +1,80 @@
+package report
+
+// 报表格式
+const (
+	FormatPDF  = "pdf"
+	FormatXLSX = "xlsx"
+)
+
+// 报表文件的MIME类型
+const (
+	ContentTypePDF  = "application/pdf"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// Document 报表文档，由若干章节组成，可渲染为PDF或Excel
+type Document struct {
+	Title    string     // 报表标题
+	Subtitle string     // 副标题(如生成时间)
+	Sections []*Section // 章节，按顺序输出
+}
+
+// Section 报表章节，依次输出键值信息、段落和表格，Excel中每个章节为一个工作表
+type Section struct {
+	Title      string   // 章节标题，同时作为Excel工作表名称
+	Fields     []Field  // 键值信息
+	Paragraphs []string // 段落文本
+	Table      *Table   // 表格
+}
+
+// Field 键值信息
+type Field struct {
+	Label string
+	Value string
+}
+
+// Table 表格
+type Table struct {
+	Headers []string   // 表头
+	Rows    [][]string // 数据行，列数不足时补空
+	Widths  []float64  // 各列相对宽度，为空时等宽
+}
+
+// AddField 追加键值信息，值为空时不输出
+func (s *Section) AddField(label, value string) {
+	if value == "" {
+		return
+	}
+	s.Fields = append(s.Fields, Field{Label: label, Value: value})
+}
+
+// columnWidths 计算表格各列宽度，按相对宽度分配total
+func (t *Table) columnWidths(total float64) []float64 {
+	columns := len(t.Headers)
+	widths := make([]float64, columns)
+	if columns == 0 {
+		return widths
+	}
+
+	sum := 0.0
+	for i := 0; i < columns; i++ {
+		weight := 1.0
+		if i < len(t.Widths) && t.Widths[i] > 0 {
+			weight = t.Widths[i]
+		}
+		widths[i] = weight
+		sum += weight
+	}
+	for i := range widths {
+		widths[i] = widths[i] / sum * total
+	}
+	return widths
+}
+
+// cell 获取单元格内容，列数不足时返回空
+func cell(row []string, column int) string {
+	if column < len(row) {
+		return row[column]
+	}
+	return ""
+}
@@ -0,0 +1,456 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// ErrFontRequired PDF报表未配置字体
+var ErrFontRequired = errors.New("PDF报表需要配置TrueType字体")
+
+// PDF页面布局(单位：点)，A4纵向
+const (
+	pdfPageWidth     = 595.28
+	pdfPageHeight    = 841.89
+	pdfMargin        = 50.0
+	pdfTitleSize     = 16.0
+	pdfSubtitleSize  = 9.0
+	pdfHeadingSize   = 12.0
+	pdfBodySize      = 9.0
+	pdfFooterSize    = 8.0
+	pdfLineSpacing   = 1.4 // 行高与字号之比
+	pdfCellPadding   = 4.0
+	pdfFieldLabelPct = 0.25 // 键值信息标签列占内容宽度的比例
+)
+
+// pdfContentWidth 页面内容区宽度
+const pdfContentWidth = pdfPageWidth - 2*pdfMargin
+
+// RenderPDF 将报表渲染为PDF，内嵌所用字形的字体子集，中文可正常显示和复制
+func RenderPDF(doc *Document, font *Font) ([]byte, error) {
+	if font == nil {
+		return nil, ErrFontRequired
+	}
+	if doc == nil || len(doc.Sections) == 0 {
+		return nil, fmt.Errorf("报表内容为空")
+	}
+
+	w := &pdfLayout{font: font, glyphs: make(map[uint16]bool)}
+	w.newPage()
+	if doc.Title != "" {
+		w.paragraph(doc.Title, pdfTitleSize)
+	}
+	if doc.Subtitle != "" {
+		w.paragraph(doc.Subtitle, pdfSubtitleSize)
+	}
+	for _, section := range doc.Sections {
+		w.section(section)
+	}
+	w.footers()
+
+	return w.output(doc.Title)
+}
+
+// pdfLayout PDF排版状态：逐页生成内容流，记录使用过的字形用于字体子集
+type pdfLayout struct {
+	font   *Font
+	glyphs map[uint16]bool
+	pages  []*bytes.Buffer
+	y      float64 // 当前页剩余内容的顶部位置
+}
+
+// page 当前页内容流
+func (w *pdfLayout) page() *bytes.Buffer {
+	return w.pages[len(w.pages)-1]
+}
+
+// newPage 新建一页
+func (w *pdfLayout) newPage() {
+	w.pages = append(w.pages, &bytes.Buffer{})
+	w.y = pdfPageHeight - pdfMargin
+}
+
+// ensureSpace 当前页剩余空间不足height时换页，返回是否换页
+func (w *pdfLayout) ensureSpace(height float64) bool {
+	if w.y-height >= pdfMargin {
+		return false
+	}
+	w.newPage()
+	return true
+}
+
+// lineHeight 指定字号的行高
+func lineHeight(size float64) float64 {
+	return size * pdfLineSpacing
+}
+
+// section 输出章节：标题、键值信息、段落和表格
+func (w *pdfLayout) section(section *Section) {
+	// 章节标题至少与下一行内容在同一页
+	w.ensureSpace(lineHeight(pdfHeadingSize)*2 + lineHeight(pdfBodySize))
+	w.y -= lineHeight(pdfHeadingSize) / 2
+	w.paragraph(section.Title, pdfHeadingSize)
+
+	if len(section.Fields) > 0 {
+		labelWidth := pdfContentWidth * pdfFieldLabelPct
+		for _, field := range section.Fields {
+			w.row([]string{field.Label, field.Value}, []float64{labelWidth, pdfContentWidth - labelWidth}, []bool{true, false}, nil)
+		}
+		w.y -= lineHeight(pdfBodySize) / 2
+	}
+	for _, paragraph := range section.Paragraphs {
+		w.paragraph(paragraph, pdfBodySize)
+		w.y -= lineHeight(pdfBodySize) / 2
+	}
+	if table := section.Table; table != nil && len(table.Headers) > 0 {
+		widths := table.columnWidths(pdfContentWidth)
+		shaded := make([]bool, len(table.Headers))
+		for i := range shaded {
+			shaded[i] = true
+		}
+		header := func() { w.row(table.Headers, widths, shaded, nil) }
+		header()
+		for _, row := range table.Rows {
+			cells := make([]string, len(table.Headers))
+			for i := range cells {
+				cells[i] = cell(row, i)
+			}
+			w.row(cells, widths, nil, header)
+		}
+		w.y -= lineHeight(pdfBodySize) / 2
+	}
+}
+
+// paragraph 输出自动换行的段落，跨页时逐行续排
+func (w *pdfLayout) paragraph(text string, size float64) {
+	for _, line := range w.wrap(text, pdfContentWidth, size) {
+		w.ensureSpace(lineHeight(size))
+		w.y -= lineHeight(size)
+		w.text(pdfMargin, w.y+(lineHeight(size)-size)/2+size*0.2, size, line)
+	}
+}
+
+// row 输出一行带边框的表格，shaded标记灰底的单元格
+// 当前页放不下时换页，换页后调用repeatHeader重复输出表头；超过一页高度的单元格截断
+func (w *pdfLayout) row(cells []string, widths []float64, shaded []bool, repeatHeader func()) {
+	lh := lineHeight(pdfBodySize)
+	maxLines := int((pdfPageHeight - 2*pdfMargin - 2*pdfCellPadding - 3*lh) / lh)
+
+	lines := make([][]string, len(cells))
+	rowLines := 1
+	for i, value := range cells {
+		lines[i] = w.wrap(value, widths[i]-2*pdfCellPadding, pdfBodySize)
+		if len(lines[i]) > maxLines {
+			lines[i] = append(lines[i][:maxLines-1], "…")
+		}
+		if len(lines[i]) > rowLines {
+			rowLines = len(lines[i])
+		}
+	}
+	height := float64(rowLines)*lh + 2*pdfCellPadding
+
+	if w.ensureSpace(height) && repeatHeader != nil {
+		repeatHeader()
+	}
+
+	page := w.page()
+	x := pdfMargin
+	top := w.y
+	for i := range cells {
+		if i < len(shaded) && shaded[i] {
+			fmt.Fprintf(page, "0.9 g %.2f %.2f %.2f %.2f re f 0 g\n", x, top-height, widths[i], height)
+		}
+		fmt.Fprintf(page, "0.5 w %.2f %.2f %.2f %.2f re S\n", x, top-height, widths[i], height)
+		for j, line := range lines[i] {
+			baseline := top - pdfCellPadding - float64(j+1)*lh + (lh-pdfBodySize)/2 + pdfBodySize*0.2
+			w.text(x+pdfCellPadding, baseline, pdfBodySize, line)
+		}
+		x += widths[i]
+	}
+	w.y = top - height
+}
+
+// text 在当前页指定位置输出一行文本
+func (w *pdfLayout) text(x, y, size float64, line string) {
+	w.textOn(w.page(), x, y, size, line)
+}
+
+// textOn 在指定页输出一行文本，字符按字形ID编码(Identity-H)
+func (w *pdfLayout) textOn(page *bytes.Buffer, x, y, size float64, line string) {
+	if line == "" {
+		return
+	}
+	var hex strings.Builder
+	for _, r := range line {
+		glyph := w.font.GlyphID(r)
+		w.glyphs[glyph] = true
+		fmt.Fprintf(&hex, "%04X", glyph)
+	}
+	fmt.Fprintf(page, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, y, hex.String())
+}
+
+// wrap 按宽度折行，保留原有换行；中文按字符折行，英文单词尽量不拆开
+func (w *pdfLayout) wrap(text string, width, size float64) []string {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\t", "    ")
+	var lines []string
+	for _, raw := range strings.Split(text, "\n") {
+		runes := []rune(raw)
+		for len(runes) > 0 {
+			lineWidth := 0.0
+			end := 0
+			for end < len(runes) {
+				charWidth := w.font.TextWidth(string(runes[end]), size)
+				if lineWidth+charWidth > width && end > 0 {
+					break
+				}
+				lineWidth += charWidth
+				end++
+			}
+			if end < len(runes) {
+				// 在英文单词中间折行时回退到最近的空格
+				for back := end; back > 0; back-- {
+					if runes[back-1] == ' ' {
+						if back > end/2 {
+							end = back
+						}
+						break
+					}
+					if runes[back-1] > 0x7F {
+						break
+					}
+				}
+			}
+			lines = append(lines, strings.TrimRight(string(runes[:end]), " "))
+			runes = runes[end:]
+			for len(runes) > 0 && runes[0] == ' ' {
+				runes = runes[1:]
+			}
+		}
+		if len(raw) == 0 {
+			lines = append(lines, "")
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// footers 为每页添加页码
+func (w *pdfLayout) footers() {
+	for i, page := range w.pages {
+		label := fmt.Sprintf("第 %d / %d 页", i+1, len(w.pages))
+		x := (pdfPageWidth - w.font.TextWidth(label, pdfFooterSize)) / 2
+		w.textOn(page, x, pdfMargin/2, pdfFooterSize, label)
+	}
+}
+
+// output 组装PDF文件：目录、页面、内嵌字体子集、字符映射和交叉引用表
+func (w *pdfLayout) output(title string) ([]byte, error) {
+	pdf := &pdfWriter{}
+	pdf.buf.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+
+	// 对象编号：1目录 2页面树 3字体 4后代字体 5字体描述 6字体文件 7字符映射 8文档信息，之后为各页及内容流
+	const (
+		catalogObj = 1 + iota
+		pagesObj
+		fontObj
+		cidFontObj
+		descriptorObj
+		fontFileObj
+		toUnicodeObj
+		infoObj
+		firstPageObj
+	)
+
+	pdf.object(catalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+i*2)
+	}
+	pdf.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+
+	fontName := w.subsetFontName()
+	pdf.object(fontObj, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		fontName, cidFontObj, toUnicodeObj))
+	pdf.object(cidFontObj, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W %s >>",
+		fontName, descriptorObj, w.widthArray()))
+	f := w.font
+	pdf.object(descriptorObj, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		fontName, f.pdfUnits(f.bbox[0]), f.pdfUnits(f.bbox[1]), f.pdfUnits(f.bbox[2]), f.pdfUnits(f.bbox[3]),
+		f.pdfUnits(f.ascent), f.pdfUnits(f.descent), f.pdfUnits(f.capHeight), fontFileObj))
+	fontData := f.Subset(w.glyphs)
+	if err := pdf.stream(fontFileObj, fmt.Sprintf("/Length1 %d", len(fontData)), fontData); err != nil {
+		return nil, err
+	}
+	if err := pdf.stream(toUnicodeObj, "", []byte(w.toUnicodeCMap())); err != nil {
+		return nil, err
+	}
+	pdf.object(infoObj, fmt.Sprintf("<< /Title %s /Producer (reimbursement-audit) /CreationDate (D:%s) >>",
+		pdfTextString(title), time.Now().Format("20060102150405")))
+
+	for i, page := range w.pages {
+		pageObj := firstPageObj + i*2
+		pdf.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesObj, pdfPageWidth, pdfPageHeight, fontObj, pageObj+1))
+		if err := pdf.stream(pageObj+1, "", page.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	pdf.finish(catalogObj, infoObj)
+	return pdf.buf.Bytes(), nil
+}
+
+// sortedGlyphs 按字形ID排序的已使用字形
+func (w *pdfLayout) sortedGlyphs() []uint16 {
+	glyphs := make([]uint16, 0, len(w.glyphs))
+	for glyph := range w.glyphs {
+		glyphs = append(glyphs, glyph)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// widthArray 生成已使用字形的宽度数组(W)
+func (w *pdfLayout) widthArray() string {
+	var b strings.Builder
+	b.WriteString("[")
+	for _, glyph := range w.sortedGlyphs() {
+		fmt.Fprintf(&b, " %d [%d]", glyph, w.font.pdfUnits(w.font.advance(glyph)))
+	}
+	b.WriteString(" ]")
+	return b.String()
+}
+
+// toUnicodeCMap 生成字形到Unicode的映射，使PDF中的文本可复制和搜索
+func (w *pdfLayout) toUnicodeCMap() string {
+	unicodes := make(map[uint16]rune, len(w.glyphs))
+	for r, glyph := range w.font.cmap {
+		if w.glyphs[glyph] {
+			if existing, ok := unicodes[glyph]; !ok || r < existing {
+				unicodes[glyph] = r
+			}
+		}
+	}
+
+	var mappings []string
+	for _, glyph := range w.sortedGlyphs() {
+		r, ok := unicodes[glyph]
+		if !ok {
+			continue
+		}
+		var hex strings.Builder
+		for _, unit := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&hex, "%04X", unit)
+		}
+		mappings = append(mappings, fmt.Sprintf("<%04X> <%s>", glyph, hex.String()))
+	}
+
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// bfchar每段最多100条
+	for start := 0; start < len(mappings); start += 100 {
+		end := start + 100
+		if end > len(mappings) {
+			end = len(mappings)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n%s\nendbfchar\n", end-start, strings.Join(mappings[start:end], "\n"))
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+// subsetFontName 生成子集字体名称，前缀为由所用字形决定的6个大写字母
+func (w *pdfLayout) subsetFontName() string {
+	hash := sha256.New()
+	for _, glyph := range w.sortedGlyphs() {
+		fmt.Fprintf(hash, "%d,", glyph)
+	}
+	sum := hash.Sum(nil)
+	prefix := make([]byte, 6)
+	for i := range prefix {
+		prefix[i] = 'A' + sum[i]%26
+	}
+	return string(prefix) + "+ReportFont"
+}
+
+// pdfTextString 将文本编码为UTF-16BE十六进制字符串(带BOM)，用于文档信息
+func pdfTextString(text string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfWriter PDF对象写出器，记录各对象偏移用于交叉引用表
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+// object 写出间接对象
+func (p *pdfWriter) object(id int, body string) {
+	if p.offsets == nil {
+		p.offsets = make(map[int]int)
+	}
+	p.offsets[id] = p.buf.Len()
+	fmt.Fprintf(&p.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream 写出Flate压缩的流对象，extra为附加的字典项
+func (p *pdfWriter) stream(id int, extra string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("压缩PDF流失败: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("压缩PDF流失败: %w", err)
+	}
+
+	if p.offsets == nil {
+		p.offsets = make(map[int]int)
+	}
+	p.offsets[id] = p.buf.Len()
+	fmt.Fprintf(&p.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode %s >>\nstream\n", id, compressed.Len(), extra)
+	p.buf.Write(compressed.Bytes())
+	p.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+// finish 写出交叉引用表和文件尾
+func (p *pdfWriter) finish(rootObj, infoObj int) {
+	size := 0
+	for id := range p.offsets {
+		if id > size {
+			size = id
+		}
+	}
+	size++
+
+	xref := p.buf.Len()
+	fmt.Fprintf(&p.buf, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		if offset, ok := p.offsets[id]; ok {
+			fmt.Fprintf(&p.buf, "%010d 00000 n \n", offset)
+		} else {
+			p.buf.WriteString("0000000000 65535 f \n")
+		}
+	}
+	fmt.Fprintf(&p.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, rootObj, infoObj, xref)
+}
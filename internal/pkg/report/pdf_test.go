@@ -0,0 +1,211 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pdfFooterRunes 页码使用的字符
+const pdfFooterRunes = "第页/ 0123456789"
+
+// testReport 生成含键值信息、段落和多页表格的报表
+func testReport(rows int) *Document {
+	table := &Table{Headers: []string{"发票号码", "销售方", "校验结论"}, Widths: []float64{1, 2, 1}}
+	for i := 1; i <= rows; i++ {
+		table.Rows = append(table.Rows, []string{fmt.Sprintf("发票%03d", i), "某某酒店有限公司", "通过"})
+	}
+	return &Document{
+		Title:    "报销审核报告",
+		Subtitle: "生成时间 2026-10-16",
+		Sections: []*Section{
+			{
+				Title:      "审核结论",
+				Fields:     []Field{{Label: "报销单号", Value: "BX001"}, {Label: "审核结果", Value: "通过"}},
+				Paragraphs: []string{"规则校验和大模型分析均通过"},
+			},
+			{Title: "发票明细", Table: table},
+		},
+	}
+}
+
+// documentText 报表中出现的全部文本，用于生成测试字体
+func documentText(doc *Document) string {
+	var b strings.Builder
+	b.WriteString(doc.Title + doc.Subtitle + "…" + pdfFooterRunes)
+	for _, section := range doc.Sections {
+		b.WriteString(section.Title)
+		for _, field := range section.Fields {
+			b.WriteString(field.Label + field.Value)
+		}
+		b.WriteString(strings.Join(section.Paragraphs, ""))
+		if section.Table != nil {
+			b.WriteString(strings.Join(section.Table.Headers, ""))
+			for _, row := range section.Table.Rows {
+				b.WriteString(strings.Join(row, ""))
+			}
+		}
+	}
+	return b.String()
+}
+
+// parsedPDF 解析后的PDF：各页按输出顺序的文本行
+type parsedPDF struct {
+	pageCount int
+	pages     [][]string
+}
+
+var (
+	pdfObjectHeader = regexp.MustCompile(`^(\d+) 0 obj\n`)
+	pdfStreamDict   = regexp.MustCompile(`^<< /Length (\d+) /Filter /FlateDecode [^\n]*>>\nstream\n`)
+	pdfReference    = func(key string) *regexp.Regexp { return regexp.MustCompile(`/` + key + ` (\d+) 0 R`) }
+	pdfShowText     = regexp.MustCompile(`<([0-9A-F]*)> Tj`)
+	pdfBFChar       = regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>`)
+)
+
+// parsePDF 按交叉引用表读取对象，解压内容流，通过ToUnicode映射还原各页文本
+func parsePDF(t *testing.T, data []byte) *parsedPDF {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.7\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("PDF文件头或文件尾不正确")
+	}
+	tail := data[bytes.LastIndex(data, []byte("startxref\n"))+len("startxref\n"):]
+	xref, err := strconv.Atoi(string(bytes.TrimSpace(bytes.TrimSuffix(tail, []byte("%%EOF\n")))))
+	if err != nil || !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref未指向交叉引用表: %v", err)
+	}
+
+	// 交叉引用表中每个对象的偏移都必须指向该对象
+	lines := strings.Split(string(data[xref:]), "\n")
+	size, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	objects := make(map[int][]byte, size)
+	for id := 1; id < size; id++ {
+		entry := strings.Fields(lines[2+id])
+		if entry[2] != "n" {
+			continue
+		}
+		offset, _ := strconv.Atoi(entry[0])
+		header := pdfObjectHeader.FindSubmatch(data[offset:])
+		if header == nil || string(header[1]) != strconv.Itoa(id) {
+			t.Fatalf("对象%d的交叉引用偏移%d不正确", id, offset)
+		}
+		body := data[offset+len(header[0]):]
+		if dict := pdfStreamDict.FindSubmatch(body); dict != nil {
+			length, _ := strconv.Atoi(string(dict[1]))
+			stream := body[len(dict[0]) : len(dict[0])+length]
+			reader, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				t.Fatalf("对象%d解压失败: %v", id, err)
+			}
+			objects[id], err = io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("对象%d解压失败: %v", id, err)
+			}
+			continue
+		}
+		objects[id] = body[:bytes.Index(body, []byte("\nendobj\n"))]
+	}
+
+	reference := func(object []byte, key string) []byte {
+		match := pdfReference(key).FindSubmatch(object)
+		if match == nil {
+			t.Fatalf("对象缺少/%s引用: %s", key, object)
+		}
+		id, _ := strconv.Atoi(string(match[1]))
+		return objects[id]
+	}
+
+	pages := reference(objects[1], "Pages")
+	var kids [][]byte
+	for _, kid := range regexp.MustCompile(`(\d+) 0 R`).FindAllSubmatch(pages, -1) {
+		id, _ := strconv.Atoi(string(kid[1]))
+		kids = append(kids, objects[id])
+	}
+	font := reference(kids[0], "F1")
+	unicodes := make(map[string]string)
+	for _, match := range pdfBFChar.FindAllSubmatch(reference(font, "ToUnicode"), -1) {
+		code, _ := strconv.ParseUint(string(match[2]), 16, 32)
+		unicodes[string(match[1])] = string(rune(code))
+	}
+
+	parsed := &parsedPDF{}
+	count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pages)
+	parsed.pageCount, _ = strconv.Atoi(string(count[1]))
+	for _, page := range kids {
+		var texts []string
+		for _, show := range pdfShowText.FindAllSubmatch(reference(page, "Contents"), -1) {
+			var text strings.Builder
+			for i := 0; i+4 <= len(show[1]); i += 4 {
+				text.WriteString(unicodes[string(show[1][i:i+4])])
+			}
+			texts = append(texts, text.String())
+		}
+		parsed.pages = append(parsed.pages, texts)
+	}
+	return parsed
+}
+
+func TestRenderPDF(t *testing.T) {
+	const rows = 80
+	doc := testReport(rows)
+	data, err := RenderPDF(doc, newTestFont(t, documentText(doc)))
+	if err != nil {
+		t.Fatalf("RenderPDF() error = %v", err)
+	}
+	parsed := parsePDF(t, data)
+
+	if parsed.pageCount < 2 || parsed.pageCount != len(parsed.pages) {
+		t.Fatalf("页数 = %d, 页面对象 = %d, want 多页且一致", parsed.pageCount, len(parsed.pages))
+	}
+	if first := parsed.pages[0]; first[0] != doc.Title || first[1] != doc.Subtitle {
+		t.Errorf("首页开头 = %q, want 标题和副标题", first[:2])
+	}
+
+	counts := make(map[string]int)
+	for i, page := range parsed.pages {
+		hasHeader, hasRows := false, false
+		for _, text := range page {
+			counts[text]++
+			hasHeader = hasHeader || text == "发票号码"
+			hasRows = hasRows || strings.HasPrefix(text, "发票") && text != "发票号码" && text != "发票明细"
+		}
+		// 表格跨页时每页重复表头
+		if hasRows && !hasHeader {
+			t.Errorf("第%d页有数据行但没有表头", i+1)
+		}
+		if footer := fmt.Sprintf("第 %d / %d 页", i+1, parsed.pageCount); page[len(page)-1] != footer {
+			t.Errorf("第%d页页脚 = %q, want %q", i+1, page[len(page)-1], footer)
+		}
+	}
+	for _, want := range []string{"审核结论", "报销单号", "BX001", "规则校验和大模型分析均通过", "发票明细"} {
+		if counts[want] == 0 {
+			t.Errorf("PDF中缺少文本%q", want)
+		}
+	}
+
+	// 每行数据恰好输出一次
+	for i := 1; i <= rows; i++ {
+		if invoice := fmt.Sprintf("发票%03d", i); counts[invoice] != 1 {
+			t.Errorf("数据行%q出现%d次, want 1", invoice, counts[invoice])
+		}
+	}
+	if counts["某某酒店有限公司"] != rows {
+		t.Errorf("销售方单元格出现%d次, want %d", counts["某某酒店有限公司"], rows)
+	}
+}
+
+func TestRenderPDFInvalid(t *testing.T) {
+	font := newTestFont(t, "报")
+	if _, err := RenderPDF(testReport(1), nil); !errors.Is(err, ErrFontRequired) {
+		t.Errorf("未配置字体 error = %v, want %v", err, ErrFontRequired)
+	}
+	if _, err := RenderPDF(&Document{Title: "报"}, font); err == nil {
+		t.Errorf("报表内容为空时应返回错误")
+	}
+}
@@ -0,0 +1,441 @@
+package report
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ErrUnsupportedFont 字体格式不支持(仅支持TrueType轮廓字体，含TTC字体集合的第一个字体)
+var ErrUnsupportedFont = errors.New("不支持的字体格式，请使用TrueType(.ttf/.ttc)字体")
+
+// 子集字体保留的表，按标签字母序排列(字体表目录要求有序)
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// Font 已解析的TrueType字体，用于在PDF中内嵌字体子集
+// 解析后只读，可被多个报表并发使用
+type Font struct {
+	tables           map[string][]byte
+	unitsPerEm       int
+	bbox             [4]int
+	ascent           int
+	descent          int
+	capHeight        int
+	numGlyphs        int
+	numHMetrics      int
+	indexToLocFormat int
+	cmap             map[rune]uint16
+}
+
+// LoadFont 从文件加载TrueType字体，PDF报表需要支持中文的字体(如思源黑体TTF版、文泉驿、黑体)
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取字体文件失败: %w", err)
+	}
+	return ParseFont(data)
+}
+
+// ParseFont 解析TrueType字体数据，TTC字体集合取第一个字体
+func ParseFont(data []byte) (*Font, error) {
+	if len(data) < 12 {
+		return nil, ErrUnsupportedFont
+	}
+
+	offset := 0
+	switch string(data[:4]) {
+	case "ttcf":
+		if len(data) < 16 {
+			return nil, ErrUnsupportedFont
+		}
+		offset = int(binary.BigEndian.Uint32(data[12:16]))
+	case "OTTO":
+		return nil, fmt.Errorf("%w: 不支持CFF轮廓的OpenType字体", ErrUnsupportedFont)
+	}
+	if offset+12 > len(data) {
+		return nil, ErrUnsupportedFont
+	}
+	if version := binary.BigEndian.Uint32(data[offset : offset+4]); version != 0x00010000 && version != 0x74727565 {
+		return nil, ErrUnsupportedFont
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[offset+4 : offset+6]))
+	font := &Font{tables: make(map[string][]byte, numTables)}
+	for i := 0; i < numTables; i++ {
+		record := offset + 12 + i*16
+		if record+16 > len(data) {
+			return nil, fmt.Errorf("%w: 字体表目录不完整", ErrUnsupportedFont)
+		}
+		tag := string(data[record : record+4])
+		start := int(binary.BigEndian.Uint32(data[record+8 : record+12]))
+		length := int(binary.BigEndian.Uint32(data[record+12 : record+16]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("%w: 字体表%s越界", ErrUnsupportedFont, tag)
+		}
+		font.tables[tag] = data[start : start+length]
+	}
+
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "loca", "glyf", "cmap"} {
+		if _, ok := font.tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: 缺少%s表", ErrUnsupportedFont, tag)
+		}
+	}
+	if err := font.parseMetrics(); err != nil {
+		return nil, err
+	}
+	if err := font.parseCmap(); err != nil {
+		return nil, err
+	}
+	return font, nil
+}
+
+// parseMetrics 解析字体度量信息
+func (f *Font) parseMetrics() error {
+	head, hhea, maxp := f.tables["head"], f.tables["hhea"], f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return fmt.Errorf("%w: 字体头信息不完整", ErrUnsupportedFont)
+	}
+
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:20]))
+	if f.unitsPerEm == 0 {
+		return fmt.Errorf("%w: unitsPerEm为0", ErrUnsupportedFont)
+	}
+	for i := 0; i < 4; i++ {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2 : 38+i*2])))
+	}
+	f.indexToLocFormat = int(int16(binary.BigEndian.Uint16(head[50:52])))
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:6])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:8])))
+	f.numHMetrics = int(binary.BigEndian.Uint16(hhea[34:36]))
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:6]))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2[0:2]) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:90])))
+	}
+
+	if f.numHMetrics == 0 || len(f.tables["hmtx"]) < f.numHMetrics*4 {
+		return fmt.Errorf("%w: hmtx表不完整", ErrUnsupportedFont)
+	}
+	locaEntry := 2
+	if f.indexToLocFormat == 1 {
+		locaEntry = 4
+	}
+	if len(f.tables["loca"]) < (f.numGlyphs+1)*locaEntry {
+		return fmt.Errorf("%w: loca表不完整", ErrUnsupportedFont)
+	}
+	return nil
+}
+
+// parseCmap 解析Unicode字符到字形的映射，优先使用完整Unicode的格式12子表
+func (f *Font) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return fmt.Errorf("%w: cmap表不完整", ErrUnsupportedFont)
+	}
+
+	var format4, format12 []byte
+	numSubtables := int(binary.BigEndian.Uint16(cmap[2:4]))
+	for i := 0; i < numSubtables; i++ {
+		record := 4 + i*8
+		if record+8 > len(cmap) {
+			break
+		}
+		platformID := binary.BigEndian.Uint16(cmap[record : record+2])
+		encodingID := binary.BigEndian.Uint16(cmap[record+2 : record+4])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4 : record+8]))
+		if offset+2 > len(cmap) {
+			continue
+		}
+		unicode := platformID == 0 || (platformID == 3 && (encodingID == 1 || encodingID == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[offset : offset+2]) {
+		case 4:
+			format4 = cmap[offset:]
+		case 12:
+			format12 = cmap[offset:]
+		}
+	}
+
+	f.cmap = make(map[rune]uint16)
+	switch {
+	case format12 != nil:
+		return f.parseCmapFormat12(format12)
+	case format4 != nil:
+		return f.parseCmapFormat4(format4)
+	}
+	return fmt.Errorf("%w: 缺少Unicode字符映射", ErrUnsupportedFont)
+}
+
+// parseCmapFormat4 解析cmap格式4子表(基本多文种平面)
+func (f *Font) parseCmapFormat4(table []byte) error {
+	if len(table) < 14 {
+		return fmt.Errorf("%w: cmap格式4子表不完整", ErrUnsupportedFont)
+	}
+	segCount := int(binary.BigEndian.Uint16(table[6:8])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if idRangeOffsets+segCount*2 > len(table) {
+		return fmt.Errorf("%w: cmap格式4子表不完整", ErrUnsupportedFont)
+	}
+
+	for i := 0; i < segCount; i++ {
+		end := int(binary.BigEndian.Uint16(table[endCodes+i*2:]))
+		start := int(binary.BigEndian.Uint16(table[startCodes+i*2:]))
+		delta := binary.BigEndian.Uint16(table[idDeltas+i*2:])
+		rangeOffsetPos := idRangeOffsets + i*2
+		rangeOffset := int(binary.BigEndian.Uint16(table[rangeOffsetPos:]))
+		for code := start; code <= end && code != 0xFFFF; code++ {
+			var glyph uint16
+			if rangeOffset == 0 {
+				glyph = uint16(code) + delta
+			} else {
+				pos := rangeOffsetPos + rangeOffset + (code-start)*2
+				if pos+2 > len(table) {
+					continue
+				}
+				glyph = binary.BigEndian.Uint16(table[pos:])
+				if glyph != 0 {
+					glyph += delta
+				}
+			}
+			if glyph != 0 && int(glyph) < f.numGlyphs {
+				f.cmap[rune(code)] = glyph
+			}
+		}
+	}
+	return nil
+}
+
+// parseCmapFormat12 解析cmap格式12子表(完整Unicode)
+func (f *Font) parseCmapFormat12(table []byte) error {
+	if len(table) < 16 {
+		return fmt.Errorf("%w: cmap格式12子表不完整", ErrUnsupportedFont)
+	}
+	numGroups := int(binary.BigEndian.Uint32(table[12:16]))
+	if 16+numGroups*12 > len(table) {
+		return fmt.Errorf("%w: cmap格式12子表不完整", ErrUnsupportedFont)
+	}
+
+	for i := 0; i < numGroups; i++ {
+		group := table[16+i*12:]
+		start := binary.BigEndian.Uint32(group[0:4])
+		end := binary.BigEndian.Uint32(group[4:8])
+		glyph := binary.BigEndian.Uint32(group[8:12])
+		for code := start; code <= end && code <= 0x10FFFF; code++ {
+			if id := glyph + code - start; id != 0 && int(id) < f.numGlyphs {
+				f.cmap[rune(code)] = uint16(id)
+			}
+		}
+	}
+	return nil
+}
+
+// GlyphID 获取字符对应的字形ID，字体不含该字符时返回0(.notdef)
+func (f *Font) GlyphID(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// HasGlyph 判断字体是否包含字符的字形
+func (f *Font) HasGlyph(r rune) bool {
+	_, ok := f.cmap[r]
+	return ok
+}
+
+// advance 获取字形的前进宽度(字体单位)
+func (f *Font) advance(glyph uint16) int {
+	index := int(glyph)
+	if index >= f.numHMetrics {
+		index = f.numHMetrics - 1
+	}
+	return int(binary.BigEndian.Uint16(f.tables["hmtx"][index*4:]))
+}
+
+// pdfUnits 将字体单位转换为PDF字形空间单位(1/1000)
+func (f *Font) pdfUnits(value int) int {
+	return value * 1000 / f.unitsPerEm
+}
+
+// TextWidth 计算文本在指定字号下的宽度(点)
+func (f *Font) TextWidth(text string, size float64) float64 {
+	width := 0
+	for _, r := range text {
+		width += f.advance(f.GlyphID(r))
+	}
+	return float64(width) * size / float64(f.unitsPerEm)
+}
+
+// glyphRange 获取字形在glyf表中的数据
+func (f *Font) glyphRange(glyph int) []byte {
+	loca := f.tables["loca"]
+	var start, end int
+	if f.indexToLocFormat == 1 {
+		start = int(binary.BigEndian.Uint32(loca[glyph*4:]))
+		end = int(binary.BigEndian.Uint32(loca[glyph*4+4:]))
+	} else {
+		start = int(binary.BigEndian.Uint16(loca[glyph*2:])) * 2
+		end = int(binary.BigEndian.Uint16(loca[glyph*2+2:])) * 2
+	}
+	glyf := f.tables["glyf"]
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// compositeComponents 获取复合字形引用的组件字形
+func compositeComponents(data []byte) []uint16 {
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data[0:2])) >= 0 {
+		return nil
+	}
+
+	const (
+		argsAreWords    = 0x0001
+		haveScale       = 0x0008
+		moreComponents  = 0x0020
+		haveXYScale     = 0x0040
+		haveTwoByTwo    = 0x0080
+		componentHeader = 4
+	)
+	var components []uint16
+	pos := 10
+	for pos+componentHeader <= len(data) {
+		flags := binary.BigEndian.Uint16(data[pos:])
+		components = append(components, binary.BigEndian.Uint16(data[pos+2:]))
+		pos += componentHeader
+		if flags&argsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// Subset 生成只包含指定字形轮廓的字体数据，字形ID保持不变，未使用的字形轮廓置空
+func (f *Font) Subset(glyphs map[uint16]bool) []byte {
+	used := map[uint16]bool{0: true}
+	pending := make([]uint16, 0, len(glyphs))
+	for glyph := range glyphs {
+		pending = append(pending, glyph)
+	}
+	for len(pending) > 0 {
+		glyph := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if used[glyph] || int(glyph) >= f.numGlyphs {
+			continue
+		}
+		used[glyph] = true
+		pending = append(pending, compositeComponents(f.glyphRange(int(glyph)))...)
+	}
+
+	glyf := make([]byte, 0)
+	loca := make([]byte, (f.numGlyphs+1)*4)
+	for glyph := 0; glyph < f.numGlyphs; glyph++ {
+		binary.BigEndian.PutUint32(loca[glyph*4:], uint32(len(glyf)))
+		if !used[uint16(glyph)] {
+			continue
+		}
+		glyf = append(glyf, f.glyphRange(glyph)...)
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+	}
+	binary.BigEndian.PutUint32(loca[f.numGlyphs*4:], uint32(len(glyf)))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:12], 0)
+	binary.BigEndian.PutUint16(head[50:52], 1)
+
+	tables := map[string][]byte{"glyf": glyf, "loca": loca, "head": head}
+	for _, tag := range subsetTables {
+		if _, ok := tables[tag]; !ok {
+			if data, exists := f.tables[tag]; exists {
+				tables[tag] = data
+			}
+		}
+	}
+	data := writeSFNT(tables)
+
+	// checkSumAdjustment使整个字体的校验和为0xB1B0AFBA
+	headOffset := tableOffset(data, "head")
+	binary.BigEndian.PutUint32(data[headOffset+8:], 0xB1B0AFBA-tableChecksum(data))
+	return data
+}
+
+// writeSFNT 按表标签顺序写出TrueType字体文件
+func writeSFNT(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	headerSize := 12 + numTables*16
+	data := make([]byte, headerSize)
+	binary.BigEndian.PutUint32(data[0:], 0x00010000)
+	binary.BigEndian.PutUint16(data[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(data[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(data[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(data[10:], uint16(numTables*16-searchRange))
+
+	for i, tag := range tags {
+		table := tables[tag]
+		record := 12 + i*16
+		copy(data[record:], tag)
+		binary.BigEndian.PutUint32(data[record+4:], tableChecksum(table))
+		binary.BigEndian.PutUint32(data[record+8:], uint32(len(data)))
+		binary.BigEndian.PutUint32(data[record+12:], uint32(len(table)))
+		data = append(data, table...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+	return data
+}
+
+// tableOffset 获取字体表在字体文件中的偏移
+func tableOffset(data []byte, tag string) int {
+	numTables := int(binary.BigEndian.Uint16(data[4:6]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + i*16
+		if string(data[record:record+4]) == tag {
+			return int(binary.BigEndian.Uint32(data[record+8:]))
+		}
+	}
+	return 0
+}
+
+// tableChecksum 计算TrueType表校验和(按32位大端整数累加)
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
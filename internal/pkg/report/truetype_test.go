@@ -0,0 +1,178 @@
+package report
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"testing"
+)
+
+// 测试字体度量：ASCII字符半宽，其余字符全宽
+const (
+	testUnitsPerEm  = 1000
+	testHalfAdvance = 500
+	testFullAdvance = 1000
+)
+
+// buildTestFont 生成包含text中全部字符的最小TrueType字体，每个字符一个方块字形，字形ID按字符码点顺序从1开始
+func buildTestFont(t *testing.T, text string) []byte {
+	t.Helper()
+	set := make(map[rune]bool)
+	for _, r := range text {
+		set[r] = true
+	}
+	runes := make([]rune, 0, len(set))
+	for r := range set {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	numGlyphs := len(runes) + 1
+
+	// 方块字形：1个轮廓4个点，坐标按int16增量存储
+	square := []byte{0, 1, 0, 100, 0, 0, 2, 0x58, 2, 0xBC, 0, 3, 0, 0, 1, 1, 1, 1}
+	for _, delta := range []int16{100, 0, 500, 0, 0, 700, 0, -700} {
+		square = binary.BigEndian.AppendUint16(square, uint16(delta))
+	}
+	glyf := make([]byte, 0, numGlyphs*len(square))
+	loca := make([]byte, 0, (numGlyphs+1)*4)
+	hmtx := make([]byte, 0, numGlyphs*4)
+	for glyph := 0; glyph < numGlyphs; glyph++ {
+		loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+		glyf = append(glyf, square...)
+		advance := testFullAdvance
+		if glyph > 0 && runes[glyph-1] < 0x80 {
+			advance = testHalfAdvance
+		}
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(advance))
+		hmtx = binary.BigEndian.AppendUint16(hmtx, 100)
+	}
+	loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint32(head[0:], 0x00010000)
+	binary.BigEndian.PutUint32(head[12:], 0x5F0F3CF5)
+	binary.BigEndian.PutUint16(head[18:], testUnitsPerEm)
+	binary.BigEndian.PutUint16(head[40:], testFullAdvance)
+	binary.BigEndian.PutUint16(head[42:], 880)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	hhea := make([]byte, 36)
+	binary.BigEndian.PutUint32(hhea[0:], 0x00010000)
+	binary.BigEndian.PutUint16(hhea[4:], 880)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(0xFFFF-119)) // -120
+	binary.BigEndian.PutUint16(hhea[34:], uint16(numGlyphs))
+
+	maxp := make([]byte, 6)
+	binary.BigEndian.PutUint32(maxp[0:], 0x00005000)
+	binary.BigEndian.PutUint16(maxp[4:], uint16(numGlyphs))
+
+	// cmap：一个Windows完整Unicode(3,10)格式12子表，每个字符一组
+	cmap := []byte{0, 0, 0, 1, 0, 3, 0, 10, 0, 0, 0, 12}
+	cmap = binary.BigEndian.AppendUint16(cmap, 12)
+	cmap = binary.BigEndian.AppendUint16(cmap, 0)
+	cmap = binary.BigEndian.AppendUint32(cmap, uint32(16+len(runes)*12))
+	cmap = binary.BigEndian.AppendUint32(cmap, 0)
+	cmap = binary.BigEndian.AppendUint32(cmap, uint32(len(runes)))
+	for i, r := range runes {
+		cmap = binary.BigEndian.AppendUint32(cmap, uint32(r))
+		cmap = binary.BigEndian.AppendUint32(cmap, uint32(r))
+		cmap = binary.BigEndian.AppendUint32(cmap, uint32(i+1))
+	}
+
+	return writeSFNT(map[string][]byte{
+		"cmap": cmap, "glyf": glyf, "head": head, "hhea": hhea, "hmtx": hmtx, "loca": loca, "maxp": maxp,
+	})
+}
+
+// newTestFont 解析buildTestFont生成的字体
+func newTestFont(t *testing.T, text string) *Font {
+	t.Helper()
+	font, err := ParseFont(buildTestFont(t, text))
+	if err != nil {
+		t.Fatalf("ParseFont() error = %v", err)
+	}
+	return font
+}
+
+func TestParseFont(t *testing.T) {
+	font := newTestFont(t, "报销A1")
+
+	// 码点顺序：1(0x31) A(0x41) 报(0x62A5) 销(0x9500)
+	for r, want := range map[rune]uint16{'1': 1, 'A': 2, '报': 3, '销': 4} {
+		if got := font.GlyphID(r); got != want {
+			t.Errorf("GlyphID(%q) = %d, want %d", r, got, want)
+		}
+	}
+	if font.HasGlyph('审') || font.GlyphID('审') != 0 {
+		t.Errorf("字体不含的字符应映射到.notdef")
+	}
+	if got := font.TextWidth("报销A1", 10); got != 30 {
+		t.Errorf("TextWidth() = %v, want 30", got)
+	}
+}
+
+func TestParseFontInvalid(t *testing.T) {
+	valid := buildTestFont(t, "报")
+	withoutCmap := func() []byte {
+		tables := make(map[string][]byte)
+		for _, tag := range []string{"glyf", "head", "hhea", "hmtx", "loca", "maxp"} {
+			offset := tableOffset(valid, tag)
+			tables[tag] = valid[offset : offset+tableLength(valid, tag)]
+		}
+		return writeSFNT(tables)
+	}()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "数据过短", data: []byte("true")},
+		{name: "CFF轮廓OpenType字体", data: append([]byte("OTTO"), make([]byte, 12)...)},
+		{name: "未知格式", data: append([]byte("wOFF"), make([]byte, 12)...)},
+		{name: "缺少cmap表", data: withoutCmap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFont(tt.data); !errors.Is(err, ErrUnsupportedFont) {
+				t.Errorf("ParseFont() error = %v, want %v", err, ErrUnsupportedFont)
+			}
+		})
+	}
+}
+
+func TestFontSubset(t *testing.T) {
+	font := newTestFont(t, "报销审核")
+	used := map[uint16]bool{font.GlyphID('报'): true, font.GlyphID('核'): true}
+	data := font.Subset(used)
+
+	if sum := tableChecksum(data); sum != 0xB1B0AFBA {
+		t.Errorf("字体校验和 = %#x, want 0xB1B0AFBA", sum)
+	}
+	for _, tag := range []string{"cmap", "OS/2"} {
+		if tableOffset(data, tag) != 0 {
+			t.Errorf("子集字体不应包含%s表", tag)
+		}
+	}
+
+	// 字形ID保持不变，.notdef和已使用字形保留轮廓，其余字形轮廓为空
+	loca := data[tableOffset(data, "loca"):]
+	for glyph := 0; glyph < font.numGlyphs; glyph++ {
+		length := binary.BigEndian.Uint32(loca[glyph*4+4:]) - binary.BigEndian.Uint32(loca[glyph*4:])
+		keep := glyph == 0 || used[uint16(glyph)]
+		if keep != (length > 0) {
+			t.Errorf("字形%d轮廓长度 = %d, 是否保留 = %v", glyph, length, keep)
+		}
+	}
+}
+
+// tableLength 获取字体表长度
+func tableLength(data []byte, tag string) int {
+	numTables := int(binary.BigEndian.Uint16(data[4:6]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + i*16
+		if string(data[record:record+4]) == tag {
+			return int(binary.BigEndian.Uint32(data[record+12:]))
+		}
+	}
+	return 0
+}
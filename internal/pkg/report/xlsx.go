@@ -0,0 +1,247 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Excel限制
+const (
+	maxSheetNameRunes = 31 // 工作表名称最大字符数
+	maxColumnWidth    = 60 // 自动列宽上限(字符数)
+)
+
+// Excel单元格样式
+const (
+	styleDefault = iota
+	styleTitle
+	styleHeader
+	styleBody
+	styleLabel
+)
+
+// xlsxFontFamily Excel报表使用的中文字体
+const xlsxFontFamily = "宋体"
+
+// xlsxCell Excel单元格
+type xlsxCell struct {
+	value string
+	style int
+}
+
+// xlsxSheet Excel工作表
+type xlsxSheet struct {
+	name   string
+	widths []float64 // 各列宽度(字符数)
+	rows   [][]xlsxCell
+}
+
+// RenderXLSX 将报表渲染为Excel工作簿，每个章节一个工作表，报表标题输出在第一个工作表顶部
+func RenderXLSX(doc *Document) ([]byte, error) {
+	if doc == nil || len(doc.Sections) == 0 {
+		return nil, fmt.Errorf("报表内容为空")
+	}
+
+	sheets := make([]*xlsxSheet, 0, len(doc.Sections))
+	usedNames := make(map[string]bool, len(doc.Sections))
+	for i, section := range doc.Sections {
+		sheet := buildXLSXSheet(section, uniqueSheetName(section.Title, i, usedNames))
+		if i == 0 && doc.Title != "" {
+			header := [][]xlsxCell{{{value: doc.Title, style: styleTitle}}}
+			if doc.Subtitle != "" {
+				header = append(header, []xlsxCell{{value: doc.Subtitle}})
+			}
+			header = append(header, nil)
+			sheet.rows = append(header, sheet.rows...)
+		}
+		sheets = append(sheets, sheet)
+	}
+
+	file := excelize.NewFile()
+	defer file.Close()
+
+	styles, err := newXLSXStyles(file)
+	if err != nil {
+		return nil, err
+	}
+	defaultSheet := file.GetSheetName(0)
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := file.SetSheetName(defaultSheet, sheet.name); err != nil {
+				return nil, fmt.Errorf("创建工作表%s失败: %w", sheet.name, err)
+			}
+		} else if _, err := file.NewSheet(sheet.name); err != nil {
+			return nil, fmt.Errorf("创建工作表%s失败: %w", sheet.name, err)
+		}
+		if err := sheet.write(file, styles); err != nil {
+			return nil, fmt.Errorf("写入工作表%s失败: %w", sheet.name, err)
+		}
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("生成Excel文件失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newXLSXStyles 创建单元格样式：标题加粗放大，表头加粗灰底带边框，正文带边框自动换行
+// 返回值按styleDefault..styleLabel排列的样式ID
+func newXLSXStyles(file *excelize.File) ([]int, error) {
+	border := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1},
+		{Type: "top", Color: "000000", Style: 1},
+		{Type: "bottom", Color: "000000", Style: 1},
+	}
+	top := &excelize.Alignment{Vertical: "top", WrapText: true}
+	definitions := []*excelize.Style{
+		styleDefault: {Font: &excelize.Font{Family: xlsxFontFamily, Size: 11}, Alignment: top},
+		styleTitle:   {Font: &excelize.Font{Family: xlsxFontFamily, Size: 14, Bold: true}},
+		styleHeader: {
+			Font:      &excelize.Font{Family: xlsxFontFamily, Size: 11, Bold: true},
+			Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9D9D9"}},
+			Border:    border,
+			Alignment: &excelize.Alignment{Vertical: "center", WrapText: true},
+		},
+		styleBody:  {Font: &excelize.Font{Family: xlsxFontFamily, Size: 11}, Border: border, Alignment: top},
+		styleLabel: {Font: &excelize.Font{Family: xlsxFontFamily, Size: 11, Bold: true}, Border: border, Alignment: top},
+	}
+
+	styles := make([]int, len(definitions))
+	for i, definition := range definitions {
+		id, err := file.NewStyle(definition)
+		if err != nil {
+			return nil, fmt.Errorf("创建Excel样式失败: %w", err)
+		}
+		styles[i] = id
+	}
+	return styles, nil
+}
+
+// buildXLSXSheet 将章节转换为工作表：键值信息两列输出，段落占一行，表格带表头
+func buildXLSXSheet(section *Section, name string) *xlsxSheet {
+	sheet := &xlsxSheet{name: name}
+	fitWidth := func(column int, value string) {
+		for len(sheet.widths) <= column {
+			sheet.widths = append(sheet.widths, 10)
+		}
+		// 中文字符按两个字符宽度估算，超长内容自动换行
+		width := float64(utf8.RuneCountInString(value)+len(value)) / 2
+		for _, line := range strings.Split(value, "\n") {
+			if w := float64(utf8.RuneCountInString(line)+len(line))/2 + 2; w > width {
+				width = w
+			}
+		}
+		if width > maxColumnWidth {
+			width = maxColumnWidth
+		}
+		if width > sheet.widths[column] {
+			sheet.widths[column] = width
+		}
+	}
+
+	for _, field := range section.Fields {
+		sheet.rows = append(sheet.rows, []xlsxCell{
+			{value: field.Label, style: styleLabel},
+			{value: field.Value, style: styleBody},
+		})
+		fitWidth(0, field.Label)
+		fitWidth(1, field.Value)
+	}
+	for _, paragraph := range section.Paragraphs {
+		if len(sheet.rows) > 0 {
+			sheet.rows = append(sheet.rows, nil)
+		}
+		sheet.rows = append(sheet.rows, []xlsxCell{{value: paragraph}})
+		fitWidth(0, strings.SplitN(paragraph, "\n", 2)[0])
+	}
+	if table := section.Table; table != nil && len(table.Headers) > 0 {
+		if len(sheet.rows) > 0 {
+			sheet.rows = append(sheet.rows, nil)
+		}
+		header := make([]xlsxCell, len(table.Headers))
+		for i, title := range table.Headers {
+			header[i] = xlsxCell{value: title, style: styleHeader}
+			fitWidth(i, title)
+		}
+		sheet.rows = append(sheet.rows, header)
+		for _, row := range table.Rows {
+			cells := make([]xlsxCell, len(table.Headers))
+			for i := range table.Headers {
+				cells[i] = xlsxCell{value: cell(row, i), style: styleBody}
+				fitWidth(i, cells[i].value)
+			}
+			sheet.rows = append(sheet.rows, cells)
+		}
+	}
+	return sheet
+}
+
+// write 写出工作表的列宽和单元格，styles为newXLSXStyles返回的样式ID
+func (s *xlsxSheet) write(file *excelize.File, styles []int) error {
+	for i, width := range s.widths {
+		column, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		if err := file.SetColWidth(s.name, column, column, width); err != nil {
+			return err
+		}
+	}
+	for r, row := range s.rows {
+		for c, cell := range row {
+			if cell.value == "" && cell.style == styleDefault {
+				continue
+			}
+			name, err := excelize.CoordinatesToCellName(c+1, r+1)
+			if err != nil {
+				return err
+			}
+			// 超过单元格字符上限的内容由excelize截断
+			if err := file.SetCellStr(s.name, name, cell.value); err != nil {
+				return err
+			}
+			if err := file.SetCellStyle(s.name, name, name, styles[cell.style]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// uniqueSheetName 生成合法且不重复的工作表名称：去除非法字符，截断到31个字符
+func uniqueSheetName(title string, index int, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '[', ']', ':', '*', '?', '/', '\\':
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Sheet" + strconv.Itoa(index+1)
+	}
+	name = truncateRunes(name, maxSheetNameRunes)
+
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := "(" + strconv.Itoa(i) + ")"
+		candidate = truncateRunes(name, maxSheetNameRunes-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// truncateRunes 按字符数截断字符串
+func truncateRunes(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	return string([]rune(value)[:max])
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRenderXLSX(t *testing.T) {
+	const rows = 80
+	doc := testReport(rows)
+	doc.Sections = append(doc.Sections,
+		&Section{Title: "违规/明细", Paragraphs: []string{"第一行\n第二行"}},
+		&Section{Title: "违规:明细"},
+	)
+	data, err := RenderXLSX(doc)
+	if err != nil {
+		t.Fatalf("RenderXLSX() error = %v", err)
+	}
+
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("excelize打开报表失败: %v", err)
+	}
+	defer file.Close()
+
+	// 工作表名称去除非法字符，重名时追加序号
+	wantSheets := []string{"审核结论", "发票明细", "违规_明细", "违规_明细(2)"}
+	if sheets := file.GetSheetList(); !reflect.DeepEqual(sheets, wantSheets) {
+		t.Fatalf("工作表 = %q, want %q", sheets, wantSheets)
+	}
+
+	summary, err := file.GetRows("审核结论")
+	if err != nil {
+		t.Fatalf("读取工作表失败: %v", err)
+	}
+	wantSummary := [][]string{
+		{doc.Title},
+		{doc.Subtitle},
+		nil,
+		{"报销单号", "BX001"},
+		{"审核结果", "通过"},
+		nil,
+		{"规则校验和大模型分析均通过"},
+	}
+	if !reflect.DeepEqual(summary, wantSummary) {
+		t.Errorf("审核结论工作表 = %q, want %q", summary, wantSummary)
+	}
+
+	details, err := file.GetRows("发票明细")
+	if err != nil {
+		t.Fatalf("读取工作表失败: %v", err)
+	}
+	if len(details) != rows+1 {
+		t.Fatalf("发票明细行数 = %d, want 表头+%d行", len(details), rows)
+	}
+	if !reflect.DeepEqual(details[0], []string{"发票号码", "销售方", "校验结论"}) {
+		t.Errorf("表头 = %q", details[0])
+	}
+	for i, row := range details[1:] {
+		want := []string{fmt.Sprintf("发票%03d", i+1), "某某酒店有限公司", "通过"}
+		if !reflect.DeepEqual(row, want) {
+			t.Errorf("第%d行 = %q, want %q", i+1, row, want)
+		}
+	}
+
+	if value, _ := file.GetCellValue("违规_明细", "A1"); value != "第一行\n第二行" {
+		t.Errorf("多行段落 = %q, 应保留换行", value)
+	}
+	if width, _ := file.GetColWidth("发票明细", "B"); width <= 10 {
+		t.Errorf("销售方列宽 = %v, 应按内容加宽", width)
+	}
+}
+
+func TestRenderXLSXLongCell(t *testing.T) {
+	long := strings.Repeat("长", excelize.TotalCellChars+10)
+	data, err := RenderXLSX(&Document{Sections: []*Section{{Title: "说明", Paragraphs: []string{long}}}})
+	if err != nil {
+		t.Fatalf("RenderXLSX() error = %v", err)
+	}
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("excelize打开报表失败: %v", err)
+	}
+	defer file.Close()
+
+	value, _ := file.GetCellValue("说明", "A1")
+	if got := len([]rune(value)); got != excelize.TotalCellChars {
+		t.Errorf("单元格字符数 = %d, want 截断为%d", got, excelize.TotalCellChars)
+	}
+}
+
+func TestRenderXLSXEmpty(t *testing.T) {
+	if _, err := RenderXLSX(&Document{Title: "报销审核报告"}); err == nil {
+		t.Errorf("报表内容为空时应返回错误")
+	}
+}
@@ -214,16 +214,41 @@ func FromJSON(jsonStr string, obj interface{}) error {
 	return json.Unmarshal([]byte(jsonStr), obj)
 }
 
-// Copy 深拷贝对象
+// Copy 深拷贝对象，dst必须为非nil指针
+// src可以是值或指针，深拷贝后的值需能赋值给dst指向的类型
 func Copy(src, dst interface{}) error {
-	// TODO: 实现深拷贝逻辑
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() {
+		return fmt.Errorf("目标必须为非nil指针")
+	}
+	target := dstValue.Elem()
+	if src == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	srcValue := reflect.ValueOf(src)
+	if !srcValue.Type().AssignableTo(target.Type()) && srcValue.Kind() == reflect.Ptr {
+		if srcValue.IsNil() {
+			target.Set(reflect.Zero(target.Type()))
+			return nil
+		}
+		srcValue = srcValue.Elem()
+	}
+	if !srcValue.Type().AssignableTo(target.Type()) {
+		return fmt.Errorf("类型%s不能复制到%s", srcValue.Type(), target.Type())
+	}
+
+	target.Set(deepCopy(srcValue, make(map[copyKey]reflect.Value)))
 	return nil
 }
 
-// Clone 克隆对象
+// Clone 深拷贝对象，返回与src类型相同的副本
 func Clone(src interface{}) interface{} {
-	// TODO: 实现克隆逻辑
-	return nil
+	if src == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(src), make(map[copyKey]reflect.Value)).Interface()
 }
 
 // IsNil 检查对象是否为nil
@@ -266,10 +291,13 @@ func IsZero(value interface{}) bool {
 	}
 }
 
-// Equal 比较两个对象是否相等
+// Equal 深度比较两个对象是否相等，类型不同时不相等
+// 浮点数按相对误差比较，time.Time比较时刻，nil与空切片/map视为相等
 func Equal(a, b interface{}) bool {
-	// TODO: 实现对象比较逻辑
-	return false
+	if a == nil || b == nil {
+		return IsNil(a) && IsNil(b)
+	}
+	return deepEqual(reflect.ValueOf(a), reflect.ValueOf(b), make(map[equalKey]bool))
 }
 
 // GetTypeName 获取类型名称
@@ -286,20 +314,47 @@ func GetTypeName(value interface{}) string {
 	return reflectType.Name()
 }
 
-// GetFieldValue 获取结构体字段值
+// GetFieldValue 获取结构体字段值，field支持字段名或json标签名，嵌套字段以.分隔
+// 字段不存在、未导出或路径上存在nil指针时返回nil
 func GetFieldValue(obj interface{}, field string) interface{} {
-	// TODO: 实现获取字段值逻辑
-	return nil
+	if obj == nil {
+		return nil
+	}
+	value, err := lookupField(reflect.ValueOf(obj), field, false)
+	if err != nil || !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
 }
 
-// SetFieldValue 设置结构体字段值
+// SetFieldValue 设置结构体字段值，obj必须为结构体指针，field规则同GetFieldValue
+// 路径上的nil结构体指针自动创建；数值类型之间自动转换，value为nil时设置为零值
 func SetFieldValue(obj interface{}, field string, value interface{}) error {
-	// TODO: 实现设置字段值逻辑
+	objValue := reflect.ValueOf(obj)
+	if objValue.Kind() != reflect.Ptr || objValue.IsNil() {
+		return fmt.Errorf("对象必须为非nil结构体指针")
+	}
+
+	target, err := lookupField(objValue, field, true)
+	if err != nil {
+		return err
+	}
+	if !target.IsValid() || !target.CanSet() {
+		return fmt.Errorf("字段%s不可设置", field)
+	}
+
+	converted, err := assignableValue(value, target.Type())
+	if err != nil {
+		return fmt.Errorf("设置字段%s失败: %w", field, err)
+	}
+	target.Set(converted)
 	return nil
 }
 
-// HasField 检查结构体是否有指定字段
+// HasField 检查结构体是否有指定的可导出字段，field规则同GetFieldValue
 func HasField(obj interface{}, field string) bool {
-	// TODO: 实现检查字段逻辑
-	return false
+	if obj == nil {
+		return false
+	}
+	return hasFieldType(reflect.TypeOf(obj), field)
 }
\ No newline at end of file
@@ -0,0 +1,350 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// floatEqualEpsilon 浮点数比较的相对误差
+const floatEqualEpsilon = 1e-9
+
+// timeType time.Time的反射类型
+var timeType = reflect.TypeOf(time.Time{})
+
+// ErrFieldNotFound 结构体字段不存在
+var ErrFieldNotFound = errors.New("字段不存在")
+
+// copyKey 深拷贝时已复制的引用，用于保持共享引用和处理循环引用
+type copyKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// deepCopy 深拷贝反射值
+// 指针、map、切片按引用地址记录已复制的副本，同一引用只复制一次，循环引用不会无限递归；
+// 结构体未导出字段无法通过反射赋值，按值浅拷贝；chan、func按引用共享
+func deepCopy(src reflect.Value, copied map[copyKey]reflect.Value) reflect.Value {
+	if !src.IsValid() {
+		return src
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return reflect.Zero(src.Type())
+		}
+		key := copyKey{ptr: src.Pointer(), typ: src.Type()}
+		if dst, ok := copied[key]; ok {
+			return dst
+		}
+		dst := reflect.New(src.Type().Elem())
+		copied[key] = dst
+		dst.Elem().Set(deepCopy(src.Elem(), copied))
+		return dst
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return reflect.Zero(src.Type())
+		}
+		dst := reflect.New(src.Type()).Elem()
+		dst.Set(deepCopy(src.Elem(), copied))
+		return dst
+
+	case reflect.Map:
+		if src.IsNil() {
+			return reflect.Zero(src.Type())
+		}
+		key := copyKey{ptr: src.Pointer(), typ: src.Type()}
+		if dst, ok := copied[key]; ok {
+			return dst
+		}
+		dst := reflect.MakeMapWithSize(src.Type(), src.Len())
+		copied[key] = dst
+		iter := src.MapRange()
+		for iter.Next() {
+			// 键按值复制，避免指针键的身份改变
+			dst.SetMapIndex(iter.Key(), deepCopy(iter.Value(), copied))
+		}
+		return dst
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return reflect.Zero(src.Type())
+		}
+		key := copyKey{ptr: src.Pointer(), typ: src.Type(), len: src.Len()}
+		if dst, ok := copied[key]; ok {
+			return dst
+		}
+		dst := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		copied[key] = dst
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(deepCopy(src.Index(i), copied))
+		}
+		return dst
+
+	case reflect.Array:
+		dst := reflect.New(src.Type()).Elem()
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(deepCopy(src.Index(i), copied))
+		}
+		return dst
+
+	case reflect.Struct:
+		dst := reflect.New(src.Type()).Elem()
+		// 先整体赋值保留未导出字段，再深拷贝可导出字段
+		if src.CanInterface() {
+			dst.Set(src)
+		}
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				dst.Field(i).Set(deepCopy(src.Field(i), copied))
+			}
+		}
+		return dst
+
+	default:
+		if !src.CanInterface() {
+			return reflect.Zero(src.Type())
+		}
+		dst := reflect.New(src.Type()).Elem()
+		dst.Set(src)
+		return dst
+	}
+}
+
+// equalKey 深度比较时正在比较的引用对，用于处理循环引用
+type equalKey struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// deepEqual 深度比较反射值
+// 浮点数按相对误差比较，NaN与NaN视为相等；time.Time比较时刻，忽略时区和单调时钟；
+// nil切片/map与空切片/map视为相等；func只有都为nil时相等
+func deepEqual(a, b reflect.Value, visited map[equalKey]bool) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	if a.Type() == timeType && a.CanInterface() && b.CanInterface() {
+		return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if a.Kind() == reflect.Ptr && (a.IsNil() || b.IsNil()) {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Kind() != reflect.Ptr && (a.Len() == 0 || b.Len() == 0) {
+			return a.Len() == b.Len()
+		}
+		if a.Pointer() == b.Pointer() && (a.Kind() != reflect.Slice || a.Len() == b.Len()) {
+			return true
+		}
+		key := equalKey{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+		if visited[key] {
+			return true
+		}
+		visited[key] = true
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		return deepEqual(a.Elem(), b.Elem(), visited)
+
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return deepEqual(a.Elem(), b.Elem(), visited)
+
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		iter := a.MapRange()
+		for iter.Next() {
+			value := b.MapIndex(iter.Key())
+			if !value.IsValid() || !deepEqual(iter.Value(), value, visited) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !deepEqual(a.Index(i), b.Index(i), visited) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !deepEqual(a.Field(i), b.Field(i), visited) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Float32, reflect.Float64:
+		return floatEqual(a.Float(), b.Float())
+
+	case reflect.Complex64, reflect.Complex128:
+		return floatEqual(real(a.Complex()), real(b.Complex())) && floatEqual(imag(a.Complex()), imag(b.Complex()))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+
+	case reflect.String:
+		return a.String() == b.String()
+
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+
+	case reflect.Func:
+		return a.IsNil() && b.IsNil()
+
+	default:
+		// chan、unsafe.Pointer比较引用地址
+		return a.Pointer() == b.Pointer()
+	}
+}
+
+// floatEqual 按相对误差比较浮点数，NaN与NaN相等，同号无穷大相等
+func floatEqual(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	if a == b {
+		return true
+	}
+	if math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+	scale := math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= floatEqualEpsilon*scale
+}
+
+// lookupField 按字段路径查找结构体字段，路径以.分隔，各级可使用字段名或json标签名
+// allocate为true时为路径上的nil结构体指针分配空间(用于赋值)，否则遇到nil指针返回无效值
+func lookupField(value reflect.Value, path string, allocate bool) (reflect.Value, error) {
+	if path == "" {
+		return reflect.Value{}, fmt.Errorf("%w: 字段名为空", ErrFieldNotFound)
+	}
+
+	for _, name := range strings.Split(path, ".") {
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				if !allocate || value.Kind() != reflect.Ptr || !value.CanSet() {
+					return reflect.Value{}, nil
+				}
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("%w: %s不是结构体", ErrFieldNotFound, value.Type())
+		}
+
+		field, ok := findStructField(value.Type(), name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("%w: %s.%s", ErrFieldNotFound, value.Type(), name)
+		}
+		// 提升字段所在的嵌入结构体指针为nil时，按allocate决定是否分配
+		for i, index := range field.Index {
+			if i > 0 && value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					if !allocate || !value.CanSet() {
+						return reflect.Value{}, nil
+					}
+					value.Set(reflect.New(value.Type().Elem()))
+				}
+				value = value.Elem()
+			}
+			value = value.Field(index)
+		}
+	}
+	return value, nil
+}
+
+// findStructField 按字段名或json标签名查找可导出字段(含嵌入结构体的提升字段)
+func findStructField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	if field, ok := typ.FieldByName(name); ok && field.IsExported() {
+		return field, true
+	}
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() {
+			continue
+		}
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// hasFieldType 按类型检查字段路径是否存在，不要求值非nil
+func hasFieldType(typ reflect.Type, path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, name := range strings.Split(path, ".") {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return false
+		}
+		field, ok := findStructField(typ, name)
+		if !ok {
+			return false
+		}
+		typ = field.Type
+	}
+	return true
+}
+
+// assignableValue 将值转换为目标类型，支持直接赋值和数值类型之间的转换，nil转换为零值
+func assignableValue(value interface{}, target reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(target), nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(target) {
+		return v, nil
+	}
+	if isNumberKind(v.Kind()) && isNumberKind(target.Kind()) {
+		return v.Convert(target), nil
+	}
+	if v.Kind() == target.Kind() && v.Type().ConvertibleTo(target) {
+		return v.Convert(target), nil
+	}
+	return reflect.Value{}, fmt.Errorf("类型%s不能赋值给%s", v.Type(), target)
+}
+
+// isNumberKind 是否为数值类型
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+}
+
+type testPerson struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Score   float64           `json:"score"`
+	Tags    []string          `json:"tags"`
+	Extra   map[string]int    `json:"extra"`
+	Address *testAddress      `json:"address"`
+	Friend  *testPerson       `json:"friend"`
+	Labels  map[string]string `json:"-"`
+	secret  string
+}
+
+func TestCopy(t *testing.T) {
+	src := &testPerson{
+		Name:    "张三",
+		Tags:    []string{"差旅"},
+		Extra:   map[string]int{"a": 1},
+		Address: &testAddress{City: "北京"},
+		secret:  "s",
+	}
+	src.Friend = src // 循环引用
+
+	var dst testPerson
+	if err := Copy(src, &dst); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	src.Tags[0] = "招待"
+	src.Extra["a"] = 2
+	src.Address.City = "上海"
+
+	if dst.Tags[0] != "差旅" || dst.Extra["a"] != 1 || dst.Address.City != "北京" {
+		t.Errorf("Copy() 未深拷贝: %+v", dst)
+	}
+	if dst.Friend == nil || dst.Friend.Friend != dst.Friend {
+		t.Errorf("Copy() 未保持循环引用")
+	}
+	if dst.secret != "s" {
+		t.Errorf("Copy() 未复制未导出字段")
+	}
+
+	tests := []struct {
+		name    string
+		src     interface{}
+		dst     interface{}
+		wantErr bool
+	}{
+		{name: "目标不是指针", src: 1, dst: 1, wantErr: true},
+		{name: "目标为nil指针", src: 1, dst: (*int)(nil), wantErr: true},
+		{name: "类型不兼容", src: "a", dst: new(int), wantErr: true},
+		{name: "源为nil时设置零值", src: nil, dst: new(int)},
+		{name: "源为nil指针时设置零值", src: (*testPerson)(nil), dst: new(testPerson)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Copy(tt.src, tt.dst); (err != nil) != tt.wantErr {
+				t.Errorf("Copy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClone(t *testing.T) {
+	src := map[string][]int{"a": {1, 2}}
+	got := Clone(src).(map[string][]int)
+	src["a"][0] = 9
+	if got["a"][0] != 1 {
+		t.Errorf("Clone() 未深拷贝: %v", got)
+	}
+	if Clone(nil) != nil {
+		t.Errorf("Clone(nil) 应返回nil")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		a    interface{}
+		b    interface{}
+		want bool
+	}{
+		{name: "都为nil", a: nil, b: nil, want: true},
+		{name: "nil与nil切片", a: nil, b: []int(nil), want: true},
+		{name: "nil与非nil值", a: nil, b: 0, want: false},
+		{name: "类型不同", a: 1, b: int64(1), want: false},
+		{name: "浮点数按相对误差比较", a: 0.1 + 0.2, b: 0.3, want: true},
+		{name: "浮点数不相等", a: 0.3, b: 0.31, want: false},
+		{name: "时间按时刻比较", a: now, b: now.UTC(), want: true},
+		{name: "nil切片与空切片相等", a: []int(nil), b: []int{}, want: true},
+		{name: "nil map与空map相等", a: map[string]int(nil), b: map[string]int{}, want: true},
+		{
+			name: "结构体深度比较",
+			a:    &testPerson{Name: "张三", Address: &testAddress{City: "北京"}},
+			b:    &testPerson{Name: "张三", Address: &testAddress{City: "北京"}},
+			want: true,
+		},
+		{
+			name: "嵌套字段不同",
+			a:    &testPerson{Address: &testAddress{City: "北京"}},
+			b:    &testPerson{Address: &testAddress{City: "上海"}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualCycle(t *testing.T) {
+	a := &testPerson{Name: "张三"}
+	a.Friend = a
+	b := &testPerson{Name: "张三"}
+	b.Friend = b
+	if !Equal(a, b) {
+		t.Errorf("Equal() 循环引用的相同结构应相等")
+	}
+}
+
+func TestGetFieldValue(t *testing.T) {
+	person := &testPerson{Name: "张三", Age: 30, Address: &testAddress{City: "北京"}, secret: "s"}
+
+	tests := []struct {
+		name  string
+		obj   interface{}
+		field string
+		want  interface{}
+	}{
+		{name: "按字段名", obj: person, field: "Name", want: "张三"},
+		{name: "按json标签", obj: person, field: "age", want: 30},
+		{name: "嵌套字段", obj: person, field: "address.city", want: "北京"},
+		{name: "结构体值", obj: *person, field: "Name", want: "张三"},
+		{name: "路径上有nil指针", obj: person, field: "friend.name", want: nil},
+		{name: "字段不存在", obj: person, field: "Unknown", want: nil},
+		{name: "未导出字段", obj: person, field: "secret", want: nil},
+		{name: "对象为nil", obj: nil, field: "Name", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetFieldValue(tt.obj, tt.field); got != tt.want {
+				t.Errorf("GetFieldValue(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFieldValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		value   interface{}
+		check   func(p *testPerson) bool
+		wantErr bool
+	}{
+		{name: "按字段名设置", field: "Name", value: "李四", check: func(p *testPerson) bool { return p.Name == "李四" }},
+		{name: "数值类型自动转换", field: "age", value: int64(40), check: func(p *testPerson) bool { return p.Age == 40 }},
+		{name: "整数转浮点数", field: "score", value: 90, check: func(p *testPerson) bool { return p.Score == 90 }},
+		{name: "自动创建nil结构体指针", field: "address.city", value: "上海", check: func(p *testPerson) bool { return p.Address != nil && p.Address.City == "上海" }},
+		{name: "nil设置为零值", field: "Name", value: nil, check: func(p *testPerson) bool { return p.Name == "" }},
+		{name: "类型不兼容", field: "Age", value: "三十", wantErr: true},
+		{name: "字段不存在", field: "Unknown", value: 1, wantErr: true},
+		{name: "未导出字段不可设置", field: "secret", value: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			person := &testPerson{Name: "张三"}
+			err := SetFieldValue(person, tt.field, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetFieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !tt.check(person) {
+				t.Errorf("SetFieldValue() 结果不符: %+v", person)
+			}
+		})
+	}
+
+	if err := SetFieldValue(testPerson{}, "Name", "李四"); err == nil {
+		t.Errorf("SetFieldValue() 对象不是指针时应返回错误")
+	}
+}
+
+func TestHasField(t *testing.T) {
+	tests := []struct {
+		name  string
+		obj   interface{}
+		field string
+		want  bool
+	}{
+		{name: "字段名", obj: testPerson{}, field: "Name", want: true},
+		{name: "json标签", obj: &testPerson{}, field: "tags", want: true},
+		{name: "nil指针上的嵌套字段", obj: &testPerson{}, field: "address.city", want: true},
+		{name: "json标签为-时只能用字段名", obj: testPerson{}, field: "Labels", want: true},
+		{name: "未导出字段", obj: testPerson{}, field: "secret", want: false},
+		{name: "字段不存在", obj: testPerson{}, field: "Unknown", want: false},
+		{name: "对象为nil", obj: nil, field: "Name", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasField(tt.obj, tt.field); got != tt.want {
+				t.Errorf("HasField(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrFieldNotFound(t *testing.T) {
+	err := SetFieldValue(&testPerson{}, "address.unknown", 1)
+	if !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("SetFieldValue() error = %v, want ErrFieldNotFound", err)
+	}
+}
@@ -0,0 +1,13 @@
+package utils
+
+// SafeTruncate 安全截取切片前n个元素
+// n大于切片长度时返回原切片，n小于0时按0处理，避免越界panic
+func SafeTruncate[T any](s []T, n int) []T {
+	if n < 0 {
+		n = 0
+	}
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSafeTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []int
+		n    int
+		want []int
+	}{
+		{name: "截取前n个", s: []int{1, 2, 3}, n: 2, want: []int{1, 2}},
+		{name: "n等于长度返回原切片", s: []int{1, 2, 3}, n: 3, want: []int{1, 2, 3}},
+		{name: "n大于长度返回原切片", s: []int{1, 2}, n: 5, want: []int{1, 2}},
+		{name: "n为0返回空切片", s: []int{1, 2}, n: 0, want: []int{}},
+		{name: "n为负数按0处理", s: []int{1, 2}, n: -1, want: []int{}},
+		{name: "nil切片", s: nil, n: 3, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SafeTruncate(tt.s, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SafeTruncate(%v, %d) = %v, want %v", tt.s, tt.n, got, tt.want)
+			}
+		})
+	}
+}
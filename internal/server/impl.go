@@ -8,24 +8,43 @@ import (
 
 	"reimbursement-audit/internal/api/handler"
 	"reimbursement-audit/internal/api/middleware"
-	"reimbursement-audit/internal/application/service"
 	"reimbursement-audit/internal/config"
+	"reimbursement-audit/internal/domain/audit"
 	"reimbursement-audit/internal/domain/ocr"
-	"reimbursement-audit/internal/domain/ocr/provider"
-	"reimbursement-audit/internal/domain/reimbursement"
-	storage "reimbursement-audit/internal/infra/storage/file"
-	mysqlRepo "reimbursement-audit/internal/infra/storage/mysql"
+	"reimbursement-audit/internal/domain/rag"
 	"reimbursement-audit/internal/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// readinessComponentRAG RAG组件的就绪状态名称
+	readinessComponentRAG = "rag"
+	// readinessComponentRAGSelfTest RAG启动自检的就绪状态名称，与RAG健康检查分开记录
+	readinessComponentRAGSelfTest = "rag_self_test"
+	// readinessComponentMySQL MySQL数据库的就绪状态名称
+	readinessComponentMySQL = "mysql"
+	// readinessComponentLLM 大模型服务的就绪状态名称
+	readinessComponentLLM = "llm"
+	// readinessComponentPGVector pgvector向量库的就绪状态名称
+	readinessComponentPGVector = "pgvector"
+	// defaultSelfTestTimeout 启动自检默认超时时间
+	defaultSelfTestTimeout = 30 * time.Second
+)
+
 // serverImpl 服务器实现
 type serverImpl struct {
 	config    *Config
 	appConfig *config.Config
 	engine    *gin.Engine
 	server    *http.Server
+	readiness *Readiness
+	logger    logger.Logger
+
+	deps         *dependencies
+	ocrTaskQueue *ocr.TaskQueue
+	ocrRetrier   *ocr.AutoRetrier
+	auditRetrier *audit.AutoRetrier
 }
 
 // Start 启动服务器
@@ -48,6 +67,26 @@ func (s *serverImpl) Start() error {
 
 // Stop 停止服务器
 func (s *serverImpl) Stop(ctx context.Context) error {
+	if s.auditRetrier != nil {
+		s.auditRetrier.Stop()
+	}
+	if s.ocrRetrier != nil {
+		s.ocrRetrier.Stop()
+	}
+	if s.ocrTaskQueue != nil {
+		s.ocrTaskQueue.Stop()
+	}
+	// 日志记录器最后关闭，确保关闭过程中的日志能写出
+	if s.logger != nil {
+		defer s.logger.Close()
+	}
+	if s.deps != nil {
+		defer s.deps.mysqlClient.Close()
+		if s.deps.vectorStore != nil {
+			defer s.deps.vectorStore.Close()
+		}
+	}
+
 	if s.server == nil {
 		return nil
 	}
@@ -83,74 +122,48 @@ func (s *serverImpl) RegisterRoutes() {
 	// 注册trace中间件，用于生成和传播traceId
 	s.engine.Use(middleware.TraceMiddleware())
 
-	// 创建日志记录器
-	// TODO: 从配置中获取日志配置
-	loggerImpl, err := logger.NewLogger(logger.DefaultConfig())
-	if err != nil {
-		panic(fmt.Sprintf("创建日志记录器失败: %v", err))
-	}
+	// 按日志配置创建日志记录器，中间件和各组件共享同一实例
+	loggerInstance := s.newLogger()
+	s.logger = loggerInstance
 
 	// 注册日志中间件，用于将带有traceId的logger注入到Gin上下文中
-	s.engine.Use(middleware.LoggerMiddleware(loggerImpl))
+	s.engine.Use(middleware.LoggerMiddleware(loggerInstance))
 
-	// 创建logger实例
-	loggerInstance, _ := logger.NewLogger(logger.DefaultConfig())
+	// 注册认证中间件，解析Bearer令牌并将用户ID、姓名和角色写入上下文，需要角色的路由再用RequireRole校验
+	authConfig := middleware.AuthConfig{}
+	if s.appConfig != nil {
+		authConfig.Secret = s.appConfig.Security.JWTSecret
+		authConfig.Issuer = s.appConfig.Security.JWTIssuer
+	}
+	if authConfig.Secret == "" {
+		loggerInstance.Warn("未配置JWT密钥(security.jwt_secret)，需要角色的接口将全部返回401")
+	}
+	s.engine.Use(middleware.NewAuth(authConfig).Middleware())
+
+	// 按配置注册响应压缩中间件，审核详情、知识库分片等大响应按Accept-Encoding压缩
+	if s.appConfig != nil && s.appConfig.Server.CompressionEnabled {
+		s.engine.Use(middleware.CompressionMiddleware(middleware.CompressionConfig{
+			MinSize: s.appConfig.Server.CompressionMinSize,
+			Level:   s.appConfig.Server.CompressionLevel,
+		}))
+	}
 
 	// 注册健康检查路由
 	s.engine.GET("/health", HealthCheck)
-	s.engine.GET("/ready", ReadyCheck)
+	s.engine.GET("/ready", ReadyCheck(s.readiness))
 	s.engine.GET("/version", VersionCheck("1.0.0"))
 
-	// 创建MySQL客户端（实际应该从依赖注入获取）
-	mysqlClient := mysqlRepo.NewClient(loggerInstance)
-	// TODO: 这里应该从配置中获取数据库连接信息
-	// mysqlClient.Connect(ctx, config)
-
-	// 创建文件存储服务
-	// TODO: 从配置中获取存储路径和URL
-	localStorage := storage.NewLocalStorage("./uploads", "http://localhost:8080/uploads")
-	fileService := storage.NewService(localStorage)
-
-	// 创建OCR服务
-	// 从配置中获取OCR配置
-	var ocrConfig ocr.Config
-	if s.appConfig != nil && s.appConfig.OCR.Provider != "" {
-		ocrConfig = ocr.Config{
-			SecretID:   s.appConfig.OCR.SecretID,
-			SecretKey:  s.appConfig.OCR.SecretKey,
-			Region:     s.appConfig.OCR.Region,
-			Timeout:    s.appConfig.OCR.Timeout,
-			MaxRetries: s.appConfig.OCR.MaxRetries,
-		}
-	} else {
-		// 使用默认配置
-		ocrConfig = ocr.Config{
-			SecretID:   "", // 需要从环境变量或配置文件中获取
-			SecretKey:  "", // 需要从环境变量或配置文件中获取
-			Region:     "ap-beijing",
-			Timeout:    30,
-			MaxRetries: 3,
-		}
-	}
-	ocrProvider := provider.NewTencentProvider(ocrConfig, loggerInstance)
-
-	reimbursementRepo := mysqlRepo.NewReimbursementRepository(mysqlClient, loggerInstance)
-
-	ocrRepo := mysqlRepo.NewOCRRepository(mysqlClient, loggerInstance)
+	// 按配置装配数据库、文件存储、OCR等依赖
+	s.deps = s.buildDependencies(loggerInstance)
+	reimbursementAppService := s.deps.reimbursementAppService
 
-	// 创建领域服务
-	reimbursementDomainService := reimbursement.NewDomainService(reimbursementRepo, loggerInstance)
-	ocrDomainService := ocr.NewParserService(ocrProvider, ocrRepo, loggerInstance)
+	// 注册依赖健康检查，就绪检查时汇总各依赖的状态
+	s.setupReadinessChecks()
 
-	// 创建应用服务
-	reimbursementAppService := service.NewReimbursementApplicationService(
-		reimbursementRepo,
-		reimbursementDomainService,
-		ocrDomainService,
-		ocrRepo,
-		fileService,
-		loggerInstance,
-	)
+	// 执行RAG启动自检，自检结果计入就绪状态
+	if s.deps.ragService != nil {
+		s.runRAGSelfTest(s.deps.ragService, loggerInstance)
+	}
 
 	// 创建上传处理器
 	uploadHandler := handler.NewUploadHandler(reimbursementAppService)
@@ -159,14 +172,109 @@ func (s *serverImpl) RegisterRoutes() {
 	s.engine.POST("/api/v1/reimbursement/upload", uploadHandler.UploadReimbursement)
 	s.engine.POST("/api/v1/invoices/upload", uploadHandler.UploadInvoices)
 	s.engine.POST("/api/v1/invoices/batch-upload", uploadHandler.BatchUpload)
+	s.engine.PATCH("/api/v1/reimbursements/:id/tags", uploadHandler.UpdateReimbursementTags)
+	s.engine.POST("/api/v1/invoices/:id/ocr/retry", uploadHandler.RetryInvoiceOCR)
+
+	// 创建查询处理器
+	queryHandler := handler.NewQueryHandler(reimbursementAppService)
+
+	// 注册查询相关路由
+	s.engine.GET("/api/v1/reimbursements", queryHandler.ListReimbursements)
+	s.engine.GET("/api/v1/invoices/:id", queryHandler.GetInvoiceByID)
+	s.engine.GET("/api/v1/invoices/:id/ocr", queryHandler.GetInvoiceOCRStatus)
+
+	// 创建规则处理器并注册规则相关路由
+	s.registerRuleRoutes(handler.NewRuleHandler(s.deps.ruleService))
 
-	// TODO: 注册其他路由
-	// s.engine.POST("/api/v1/audit", auditHandler)
-	// s.engine.GET("/api/v1/query", queryHandler)
-	// s.engine.POST("/api/v1/rules", createRuleHandler)
-	// s.engine.PUT("/api/v1/rules/:id", updateRuleHandler)
-	// s.engine.DELETE("/api/v1/rules/:id", deleteRuleHandler)
-	// s.engine.GET("/api/v1/rules", listRulesHandler)
+	// 审核与知识库服务依赖RAG，未启用RAG时不注册相关路由
+	if s.deps.auditAppService != nil {
+		s.registerAuditRoutes(handler.NewAuditHandler(s.deps.auditAppService))
+	}
+	if s.deps.ragService != nil {
+		s.registerKnowledgeRoutes(handler.NewKnowledgeHandler(s.deps.ragService))
+	}
+
+}
+
+// registerRuleRoutes 注册规则管理相关路由
+func (s *serverImpl) registerRuleRoutes(ruleHandler *handler.RuleHandler) {
+	s.engine.POST("/api/v1/rules", middleware.RequireRole(middleware.RoleAuditorAdmin), ruleHandler.CreateRule)
+	s.engine.GET("/api/v1/rules", ruleHandler.GetRules)
+	s.engine.PUT("/api/v1/rules/:id", middleware.RequireRole(middleware.RoleAuditorAdmin), ruleHandler.UpdateRule)
+	s.engine.DELETE("/api/v1/rules/:id", middleware.RequireRole(middleware.RoleAuditorAdmin), ruleHandler.DeleteRule)
+	s.engine.POST("/api/v1/rules/:id/enable", middleware.RequireRole(middleware.RoleAuditorAdmin), ruleHandler.EnableRule)
+	s.engine.POST("/api/v1/rules/:id/disable", middleware.RequireRole(middleware.RoleAuditorAdmin), ruleHandler.DisableRule)
+	s.engine.GET("/api/v1/rules/coverage", ruleHandler.GetRuleCoverage)
+	s.engine.GET("/api/v1/rules/seller-blacklist", ruleHandler.ListSellerBlacklist)
+	s.engine.POST("/api/v1/rules/seller-blacklist", middleware.RequireRole(middleware.RoleAuditorAdmin), ruleHandler.AddSellerBlacklistEntry)
+	s.engine.DELETE("/api/v1/rules/seller-blacklist/:id", middleware.RequireRole(middleware.RoleAuditorAdmin), ruleHandler.RemoveSellerBlacklistEntry)
+	s.engine.POST("/api/v1/rules/test", ruleHandler.TestRule)
+	s.engine.POST("/api/v1/rules/:id/test", ruleHandler.TestRule)
+}
+
+// registerAuditRoutes 注册审核相关路由
+func (s *serverImpl) registerAuditRoutes(auditHandler *handler.AuditHandler) {
+	s.engine.POST("/api/v1/audit", auditHandler.StartAudit)
+	s.engine.GET("/api/v1/audit/:id/status", auditHandler.GetAuditStatus)
+	s.engine.GET("/api/v1/audit/:id/result", auditHandler.GetAuditResult)
+	s.engine.POST("/api/v1/audit/:id/retry", auditHandler.RetryAudit)
+	s.engine.GET("/api/v1/audits/sla-breaches", auditHandler.ListSLABreaches)
+	s.engine.GET("/api/v1/audits", auditHandler.ListAudits)
+	s.engine.GET("/api/v1/audit/:id/standards", auditHandler.GetAuditStandards)
+	s.engine.GET("/api/v1/audit/:id/attestation", auditHandler.GetAuditAttestation)
+	s.engine.GET("/api/v1/audit/:id/report", auditHandler.ExportAuditReport)
+	s.engine.POST("/api/v1/audit/attestations/verify", auditHandler.VerifyAuditAttestation)
+	s.engine.GET("/api/v1/reimbursement/:id/applicable-rules", auditHandler.GetApplicableRules)
+	s.engine.POST("/api/v1/audit/:id/override", middleware.RequireRole(middleware.RoleReviewer, middleware.RoleAuditorAdmin), auditHandler.OverrideAudit)
+	s.engine.POST("/api/v1/rules/:id/reaudit-affected", middleware.RequireRole(middleware.RoleAuditorAdmin), auditHandler.ReauditAffected)
+	s.engine.GET("/api/v1/rules/reaudit-batches/:batch_id", auditHandler.GetReauditBatch)
+	s.engine.GET("/api/v1/reimbursements/:id/audits", auditHandler.ListAuditHistory)
+}
+
+// registerKnowledgeRoutes 注册知识库相关路由
+func (s *serverImpl) registerKnowledgeRoutes(knowledgeHandler *handler.KnowledgeHandler) {
+	s.engine.GET("/api/v1/knowledge/chunks", knowledgeHandler.ListChunks)
+	s.engine.POST("/api/v1/knowledge/query", knowledgeHandler.Query)
+	s.engine.GET("/api/v1/knowledge/embeddings", middleware.RequireRole(middleware.RoleDataScientist, middleware.RoleAuditorAdmin), knowledgeHandler.ListEmbeddings)
+	s.engine.GET("/api/v1/knowledge/embedding-cache/stats", knowledgeHandler.GetEmbeddingCacheStats)
+}
+
+// setupReadinessChecks 按配置设置就绪检查的超时和缓存时间，并注册已装配依赖的健康检查
+func (s *serverImpl) setupReadinessChecks() {
+	if s.appConfig != nil {
+		s.readiness.SetCheckTimeout(time.Duration(s.appConfig.Server.ReadinessTimeout) * time.Millisecond)
+		s.readiness.SetCacheTTL(time.Duration(s.appConfig.Server.ReadinessCacheTTL) * time.Second)
+	}
+	if s.deps != nil && s.deps.mysqlClient != nil {
+		s.readiness.RegisterCheck(readinessComponentMySQL, s.deps.mysqlClient.Ping)
+	}
+	// 未启用RAG时不装配RAG、大模型和向量库，不注册其健康检查
+	if s.deps != nil && s.deps.ragService != nil {
+		s.readiness.RegisterCheck(readinessComponentRAG, s.deps.ragService.HealthCheck)
+		s.readiness.RegisterCheck(readinessComponentLLM, s.deps.llmClient.HealthCheck)
+		s.readiness.RegisterCheck(readinessComponentPGVector, s.deps.vectorStore.Ping)
+	}
+}
+
+// runRAGSelfTest 执行RAG金丝雀自检（配置开启时），失败时标记服务未就绪
+func (s *serverImpl) runRAGSelfTest(ragService *rag.RAGService, log logger.Logger) {
+	if s.appConfig == nil || !s.appConfig.RAG.Enabled || !s.appConfig.RAG.SelfTest {
+		return
+	}
+
+	timeout := time.Duration(s.appConfig.RAG.SelfTestTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultSelfTestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := ragService.RunCanary(ctx)
+	if err != nil {
+		log.Error("RAG启动自检失败，服务标记为未就绪", logger.NewField("error", err.Error()))
+	}
+	s.readiness.SetComponentError(readinessComponentRAGSelfTest, err)
 }
 
 // SetupMiddleware 设置中间件
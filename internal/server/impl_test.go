@@ -0,0 +1,218 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/api/handler"
+	"reimbursement-audit/internal/api/middleware"
+	"reimbursement-audit/internal/domain/rag"
+	"reimbursement-audit/internal/pkg/logger/loggertest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func TestRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &serverImpl{engine: gin.New()}
+	s.registerRuleRoutes(handler.NewRuleHandler(nil))
+	s.registerAuditRoutes(handler.NewAuditHandler(nil))
+	s.registerKnowledgeRoutes(handler.NewKnowledgeHandler(nil))
+
+	registered := make(map[string]bool)
+	for _, route := range s.engine.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "创建规则", method: "POST", path: "/api/v1/rules"},
+		{name: "规则列表", method: "GET", path: "/api/v1/rules"},
+		{name: "更新规则", method: "PUT", path: "/api/v1/rules/:id"},
+		{name: "删除规则", method: "DELETE", path: "/api/v1/rules/:id"},
+		{name: "启用规则", method: "POST", path: "/api/v1/rules/:id/enable"},
+		{name: "禁用规则", method: "POST", path: "/api/v1/rules/:id/disable"},
+		{name: "触发审核", method: "POST", path: "/api/v1/audit"},
+		{name: "审核状态", method: "GET", path: "/api/v1/audit/:id/status"},
+		{name: "审核结果", method: "GET", path: "/api/v1/audit/:id/result"},
+		{name: "重试审核", method: "POST", path: "/api/v1/audit/:id/retry"},
+		{name: "文档分片列表", method: "GET", path: "/api/v1/knowledge/chunks"},
+		{name: "知识库问答", method: "POST", path: "/api/v1/knowledge/query"},
+		{name: "超出SLA的审核", method: "GET", path: "/api/v1/audits/sla-breaches"},
+		{name: "规则覆盖度", method: "GET", path: "/api/v1/rules/coverage"},
+		{name: "审核列表", method: "GET", path: "/api/v1/audits"},
+		{name: "审核限额标准", method: "GET", path: "/api/v1/audit/:id/standards"},
+		{name: "审核签名证明", method: "GET", path: "/api/v1/audit/:id/attestation"},
+		{name: "校验审核证明", method: "POST", path: "/api/v1/audit/attestations/verify"},
+		{name: "适用规则预览", method: "GET", path: "/api/v1/reimbursement/:id/applicable-rules"},
+		{name: "人工改判", method: "POST", path: "/api/v1/audit/:id/override"},
+		{name: "向量导出", method: "GET", path: "/api/v1/knowledge/embeddings"},
+		{name: "销售方黑名单", method: "GET", path: "/api/v1/rules/seller-blacklist"},
+		{name: "列入黑名单", method: "POST", path: "/api/v1/rules/seller-blacklist"},
+		{name: "移出黑名单", method: "DELETE", path: "/api/v1/rules/seller-blacklist/:id"},
+		{name: "重审受影响报销单", method: "POST", path: "/api/v1/rules/:id/reaudit-affected"},
+		{name: "重审批次进度", method: "GET", path: "/api/v1/rules/reaudit-batches/:batch_id"},
+		{name: "测试规则定义", method: "POST", path: "/api/v1/rules/test"},
+		{name: "测试已有规则", method: "POST", path: "/api/v1/rules/:id/test"},
+		{name: "审核历史", method: "GET", path: "/api/v1/reimbursements/:id/audits"},
+		{name: "向量缓存统计", method: "GET", path: "/api/v1/knowledge/embedding-cache/stats"},
+		{name: "下载审核报告", method: "GET", path: "/api/v1/audit/:id/report"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !registered[tt.method+" "+tt.path] {
+				t.Errorf("路由未注册: %s %s", tt.method, tt.path)
+			}
+		})
+	}
+}
+
+func TestProtectedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := middleware.NewAuth(middleware.AuthConfig{Secret: "test-secret"})
+	s := &serverImpl{engine: gin.New()}
+	s.engine.Use(auth.Middleware())
+	// 处理器未注入服务，请求若越过角色校验到达处理器会panic
+	s.registerRuleRoutes(handler.NewRuleHandler(nil))
+	s.registerAuditRoutes(handler.NewAuditHandler(nil))
+	s.registerKnowledgeRoutes(handler.NewKnowledgeHandler(nil))
+
+	token, err := auth.IssueToken("u1", "张三", []string{"auditor"}, time.Hour)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+
+	routes := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "创建规则", method: http.MethodPost, path: "/api/v1/rules"},
+		{name: "更新规则", method: http.MethodPut, path: "/api/v1/rules/r1"},
+		{name: "删除规则", method: http.MethodDelete, path: "/api/v1/rules/r1"},
+		{name: "启用规则", method: http.MethodPost, path: "/api/v1/rules/r1/enable"},
+		{name: "禁用规则", method: http.MethodPost, path: "/api/v1/rules/r1/disable"},
+		{name: "列入黑名单", method: http.MethodPost, path: "/api/v1/rules/seller-blacklist"},
+		{name: "移出黑名单", method: http.MethodDelete, path: "/api/v1/rules/seller-blacklist/e1"},
+		{name: "重审受影响报销单", method: http.MethodPost, path: "/api/v1/rules/r1/reaudit-affected"},
+		{name: "向量导出", method: http.MethodGet, path: "/api/v1/knowledge/embeddings"},
+		{name: "人工改判", method: http.MethodPost, path: "/api/v1/audit/a1/override"},
+	}
+	for _, tt := range routes {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range []struct {
+				authorization string
+				wantStatus    int
+			}{
+				{authorization: "", wantStatus: http.StatusUnauthorized},
+				{authorization: "Bearer " + token, wantStatus: http.StatusForbidden},
+			} {
+				req := httptest.NewRequest(tt.method, tt.path, nil)
+				if c.authorization != "" {
+					req.Header.Set("Authorization", c.authorization)
+				}
+				w := httptest.NewRecorder()
+				s.engine.ServeHTTP(w, req)
+				if w.Code != c.wantStatus {
+					t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, c.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddingExportPaging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := loggertest.New(t)
+
+	const query = `SELECT "id","file_name","category","chunk_id","chunk_index","embedding" FROM "reimbursement_documents" WHERE category = \$1`
+	columns := []string{"id", "file_name", "category", "chunk_id", "chunk_index", "embedding"}
+
+	for _, role := range []string{middleware.RoleDataScientist, middleware.RoleAuditorAdmin} {
+		t.Run(role, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("创建sqlmock失败: %v", err)
+			}
+			defer db.Close()
+			gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{Logger: gormLogger.Discard})
+			if err != nil {
+				t.Fatalf("创建GORM实例失败: %v", err)
+			}
+			// 第一页取满2条，返回最后一条的ID作为游标；第二页从游标之后读取，不足一页时没有下一页
+			mock.ExpectQuery(query+` ORDER BY id ASC LIMIT \$2$`).
+				WithArgs("差旅费", 2).
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("v1", "doc1", "差旅费", "c1", 0, []byte("[0.1,0.2]")).
+					AddRow("v2", "doc1", "差旅费", "c2", 1, []byte("[0.3,0.4]")))
+			mock.ExpectQuery(query+` AND id > \$2 ORDER BY id ASC LIMIT \$3$`).
+				WithArgs("差旅费", "v2", 2).
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow("v3", "doc2", "差旅费", "c3", 0, []byte("[0.5,0.6]")))
+
+			ragService := rag.NewRAGService(log, nil, nil, rag.NewVectorStoreWithDB(gormDB, log), nil)
+			auth := middleware.NewAuth(middleware.AuthConfig{Secret: "test-secret"})
+			s := &serverImpl{engine: gin.New()}
+			s.engine.Use(auth.Middleware())
+			s.registerKnowledgeRoutes(handler.NewKnowledgeHandler(ragService))
+			token, err := auth.IssueToken("u1", "张三", []string{role}, time.Hour)
+			if err != nil {
+				t.Fatalf("签发令牌失败: %v", err)
+			}
+
+			var ids []string
+			cursor := ""
+			for page := 1; ; page++ {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/knowledge/embeddings?collection=差旅费&size=2&cursor="+cursor, nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				w := httptest.NewRecorder()
+				s.engine.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					t.Fatalf("第%d页 status = %d, body = %s", page, w.Code, w.Body.String())
+				}
+
+				var body struct {
+					Data struct {
+						Collection string                 `json:"collection"`
+						Embeddings []*rag.EmbeddingRecord `json:"embeddings"`
+						NextCursor string                 `json:"next_cursor"`
+					} `json:"data"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("第%d页响应不是合法JSON: %v, body = %s", page, err, w.Body.String())
+				}
+				for _, record := range body.Data.Embeddings {
+					if len(record.Embedding) != 2 {
+						t.Errorf("分片%s向量 = %v, want 2维", record.ID, record.Embedding)
+					}
+					ids = append(ids, record.ID)
+				}
+				if body.Data.NextCursor == "" {
+					break
+				}
+				if page > 2 {
+					t.Fatalf("分页未结束，游标 = %s", body.Data.NextCursor)
+				}
+				cursor = body.Data.NextCursor
+			}
+
+			if want := []string{"v1", "v2", "v3"}; !reflect.DeepEqual(ids, want) {
+				t.Errorf("导出的分片 = %q, want %q", ids, want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("SQL不符合预期: %v", err)
+			}
+		})
+	}
+}
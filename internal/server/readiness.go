@@ -0,0 +1,225 @@
+// readiness.go 服务就绪状态
+// 功能点：
+// 1. 记录各组件的就绪失败原因
+// 2. 启动自检失败时标记服务未就绪
+// 3. 为就绪检查接口提供状态查询
+// 4. 注册各依赖(数据库、Redis、大模型、向量库等)的健康检查，就绪检查时并发探测并汇总结果
+// 5. 单个依赖探测有超时，探测结果短时间缓存，避免探针频繁调用外部接口或被慢依赖挂住
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 依赖健康检查默认配置
+const (
+	DefaultReadinessCheckTimeout = 2 * time.Second // 单个依赖探测的超时时间
+	DefaultReadinessCacheTTL     = 5 * time.Second // 探测结果的缓存时间
+)
+
+// readinessStatusOK 组件可用时的状态
+const readinessStatusOK = "ok"
+
+// HealthCheckFunc 依赖健康检查函数，返回nil表示依赖可用
+type HealthCheckFunc func(ctx context.Context) error
+
+// ReadinessReport 就绪检查结果
+type ReadinessReport struct {
+	Ready    bool              `json:"ready"`    // 是否所有组件和依赖均可用
+	Checks   map[string]string `json:"checks"`   // 各依赖的探测结果，可用时为ok，否则为异常原因
+	Failures map[string]string `json:"failures"` // 不可用的组件和依赖及原因
+}
+
+// Readiness 服务就绪状态
+type Readiness struct {
+	mu       sync.RWMutex
+	failures map[string]string
+	checks   map[string]HealthCheckFunc
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	probeMu    sync.Mutex        // 同一时间只进行一轮探测，并发的就绪检查复用探测结果
+	probedAt   time.Time         // 最近一轮探测的完成时间
+	lastProbed map[string]string // 最近一轮探测结果
+}
+
+// NewReadiness 创建服务就绪状态
+func NewReadiness() *Readiness {
+	return &Readiness{
+		failures: make(map[string]string),
+		checks:   make(map[string]HealthCheckFunc),
+		timeout:  DefaultReadinessCheckTimeout,
+		cacheTTL: DefaultReadinessCacheTTL,
+	}
+}
+
+// SetComponentError 设置组件的就绪状态，err为nil表示组件就绪
+func (r *Readiness) SetComponentError(component string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		delete(r.failures, component)
+		return
+	}
+	r.failures[component] = err.Error()
+}
+
+// RegisterCheck 注册依赖的健康检查，同名依赖重复注册时替换
+func (r *Readiness) RegisterCheck(component string, check HealthCheckFunc) {
+	if check == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.checks[component] = check
+	r.mu.Unlock()
+	r.invalidate()
+}
+
+// SetCheckTimeout 设置单个依赖探测的超时时间，非正数时使用默认值
+func (r *Readiness) SetCheckTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultReadinessCheckTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout = timeout
+}
+
+// SetCacheTTL 设置探测结果的缓存时间，非正数时使用默认值
+func (r *Readiness) SetCacheTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultReadinessCacheTTL
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheTTL = ttl
+}
+
+// IsReady 是否所有组件均已就绪
+func (r *Readiness) IsReady() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.failures) == 0
+}
+
+// Failures 获取未就绪组件及原因
+func (r *Readiness) Failures() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	failures := make(map[string]string, len(r.failures))
+	for component, reason := range r.failures {
+		failures[component] = reason
+	}
+	return failures
+}
+
+// Probe 探测已注册的依赖并汇总组件就绪状态，缓存时间内直接使用上一轮探测结果
+func (r *Readiness) Probe(ctx context.Context) *ReadinessReport {
+	report := &ReadinessReport{
+		Checks:   r.probeChecks(ctx),
+		Failures: r.Failures(),
+	}
+	for component, status := range report.Checks {
+		if status != readinessStatusOK {
+			report.Failures[component] = status
+		}
+	}
+	report.Ready = len(report.Failures) == 0
+	return report
+}
+
+// probeChecks 并发执行依赖健康检查，返回各依赖的探测结果
+func (r *Readiness) probeChecks(ctx context.Context) map[string]string {
+	r.probeMu.Lock()
+	defer r.probeMu.Unlock()
+
+	r.mu.RLock()
+	checks := make(map[string]HealthCheckFunc, len(r.checks))
+	for component, check := range r.checks {
+		checks[component] = check
+	}
+	timeout := r.timeout
+	cacheTTL := r.cacheTTL
+	r.mu.RUnlock()
+
+	if r.lastProbed != nil && time.Since(r.probedAt) < cacheTTL {
+		return copyStatuses(r.lastProbed)
+	}
+
+	results := make(map[string]string, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for component, check := range checks {
+		wg.Add(1)
+		go func(component string, check HealthCheckFunc) {
+			defer wg.Done()
+			status := readinessStatusOK
+			if err := runHealthCheck(ctx, check, timeout); err != nil {
+				status = err.Error()
+			}
+			mu.Lock()
+			results[component] = status
+			mu.Unlock()
+		}(component, check)
+	}
+	wg.Wait()
+
+	// 就绪检查请求被取消时结果不完整，不缓存
+	if ctx.Err() == nil {
+		r.lastProbed = results
+		r.probedAt = time.Now()
+	}
+	return copyStatuses(results)
+}
+
+// invalidate 清除缓存的探测结果
+func (r *Readiness) invalidate() {
+	r.probeMu.Lock()
+	defer r.probeMu.Unlock()
+	r.lastProbed = nil
+}
+
+// runHealthCheck 在超时时间内执行健康检查，检查函数未响应上下文取消时也按超时返回
+func runHealthCheck(ctx context.Context, check HealthCheckFunc, timeout time.Duration) (err error) {
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("健康检查异常: %v", recovered)
+			}
+		}()
+		done <- check(checkCtx)
+	}()
+
+	select {
+	case err = <-done:
+		if err != nil && checkCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("健康检查超时(%s)", timeout)
+		}
+		return err
+	case <-checkCtx.Done():
+		if checkCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("健康检查超时(%s)", timeout)
+		}
+		return fmt.Errorf("健康检查已取消: %w", checkCtx.Err())
+	}
+}
+
+// copyStatuses 复制探测结果，避免调用方修改缓存
+func copyStatuses(statuses map[string]string) map[string]string {
+	result := make(map[string]string, len(statuses))
+	for component, status := range statuses {
+		result[component] = status
+	}
+	return result
+}
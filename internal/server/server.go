@@ -116,8 +116,9 @@ func NewServer(config *Config) Server {
 	engine.Use(gin.Recovery())
 
 	return &serverImpl{
-		config: config,
-		engine: engine,
+		config:    config,
+		engine:    engine,
+		readiness: NewReadiness(),
 	}
 }
 
@@ -155,12 +156,34 @@ func HealthCheck(c *gin.Context) {
 	})
 }
 
-// ReadyCheck 就绪检查
-func ReadyCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "ready",
-		"timestamp": time.Now().Unix(),
-	})
+// ReadyCheck 就绪检查，汇总各组件及依赖的健康状态，存在不可用的组件或依赖时返回503
+func ReadyCheck(readiness *Readiness) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if readiness == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"status":    "ready",
+				"timestamp": time.Now().Unix(),
+			})
+			return
+		}
+
+		report := readiness.Probe(c.Request.Context())
+		if !report.Ready {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":    "not_ready",
+				"failures":  report.Failures,
+				"checks":    report.Checks,
+				"timestamp": time.Now().Unix(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "ready",
+			"checks":    report.Checks,
+			"timestamp": time.Now().Unix(),
+		})
+	}
 }
 
 // VersionCheck 版本检查
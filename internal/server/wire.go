@@ -0,0 +1,596 @@
+// wire.go 服务依赖装配
+// 功能点：
+// 1. 从应用配置手动装配数据库、文件存储、OCR及应用服务等依赖
+// 2. 启动时真正连接数据库，连接失败或关键配置缺失时panic，避免服务带病运行
+// 3. 切换存储后端和数据库只需修改配置文件
+// 4. 按日志配置创建唯一的日志记录器，注入中间件和各组件共享
+// 5. 按配置创建RAG查询结果缓存(内存LRU/Redis)
+// 6. 按配置创建向量嵌入缓存(内存LRU/Redis)
+// 7. 按配置加载PDF审核报告内嵌的字体
+// 8. 按规则配置装配规则引擎、规则服务与发票校验器，启动时加载规则
+// 9. 启用RAG时装配大模型客户端、向量库、RAG服务及依赖RAG的审核服务
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"reimbursement-audit/internal/application/service"
+	"reimbursement-audit/internal/config"
+	"reimbursement-audit/internal/domain/audit"
+	"reimbursement-audit/internal/domain/ocr"
+	"reimbursement-audit/internal/domain/ocr/provider"
+	"reimbursement-audit/internal/domain/rag"
+	"reimbursement-audit/internal/domain/reimbursement"
+	"reimbursement-audit/internal/domain/rule"
+	storage "reimbursement-audit/internal/infra/storage/file"
+	mysqlRepo "reimbursement-audit/internal/infra/storage/mysql"
+	"reimbursement-audit/internal/pkg/logger"
+	"reimbursement-audit/internal/pkg/report"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultDBConnectTimeout 启动时连接数据库的超时时间
+	defaultDBConnectTimeout = 10 * time.Second
+	// defaultChunkSize 制度文档分片大小(字符)
+	defaultChunkSize = 500
+	// defaultChunkOverlap 相邻分片重叠的字符数
+	defaultChunkOverlap = 50
+)
+
+// dependencies 装配完成的服务依赖
+// 未启用RAG时llmClient、vectorStore、ragService、auditService、auditAppService为nil
+type dependencies struct {
+	mysqlClient             *mysqlRepo.Client
+	reimbursementAppService *service.ReimbursementApplicationService
+	ruleService             *rule.RuleService
+	llmClient               *rag.LLMClient
+	vectorStore             *rag.VectorStore
+	ragService              *rag.RAGService
+	auditService            *audit.Service
+	auditAppService         *service.AuditApplicationService
+}
+
+// buildDependencies 按应用配置装配服务依赖，任一依赖创建失败时panic
+func (s *serverImpl) buildDependencies(log logger.Logger) *dependencies {
+	if s.appConfig == nil {
+		panic("未设置应用配置，无法装配服务依赖")
+	}
+
+	mysqlClient := s.newMySQLClient(log)
+	fileService := s.newFileService()
+
+	reimbursementRepo := mysqlRepo.NewReimbursementRepository(mysqlClient, log)
+	ocrRepo := mysqlRepo.NewOCRRepository(mysqlClient, log)
+
+	reimbursementDomainService := reimbursement.NewDomainService(reimbursementRepo, log)
+	rateProvider := s.newExchangeRateProvider()
+	ocrDomainService := s.newOCRParserService(ocrRepo, rateProvider, log)
+
+	reimbursementAppService := service.NewReimbursementApplicationService(
+		reimbursementRepo,
+		reimbursementDomainService,
+		ocrDomainService,
+		ocrRepo,
+		fileService,
+		log,
+	)
+
+	// 创建OCR识别任务队列，上传发票后入队由worker并发识别
+	s.ocrTaskQueue = ocr.NewTaskQueue(ocrDomainService.ParseInvoiceImage, ocr.TaskQueueConfig{
+		Workers:   s.appConfig.OCR.Workers,
+		QueueSize: s.appConfig.OCR.QueueSize,
+		Timeout:   time.Duration(s.appConfig.OCR.TaskTimeout) * time.Second,
+	}, log)
+	s.ocrTaskQueue.Start(context.Background())
+	reimbursementAppService.SetOCRTaskQueue(s.ocrTaskQueue)
+
+	// 创建识别失败自动重试器，"解析失败"的发票退避后重新进入识别任务队列
+	s.ocrRetrier = ocr.NewAutoRetrier(ocrDomainService, s.ocrTaskQueue, ocr.RetryConfig{
+		Enabled:  s.appConfig.OCR.AutoRetry,
+		Interval: time.Duration(s.appConfig.OCR.RetryInterval) * time.Second,
+		Backoff:  time.Duration(s.appConfig.OCR.RetryBackoff) * time.Second,
+	}, log)
+	s.ocrRetrier.Start(context.Background())
+
+	// 规则引擎、规则服务与发票校验器，三者共享同一规则引擎，规则服务与发票校验器共享销售方黑名单
+	ruleRepo := mysqlRepo.NewRuleRepository(mysqlClient, log)
+	ruleEngine := rule.NewGRuleEngine(ruleRepo, log)
+	ruleEngine.SetMaxCycle(s.appConfig.Rule.MaxCycle)
+	ruleEngine.SetDefaultTimeout(time.Duration(s.appConfig.Rule.ExecutionTimeout) * time.Millisecond)
+	ruleService := rule.NewRuleService(ruleRepo, log, ruleEngine)
+	sellerBlacklistRepo := mysqlRepo.NewSellerBlacklistRepository(mysqlClient, log)
+	sellerBlacklist := rule.NewSellerBlacklist(sellerBlacklistRepo)
+	ruleService.SetSellerBlacklist(sellerBlacklistRepo, sellerBlacklist)
+	invoiceValidator := s.newInvoiceValidator(ruleEngine, ruleRepo, ocrRepo, log)
+	invoiceValidator.SetReimbursementRepository(reimbursementRepo)
+	invoiceValidator.SetSellerBlacklist(sellerBlacklist)
+	if rateProvider != nil {
+		invoiceValidator.SetExchangeRateProvider(rateProvider)
+	}
+	invoiceValidator.SetHolidayProvider(s.newHolidayProvider(mysqlClient, log))
+
+	loadCtx, cancel := context.WithTimeout(context.Background(), defaultDBConnectTimeout)
+	defer cancel()
+	if err := ruleService.LoadRules(loadCtx); err != nil {
+		panic(fmt.Sprintf("加载审核规则失败: %v", err))
+	}
+
+	deps := &dependencies{
+		mysqlClient:             mysqlClient,
+		reimbursementAppService: reimbursementAppService,
+		ruleService:             ruleService,
+	}
+
+	// 审核依赖RAG分析，未启用RAG时不装配审核服务，也不提供审核和知识库接口
+	if !s.appConfig.RAG.Enabled {
+		log.Warn("RAG未启用，审核与知识库接口不可用")
+		return deps
+	}
+
+	deps.llmClient = s.newLLMClient(log)
+	deps.vectorStore = s.newVectorStore(log)
+	promptBuilder := s.newPromptBuilder(mysqlRepo.NewPromptTemplateRepository(mysqlClient, log), log)
+	deps.ragService = s.newRAGService(deps.llmClient, deps.vectorStore, promptBuilder, log)
+
+	deps.auditService = s.newAuditService(mysqlRepo.NewAuditRepository(mysqlClient, log), reimbursementRepo, ruleService, deps.ragService, log)
+	deps.auditService.SetInvoiceValidator(invoiceValidator)
+	deps.auditAppService = service.NewAuditApplicationService(deps.auditService, log)
+
+	// 创建失败审核自动重试器，限流、超时等临时性失败的审核退避后重新审核
+	s.auditRetrier = audit.NewAutoRetrier(deps.auditService, newAutoRetryConfig(s.appConfig.Audit.AutoRetry), log)
+	s.auditRetrier.Start(context.Background())
+	return deps
+}
+
+// newLogger 按日志配置创建日志记录器，未设置应用配置时使用默认配置，配置不合法时panic
+func (s *serverImpl) newLogger() logger.Logger {
+	logConfig := logger.DefaultConfig()
+	if s.appConfig != nil {
+		var err error
+		if logConfig, err = newLoggerConfig(s.appConfig.Logger); err != nil {
+			panic(fmt.Sprintf("日志配置不合法: %v", err))
+		}
+	}
+
+	log, err := logger.NewLogger(logConfig)
+	if err != nil {
+		panic(fmt.Sprintf("创建日志记录器失败: %v", err))
+	}
+	return log
+}
+
+// newLoggerConfig 根据日志配置构建日志记录器配置，未配置的项使用默认值
+// 输出到文件时按max_size滚动，保留max_backups个旧文件、最长max_age天
+func newLoggerConfig(cfg config.LoggerConfig) (*logger.Config, error) {
+	level, err := logger.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	logConfig := logger.DefaultConfig()
+	logConfig.Level = level
+	if cfg.Format != "" {
+		logConfig.Format = strings.ToLower(cfg.Format)
+	}
+	if cfg.Output != "" {
+		logConfig.Output = strings.ToLower(cfg.Output)
+	}
+	logConfig.Filename = cfg.Filename
+	if cfg.MaxSize > 0 {
+		logConfig.MaxSize = cfg.MaxSize
+	}
+	if cfg.MaxBackups > 0 {
+		logConfig.MaxBackups = cfg.MaxBackups
+	}
+	if cfg.MaxAge > 0 {
+		logConfig.MaxAge = cfg.MaxAge
+	}
+	logConfig.Compress = cfg.Compress
+	return logConfig, logConfig.Validate()
+}
+
+// newQueryCache 按配置创建RAG查询结果缓存，未启用时返回nil，缓存后端不支持时panic
+func (s *serverImpl) newQueryCache(log logger.Logger) rag.QueryCache {
+	cfg := s.appConfig.RAG
+	if !cfg.QueryCacheEnabled {
+		return nil
+	}
+
+	ttl := time.Duration(cfg.QueryCacheTTL) * time.Second
+	switch strings.ToLower(cfg.QueryCacheBackend) {
+	case "", "memory":
+		return rag.NewMemoryQueryCache(ttl, cfg.QueryCacheSize)
+	case "redis":
+		return rag.NewRedisQueryCache(s.newRedisClient(), ttl, log)
+	default:
+		panic(fmt.Sprintf("不支持的查询缓存后端: %s", cfg.QueryCacheBackend))
+	}
+}
+
+// newEmbeddingCache 按配置创建向量嵌入缓存，未启用时返回nil，缓存后端不支持时panic
+func (s *serverImpl) newEmbeddingCache(log logger.Logger) rag.EmbeddingCache {
+	cfg := s.appConfig.RAG
+	if !cfg.EmbeddingCacheEnabled {
+		return nil
+	}
+
+	switch strings.ToLower(cfg.EmbeddingCacheBackend) {
+	case "", "memory":
+		return rag.NewMemoryEmbeddingCache(cfg.EmbeddingCacheSize)
+	case "redis":
+		ttl := time.Duration(cfg.EmbeddingCacheTTL) * time.Second
+		return rag.NewRedisEmbeddingCache(s.newRedisClient(), ttl, log)
+	default:
+		panic(fmt.Sprintf("不支持的向量缓存后端: %s", cfg.EmbeddingCacheBackend))
+	}
+}
+
+// newReportFont 按配置加载PDF审核报告字体，未配置时返回nil，字体加载失败时panic
+func (s *serverImpl) newReportFont() *report.Font {
+	path := strings.TrimSpace(s.appConfig.Audit.ReportFontPath)
+	if path == "" {
+		return nil
+	}
+
+	font, err := report.LoadFont(path)
+	if err != nil {
+		panic(fmt.Sprintf("加载审核报告字体失败: %v", err))
+	}
+	return font
+}
+
+// newRedisClient 按redis配置创建Redis客户端
+func (s *serverImpl) newRedisClient() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", s.appConfig.Redis.Host, s.appConfig.Redis.Port),
+		Password: s.appConfig.Redis.Password,
+		DB:       s.appConfig.Redis.DB,
+	})
+}
+
+// newMySQLClient 创建MySQL客户端并连接数据库，连接失败时panic
+func (s *serverImpl) newMySQLClient(log logger.Logger) *mysqlRepo.Client {
+	client := mysqlRepo.NewClient(log)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDBConnectTimeout)
+	defer cancel()
+	if err := client.Connect(ctx, newMySQLConfig(s.appConfig.Database)); err != nil {
+		panic(fmt.Sprintf("连接数据库失败: %v", err))
+	}
+	return client
+}
+
+// newMySQLConfig 根据数据库配置构建MySQL连接配置，未配置的项使用默认值
+func newMySQLConfig(cfg config.DatabaseConfig) *mysqlRepo.Config {
+	return mysqlRepo.DefaultConfig().Merge(&mysqlRepo.Config{
+		Host:            cfg.Host,
+		Port:            cfg.Port,
+		Username:        cfg.Username,
+		Password:        cfg.Password,
+		DBName:          cfg.DBName,
+		Charset:         cfg.Charset,
+		Collation:       cfg.Collation,
+		Loc:             cfg.Loc,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	})
+}
+
+// newFileService 按配置的存储类型创建文件服务，存储创建失败时panic
+func (s *serverImpl) newFileService() *storage.Service {
+	cfg := s.appConfig.Storage
+	fileStorage, err := storage.NewStorage(storage.Options{
+		Type:      cfg.Type,
+		LocalPath: cfg.Local.Path,
+		BaseURL:   cfg.Local.BaseURL,
+		MinIO: storage.MinIOOptions{
+			Endpoint:  cfg.MinIO.Endpoint,
+			AccessKey: cfg.MinIO.AccessKey,
+			SecretKey: cfg.MinIO.SecretKey,
+			Bucket:    cfg.MinIO.Bucket,
+			Region:    cfg.MinIO.Region,
+			UseSSL:    cfg.MinIO.UseSSL,
+			PublicURL: cfg.MinIO.PublicURL,
+			URLExpiry: time.Duration(cfg.MinIO.URLExpiry) * time.Second,
+		},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("创建文件存储失败: %v", err))
+	}
+
+	fileService := storage.NewService(fileStorage)
+	fileService.SetMaxFileSize(int64(cfg.MaxFileSize) * 1024 * 1024)
+	fileService.SetAllowedFileTypes(cfg.AllowedTypes)
+	return fileService
+}
+
+// newOCRParserService 按配置创建OCR解析服务，密钥未配置或提供商不支持时panic
+func (s *serverImpl) newOCRParserService(ocrRepo ocr.Repository, rateProvider ocr.ExchangeRateProvider, log logger.Logger) *ocr.ParserService {
+	cfg := s.appConfig.OCR
+	if strings.TrimSpace(cfg.SecretID) == "" || strings.TrimSpace(cfg.SecretKey) == "" {
+		panic("OCR密钥未配置，请设置ocr.secret_id/ocr.secret_key或环境变量OCR_SECRET_ID/OCR_SECRET_KEY")
+	}
+
+	// 发票代码/号码格式规则，未配置时使用内置规则
+	if len(cfg.InvoiceFormats) > 0 {
+		rules := make([]ocr.InvoiceFormatRule, 0, len(cfg.InvoiceFormats))
+		for _, format := range cfg.InvoiceFormats {
+			rules = append(rules, ocr.InvoiceFormatRule(format))
+		}
+		ocr.SetInvoiceFormatRules(rules)
+	}
+
+	// 按配置的提供商创建OCR解析器
+	ocrProvider, err := provider.NewProvider(ocr.Config{
+		Provider:        cfg.Provider,
+		Endpoint:        cfg.Endpoint,
+		SecretID:        cfg.SecretID,
+		SecretKey:       cfg.SecretKey,
+		Region:          cfg.Region,
+		Timeout:         cfg.Timeout,
+		MaxRetries:      cfg.MaxRetries,
+		UseImageURL:     cfg.UseImageURL,
+		DownloadTimeout: cfg.DownloadTimeout,
+		MaxImageSize:    cfg.MaxImageSize,
+	}, log)
+	if err != nil {
+		panic(fmt.Sprintf("创建OCR提供商失败: %v", err))
+	}
+
+	parserService := ocr.NewParserService(ocrProvider, ocrRepo, log)
+	parserService.SetMaxRetries(cfg.MaxRetries)
+	parserService.SetPartialRecognitionPolicy(ocr.PartialRecognitionPolicy{
+		Enabled:        cfg.PartialRecognition,
+		CriticalFields: cfg.CriticalFields,
+	})
+
+	if rateProvider != nil {
+		parserService.SetExchangeRateProvider(rateProvider)
+	}
+	return parserService
+}
+
+// newExchangeRateProvider 按配置的汇率历史表创建汇率数据源，未配置时返回nil(外币发票无法折算)，配置错误时panic
+func (s *serverImpl) newExchangeRateProvider() ocr.ExchangeRateProvider {
+	cfg := s.appConfig.OCR
+	if len(cfg.ExchangeRates) == 0 {
+		return nil
+	}
+
+	rates := make([]ocr.ExchangeRate, 0, len(cfg.ExchangeRates))
+	for _, item := range cfg.ExchangeRates {
+		rate, err := ocr.ParseExchangeRate(item.Currency, item.Rate, item.EffectiveDate)
+		if err != nil {
+			panic(fmt.Sprintf("汇率配置错误: %v", err))
+		}
+		rates = append(rates, rate)
+	}
+	rateProvider, err := ocr.NewStaticExchangeRateProvider(rates...)
+	if err != nil {
+		panic(fmt.Sprintf("汇率配置错误: %v", err))
+	}
+	return rateProvider
+}
+
+// newInvoiceValidator 按规则配置创建发票校验器，限额标准配置错误时panic
+func (s *serverImpl) newInvoiceValidator(ruleEngine *rule.GRuleEngine, ruleRepo rule.Repository, ocrRepo ocr.Repository, log logger.Logger) *rule.InvoiceValidatorImpl {
+	cfg := s.appConfig.Rule
+
+	validator := rule.NewInvoiceValidator(ruleEngine, ruleRepo, ocrRepo, log)
+	validator.SetAmountTolerance(cfg.AmountTolerance)
+	validator.SetInvoiceMaxAge(cfg.InvoiceMaxAge)
+	validator.SetOCRConfidenceThreshold(cfg.OCRConfidenceThreshold)
+
+	standards := make([]*rule.LimitStandard, 0, len(cfg.LimitStandards))
+	for _, item := range cfg.LimitStandards {
+		standard, err := rule.ParseLimitStandard(item.Category, item.City, item.Tier, item.Limit, item.EffectiveFrom)
+		if err != nil {
+			panic(fmt.Sprintf("限额标准配置错误: %v", err))
+		}
+		standards = append(standards, standard)
+	}
+	validator.SetLimitStandards(standards)
+	return validator
+}
+
+// newHolidayProvider 按配置的节假日数据源创建节假日数据源，数据源不支持或节假日配置错误时panic
+func (s *serverImpl) newHolidayProvider(mysqlClient *mysqlRepo.Client, log logger.Logger) rule.HolidayProvider {
+	cfg := s.appConfig.Rule
+	switch strings.ToLower(strings.TrimSpace(cfg.HolidaySource)) {
+	case "", "builtin":
+		return rule.DefaultHolidayProvider()
+	case "config":
+		calendars := make([]rule.HolidayCalendar, 0, len(cfg.Holidays))
+		for _, item := range cfg.Holidays {
+			calendars = append(calendars, rule.HolidayCalendar(item))
+		}
+		provider, err := rule.NewStaticHolidayProvider(calendars...)
+		if err != nil {
+			panic(fmt.Sprintf("节假日配置错误: %v", err))
+		}
+		return provider
+	case "database":
+		return rule.NewDatabaseHolidayProvider(mysqlRepo.NewHolidayRepository(mysqlClient, log))
+	default:
+		panic(fmt.Sprintf("不支持的节假日数据源: %s", cfg.HolidaySource))
+	}
+}
+
+// newLLMClient 按大模型配置创建大模型客户端，未启用大模型时panic
+func (s *serverImpl) newLLMClient(log logger.Logger) *rag.LLMClient {
+	cfg := s.appConfig.LLM
+	if !cfg.Enabled {
+		panic("启用RAG时必须启用大模型(llm.enabled)")
+	}
+
+	llmClient := rag.NewLLMClient(cfg.APIKey, cfg.BaseURL, cfg.Model, cfg.Timeout, log)
+	llmClient.SetEmbeddingConcurrency(s.appConfig.RAG.EmbeddingConcurrency)
+	llmClient.SetEmbeddingBatchSize(s.appConfig.RAG.EmbeddingBatchSize)
+	llmClient.SetContextWindow(s.appConfig.RAG.ContextWindow)
+	llmClient.SetEmbeddingModel(s.appConfig.RAG.EmbeddingModel)
+	llmClient.SetEmbeddingCache(s.newEmbeddingCache(log))
+	return llmClient
+}
+
+// newVectorStore 连接pgvector向量库并按配置的索引参数迁移表结构，连接串未配置或迁移失败时panic
+func (s *serverImpl) newVectorStore(log logger.Logger) *rag.VectorStore {
+	cfg := s.appConfig.RAG
+	if strings.TrimSpace(cfg.VectorDSN) == "" {
+		panic("启用RAG时必须配置向量库连接串(rag.vector_dsn)")
+	}
+
+	db, err := gorm.Open(postgres.Open(cfg.VectorDSN), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("连接向量库失败: %v", err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDBConnectTimeout)
+	defer cancel()
+	if err := rag.MigrateVectorSchema(ctx, db, s.vectorSchemaOptions()); err != nil {
+		panic(fmt.Sprintf("迁移向量库表结构失败: %v", err))
+	}
+
+	vectorStore := rag.NewVectorStoreWithDB(db, log)
+	vectorStore.SetMinKeywordDensity(cfg.MinKeywordDensity)
+	return vectorStore
+}
+
+// vectorSchemaOptions 按配置生成向量索引参数，迁移和自动重建共用
+func (s *serverImpl) vectorSchemaOptions() rag.VectorSchemaOptions {
+	cfg := s.appConfig.RAG
+	return rag.VectorSchemaOptions{
+		IndexName:      cfg.VectorIndexName,
+		IndexMethod:    cfg.VectorIndexMethod,
+		Lists:          cfg.VectorIndexLists,
+		M:              cfg.VectorIndexM,
+		EfConstruction: cfg.VectorIndexEfConstruction,
+	}
+}
+
+// newPromptBuilder 按配置创建Prompt构建器并加载数据库中的模板，防护规则不合法或模板加载失败时panic
+func (s *serverImpl) newPromptBuilder(templateRepo rag.PromptTemplateRepository, log logger.Logger) *rag.PromptBuilder {
+	cfg := s.appConfig.RAG
+
+	promptBuilder := rag.NewPromptBuilder(log)
+	promptBuilder.SetMaxPromptInvoices(cfg.AuditPromptMaxInvoices)
+	if err := promptBuilder.SetPromptGuard(cfg.PromptGuardEnabled, cfg.PromptGuardPatterns); err != nil {
+		panic(fmt.Sprintf("提示词注入防护配置错误: %v", err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDBConnectTimeout)
+	defer cancel()
+	if err := promptBuilder.LoadTemplatesFromDB(ctx, templateRepo); err != nil {
+		panic(fmt.Sprintf("加载Prompt模板失败: %v", err))
+	}
+	return promptBuilder
+}
+
+// newRAGService 按配置创建RAG服务
+func (s *serverImpl) newRAGService(llmClient *rag.LLMClient, vectorStore *rag.VectorStore, promptBuilder *rag.PromptBuilder, log logger.Logger) *rag.RAGService {
+	cfg := s.appConfig.RAG
+
+	documentProcessor := rag.NewDocumentProcessor(defaultChunkSize, defaultChunkOverlap, log)
+	ragService := rag.NewRAGService(log, llmClient, documentProcessor, vectorStore, promptBuilder)
+	ragService.SetIngestConcurrency(cfg.IngestConcurrency)
+	ragService.SetLanguageBoost(cfg.LanguageBoost)
+	ragService.SetEmbeddingFieldPolicy(rag.EmbeddingFieldPolicy{
+		AllowedFields:  cfg.EmbeddingFields,
+		RedactedFields: cfg.EmbeddingRedactedFields,
+	})
+	// 自动重建沿用迁移时的索引类型和参数，避免把HNSW索引重建为IVFFlat
+	ragService.SetVectorIndexPolicy(rag.VectorIndexPolicy{
+		VectorSchemaOptions: s.vectorSchemaOptions(),
+		RebuildThreshold:    cfg.VectorIndexRebuildThreshold,
+	})
+	ragService.SetCategoryClassifier(rag.NewKeywordCategoryClassifier(cfg.CategoryKeywords))
+	ragService.SetQueryCache(s.newQueryCache(log))
+	return ragService
+}
+
+// newAuditService 按审核配置创建审核服务，结论措辞模板或审核证明密钥不合法时panic
+func (s *serverImpl) newAuditService(auditRepo audit.Repository, reimbursementRepo reimbursement.Repository, ruleService *rule.RuleService, ragService *rag.RAGService, log logger.Logger) *audit.Service {
+	cfg := s.appConfig.Audit
+
+	auditService := audit.NewService(auditRepo, reimbursementRepo, ruleService, ragService, log)
+	// 同一报销单重新审核结论未变化时，去重时间窗口内不重复通知
+	auditService.SetNotifier(audit.NewDedupNotifier(audit.NewLogNotifier(log), time.Duration(cfg.NotifyDedupWindow)*time.Second, log))
+	auditService.SetRiskScoreOptions(audit.RiskScoreOptions{
+		Mode:       audit.RiskScoreMode(strings.ToLower(cfg.RiskScoreMode)),
+		IncludeRAG: cfg.RiskScoreIncludeRAG,
+		RAGWeight:  cfg.RiskScoreRAGWeight,
+	})
+	auditService.SetRiskConfig(newRiskConfig(cfg.Risk))
+	auditService.SetMaxRetries(cfg.MaxRetries)
+	auditService.SetSLA(time.Duration(cfg.SLAMinutes) * time.Minute)
+	auditService.SetConcurrencyMode(cfg.ConcurrencyMode)
+	auditService.SetRuleCoveragePolicy(audit.RuleCoveragePolicy{
+		AllowEmpty: cfg.AllowEmptyRules,
+		Categories: cfg.RuleRequiredTypes,
+	})
+	auditService.SetRAGTopK(s.appConfig.RAG.AuditTopK, s.appConfig.RAG.CategoryTopK)
+
+	businessUnits := make(map[string]audit.VerdictTemplates, len(cfg.VerdictTemplates.BusinessUnits))
+	for unit, phrasing := range cfg.VerdictTemplates.BusinessUnits {
+		businessUnits[unit] = audit.VerdictTemplates(phrasing)
+	}
+	renderer, err := audit.NewVerdictRenderer(audit.VerdictTemplates(cfg.VerdictTemplates.Default), businessUnits)
+	if err != nil {
+		panic(fmt.Sprintf("审核结论措辞模板配置错误: %v", err))
+	}
+	auditService.SetVerdictRenderer(renderer)
+
+	// 未配置签名密钥时不提供审核证明
+	if secret := s.appConfig.Security.AttestationSecret; secret != "" {
+		signer, err := audit.NewAttestationSigner(secret)
+		if err != nil {
+			panic(fmt.Sprintf("审核证明签名密钥配置错误: %v", err))
+		}
+		auditService.SetAttestationSigner(signer)
+	}
+	auditService.SetReportFont(s.newReportFont())
+	return auditService
+}
+
+// newAutoRetryConfig 根据失败审核自动重试配置构建重试配置，配置中的时长单位为秒
+func newAutoRetryConfig(cfg config.AutoRetryConfig) audit.AutoRetryConfig {
+	retryConfig := audit.DefaultAutoRetryConfig()
+	retryConfig.Enabled = cfg.Enabled
+	retryConfig.MaxAttempts = cfg.MaxAttempts
+	retryConfig.InitialBackoff = time.Duration(cfg.InitialBackoff) * time.Second
+	retryConfig.MaxBackoff = time.Duration(cfg.MaxBackoff) * time.Second
+	retryConfig.Interval = time.Duration(cfg.Interval) * time.Second
+	retryConfig.Lookback = time.Duration(cfg.Lookback) * time.Second
+	return retryConfig
+}
+
+// newRiskConfig 根据风险权重配置构建风险权重，未配置的项使用默认值
+func newRiskConfig(cfg config.RiskConfig) audit.RiskConfig {
+	riskConfig := audit.DefaultRiskConfig()
+	if len(cfg.SeverityWeights) > 0 {
+		riskConfig.SeverityWeights = cfg.SeverityWeights
+	}
+	if cfg.DefaultSeverityWeight != nil {
+		riskConfig.DefaultSeverityWeight = *cfg.DefaultSeverityWeight
+	}
+	if cfg.RAGFailWeight != nil {
+		riskConfig.RAGFailWeight = *cfg.RAGFailWeight
+	}
+	if cfg.RAGConfidenceWeight != nil {
+		riskConfig.RAGConfidenceWeight = *cfg.RAGConfidenceWeight
+	}
+	riskConfig.HighThreshold = cfg.HighThreshold
+	riskConfig.MediumThreshold = cfg.MediumThreshold
+	return riskConfig
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"reimbursement-audit/internal/config"
+	"reimbursement-audit/internal/domain/audit"
+)
+
+func TestNewRiskConfig(t *testing.T) {
+	zero, half := 0.0, 0.5
+	defaults := audit.DefaultRiskConfig()
+
+	tests := []struct {
+		name string
+		cfg  config.RiskConfig
+		want audit.RiskConfig
+	}{
+		{
+			name: "未配置权重使用默认值",
+			cfg:  config.RiskConfig{HighThreshold: 0.7, MediumThreshold: 0.4},
+			want: defaults,
+		},
+		{
+			name: "配置为0的权重保留",
+			cfg: config.RiskConfig{
+				DefaultSeverityWeight: &zero, RAGFailWeight: &zero, RAGConfidenceWeight: &half,
+				HighThreshold: 0.8, MediumThreshold: 0.3,
+			},
+			want: audit.RiskConfig{
+				SeverityWeights:       defaults.SeverityWeights,
+				DefaultSeverityWeight: 0, RAGFailWeight: 0, RAGConfidenceWeight: 0.5,
+				HighThreshold: 0.8, MediumThreshold: 0.3,
+			},
+		},
+		{
+			name: "配置严重程度权重",
+			cfg: config.RiskConfig{
+				SeverityWeights: map[string]float64{audit.SeverityHigh: 1},
+				HighThreshold:   0.7, MediumThreshold: 0.4,
+			},
+			want: audit.RiskConfig{
+				SeverityWeights:       map[string]float64{audit.SeverityHigh: 1},
+				DefaultSeverityWeight: defaults.DefaultSeverityWeight,
+				RAGFailWeight:         defaults.RAGFailWeight,
+				RAGConfidenceWeight:   defaults.RAGConfidenceWeight,
+				HighThreshold:         0.7, MediumThreshold: 0.4,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newRiskConfig(tt.cfg)
+			if got.DefaultSeverityWeight != tt.want.DefaultSeverityWeight ||
+				got.RAGFailWeight != tt.want.RAGFailWeight ||
+				got.RAGConfidenceWeight != tt.want.RAGConfidenceWeight ||
+				got.HighThreshold != tt.want.HighThreshold ||
+				got.MediumThreshold != tt.want.MediumThreshold {
+				t.Fatalf("newRiskConfig() = %+v, want %+v", got, tt.want)
+			}
+			if len(got.SeverityWeights) != len(tt.want.SeverityWeights) {
+				t.Fatalf("SeverityWeights = %v, want %v", got.SeverityWeights, tt.want.SeverityWeights)
+			}
+			for severity, weight := range tt.want.SeverityWeights {
+				if got.SeverityWeights[severity] != weight {
+					t.Fatalf("SeverityWeights[%s] = %v, want %v", severity, got.SeverityWeights[severity], weight)
+				}
+			}
+		})
+	}
+}
+
+func TestNewAutoRetryConfig(t *testing.T) {
+	defaults := audit.DefaultAutoRetryConfig()
+
+	tests := []struct {
+		name string
+		cfg  config.AutoRetryConfig
+		want audit.AutoRetryConfig
+	}{
+		{
+			name: "时长按秒换算",
+			cfg: config.AutoRetryConfig{
+				Enabled: true, MaxAttempts: 3, InitialBackoff: 30, MaxBackoff: 600, Interval: 30, Lookback: 86400,
+			},
+			want: audit.AutoRetryConfig{
+				Enabled: true, MaxAttempts: 3, InitialBackoff: 30 * time.Second, MaxBackoff: 10 * time.Minute,
+				Interval: 30 * time.Second, BatchSize: defaults.BatchSize, Lookback: 24 * time.Hour,
+			},
+		},
+		{
+			name: "未配置的时长为0，创建重试器时回退默认值",
+			cfg:  config.AutoRetryConfig{MaxAttempts: 5, Interval: 60},
+			want: audit.AutoRetryConfig{
+				MaxAttempts: 5, Interval: time.Minute, BatchSize: defaults.BatchSize,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newAutoRetryConfig(tt.cfg); got != tt.want {
+				t.Fatalf("newAutoRetryConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
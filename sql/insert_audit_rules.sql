@@ -252,7 +252,7 @@ INSERT INTO audit_rules (
 
 -- 发票基本规则
 
--- 8. 发票时效性规则 - 普通发票
+-- 8. 发票时效性规则（按报销申请日期计算，最长天数由rule.invoice_max_age配置）
 INSERT INTO audit_rules (
     id, 
     rule_code, 
@@ -268,20 +268,20 @@ INSERT INTO audit_rules (
 ) VALUES (
     UUID(),
     'RULE_INVOICE_TIMELINESS_COMMON',
-    '普通发票6个月内有效',
-    'rule invoice_timeliness_common "普通发票时效性检查" salience 20 {
+    '发票须在报销时限内提交',
+    'rule invoice_timeliness_common "发票时效性检查" salience 20 {
     when
-        data.Invoice.IsVAT == false && daysBetween(data.Invoice.Date, data.Reimbursement.ApplyDate) > 180
+        data.InvoiceTimeliness.Valid == false
     then
         result.Passed = false;
-        result.Message = "普通发票开具日期超过6个月，无法报销";
+        result.Message = data.InvoiceTimeliness.Message;
         result.Severity = "high";
-        ret.AddViolation("普通发票开具日期超过6个月，无法报销", "high", 20);
+        ret.AddViolation(data.InvoiceTimeliness.Message, "high", 20);
     }',
     20,
     '发票校验',
     'enabled',
-    '普通发票必须在开具日期后6个月内提交报销，超过期限无法报销',
+    '开票日期距报销申请日期不得超过规定天数（默认180天），开票日期缺失或晚于申请日期时分别报出违规',
     'system',
     NOW(),
     NOW()
@@ -306,7 +306,7 @@ INSERT INTO audit_rules (
     '增值税专用发票180天内有效',
     'rule invoice_timeliness_vat "增值税专用发票时效性检查" salience 20 {
     when
-        data.Invoice.IsVAT == true && daysBetween(data.Invoice.Date, data.Reimbursement.ApplyDate) > 180
+        data.Invoice.IsVAT == true && data.InvoiceTimeliness.Days > 180
     then
         result.Passed = false;
         result.Message = "增值税专用发票开具日期超过180天，无法认证抵扣";
@@ -711,4 +711,106 @@ INSERT INTO audit_rules (
     'system',
     NOW(),
     NOW()
-);
\ No newline at end of file
+);
+-- 21. 报销单总额与发票合计一致性规则
+INSERT INTO audit_rules (
+    id, 
+    rule_code, 
+    rule_name, 
+    rule_content, 
+    priority, 
+    category, 
+    status, 
+    description,
+    created_by,
+    created_at,
+    updated_at
+) VALUES (
+    UUID(),
+    'RULE_INVOICE_AMOUNT_RECONCILIATION',
+    '报销单总额与发票合计一致',
+    'rule invoice_amount_reconciliation "报销单总额与发票合计一致性检查" salience 30 {
+    when
+        isAmountMismatch()
+    then
+        result.Passed = false;
+        result.Message = "报销单总额与发票价税合计之和不一致";
+        result.Severity = "high";
+        ret.AddViolation("报销单总额与发票价税合计之和不一致", "high", 30);
+    }',
+    30,
+    '发票校验',
+    'enabled',
+    '报销单总额应等于其下全部发票价税合计之和（外币先按汇率折算），允许0.01元误差',
+    'system',
+    NOW(),
+    NOW()
+);
+-- 22. 购销双方为同一主体规则
+INSERT INTO audit_rules (
+    id, 
+    rule_code, 
+    rule_name, 
+    rule_content, 
+    priority, 
+    category, 
+    status, 
+    description,
+    created_by,
+    created_at,
+    updated_at
+) VALUES (
+    UUID(),
+    'RULE_INVOICE_SELF_DEALING',
+    '购销双方为同一主体',
+    'rule invoice_self_dealing "购买方与销售方为同一主体检查" salience 30 {
+    when
+        isSelfDealing(data.Invoice.BuyerTaxNo, data.Invoice.SellerTaxNo)
+    then
+        result.Passed = false;
+        result.Message = "发票购买方与销售方税号相同，疑似自开自报";
+        result.Severity = "high";
+        ret.AddViolation("发票购买方与销售方税号相同，疑似自开自报", "high", 30);
+    }',
+    30,
+    '发票校验',
+    'enabled',
+    '购买方税号与销售方税号规范化后相同（同一主体给自己开票）的发票不予报销',
+    'system',
+    NOW(),
+    NOW()
+);
+-- 23. 销售方黑名单规则
+INSERT INTO audit_rules (
+    id, 
+    rule_code, 
+    rule_name, 
+    rule_content, 
+    priority, 
+    category, 
+    status, 
+    description,
+    created_by,
+    created_at,
+    updated_at
+) VALUES (
+    UUID(),
+    'RULE_INVOICE_SELLER_BLACKLIST',
+    '销售方黑名单',
+    'rule invoice_seller_blacklist "销售方黑名单检查" salience 30 {
+    when
+        isBlacklistedSeller(data.Invoice.SellerName, data.Invoice.SellerTaxNo)
+    then
+        result.Passed = false;
+        result.Message = "发票销售方在黑名单中，属于已知不合规销售方";
+        result.Severity = "high";
+        ret.AddViolation("发票销售方在黑名单中，属于已知不合规销售方", "high", 30);
+    }',
+    30,
+    '发票校验',
+    'enabled',
+    '销售方税号或名称命中销售方黑名单的发票按高风险处理，需人工核实',
+    'system',
+    NOW(),
+    NOW()
+);